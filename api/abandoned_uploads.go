@@ -0,0 +1,27 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/ONSdigital/dp-files-api/files"
+)
+
+type GetAbandonedUploads func(ctx context.Context) (*files.AbandonedUploads, error)
+
+// HandleGetAbandonedUploads reports the uploads that the upload reaper would remove, without removing them
+func HandleGetAbandonedUploads(getAbandonedUploads GetAbandonedUploads) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		report, err := getAbandonedUploads(req.Context())
+		if err != nil {
+			handleError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(report); err != nil {
+			handleError(w, err)
+		}
+	}
+}
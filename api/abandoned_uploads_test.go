@@ -0,0 +1,53 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-files-api/api"
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAbandonedUploadsReturnsReport(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/abandoned-uploads", nil)
+	createdBefore := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	h := api.HandleGetAbandonedUploads(func(ctx context.Context) (*files.AbandonedUploads, error) {
+		return &files.AbandonedUploads{
+			CreatedBefore: createdBefore,
+			Count:         1,
+			Items:         []files.AbandonedUpload{{Path: "abandoned.csv", SizeInBytes: 10, CreatedAt: createdBefore.Add(-time.Hour)}},
+		}, nil
+	})
+
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	body := map[string]interface{}{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	assert.Equal(t, "2026-01-01T09:00:00Z", body["created_before"])
+	assert.Equal(t, float64(1), body["count"])
+	assert.Equal(t, "abandoned.csv", body["items"].([]interface{})[0].(map[string]interface{})["path"])
+}
+
+func TestGetAbandonedUploadsError(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/abandoned-uploads", nil)
+
+	h := api.HandleGetAbandonedUploads(func(ctx context.Context) (*files.AbandonedUploads, error) {
+		return nil, errors.New("broken")
+	})
+
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+}
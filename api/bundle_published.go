@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"github.com/ONSdigital/dp-files-api/store"
 	"github.com/gorilla/mux"
@@ -10,10 +11,27 @@ import (
 
 type MarkBundlePublished func(ctx context.Context, bundleID string) error
 
-func HandleMarkBundlePublished(markBundlePublished MarkBundlePublished) http.HandlerFunc {
+type ScheduleBundlePublication func(ctx context.Context, bundleID string, publishAt time.Time) error
+
+func HandleMarkBundlePublished(markBundlePublished MarkBundlePublished, scheduleBundlePublication ScheduleBundlePublication) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		bundleID := mux.Vars(req)["bundleID"]
 
+		publication, err := decodePublication(req)
+		if err != nil {
+			writeError(w, buildErrors(err, "BadJsonEncoding"), http.StatusBadRequest)
+			return
+		}
+
+		if publication.PublishAt != nil {
+			if err := scheduleBundlePublication(req.Context(), bundleID, *publication.PublishAt); err != nil {
+				handleError(w, err)
+				return
+			}
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+
 		if err := markBundlePublished(req.Context(), bundleID); err != nil && err != store.ErrNoFilesInBundle {
 			handleError(w, err)
 			return
@@ -8,9 +8,11 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/ONSdigital/dp-files-api/api"
 	"github.com/ONSdigital/dp-files-api/store"
+	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -20,7 +22,7 @@ func TestMarkBundlePublishedHandlerHandlesUnexpectedPublishingError(t *testing.T
 
 	h := api.HandleMarkBundlePublished(func(ctx context.Context, bundleID string) error {
 		return errors.New("broken")
-	})
+	}, nil)
 
 	h.ServeHTTP(rec, req)
 
@@ -35,9 +37,41 @@ func TestMarkBundlePublishedHandlerDontReturnErrorIfCollectionEmpty(t *testing.T
 
 	h := api.HandleMarkBundlePublished(func(ctx context.Context, bundleID string) error {
 		return store.ErrNoFilesInBundle
-	})
+	}, nil)
 
 	h.ServeHTTP(rec, req)
 
 	assert.Equal(t, http.StatusCreated, rec.Code)
 }
+
+func TestMarkBundlePublishedHandlerSchedulesPublication(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPatch, "/bundle/bundle1", strings.NewReader(`{"publish_at": "2024-03-01T07:00:00Z"}`))
+	req = mux.SetURLVars(req, map[string]string{"bundleID": "bundle1"})
+
+	var scheduledID string
+	h := api.HandleMarkBundlePublished(nil, func(ctx context.Context, bundleID string, publishAt time.Time) error {
+		scheduledID = bundleID
+		return nil
+	})
+
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Equal(t, "bundle1", scheduledID)
+}
+
+func TestMarkBundlePublishedHandlerRejectsPublishedBundle(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPatch, "/bundle/bundle1", strings.NewReader(`{"publish_at": "2024-03-01T07:00:00Z"}`))
+
+	h := api.HandleMarkBundlePublished(nil, func(ctx context.Context, bundleID string, publishAt time.Time) error {
+		return store.ErrBundleAlreadyPublished
+	})
+
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	response, _ := io.ReadAll(rec.Body)
+	assert.Contains(t, string(response), "BundleAlreadyPublished")
+}
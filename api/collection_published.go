@@ -2,7 +2,11 @@ package api
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
+	"time"
 
 	"github.com/ONSdigital/dp-files-api/store"
 	"github.com/gorilla/mux"
@@ -10,10 +14,33 @@ import (
 
 type MarkCollectionPublished func(ctx context.Context, collectionID string) error
 
-func HandleMarkCollectionPublished(markCollectionPublished MarkCollectionPublished) http.HandlerFunc {
+type ScheduleCollectionPublication func(ctx context.Context, collectionID string, publishAt time.Time) error
+
+// Publication is the optional body of a request to publish a collection or bundle. Without a publish_at it is
+// published straight away.
+type Publication struct {
+	PublishAt *time.Time `json:"publish_at"`
+}
+
+func HandleMarkCollectionPublished(markCollectionPublished MarkCollectionPublished, scheduleCollectionPublication ScheduleCollectionPublication) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		collectionID := mux.Vars(req)["collectionID"]
 
+		publication, err := decodePublication(req)
+		if err != nil {
+			writeError(w, buildErrors(err, "BadJsonEncoding"), http.StatusBadRequest)
+			return
+		}
+
+		if publication.PublishAt != nil {
+			if err := scheduleCollectionPublication(req.Context(), collectionID, *publication.PublishAt); err != nil {
+				handleError(w, err)
+				return
+			}
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+
 		if err := markCollectionPublished(req.Context(), collectionID); err != nil && err != store.ErrNoFilesInCollection {
 			handleError(w, err)
 			return
@@ -22,3 +49,15 @@ func HandleMarkCollectionPublished(markCollectionPublished MarkCollectionPublish
 		w.WriteHeader(http.StatusCreated)
 	}
 }
+
+// decodePublication reads the body of a request to publish a collection or bundle, which can be empty
+func decodePublication(req *http.Request) (Publication, error) {
+	var publication Publication
+	if req.Body == nil {
+		return publication, nil
+	}
+	if err := json.NewDecoder(req.Body).Decode(&publication); err != nil && !errors.Is(err, io.EOF) {
+		return publication, err
+	}
+	return publication, nil
+}
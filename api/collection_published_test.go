@@ -8,9 +8,11 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/ONSdigital/dp-files-api/api"
 	"github.com/ONSdigital/dp-files-api/store"
+	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -20,7 +22,7 @@ func TestMarkCollectionPublishedHandlerHandlesUnexpectedPublishingError(t *testi
 
 	h := api.HandleMarkCollectionPublished(func(ctx context.Context, collectionID string) error {
 		return errors.New("broken")
-	})
+	}, nil)
 
 	h.ServeHTTP(rec, req)
 
@@ -35,9 +37,72 @@ func TestMarkCollectionPublishedHandlerDontReturnErrorIfCollectionEmpty(t *testi
 
 	h := api.HandleMarkCollectionPublished(func(ctx context.Context, collectionID string) error {
 		return store.ErrNoFilesInCollection
+	}, nil)
+
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+}
+
+func TestMarkCollectionPublishedHandlerSchedulesPublication(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPatch, "/collection/coll1", strings.NewReader(`{"publish_at": "2024-03-01T07:00:00Z"}`))
+	req = mux.SetURLVars(req, map[string]string{"collectionID": "coll1"})
+
+	var scheduledID string
+	var scheduledAt time.Time
+	h := api.HandleMarkCollectionPublished(nil, func(ctx context.Context, collectionID string, publishAt time.Time) error {
+		scheduledID, scheduledAt = collectionID, publishAt
+		return nil
 	})
 
 	h.ServeHTTP(rec, req)
 
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Equal(t, "coll1", scheduledID)
+	assert.Equal(t, time.Date(2024, 3, 1, 7, 0, 0, 0, time.UTC), scheduledAt.UTC())
+}
+
+func TestMarkCollectionPublishedHandlerPublishesWithoutBody(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPatch, "/collection/coll1", http.NoBody)
+
+	published := false
+	h := api.HandleMarkCollectionPublished(func(ctx context.Context, collectionID string) error {
+		published = true
+		return nil
+	}, nil)
+
+	h.ServeHTTP(rec, req)
+
 	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.True(t, published)
+}
+
+func TestMarkCollectionPublishedHandlerRejectsPastPublishAt(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPatch, "/collection/coll1", strings.NewReader(`{"publish_at": "2020-01-01T07:00:00Z"}`))
+
+	h := api.HandleMarkCollectionPublished(nil, func(ctx context.Context, collectionID string, publishAt time.Time) error {
+		return store.ErrPublishAtNotInFuture
+	})
+
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	response, _ := io.ReadAll(rec.Body)
+	assert.Contains(t, string(response), "InvalidPublishAt")
+}
+
+func TestMarkCollectionPublishedHandlerHandlesInvalidJSON(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPatch, "/collection/coll1", strings.NewReader(`{"publish_at": "07:00"}`))
+
+	h := api.HandleMarkCollectionPublished(nil, nil)
+
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	response, _ := io.ReadAll(rec.Body)
+	assert.Contains(t, string(response), "BadJsonEncoding")
 }
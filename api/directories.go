@@ -0,0 +1,55 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/dp-files-api/store"
+	"github.com/gorilla/mux"
+)
+
+type GetDirectory func(ctx context.Context, query store.DirectoryQuery) (*files.Directory, error)
+
+func HandleGetDirectory(getDirectory GetDirectory) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		query, err := parseDirectoryQuery(mux.Vars(req)["prefix"], req.URL.Query())
+		if err != nil {
+			handleError(w, err)
+			return
+		}
+
+		directory, err := getDirectory(req.Context(), query)
+		if err != nil {
+			handleError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(directory); err != nil {
+			handleError(w, err)
+		}
+	}
+}
+
+// parseDirectoryQuery reads the page of a directory listing asked for, which is never more than maxFilesLimit entries
+func parseDirectoryQuery(prefix string, params url.Values) (store.DirectoryQuery, error) {
+	query := store.DirectoryQuery{
+		Prefix: prefix,
+		Cursor: params.Get("cursor"),
+		Limit:  maxFilesLimit,
+	}
+
+	if limit := params.Get("limit"); limit != "" {
+		val, err := strconv.Atoi(limit)
+		if err != nil || val < 1 || val > maxFilesLimit {
+			return query, store.ErrInvalidLimit
+		}
+		query.Limit = val
+	}
+
+	return query, nil
+}
@@ -0,0 +1,94 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ONSdigital/dp-files-api/api"
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/dp-files-api/store"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetDirectoryReturnsListing(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/directories/cpih01/2024", nil), map[string]string{"prefix": "cpih01/2024"})
+
+	var requested store.DirectoryQuery
+	h := api.HandleGetDirectory(func(ctx context.Context, query store.DirectoryQuery) (*files.Directory, error) {
+		requested = query
+		return &files.Directory{
+			Path:        query.Prefix,
+			FileCount:   2,
+			SizeInBytes: 30,
+			Directories: []files.DirectoryEntry{{Name: "v1", Path: "cpih01/2024/v1", IsDirectory: true, FileCount: 1, SizeInBytes: 10}},
+			Files:       []files.DirectoryEntry{{Name: "readme.md", Path: "cpih01/2024/readme.md", SizeInBytes: 20}},
+		}, nil
+	})
+
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.Equal(t, store.DirectoryQuery{Prefix: "cpih01/2024", Limit: 1000}, requested)
+
+	body := map[string]interface{}{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	assert.Equal(t, float64(2), body["file_count"])
+	assert.Equal(t, "cpih01/2024/v1", body["directories"].([]interface{})[0].(map[string]interface{})["path"])
+	assert.NotContains(t, body["files"].([]interface{})[0], "file_count")
+}
+
+func TestGetDirectoryNotFound(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/directories/missing", nil), map[string]string{"prefix": "missing"})
+
+	h := api.HandleGetDirectory(func(ctx context.Context, query store.DirectoryQuery) (*files.Directory, error) {
+		return nil, store.ErrPathNotFound
+	})
+
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestGetDirectoryWithLimitAndCursor(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/directories?limit=2&cursor=YS8", nil), map[string]string{"prefix": ""})
+
+	var requested store.DirectoryQuery
+	h := api.HandleGetDirectory(func(ctx context.Context, query store.DirectoryQuery) (*files.Directory, error) {
+		requested = query
+		return &files.Directory{Limit: query.Limit, NextCursor: "Yy8"}, nil
+	})
+
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, store.DirectoryQuery{Cursor: "YS8", Limit: 2}, requested)
+
+	body := map[string]interface{}{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	assert.Equal(t, float64(2), body["limit"])
+	assert.Equal(t, "Yy8", body["next_cursor"])
+}
+
+func TestGetDirectoryInvalidLimit(t *testing.T) {
+	for _, limit := range []string{"0", "1001", "ten"} {
+		rec := httptest.NewRecorder()
+		req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/directories/cpih01?limit="+limit, nil), map[string]string{"prefix": "cpih01"})
+
+		h := api.HandleGetDirectory(func(ctx context.Context, query store.DirectoryQuery) (*files.Directory, error) {
+			t.Fatal("the directory should not be listed")
+			return nil, nil
+		})
+
+		h.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code, limit)
+	}
+}
@@ -42,6 +42,8 @@ func handleError(w http.ResponseWriter, err error) {
 		writeError(w, buildErrors(err, "FileStateError"), http.StatusConflict)
 	case store.ErrNoFilesInCollection:
 		writeError(w, buildErrors(err, "EmptyCollection"), http.StatusNotFound)
+	case store.ErrChecksumMismatch:
+		writeError(w, buildErrors(err, "ChecksumMismatch"), http.StatusConflict)
 	case store.ErrFileIsNotPublishable:
 		writeError(w, buildErrors(err, "FileNotPublishable"), http.StatusConflict)
 	case store.ErrBothCollectionAndBundleIDSet:
@@ -52,6 +54,60 @@ func handleError(w http.ResponseWriter, err error) {
 		writeError(w, buildErrors(err, "FileIsPublished"), http.StatusConflict)
 	case store.ErrPathNotFound:
 		writeError(w, buildErrors(err, "NotFound"), http.StatusNotFound)
+	case store.ErrPublishJobNotFound:
+		writeError(w, buildErrors(err, "PublishJobNotFound"), http.StatusNotFound)
+	case store.ErrFileVersionNotFound:
+		writeError(w, buildErrors(err, "FileVersionNotFound"), http.StatusNotFound)
+	case store.ErrFileVersionExists:
+		writeError(w, buildErrors(err, "FileVersionExists"), http.StatusConflict)
+	case store.ErrInvalidFileVersion:
+		writeError(w, buildErrors(err, "InvalidFileVersion"), http.StatusBadRequest)
+	case store.ErrInvalidCursor:
+		writeError(w, buildErrors(err, "InvalidCursor"), http.StatusBadRequest)
+	case store.ErrInvalidLimit:
+		writeError(w, buildErrors(err, "InvalidLimit"), http.StatusBadRequest)
+	case store.ErrInvalidOffset:
+		writeError(w, buildErrors(err, "InvalidOffset"), http.StatusBadRequest)
+	case store.ErrInvalidStateFilter:
+		writeError(w, buildErrors(err, "InvalidState"), http.StatusBadRequest)
+	case store.ErrInvalidIsPublishable:
+		writeError(w, buildErrors(err, "InvalidIsPublishable"), http.StatusBadRequest)
+	case store.ErrInvalidCreatedAfter, store.ErrInvalidCreatedBefore, store.ErrInvalidModifiedAfter, store.ErrInvalidModifiedBefore:
+		writeError(w, buildErrors(err, "InvalidDate"), http.StatusBadRequest)
+	case store.ErrFileWithdrawn:
+		writeError(w, buildErrors(err, "FileWithdrawn"), http.StatusConflict)
+	case store.ErrFileNotPublished:
+		writeError(w, buildErrors(err, "FileNotPublished"), http.StatusConflict)
+	case store.ErrCollectionNotPublished:
+		writeError(w, buildErrors(err, "CollectionNotPublished"), http.StatusConflict)
+	case store.ErrBundleNotPublished:
+		writeError(w, buildErrors(err, "BundleNotPublished"), http.StatusConflict)
+	case store.ErrCollectionWithdrawn:
+		writeError(w, buildErrors(err, "CollectionWithdrawn"), http.StatusConflict)
+	case store.ErrBundleWithdrawn:
+		writeError(w, buildErrors(err, "BundleWithdrawn"), http.StatusConflict)
+	case store.ErrWithdrawalReasonRequired:
+		writeError(w, buildErrors(err, "WithdrawalReasonRequired"), http.StatusBadRequest)
+	case store.ErrCollectionAlreadyPublished:
+		writeError(w, buildErrors(err, "CollectionAlreadyPublished"), http.StatusConflict)
+	case store.ErrBundleAlreadyPublished:
+		writeError(w, buildErrors(err, "BundleAlreadyPublished"), http.StatusConflict)
+	case store.ErrPublishAtNotInFuture:
+		writeError(w, buildErrors(err, "InvalidPublishAt"), http.StatusBadRequest)
+	case store.ErrFileNotInTrash:
+		writeError(w, buildErrors(err, "FileNotInTrash"), http.StatusNotFound)
+	case store.ErrReconciliationNotFound:
+		writeError(w, buildErrors(err, "ReconciliationNotFound"), http.StatusNotFound)
+	case store.ErrReconciliationInProgress:
+		writeError(w, buildErrors(err, "ReconciliationInProgress"), http.StatusConflict)
+	case store.ErrIdempotencyKeyReused:
+		writeError(w, buildErrors(err, "IdempotencyKeyReused"), http.StatusUnprocessableEntity)
+	case store.ErrIdempotencyKeyInProgress:
+		writeError(w, buildErrors(err, "IdempotencyKeyInProgress"), http.StatusConflict)
+	case store.ErrFileModified:
+		writeError(w, buildErrors(err, "PreconditionFailed"), http.StatusPreconditionFailed)
+	case store.ErrInvalidFileChangeID:
+		writeError(w, buildErrors(err, "InvalidLastEventID"), http.StatusBadRequest)
 	default:
 		writeError(w, buildErrors(err, "InternalError"), http.StatusInternalServerError)
 	}
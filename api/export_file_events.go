@@ -0,0 +1,192 @@
+package api
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	clientsidentity "github.com/ONSdigital/dp-api-clients-go/v2/identity"
+	auth "github.com/ONSdigital/dp-authorisation/v2/authorisation"
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/dp-files-api/store"
+	dprequest "github.com/ONSdigital/dp-net/v3/request"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+const (
+	ndjsonContentType = "application/x-ndjson"
+	csvContentType    = "text/csv"
+)
+
+var fileEventCSVHeader = []string{
+	"sequence", "created_at", "requested_by_id", "requested_by_email", "action", "resource",
+	"path", "collection_id", "bundle_id", "state", "hash",
+}
+
+type ExportFileEvents func(ctx context.Context, filter store.FileEventFilter, each func(event files.FileEvent) error) error
+
+// HandlerExportFileEvents streams every file event matching the same filters as GET /file-events, oldest first, as
+// NDJSON or CSV as the Accept header asks. The export is recorded as a READ file event before it starts, as the status
+// cannot be changed once the events are being written.
+func HandlerExportFileEvents(exportFileEvents ExportFileEvents, createFileEvent CreateFileEvent, authMiddleware auth.Middleware, idClient *clientsidentity.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+
+		logData := log.Data{
+			"method": req.Method,
+			"path":   req.URL.Path,
+		}
+
+		accessToken := strings.TrimPrefix(req.Header.Get(dprequest.AuthHeaderKey), dprequest.BearerPrefix)
+		if accessToken == "" {
+			log.Info(ctx, "authorisation failed: no authorisation header in request", log.Classification(log.ProtectiveMonitoring), logData)
+			writeError(w, buildGenericError("Unauthorised", "The user is unauthorised"), http.StatusUnauthorized)
+			return
+		}
+
+		authEntityData, err := getAuthEntityData(ctx, authMiddleware, idClient, accessToken, logData)
+		if err != nil {
+			log.Error(ctx, "failed to get auth entity data for file-events export", err, logData)
+			if strings.Contains(err.Error(), "key id unknown or invalid") || strings.Contains(err.Error(), "jwt token is malformed") {
+				writeError(w, buildGenericError("Unauthorised", "the request was not authorised"), http.StatusUnauthorized)
+				return
+			}
+			writeError(w, buildGenericError("Forbidden", "the request was not authorised - check token and user's permissions"), http.StatusForbidden)
+			return
+		}
+
+		filter, err := parseFileEventFilterParams(req)
+		if err != nil {
+			writeError(w, buildErrors(err, "InvalidRequest"), http.StatusBadRequest)
+			return
+		}
+
+		contentType, ok := exportContentType(req.Header.Get("Accept"))
+		if !ok {
+			err := errors.New("file events can be exported as " + ndjsonContentType + " or " + csvContentType)
+			writeError(w, buildErrors(err, "NotAcceptable"), http.StatusNotAcceptable)
+			return
+		}
+
+		if err := createAuditEvent(ctx, createFileEvent, authEntityData.EntityData, authEntityData.IsServiceAuth, files.ActionRead, req.URL.Path, nil, logData); err != nil {
+			handleError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", contentType)
+
+		var exported int
+		if contentType == csvContentType {
+			w.Header().Set("Content-Disposition", `attachment; filename="file-events.csv"`)
+			exported, err = exportFileEventsCSV(ctx, w, filter, exportFileEvents)
+		} else {
+			w.Header().Set("Content-Disposition", `attachment; filename="file-events.ndjson"`)
+			exported, err = exportFileEventsNDJSON(ctx, w, filter, exportFileEvents)
+		}
+
+		logData["exported"] = exported
+		if err != nil {
+			// the events written so far have been sent, so the export is cut short rather than answered with an error
+			log.Error(ctx, "file events export failed", err, logData)
+			return
+		}
+		log.Info(ctx, "file events exported", logData)
+	}
+}
+
+// exportContentType picks the first media type in the Accept header that file events can be exported as. NDJSON is
+// given when there is no preference.
+func exportContentType(accept string) (string, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return ndjsonContentType, true
+	}
+
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(mediaRange)
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case ndjsonContentType, "application/*", "*/*":
+			return ndjsonContentType, true
+		case csvContentType, "text/*":
+			return csvContentType, true
+		}
+	}
+	return "", false
+}
+
+func exportFileEventsNDJSON(ctx context.Context, w http.ResponseWriter, filter store.FileEventFilter, exportFileEvents ExportFileEvents) (int, error) {
+	encoder := json.NewEncoder(w)
+	exported := 0
+	err := exportFileEvents(ctx, filter, func(event files.FileEvent) error {
+		exported++
+		return encoder.Encode(event)
+	})
+	return exported, err
+}
+
+func exportFileEventsCSV(ctx context.Context, w http.ResponseWriter, filter store.FileEventFilter, exportFileEvents ExportFileEvents) (int, error) {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(fileEventCSVHeader); err != nil {
+		return 0, err
+	}
+
+	exported := 0
+	err := exportFileEvents(ctx, filter, func(event files.FileEvent) error {
+		exported++
+		return writer.Write(fileEventCSVRecord(event))
+	})
+	writer.Flush()
+	if err != nil {
+		return exported, err
+	}
+	return exported, writer.Error()
+}
+
+func fileEventCSVRecord(event files.FileEvent) []string {
+	record := make([]string, len(fileEventCSVHeader))
+	if event.Sequence != 0 {
+		record[0] = strconv.FormatInt(event.Sequence, 10)
+	}
+	if event.CreatedAt != nil {
+		record[1] = event.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
+	if event.RequestedBy != nil {
+		record[2] = event.RequestedBy.ID
+		record[3] = event.RequestedBy.Email
+	}
+	record[4] = event.Action
+	record[5] = event.Resource
+	if event.File != nil {
+		record[6] = event.File.Path
+		if event.File.CollectionID != nil {
+			record[7] = *event.File.CollectionID
+		}
+		if event.File.BundleID != nil {
+			record[8] = *event.File.BundleID
+		}
+		record[9] = event.File.State
+	}
+	record[10] = event.Hash
+
+	for i, cell := range record {
+		record[i] = csvSafeCell(cell)
+	}
+	return record
+}
+
+// csvSafeCell stops a spreadsheet opening the export from treating a cell as a formula, by prefixing cells that start
+// with a formula character with a quote
+func csvSafeCell(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
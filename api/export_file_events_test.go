@@ -0,0 +1,273 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-authorisation/v2/authorisationtest"
+	"github.com/ONSdigital/dp-files-api/api"
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/dp-files-api/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func exportedEvents(t *testing.T, expectedFilter store.FileEventFilter) api.ExportFileEvents {
+	createdAt := time.Date(2026, 1, 2, 9, 0, 0, 0, time.UTC)
+	collectionID := "collection-1"
+	events := []files.FileEvent{
+		{
+			Sequence:    1,
+			CreatedAt:   &createdAt,
+			RequestedBy: &files.RequestedBy{ID: "user123", Email: "user123@example.com"},
+			Action:      files.ActionRead,
+			Resource:    "/downloads/file1.csv",
+			File:        &files.StoredRegisteredMetaData{Path: "file1.csv", CollectionID: &collectionID, State: store.StatePublished},
+			Hash:        "abc",
+		},
+		{
+			CreatedAt:   &createdAt,
+			RequestedBy: &files.RequestedBy{ID: "service"},
+			Action:      files.ActionRead,
+			Resource:    "/file-events",
+		},
+	}
+
+	return func(ctx context.Context, filter store.FileEventFilter, each func(event files.FileEvent) error) error {
+		assert.Equal(t, expectedFilter, filter)
+		for _, event := range events {
+			if err := each(event); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func splitLines(body string) []string {
+	return strings.Split(strings.TrimSuffix(body, "\n"), "\n")
+}
+
+func TestExportFileEventsAsNDJSON(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/file-events/export?path=file1.csv", http.NoBody)
+	req.Header.Add("Authorization", authorisationtest.AdminJWTToken)
+
+	authMiddlewareMock, identityClientMock, _ := setUpAuthServices()
+
+	h := api.HandlerExportFileEvents(
+		exportedEvents(t, store.FileEventFilter{Path: "file1.csv"}),
+		func(ctx context.Context, event *files.FileEvent) error { return nil },
+		authMiddlewareMock,
+		identityClientMock,
+	)
+
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/x-ndjson", rec.Header().Get("Content-Type"))
+	lines := splitLines(rec.Body.String())
+	assert.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `"sequence":1`)
+	assert.Contains(t, lines[0], `"resource":"/downloads/file1.csv"`)
+	assert.Contains(t, lines[1], `"resource":"/file-events"`)
+}
+
+func TestExportFileEventsAsCSV(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/file-events/export?after=2025-01-01T00:00:00Z&before=2026-12-31T23:59:59Z", http.NoBody)
+	req.Header.Add("Authorization", authorisationtest.AdminJWTToken)
+	req.Header.Add("Accept", "text/csv")
+
+	authMiddlewareMock, identityClientMock, _ := setUpAuthServices()
+	after := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	before := time.Date(2026, 12, 31, 23, 59, 59, 0, time.UTC)
+
+	h := api.HandlerExportFileEvents(
+		exportedEvents(t, store.FileEventFilter{After: &after, Before: &before}),
+		func(ctx context.Context, event *files.FileEvent) error { return nil },
+		authMiddlewareMock,
+		identityClientMock,
+	)
+
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/csv", rec.Header().Get("Content-Type"))
+	assert.Equal(t, []string{
+		"sequence,created_at,requested_by_id,requested_by_email,action,resource,path,collection_id,bundle_id,state,hash",
+		"1,2026-01-02T09:00:00Z,user123,user123@example.com,READ,/downloads/file1.csv,file1.csv,collection-1,,PUBLISHED,abc",
+		",2026-01-02T09:00:00Z,service,,READ,/file-events,,,,,",
+	}, splitLines(rec.Body.String()))
+}
+
+func TestExportFileEventsAsCSVEscapesFormulas(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/file-events/export", http.NoBody)
+	req.Header.Add("Authorization", authorisationtest.AdminJWTToken)
+	req.Header.Add("Accept", "text/csv")
+
+	authMiddlewareMock, identityClientMock, _ := setUpAuthServices()
+	collectionID := "+collection"
+	bundleID := "-bundle"
+
+	h := api.HandlerExportFileEvents(
+		func(ctx context.Context, filter store.FileEventFilter, each func(event files.FileEvent) error) error {
+			return each(files.FileEvent{
+				RequestedBy: &files.RequestedBy{ID: "=HYPERLINK(\"http://example.com\")", Email: "@user"},
+				Action:      files.ActionRead,
+				Resource:    "\t/downloads/file.csv",
+				File:        &files.StoredRegisteredMetaData{Path: "\rfile.csv", CollectionID: &collectionID, BundleID: &bundleID},
+			})
+		},
+		func(ctx context.Context, event *files.FileEvent) error { return nil },
+		authMiddlewareMock,
+		identityClientMock,
+	)
+
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, []string{
+		"sequence,created_at,requested_by_id,requested_by_email,action,resource,path,collection_id,bundle_id,state,hash",
+		",,\"'=HYPERLINK(\"\"http://example.com\"\")\",'@user,READ,'\t/downloads/file.csv,\"'\rfile.csv\",'+collection,'-bundle,,",
+	}, splitLines(rec.Body.String()))
+}
+
+func TestExportFileEventsWithFileAndRequesterFilters(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/file-events/export?action=READ&requested_by=user123&collection_id=collection-1", http.NoBody)
+	req.Header.Add("Authorization", authorisationtest.AdminJWTToken)
+
+	authMiddlewareMock, identityClientMock, _ := setUpAuthServices()
+
+	h := api.HandlerExportFileEvents(
+		exportedEvents(t, store.FileEventFilter{Action: files.ActionRead, RequestedByID: "user123", CollectionID: "collection-1"}),
+		func(ctx context.Context, event *files.FileEvent) error { return nil },
+		authMiddlewareMock,
+		identityClientMock,
+	)
+
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestExportFileEventsNotAcceptable(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/file-events/export", http.NoBody)
+	req.Header.Add("Authorization", authorisationtest.AdminJWTToken)
+	req.Header.Add("Accept", "application/xml")
+
+	authMiddlewareMock, identityClientMock, _ := setUpAuthServices()
+	auditEventCreated := false
+
+	h := api.HandlerExportFileEvents(
+		exportedEvents(t, store.FileEventFilter{}),
+		func(ctx context.Context, event *files.FileEvent) error {
+			auditEventCreated = true
+			return nil
+		},
+		authMiddlewareMock,
+		identityClientMock,
+	)
+
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotAcceptable, rec.Code)
+	assert.False(t, auditEventCreated)
+}
+
+func TestExportFileEventsInvalidAfter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/file-events/export?after=yesterday", http.NoBody)
+	req.Header.Add("Authorization", authorisationtest.AdminJWTToken)
+
+	authMiddlewareMock, identityClientMock, _ := setUpAuthServices()
+
+	h := api.HandlerExportFileEvents(
+		exportedEvents(t, store.FileEventFilter{}),
+		func(ctx context.Context, event *files.FileEvent) error { return nil },
+		authMiddlewareMock,
+		identityClientMock,
+	)
+
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestExportFileEvents_AuditRecordCreated(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/file-events/export", http.NoBody)
+	req.Header.Add("Authorization", authorisationtest.AdminJWTToken)
+
+	auditEventCreated := false
+	authMiddlewareMock, identityClientMock, _ := setUpAuthServices()
+
+	h := api.HandlerExportFileEvents(
+		exportedEvents(t, store.FileEventFilter{}),
+		func(ctx context.Context, event *files.FileEvent) error {
+			auditEventCreated = true
+			assert.Equal(t, files.ActionRead, event.Action)
+			assert.Equal(t, "/file-events/export", event.Resource)
+			assert.Equal(t, "admin", event.RequestedBy.ID)
+			return nil
+		},
+		authMiddlewareMock,
+		identityClientMock,
+	)
+
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, auditEventCreated)
+}
+
+func TestExportFileEvents_AuditRecordFailure_Returns500(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/file-events/export", http.NoBody)
+	req.Header.Add("Authorization", authorisationtest.AdminJWTToken)
+
+	exported := false
+	authMiddlewareMock, identityClientMock, _ := setUpAuthServices()
+
+	h := api.HandlerExportFileEvents(
+		func(ctx context.Context, filter store.FileEventFilter, each func(event files.FileEvent) error) error {
+			exported = true
+			return nil
+		},
+		func(ctx context.Context, event *files.FileEvent) error {
+			return errors.New("failed to create audit record")
+		},
+		authMiddlewareMock,
+		identityClientMock,
+	)
+
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.False(t, exported, "nothing is exported without an audit record")
+}
+
+func TestExportFileEvents_NoToken_Returns401(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/file-events/export", http.NoBody)
+
+	authMiddlewareMock, identityClientMock, _ := setUpAuthServices()
+
+	h := api.HandlerExportFileEvents(
+		exportedEvents(t, store.FileEventFilter{}),
+		func(ctx context.Context, event *files.FileEvent) error { return nil },
+		authMiddlewareMock,
+		identityClientMock,
+	)
+
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
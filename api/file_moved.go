@@ -43,6 +43,7 @@ func HandleMarkFileMoved(markMovementComplete MarkMovementComplete, createFileEv
 			writeError(w, buildGenericError("Forbidden", "the request was not authorised - check token and user's permissions"), http.StatusForbidden)
 			return
 		}
+		ctx = dprequest.SetCaller(ctx, authEntityData.EntityData.UserID)
 
 		m := EtagChange{}
 		if err = json.NewDecoder(req.Body).Decode(&m); err != nil {
@@ -36,6 +36,7 @@ func HandleRemoveFile(removeFile RemoveFile, createFileEvent CreateFileEvent, ge
 			writeError(w, buildGenericError("Forbidden", "the request was not authorised - check token and user's permissions"), http.StatusForbidden)
 			return
 		}
+		ctx = dprequest.SetCaller(ctx, authEntityData.EntityData.UserID)
 
 		logData["entity_data"] = authEntityData
 
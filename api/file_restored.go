@@ -0,0 +1,64 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	clientsidentity "github.com/ONSdigital/dp-api-clients-go/v2/identity"
+	auth "github.com/ONSdigital/dp-authorisation/v2/authorisation"
+	"github.com/ONSdigital/dp-files-api/files"
+	dprequest "github.com/ONSdigital/dp-net/v3/request"
+	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/gorilla/mux"
+)
+
+type RestoreFile func(ctx context.Context, path string) error
+
+type GetTrashedFile func(ctx context.Context, path string) (files.TrashedFile, error)
+
+// HandleRestoreFile restores the file most recently removed from a path, as long as it has not been purged
+func HandleRestoreFile(restoreFile RestoreFile, getTrashedFile GetTrashedFile, createFileEvent CreateFileEvent, authMiddleware auth.Middleware, identityClient *clientsidentity.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		path := mux.Vars(req)["path"]
+
+		logData := log.Data{
+			"method": req.Method,
+			"path":   path,
+		}
+
+		accessToken := strings.TrimPrefix(req.Header.Get(dprequest.AuthHeaderKey), dprequest.BearerPrefix)
+
+		authEntityData, err := getAuthEntityData(ctx, authMiddleware, identityClient, accessToken, logData)
+		if err != nil {
+			if strings.Contains(err.Error(), "key id unknown or invalid") || strings.Contains(err.Error(), "jwt token is malformed") {
+				writeError(w, buildGenericError("Unauthorised", "the request was not authorised"), http.StatusUnauthorized)
+				return
+			}
+			writeError(w, buildGenericError("Forbidden", "the request was not authorised - check token and user's permissions"), http.StatusForbidden)
+			return
+		}
+		ctx = dprequest.SetCaller(ctx, authEntityData.EntityData.UserID)
+
+		trashed, err := getTrashedFile(ctx, path)
+		if err != nil {
+			log.Error(ctx, "failed to get trashed file", err, logData)
+			handleError(w, err)
+			return
+		}
+
+		if err := createAuditEvent(ctx, createFileEvent, authEntityData.EntityData, authEntityData.IsServiceAuth, files.ActionCreate, path, &trashed.Metadata, logData); err != nil {
+			handleError(w, err)
+			return
+		}
+
+		if err := restoreFile(ctx, path); err != nil {
+			log.Error(ctx, "failed to restore file", err, logData)
+			handleError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
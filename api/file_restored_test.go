@@ -0,0 +1,98 @@
+package api_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ONSdigital/dp-authorisation/v2/authorisationtest"
+	"github.com/ONSdigital/dp-files-api/api"
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/dp-files-api/store"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func restoreRequest() *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/files/path.txt/restore", http.NoBody)
+	req.Header.Add("Authorization", authorisationtest.AdminJWTToken)
+	return mux.SetURLVars(req, map[string]string{"path": "path.txt"})
+}
+
+func TestHandleRestoreFile_Successful(t *testing.T) {
+	rec := httptest.NewRecorder()
+
+	var events []*files.FileEvent
+	var restoredPath string
+	getTrashedFileFunc := func(ctx context.Context, path string) (files.TrashedFile, error) {
+		return files.TrashedFile{Path: path, Metadata: files.StoredRegisteredMetaData{Path: path, State: store.StateUploaded}}, nil
+	}
+	createFileEventFunc := func(ctx context.Context, event *files.FileEvent) error {
+		events = append(events, event)
+		return nil
+	}
+	restoreFileFunc := func(ctx context.Context, path string) error {
+		restoredPath = path
+		return nil
+	}
+
+	authMiddlewareMock, identityClientMock, _ := setUpAuthServices()
+
+	h := api.HandleRestoreFile(restoreFileFunc, getTrashedFileFunc, createFileEventFunc, authMiddlewareMock, identityClientMock)
+	h.ServeHTTP(rec, restoreRequest())
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "path.txt", restoredPath)
+	assert.Len(t, events, 1)
+	assert.Equal(t, files.ActionCreate, events[0].Action)
+	assert.Equal(t, "path.txt", events[0].File.Path)
+}
+
+func TestHandleRestoreFile_Forbidden(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/files/path.txt/restore", http.NoBody)
+
+	authMiddlewareMock, identityClientMock, _ := setUpAuthServices()
+
+	h := api.HandleRestoreFile(nil, nil, nil, authMiddlewareMock, identityClientMock)
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestHandleRestoreFile_NotInTrash(t *testing.T) {
+	rec := httptest.NewRecorder()
+
+	getTrashedFileFunc := func(ctx context.Context, path string) (files.TrashedFile, error) {
+		return files.TrashedFile{}, store.ErrFileNotInTrash
+	}
+
+	authMiddlewareMock, identityClientMock, _ := setUpAuthServices()
+
+	h := api.HandleRestoreFile(nil, getTrashedFileFunc, nil, authMiddlewareMock, identityClientMock)
+	h.ServeHTTP(rec, restoreRequest())
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestHandleRestoreFile_PathTaken(t *testing.T) {
+	rec := httptest.NewRecorder()
+
+	getTrashedFileFunc := func(ctx context.Context, path string) (files.TrashedFile, error) {
+		return files.TrashedFile{Path: path}, nil
+	}
+	createFileEventFunc := func(ctx context.Context, event *files.FileEvent) error {
+		return nil
+	}
+	restoreFileFunc := func(ctx context.Context, path string) error {
+		return store.ErrDuplicateFile
+	}
+
+	authMiddlewareMock, identityClientMock, _ := setUpAuthServices()
+
+	h := api.HandleRestoreFile(restoreFileFunc, getTrashedFileFunc, createFileEventFunc, authMiddlewareMock, identityClientMock)
+	h.ServeHTTP(rec, restoreRequest())
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+}
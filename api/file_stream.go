@@ -0,0 +1,116 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+const fileStreamPageSize = 100
+
+type GetFileChanges func(ctx context.Context, collectionID, bundleID, after string, limit int) ([]files.FileChange, string, error)
+
+type FileChanged func() <-chan struct{}
+
+// HandleFileStream streams changes to the files in a collection or bundle as Server-Sent Events. Each event's ID is
+// the change's sequence number so that a client reconnecting with the Last-Event-ID header resumes from where it left
+// off.
+func HandleFileStream(getFileChanges GetFileChanges, fileChanged FileChanged, pollInterval, heartbeat time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		collectionID := req.URL.Query().Get("collection_id")
+		bundleID := req.URL.Query().Get("bundle_id")
+
+		if collectionID == "" && bundleID == "" {
+			err := errors.New("missing required ID: either collection_id or bundle_id must be provided")
+			writeError(w, buildErrors(err, "BadRequest"), http.StatusBadRequest)
+			return
+		}
+
+		if collectionID != "" && bundleID != "" {
+			err := errors.New("only one of collection_id or bundle_id should be provided")
+			writeError(w, buildErrors(err, "BadRequest"), http.StatusBadRequest)
+			return
+		}
+
+		changed := fileChanged()
+		changes, cursor, err := getFileChanges(ctx, collectionID, bundleID, req.Header.Get("Last-Event-ID"), fileStreamPageSize)
+		if err != nil {
+			log.Error(ctx, "file changes fetch failed", err, log.Data{"collection_id": collectionID, "bundle_id": bundleID})
+			handleError(w, err)
+			return
+		}
+
+		// the server's write timeout would otherwise end the stream
+		rc := http.NewResponseController(w)
+		if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			log.Error(ctx, "failed to clear write deadline for file stream", err)
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
+
+		poll := time.NewTicker(pollInterval)
+		defer poll.Stop()
+		keepAlive := time.NewTicker(heartbeat)
+		defer keepAlive.Stop()
+
+		for {
+			if err := writeFileChanges(w, changes); err != nil {
+				log.Error(ctx, "failed to write file changes to stream", err)
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				log.Error(ctx, "failed to flush file stream", err)
+				return
+			}
+
+			// a full page means there may be more changes waiting, so fetch again straight away
+			if len(changes) < fileStreamPageSize {
+				select {
+				case <-ctx.Done():
+					return
+				case <-changed:
+				case <-poll.C:
+				case <-keepAlive.C:
+					if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+						return
+					}
+					if err := rc.Flush(); err != nil {
+						return
+					}
+					changes = nil
+					continue
+				}
+			}
+
+			changed = fileChanged()
+			changes, cursor, err = getFileChanges(ctx, collectionID, bundleID, cursor, fileStreamPageSize)
+			if err != nil {
+				log.Error(ctx, "file changes fetch failed", err, log.Data{"collection_id": collectionID, "bundle_id": bundleID})
+				return
+			}
+		}
+	}
+}
+
+func writeFileChanges(w http.ResponseWriter, changes []files.FileChange) error {
+	for _, change := range changes {
+		data, err := json.Marshal(change)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "id: %d\nevent: file-change\ndata: %s\n\n", change.Sequence, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package api_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-files-api/api"
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/dp-files-api/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileStreamWritesChangesAsEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/files/stream?collection_id=c1", nil).WithContext(ctx)
+	req.Header.Set("Last-Event-ID", "1")
+
+	collectionID := "c1"
+	var requestedAfter []string
+	h := api.HandleFileStream(func(ctx context.Context, cID, bID, after string, limit int) ([]files.FileChange, string, error) {
+		assert.Equal(t, "c1", cID)
+		assert.Equal(t, "", bID)
+		requestedAfter = append(requestedAfter, after)
+		if len(requestedAfter) > 1 {
+			cancel()
+			return nil, after, nil
+		}
+		return []files.FileChange{
+			{Sequence: 2, Path: "data/file.csv", CollectionID: &collectionID, Change: files.LifecycleStateChanged, FromState: store.StateCreated, ToState: store.StateUploaded},
+		}, "2", nil
+	}, func() <-chan struct{} { return make(chan struct{}) }, time.Millisecond, time.Hour)
+
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/event-stream", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "no-cache", rec.Header().Get("Cache-Control"))
+	assert.Equal(t, []string{"1", "2"}, requestedAfter)
+
+	body, _ := io.ReadAll(rec.Body)
+	assert.Contains(t, string(body), "id: 2\nevent: file-change\ndata: {")
+	assert.Contains(t, string(body), `"sequence":2`)
+	assert.Contains(t, string(body), `"to_state":"UPLOADED"`)
+}
+
+func TestFileStreamRequiresExactlyOneID(t *testing.T) {
+	h := api.HandleFileStream(func(ctx context.Context, cID, bID, after string, limit int) ([]files.FileChange, string, error) {
+		t.Fatal("changes should not be fetched")
+		return nil, "", nil
+	}, func() <-chan struct{} { return nil }, time.Second, time.Second)
+
+	for _, query := range []string{"", "?collection_id=c1&bundle_id=b1"} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/files/stream"+query, nil))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	}
+}
+
+func TestFileStreamInvalidLastEventID(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/files/stream?bundle_id=b1", nil)
+	req.Header.Set("Last-Event-ID", "nope")
+
+	h := api.HandleFileStream(func(ctx context.Context, cID, bID, after string, limit int) ([]files.FileChange, string, error) {
+		return nil, "", store.ErrInvalidFileChangeID
+	}, func() <-chan struct{} { return nil }, time.Second, time.Second)
+
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	response, _ := io.ReadAll(rec.Body)
+	assert.Contains(t, string(response), "InvalidLastEventID")
+}
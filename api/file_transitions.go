@@ -0,0 +1,105 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	clientsidentity "github.com/ONSdigital/dp-api-clients-go/v2/identity"
+	auth "github.com/ONSdigital/dp-authorisation/v2/authorisation"
+	"github.com/ONSdigital/dp-files-api/files"
+	dprequest "github.com/ONSdigital/dp-net/v3/request"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+type TransitionFileStates func(ctx context.Context, transitions []files.StateTransition, allOrNothing bool) []files.TransitionResult
+
+type FileTransitions struct {
+	AllOrNothing bool                    `json:"all_or_nothing"`
+	Transitions  []files.StateTransition `json:"transitions"`
+}
+
+// HandleFileTransitions moves many files to new states in one request, responding with the outcome for each
+// transition in the order given
+func HandleFileTransitions(transitionFileStates TransitionFileStates, createFileEvents CreateFileEvents, authMiddleware auth.Middleware, idClient *clientsidentity.Client, maxSize int) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+
+		logData := log.Data{
+			"method": req.Method,
+		}
+
+		accessToken := strings.TrimPrefix(req.Header.Get(dprequest.AuthHeaderKey), dprequest.BearerPrefix)
+		if accessToken == "" {
+			log.Info(ctx, "authorisation failed: no authorisation header in request", log.Classification(log.ProtectiveMonitoring), logData)
+			writeError(w, buildGenericError("Unauthorised", "The user is unauthorised"), http.StatusUnauthorized)
+			return
+		}
+
+		authEntityData, err := getAuthEntityData(ctx, authMiddleware, idClient, accessToken, logData)
+		if err != nil {
+			log.Error(ctx, "failed to get auth entity data", err, logData)
+			if strings.Contains(err.Error(), "key id unknown or invalid") || strings.Contains(err.Error(), "jwt token is malformed") {
+				writeError(w, buildGenericError("Unauthorised", "the request was not authorised"), http.StatusUnauthorized)
+				return
+			}
+			writeError(w, buildGenericError("Forbidden", "the request was not authorised - check token and user's permissions"), http.StatusForbidden)
+			return
+		}
+		ctx = dprequest.SetCaller(ctx, authEntityData.EntityData.UserID)
+
+		identityType := log.USER
+		if authEntityData.IsServiceAuth {
+			identityType = log.SERVICE
+		}
+		logAuth := log.Auth(identityType, authEntityData.EntityData.UserID)
+
+		var ft FileTransitions
+		if err := json.NewDecoder(req.Body).Decode(&ft); err != nil {
+			writeError(w, buildErrors(err, "BadJsonEncoding"), http.StatusBadRequest)
+			return
+		}
+
+		if len(ft.Transitions) == 0 || len(ft.Transitions) > maxSize {
+			err := fmt.Errorf("a request must contain between 1 and %d transitions", maxSize)
+			writeError(w, buildErrors(err, "InvalidBatchSize"), http.StatusBadRequest)
+			return
+		}
+
+		events := make([]*files.FileEvent, 0, len(ft.Transitions))
+		for _, t := range ft.Transitions {
+			events = append(events, &files.FileEvent{
+				RequestedBy: &files.RequestedBy{
+					ID: authEntityData.EntityData.UserID,
+				},
+				Action:   files.ActionUpdate,
+				Resource: t.Path,
+			})
+		}
+		if err := createFileEvents(ctx, events); err != nil {
+			log.Error(ctx, "failed to create file events", err, log.Classification(log.ProtectiveMonitoring), logAuth, logData)
+			handleError(w, err)
+			return
+		}
+		log.Info(ctx, "successfully created file events for file state transitions", log.Classification(log.ProtectiveMonitoring), logAuth, logData)
+
+		results := transitionFileStates(ctx, ft.Transitions, ft.AllOrNothing)
+
+		response := files.TransitionResults{
+			Count: len(results),
+			Items: results,
+		}
+		for _, result := range results {
+			if result.Result == files.TransitionApplied {
+				response.Applied++
+			}
+		}
+
+		w.Header().Add("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}
+}
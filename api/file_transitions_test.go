@@ -0,0 +1,118 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ONSdigital/dp-authorisation/v2/authorisationtest"
+	"github.com/ONSdigital/dp-files-api/api"
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/dp-files-api/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileTransitionsReportsOutcomeForEachPath(t *testing.T) {
+	rec := httptest.NewRecorder()
+	body := bytes.NewBufferString(`{
+          "all_or_nothing": true,
+          "transitions": [
+            {"path": "data/one.csv", "state": "MOVED", "etag": "etag-1"},
+            {"path": "data/two.csv", "state": "MOVED", "etag": "etag-2"}
+          ]
+        }`)
+	req := httptest.NewRequest(http.MethodPost, "/files/transitions", body)
+	req.Header.Add("Authorization", authorisationtest.AdminJWTToken)
+
+	var requested []files.StateTransition
+	var atomic bool
+	transitionFunc := func(ctx context.Context, transitions []files.StateTransition, allOrNothing bool) []files.TransitionResult {
+		requested, atomic = transitions, allOrNothing
+		return []files.TransitionResult{
+			{Path: "data/one.csv", State: store.StateMoved, Result: files.TransitionApplied},
+			{Path: "data/two.csv", State: store.StateMoved, Result: files.TransitionFailed, Error: store.ErrFileStateMismatch.Error()},
+		}
+	}
+	var events []*files.FileEvent
+	createFileEventsFunc := func(ctx context.Context, e []*files.FileEvent) error {
+		events = e
+		return nil
+	}
+	authMock, identityClientMock, _ := setUpAuthServices()
+
+	h := api.HandleFileTransitions(transitionFunc, createFileEventsFunc, authMock, identityClientMock, 10)
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, atomic)
+	assert.Equal(t, []files.StateTransition{
+		{Path: "data/one.csv", State: store.StateMoved, Etag: "etag-1"},
+		{Path: "data/two.csv", State: store.StateMoved, Etag: "etag-2"},
+	}, requested)
+
+	var results files.TransitionResults
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&results))
+	assert.Equal(t, 2, results.Count)
+	assert.Equal(t, 1, results.Applied)
+	assert.Equal(t, store.ErrFileStateMismatch.Error(), results.Items[1].Error)
+
+	assert.Len(t, events, 2)
+	assert.Equal(t, files.ActionUpdate, events[0].Action)
+	assert.Equal(t, "data/two.csv", events[1].Resource)
+}
+
+func TestFileTransitionsRejectsInvalidBatchSize(t *testing.T) {
+	authMock, identityClientMock, _ := setUpAuthServices()
+	h := api.HandleFileTransitions(nil, nil, authMock, identityClientMock, 1)
+
+	for _, body := range []string{
+		`{"transitions": []}`,
+		`{"transitions": [{"path": "a.csv", "state": "MOVED"}, {"path": "b.csv", "state": "MOVED"}]}`,
+	} {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/files/transitions", bytes.NewBufferString(body))
+		req.Header.Add("Authorization", authorisationtest.AdminJWTToken)
+
+		h.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		response, _ := io.ReadAll(rec.Body)
+		assert.Contains(t, string(response), "InvalidBatchSize")
+	}
+}
+
+func TestFileTransitionsFileEventsFail(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/files/transitions", bytes.NewBufferString(`{"transitions": [{"path": "a.csv", "state": "MOVED"}]}`))
+	req.Header.Add("Authorization", authorisationtest.AdminJWTToken)
+
+	transitionFunc := func(ctx context.Context, transitions []files.StateTransition, allOrNothing bool) []files.TransitionResult {
+		t.Fatal("no transitions should be made")
+		return nil
+	}
+	createFileEventsFunc := func(ctx context.Context, e []*files.FileEvent) error {
+		return errors.New("audit failed")
+	}
+	authMock, identityClientMock, _ := setUpAuthServices()
+
+	h := api.HandleFileTransitions(transitionFunc, createFileEventsFunc, authMock, identityClientMock, 10)
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+}
+
+func TestFileTransitionsWithoutAuthorisation(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/files/transitions", bytes.NewBufferString(`{"transitions": []}`))
+
+	authMock, identityClientMock, _ := setUpAuthServices()
+	h := api.HandleFileTransitions(nil, nil, authMock, identityClientMock, 10)
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
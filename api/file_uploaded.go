@@ -45,6 +45,7 @@ func HandleMarkUploadComplete(markUploaded MarkUploadComplete, createFileEvent C
 			writeError(w, buildGenericError("Forbidden", "the request was not authorised - check token and user's permissions"), http.StatusForbidden)
 			return
 		}
+		ctx = dprequest.SetCaller(ctx, authEntityData.EntityData.UserID)
 
 		ec, err := getEtagChangeFromRequest(req)
 		if err != nil {
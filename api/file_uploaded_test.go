@@ -13,6 +13,8 @@ import (
 	"github.com/ONSdigital/dp-authorisation/v2/authorisationtest"
 	"github.com/ONSdigital/dp-files-api/api"
 	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/dp-files-api/store"
+	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -81,6 +83,16 @@ func TestValidateUploadComplete(t *testing.T) {
 			incomingJSON:             `{"": ""}`,
 			expectedErrorDescription: "Etag required",
 		},
+		{
+			name:                     "Validate that checksum_sha256 is hexadecimal",
+			incomingJSON:             `{"etag": "1234", "checksum_sha256": "ed7002b439e9ac845f22357d822bac1444730fbdb6016d3ec9432297b9ec9fzz"}`,
+			expectedErrorDescription: "ChecksumSHA256 hexadecimal",
+		},
+		{
+			name:                     "Validate that checksum_md5 is an MD5 hex digest",
+			incomingJSON:             `{"etag": "1234", "checksum_md5": "d41d8cd98f00b204"}`,
+			expectedErrorDescription: "ChecksumMD5 len",
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -213,3 +225,56 @@ func TestMarkUploadComplete_NoToken_Returns401(t *testing.T) {
 
 	assert.Equal(t, http.StatusUnauthorized, rec.Code)
 }
+
+func TestMarkUploadComplete_PassesChecksums(t *testing.T) {
+	rec := httptest.NewRecorder()
+	body := bytes.NewBufferString(`{"etag": "1234-asdfg-54321-qwerty", "checksum_md5": "D41D8CD98F00B204E9800998ECF8427E"}`)
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodPatch, "/files/meme.jpg", body), map[string]string{"path": "meme.jpg"})
+	req.Header.Add("Authorization", authorisationtest.AdminJWTToken)
+
+	authMiddlewareMock, identityClientMock, _ := setUpAuthServices()
+
+	var change files.FileEtagChange
+	h := api.HandleMarkUploadComplete(
+		func(ctx context.Context, metaData files.FileEtagChange) error {
+			change = metaData
+			return nil
+		},
+		func(ctx context.Context, event *files.FileEvent) error { return nil },
+		func(ctx context.Context, path string) (files.StoredRegisteredMetaData, error) {
+			return files.StoredRegisteredMetaData{Path: path}, nil
+		},
+		authMiddlewareMock,
+		identityClientMock,
+	)
+
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, files.FileEtagChange{Path: "meme.jpg", Etag: "1234-asdfg-54321-qwerty", ChecksumMD5: "d41d8cd98f00b204e9800998ecf8427e"}, change)
+}
+
+func TestMarkUploadComplete_ChecksumMismatch_Returns409(t *testing.T) {
+	rec := httptest.NewRecorder()
+	body := bytes.NewBufferString(`{"etag": "1234-asdfg-54321-qwerty"}`)
+	req := httptest.NewRequest(http.MethodPatch, "/files/meme.jpg", body)
+	req.Header.Add("Authorization", authorisationtest.AdminJWTToken)
+
+	authMiddlewareMock, identityClientMock, _ := setUpAuthServices()
+
+	h := api.HandleMarkUploadComplete(
+		func(ctx context.Context, metaData files.FileEtagChange) error { return store.ErrChecksumMismatch },
+		func(ctx context.Context, event *files.FileEvent) error { return nil },
+		func(ctx context.Context, path string) (files.StoredRegisteredMetaData, error) {
+			return files.StoredRegisteredMetaData{Path: path}, nil
+		},
+		authMiddlewareMock,
+		identityClientMock,
+	)
+
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	response, _ := io.ReadAll(rec.Body)
+	assert.Contains(t, string(response), "ChecksumMismatch")
+}
@@ -0,0 +1,29 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/gorilla/mux"
+)
+
+type GetFileVersions func(ctx context.Context, path string) (*files.FileVersionsList, error)
+
+type GetFileVersion func(ctx context.Context, path string, version int) (files.StoredRegisteredMetaData, error)
+
+func HandleGetFileVersions(getFileVersions GetFileVersions) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		versions, err := getFileVersions(req.Context(), mux.Vars(req)["path"])
+		if err != nil {
+			handleError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(versions); err != nil {
+			handleError(w, err)
+		}
+	}
+}
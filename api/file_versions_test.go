@@ -0,0 +1,73 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ONSdigital/dp-files-api/api"
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/dp-files-api/store"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetFileVersionsReturnsVersions(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/files/data/file.csv/versions", nil), map[string]string{"path": "data/file.csv"})
+
+	var requestedPath string
+	h := api.HandleGetFileVersions(func(ctx context.Context, path string) (*files.FileVersionsList, error) {
+		requestedPath = path
+		return &files.FileVersionsList{Path: path, Count: 2, Items: []files.FileVersion{
+			{Version: 1, Etag: "first", State: store.StateUploaded},
+			{Version: 2, Etag: "second", State: store.StatePublished},
+		}}, nil
+	})
+
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "data/file.csv", requestedPath)
+
+	versions := files.FileVersionsList{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&versions))
+	assert.Equal(t, 2, versions.Count)
+	assert.Equal(t, "first", versions.Items[0].Etag)
+	assert.Equal(t, 2, versions.Items[1].Version)
+}
+
+func TestGetFileVersionsFileNotRegistered(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/files/missing.csv/versions", nil), map[string]string{"path": "missing.csv"})
+
+	h := api.HandleGetFileVersions(func(ctx context.Context, path string) (*files.FileVersionsList, error) {
+		return nil, store.ErrFileNotRegistered
+	})
+
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	response, _ := io.ReadAll(rec.Body)
+	assert.Contains(t, string(response), "FileNotRegistered")
+}
+
+func TestGetFileVersionsHandlesUnexpectedError(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/files/file.csv/versions", nil), map[string]string{"path": "file.csv"})
+
+	h := api.HandleGetFileVersions(func(ctx context.Context, path string) (*files.FileVersionsList, error) {
+		return nil, errors.New("broken")
+	})
+
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	response, _ := io.ReadAll(rec.Body)
+	assert.Contains(t, string(response), "InternalError")
+}
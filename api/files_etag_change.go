@@ -1,13 +1,22 @@
 package api
 
 import (
+	"strings"
+
 	"github.com/ONSdigital/dp-files-api/files"
 )
 
 type EtagChange struct {
-	Etag string `json:"etag" validate:"required"`
+	Etag           string `json:"etag" validate:"required"`
+	ChecksumSHA256 string `json:"checksum_sha256,omitempty" validate:"omitempty,len=64,hexadecimal"`
+	ChecksumMD5    string `json:"checksum_md5,omitempty" validate:"omitempty,len=32,hexadecimal"`
 }
 
 func generateFileEtagChange(m EtagChange, path string) files.FileEtagChange {
-	return files.FileEtagChange{Path: path, Etag: m.Etag}
+	return files.FileEtagChange{
+		Path:           path,
+		Etag:           m.Etag,
+		ChecksumSHA256: strings.ToLower(m.ChecksumSHA256),
+		ChecksumMD5:    strings.ToLower(m.ChecksumMD5),
+	}
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -16,7 +17,14 @@ import (
 	"github.com/ONSdigital/log.go/v2/log"
 )
 
-type GetFileEvents func(ctx context.Context, limit, offset int, path string, after, before *time.Time) (*files.EventsList, error)
+const (
+	sortAscending  = "asc"
+	sortDescending = "desc"
+)
+
+var fileEventActions = []string{files.ActionCreate, files.ActionRead, files.ActionUpdate, files.ActionDelete}
+
+type GetFileEvents func(ctx context.Context, query store.FileEventsQuery) (*files.EventsList, error)
 
 func HandlerGetFileEvents(getFileEvents GetFileEvents, createFileEvent CreateFileEvent, authMiddleware auth.Middleware, idClient *clientsidentity.Client) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
@@ -51,15 +59,19 @@ func HandlerGetFileEvents(getFileEvents GetFileEvents, createFileEvent CreateFil
 			return
 		}
 
-		path := req.URL.Query().Get("path")
+		filter, err := parseFileEventFilterParams(req)
+		if err != nil {
+			writeError(w, buildErrors(err, "InvalidRequest"), http.StatusBadRequest)
+			return
+		}
 
-		after, before, err := parseDateTimeParams(req)
+		oldestFirst, err := parseSortParam(req)
 		if err != nil {
 			writeError(w, buildErrors(err, "InvalidRequest"), http.StatusBadRequest)
 			return
 		}
 
-		eventsList, err := getFileEvents(ctx, limit, offset, path, after, before)
+		eventsList, err := getFileEvents(ctx, store.FileEventsQuery{Filter: filter, OldestFirst: oldestFirst, Limit: limit, Offset: offset})
 		if err != nil {
 			handleError(w, err)
 			return
@@ -122,3 +134,39 @@ func parseDateTimeParams(req *http.Request) (after, before *time.Time, err error
 
 	return after, before, nil
 }
+
+// parseFileEventFilterParams reads the file event filters from the query string. Action must be one of the file event
+// actions, and after and before RFC 3339 times.
+func parseFileEventFilterParams(req *http.Request) (store.FileEventFilter, error) {
+	params := req.URL.Query()
+	filter := store.FileEventFilter{
+		Path:          params.Get("path"),
+		PathPrefix:    params.Get("path_prefix"),
+		Action:        params.Get("action"),
+		RequestedByID: params.Get("requested_by"),
+		CollectionID:  params.Get("collection_id"),
+		BundleID:      params.Get("bundle_id"),
+		DatasetID:     params.Get("dataset_id"),
+		Edition:       params.Get("edition"),
+	}
+
+	if filter.Action != "" && !slices.Contains(fileEventActions, filter.Action) {
+		return filter, store.ErrInvalidPagination
+	}
+
+	var err error
+	filter.After, filter.Before, err = parseDateTimeParams(req)
+	return filter, err
+}
+
+// parseSortParam reports whether the oldest events are asked for first. Newest first is the default.
+func parseSortParam(req *http.Request) (oldestFirst bool, err error) {
+	switch req.URL.Query().Get("sort") {
+	case "", sortDescending:
+		return false, nil
+	case sortAscending:
+		return true, nil
+	default:
+		return false, store.ErrInvalidPagination
+	}
+}
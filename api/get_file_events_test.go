@@ -6,7 +6,6 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
-	"time"
 
 	"github.com/ONSdigital/dp-authorisation/v2/authorisationtest"
 	"github.com/ONSdigital/dp-files-api/api"
@@ -31,7 +30,7 @@ func TestGetFileEventsSuccess(t *testing.T) {
 	authMiddlewareMock, identityClientMock, _ := setUpAuthServices()
 
 	h := api.HandlerGetFileEvents(
-		func(ctx context.Context, limit, offset int, path string, after, before *time.Time) (*files.EventsList, error) {
+		func(ctx context.Context, query store.FileEventsQuery) (*files.EventsList, error) {
 			return mockEventsList, nil
 		},
 		func(ctx context.Context, event *files.FileEvent) error { return nil },
@@ -53,9 +52,9 @@ func TestGetFileEventsWithLimitAndOffset(t *testing.T) {
 	authMiddlewareMock, identityClientMock, _ := setUpAuthServices()
 
 	h := api.HandlerGetFileEvents(
-		func(ctx context.Context, limit, offset int, path string, after, before *time.Time) (*files.EventsList, error) {
-			assert.Equal(t, 10, limit)
-			assert.Equal(t, 5, offset)
+		func(ctx context.Context, query store.FileEventsQuery) (*files.EventsList, error) {
+			assert.Equal(t, 10, query.Limit)
+			assert.Equal(t, 5, query.Offset)
 			return &files.EventsList{Count: 0, Limit: 10, Offset: 5, TotalCount: 0, Items: []files.FileEvent{}}, nil
 		},
 		func(ctx context.Context, event *files.FileEvent) error { return nil },
@@ -76,8 +75,8 @@ func TestGetFileEventsWithPath(t *testing.T) {
 	authMiddlewareMock, identityClientMock, _ := setUpAuthServices()
 
 	h := api.HandlerGetFileEvents(
-		func(ctx context.Context, limit, offset int, path string, after, before *time.Time) (*files.EventsList, error) {
-			assert.Equal(t, "test-file.csv", path)
+		func(ctx context.Context, query store.FileEventsQuery) (*files.EventsList, error) {
+			assert.Equal(t, "test-file.csv", query.Filter.Path)
 			return &files.EventsList{Count: 0, Limit: 20, Offset: 0, TotalCount: 0, Items: []files.FileEvent{}}, nil
 		},
 		func(ctx context.Context, event *files.FileEvent) error { return nil },
@@ -98,11 +97,11 @@ func TestGetFileEventsWithAfterAndBefore(t *testing.T) {
 	authMiddlewareMock, identityClientMock, _ := setUpAuthServices()
 
 	h := api.HandlerGetFileEvents(
-		func(ctx context.Context, limit, offset int, path string, after, before *time.Time) (*files.EventsList, error) {
-			assert.NotNil(t, after)
-			assert.NotNil(t, before)
-			assert.Equal(t, 2025, after.Year())
-			assert.Equal(t, 2025, before.Year())
+		func(ctx context.Context, query store.FileEventsQuery) (*files.EventsList, error) {
+			assert.NotNil(t, query.Filter.After)
+			assert.NotNil(t, query.Filter.Before)
+			assert.Equal(t, 2025, query.Filter.After.Year())
+			assert.Equal(t, 2025, query.Filter.Before.Year())
 			return &files.EventsList{Count: 0, Limit: 20, Offset: 0, TotalCount: 0, Items: []files.FileEvent{}}, nil
 		},
 		func(ctx context.Context, event *files.FileEvent) error { return nil },
@@ -123,7 +122,7 @@ func TestGetFileEventsWithInvalidLimit(t *testing.T) {
 	authMiddlewareMock, identityClientMock, _ := setUpAuthServices()
 
 	h := api.HandlerGetFileEvents(
-		func(ctx context.Context, limit, offset int, path string, after, before *time.Time) (*files.EventsList, error) {
+		func(ctx context.Context, query store.FileEventsQuery) (*files.EventsList, error) {
 			return nil, nil
 		},
 		func(ctx context.Context, event *files.FileEvent) error { return nil },
@@ -144,7 +143,7 @@ func TestGetFileEventsWithLimitTooLarge(t *testing.T) {
 	authMiddlewareMock, identityClientMock, _ := setUpAuthServices()
 
 	h := api.HandlerGetFileEvents(
-		func(ctx context.Context, limit, offset int, path string, after, before *time.Time) (*files.EventsList, error) {
+		func(ctx context.Context, query store.FileEventsQuery) (*files.EventsList, error) {
 			return nil, nil
 		},
 		func(ctx context.Context, event *files.FileEvent) error { return nil },
@@ -165,7 +164,7 @@ func TestGetFileEventsWithNegativeLimit(t *testing.T) {
 	authMiddlewareMock, identityClientMock, _ := setUpAuthServices()
 
 	h := api.HandlerGetFileEvents(
-		func(ctx context.Context, limit, offset int, path string, after, before *time.Time) (*files.EventsList, error) {
+		func(ctx context.Context, query store.FileEventsQuery) (*files.EventsList, error) {
 			return nil, nil
 		},
 		func(ctx context.Context, event *files.FileEvent) error { return nil },
@@ -186,7 +185,7 @@ func TestGetFileEventsWithInvalidOffset(t *testing.T) {
 	authMiddlewareMock, identityClientMock, _ := setUpAuthServices()
 
 	h := api.HandlerGetFileEvents(
-		func(ctx context.Context, limit, offset int, path string, after, before *time.Time) (*files.EventsList, error) {
+		func(ctx context.Context, query store.FileEventsQuery) (*files.EventsList, error) {
 			return nil, nil
 		},
 		func(ctx context.Context, event *files.FileEvent) error { return nil },
@@ -207,7 +206,7 @@ func TestGetFileEventsWithNegativeOffset(t *testing.T) {
 	authMiddlewareMock, identityClientMock, _ := setUpAuthServices()
 
 	h := api.HandlerGetFileEvents(
-		func(ctx context.Context, limit, offset int, path string, after, before *time.Time) (*files.EventsList, error) {
+		func(ctx context.Context, query store.FileEventsQuery) (*files.EventsList, error) {
 			return nil, nil
 		},
 		func(ctx context.Context, event *files.FileEvent) error { return nil },
@@ -228,7 +227,7 @@ func TestGetFileEventsWithInvalidAfterDatetime(t *testing.T) {
 	authMiddlewareMock, identityClientMock, _ := setUpAuthServices()
 
 	h := api.HandlerGetFileEvents(
-		func(ctx context.Context, limit, offset int, path string, after, before *time.Time) (*files.EventsList, error) {
+		func(ctx context.Context, query store.FileEventsQuery) (*files.EventsList, error) {
 			return nil, nil
 		},
 		func(ctx context.Context, event *files.FileEvent) error { return nil },
@@ -241,6 +240,65 @@ func TestGetFileEventsWithInvalidAfterDatetime(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, rec.Code)
 }
 
+func TestGetFileEventsWithFileAndRequesterFilters(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/file-events?path_prefix=data/&action=UPDATE&requested_by=user123&collection_id=collection-1&bundle_id=bundle-1&dataset_id=cpih&edition=2026&sort=asc", http.NoBody)
+	req.Header.Add("Authorization", authorisationtest.AdminJWTToken)
+
+	authMiddlewareMock, identityClientMock, _ := setUpAuthServices()
+
+	h := api.HandlerGetFileEvents(
+		func(ctx context.Context, query store.FileEventsQuery) (*files.EventsList, error) {
+			assert.Equal(t, store.FileEventsQuery{
+				Filter: store.FileEventFilter{
+					PathPrefix:    "data/",
+					Action:        files.ActionUpdate,
+					RequestedByID: "user123",
+					CollectionID:  "collection-1",
+					BundleID:      "bundle-1",
+					DatasetID:     "cpih",
+					Edition:       "2026",
+				},
+				OldestFirst: true,
+				Limit:       20,
+			}, query)
+			return &files.EventsList{Limit: 20, Items: []files.FileEvent{}}, nil
+		},
+		func(ctx context.Context, event *files.FileEvent) error { return nil },
+		authMiddlewareMock,
+		identityClientMock,
+	)
+
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestGetFileEventsWithInvalidFilterOrSort(t *testing.T) {
+	for _, query := range []string{"action=PUBLISH", "action=read", "sort=newest"} {
+		t.Run(query, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/file-events?"+query, http.NoBody)
+			req.Header.Add("Authorization", authorisationtest.AdminJWTToken)
+
+			authMiddlewareMock, identityClientMock, _ := setUpAuthServices()
+
+			h := api.HandlerGetFileEvents(
+				func(ctx context.Context, query store.FileEventsQuery) (*files.EventsList, error) {
+					return nil, nil
+				},
+				func(ctx context.Context, event *files.FileEvent) error { return nil },
+				authMiddlewareMock,
+				identityClientMock,
+			)
+
+			h.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		})
+	}
+}
+
 func TestGetFileEventsWithInvalidBeforeDatetime(t *testing.T) {
 	rec := httptest.NewRecorder()
 	req := httptest.NewRequest(http.MethodGet, "/file-events?before=invalid-datetime", http.NoBody)
@@ -249,7 +307,7 @@ func TestGetFileEventsWithInvalidBeforeDatetime(t *testing.T) {
 	authMiddlewareMock, identityClientMock, _ := setUpAuthServices()
 
 	h := api.HandlerGetFileEvents(
-		func(ctx context.Context, limit, offset int, path string, after, before *time.Time) (*files.EventsList, error) {
+		func(ctx context.Context, query store.FileEventsQuery) (*files.EventsList, error) {
 			return nil, nil
 		},
 		func(ctx context.Context, event *files.FileEvent) error { return nil },
@@ -270,7 +328,7 @@ func TestGetFileEventsWithPathNotFound(t *testing.T) {
 	authMiddlewareMock, identityClientMock, _ := setUpAuthServices()
 
 	h := api.HandlerGetFileEvents(
-		func(ctx context.Context, limit, offset int, path string, after, before *time.Time) (*files.EventsList, error) {
+		func(ctx context.Context, query store.FileEventsQuery) (*files.EventsList, error) {
 			return nil, store.ErrPathNotFound
 		},
 		func(ctx context.Context, event *files.FileEvent) error { return nil },
@@ -291,7 +349,7 @@ func TestGetFileEventsWithStoreError(t *testing.T) {
 	authMiddlewareMock, identityClientMock, _ := setUpAuthServices()
 
 	h := api.HandlerGetFileEvents(
-		func(ctx context.Context, limit, offset int, path string, after, before *time.Time) (*files.EventsList, error) {
+		func(ctx context.Context, query store.FileEventsQuery) (*files.EventsList, error) {
 			return nil, errors.New("database error")
 		},
 		func(ctx context.Context, event *files.FileEvent) error { return nil },
@@ -312,12 +370,12 @@ func TestGetFileEventsDefaultPagination(t *testing.T) {
 	authMiddlewareMock, identityClientMock, _ := setUpAuthServices()
 
 	h := api.HandlerGetFileEvents(
-		func(ctx context.Context, limit, offset int, path string, after, before *time.Time) (*files.EventsList, error) {
-			assert.Equal(t, 20, limit)
-			assert.Equal(t, 0, offset)
-			assert.Nil(t, after)
-			assert.Nil(t, before)
-			assert.Equal(t, "", path)
+		func(ctx context.Context, query store.FileEventsQuery) (*files.EventsList, error) {
+			assert.Equal(t, 20, query.Limit)
+			assert.Equal(t, 0, query.Offset)
+			assert.Nil(t, query.Filter.After)
+			assert.Nil(t, query.Filter.Before)
+			assert.Equal(t, "", query.Filter.Path)
 			return &files.EventsList{Count: 0, Limit: 20, Offset: 0, TotalCount: 0, Items: []files.FileEvent{}}, nil
 		},
 		func(ctx context.Context, event *files.FileEvent) error { return nil },
@@ -338,12 +396,12 @@ func TestGetFileEventsWithAllQueryParams(t *testing.T) {
 	authMiddlewareMock, identityClientMock, _ := setUpAuthServices()
 
 	h := api.HandlerGetFileEvents(
-		func(ctx context.Context, limit, offset int, path string, after, before *time.Time) (*files.EventsList, error) {
-			assert.Equal(t, 50, limit)
-			assert.Equal(t, 10, offset)
-			assert.Equal(t, "data.csv", path)
-			assert.NotNil(t, after)
-			assert.NotNil(t, before)
+		func(ctx context.Context, query store.FileEventsQuery) (*files.EventsList, error) {
+			assert.Equal(t, 50, query.Limit)
+			assert.Equal(t, 10, query.Offset)
+			assert.Equal(t, "data.csv", query.Filter.Path)
+			assert.NotNil(t, query.Filter.After)
+			assert.NotNil(t, query.Filter.Before)
 			return &files.EventsList{Count: 0, Limit: 50, Offset: 10, TotalCount: 0, Items: []files.FileEvent{}}, nil
 		},
 		func(ctx context.Context, event *files.FileEvent) error { return nil },
@@ -365,7 +423,7 @@ func TestGetFileEvents_AuditRecordCreated(t *testing.T) {
 	authMiddlewareMock, identityClientMock, _ := setUpAuthServices()
 
 	h := api.HandlerGetFileEvents(
-		func(ctx context.Context, limit, offset int, path string, after, before *time.Time) (*files.EventsList, error) {
+		func(ctx context.Context, query store.FileEventsQuery) (*files.EventsList, error) {
 			return &files.EventsList{Count: 0, Limit: 20, Offset: 0, TotalCount: 0, Items: []files.FileEvent{}}, nil
 		},
 		func(ctx context.Context, event *files.FileEvent) error {
@@ -394,7 +452,7 @@ func TestGetFileEvents_AuditRecordFailure_Returns500(t *testing.T) {
 	authMiddlewareMock, identityClientMock, _ := setUpAuthServices()
 
 	h := api.HandlerGetFileEvents(
-		func(ctx context.Context, limit, offset int, path string, after, before *time.Time) (*files.EventsList, error) {
+		func(ctx context.Context, query store.FileEventsQuery) (*files.EventsList, error) {
 			return &files.EventsList{Count: 0, Limit: 20, Offset: 0, TotalCount: 0, Items: []files.FileEvent{}}, nil
 		},
 		func(ctx context.Context, event *files.FileEvent) error {
@@ -416,7 +474,7 @@ func TestGetFileEvents_NoToken_Returns401(t *testing.T) {
 	authMiddlewareMock, identityClientMock, _ := setUpAuthServices()
 
 	h := api.HandlerGetFileEvents(
-		func(ctx context.Context, limit, offset int, path string, after, before *time.Time) (*files.EventsList, error) {
+		func(ctx context.Context, query store.FileEventsQuery) (*files.EventsList, error) {
 			return &files.EventsList{Count: 0, Limit: 20, Offset: 0, TotalCount: 0, Items: []files.FileEvent{}}, nil
 		},
 		func(ctx context.Context, event *files.FileEvent) error { return nil },
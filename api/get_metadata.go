@@ -3,12 +3,16 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	clientsidentity "github.com/ONSdigital/dp-api-clients-go/v2/identity"
 	auth "github.com/ONSdigital/dp-authorisation/v2/authorisation"
 	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/dp-files-api/store"
 	dprequest "github.com/ONSdigital/dp-net/v3/request"
 	"github.com/ONSdigital/log.go/v2/log"
 	"github.com/gorilla/mux"
@@ -18,7 +22,9 @@ type GetFileMetadata func(ctx context.Context, path string) (files.StoredRegiste
 
 type GetFileMetadataWeb func(ctx context.Context, path string) (files.StoredRegisteredMetaData, error)
 
-func HandleGetFileMetadataWithAuth(getMetadata GetFileMetadata, authMiddleware auth.Middleware, idClient *clientsidentity.Client, permissionsChecker auth.PermissionsChecker) http.HandlerFunc {
+// HandleGetFileMetadataWithAuth returns the metadata of a file, or of one of its versions, to users and services
+// permitted to read it. Only the requester's own cache may keep it, for movedMaxAge once the file has been moved.
+func HandleGetFileMetadataWithAuth(getMetadata GetFileMetadata, getFileVersion GetFileVersion, authMiddleware auth.Middleware, idClient *clientsidentity.Client, permissionsChecker auth.PermissionsChecker, movedMaxAge time.Duration) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		vars := mux.Vars(req)
 		w.Header().Add("Content-Type", "application/json")
@@ -46,7 +52,17 @@ func HandleGetFileMetadataWithAuth(getMetadata GetFileMetadata, authMiddleware a
 			return
 		}
 
-		metadata, err := getMetadata(req.Context(), vars["path"])
+		var metadata files.StoredRegisteredMetaData
+		if v := req.URL.Query().Get("version"); v != "" {
+			version, convErr := strconv.Atoi(v)
+			if convErr != nil || version < 1 {
+				handleError(w, store.ErrInvalidFileVersion)
+				return
+			}
+			metadata, err = getFileVersion(req.Context(), vars["path"], version)
+		} else {
+			metadata, err = getMetadata(req.Context(), vars["path"])
+		}
 		if err != nil {
 			log.Error(req.Context(), "unable to retrieve metadata", err)
 			handleError(w, err)
@@ -66,6 +82,9 @@ func HandleGetFileMetadataWithAuth(getMetadata GetFileMetadata, authMiddleware a
 		}
 
 		if checkUserPermission(req, logData, "static-files:read", permissionAttrs, permissionsChecker, authEntityData.EntityData) {
+			if writeMetadataCacheHeaders(w, req, metadata, movedMaxAge, true) {
+				return
+			}
 			if err := json.NewEncoder(w).Encode(metadata); err != nil {
 				handleError(w, err)
 				return
@@ -86,16 +105,26 @@ func HandleGetFileMetadataWithAuth(getMetadata GetFileMetadata, authMiddleware a
 	}
 }
 
-func HandleGetFileMetadata(getMetadata GetFileMetadataWeb) http.HandlerFunc {
+// HandleGetFileMetadata returns the metadata of a published file to anyone. Shared caches may keep it for movedMaxAge
+// once the file has been moved.
+func HandleGetFileMetadata(getMetadata GetFileMetadataWeb, movedMaxAge time.Duration) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		vars := mux.Vars(req)
 		w.Header().Add("Content-Type", "application/json")
 		metadata, err := getMetadata(req.Context(), vars["path"])
+		if errors.Is(err, store.ErrFileWithdrawn) {
+			writeWithdrawalNotice(w, metadata)
+			return
+		}
 		if err != nil {
 			handleError(w, err)
 			return
 		}
 
+		if writeMetadataCacheHeaders(w, req, metadata, movedMaxAge, false) {
+			return
+		}
+
 		if err := json.NewEncoder(w).Encode(metadata); err != nil {
 			handleError(w, err)
 			return
@@ -104,3 +133,17 @@ func HandleGetFileMetadata(getMetadata GetFileMetadataWeb) http.HandlerFunc {
 		w.WriteHeader(http.StatusOK)
 	}
 }
+
+// writeWithdrawalNotice tells web users that a file is gone, and when and why it was withdrawn
+func writeWithdrawalNotice(w http.ResponseWriter, metadata files.StoredRegisteredMetaData) {
+	notice := files.WithdrawalNotice{
+		Path:   metadata.Path,
+		Reason: metadata.WithdrawalReason,
+	}
+	if metadata.WithdrawnAt != nil {
+		notice.WithdrawnAt = *metadata.WithdrawnAt
+	}
+
+	w.WriteHeader(http.StatusGone)
+	_ = json.NewEncoder(w).Encode(notice)
+}
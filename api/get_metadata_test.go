@@ -2,15 +2,18 @@ package api_test
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/ONSdigital/dp-authorisation/v2/authorisationtest"
 	"github.com/ONSdigital/dp-files-api/api"
 	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/dp-files-api/store"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -19,7 +22,7 @@ func TestGetFileMetadataHandlesUnexpectedError(t *testing.T) {
 	req := httptest.NewRequest(http.MethodGet, "/files/path.jpg", http.NoBody)
 	h := api.HandleGetFileMetadata(func(ctx context.Context, path string) (files.StoredRegisteredMetaData, error) {
 		return files.StoredRegisteredMetaData{}, errors.New("broken")
-	})
+	}, time.Hour)
 	h.ServeHTTP(rec, req)
 
 	assert.Equal(t, http.StatusInternalServerError, rec.Code)
@@ -27,6 +30,26 @@ func TestGetFileMetadataHandlesUnexpectedError(t *testing.T) {
 	assert.Contains(t, string(response), "InternalError")
 }
 
+func TestGetFileMetadataReturnsGoneForWithdrawnFile(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/files/path.jpg", http.NoBody)
+	withdrawnAt := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+	h := api.HandleGetFileMetadata(func(ctx context.Context, path string) (files.StoredRegisteredMetaData, error) {
+		return files.StoredRegisteredMetaData{
+			Path:             "path.jpg",
+			State:            store.StateWithdrawn,
+			WithdrawnAt:      &withdrawnAt,
+			WithdrawalReason: "released in error",
+		}, store.ErrFileWithdrawn
+	}, time.Hour)
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusGone, rec.Code)
+	var notice files.WithdrawalNotice
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&notice))
+	assert.Equal(t, files.WithdrawalNotice{Path: "path.jpg", WithdrawnAt: withdrawnAt, Reason: "released in error"}, notice)
+}
+
 func TestGetFileMetadataWithAuthSuccessful(t *testing.T) {
 	rec := httptest.NewRecorder()
 	req := httptest.NewRequest(http.MethodGet, "/files/path.jpg", http.NoBody)
@@ -36,7 +59,7 @@ func TestGetFileMetadataWithAuthSuccessful(t *testing.T) {
 
 	h := api.HandleGetFileMetadataWithAuth(func(ctx context.Context, path string) (files.StoredRegisteredMetaData, error) {
 		return files.StoredRegisteredMetaData{Path: "/files/path.jpg"}, nil
-	}, authMiddlewareMock, identityClientMock, permissionsMock)
+	}, nil, authMiddlewareMock, identityClientMock, permissionsMock, time.Hour)
 	h.ServeHTTP(rec, req)
 
 	assert.Equal(t, http.StatusOK, rec.Code)
@@ -52,7 +75,7 @@ func TestGetFileMetadataWithAuthUnauthorised(t *testing.T) {
 
 	h := api.HandleGetFileMetadataWithAuth(func(ctx context.Context, path string) (files.StoredRegisteredMetaData, error) {
 		return files.StoredRegisteredMetaData{Path: "/files/path.jpg"}, nil
-	}, authMiddlewareMock, identityClientMock, permissionsMock)
+	}, nil, authMiddlewareMock, identityClientMock, permissionsMock, time.Hour)
 	h.ServeHTTP(rec, req)
 
 	assert.Equal(t, http.StatusUnauthorized, rec.Code)
@@ -69,10 +92,66 @@ func TestGetFileMetadataWithAuthForbidden(t *testing.T) {
 
 	h := api.HandleGetFileMetadataWithAuth(func(ctx context.Context, path string) (files.StoredRegisteredMetaData, error) {
 		return files.StoredRegisteredMetaData{Path: "/files/path.jpg"}, nil
-	}, authMiddlewareMock, identityClientMock, permissionsMock)
+	}, nil, authMiddlewareMock, identityClientMock, permissionsMock, time.Hour)
 	h.ServeHTTP(rec, req)
 
 	assert.Equal(t, http.StatusForbidden, rec.Code)
 	response, _ := io.ReadAll(rec.Body)
 	assert.Contains(t, string(response), "the request was not authorised - check token and user's permissions")
 }
+
+func TestGetFileMetadataWithAuthReturnsRequestedVersion(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/files/path.jpg?version=2", http.NoBody)
+	req.Header.Add("Authorization", authorisationtest.AdminJWTToken)
+
+	authMiddlewareMock, identityClientMock, permissionsMock := setUpAuthServices()
+
+	var requestedVersion int
+	h := api.HandleGetFileMetadataWithAuth(func(ctx context.Context, path string) (files.StoredRegisteredMetaData, error) {
+		return files.StoredRegisteredMetaData{}, errors.New("current version should not be read")
+	}, func(ctx context.Context, path string, version int) (files.StoredRegisteredMetaData, error) {
+		requestedVersion = version
+		return files.StoredRegisteredMetaData{Path: "/files/path.jpg", Etag: "second", Version: version}, nil
+	}, authMiddlewareMock, identityClientMock, permissionsMock, time.Hour)
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, 2, requestedVersion)
+	response, _ := io.ReadAll(rec.Body)
+	assert.Contains(t, string(response), `"etag":"second"`)
+}
+
+func TestGetFileMetadataWithAuthRejectsInvalidVersion(t *testing.T) {
+	authMiddlewareMock, identityClientMock, permissionsMock := setUpAuthServices()
+
+	for _, version := range []string{"0", "-1", "latest"} {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/files/path.jpg?version="+version, http.NoBody)
+		req.Header.Add("Authorization", authorisationtest.AdminJWTToken)
+
+		h := api.HandleGetFileMetadataWithAuth(nil, nil, authMiddlewareMock, identityClientMock, permissionsMock, time.Hour)
+		h.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code, "version %s", version)
+		response, _ := io.ReadAll(rec.Body)
+		assert.Contains(t, string(response), "InvalidFileVersion")
+	}
+}
+
+func TestGetFileMetadataWithAuthVersionNotFound(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/files/path.jpg?version=7", http.NoBody)
+	req.Header.Add("Authorization", authorisationtest.AdminJWTToken)
+
+	authMiddlewareMock, identityClientMock, permissionsMock := setUpAuthServices()
+
+	h := api.HandleGetFileMetadataWithAuth(nil, func(ctx context.Context, path string, version int) (files.StoredRegisteredMetaData, error) {
+		return files.StoredRegisteredMetaData{}, store.ErrFileVersionNotFound
+	}, authMiddlewareMock, identityClientMock, permissionsMock, time.Hour)
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	response, _ := io.ReadAll(rec.Body)
+	assert.Contains(t, string(response), "FileVersionNotFound")
+}
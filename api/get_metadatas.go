@@ -5,12 +5,24 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
 
 	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/dp-files-api/store"
 	"github.com/ONSdigital/log.go/v2/log"
 )
 
-type GetFilesMetadata func(ctx context.Context, collectionID, bundleID string) ([]files.StoredRegisteredMetaData, error)
+const (
+	defaultFilesLimit = 20
+	maxFilesLimit     = 1000
+)
+
+var fileStates = []string{store.StateCreated, store.StateUploaded, store.StatePublished, store.StateMoved, store.StateWithdrawn}
+
+type GetFilesMetadata func(ctx context.Context, query store.FilesQuery) (*files.FilesList, error)
 
 func HandlerGetFilesMetadata(getFilesMetadata GetFilesMetadata) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
@@ -29,7 +41,15 @@ func HandlerGetFilesMetadata(getFilesMetadata GetFilesMetadata) http.HandlerFunc
 			return
 		}
 
-		fm, err := getFilesMetadata(req.Context(), collectionID, bundleID)
+		query, err := parseFilesQuery(req.URL.Query())
+		if err != nil {
+			handleError(w, err)
+			return
+		}
+		query.CollectionID = collectionID
+		query.BundleID = bundleID
+
+		fl, err := getFilesMetadata(req.Context(), query)
 		if err != nil {
 			idType := "collection"
 			idValue := collectionID
@@ -42,35 +62,73 @@ func HandlerGetFilesMetadata(getFilesMetadata GetFilesMetadata) http.HandlerFunc
 			return
 		}
 
-		fc := filesCollectionFromMetadata(fm)
-		if err := respondWithFilesCollectionJSON(w, fc); err != nil {
+		w.Header().Add("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(fl); err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 		}
 	}
 }
 
-type FilesCollection struct {
-	Count      int64                            `json:"count"`
-	Limit      int64                            `json:"limit"`
-	Offset     int64                            `json:"offset"`
-	TotalCount int64                            `json:"total_count"`
-	Items      []files.StoredRegisteredMetaData `json:"items"`
-}
+// parseFilesQuery reads the page and filters asked for from the query parameters. The files are only paginated when
+// a limit or cursor is given, so callers that list every file in a collection or bundle still get them all. Pages are
+// taken either by cursor or by offset.
+func parseFilesQuery(params url.Values) (store.FilesQuery, error) {
+	query := store.FilesQuery{
+		State:      params.Get("state"),
+		Type:       params.Get("type"),
+		PathPrefix: params.Get("path_prefix"),
+		Cursor:     params.Get("cursor"),
+	}
 
-func filesCollectionFromMetadata(f []files.StoredRegisteredMetaData) FilesCollection {
-	count := int64(len(f))
-	fc := FilesCollection{
-		Count:      count,
-		Limit:      count,
-		Offset:     0,
-		TotalCount: count,
-		Items:      f,
+	if limit := params.Get("limit"); limit != "" {
+		val, err := strconv.Atoi(limit)
+		if err != nil || val < 1 || val > maxFilesLimit {
+			return query, store.ErrInvalidLimit
+		}
+		query.Limit = val
+	} else if query.Cursor != "" {
+		query.Limit = defaultFilesLimit
+	}
+
+	if offset := params.Get("offset"); offset != "" {
+		val, err := strconv.Atoi(offset)
+		if err != nil || val < 0 || query.Cursor != "" {
+			return query, store.ErrInvalidOffset
+		}
+		query.Offset = val
+	}
+
+	if query.State != "" && !slices.Contains(fileStates, query.State) {
+		return query, store.ErrInvalidStateFilter
+	}
+
+	if isPublishable := params.Get("is_publishable"); isPublishable != "" {
+		val, err := strconv.ParseBool(isPublishable)
+		if err != nil {
+			return query, store.ErrInvalidIsPublishable
+		}
+		query.IsPublishable = &val
+	}
+
+	times := []struct {
+		name  string
+		field **time.Time
+		err   error
+	}{
+		{"created_after", &query.CreatedAfter, store.ErrInvalidCreatedAfter},
+		{"created_before", &query.CreatedBefore, store.ErrInvalidCreatedBefore},
+		{"modified_after", &query.ModifiedAfter, store.ErrInvalidModifiedAfter},
+		{"modified_before", &query.ModifiedBefore, store.ErrInvalidModifiedBefore},
+	}
+	for _, tm := range times {
+		if value := params.Get(tm.name); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return query, tm.err
+			}
+			*tm.field = &t
+		}
 	}
-	return fc
-}
 
-func respondWithFilesCollectionJSON(w http.ResponseWriter, fc FilesCollection) error {
-	w.Header().Add("Content-Type", "application/json")
-	err := json.NewEncoder(w).Encode(fc)
-	return err
+	return query, nil
 }
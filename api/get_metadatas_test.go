@@ -2,13 +2,16 @@ package api_test
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/ONSdigital/dp-files-api/api"
 	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/dp-files-api/store"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -16,8 +19,8 @@ func TestGetFilesMetadataWhenNoIDsProvided(t *testing.T) {
 	rec := httptest.NewRecorder()
 	req := httptest.NewRequest(http.MethodGet, "/files", http.NoBody)
 
-	h := api.HandlerGetFilesMetadata(func(ctx context.Context, collectionID, bundleID string) ([]files.StoredRegisteredMetaData, error) {
-		return []files.StoredRegisteredMetaData{}, nil
+	h := api.HandlerGetFilesMetadata(func(ctx context.Context, query store.FilesQuery) (*files.FilesList, error) {
+		return &files.FilesList{}, nil
 	})
 
 	h.ServeHTTP(rec, req)
@@ -32,10 +35,10 @@ func TestGetFilesMetadataWithCollectionID(t *testing.T) {
 	calledWithCollection := ""
 	calledWithBundle := ""
 
-	h := api.HandlerGetFilesMetadata(func(ctx context.Context, collectionID, bundleID string) ([]files.StoredRegisteredMetaData, error) {
-		calledWithCollection = collectionID
-		calledWithBundle = bundleID
-		return []files.StoredRegisteredMetaData{}, nil
+	h := api.HandlerGetFilesMetadata(func(ctx context.Context, query store.FilesQuery) (*files.FilesList, error) {
+		calledWithCollection = query.CollectionID
+		calledWithBundle = query.BundleID
+		return &files.FilesList{}, nil
 	})
 
 	h.ServeHTTP(rec, req)
@@ -52,10 +55,10 @@ func TestGetFilesMetadataWithBundleID(t *testing.T) {
 	calledWithCollection := ""
 	calledWithBundle := ""
 
-	h := api.HandlerGetFilesMetadata(func(ctx context.Context, collectionID, bundleID string) ([]files.StoredRegisteredMetaData, error) {
-		calledWithCollection = collectionID
-		calledWithBundle = bundleID
-		return []files.StoredRegisteredMetaData{}, nil
+	h := api.HandlerGetFilesMetadata(func(ctx context.Context, query store.FilesQuery) (*files.FilesList, error) {
+		calledWithCollection = query.CollectionID
+		calledWithBundle = query.BundleID
+		return &files.FilesList{}, nil
 	})
 
 	h.ServeHTTP(rec, req)
@@ -69,8 +72,8 @@ func TestGetFilesMetadataWhenErrorReturned(t *testing.T) {
 	rec := httptest.NewRecorder()
 	req := httptest.NewRequest(http.MethodGet, "/files?collection_id=12345678", http.NoBody)
 
-	h := api.HandlerGetFilesMetadata(func(ctx context.Context, collectionID, bundleID string) ([]files.StoredRegisteredMetaData, error) {
-		return []files.StoredRegisteredMetaData{}, errors.New("something went wrong")
+	h := api.HandlerGetFilesMetadata(func(ctx context.Context, query store.FilesQuery) (*files.FilesList, error) {
+		return nil, errors.New("something went wrong")
 	})
 
 	h.ServeHTTP(rec, req)
@@ -82,11 +85,140 @@ func TestGetFilesMetadataHandledWriteFailures(t *testing.T) {
 	rec := &ErrorWriter{}
 	req := httptest.NewRequest(http.MethodGet, "/files?collection_id=12345678", http.NoBody)
 
-	h := api.HandlerGetFilesMetadata(func(ctx context.Context, collectionID, bundleID string) ([]files.StoredRegisteredMetaData, error) {
-		return []files.StoredRegisteredMetaData{}, nil
+	h := api.HandlerGetFilesMetadata(func(ctx context.Context, query store.FilesQuery) (*files.FilesList, error) {
+		return &files.FilesList{}, nil
 	})
 
 	h.ServeHTTP(rec, req)
 
 	assert.Equal(t, http.StatusInternalServerError, rec.status)
 }
+
+func TestGetFilesMetadataPassesPageAndFilters(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/files?collection_id=c1&limit=2&cursor=abc&state=UPLOADED&type=text/csv"+
+		"&is_publishable=false&path_prefix=data/&created_after=2024-01-01T00:00:00Z&modified_before=2024-02-01T00:00:00Z", http.NoBody)
+
+	var called store.FilesQuery
+	h := api.HandlerGetFilesMetadata(func(ctx context.Context, query store.FilesQuery) (*files.FilesList, error) {
+		called = query
+		return &files.FilesList{Count: 2, Limit: 2, TotalCount: 3, NextCursor: "next", Items: []files.StoredRegisteredMetaData{{Path: "data/a.csv"}, {Path: "data/b.csv"}}}, nil
+	})
+
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, 2, called.Limit)
+	assert.Equal(t, "abc", called.Cursor)
+	assert.Equal(t, store.StateUploaded, called.State)
+	assert.Equal(t, "text/csv", called.Type)
+	assert.Equal(t, false, *called.IsPublishable)
+	assert.Equal(t, "data/", called.PathPrefix)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), *called.CreatedAfter)
+	assert.Nil(t, called.CreatedBefore)
+	assert.Nil(t, called.ModifiedAfter)
+	assert.Equal(t, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), *called.ModifiedBefore)
+
+	fl := files.FilesList{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&fl))
+	assert.Equal(t, 3, fl.TotalCount)
+	assert.Equal(t, "next", fl.NextCursor)
+	assert.Len(t, fl.Items, 2)
+}
+
+func TestGetFilesMetadataUnpaginatedWithoutLimitOrCursor(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/files?bundle_id=b1", http.NoBody)
+
+	var called store.FilesQuery
+	h := api.HandlerGetFilesMetadata(func(ctx context.Context, query store.FilesQuery) (*files.FilesList, error) {
+		called = query
+		return &files.FilesList{}, nil
+	})
+
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, 0, called.Limit)
+	assert.Nil(t, called.IsPublishable)
+}
+
+func TestGetFilesMetadataDefaultLimitWithCursor(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/files?bundle_id=b1&cursor=abc", http.NoBody)
+
+	var called store.FilesQuery
+	h := api.HandlerGetFilesMetadata(func(ctx context.Context, query store.FilesQuery) (*files.FilesList, error) {
+		called = query
+		return &files.FilesList{}, nil
+	})
+
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, 20, called.Limit)
+}
+
+func TestGetFilesMetadataWithOffset(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/files?collection_id=c1&offset=40&limit=20", http.NoBody)
+
+	var called store.FilesQuery
+	h := api.HandlerGetFilesMetadata(func(ctx context.Context, query store.FilesQuery) (*files.FilesList, error) {
+		called = query
+		return &files.FilesList{Count: 1, Limit: 20, Offset: 40, TotalCount: 41, Items: []files.StoredRegisteredMetaData{{Path: "z.csv"}}}, nil
+	})
+
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, 40, called.Offset)
+	assert.Equal(t, 20, called.Limit)
+	assert.Contains(t, rec.Body.String(), `"offset":40`)
+}
+
+func TestGetFilesMetadataInvalidQuery(t *testing.T) {
+	h := api.HandlerGetFilesMetadata(func(ctx context.Context, query store.FilesQuery) (*files.FilesList, error) {
+		t.Fatal("files should not be fetched")
+		return nil, nil
+	})
+
+	tests := []struct {
+		query string
+		err   error
+	}{
+		{"limit=0", store.ErrInvalidLimit},
+		{"limit=1001", store.ErrInvalidLimit},
+		{"limit=ten", store.ErrInvalidLimit},
+		{"offset=-1", store.ErrInvalidOffset},
+		{"offset=first", store.ErrInvalidOffset},
+		{"offset=20&cursor=abc", store.ErrInvalidOffset},
+		{"state=DELETED", store.ErrInvalidStateFilter},
+		{"is_publishable=maybe", store.ErrInvalidIsPublishable},
+		{"created_after=yesterday", store.ErrInvalidCreatedAfter},
+		{"created_before=yesterday", store.ErrInvalidCreatedBefore},
+		{"modified_after=yesterday", store.ErrInvalidModifiedAfter},
+		{"modified_before=yesterday", store.ErrInvalidModifiedBefore},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/files?collection_id=c1&"+tt.query, http.NoBody))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code, tt.query)
+		assert.Contains(t, rec.Body.String(), tt.err.Error(), tt.query)
+	}
+}
+
+func TestGetFilesMetadataInvalidCursor(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/files?collection_id=c1&cursor=%25", http.NoBody)
+
+	h := api.HandlerGetFilesMetadata(func(ctx context.Context, query store.FilesQuery) (*files.FilesList, error) {
+		return nil, store.ErrInvalidCursor
+	})
+
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "InvalidCursor")
+}
@@ -0,0 +1,166 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	clientsidentity "github.com/ONSdigital/dp-api-clients-go/v2/identity"
+	auth "github.com/ONSdigital/dp-authorisation/v2/authorisation"
+	"github.com/ONSdigital/dp-files-api/files"
+	dprequest "github.com/ONSdigital/dp-net/v3/request"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
+// preconditionHeaders are the request headers that decide whether a change is made, so they are part of what identifies
+// a request
+var preconditionHeaders = []string{"If-Match", "If-None-Match", "If-Modified-Since", "If-Unmodified-Since"}
+
+type ClaimIdempotencyKey func(ctx context.Context, key, fingerprint string) (files.IdempotencyRecord, bool, error)
+
+type CompleteIdempotencyKey func(ctx context.Context, record files.IdempotencyRecord, response files.IdempotentResponse) error
+
+type ReleaseIdempotencyKey func(ctx context.Context, record files.IdempotencyRecord) error
+
+// Idempotent returns a wrapper for handlers that change files, so that a request made with an Idempotency-Key can be
+// retried safely. Keys belong to the user or service making the request, so one caller cannot be given the response to
+// another's request. The first response to a key is recorded and replayed to each retry with the same method, URL,
+// precondition headers and body, with an Idempotent-Replayed header. A key used for a different request is rejected, as
+// are retries while the first request is still being handled. Requests that fail with a server error are not recorded,
+// so their retries are handled afresh. Requests without an Idempotency-Key are handled as they always have been.
+func Idempotent(claimKey ClaimIdempotencyKey, completeKey CompleteIdempotencyKey, releaseKey ReleaseIdempotencyKey, authMiddleware auth.Middleware, identityClient *clientsidentity.Client) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			key := req.Header.Get(idempotencyKeyHeader)
+			if key == "" {
+				next(w, req)
+				return
+			}
+
+			ctx := req.Context()
+			logData := log.Data{"method": req.Method, "url": req.URL.RequestURI(), "idempotency_key": key}
+
+			if len(key) > maxIdempotencyKeyLength {
+				writeError(w, buildGenericError("InvalidIdempotencyKey", "idempotency key must be no longer than 255 characters"), http.StatusBadRequest)
+				return
+			}
+
+			accessToken := strings.TrimPrefix(req.Header.Get(dprequest.AuthHeaderKey), dprequest.BearerPrefix)
+			authEntityData, err := getAuthEntityData(ctx, authMiddleware, identityClient, accessToken, logData)
+			if err != nil {
+				writeError(w, buildGenericError("Forbidden", "the request was not authorised - check token and user's permissions"), http.StatusForbidden)
+				return
+			}
+
+			body, err := io.ReadAll(req.Body)
+			if err != nil {
+				log.Error(ctx, "failed to read request body", err, logData)
+				writeError(w, buildGenericError("BadRequest", "unable to read request body"), http.StatusBadRequest)
+				return
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+
+			record, claimed, err := claimKey(ctx, callerKey(authEntityData, key), requestFingerprint(req, body))
+			if err != nil {
+				log.Error(ctx, "failed to claim idempotency key", err, logData)
+				handleError(w, err)
+				return
+			}
+
+			if !claimed {
+				log.Info(ctx, "replaying response to idempotency key", logData)
+				replayResponse(w, *record.Response)
+				return
+			}
+
+			recorder := &responseRecorder{ResponseWriter: w}
+			next(recorder, req)
+			response := recorder.response()
+
+			// the response has been sent, so the key is settled even if the client has gone
+			ctx = context.WithoutCancel(ctx)
+			if response.StatusCode >= http.StatusInternalServerError {
+				if err := releaseKey(ctx, record); err != nil {
+					log.Error(ctx, "failed to release idempotency key", err, logData)
+				}
+				return
+			}
+			if err := completeKey(ctx, record, response); err != nil {
+				log.Error(ctx, "failed to record response to idempotency key", err, logData)
+			}
+		}
+	}
+}
+
+// callerKey is the key as held for the user or service making the request
+func callerKey(authEntityData *AuthEntityData, key string) string {
+	callerType := "user"
+	if authEntityData.IsServiceAuth {
+		callerType = "service"
+	}
+	return fmt.Sprintf("%s:%s:%s", callerType, authEntityData.EntityData.UserID, key)
+}
+
+// requestFingerprint identifies a request by its method, URL, precondition headers and body, so that a key reused for
+// another request is recognised
+func requestFingerprint(req *http.Request, body []byte) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\x00%s\x00", req.Method, req.URL.RequestURI())
+	for _, header := range preconditionHeaders {
+		fmt.Fprintf(hash, "%s\x00", strings.Join(req.Header.Values(header), ","))
+	}
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func replayResponse(w http.ResponseWriter, response files.IdempotentResponse) {
+	for name, values := range response.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set(idempotentReplayedHeader, "true")
+	w.WriteHeader(response.StatusCode)
+	_, _ = w.Write(response.Body)
+}
+
+// responseRecorder passes a response through to the client, keeping a copy of it
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode int
+	header     http.Header
+	body       bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(statusCode int) {
+	if r.statusCode == 0 {
+		r.statusCode = statusCode
+		r.header = r.Header().Clone()
+	}
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.statusCode == 0 {
+		r.WriteHeader(http.StatusOK)
+	}
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *responseRecorder) response() files.IdempotentResponse {
+	if r.statusCode == 0 {
+		r.statusCode = http.StatusOK
+		r.header = r.Header().Clone()
+	}
+	return files.IdempotentResponse{StatusCode: r.statusCode, Header: r.header, Body: r.body.Bytes()}
+}
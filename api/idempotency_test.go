@@ -0,0 +1,237 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	clientsidentity "github.com/ONSdigital/dp-api-clients-go/v2/identity"
+	authMock "github.com/ONSdigital/dp-authorisation/v2/authorisation/mock"
+	"github.com/ONSdigital/dp-files-api/api"
+	"github.com/ONSdigital/dp-files-api/config"
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/dp-files-api/store"
+	permissionsAPISDK "github.com/ONSdigital/dp-permissions-api/sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+// idempotencyKeys holds claimed keys in memory, as the store does in MongoDB
+type idempotencyKeys map[string]*files.IdempotencyRecord
+
+func (k idempotencyKeys) wrap(handler http.HandlerFunc) http.HandlerFunc {
+	claim := func(ctx context.Context, key, fingerprint string) (files.IdempotencyRecord, bool, error) {
+		existing, ok := k[key]
+		switch {
+		case !ok:
+			k[key] = &files.IdempotencyRecord{Key: key, Fingerprint: fingerprint}
+			return *k[key], true, nil
+		case existing.Fingerprint != fingerprint:
+			return files.IdempotencyRecord{}, false, store.ErrIdempotencyKeyReused
+		case existing.Response == nil:
+			return files.IdempotencyRecord{}, false, store.ErrIdempotencyKeyInProgress
+		default:
+			return *existing, false, nil
+		}
+	}
+	complete := func(ctx context.Context, record files.IdempotencyRecord, response files.IdempotentResponse) error {
+		k[record.Key].Response = &response
+		return nil
+	}
+	release := func(ctx context.Context, record files.IdempotencyRecord) error {
+		delete(k, record.Key)
+		return nil
+	}
+	cfg, _ := config.Get()
+	authMiddleware := &authMock.MiddlewareMock{
+		// the user is named by the part of the token before the dot
+		ParseFunc: func(token string) (*permissionsAPISDK.EntityData, error) {
+			user, _, _ := strings.Cut(token, ".")
+			if user == "unknown" {
+				return nil, errors.New("key id unknown or invalid")
+			}
+			return &permissionsAPISDK.EntityData{UserID: user}, nil
+		},
+	}
+	return api.Idempotent(claim, complete, release, authMiddleware, clientsidentity.New(cfg.ZebedeeURL))(handler)
+}
+
+// countingHandler registers a file, or fails with the status given, counting how often it is called
+func countingHandler(calls *int, status int) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		*calls++
+		if status != http.StatusCreated {
+			w.WriteHeader(status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"path":"data/file.csv"}`))
+	}
+}
+
+func idempotentRequest(key, body string) *http.Request {
+	return idempotentRequestBy("publisher", key, body)
+}
+
+func idempotentRequestBy(user, key, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/files", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+user+".token")
+	if key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
+	return req
+}
+
+func TestIdempotentReplaysFirstResponse(t *testing.T) {
+	calls := 0
+	h := idempotencyKeys{}.wrap(countingHandler(&calls, http.StatusCreated))
+
+	first := httptest.NewRecorder()
+	h.ServeHTTP(first, idempotentRequest("key-1", `{"path":"data/file.csv"}`))
+	retry := httptest.NewRecorder()
+	h.ServeHTTP(retry, idempotentRequest("key-1", `{"path":"data/file.csv"}`))
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Empty(t, first.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, "application/json", retry.Header().Get("Content-Type"))
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+}
+
+func TestIdempotentReplaysClientErrors(t *testing.T) {
+	calls := 0
+	h := idempotencyKeys{}.wrap(countingHandler(&calls, http.StatusConflict))
+
+	h.ServeHTTP(httptest.NewRecorder(), idempotentRequest("key-1", "{}"))
+	retry := httptest.NewRecorder()
+	h.ServeHTTP(retry, idempotentRequest("key-1", "{}"))
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusConflict, retry.Code)
+}
+
+func TestIdempotentRetriesServerErrors(t *testing.T) {
+	calls := 0
+	h := idempotencyKeys{}.wrap(countingHandler(&calls, http.StatusInternalServerError))
+
+	h.ServeHTTP(httptest.NewRecorder(), idempotentRequest("key-1", "{}"))
+	retry := httptest.NewRecorder()
+	h.ServeHTTP(retry, idempotentRequest("key-1", "{}"))
+
+	assert.Equal(t, 2, calls)
+	assert.Empty(t, retry.Header().Get("Idempotent-Replayed"))
+}
+
+func TestIdempotentRejectsKeyReusedForAnotherRequest(t *testing.T) {
+	calls := 0
+	h := idempotencyKeys{}.wrap(countingHandler(&calls, http.StatusCreated))
+
+	h.ServeHTTP(httptest.NewRecorder(), idempotentRequest("key-1", `{"path":"data/file.csv"}`))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, idempotentRequest("key-1", `{"path":"data/other.csv"}`))
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Contains(t, rec.Body.String(), "IdempotencyKeyReused")
+}
+
+func TestIdempotentRejectsRetryWhileInProgress(t *testing.T) {
+	keys := idempotencyKeys{}
+	var retry *httptest.ResponseRecorder
+	var h http.HandlerFunc
+	h = keys.wrap(func(w http.ResponseWriter, req *http.Request) {
+		retry = httptest.NewRecorder()
+		h.ServeHTTP(retry, idempotentRequest("key-1", "{}"))
+		w.WriteHeader(http.StatusOK)
+	})
+
+	h.ServeHTTP(httptest.NewRecorder(), idempotentRequest("key-1", "{}"))
+
+	assert.Equal(t, http.StatusConflict, retry.Code)
+	assert.Contains(t, retry.Body.String(), "IdempotencyKeyInProgress")
+}
+
+func TestIdempotentPassesRequestBodyToHandler(t *testing.T) {
+	var body string
+	h := idempotencyKeys{}.wrap(func(w http.ResponseWriter, req *http.Request) {
+		b, _ := io.ReadAll(req.Body)
+		body = string(b)
+	})
+
+	h.ServeHTTP(httptest.NewRecorder(), idempotentRequest("key-1", `{"state":"UPLOADED"}`))
+
+	assert.Equal(t, `{"state":"UPLOADED"}`, body)
+}
+
+func TestIdempotentWithoutKey(t *testing.T) {
+	keys := idempotencyKeys{}
+	calls := 0
+	h := keys.wrap(countingHandler(&calls, http.StatusCreated))
+
+	h.ServeHTTP(httptest.NewRecorder(), idempotentRequest("", "{}"))
+	h.ServeHTTP(httptest.NewRecorder(), idempotentRequest("", "{}"))
+
+	assert.Equal(t, 2, calls)
+	assert.Empty(t, keys)
+}
+
+func TestIdempotentRejectsOverlongKey(t *testing.T) {
+	calls := 0
+	h := idempotencyKeys{}.wrap(countingHandler(&calls, http.StatusCreated))
+	rec := httptest.NewRecorder()
+
+	h.ServeHTTP(rec, idempotentRequest(strings.Repeat("k", 256), "{}"))
+
+	assert.Equal(t, 0, calls)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestIdempotentKeysBelongToTheCaller(t *testing.T) {
+	calls := 0
+	h := idempotencyKeys{}.wrap(countingHandler(&calls, http.StatusCreated))
+
+	h.ServeHTTP(httptest.NewRecorder(), idempotentRequestBy("publisher", "key-1", `{"path":"data/file.csv"}`))
+	same := httptest.NewRecorder()
+	h.ServeHTTP(same, idempotentRequestBy("other-publisher", "key-1", `{"path":"data/file.csv"}`))
+	different := httptest.NewRecorder()
+	h.ServeHTTP(different, idempotentRequestBy("another-publisher", "key-1", `{"path":"data/other.csv"}`))
+
+	assert.Equal(t, 3, calls)
+	assert.Empty(t, same.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, http.StatusCreated, different.Code)
+}
+
+func TestIdempotentRejectsKeyReusedWithOtherPreconditions(t *testing.T) {
+	calls := 0
+	h := idempotencyKeys{}.wrap(countingHandler(&calls, http.StatusCreated))
+
+	first := idempotentRequest("key-1", "{}")
+	first.Header.Set("If-Match", `"1-abc-def"`)
+	h.ServeHTTP(httptest.NewRecorder(), first)
+	retry := idempotentRequest("key-1", "{}")
+	retry.Header.Set("If-Match", `"2-abc-def"`)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, retry)
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+}
+
+func TestIdempotentRejectsUnidentifiedCaller(t *testing.T) {
+	keys := idempotencyKeys{}
+	calls := 0
+	h := keys.wrap(countingHandler(&calls, http.StatusCreated))
+	rec := httptest.NewRecorder()
+
+	h.ServeHTTP(rec, idempotentRequestBy("unknown", "key-1", "{}"))
+
+	assert.Equal(t, 0, calls)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Empty(t, keys)
+}
@@ -0,0 +1,19 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/ONSdigital/dp-files-api/store"
+)
+
+// IfMatch passes the If-Match header of a request that changes a file on to the store, so that the file is only
+// changed while its metadata still has one of the ETags given, and 412 Precondition Failed is returned otherwise
+func IfMatch(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if ifMatch := strings.Join(req.Header.Values("If-Match"), ","); ifMatch != "" {
+			req = req.WithContext(store.WithIfMatch(req.Context(), ifMatch))
+		}
+		next(w, req)
+	}
+}
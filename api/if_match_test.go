@@ -0,0 +1,86 @@
+package api_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dp-authorisation/v2/authorisationtest"
+	"github.com/ONSdigital/dp-files-api/api"
+	"github.com/ONSdigital/dp-files-api/config"
+	"github.com/ONSdigital/dp-files-api/features/steps"
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/dp-files-api/store"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ifMatchRequest(ifMatch ...string) *http.Request {
+	req := httptest.NewRequest(http.MethodPatch, "/files/file.txt", strings.NewReader(`{"collection_id": "123456789"}`))
+	for _, value := range ifMatch {
+		req.Header.Add("If-Match", value)
+	}
+	return mux.SetURLVars(req, map[string]string{"path": "file.txt"})
+}
+
+func TestIfMatchUpdatesFileWithMatchingETag(t *testing.T) {
+	cfg, _ := config.Get()
+	dataStore := store.NewStore(store.NewMemoryRepository(), steps.TestClock{}, nil, cfg)
+	require.NoError(t, dataStore.RegisterFileUpload(context.Background(), files.StoredRegisteredMetaData{Path: "file.txt"}))
+	metadata, err := dataStore.GetFileMetadata(context.Background(), "file.txt")
+	require.NoError(t, err)
+	h := api.IfMatch(api.HandlerUpdateCollectionID(dataStore.UpdateCollectionID))
+
+	stale := httptest.NewRecorder()
+	h.ServeHTTP(stale, ifMatchRequest(`"other"`))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, ifMatchRequest(`"other"`, store.MetadataETag(metadata)))
+
+	assert.Equal(t, http.StatusPreconditionFailed, stale.Code)
+	assert.Contains(t, stale.Body.String(), "PreconditionFailed")
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestIfMatchRemovesFileWithMatchingETag(t *testing.T) {
+	cfg, _ := config.Get()
+	repo := store.NewMemoryRepository()
+	dataStore := store.NewStore(repo, steps.TestClock{}, nil, cfg)
+	require.NoError(t, dataStore.RegisterFileUpload(context.Background(), files.StoredRegisteredMetaData{Path: "file.txt"}))
+	require.NoError(t, repo.UpdateMetadata(context.Background(), "file.txt", store.Update{Set: []store.Field{{Key: "state", Value: store.StateUploaded}}}))
+	metadata, err := dataStore.GetFileMetadata(context.Background(), "file.txt")
+	require.NoError(t, err)
+	authMiddlewareMock, identityClientMock, _ := setUpAuthServices()
+	h := api.IfMatch(api.HandleRemoveFile(dataStore.RemoveFile, dataStore.CreateFileEvent, dataStore.GetFileMetadata, authMiddlewareMock, identityClientMock))
+	deleteRequest := func(ifMatch string) *http.Request {
+		req := httptest.NewRequest(http.MethodDelete, "/files/file.txt", http.NoBody)
+		req.Header.Add("Authorization", authorisationtest.AdminJWTToken)
+		req.Header.Add("If-Match", ifMatch)
+		return mux.SetURLVars(req, map[string]string{"path": "file.txt"})
+	}
+
+	stale := httptest.NewRecorder()
+	h.ServeHTTP(stale, deleteRequest(`"0-0000000000000000"`))
+	_, staleErr := repo.GetMetadata(context.Background(), "file.txt")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, deleteRequest(store.MetadataETag(metadata)))
+
+	assert.Equal(t, http.StatusPreconditionFailed, stale.Code)
+	assert.Contains(t, stale.Body.String(), "PreconditionFailed")
+	assert.NoError(t, staleErr, "a stale ETag removes nothing")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	_, err = repo.GetMetadata(context.Background(), "file.txt")
+	assert.Error(t, err)
+}
+
+func TestIfMatchIsOptional(t *testing.T) {
+	var ctx context.Context
+	h := api.IfMatch(func(w http.ResponseWriter, req *http.Request) { ctx = req.Context() })
+	req := httptest.NewRequest(http.MethodPatch, "/files/file.txt", http.NoBody)
+
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, req.Context(), ctx)
+}
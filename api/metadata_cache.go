@@ -0,0 +1,80 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/dp-files-api/store"
+)
+
+// metadataCacheControl gives the caching allowed for the metadata of a file by its state. A MOVED file no longer
+// changes so can be kept for maxAge, a PUBLISHED file is about to be moved so must be checked each time it is used,
+// and nothing is kept of the metadata of a file that is not yet published. Metadata read with permissions is only kept
+// by the user's own cache.
+func metadataCacheControl(state string, maxAge time.Duration, private bool) string {
+	scope := "public"
+	if private {
+		scope = "private"
+	}
+
+	switch state {
+	case store.StateMoved:
+		return scope + ", max-age=" + strconv.Itoa(int(maxAge.Seconds()))
+	case store.StatePublished:
+		return scope + ", no-cache"
+	default:
+		return "no-store"
+	}
+}
+
+// writeMetadataCacheHeaders sets the validators and caching of the metadata of a file, and reports whether the
+// request's conditions show the client already has it, in which case 304 Not Modified has been written. The validators
+// are those of the metadata as served, so they change when the file is served as published because its collection or
+// bundle has been. The ETag may also be given in If-Match to change the file only if it has not changed since.
+func writeMetadataCacheHeaders(w http.ResponseWriter, req *http.Request, metadata files.StoredRegisteredMetaData, maxAge time.Duration, private bool) bool {
+	etag := store.MetadataETag(metadata)
+	lastModified := metadata.LastModified
+	if metadata.PublishedAt != nil && metadata.PublishedAt.After(lastModified) {
+		lastModified = *metadata.PublishedAt
+	}
+
+	w.Header().Set("ETag", etag)
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+	w.Header().Set("Cache-Control", metadataCacheControl(metadata.State, maxAge, private))
+
+	if !notModified(req, etag, lastModified) {
+		return false
+	}
+
+	w.Header().Del("Content-Type")
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// notModified evaluates If-None-Match, or If-Modified-Since when there is no If-None-Match, as RFC 9110 has it
+func notModified(req *http.Request, etag string, lastModified time.Time) bool {
+	if ifNoneMatch := req.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		for _, candidate := range strings.Split(ifNoneMatch, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	if ifModifiedSince := req.Header.Get("If-Modified-Since"); ifModifiedSince != "" && !lastModified.IsZero() {
+		since, err := http.ParseTime(ifModifiedSince)
+		if err != nil {
+			return false
+		}
+		return !lastModified.Truncate(time.Second).After(since)
+	}
+
+	return false
+}
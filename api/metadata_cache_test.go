@@ -0,0 +1,174 @@
+package api_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	authMock "github.com/ONSdigital/dp-authorisation/v2/authorisation/mock"
+	"github.com/ONSdigital/dp-authorisation/v2/authorisationtest"
+	"github.com/ONSdigital/dp-files-api/api"
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/dp-files-api/store"
+	permissionsAPISDK "github.com/ONSdigital/dp-permissions-api/sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+var cachedFileLastModified = time.Date(2026, 3, 2, 9, 30, 15, 500, time.UTC)
+
+func cachedFileMetadata(state string) func(ctx context.Context, path string) (files.StoredRegisteredMetaData, error) {
+	return func(ctx context.Context, path string) (files.StoredRegisteredMetaData, error) {
+		return files.StoredRegisteredMetaData{Path: path, State: state, Etag: "etag-1", LastModified: cachedFileLastModified, Revision: 3}, nil
+	}
+}
+
+func getCachedFileMetadata(state string, headers map[string]string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/files/data.csv", http.NoBody)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	api.HandleGetFileMetadata(cachedFileMetadata(state), time.Hour).ServeHTTP(rec, req)
+	return rec
+}
+
+func TestGetFileMetadataSetsCacheHeadersByState(t *testing.T) {
+	tests := map[string]string{
+		store.StateMoved:     "public, max-age=3600",
+		store.StatePublished: "public, no-cache",
+		store.StateUploaded:  "no-store",
+		store.StateCreated:   "no-store",
+	}
+
+	for state, cacheControl := range tests {
+		t.Run(state, func(t *testing.T) {
+			rec := getCachedFileMetadata(state, nil)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, cacheControl, rec.Header().Get("Cache-Control"))
+			assert.Regexp(t, `^"3-[0-9a-f]{16}-[0-9a-f]{8}"$`, rec.Header().Get("ETag"))
+			assert.Equal(t, "Mon, 02 Mar 2026 09:30:15 GMT", rec.Header().Get("Last-Modified"))
+		})
+	}
+}
+
+func TestGetFileMetadataETagChangesWithRevision(t *testing.T) {
+	etag := func(revision int64) string {
+		rec := httptest.NewRecorder()
+		getMetadata := func(ctx context.Context, path string) (files.StoredRegisteredMetaData, error) {
+			return files.StoredRegisteredMetaData{Path: path, State: store.StateMoved, LastModified: cachedFileLastModified, Revision: revision}, nil
+		}
+		api.HandleGetFileMetadata(getMetadata, time.Hour).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/files/data.csv", http.NoBody))
+		return rec.Header().Get("ETag")
+	}
+
+	assert.NotEqual(t, etag(3), etag(4))
+	assert.Equal(t, etag(4), etag(4))
+}
+
+func TestGetFileMetadataValidatorsChangeWhenServedAsPublished(t *testing.T) {
+	publishedAt := cachedFileLastModified.Add(time.Hour)
+	served := files.StoredRegisteredMetaData{Path: "data.csv", State: store.StateUploaded, LastModified: cachedFileLastModified, Revision: 3}
+	getMetadata := func(ctx context.Context, path string) (files.StoredRegisteredMetaData, error) {
+		return served, nil
+	}
+	get := func(headers map[string]string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/files/data.csv", http.NoBody)
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		api.HandleGetFileMetadata(getMetadata, time.Hour).ServeHTTP(rec, req)
+		return rec
+	}
+	uploaded := get(nil)
+
+	// the collection is published, which changes neither the revision nor the last modified time of the file
+	served.State = store.StatePublished
+	served.PublishedAt = &publishedAt
+	byETag := get(map[string]string{"If-None-Match": uploaded.Header().Get("ETag")})
+	byDate := get(map[string]string{"If-Modified-Since": uploaded.Header().Get("Last-Modified")})
+
+	assert.Equal(t, http.StatusOK, byETag.Code)
+	assert.NotEqual(t, uploaded.Header().Get("ETag"), byETag.Header().Get("ETag"))
+	assert.Equal(t, http.StatusOK, byDate.Code)
+	assert.Equal(t, "Mon, 02 Mar 2026 10:30:15 GMT", byDate.Header().Get("Last-Modified"))
+	assert.Equal(t, http.StatusNotModified, get(map[string]string{"If-None-Match": byETag.Header().Get("ETag")}).Code)
+}
+
+func TestGetFileMetadataConditionalRequests(t *testing.T) {
+	etag := getCachedFileMetadata(store.StateMoved, nil).Header().Get("ETag")
+	weakETag := "W/" + etag
+
+	tests := map[string]struct {
+		headers map[string]string
+		status  int
+	}{
+		"matching etag":                {headers: map[string]string{"If-None-Match": etag}, status: http.StatusNotModified},
+		"etag compared weakly":         {headers: map[string]string{"If-None-Match": weakETag}, status: http.StatusNotModified},
+		"etag in a list":               {headers: map[string]string{"If-None-Match": `"other", ` + etag}, status: http.StatusNotModified},
+		"any etag":                     {headers: map[string]string{"If-None-Match": "*"}, status: http.StatusNotModified},
+		"other etag":                   {headers: map[string]string{"If-None-Match": `W/"other"`}, status: http.StatusOK},
+		"not modified since":           {headers: map[string]string{"If-Modified-Since": "Mon, 02 Mar 2026 09:30:15 GMT"}, status: http.StatusNotModified},
+		"modified since":               {headers: map[string]string{"If-Modified-Since": "Mon, 02 Mar 2026 09:30:14 GMT"}, status: http.StatusOK},
+		"unreadable modified since":    {headers: map[string]string{"If-Modified-Since": "yesterday"}, status: http.StatusOK},
+		"etag checked before the date": {headers: map[string]string{"If-None-Match": `W/"other"`, "If-Modified-Since": "Tue, 03 Mar 2026 00:00:00 GMT"}, status: http.StatusOK},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			rec := getCachedFileMetadata(store.StateMoved, test.headers)
+
+			assert.Equal(t, test.status, rec.Code)
+			assert.Equal(t, etag, rec.Header().Get("ETag"))
+			assert.Equal(t, "public, max-age=3600", rec.Header().Get("Cache-Control"))
+			if test.status == http.StatusNotModified {
+				assert.Empty(t, rec.Body.String())
+				assert.Empty(t, rec.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestGetFileMetadataWithAuthIsOnlyCachedPrivately(t *testing.T) {
+	authMiddlewareMock, identityClientMock, permissionsMock := setUpAuthServices()
+	h := api.HandleGetFileMetadataWithAuth(cachedFileMetadata(store.StateMoved), nil, authMiddlewareMock, identityClientMock, permissionsMock, time.Hour)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/files/data.csv", http.NoBody)
+	req.Header.Add("Authorization", authorisationtest.AdminJWTToken)
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "private, max-age=3600", rec.Header().Get("Cache-Control"))
+
+	notModified := httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/files/data.csv", http.NoBody)
+	req.Header.Add("Authorization", authorisationtest.AdminJWTToken)
+	req.Header.Set("If-None-Match", rec.Header().Get("ETag"))
+	h.ServeHTTP(notModified, req)
+
+	assert.Equal(t, http.StatusNotModified, notModified.Code)
+	assert.Empty(t, notModified.Body.String())
+}
+
+func TestGetFileMetadataWithAuthChecksPermissionBeforeNotModified(t *testing.T) {
+	authMiddlewareMock, identityClientMock, _ := setUpAuthServices()
+	permissionsMock := &authMock.PermissionsCheckerMock{
+		HasPermissionFunc: func(ctx context.Context, entityData permissionsAPISDK.EntityData, permission string, attributes map[string]string) (bool, error) {
+			return false, nil
+		},
+	}
+	h := api.HandleGetFileMetadataWithAuth(cachedFileMetadata(store.StateMoved), nil, authMiddlewareMock, identityClientMock, permissionsMock, time.Hour)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/files/data.csv", http.NoBody)
+	req.Header.Add("Authorization", authorisationtest.AdminJWTToken)
+	req.Header.Set("If-None-Match", "*")
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Empty(t, rec.Header().Get("ETag"))
+}
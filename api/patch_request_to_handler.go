@@ -15,6 +15,7 @@ type PatchRequestHandlers struct {
 	UploadComplete   http.HandlerFunc
 	Published        http.HandlerFunc
 	Moved            http.HandlerFunc
+	Withdrawn        http.HandlerFunc
 	CollectionUpdate http.HandlerFunc
 	BundleUpdate     http.HandlerFunc
 }
@@ -50,6 +51,8 @@ func PatchRequestToHandler(handlers PatchRequestHandlers) http.HandlerFunc {
 			handlers.Published.ServeHTTP(w, req)
 		case store.StateMoved:
 			handlers.Moved.ServeHTTP(w, req)
+		case store.StateWithdrawn:
+			handlers.Withdrawn.ServeHTTP(w, req)
 		default:
 			log.Error(req.Context(), "InvalidStateChange", errors.New("invalid state change"), log.Data{"state": *stateMetaData.State})
 			writeError(w, buildErrors(errors.New("invalid state change"), "InvalidStateChange"), http.StatusBadRequest)
@@ -30,11 +30,13 @@ func (s *PatchRequestToHandlerSuite) SetupTest() {
 	stateMoved := "MOVED"
 	statePublished := "PUBLISHED"
 	stateUploaded := "UPLOADED"
+	stateWithdrawn := "WITHDRAWN"
 	collectionUpdateHandlerBody := "collectionUpdateHandler"
 	bundleUpdateHandlerBody := "bundleUpdateHandler"
 	movedHandlerBody := "movedHandler"
 	publishedHandlerBody := "publishedHandler"
 	uploadCompleteHandlerBody := "uploadCompleteHandler"
+	withdrawnHandlerBody := "withdrawnHandler"
 
 	generatePatchRequestHandler := func(body string) http.HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(body)) }
@@ -46,12 +48,14 @@ func (s *PatchRequestToHandlerSuite) SetupTest() {
 		{Metadata: api.StateMetadata{State: &stateMoved}, ExpectedBody: movedHandlerBody},
 		{Metadata: api.StateMetadata{State: &statePublished}, ExpectedBody: publishedHandlerBody},
 		{Metadata: api.StateMetadata{State: &stateUploaded}, ExpectedBody: uploadCompleteHandlerBody},
+		{Metadata: api.StateMetadata{State: &stateWithdrawn}, ExpectedBody: withdrawnHandlerBody},
 	}
 
 	s.PatchRequestHandlers = api.PatchRequestHandlers{
 		UploadComplete:   generatePatchRequestHandler(uploadCompleteHandlerBody),
 		Published:        generatePatchRequestHandler(publishedHandlerBody),
 		Moved:            generatePatchRequestHandler(movedHandlerBody),
+		Withdrawn:        generatePatchRequestHandler(withdrawnHandlerBody),
 		CollectionUpdate: generatePatchRequestHandler(collectionUpdateHandlerBody),
 		BundleUpdate:     generatePatchRequestHandler(bundleUpdateHandlerBody),
 	}
@@ -84,6 +88,7 @@ func (s *PatchRequestToHandlerSuite) TestPatchRequestToHandlerPassesBodyToSubseq
 		UploadComplete:   testHandler,
 		Published:        testHandler,
 		Moved:            testHandler,
+		Withdrawn:        testHandler,
 		CollectionUpdate: testHandler,
 		BundleUpdate:     testHandler,
 	}
@@ -0,0 +1,266 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+
+	auth "github.com/ONSdigital/dp-authorisation/v2/authorisation"
+	"github.com/ONSdigital/dp-files-api/store"
+	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/gorilla/mux"
+)
+
+const (
+	datasetEditionAttribute = "dataset_edition"
+	collectionIDAttribute   = "collection_id"
+	bundleIDAttribute       = "bundle_id"
+)
+
+// FileAttributes gives the attributes the permissions of a request to change the file at its path are checked
+// against: the dataset and edition of the file's content item, as well as the Collection-Id header the authorisation
+// middleware checks by default. A file that is not registered has no attributes of its own, so the handler can say so.
+func FileAttributes(getMetadata GetFileMetadata) auth.GetAttributesFromRequest {
+	return func(req *http.Request) (map[string]string, error) {
+		attributes, err := auth.GetCollectionIDAttribute(req)
+		if err != nil {
+			return nil, err
+		}
+
+		metadata, err := getMetadata(req.Context(), mux.Vars(req)["path"])
+		if errors.Is(err, store.ErrFileNotRegistered) {
+			return attributes, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if metadata.ContentItem != nil {
+			addDatasetEditionAttribute(attributes, metadata.ContentItem.DatasetID, metadata.ContentItem.Edition)
+		}
+		return attributes, nil
+	}
+}
+
+// FileTargetAttributes gives the attributes of a PATCH that moves the file at its path to another collection or bundle:
+// those FileAttributes gives, with the collection_id or bundle_id in the body being moved to. This is checked as well as
+// FileAttributes, so that the file cannot be moved into a collection or bundle the request has no permission on. The
+// body is put back for the handler, which rejects it if it cannot be read.
+func FileTargetAttributes(getMetadata GetFileMetadata) auth.GetAttributesFromRequest {
+	fileAttributes := FileAttributes(getMetadata)
+	return func(req *http.Request) (map[string]string, error) {
+		attributes, err := fileAttributes(req)
+		if err != nil {
+			return nil, err
+		}
+
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+
+		var target StateMetadata
+		if err := json.Unmarshal(body, &target); err != nil {
+			return attributes, nil
+		}
+		if target.CollectionID != nil {
+			attributes[collectionIDAttribute] = *target.CollectionID
+		}
+		if target.BundleID != nil {
+			attributes[bundleIDAttribute] = *target.BundleID
+		}
+		return attributes, nil
+	}
+}
+
+// TrashedFileAttributes gives the attributes the permissions of a request to restore the file removed from its path
+// are checked against, in the same way as FileAttributes does for a registered file
+func TrashedFileAttributes(getTrashedFile GetTrashedFile) auth.GetAttributesFromRequest {
+	return func(req *http.Request) (map[string]string, error) {
+		attributes, err := auth.GetCollectionIDAttribute(req)
+		if err != nil {
+			return nil, err
+		}
+
+		trashed, err := getTrashedFile(req.Context(), mux.Vars(req)["path"])
+		if errors.Is(err, store.ErrFileNotInTrash) {
+			return attributes, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if trashed.Metadata.ContentItem != nil {
+			addDatasetEditionAttribute(attributes, trashed.Metadata.ContentItem.DatasetID, trashed.Metadata.ContentItem.Edition)
+		}
+		return attributes, nil
+	}
+}
+
+// ContentItemAttributes gives the attributes the permissions of a request with a content item in its JSON body are
+// checked against: the dataset and edition of that content item, as well as the Collection-Id header. The body is put
+// back for the handler, which rejects it if it cannot be read.
+func ContentItemAttributes(req *http.Request) (map[string]string, error) {
+	attributes, err := auth.GetCollectionIDAttribute(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	var request struct {
+		ContentItem *ContentItem `json:"content_item"`
+	}
+	if err := json.Unmarshal(body, &request); err == nil && request.ContentItem != nil {
+		addDatasetEditionAttribute(attributes, request.ContentItem.DatasetID, request.ContentItem.Edition)
+	}
+	return attributes, nil
+}
+
+// CollectionAttributes gives the collection in the request path as the collection_id attribute, in place of any
+// Collection-Id header
+func CollectionAttributes(req *http.Request) (map[string]string, error) {
+	attributes, err := auth.GetCollectionIDAttribute(req)
+	if err != nil {
+		return nil, err
+	}
+	attributes[collectionIDAttribute] = mux.Vars(req)["collectionID"]
+	return attributes, nil
+}
+
+// BundleAttributes gives the bundle in the request path as the bundle_id attribute, as well as the Collection-Id header
+func BundleAttributes(req *http.Request) (map[string]string, error) {
+	attributes, err := auth.GetCollectionIDAttribute(req)
+	if err != nil {
+		return nil, err
+	}
+	attributes[bundleIDAttribute] = mux.Vars(req)["bundleID"]
+	return attributes, nil
+}
+
+// GetItemAttributesFromRequest gives the attributes of each of the files a request changes
+type GetItemAttributesFromRequest func(req *http.Request) ([]map[string]string, error)
+
+// RequireForEachItem checks the permission against the attributes of every file a request changes, in the way
+// RequireWithAttributes does for a request that changes one file. The whole request is refused if any file is not
+// permitted. Files with the same attributes are checked once.
+func RequireForEachItem(authMiddleware auth.Middleware, permission string, handlerFunc http.HandlerFunc, getItemAttributes GetItemAttributesFromRequest) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		itemAttributes, err := getItemAttributes(req)
+		if err != nil {
+			log.Error(req.Context(), "authorisation failed: request attributes retrieval error", err, log.Data{"url": req.URL.String(), "permission": permission})
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		checked := make(map[string]bool)
+		next := handlerFunc
+		for _, attributes := range itemAttributes {
+			key := attributesKey(attributes)
+			if checked[key] {
+				continue
+			}
+			checked[key] = true
+			next = authMiddleware.RequireWithAttributes(permission, next, func(*http.Request) (map[string]string, error) {
+				return attributes, nil
+			})
+		}
+		next(w, req)
+	}
+}
+
+// BatchAttributes gives the attributes of each file in a JSON array of RegisterMetadata: the dataset and edition of
+// its content item, as well as the Collection-Id header. A body that cannot be read as a batch gives just the header,
+// and is put back for the handler to reject.
+func BatchAttributes(req *http.Request) ([]map[string]string, error) {
+	attributes, err := auth.GetCollectionIDAttribute(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	var batch []struct {
+		ContentItem *ContentItem `json:"content_item"`
+	}
+	if err := json.Unmarshal(body, &batch); err != nil || len(batch) == 0 {
+		return []map[string]string{attributes}, nil
+	}
+
+	itemAttributes := make([]map[string]string, 0, len(batch))
+	for _, item := range batch {
+		a := maps.Clone(attributes)
+		if item.ContentItem != nil {
+			addDatasetEditionAttribute(a, item.ContentItem.DatasetID, item.ContentItem.Edition)
+		}
+		itemAttributes = append(itemAttributes, a)
+	}
+	return itemAttributes, nil
+}
+
+// TransitionAttributes gives the attributes of each file in a FileTransitions request, in the way FileAttributes does
+// for one file. A body that cannot be read as transitions gives just the Collection-Id header, and is put back for the
+// handler to reject.
+func TransitionAttributes(getMetadata GetFileMetadata) GetItemAttributesFromRequest {
+	return func(req *http.Request) ([]map[string]string, error) {
+		attributes, err := auth.GetCollectionIDAttribute(req)
+		if err != nil {
+			return nil, err
+		}
+
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+
+		var ft FileTransitions
+		if err := json.Unmarshal(body, &ft); err != nil || len(ft.Transitions) == 0 {
+			return []map[string]string{attributes}, nil
+		}
+
+		itemAttributes := make([]map[string]string, 0, len(ft.Transitions))
+		for _, t := range ft.Transitions {
+			a := maps.Clone(attributes)
+			metadata, err := getMetadata(req.Context(), t.Path)
+			if err != nil && !errors.Is(err, store.ErrFileNotRegistered) {
+				return nil, err
+			}
+			if err == nil && metadata.ContentItem != nil {
+				addDatasetEditionAttribute(a, metadata.ContentItem.DatasetID, metadata.ContentItem.Edition)
+			}
+			itemAttributes = append(itemAttributes, a)
+		}
+		return itemAttributes, nil
+	}
+}
+
+func attributesKey(attributes map[string]string) string {
+	pairs := make([]string, 0, len(attributes))
+	for _, k := range slices.Sorted(maps.Keys(attributes)) {
+		pairs = append(pairs, k+"="+attributes[k])
+	}
+	return strings.Join(pairs, "\x00")
+}
+
+// addDatasetEditionAttribute adds the dataset_edition attribute when both the dataset and edition are known, as it is
+// for reading a file
+func addDatasetEditionAttribute(attributes map[string]string, datasetID, edition string) {
+	if datasetID != "" && edition != "" {
+		attributes[datasetEditionAttribute] = datasetID + "/" + edition
+	}
+}
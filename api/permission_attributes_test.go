@@ -0,0 +1,285 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	auth "github.com/ONSdigital/dp-authorisation/v2/authorisation"
+	authMock "github.com/ONSdigital/dp-authorisation/v2/authorisation/mock"
+	"github.com/ONSdigital/dp-files-api/api"
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/dp-files-api/store"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileAttributes(t *testing.T) {
+	getMetadata := func(ctx context.Context, path string) (files.StoredRegisteredMetaData, error) {
+		switch path {
+		case "cpih/data.csv":
+			return files.StoredRegisteredMetaData{Path: path, ContentItem: &files.StoredContentItem{DatasetID: "cpih01", Edition: "feb-2026"}}, nil
+		case "no-content-item.csv":
+			return files.StoredRegisteredMetaData{Path: path}, nil
+		case "broken.csv":
+			return files.StoredRegisteredMetaData{}, errors.New("mongo is down")
+		}
+		return files.StoredRegisteredMetaData{}, store.ErrFileNotRegistered
+	}
+
+	tests := map[string]struct {
+		path       string
+		attributes map[string]string
+		err        bool
+	}{
+		"file with a content item": {path: "cpih/data.csv", attributes: map[string]string{"dataset_edition": "cpih01/feb-2026", "collection_id": "collection-1"}},
+		"file without one":         {path: "no-content-item.csv", attributes: map[string]string{"collection_id": "collection-1"}},
+		"unregistered file":        {path: "missing.csv", attributes: map[string]string{"collection_id": "collection-1"}},
+		"failed lookup":            {path: "broken.csv", err: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPatch, "/files/"+test.path, http.NoBody)
+			req.Header.Set("Collection-Id", "collection-1")
+			req = mux.SetURLVars(req, map[string]string{"path": test.path})
+
+			attributes, err := api.FileAttributes(getMetadata)(req)
+
+			assert.Equal(t, test.err, err != nil)
+			assert.Equal(t, test.attributes, attributes)
+		})
+	}
+}
+
+func TestFileTargetAttributes(t *testing.T) {
+	getMetadata := func(ctx context.Context, path string) (files.StoredRegisteredMetaData, error) {
+		return files.StoredRegisteredMetaData{Path: path, ContentItem: &files.StoredContentItem{DatasetID: "cpih01", Edition: "feb-2026"}}, nil
+	}
+
+	tests := map[string]struct {
+		body       string
+		attributes map[string]string
+	}{
+		"moved to a collection": {body: `{"collection_id":"collection-2"}`, attributes: map[string]string{"dataset_edition": "cpih01/feb-2026", "collection_id": "collection-2"}},
+		"moved to a bundle":     {body: `{"bundle_id":"bundle-2"}`, attributes: map[string]string{"dataset_edition": "cpih01/feb-2026", "collection_id": "collection-1", "bundle_id": "bundle-2"}},
+		"body that is not json": {body: `not json`, attributes: map[string]string{"dataset_edition": "cpih01/feb-2026", "collection_id": "collection-1"}},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPatch, "/files/cpih/data.csv", strings.NewReader(test.body))
+			req.Header.Set("Collection-Id", "collection-1")
+			req = mux.SetURLVars(req, map[string]string{"path": "cpih/data.csv"})
+
+			attributes, err := api.FileTargetAttributes(getMetadata)(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.attributes, attributes)
+			body, _ := io.ReadAll(req.Body)
+			assert.Equal(t, test.body, string(body), "the body is left for the handler")
+		})
+	}
+}
+
+func TestContentItemAttributes(t *testing.T) {
+	tests := map[string]struct {
+		body       string
+		attributes map[string]string
+	}{
+		"content item":          {body: `{"path":"data.csv","content_item":{"dataset_id":"cpih01","edition":"feb-2026","version":"1"}}`, attributes: map[string]string{"dataset_edition": "cpih01/feb-2026"}},
+		"no edition":            {body: `{"content_item":{"dataset_id":"cpih01"}}`, attributes: map[string]string{}},
+		"no content item":       {body: `{"path":"data.csv"}`, attributes: map[string]string{}},
+		"body that is not json": {body: `not json`, attributes: map[string]string{}},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/files", strings.NewReader(test.body))
+
+			attributes, err := api.ContentItemAttributes(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.attributes, attributes)
+			body, _ := io.ReadAll(req.Body)
+			assert.Equal(t, test.body, string(body), "the body is left for the handler")
+		})
+	}
+}
+
+func TestCollectionAndBundleAttributes(t *testing.T) {
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodPatch, "/collection/collection-1", http.NoBody), map[string]string{"collectionID": "collection-1"})
+	attributes, err := api.CollectionAttributes(req)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"collection_id": "collection-1"}, attributes)
+
+	req = mux.SetURLVars(httptest.NewRequest(http.MethodPatch, "/bundle/bundle-1", http.NoBody), map[string]string{"bundleID": "bundle-1"})
+	attributes, err = api.BundleAttributes(req)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"bundle_id": "bundle-1"}, attributes)
+}
+
+func TestCollectionAndBundleAttributesKeepCollectionIDHeader(t *testing.T) {
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodPatch, "/collection/collection-1", http.NoBody), map[string]string{"collectionID": "collection-1"})
+	req.Header.Set("Collection-Id", "collection-2")
+	attributes, err := api.CollectionAttributes(req)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"collection_id": "collection-1"}, attributes, "the collection in the path is the one changed")
+
+	req = mux.SetURLVars(httptest.NewRequest(http.MethodPatch, "/bundle/bundle-1", http.NoBody), map[string]string{"bundleID": "bundle-1"})
+	req.Header.Set("Collection-Id", "collection-2")
+	attributes, err = api.BundleAttributes(req)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"bundle_id": "bundle-1", "collection_id": "collection-2"}, attributes)
+}
+
+func TestTrashedFileAttributes(t *testing.T) {
+	getTrashedFile := func(ctx context.Context, path string) (files.TrashedFile, error) {
+		switch path {
+		case "cpih/data.csv":
+			return files.TrashedFile{Path: path, Metadata: files.StoredRegisteredMetaData{ContentItem: &files.StoredContentItem{DatasetID: "cpih01", Edition: "feb-2026"}}}, nil
+		case "broken.csv":
+			return files.TrashedFile{}, errors.New("mongo is down")
+		}
+		return files.TrashedFile{}, store.ErrFileNotInTrash
+	}
+
+	tests := map[string]struct {
+		path       string
+		attributes map[string]string
+		err        bool
+	}{
+		"trashed file with a content item": {path: "cpih/data.csv", attributes: map[string]string{"dataset_edition": "cpih01/feb-2026", "collection_id": "collection-1"}},
+		"file not in the trash":            {path: "missing.csv", attributes: map[string]string{"collection_id": "collection-1"}},
+		"failed lookup":                    {path: "broken.csv", err: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/files/"+test.path+"/restore", http.NoBody)
+			req.Header.Set("Collection-Id", "collection-1")
+			req = mux.SetURLVars(req, map[string]string{"path": test.path})
+
+			attributes, err := api.TrashedFileAttributes(getTrashedFile)(req)
+
+			assert.Equal(t, test.err, err != nil)
+			assert.Equal(t, test.attributes, attributes)
+		})
+	}
+}
+
+func TestBatchAttributes(t *testing.T) {
+	tests := map[string]struct {
+		body       string
+		attributes []map[string]string
+	}{
+		"files with and without content items": {
+			body: `[{"path":"a.csv","content_item":{"dataset_id":"cpih01","edition":"feb-2026"}},{"path":"b.csv"}]`,
+			attributes: []map[string]string{
+				{"dataset_edition": "cpih01/feb-2026", "collection_id": "collection-1"},
+				{"collection_id": "collection-1"},
+			},
+		},
+		"body that is not a batch": {body: `{"path":"a.csv"}`, attributes: []map[string]string{{"collection_id": "collection-1"}}},
+		"empty batch":              {body: `[]`, attributes: []map[string]string{{"collection_id": "collection-1"}}},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/files/batch", strings.NewReader(test.body))
+			req.Header.Set("Collection-Id", "collection-1")
+
+			attributes, err := api.BatchAttributes(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.attributes, attributes)
+			body, _ := io.ReadAll(req.Body)
+			assert.Equal(t, test.body, string(body), "the body is left for the handler")
+		})
+	}
+}
+
+func TestTransitionAttributes(t *testing.T) {
+	getMetadata := func(ctx context.Context, path string) (files.StoredRegisteredMetaData, error) {
+		switch path {
+		case "cpih/data.csv":
+			return files.StoredRegisteredMetaData{Path: path, ContentItem: &files.StoredContentItem{DatasetID: "cpih01", Edition: "feb-2026"}}, nil
+		case "broken.csv":
+			return files.StoredRegisteredMetaData{}, errors.New("mongo is down")
+		}
+		return files.StoredRegisteredMetaData{}, store.ErrFileNotRegistered
+	}
+
+	body := `{"transitions":[{"path":"cpih/data.csv","state":"PUBLISHED"},{"path":"missing.csv","state":"PUBLISHED"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/files/transitions", strings.NewReader(body))
+	attributes, err := api.TransitionAttributes(getMetadata)(req)
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]string{{"dataset_edition": "cpih01/feb-2026"}, {}}, attributes)
+	read, _ := io.ReadAll(req.Body)
+	assert.Equal(t, body, string(read), "the body is left for the handler")
+
+	req = httptest.NewRequest(http.MethodPost, "/files/transitions", strings.NewReader(`{"transitions":[{"path":"broken.csv","state":"PUBLISHED"}]}`))
+	_, err = api.TransitionAttributes(getMetadata)(req)
+	assert.Error(t, err)
+}
+
+func TestRequireForEachItem(t *testing.T) {
+	permitted := map[string]bool{"": true, "cpih01/feb-2026": true}
+	newAuthMiddleware := func() *authMock.MiddlewareMock {
+		return &authMock.MiddlewareMock{
+			RequireWithAttributesFunc: func(permission string, handlerFunc http.HandlerFunc, getAttributes auth.GetAttributesFromRequest) http.HandlerFunc {
+				return func(w http.ResponseWriter, req *http.Request) {
+					attributes, _ := getAttributes(req)
+					if !permitted[attributes["dataset_edition"]] {
+						w.WriteHeader(http.StatusForbidden)
+						return
+					}
+					handlerFunc(w, req)
+				}
+			},
+		}
+	}
+	handled := false
+	handler := func(w http.ResponseWriter, req *http.Request) { handled = true }
+
+	tests := map[string]struct {
+		attributes []map[string]string
+		status     int
+		checks     int
+	}{
+		"every file permitted": {
+			attributes: []map[string]string{{"dataset_edition": "cpih01/feb-2026"}, {}, {"dataset_edition": "cpih01/feb-2026"}},
+			status:     http.StatusOK,
+			checks:     2,
+		},
+		"one file not permitted": {
+			attributes: []map[string]string{{"dataset_edition": "cpih01/feb-2026"}, {"dataset_edition": "rpi/jan-2026"}},
+			status:     http.StatusForbidden,
+			checks:     2,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			authMiddleware := newAuthMiddleware()
+			handled = false
+			getItemAttributes := func(*http.Request) ([]map[string]string, error) { return test.attributes, nil }
+
+			rec := httptest.NewRecorder()
+			api.RequireForEachItem(authMiddleware, "static-files:update", handler, getItemAttributes)(rec, httptest.NewRequest(http.MethodPost, "/files/transitions", http.NoBody))
+
+			assert.Equal(t, test.status, rec.Code)
+			assert.Equal(t, test.status == http.StatusOK, handled)
+			assert.Len(t, authMiddleware.RequireWithAttributesCalls(), test.checks)
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	failing := func(*http.Request) ([]map[string]string, error) { return nil, errors.New("mongo is down") }
+	api.RequireForEachItem(newAuthMiddleware(), "static-files:update", handler, failing)(rec, httptest.NewRequest(http.MethodPost, "/files/transitions", http.NoBody))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+}
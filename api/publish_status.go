@@ -0,0 +1,40 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/gorilla/mux"
+)
+
+type GetCollectionPublishJob func(ctx context.Context, collectionID string) (files.PublishJob, error)
+
+type GetBundlePublishJob func(ctx context.Context, bundleID string) (files.PublishJob, error)
+
+func HandleGetCollectionPublishStatus(getPublishJob GetCollectionPublishJob) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		job, err := getPublishJob(req.Context(), mux.Vars(req)["collectionID"])
+		writePublishJob(w, job, err)
+	}
+}
+
+func HandleGetBundlePublishStatus(getPublishJob GetBundlePublishJob) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		job, err := getPublishJob(req.Context(), mux.Vars(req)["bundleID"])
+		writePublishJob(w, job, err)
+	}
+}
+
+func writePublishJob(w http.ResponseWriter, job files.PublishJob, err error) {
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(job); err != nil {
+		handleError(w, err)
+	}
+}
@@ -0,0 +1,71 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ONSdigital/dp-files-api/api"
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/dp-files-api/store"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetCollectionPublishStatusReturnsJob(t *testing.T) {
+	collectionID := "1234"
+	rec := httptest.NewRecorder()
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/collection/1234/publish-status", nil), map[string]string{"collectionID": collectionID})
+
+	var requestedID string
+	h := api.HandleGetCollectionPublishStatus(func(ctx context.Context, id string) (files.PublishJob, error) {
+		requestedID = id
+		return files.PublishJob{ID: "job", CollectionID: &collectionID, State: store.PublishJobStateInProgress, TotalFiles: 10, Sent: 4}, nil
+	})
+
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.Equal(t, collectionID, requestedID)
+
+	job := files.PublishJob{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&job))
+	assert.Equal(t, store.PublishJobStateInProgress, job.State)
+	assert.Equal(t, 10, job.TotalFiles)
+	assert.Equal(t, 4, job.Sent)
+}
+
+func TestGetBundlePublishStatusReturnsNotFound(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/bundle/789/publish-status", nil), map[string]string{"bundleID": "789"})
+
+	h := api.HandleGetBundlePublishStatus(func(ctx context.Context, id string) (files.PublishJob, error) {
+		return files.PublishJob{}, store.ErrPublishJobNotFound
+	})
+
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	response, _ := io.ReadAll(rec.Body)
+	assert.Contains(t, string(response), "PublishJobNotFound")
+}
+
+func TestGetBundlePublishStatusHandlesUnexpectedError(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/bundle/789/publish-status", nil), map[string]string{"bundleID": "789"})
+
+	h := api.HandleGetBundlePublishStatus(func(ctx context.Context, id string) (files.PublishJob, error) {
+		return files.PublishJob{}, errors.New("broken")
+	})
+
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	response, _ := io.ReadAll(rec.Body)
+	assert.Contains(t, string(response), "InternalError")
+}
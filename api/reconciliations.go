@@ -0,0 +1,65 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/gorilla/mux"
+)
+
+type StartReconciliation func(ctx context.Context) (*files.ReconciliationReport, error)
+type GetReconciliation func(ctx context.Context, id string) (files.ReconciliationReport, error)
+type GetLatestReconciliation func(ctx context.Context) (files.ReconciliationReport, error)
+
+// HandleReconcile starts comparing the metadata of the files with the objects in the private bucket, responding with
+// the report while it is IN_PROGRESS. The finished report can be got from GET /reconciliations/{id}.
+func HandleReconcile(startReconciliation StartReconciliation) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		report, err := startReconciliation(req.Context())
+		if err != nil {
+			handleError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		if err := json.NewEncoder(w).Encode(report); err != nil {
+			handleError(w, err)
+		}
+	}
+}
+
+// HandleGetReconciliation responds with the report of the reconciliation with the ID in the path
+func HandleGetReconciliation(getReconciliation GetReconciliation) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		report, err := getReconciliation(req.Context(), mux.Vars(req)["id"])
+		if err != nil {
+			handleError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(report); err != nil {
+			handleError(w, err)
+		}
+	}
+}
+
+// HandleGetLatestReconciliation responds with the report of the most recent reconciliation, whether it was requested
+// or scheduled
+func HandleGetLatestReconciliation(getLatestReconciliation GetLatestReconciliation) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		report, err := getLatestReconciliation(req.Context())
+		if err != nil {
+			handleError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(report); err != nil {
+			handleError(w, err)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ONSdigital/dp-files-api/api"
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/dp-files-api/store"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReconcileReturnsStartedReport(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/reconciliations", nil)
+
+	h := api.HandleReconcile(func(ctx context.Context) (*files.ReconciliationReport, error) {
+		return &files.ReconciliationReport{ID: "report-1", State: store.ReconciliationStateInProgress}, nil
+	})
+
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	body := map[string]interface{}{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	assert.Equal(t, "report-1", body["id"])
+	assert.Equal(t, "IN_PROGRESS", body["state"])
+	assert.NotContains(t, body, "completed_at")
+}
+
+func TestReconcileAlreadyRunning(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/reconciliations", nil)
+
+	h := api.HandleReconcile(func(ctx context.Context) (*files.ReconciliationReport, error) {
+		return nil, store.ErrReconciliationInProgress
+	})
+
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Contains(t, rec.Body.String(), "ReconciliationInProgress")
+}
+
+func TestReconcileError(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/reconciliations", nil)
+
+	h := api.HandleReconcile(func(ctx context.Context) (*files.ReconciliationReport, error) {
+		return nil, errors.New("broken")
+	})
+
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+}
+
+func TestGetLatestReconciliationReturnsReport(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/reconciliations/latest", nil)
+
+	h := api.HandleGetLatestReconciliation(func(ctx context.Context) (files.ReconciliationReport, error) {
+		return files.ReconciliationReport{ID: "report-1", OrphanedObjectCount: 1, OrphanedObjects: []string{"orphan.csv"}}, nil
+	})
+
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	body := map[string]interface{}{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	assert.Equal(t, "report-1", body["id"])
+	assert.Equal(t, []interface{}{"orphan.csv"}, body["orphaned_objects"])
+}
+
+func TestGetLatestReconciliationNotFound(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/reconciliations/latest", nil)
+
+	h := api.HandleGetLatestReconciliation(func(ctx context.Context) (files.ReconciliationReport, error) {
+		return files.ReconciliationReport{}, store.ErrReconciliationNotFound
+	})
+
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Contains(t, rec.Body.String(), "ReconciliationNotFound")
+}
+
+func TestGetReconciliationReturnsReport(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/reconciliations/report-1", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "report-1"})
+
+	var requestedID string
+	h := api.HandleGetReconciliation(func(ctx context.Context, id string) (files.ReconciliationReport, error) {
+		requestedID = id
+		return files.ReconciliationReport{ID: id, State: store.ReconciliationStateCompleted, MissingObjectCount: 1, MissingObjects: []string{"missing.csv"}}, nil
+	})
+
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "report-1", requestedID)
+
+	body := map[string]interface{}{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	assert.Equal(t, "COMPLETED", body["state"])
+	assert.Equal(t, []interface{}{"missing.csv"}, body["missing_objects"])
+}
+
+func TestGetReconciliationNotFound(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/reconciliations/missing", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "missing"})
+
+	h := api.HandleGetReconciliation(func(ctx context.Context, id string) (files.ReconciliationReport, error) {
+		return files.ReconciliationReport{}, store.ErrReconciliationNotFound
+	})
+
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
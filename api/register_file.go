@@ -20,16 +20,18 @@ import (
 type RegisterFileUpload func(ctx context.Context, metaData files.StoredRegisteredMetaData) error
 
 type RegisterMetadata struct {
-	Path          string       `json:"path" validate:"required,aws-upload-key"`
-	IsPublishable *bool        `json:"is_publishable,omitempty" validate:"required"`
-	CollectionID  *string      `json:"collection_id,omitempty"`
-	BundleID      *string      `json:"bundle_id,omitempty"`
-	Title         string       `json:"title"`
-	SizeInBytes   uint64       `json:"size_in_bytes" validate:"gt=0"`
-	Type          string       `json:"type"`
-	Licence       string       `json:"licence" validate:"required"`
-	LicenceURL    string       `json:"licence_url" validate:"required"`
-	ContentItem   *ContentItem `json:"content_item,omitempty"`
+	Path           string       `json:"path" validate:"required,aws-upload-key"`
+	IsPublishable  *bool        `json:"is_publishable,omitempty" validate:"required"`
+	CollectionID   *string      `json:"collection_id,omitempty"`
+	BundleID       *string      `json:"bundle_id,omitempty"`
+	Title          string       `json:"title"`
+	SizeInBytes    uint64       `json:"size_in_bytes" validate:"gt=0"`
+	Type           string       `json:"type"`
+	Licence        string       `json:"licence" validate:"required"`
+	LicenceURL     string       `json:"licence_url" validate:"required"`
+	ContentItem    *ContentItem `json:"content_item,omitempty"`
+	ChecksumSHA256 string       `json:"checksum_sha256,omitempty" validate:"omitempty,len=64,hexadecimal"`
+	ChecksumMD5    string       `json:"checksum_md5,omitempty" validate:"omitempty,len=32,hexadecimal"`
 }
 
 type ContentItem struct {
@@ -58,6 +60,7 @@ func HandlerRegisterUploadStarted(register RegisterFileUpload, createFileEvent C
 			writeError(w, buildGenericError("Forbidden", "the request was not authorised - check token and user's permissions"), http.StatusForbidden)
 			return
 		}
+		ctx = dprequest.SetCaller(ctx, authEntityData.EntityData.UserID)
 
 		identityType := log.USER
 		if authEntityData.IsServiceAuth {
@@ -132,15 +135,17 @@ func generateStoredRegisterMetaData(m RegisterMetadata) files.StoredRegisteredMe
 	}
 
 	return files.StoredRegisteredMetaData{
-		Path:          m.Path,
-		IsPublishable: *m.IsPublishable,
-		CollectionID:  m.CollectionID,
-		BundleID:      m.BundleID,
-		Title:         m.Title,
-		SizeInBytes:   m.SizeInBytes,
-		Type:          m.Type,
-		Licence:       m.Licence,
-		LicenceURL:    m.LicenceURL,
-		ContentItem:   contentItem,
+		Path:           m.Path,
+		IsPublishable:  *m.IsPublishable,
+		CollectionID:   m.CollectionID,
+		BundleID:       m.BundleID,
+		Title:          m.Title,
+		SizeInBytes:    m.SizeInBytes,
+		Type:           m.Type,
+		Licence:        m.Licence,
+		LicenceURL:     m.LicenceURL,
+		ContentItem:    contentItem,
+		ChecksumSHA256: strings.ToLower(m.ChecksumSHA256),
+		ChecksumMD5:    strings.ToLower(m.ChecksumMD5),
 	}
 }
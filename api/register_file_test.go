@@ -15,6 +15,7 @@ import (
 	"github.com/ONSdigital/dp-authorisation/v2/authorisationtest"
 	"github.com/ONSdigital/dp-files-api/api"
 	"github.com/ONSdigital/dp-files-api/files"
+	dprequest "github.com/ONSdigital/dp-net/v3/request"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -149,6 +150,16 @@ func TestValidationMetaDataCreation(t *testing.T) {
 			incomingJSON:             `{"path": "some/file.txt", "is_publishable":false,"collection_id":"1234-asdfg-54321-qwerty","title":"The latest Meme", "size_in_bytes": 10, "type":"image/jpeg","licence":"OGL v3"}`,
 			expectedErrorDescription: "LicenceURL required",
 		},
+		{
+			name:                     "Validate that checksum_sha256 is a SHA-256 hex digest",
+			incomingJSON:             `{"path": "some/file.txt", "is_publishable":false,"title":"The latest Meme", "size_in_bytes": 10, "type":"image/jpeg","licence":"OGL v3","licence_url":"http://www.nationalarchives.gov.uk/doc/open-government-licence/version/3/","checksum_sha256":"abc123"}`,
+			expectedErrorDescription: "ChecksumSHA256 len",
+		},
+		{
+			name:                     "Validate that checksum_md5 is hexadecimal",
+			incomingJSON:             `{"path": "some/file.txt", "is_publishable":false,"title":"The latest Meme", "size_in_bytes": 10, "type":"image/jpeg","licence":"OGL v3","licence_url":"http://www.nationalarchives.gov.uk/doc/open-government-licence/version/3/","checksum_md5":"zzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzz"}`,
+			expectedErrorDescription: "ChecksumMD5 hexadecimal",
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -196,6 +207,29 @@ func TestCollectionIDOmittedFromBodyDoesNotRaiseError(t *testing.T) {
 	assert.Equal(t, http.StatusCreated, rec.Code)
 }
 
+func TestRegisterFileRecordsCallerOnContext(t *testing.T) {
+	body := `{"path": "some/file.txt", "is_publishable":false,"title":"The latest Meme","size_in_bytes":14794,"type":"image/jpeg","licence":"OGL v3","licence_url":"http://www.nationalarchives.gov.uk/doc/open-government-licence/version/3/"}`
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/files", strings.NewReader(body))
+	req.Header.Add("Authorization", authorisationtest.AdminJWTToken)
+
+	var caller string
+	registerFileFunc := func(ctx context.Context, metaData files.StoredRegisteredMetaData) error {
+		caller = dprequest.Caller(ctx)
+		return nil
+	}
+	createFileEventFunc := func(ctx context.Context, event *files.FileEvent) error {
+		return nil
+	}
+	authMock, identityClientMock, _ := setUpAuthServices()
+
+	h := api.HandlerRegisterUploadStarted(registerFileFunc, createFileEventFunc, authMock, identityClientMock, 5*time.Second)
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "admin", caller)
+}
+
 func TestCollectionIDInBodyDoesNotRaiseError(t *testing.T) {
 	collectionID := "1234"
 	body := fmt.Sprintf(`{"path": "some/file.txt", "collection_id": %q, "is_publishable":false,"title":"The latest Meme","size_in_bytes":14794,"type":"image/jpeg","licence":"OGL v3","licence_url":"http://www.nationalarchives.gov.uk/doc/open-government-licence/version/3/"}`, collectionID)
@@ -343,3 +377,35 @@ func TestContentItemEmptyObjectDoesNotRaiseError(t *testing.T) {
 
 	assert.Equal(t, http.StatusCreated, rec.Code)
 }
+
+func TestChecksumsInBodyAreStoredInLowerCase(t *testing.T) {
+	body := `{
+		"path": "some/file.txt",
+		"is_publishable":false,
+		"title":"The latest Meme",
+		"size_in_bytes":14794,
+		"type":"image/jpeg",
+		"licence":"OGL v3",
+		"licence_url":"http://www.nationalarchives.gov.uk/doc/open-government-licence/version/3/",
+		"checksum_sha256": "ED7002B439E9AC845F22357D822BAC1444730FBDB6016D3EC9432297B9EC9F73",
+		"checksum_md5": "D41D8CD98F00B204E9800998ECF8427E"
+	}`
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/files", strings.NewReader(body))
+	req.Header.Add("Authorization", authorisationtest.AdminJWTToken)
+
+	registerFileFunc := func(ctx context.Context, metaData files.StoredRegisteredMetaData) error {
+		assert.Equal(t, "ed7002b439e9ac845f22357d822bac1444730fbdb6016d3ec9432297b9ec9f73", metaData.ChecksumSHA256)
+		assert.Equal(t, "d41d8cd98f00b204e9800998ecf8427e", metaData.ChecksumMD5)
+		return nil
+	}
+	createFileEventFunc := func(ctx context.Context, event *files.FileEvent) error {
+		return nil
+	}
+	authMock, identityClientMock, _ := setUpAuthServices()
+
+	h := api.HandlerRegisterUploadStarted(registerFileFunc, createFileEventFunc, authMock, identityClientMock, 5*time.Second)
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+}
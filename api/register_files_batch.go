@@ -0,0 +1,131 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	clientsidentity "github.com/ONSdigital/dp-api-clients-go/v2/identity"
+	auth "github.com/ONSdigital/dp-authorisation/v2/authorisation"
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/dp-files-api/store"
+	dprequest "github.com/ONSdigital/dp-net/v3/request"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+type RegisterFileUploads func(ctx context.Context, metaData []files.StoredRegisteredMetaData) ([]files.RegistrationResult, error)
+
+type CreateFileEvents func(ctx context.Context, events []*files.FileEvent) error
+
+// HandlerRegisterFilesBatch registers each file in an array of RegisterMetadata, responding with the result for each
+// file in the order given. A file that fails validation is reported as invalid without stopping the rest of the batch.
+func HandlerRegisterFilesBatch(register RegisterFileUploads, createFileEvents CreateFileEvents, authMiddleware auth.Middleware, identityClient *clientsidentity.Client, maxSize int, deadlineDuration time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx, cancel := context.WithTimeout(req.Context(), deadlineDuration)
+		defer cancel()
+
+		logData := log.Data{
+			"method": req.Method,
+		}
+
+		accessToken := strings.TrimPrefix(req.Header.Get(dprequest.AuthHeaderKey), dprequest.BearerPrefix)
+
+		authEntityData, err := getAuthEntityData(ctx, authMiddleware, identityClient, accessToken, logData)
+		if err != nil {
+			if strings.Contains(err.Error(), "key id unknown or invalid") || strings.Contains(err.Error(), "jwt token is malformed") {
+				writeError(w, buildGenericError("Unauthorised", "the request was not authorised"), http.StatusUnauthorized)
+				return
+			}
+			writeError(w, buildGenericError("Forbidden", "the request was not authorised - check token and user's permissions"), http.StatusForbidden)
+			return
+		}
+		ctx = dprequest.SetCaller(ctx, authEntityData.EntityData.UserID)
+
+		identityType := log.USER
+		if authEntityData.IsServiceAuth {
+			identityType = log.SERVICE
+		}
+		logAuth := log.Auth(identityType, authEntityData.EntityData.UserID)
+
+		var batch []RegisterMetadata
+		if err := json.NewDecoder(req.Body).Decode(&batch); err != nil {
+			writeError(w, buildErrors(err, "BadJsonEncoding"), http.StatusBadRequest)
+			return
+		}
+
+		if len(batch) == 0 || len(batch) > maxSize {
+			err := fmt.Errorf("a batch must contain between 1 and %d files", maxSize)
+			writeError(w, buildErrors(err, "InvalidBatchSize"), http.StatusBadRequest)
+			return
+		}
+
+		results := make([]files.RegistrationResult, len(batch))
+		valid := make([]files.StoredRegisteredMetaData, 0, len(batch))
+		validIndexes := make([]int, 0, len(batch))
+		events := make([]*files.FileEvent, 0, len(batch))
+		for i, rm := range batch {
+			results[i] = files.RegistrationResult{Path: rm.Path}
+
+			if err := validateBatchRegisterMetadata(rm); err != nil {
+				results[i].Result = files.RegistrationInvalid
+				results[i].Error = err.Error()
+				continue
+			}
+
+			storedRegisterMetadata := generateStoredRegisterMetaData(rm)
+			valid = append(valid, storedRegisterMetadata)
+			validIndexes = append(validIndexes, i)
+			events = append(events, &files.FileEvent{
+				RequestedBy: &files.RequestedBy{
+					ID: authEntityData.EntityData.UserID,
+				},
+				Action:   files.ActionCreate,
+				Resource: rm.Path,
+				File:     &storedRegisterMetadata,
+			})
+		}
+
+		if len(valid) > 0 {
+			if err := createFileEvents(ctx, events); err != nil {
+				log.Error(ctx, "failed to create file events", err, log.Classification(log.ProtectiveMonitoring), logAuth, logData)
+				handleError(w, err)
+				return
+			}
+			log.Info(ctx, "successfully created file events for batch file creation", log.Classification(log.ProtectiveMonitoring), logAuth, logData)
+
+			registered, err := register(ctx, valid)
+			if err != nil {
+				handleError(w, err)
+				return
+			}
+			for j, i := range validIndexes {
+				results[i] = registered[j]
+			}
+		}
+
+		response := files.RegistrationResults{
+			Count: len(results),
+			Items: results,
+		}
+		for _, result := range results {
+			if result.Result == files.RegistrationCreated {
+				response.Created++
+			}
+		}
+
+		w.Header().Add("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}
+}
+
+func validateBatchRegisterMetadata(rm RegisterMetadata) error {
+	if rm.CollectionID != nil && rm.BundleID != nil {
+		return store.ErrBothCollectionAndBundleIDSet
+	}
+	return validateRegisterMetadata(rm)
+}
@@ -0,0 +1,141 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-authorisation/v2/authorisationtest"
+	"github.com/ONSdigital/dp-files-api/api"
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/stretchr/testify/assert"
+)
+
+const batchFile = `{
+          "path": "%s",
+          "is_publishable": true,
+          "collection_id": "1234-asdfg-54321-qwerty",
+          "title": "The latest Meme",
+          "size_in_bytes": 14794,
+          "type": "image/jpeg",
+          "licence": "OGL v3",
+          "licence_url": "http://www.nationalarchives.gov.uk/doc/open-government-licence/version/3/"
+        }`
+
+func TestRegisterFilesBatchReportsResultForEachFile(t *testing.T) {
+	rec := httptest.NewRecorder()
+	body := bytes.NewBufferString(`[` +
+		fmtBatchFile("images/one.jpg") + `,` +
+		`{"path": "images/two.jpg", "is_publishable": true, "size_in_bytes": 0, "licence": "OGL v3", "licence_url": "http://example.com"},` +
+		fmtBatchFile("images/three.jpg") + `]`)
+	req := httptest.NewRequest(http.MethodPost, "/files/batch", body)
+	req.Header.Add("Authorization", authorisationtest.AdminJWTToken)
+
+	var registered []files.StoredRegisteredMetaData
+	registerFunc := func(ctx context.Context, metaData []files.StoredRegisteredMetaData) ([]files.RegistrationResult, error) {
+		registered = metaData
+		return []files.RegistrationResult{
+			{Path: "images/one.jpg", Result: files.RegistrationCreated},
+			{Path: "images/three.jpg", Result: files.RegistrationDuplicate},
+		}, nil
+	}
+	var events []*files.FileEvent
+	createFileEventsFunc := func(ctx context.Context, e []*files.FileEvent) error {
+		events = e
+		return nil
+	}
+	authMock, identityClientMock, _ := setUpAuthServices()
+
+	h := api.HandlerRegisterFilesBatch(registerFunc, createFileEventsFunc, authMock, identityClientMock, 10, 5*time.Second)
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var results files.RegistrationResults
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&results))
+	assert.Equal(t, 3, results.Count)
+	assert.Equal(t, 1, results.Created)
+	assert.Equal(t, files.RegistrationCreated, results.Items[0].Result)
+	assert.Equal(t, "images/two.jpg", results.Items[1].Path)
+	assert.Equal(t, files.RegistrationInvalid, results.Items[1].Result)
+	assert.Contains(t, results.Items[1].Error, "SizeInBytes")
+	assert.Equal(t, files.RegistrationDuplicate, results.Items[2].Result)
+
+	assert.Len(t, registered, 2)
+	assert.Len(t, events, 2)
+	assert.Equal(t, files.ActionCreate, events[0].Action)
+	assert.Equal(t, "images/one.jpg", events[0].Resource)
+	assert.Equal(t, "images/three.jpg", events[1].File.Path)
+}
+
+func TestRegisterFilesBatchRejectsBothCollectionAndBundleID(t *testing.T) {
+	rec := httptest.NewRecorder()
+	body := bytes.NewBufferString(`[{"path": "images/one.jpg", "is_publishable": true, "collection_id": "c1", "bundle_id": "b1", "size_in_bytes": 10, "licence": "OGL v3", "licence_url": "http://example.com"}]`)
+	req := httptest.NewRequest(http.MethodPost, "/files/batch", body)
+	req.Header.Add("Authorization", authorisationtest.AdminJWTToken)
+
+	registerFunc := func(ctx context.Context, metaData []files.StoredRegisteredMetaData) ([]files.RegistrationResult, error) {
+		t.Fatal("no files should be registered")
+		return nil, nil
+	}
+	createFileEventsFunc := func(ctx context.Context, e []*files.FileEvent) error {
+		t.Fatal("no file events should be created")
+		return nil
+	}
+	authMock, identityClientMock, _ := setUpAuthServices()
+
+	h := api.HandlerRegisterFilesBatch(registerFunc, createFileEventsFunc, authMock, identityClientMock, 10, 5*time.Second)
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var results files.RegistrationResults
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&results))
+	assert.Equal(t, 0, results.Created)
+	assert.Equal(t, files.RegistrationInvalid, results.Items[0].Result)
+}
+
+func TestRegisterFilesBatchRejectsInvalidBatchSize(t *testing.T) {
+	authMock, identityClientMock, _ := setUpAuthServices()
+	h := api.HandlerRegisterFilesBatch(nil, nil, authMock, identityClientMock, 1, 5*time.Second)
+
+	for _, body := range []string{`[]`, `[` + fmtBatchFile("a.jpg") + `,` + fmtBatchFile("b.jpg") + `]`} {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/files/batch", bytes.NewBufferString(body))
+		req.Header.Add("Authorization", authorisationtest.AdminJWTToken)
+
+		h.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		response, _ := io.ReadAll(rec.Body)
+		assert.Contains(t, string(response), "InvalidBatchSize")
+	}
+}
+
+func TestRegisterFilesBatchRegistrationFails(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/files/batch", bytes.NewBufferString(`[`+fmtBatchFile("images/one.jpg")+`]`))
+	req.Header.Add("Authorization", authorisationtest.AdminJWTToken)
+
+	registerFunc := func(ctx context.Context, metaData []files.StoredRegisteredMetaData) ([]files.RegistrationResult, error) {
+		return nil, errors.New("it's all gone very wrong")
+	}
+	createFileEventsFunc := func(ctx context.Context, e []*files.FileEvent) error {
+		return nil
+	}
+	authMock, identityClientMock, _ := setUpAuthServices()
+
+	h := api.HandlerRegisterFilesBatch(registerFunc, createFileEventsFunc, authMock, identityClientMock, 10, 5*time.Second)
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+}
+
+func fmtBatchFile(path string) string {
+	return fmt.Sprintf(batchFile, path)
+}
@@ -50,6 +50,7 @@ func HandlerUpdateContentItem(updateContentItem UpdateContentItem, createFileEve
 			writeError(w, buildGenericError("Forbidden", "the request was not authorised - check token and user's permissions"), http.StatusForbidden)
 			return
 		}
+		ctx = dprequest.SetCaller(ctx, authEntityData.EntityData.UserID)
 
 		logData["entity_data"] = authEntityData
 
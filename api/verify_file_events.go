@@ -0,0 +1,28 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/ONSdigital/dp-files-api/files"
+)
+
+type VerifyFileEventChain func(ctx context.Context) (*files.FileEventChainVerification, error)
+
+// HandleVerifyFileEvents walks the chain of file events, responding with whether it is intact and, if it is not, where
+// it was first found to be broken
+func HandleVerifyFileEvents(verifyFileEventChain VerifyFileEventChain) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		verification, err := verifyFileEventChain(req.Context())
+		if err != nil {
+			handleError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(verification); err != nil {
+			handleError(w, err)
+		}
+	}
+}
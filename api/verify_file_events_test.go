@@ -0,0 +1,52 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ONSdigital/dp-files-api/api"
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyFileEventsReportsBreak(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/file-events/verify", nil)
+
+	h := api.HandleVerifyFileEvents(func(ctx context.Context) (*files.FileEventChainVerification, error) {
+		return &files.FileEventChainVerification{
+			EventsChecked: 3,
+			LastSequence:  2,
+			LastHash:      "abc",
+			Break:         &files.FileEventChainBreak{Sequence: 3, Reason: files.ChainBreakHashMismatch},
+		}, nil
+	})
+
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	body := map[string]interface{}{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	assert.Equal(t, false, body["verified"])
+	assert.Equal(t, float64(2), body["last_sequence"])
+	assert.Equal(t, map[string]interface{}{"sequence": float64(3), "reason": "HASH_MISMATCH"}, body["break"])
+}
+
+func TestVerifyFileEventsError(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/file-events/verify", nil)
+
+	h := api.HandleVerifyFileEvents(func(ctx context.Context) (*files.FileEventChainVerification, error) {
+		return nil, errors.New("broken")
+	})
+
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+}
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	clientsidentity "github.com/ONSdigital/dp-api-clients-go/v2/identity"
+	auth "github.com/ONSdigital/dp-authorisation/v2/authorisation"
+	"github.com/ONSdigital/dp-files-api/files"
+	dprequest "github.com/ONSdigital/dp-net/v3/request"
+	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/gorilla/mux"
+)
+
+type WithdrawFile func(ctx context.Context, path, reason string) error
+
+type WithdrawCollection func(ctx context.Context, collectionID, reason string) error
+
+type WithdrawBundle func(ctx context.Context, bundleID, reason string) error
+
+// Withdrawal is the reason given for withdrawing a file, collection or bundle
+type Withdrawal struct {
+	Reason string `json:"reason"`
+}
+
+// HandleWithdrawFile withdraws a published file. It is reached through PATCH /files/{path} with the WITHDRAWN state.
+func HandleWithdrawFile(withdrawFile WithdrawFile, createFileEvent CreateFileEvent, getFileMetadata GetFileMetadata, authMiddleware auth.Middleware, idClient *clientsidentity.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		path := mux.Vars(req)["path"]
+		logData := log.Data{
+			"method": req.Method,
+			"path":   path,
+		}
+
+		ctx, authEntityData, ok := authoriseWithdrawal(w, req, authMiddleware, idClient, logData)
+		if !ok {
+			return
+		}
+
+		var withdrawal Withdrawal
+		if err := json.NewDecoder(req.Body).Decode(&withdrawal); err != nil {
+			writeError(w, buildErrors(err, "BadJsonEncoding"), http.StatusBadRequest)
+			return
+		}
+
+		fileMetadata, err := getFileMetadata(ctx, path)
+		if err != nil {
+			log.Error(ctx, "failed to get file metadata for audit record", err, logData)
+			handleError(w, err)
+			return
+		}
+
+		if err := createAuditEvent(ctx, createFileEvent, authEntityData.EntityData, authEntityData.IsServiceAuth, files.ActionUpdate, path, &fileMetadata, logData); err != nil {
+			handleError(w, err)
+			return
+		}
+
+		if err := withdrawFile(ctx, path, withdrawal.Reason); err != nil {
+			handleError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// HandleWithdrawCollection withdraws a published collection, accepting the withdrawal of every file in it to be made in
+// the background
+func HandleWithdrawCollection(withdrawCollection WithdrawCollection, authMiddleware auth.Middleware, idClient *clientsidentity.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		collectionID := mux.Vars(req)["collectionID"]
+		logData := log.Data{
+			"method":        req.Method,
+			"collection_id": collectionID,
+		}
+
+		ctx, _, ok := authoriseWithdrawal(w, req, authMiddleware, idClient, logData)
+		if !ok {
+			return
+		}
+
+		var withdrawal Withdrawal
+		if err := json.NewDecoder(req.Body).Decode(&withdrawal); err != nil {
+			writeError(w, buildErrors(err, "BadJsonEncoding"), http.StatusBadRequest)
+			return
+		}
+
+		if err := withdrawCollection(ctx, collectionID, withdrawal.Reason); err != nil {
+			handleError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// HandleWithdrawBundle withdraws a published bundle, accepting the withdrawal of every file in it to be made in the
+// background
+func HandleWithdrawBundle(withdrawBundle WithdrawBundle, authMiddleware auth.Middleware, idClient *clientsidentity.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		bundleID := mux.Vars(req)["bundleID"]
+		logData := log.Data{
+			"method":    req.Method,
+			"bundle_id": bundleID,
+		}
+
+		ctx, _, ok := authoriseWithdrawal(w, req, authMiddleware, idClient, logData)
+		if !ok {
+			return
+		}
+
+		var withdrawal Withdrawal
+		if err := json.NewDecoder(req.Body).Decode(&withdrawal); err != nil {
+			writeError(w, buildErrors(err, "BadJsonEncoding"), http.StatusBadRequest)
+			return
+		}
+
+		if err := withdrawBundle(ctx, bundleID, withdrawal.Reason); err != nil {
+			handleError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// authoriseWithdrawal finds who is making a withdrawal, so that it can be recorded against them. It writes the error
+// response when they cannot be identified.
+func authoriseWithdrawal(w http.ResponseWriter, req *http.Request, authMiddleware auth.Middleware, idClient *clientsidentity.Client, logData log.Data) (context.Context, *AuthEntityData, bool) {
+	ctx := req.Context()
+
+	accessToken := strings.TrimPrefix(req.Header.Get(dprequest.AuthHeaderKey), dprequest.BearerPrefix)
+	if accessToken == "" {
+		log.Info(ctx, "authorisation failed: no authorisation header in request", log.Classification(log.ProtectiveMonitoring), logData)
+		writeError(w, buildGenericError("Unauthorised", "The user is unauthorised"), http.StatusUnauthorized)
+		return ctx, nil, false
+	}
+
+	authEntityData, err := getAuthEntityData(ctx, authMiddleware, idClient, accessToken, logData)
+	if err != nil {
+		log.Error(ctx, "failed to get auth entity data", err, logData)
+		if strings.Contains(err.Error(), "key id unknown or invalid") || strings.Contains(err.Error(), "jwt token is malformed") {
+			writeError(w, buildGenericError("Unauthorised", "the request was not authorised"), http.StatusUnauthorized)
+			return ctx, nil, false
+		}
+		writeError(w, buildGenericError("Forbidden", "the request was not authorised - check token and user's permissions"), http.StatusForbidden)
+		return ctx, nil, false
+	}
+
+	return dprequest.SetCaller(ctx, authEntityData.EntityData.UserID), authEntityData, true
+}
@@ -0,0 +1,168 @@
+package api_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dp-authorisation/v2/authorisationtest"
+	"github.com/ONSdigital/dp-files-api/api"
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/dp-files-api/store"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithdrawFileRecordsReasonAndAuditEvent(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPatch, "/files/data/file.csv", strings.NewReader(`{"state": "WITHDRAWN", "reason": "released in error"}`))
+	req = mux.SetURLVars(req, map[string]string{"path": "data/file.csv"})
+	req.Header.Add("Authorization", authorisationtest.AdminJWTToken)
+
+	var withdrawnPath, withdrawnReason string
+	var auditEvent *files.FileEvent
+	authMiddlewareMock, identityClientMock, _ := setUpAuthServices()
+
+	h := api.HandleWithdrawFile(
+		func(ctx context.Context, path, reason string) error {
+			withdrawnPath, withdrawnReason = path, reason
+			return nil
+		},
+		func(ctx context.Context, event *files.FileEvent) error {
+			auditEvent = event
+			return nil
+		},
+		func(ctx context.Context, path string) (files.StoredRegisteredMetaData, error) {
+			return files.StoredRegisteredMetaData{Path: path, State: store.StateMoved}, nil
+		},
+		authMiddlewareMock,
+		identityClientMock,
+	)
+
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "data/file.csv", withdrawnPath)
+	assert.Equal(t, "released in error", withdrawnReason)
+	assert.NotNil(t, auditEvent)
+	assert.Equal(t, files.ActionUpdate, auditEvent.Action)
+	assert.Equal(t, "data/file.csv", auditEvent.Resource)
+}
+
+func TestWithdrawFileHandlesStoreErrors(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{store.ErrWithdrawalReasonRequired, http.StatusBadRequest, "WithdrawalReasonRequired"},
+		{store.ErrFileNotPublished, http.StatusConflict, "FileNotPublished"},
+		{store.ErrFileWithdrawn, http.StatusConflict, "FileWithdrawn"},
+	}
+
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPatch, "/files/file.csv", strings.NewReader(`{"state": "WITHDRAWN"}`))
+		req.Header.Add("Authorization", authorisationtest.AdminJWTToken)
+		authMiddlewareMock, identityClientMock, _ := setUpAuthServices()
+
+		h := api.HandleWithdrawFile(
+			func(ctx context.Context, path, reason string) error { return test.err },
+			func(ctx context.Context, event *files.FileEvent) error { return nil },
+			func(ctx context.Context, path string) (files.StoredRegisteredMetaData, error) {
+				return files.StoredRegisteredMetaData{}, nil
+			},
+			authMiddlewareMock,
+			identityClientMock,
+		)
+
+		h.ServeHTTP(rec, req)
+
+		assert.Equal(t, test.status, rec.Code)
+		response, _ := io.ReadAll(rec.Body)
+		assert.Contains(t, string(response), test.code)
+	}
+}
+
+func TestWithdrawFileRequiresAuthorisation(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPatch, "/files/file.csv", strings.NewReader(`{"reason": "released in error"}`))
+	authMiddlewareMock, identityClientMock, _ := setUpAuthServices()
+
+	h := api.HandleWithdrawFile(nil, nil, nil, authMiddlewareMock, identityClientMock)
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestWithdrawCollection(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/collection/coll1/withdraw", strings.NewReader(`{"reason": "released in error"}`))
+	req = mux.SetURLVars(req, map[string]string{"collectionID": "coll1"})
+	req.Header.Add("Authorization", authorisationtest.AdminJWTToken)
+
+	var withdrawnID, withdrawnReason string
+	authMiddlewareMock, identityClientMock, _ := setUpAuthServices()
+
+	h := api.HandleWithdrawCollection(func(ctx context.Context, collectionID, reason string) error {
+		withdrawnID, withdrawnReason = collectionID, reason
+		return nil
+	}, authMiddlewareMock, identityClientMock)
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Equal(t, "coll1", withdrawnID)
+	assert.Equal(t, "released in error", withdrawnReason)
+}
+
+func TestWithdrawCollectionNotPublished(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/collection/coll1/withdraw", strings.NewReader(`{"reason": "released in error"}`))
+	req.Header.Add("Authorization", authorisationtest.AdminJWTToken)
+	authMiddlewareMock, identityClientMock, _ := setUpAuthServices()
+
+	h := api.HandleWithdrawCollection(func(ctx context.Context, collectionID, reason string) error {
+		return store.ErrCollectionNotPublished
+	}, authMiddlewareMock, identityClientMock)
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	response, _ := io.ReadAll(rec.Body)
+	assert.Contains(t, string(response), "CollectionNotPublished")
+}
+
+func TestWithdrawBundle(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/bundle/bundle1/withdraw", strings.NewReader(`{"reason": "released in error"}`))
+	req = mux.SetURLVars(req, map[string]string{"bundleID": "bundle1"})
+	req.Header.Add("Authorization", authorisationtest.AdminJWTToken)
+
+	var withdrawnID string
+	authMiddlewareMock, identityClientMock, _ := setUpAuthServices()
+
+	h := api.HandleWithdrawBundle(func(ctx context.Context, bundleID, reason string) error {
+		withdrawnID = bundleID
+		return nil
+	}, authMiddlewareMock, identityClientMock)
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Equal(t, "bundle1", withdrawnID)
+}
+
+func TestWithdrawBundleHandlesInvalidJSON(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/bundle/bundle1/withdraw", strings.NewReader("<json>invalid</json>"))
+	req.Header.Add("Authorization", authorisationtest.AdminJWTToken)
+	authMiddlewareMock, identityClientMock, _ := setUpAuthServices()
+
+	h := api.HandleWithdrawBundle(nil, authMiddlewareMock, identityClientMock)
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	response, _ := io.ReadAll(rec.Body)
+	assert.Contains(t, string(response), "BadJsonEncoding")
+}
@@ -0,0 +1,73 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	dps3 "github.com/ONSdigital/dp-s3/v3"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// Client is the S3Clienter for a bucket. It is a dp-s3 client whose Head also asks S3 for the object's checksums, so
+// that the content of uploaded files can be verified.
+type Client struct {
+	*dps3.Client
+	sdkClient *s3.Client
+}
+
+// NewClient wraps the dp-s3 client, creating an S3 client for Head from its config with the same options
+func NewClient(client *dps3.Client, optFns ...func(*s3.Options)) *Client {
+	return &Client{
+		Client:    client,
+		sdkClient: s3.NewFromConfig(client.Config(), optFns...),
+	}
+}
+
+// Head returns the metadata of the object with the given key, including any checksums stored with it
+func (c *Client) Head(ctx context.Context, key string) (*s3.HeadObjectOutput, error) {
+	bucketName := c.BucketName()
+	result, err := c.sdkClient.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket:       &bucketName,
+		Key:          &key,
+		ChecksumMode: types.ChecksumModeEnabled,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error trying to obtain s3 object metadata with HeadObject call for %s in bucket %s: %w", key, bucketName, err)
+	}
+	return result, nil
+}
+
+// ListObjectsPage lists a page of the objects in the bucket in key order, carrying on from the continuation token
+// given with the previous page, or from the first object when it is empty. The token given with the last page is empty.
+func (c *Client) ListObjectsPage(ctx context.Context, continuationToken string) ([]Object, string, error) {
+	bucketName := c.BucketName()
+	input := &s3.ListObjectsV2Input{Bucket: &bucketName}
+	if continuationToken != "" {
+		input.ContinuationToken = &continuationToken
+	}
+
+	page, err := c.sdkClient.ListObjectsV2(ctx, input)
+	if err != nil {
+		return nil, "", fmt.Errorf("error trying to list s3 objects in bucket %s: %w", bucketName, err)
+	}
+
+	objects := make([]Object, 0, len(page.Contents))
+	for _, o := range page.Contents {
+		object := Object{Key: *o.Key}
+		if o.Size != nil {
+			object.SizeInBytes = *o.Size
+		}
+		if o.ETag != nil {
+			object.Etag = strings.Trim(*o.ETag, `"`)
+		}
+		objects = append(objects, object)
+	}
+
+	next := ""
+	if page.IsTruncated != nil && *page.IsTruncated && page.NextContinuationToken != nil {
+		next = *page.NextContinuationToken
+	}
+	return objects, next, nil
+}
@@ -30,6 +30,9 @@ var _ aws.S3Clienter = &S3ClienterMock{}
 //			HeadFunc: func(ctx context.Context, key string) (*s3.HeadObjectOutput, error) {
 //				panic("mock out the Head method")
 //			},
+//			ListObjectsPageFunc: func(ctx context.Context, continuationToken string) ([]aws.Object, string, error) {
+//				panic("mock out the ListObjectsPage method")
+//			},
 //		}
 //
 //		// use mockedS3Clienter in code that requires aws.S3Clienter
@@ -46,6 +49,9 @@ type S3ClienterMock struct {
 	// HeadFunc mocks the Head method.
 	HeadFunc func(ctx context.Context, key string) (*s3.HeadObjectOutput, error)
 
+	// ListObjectsPageFunc mocks the ListObjectsPage method.
+	ListObjectsPageFunc func(ctx context.Context, continuationToken string) ([]aws.Object, string, error)
+
 	// calls tracks calls to the methods.
 	calls struct {
 		// Checker holds details about calls to the Checker method.
@@ -69,10 +75,18 @@ type S3ClienterMock struct {
 			// Key is the key argument value.
 			Key string
 		}
+		// ListObjectsPage holds details about calls to the ListObjectsPage method.
+		ListObjectsPage []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// ContinuationToken is the continuationToken argument value.
+			ContinuationToken string
+		}
 	}
-	lockChecker sync.RWMutex
-	lockDelete  sync.RWMutex
-	lockHead    sync.RWMutex
+	lockChecker         sync.RWMutex
+	lockDelete          sync.RWMutex
+	lockHead            sync.RWMutex
+	lockListObjectsPage sync.RWMutex
 }
 
 // Checker calls CheckerFunc.
@@ -182,3 +196,39 @@ func (mock *S3ClienterMock) HeadCalls() []struct {
 	mock.lockHead.RUnlock()
 	return calls
 }
+
+// ListObjectsPage calls ListObjectsPageFunc.
+func (mock *S3ClienterMock) ListObjectsPage(ctx context.Context, continuationToken string) ([]aws.Object, string, error) {
+	if mock.ListObjectsPageFunc == nil {
+		panic("S3ClienterMock.ListObjectsPageFunc: method is nil but S3Clienter.ListObjectsPage was just called")
+	}
+	callInfo := struct {
+		Ctx               context.Context
+		ContinuationToken string
+	}{
+		Ctx:               ctx,
+		ContinuationToken: continuationToken,
+	}
+	mock.lockListObjectsPage.Lock()
+	mock.calls.ListObjectsPage = append(mock.calls.ListObjectsPage, callInfo)
+	mock.lockListObjectsPage.Unlock()
+	return mock.ListObjectsPageFunc(ctx, continuationToken)
+}
+
+// ListObjectsPageCalls gets all the calls that were made to ListObjectsPage.
+// Check the length with:
+//
+//	len(mockedS3Clienter.ListObjectsPageCalls())
+func (mock *S3ClienterMock) ListObjectsPageCalls() []struct {
+	Ctx               context.Context
+	ContinuationToken string
+} {
+	var calls []struct {
+		Ctx               context.Context
+		ContinuationToken string
+	}
+	mock.lockListObjectsPage.RLock()
+	calls = mock.calls.ListObjectsPage
+	mock.lockListObjectsPage.RUnlock()
+	return calls
+}
@@ -13,4 +13,12 @@ type S3Clienter interface {
 	Checker(ctx context.Context, state *healthcheck.CheckState) error
 	Head(ctx context.Context, key string) (*s3.HeadObjectOutput, error)
 	Delete(ctx context.Context, key string) error
+	ListObjectsPage(ctx context.Context, continuationToken string) ([]Object, string, error)
+}
+
+// Object is an object stored in the bucket. Etag is without the quotes S3 puts around it.
+type Object struct {
+	Key         string
+	SizeInBytes int64
+	Etag        string
 }
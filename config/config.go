@@ -25,6 +25,35 @@ type Config struct {
 	IsPublishing               bool          `envconfig:"IS_PUBLISHING"`
 	MaxNumBatches              int           `envconfig:"MAX_NUM_BATCHES"`
 	MinBatchSize               int           `envconfig:"MIN_BATCH_SIZE"`
+	PublishJobLeaseTTL         time.Duration `envconfig:"PUBLISH_JOB_LEASE_TTL"`
+	OutboxRelayInterval        time.Duration `envconfig:"OUTBOX_RELAY_INTERVAL"`
+	OutboxRelayBatchSize       int           `envconfig:"OUTBOX_RELAY_BATCH_SIZE"`
+	OutboxRelayMaxBackoff      time.Duration `envconfig:"OUTBOX_RELAY_MAX_BACKOFF"`
+	OutboxRelayAckTimeout      time.Duration `envconfig:"OUTBOX_RELAY_ACK_TIMEOUT"`
+	OutboxRelayLockTTL         time.Duration `envconfig:"OUTBOX_RELAY_LOCK_TTL"`
+	OutboxPendingTimeout       time.Duration `envconfig:"OUTBOX_PENDING_TIMEOUT"`
+	FileStreamPollInterval     time.Duration `envconfig:"FILE_STREAM_POLL_INTERVAL"`
+	FileStreamHeartbeat        time.Duration `envconfig:"FILE_STREAM_HEARTBEAT"`
+	FileChangeTTL              time.Duration `envconfig:"FILE_CHANGE_TTL"`
+	FileEventsHMACKey          string        `envconfig:"FILE_EVENTS_HMAC_KEY"                  json:"-"`
+	FilesBatchMaxSize          int           `envconfig:"FILES_BATCH_MAX_SIZE"`
+	FilesTransitionConcurrency int           `envconfig:"FILES_TRANSITION_CONCURRENCY"`
+	ScheduledPublishInterval   time.Duration `envconfig:"SCHEDULED_PUBLISH_INTERVAL"`
+	ScheduledPublishLockTTL    time.Duration `envconfig:"SCHEDULED_PUBLISH_LOCK_TTL"`
+	UploadReaperEnabled        bool          `envconfig:"UPLOAD_REAPER_ENABLED"`
+	UploadReaperInterval       time.Duration `envconfig:"UPLOAD_REAPER_INTERVAL"`
+	AbandonedUploadTTL         time.Duration `envconfig:"ABANDONED_UPLOAD_TTL"`
+	TrashRetention             time.Duration `envconfig:"TRASH_RETENTION"`
+	TrashPurgeInterval         time.Duration `envconfig:"TRASH_PURGE_INTERVAL"`
+	ReconciliationEnabled      bool          `envconfig:"RECONCILIATION_ENABLED"`
+	ReconciliationInterval     time.Duration `envconfig:"RECONCILIATION_INTERVAL"`
+	ReconciliationLockTTL      time.Duration `envconfig:"RECONCILIATION_LOCK_TTL"`
+	MovedMetadataMaxAge        time.Duration `envconfig:"MOVED_METADATA_MAX_AGE"`
+	WebMetadataCacheSize       int           `envconfig:"WEB_METADATA_CACHE_SIZE"`
+	WebMetadataCacheTTL        time.Duration `envconfig:"WEB_METADATA_CACHE_TTL"`
+	IdempotencyKeyTTL          time.Duration `envconfig:"IDEMPOTENCY_KEY_TTL"`
+	IdempotencyKeyClaimTimeout time.Duration `envconfig:"IDEMPOTENCY_KEY_CLAIM_TIMEOUT"`
+	StorageBackend             string        `envconfig:"STORAGE_BACKEND"`
 	MongoConfig
 	KafkaConfig
 	AuthConfig
@@ -32,25 +61,68 @@ type Config struct {
 
 // KafkaConfig contains the config required to connect to Kafka
 type KafkaConfig struct {
-	Addr                      []string `envconfig:"KAFKA_ADDR"                            json:"-"`
-	ProducerMinBrokersHealthy int      `envconfig:"KAFKA_PRODUCER_MIN_BROKERS_HEALTHY"`
-	Version                   string   `envconfig:"KAFKA_VERSION"`
-	MaxBytes                  int      `envconfig:"KAFKA_MAX_BYTES"`
-	SecProtocol               string   `envconfig:"KAFKA_SEC_PROTO"`
-	SecCACerts                string   `envconfig:"KAFKA_SEC_CA_CERTS"`
-	SecClientKey              string   `envconfig:"KAFKA_SEC_CLIENT_KEY"                  json:"-"`
-	SecClientCert             string   `envconfig:"KAFKA_SEC_CLIENT_CERT"`
-	SecSkipVerify             bool     `envconfig:"KAFKA_SEC_SKIP_VERIFY"`
-	StaticFilePublishedTopic  string   `envconfig:"STATIC_FILE_PUBLISHED_TOPIC"`
+	Addr                       []string `envconfig:"KAFKA_ADDR"                            json:"-"`
+	ProducerMinBrokersHealthy  int      `envconfig:"KAFKA_PRODUCER_MIN_BROKERS_HEALTHY"`
+	Version                    string   `envconfig:"KAFKA_VERSION"`
+	MaxBytes                   int      `envconfig:"KAFKA_MAX_BYTES"`
+	SecProtocol                string   `envconfig:"KAFKA_SEC_PROTO"`
+	SecCACerts                 string   `envconfig:"KAFKA_SEC_CA_CERTS"`
+	SecClientKey               string   `envconfig:"KAFKA_SEC_CLIENT_KEY"                  json:"-"`
+	SecClientCert              string   `envconfig:"KAFKA_SEC_CLIENT_CERT"`
+	SecSkipVerify              bool     `envconfig:"KAFKA_SEC_SKIP_VERIFY"`
+	StaticFilePublishedTopic   string   `envconfig:"STATIC_FILE_PUBLISHED_TOPIC"`
+	StaticFilePublishedV3Topic string   `envconfig:"STATIC_FILE_PUBLISHED_V3_TOPIC"`
+	FilePublishedV2Enabled     bool     `envconfig:"FILE_PUBLISHED_V2_ENABLED"`
+	FilePublishedV3Enabled     bool     `envconfig:"FILE_PUBLISHED_V3_ENABLED"`
+	FileLifecycleTopic         string   `envconfig:"FILE_LIFECYCLE_TOPIC"`
+	FileWithdrawnTopic         string   `envconfig:"FILE_WITHDRAWN_TOPIC"`
+	WebCacheConsumerGroup      string   `envconfig:"KAFKA_WEB_CACHE_CONSUMER_GROUP"`
 }
 
 var cfg *Config
 
+// FilePublishedTopics lists the topics that file published events are sent to, one for each enabled version
+func (c KafkaConfig) FilePublishedTopics() []string {
+	var topics []string
+	if c.FilePublishedV2Enabled {
+		topics = append(topics, c.StaticFilePublishedTopic)
+	}
+	if c.FilePublishedV3Enabled {
+		topics = append(topics, c.StaticFilePublishedV3Topic)
+	}
+	return topics
+}
+
+// ProducerTopics lists every topic the service sends events to
+func (c KafkaConfig) ProducerTopics() []string {
+	return append(c.FilePublishedTopics(), c.FileLifecycleTopic, c.FileWithdrawnTopic)
+}
+
+// WebCacheTopics lists the topics consumed in web mode to forget cached metadata as files change: the file published
+// topics, the lifecycle topic and the withdrawn topic
+func (c KafkaConfig) WebCacheTopics() []string {
+	return c.ProducerTopics()
+}
+
+const (
+	MetadataCollection        = "MetadataCollection"
+	CollectionsCollection     = "CollectionsCollection"
+	BundlesCollection         = "BundlesCollection"
+	FileEventsCollection      = "FileEventsCollection"
+	OutboxCollection          = "OutboxCollection"
+	PublishJobsCollection     = "PublishJobsCollection"
+	FileVersionsCollection    = "FileVersionsCollection"
+	FileChangesCollection     = "FileChangesCollection"
+	LocksCollection           = "LocksCollection"
+	TrashCollection           = "TrashCollection"
+	ReconciliationsCollection = "ReconciliationsCollection"
+	FileEventChainCollection  = "FileEventChainCollection"
+	IdempotencyKeysCollection = "IdempotencyKeysCollection"
+)
+
 const (
-	MetadataCollection    = "MetadataCollection"
-	CollectionsCollection = "CollectionsCollection"
-	BundlesCollection     = "BundlesCollection"
-	FileEventsCollection  = "FileEventsCollection"
+	StorageBackendMongo  = "mongo"
+	StorageBackendMemory = "memory"
 )
 
 // Get returns the default config with any modifications through environment
@@ -71,14 +143,51 @@ func Get() (*Config, error) {
 		IsPublishing:               false,
 		MaxNumBatches:              5,
 		MinBatchSize:               20,
+		PublishJobLeaseTTL:         5 * time.Minute,
+		OutboxRelayInterval:        time.Second,
+		OutboxRelayBatchSize:       100,
+		OutboxRelayMaxBackoff:      5 * time.Minute,
+		OutboxRelayAckTimeout:      time.Minute,
+		OutboxRelayLockTTL:         30 * time.Second,
+		OutboxPendingTimeout:       5 * time.Minute,
+		FileStreamPollInterval:     2 * time.Second,
+		FileStreamHeartbeat:        15 * time.Second,
+		FileChangeTTL:              24 * time.Hour,
+		FilesBatchMaxSize:          1000,
+		FilesTransitionConcurrency: 10,
+		ScheduledPublishInterval:   10 * time.Second,
+		ScheduledPublishLockTTL:    time.Minute,
+		UploadReaperEnabled:        false,
+		UploadReaperInterval:       time.Hour,
+		AbandonedUploadTTL:         24 * time.Hour,
+		TrashRetention:             7 * 24 * time.Hour,
+		TrashPurgeInterval:         time.Hour,
+		ReconciliationEnabled:      false,
+		ReconciliationInterval:     24 * time.Hour,
+		ReconciliationLockTTL:      time.Hour,
+		MovedMetadataMaxAge:        24 * time.Hour,
+		WebMetadataCacheSize:       10000,
+		WebMetadataCacheTTL:        time.Minute,
+		IdempotencyKeyTTL:          24 * time.Hour,
+		IdempotencyKeyClaimTimeout: time.Minute,
+		StorageBackend:             StorageBackendMongo,
 		MongoConfig: MongoConfig{
 			ClusterEndpoint: "localhost:27017",
 			Database:        "files",
 			Collections: map[string]string{
-				MetadataCollection:    "metadata",
-				CollectionsCollection: "collections",
-				BundlesCollection:     "bundles",
-				FileEventsCollection:  "file_events",
+				MetadataCollection:        "metadata",
+				CollectionsCollection:     "collections",
+				BundlesCollection:         "bundles",
+				FileEventsCollection:      "file_events",
+				OutboxCollection:          "outbox",
+				PublishJobsCollection:     "publish_jobs",
+				FileVersionsCollection:    "file_versions",
+				FileChangesCollection:     "file_changes",
+				LocksCollection:           "locks",
+				TrashCollection:           "trash",
+				ReconciliationsCollection: "reconciliations",
+				FileEventChainCollection:  "file_event_chain",
+				IdempotencyKeysCollection: "idempotency_keys",
 			},
 			IsStrongReadConcernEnabled:    false,
 			IsWriteConcernMajorityEnabled: true,
@@ -89,16 +198,22 @@ func Get() (*Config, error) {
 			},
 		},
 		KafkaConfig: KafkaConfig{
-			Addr:                      []string{"kafka:9092"},
-			ProducerMinBrokersHealthy: 1,
-			Version:                   "2.6.1",
-			MaxBytes:                  2000000,
-			SecProtocol:               "",
-			SecCACerts:                "",
-			SecClientKey:              "",
-			SecClientCert:             "",
-			SecSkipVerify:             false,
-			StaticFilePublishedTopic:  "static-file-published-v2",
+			Addr:                       []string{"kafka:9092"},
+			ProducerMinBrokersHealthy:  1,
+			Version:                    "2.6.1",
+			MaxBytes:                   2000000,
+			SecProtocol:                "",
+			SecCACerts:                 "",
+			SecClientKey:               "",
+			SecClientCert:              "",
+			SecSkipVerify:              false,
+			StaticFilePublishedTopic:   "static-file-published-v2",
+			StaticFilePublishedV3Topic: "static-file-published-v3",
+			FilePublishedV2Enabled:     true,
+			FilePublishedV3Enabled:     false,
+			FileLifecycleTopic:         "file-lifecycle",
+			FileWithdrawnTopic:         "file-withdrawn",
+			WebCacheConsumerGroup:      "dp-files-api-web-cache",
 		},
 		AuthConfig: *authorisation.NewDefaultConfig(),
 	}
@@ -32,9 +32,37 @@ func TestConfig(t *testing.T) {
 				So(testCfg.IsPublishing, ShouldBeFalse)
 				So(testCfg.MaxNumBatches, ShouldEqual, 5)
 				So(testCfg.MinBatchSize, ShouldEqual, 20)
+				So(testCfg.PublishJobLeaseTTL, ShouldEqual, 5*time.Minute)
+				So(testCfg.OutboxRelayInterval, ShouldEqual, time.Second)
+				So(testCfg.OutboxRelayBatchSize, ShouldEqual, 100)
+				So(testCfg.OutboxRelayMaxBackoff, ShouldEqual, 5*time.Minute)
+				So(testCfg.OutboxRelayAckTimeout, ShouldEqual, time.Minute)
+				So(testCfg.OutboxRelayLockTTL, ShouldEqual, 30*time.Second)
+				So(testCfg.OutboxPendingTimeout, ShouldEqual, 5*time.Minute)
+				So(testCfg.FileStreamPollInterval, ShouldEqual, 2*time.Second)
+				So(testCfg.FileStreamHeartbeat, ShouldEqual, 15*time.Second)
+				So(testCfg.FileChangeTTL, ShouldEqual, 24*time.Hour)
+				So(testCfg.FilesBatchMaxSize, ShouldEqual, 1000)
+				So(testCfg.FilesTransitionConcurrency, ShouldEqual, 10)
+				So(testCfg.ScheduledPublishInterval, ShouldEqual, 10*time.Second)
+				So(testCfg.ScheduledPublishLockTTL, ShouldEqual, time.Minute)
+				So(testCfg.UploadReaperEnabled, ShouldBeFalse)
+				So(testCfg.UploadReaperInterval, ShouldEqual, time.Hour)
+				So(testCfg.AbandonedUploadTTL, ShouldEqual, 24*time.Hour)
+				So(testCfg.TrashRetention, ShouldEqual, 7*24*time.Hour)
+				So(testCfg.TrashPurgeInterval, ShouldEqual, time.Hour)
+				So(testCfg.ReconciliationEnabled, ShouldBeFalse)
+				So(testCfg.ReconciliationInterval, ShouldEqual, 24*time.Hour)
+				So(testCfg.ReconciliationLockTTL, ShouldEqual, time.Hour)
+				So(testCfg.MovedMetadataMaxAge, ShouldEqual, 24*time.Hour)
+				So(testCfg.WebMetadataCacheSize, ShouldEqual, 10000)
+				So(testCfg.WebMetadataCacheTTL, ShouldEqual, time.Minute)
+				So(testCfg.IdempotencyKeyTTL, ShouldEqual, 24*time.Hour)
+				So(testCfg.IdempotencyKeyClaimTimeout, ShouldEqual, time.Minute)
+				So(testCfg.StorageBackend, ShouldEqual, StorageBackendMongo)
 				So(testCfg.ClusterEndpoint, ShouldEqual, "localhost:27017")
 				So(testCfg.Database, ShouldEqual, "files")
-				So(testCfg.Collections, ShouldResemble, map[string]string{MetadataCollection: "metadata", CollectionsCollection: "collections", BundlesCollection: "bundles", FileEventsCollection: "file_events"})
+				So(testCfg.Collections, ShouldResemble, map[string]string{MetadataCollection: "metadata", CollectionsCollection: "collections", BundlesCollection: "bundles", FileEventsCollection: "file_events", OutboxCollection: "outbox", PublishJobsCollection: "publish_jobs", FileVersionsCollection: "file_versions", FileChangesCollection: "file_changes", LocksCollection: "locks", TrashCollection: "trash", ReconciliationsCollection: "reconciliations", FileEventChainCollection: "file_event_chain", IdempotencyKeysCollection: "idempotency_keys"})
 				So(testCfg.IsStrongReadConcernEnabled, ShouldEqual, false)
 				So(testCfg.IsWriteConcernMajorityEnabled, ShouldEqual, true)
 				So(testCfg.ConnectTimeout, ShouldEqual, 5*time.Second)
@@ -50,6 +78,12 @@ func TestConfig(t *testing.T) {
 				So(testCfg.SecClientCert, ShouldEqual, "")
 				So(testCfg.SecSkipVerify, ShouldEqual, false)
 				So(testCfg.StaticFilePublishedTopic, ShouldEqual, "static-file-published-v2")
+				So(testCfg.StaticFilePublishedV3Topic, ShouldEqual, "static-file-published-v3")
+				So(testCfg.FilePublishedV2Enabled, ShouldBeTrue)
+				So(testCfg.FilePublishedV3Enabled, ShouldBeFalse)
+				So(testCfg.FileLifecycleTopic, ShouldEqual, "file-lifecycle")
+				So(testCfg.FileWithdrawnTopic, ShouldEqual, "file-withdrawn")
+				So(testCfg.WebCacheConsumerGroup, ShouldEqual, "dp-files-api-web-cache")
 				So(testCfg.Enabled, ShouldEqual, false)
 				So(testCfg.PermissionsAPIURL, ShouldEqual, "http://localhost:25400")
 				So(testCfg.IdentityWebKeySetURL, ShouldEqual, "http://localhost:25600")
@@ -67,3 +101,36 @@ func TestConfig(t *testing.T) {
 		})
 	})
 }
+
+func TestFilePublishedTopics(t *testing.T) {
+	Convey("Given the topics for each version of the file published event", t, func() {
+		kafkaCfg := KafkaConfig{StaticFilePublishedTopic: "v2-topic", StaticFilePublishedV3Topic: "v3-topic"}
+
+		Convey("Only the topics of enabled versions are returned", func() {
+			So(kafkaCfg.FilePublishedTopics(), ShouldBeEmpty)
+
+			kafkaCfg.FilePublishedV2Enabled = true
+			So(kafkaCfg.FilePublishedTopics(), ShouldResemble, []string{"v2-topic"})
+
+			kafkaCfg.FilePublishedV3Enabled = true
+			So(kafkaCfg.FilePublishedTopics(), ShouldResemble, []string{"v2-topic", "v3-topic"})
+
+			kafkaCfg.FilePublishedV2Enabled = false
+			So(kafkaCfg.FilePublishedTopics(), ShouldResemble, []string{"v3-topic"})
+		})
+
+		Convey("The producer topics include the lifecycle and withdrawn topics", func() {
+			kafkaCfg.FilePublishedV2Enabled = true
+			kafkaCfg.FileLifecycleTopic = "lifecycle-topic"
+			kafkaCfg.FileWithdrawnTopic = "withdrawn-topic"
+			So(kafkaCfg.ProducerTopics(), ShouldResemble, []string{"v2-topic", "lifecycle-topic", "withdrawn-topic"})
+		})
+
+		Convey("The web cache topics include the lifecycle and withdrawn topics", func() {
+			kafkaCfg.FilePublishedV3Enabled = true
+			kafkaCfg.FileLifecycleTopic = "lifecycle-topic"
+			kafkaCfg.FileWithdrawnTopic = "withdrawn-topic"
+			So(kafkaCfg.WebCacheTopics(), ShouldResemble, []string{"v3-topic", "lifecycle-topic", "withdrawn-topic"})
+		})
+	})
+}
@@ -0,0 +1,85 @@
+Feature: Dataset and edition scoped permissions on changing files
+
+  Scenario: A publisher of a dataset edition can update the content item of its files
+    Given I am a JWT user with email "publisher1@ons.gov.uk" and group "role-dataset-publisher"
+    And the file upload "cpih/data.csv" has been registered with:
+      | IsPublishable | true                                                                      |
+      | CollectionID  | 1234-asdfg-54321-qwerty                                                   |
+      | Title         | CPIH                                                                      |
+      | SizeInBytes   | 14794                                                                     |
+      | Type          | text/csv                                                                  |
+      | Licence       | OGL v3                                                                    |
+      | LicenceURL    | http://www.nationalarchives.gov.uk/doc/open-government-licence/version/3/ |
+      | CreatedAt     | 2021-10-21T15:13:14Z                                                      |
+      | LastModified  | 2021-10-21T15:13:14Z                                                      |
+      | State         | CREATED                                                                   |
+      | DatasetID     | cpih01                                                                    |
+      | Edition       | feb-2026                                                                  |
+      | Version       | 1                                                                         |
+    When I update the content item of the file "cpih/data.csv" with:
+      """
+        {
+          "content_item": {
+            "dataset_id": "cpih01",
+            "edition": "feb-2026",
+            "version": "2"
+          }
+        }
+      """
+    Then the HTTP status code should be "200"
+
+  Scenario: A publisher of a dataset edition cannot update the files of another
+    Given I am a JWT user with email "publisher1@ons.gov.uk" and group "role-dataset-publisher"
+    And the file upload "meme/data.csv" has been registered with:
+      | IsPublishable | true                                                                      |
+      | CollectionID  | 1234-asdfg-54321-qwerty                                                   |
+      | Title         | Memes                                                                     |
+      | SizeInBytes   | 14794                                                                     |
+      | Type          | text/csv                                                                  |
+      | Licence       | OGL v3                                                                    |
+      | LicenceURL    | http://www.nationalarchives.gov.uk/doc/open-government-licence/version/3/ |
+      | CreatedAt     | 2021-10-21T15:13:14Z                                                      |
+      | LastModified  | 2021-10-21T15:13:14Z                                                      |
+      | State         | CREATED                                                                   |
+      | DatasetID     | meme-dataset                                                              |
+      | Edition       | jan                                                                       |
+      | Version       | 1                                                                         |
+    When I update the content item of the file "meme/data.csv" with:
+      """
+        {
+          "content_item": {
+            "dataset_id": "cpih01",
+            "edition": "feb-2026",
+            "version": "1"
+          }
+        }
+      """
+    Then the HTTP status code should be "403"
+
+  Scenario: A publisher of a dataset edition cannot move its files to another
+    Given I am a JWT user with email "publisher1@ons.gov.uk" and group "role-dataset-publisher"
+    And the file upload "cpih/data.csv" has been registered with:
+      | IsPublishable | true                                                                      |
+      | CollectionID  | 1234-asdfg-54321-qwerty                                                   |
+      | Title         | CPIH                                                                      |
+      | SizeInBytes   | 14794                                                                     |
+      | Type          | text/csv                                                                  |
+      | Licence       | OGL v3                                                                    |
+      | LicenceURL    | http://www.nationalarchives.gov.uk/doc/open-government-licence/version/3/ |
+      | CreatedAt     | 2021-10-21T15:13:14Z                                                      |
+      | LastModified  | 2021-10-21T15:13:14Z                                                      |
+      | State         | CREATED                                                                   |
+      | DatasetID     | cpih01                                                                    |
+      | Edition       | feb-2026                                                                  |
+      | Version       | 1                                                                         |
+    When I update the content item of the file "cpih/data.csv" with:
+      """
+        {
+          "content_item": {
+            "dataset_id": "meme-dataset",
+            "edition": "jan",
+            "version": "1"
+          }
+        }
+      """
+    Then the HTTP status code should be "403"
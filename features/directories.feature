@@ -0,0 +1,46 @@
+Feature: Browsing files by directory
+
+  Scenario: The one where a directory has files and sub-directories
+    Given I am an admin user
+    And the file upload "cpih01/2024/v1/data.csv" has been registered with:
+      | IsPublishable | true                 |
+      | CollectionID  | collection-1         |
+      | Title         | Data                 |
+      | SizeInBytes   | 100                  |
+      | Type          | text/csv             |
+      | Licence       | OGL v3               |
+      | LicenceURL    | http://licence.com   |
+      | CreatedAt     | 2021-10-21T15:13:14Z |
+      | LastModified  | 2021-10-21T15:13:14Z |
+      | State         | CREATED              |
+    And the file upload "cpih01/2024/readme.md" has been registered with:
+      | IsPublishable | true                 |
+      | CollectionID  | collection-1         |
+      | Title         | Readme               |
+      | SizeInBytes   | 5                    |
+      | Type          | text/markdown        |
+      | Licence       | OGL v3               |
+      | LicenceURL    | http://licence.com   |
+      | CreatedAt     | 2021-10-21T15:13:14Z |
+      | LastModified  | 2021-10-21T15:13:14Z |
+      | State         | CREATED              |
+    When I GET "/directories/cpih01/2024"
+    Then I should receive the following JSON response with status "200":
+    """
+    {
+      "path": "cpih01/2024",
+      "file_count": 2,
+      "size_in_bytes": 105,
+      "directories": [
+        {"name": "v1", "path": "cpih01/2024/v1", "file_count": 1, "size_in_bytes": 100}
+      ],
+      "files": [
+        {"name": "readme.md", "path": "cpih01/2024/readme.md", "size_in_bytes": 5}
+      ]
+    }
+    """
+
+  Scenario: The one where nothing is stored under the directory
+    Given I am an admin user
+    When I GET "/directories/cpih01/2025"
+    Then the HTTP status code should be "404"
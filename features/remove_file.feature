@@ -69,4 +69,27 @@ Feature: DELETE /files/{filepath}
         | State         | MOVED                                                                     |
       When the file upload "images/with-bundle.jpg" is removed
       Then the HTTP status code should be "409"
-      And the file event should be created in the database
\ No newline at end of file
+      And the file event should be created in the database
+  Scenario: A removed file can be restored
+    Given I am a publisher user
+    And the file upload "images/with-bundle.jpg" has been registered with:
+      | IsPublishable | true                                                                      |
+      | BundleID      | existing-bundle-789                                                       |
+      | Title         | Image with existing bundle                                                |
+      | SizeInBytes   | 14794                                                                     |
+      | Type          | image/jpeg                                                                |
+      | Licence       | OGL v3                                                                    |
+      | LicenceURL    | http://www.nationalarchives.gov.uk/doc/open-government-licence/version/3/ |
+      | CreatedAt     | 2021-10-21T15:13:14Z                                                      |
+      | LastModified  | 2021-10-21T15:13:14Z                                                      |
+      | State         | UPLOADED                                                                  |
+    And the file upload "images/with-bundle.jpg" is removed
+    When the file upload "images/with-bundle.jpg" is restored
+    Then the HTTP status code should be "200"
+    And the file upload "images/with-bundle.jpg" is restored
+    And the HTTP status code should be "404"
+
+  Scenario: Restoring a file that was never removed returns 404
+    Given I am a publisher user
+    When the file upload "images/non-existent.jpg" is restored
+    Then the HTTP status code should be "404"
@@ -7,10 +7,10 @@ import (
 	"time"
 
 	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/dp-files-api/store"
 	"github.com/cucumber/godog"
 	"github.com/rdumont/assistdog"
 	"github.com/stretchr/testify/assert"
-	"go.mongodb.org/mongo-driver/bson"
 )
 
 type FileEventData struct {
@@ -29,16 +29,15 @@ func (c *FilesAPIComponent) iCreateFileEvent(payload *godog.DocString) error {
 func (c *FilesAPIComponent) theFileEventShouldBeCreatedInTheDatabase() error {
 	ctx := context.Background()
 
-	count, err := c.mongoClient.Database("files").Collection("file_events").CountDocuments(ctx, bson.M{})
+	count, err := c.repository.CountFileEvents(ctx, store.FileEventFilter{})
 	assert.NoError(c.APIFeature, err)
-	assert.Greater(c.APIFeature, count, int64(0), "Expected at least one file event in the database")
+	assert.Greater(c.APIFeature, count, 0, "Expected at least one file event in the database")
 
 	return c.APIFeature.StepError()
 }
 
 func (c *FilesAPIComponent) theFollowingFileEventsExistInTheDatabase(table *godog.Table) error {
 	ctx := context.Background()
-	collection := c.mongoClient.Database("files").Collection("file_events")
 
 	baseTime := time.Date(2025, 10, 28, 12, 0, 0, 0, time.UTC)
 
@@ -49,35 +48,34 @@ func (c *FilesAPIComponent) theFollowingFileEventsExistInTheDatabase(table *godo
 	}
 
 	for i, item := range events.([]*FileEventData) {
-		fileObj := bson.M{
-			"path":           item.FilePath,
-			"is_publishable": true,
-			"title":          "Test File",
-			"size_in_bytes":  1024,
-			"type":           "text/csv",
-			"licence":        "OGL v3",
-			"licence_url":    "http://www.nationalarchives.gov.uk/doc/open-government-licence/version/3/",
+		file := &files.StoredRegisteredMetaData{
+			Path:          item.FilePath,
+			IsPublishable: true,
+			Title:         "Test File",
+			SizeInBytes:   1024,
+			Type:          "text/csv",
+			Licence:       "OGL v3",
+			LicenceURL:    "http://www.nationalarchives.gov.uk/doc/open-government-licence/version/3/",
 		}
 
 		if item.BundleID != "" {
-			fileObj["bundle_id"] = item.BundleID
+			file.BundleID = &item.BundleID
 		}
 
 		createdAt := baseTime.Add(-time.Duration(i+1) * time.Hour)
 
-		event := bson.M{
-			"created_at": createdAt,
-			"requested_by": bson.M{
-				"id":    item.RequestedByID,
-				"email": item.RequestedByID + "@example.com",
+		event := &files.FileEvent{
+			CreatedAt: &createdAt,
+			RequestedBy: &files.RequestedBy{
+				ID:    item.RequestedByID,
+				Email: item.RequestedByID + "@example.com",
 			},
-			"action":   item.Action,
-			"resource": item.Resource,
-			"file":     fileObj,
+			Action:   item.Action,
+			Resource: item.Resource,
+			File:     file,
 		}
 
-		_, err := collection.InsertOne(ctx, event)
-		if err != nil {
+		if err := c.repository.InsertFileEvent(ctx, event); err != nil {
 			return err
 		}
 	}
@@ -188,12 +186,32 @@ func (c *FilesAPIComponent) allReturnedEventsShouldHaveFilePath(expectedPath str
 func (c *FilesAPIComponent) aReadAuditEventShouldBeCreatedForFileEvents() error {
 	ctx := context.Background()
 
-	count, err := c.mongoClient.Database("files").Collection("file_events").CountDocuments(ctx, bson.M{
-		"action":   "READ",
-		"resource": "/file-events",
+	count, err := c.countFileEvents(ctx, store.FileEventFilter{}, func(e files.FileEvent) bool {
+		return e.Action == "READ" && e.Resource == "/file-events"
 	})
 	assert.NoError(c.APIFeature, err)
-	assert.Equal(c.APIFeature, int64(1), count, "Expected exactly one READ audit event for /file-events")
+	assert.Equal(c.APIFeature, 1, count, "Expected exactly one READ audit event for /file-events")
 
 	return c.APIFeature.StepError()
 }
+
+// countFileEvents counts the stored file events selected by filter that also satisfy match
+func (c *FilesAPIComponent) countFileEvents(ctx context.Context, filter store.FileEventFilter, match func(e files.FileEvent) bool) (int, error) {
+	total, err := c.repository.CountFileEvents(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	events, err := c.repository.FindFileEvents(ctx, filter, false, 0, total)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, e := range events {
+		if match(e) {
+			count++
+		}
+	}
+	return count, nil
+}
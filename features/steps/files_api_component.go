@@ -25,6 +25,7 @@ import (
 	"github.com/ONSdigital/dp-files-api/config"
 	filesmongo "github.com/ONSdigital/dp-files-api/mongo"
 	"github.com/ONSdigital/dp-files-api/service"
+	"github.com/ONSdigital/dp-files-api/store"
 	dphttp "github.com/ONSdigital/dp-net/v3/http"
 	"github.com/ONSdigital/log.go/v2/log"
 )
@@ -37,6 +38,7 @@ type FilesAPIComponent struct {
 	errChan                 chan error
 	mongoClient             *mongo.Client
 	mongoStoreClient        filesmongo.Client
+	repository              store.Repository
 	mongoCfg                config.MongoConfig
 	cg                      *kafka.ConsumerGroup
 	msgs                    map[string]files.FilePublished
@@ -48,6 +50,7 @@ type FilesAPIComponent struct {
 	Config                  *config.Config
 	AuthorisationMiddleware authorisation.Middleware
 	mongoURI                string
+	storageBackend          string
 
 	initMu      sync.Mutex
 	initHandler http.Handler
@@ -105,13 +108,15 @@ func waitForPrimary(ctx context.Context, c *mongo.Client) error {
 	}
 }
 
-func NewFilesAPIComponent(mongoURI, zebedeeURL string) (*FilesAPIComponent, error) {
+// NewFilesAPIComponent creates the component under test. With the memory storage backend the mongoURI is not used.
+func NewFilesAPIComponent(storageBackend, mongoURI, zebedeeURL string) (*FilesAPIComponent, error) {
 	s := dphttp.NewServer("", http.NewServeMux())
 	s.HandleOSSignals = false
 
 	c := &FilesAPIComponent{
-		DpHTTPServer: s,
-		errChan:      make(chan error),
+		DpHTTPServer:   s,
+		errChan:        make(chan error),
+		storageBackend: storageBackend,
 	}
 
 	var err error
@@ -176,6 +181,16 @@ func getPermissionsBundle() *permissionsAPISDK.Bundle {
 					ID: "1",
 				},
 			},
+			"groups/role-dataset-publisher": {
+				{
+					ID: "1",
+					Condition: permissionsAPISDK.Condition{
+						Values:    []string{"cpih01/feb-2026"},
+						Attribute: "dataset_edition",
+						Operator:  "StringEquals",
+					},
+				},
+			},
 			"users/service": {
 				{
 					ID: "1",
@@ -233,12 +248,14 @@ func (c *FilesAPIComponent) Initialiser() (http.Handler, error) {
 	}
 
 	r := &mux.Router{}
-	c.svcList = &fakeServiceContainer{c.DpHTTPServer, r, c.isAuthorised, c.isViewerAllowed, c.isViewerNotAllowed, c.mongoStoreClient}
+	c.svcList = &fakeServiceContainer{c.DpHTTPServer, r, c.isAuthorised, c.isViewerAllowed, c.isViewerNotAllowed, c.mongoStoreClient, c.repository}
 	cfg, err := config.Get()
 	if err != nil {
 		return nil, err
 	}
 	cfg.IsPublishing = c.isPublishing
+	// relay outbox messages promptly so that published message steps don't wait on the default interval
+	cfg.OutboxRelayInterval = 10 * time.Millisecond
 
 	c.svc, err = service.Run(context.Background(), c.svcList, c.errChan, cfg, r)
 	if err != nil {
@@ -250,6 +267,23 @@ func (c *FilesAPIComponent) Initialiser() (http.Handler, error) {
 }
 
 func (c *FilesAPIComponent) Reset() {
+	if c.storageBackend == config.StorageBackendMemory {
+		c.mongoStoreClient = nil
+		c.repository = store.NewMemoryRepository()
+	} else {
+		c.resetMongo()
+	}
+
+	key, err := c.APIFeature.JWTFeature.EnsureKeys()
+	if err != nil {
+		panic(err)
+	}
+	c.Config.JWTVerificationPublicKeys[key.KID] = key.PublicKeyB64
+
+	c.isPublishing = true
+}
+
+func (c *FilesAPIComponent) resetMongo() {
 	ctx, cancel := context.WithTimeout(context.Background(), 45*time.Second)
 	defer cancel()
 
@@ -282,7 +316,7 @@ func (c *FilesAPIComponent) Reset() {
 	}
 
 	// Recreate collections
-	for _, name := range []string{"metadata", "collections", "bundles", "file_events"} {
+	for _, name := range []string{"metadata", "collections", "bundles", "file_events", "file_event_chain", "outbox", "publish_jobs", "file_versions", "file_changes"} {
 		if err = db.CreateCollection(ctx, name); err != nil {
 			log.Error(ctx, "failed to create collection", err, log.Data{"collection": name})
 			panic(err)
@@ -323,13 +357,46 @@ func (c *FilesAPIComponent) Reset() {
 		panic(err)
 	}
 
-	key, err := c.APIFeature.JWTFeature.EnsureKeys()
-	if err != nil {
+	if err = c.mongoStoreClient.CreateIndexes(ctx, config.FileVersionsCollection, store.FileVersionIndexes); err != nil {
+		log.Error(ctx, "failed to create index on file_versions collection", err)
 		panic(err)
 	}
-	c.Config.JWTVerificationPublicKeys[key.KID] = key.PublicKeyB64
 
-	c.isPublishing = true
+	if err = c.mongoStoreClient.CreateIndexes(ctx, config.FileEventsCollection, store.FileEventIndexes); err != nil {
+		log.Error(ctx, "failed to create index on file_events collection", err)
+		panic(err)
+	}
+
+	if err = c.mongoStoreClient.CreateIndexes(ctx, config.IdempotencyKeysCollection, store.IdempotencyKeyIndexes); err != nil {
+		log.Error(ctx, "failed to create index on idempotency_keys collection", err)
+		panic(err)
+	}
+
+	if err = c.mongoStoreClient.CreateIndexes(ctx, config.FileChangesCollection, store.FileChangeIndexes); err != nil {
+		log.Error(ctx, "failed to create index on file_changes collection", err)
+		panic(err)
+	}
+
+	if err = store.CreateLockIndexes(ctx, c.mongoStoreClient.Collection(config.LocksCollection)); err != nil {
+		log.Error(ctx, "failed to create index on locks collection", err)
+		panic(err)
+	}
+
+	c.repository = store.NewMongoRepository(store.MongoCollections{
+		Metadata:        c.mongoStoreClient.Collection(config.MetadataCollection),
+		Collections:     c.mongoStoreClient.Collection(config.CollectionsCollection),
+		Bundles:         c.mongoStoreClient.Collection(config.BundlesCollection),
+		FileEvents:      c.mongoStoreClient.Collection(config.FileEventsCollection),
+		Outbox:          c.mongoStoreClient.Collection(config.OutboxCollection),
+		PublishJobs:     c.mongoStoreClient.Collection(config.PublishJobsCollection),
+		FileVersions:    c.mongoStoreClient.Collection(config.FileVersionsCollection),
+		FileChanges:     c.mongoStoreClient.Collection(config.FileChangesCollection),
+		Locks:           c.mongoStoreClient.Collection(config.LocksCollection),
+		Trash:           c.mongoStoreClient.Collection(config.TrashCollection),
+		Reconciliations: c.mongoStoreClient.Collection(config.ReconciliationsCollection),
+		IdempotencyKeys: c.mongoStoreClient.Collection(config.IdempotencyKeysCollection),
+		FileEventChain:  c.mongoStoreClient.Collection(config.FileEventChainCollection),
+	})
 }
 
 func (c *FilesAPIComponent) Close() error {
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/ONSdigital/dp-files-api/mongo"
+	"github.com/ONSdigital/dp-files-api/store"
 	"github.com/gorilla/mux"
 
 	"github.com/ONSdigital/dp-authorisation/v2/authorisation"
@@ -33,6 +34,7 @@ type fakeServiceContainer struct {
 	isViewerAllowed    bool
 	isViewerNotAllowed bool
 	mongoClient        mongo.Client
+	repository         store.Repository
 }
 
 func (e *fakeServiceContainer) GetAuthMiddleware() authorisation.Middleware {
@@ -59,6 +61,10 @@ func (e *fakeServiceContainer) GetMongoDB() mongo.Client {
 	return e.mongoClient
 }
 
+func (e *fakeServiceContainer) GetRepository() store.Repository {
+	return e.repository
+}
+
 func (e *fakeServiceContainer) GetClock() clock.Clock {
 	return TestClock{}
 }
@@ -74,24 +80,34 @@ func (e *fakeServiceContainer) GetS3Clienter() aws.S3Clienter {
 		fmt.Println("S3 ERROR: " + err.Error())
 	}
 
-	return dps3.NewClientWithConfig(cfg.PrivateBucketName, awsCfg, func(o *s3.Options) {
+	localstack := func(o *s3.Options) {
 		o.BaseEndpoint = awssdk.String("http://localstack:4566")
 		o.UsePathStyle = true
-	})
+	}
+	return aws.NewClient(dps3.NewClientWithConfig(cfg.PrivateBucketName, awsCfg, localstack), localstack)
 }
 
-func (e *fakeServiceContainer) GetKafkaProducer() kafka.IProducer {
+func (e *fakeServiceContainer) GetKafkaProducers() map[string]kafka.IProducer {
 	cfg, _ := config.Get()
-	pConfig := &kafka.ProducerConfig{
-		BrokerAddrs:       cfg.Addr,
-		Topic:             cfg.StaticFilePublishedTopic,
-		MinBrokersHealthy: &cfg.ProducerMinBrokersHealthy,
-		KafkaVersion:      &cfg.Version,
-		MaxMessageBytes:   &cfg.MaxBytes,
+	producers := map[string]kafka.IProducer{}
+	for _, topic := range cfg.ProducerTopics() {
+		pConfig := &kafka.ProducerConfig{
+			BrokerAddrs:       cfg.Addr,
+			Topic:             topic,
+			MinBrokersHealthy: &cfg.ProducerMinBrokersHealthy,
+			KafkaVersion:      &cfg.Version,
+			MaxMessageBytes:   &cfg.MaxBytes,
+		}
+
+		producer, _ := kafka.NewProducer(context.Background(), pConfig)
+		producers[topic] = producer
 	}
+	return producers
+}
 
-	producer, _ := kafka.NewProducer(context.Background(), pConfig)
-	return producer
+// GetKafkaConsumers gives no consumers, so the web metadata cache is only emptied as its entries expire
+func (e *fakeServiceContainer) GetKafkaConsumers() map[string]kafka.IConsumerGroup {
+	return map[string]kafka.IConsumerGroup{}
 }
 
 func (e *fakeServiceContainer) Shutdown(ctx context.Context) error {
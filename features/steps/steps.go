@@ -11,7 +11,6 @@ import (
 	"github.com/ONSdigital/dp-files-api/config"
 	"github.com/ONSdigital/dp-files-api/store"
 	kafka "github.com/ONSdigital/dp-kafka/v3"
-	"github.com/ONSdigital/dp-kafka/v3/avro"
 
 	messages "github.com/cucumber/messages/go/v21"
 
@@ -19,7 +18,6 @@ import (
 	"github.com/cucumber/godog"
 	"github.com/rdumont/assistdog"
 	"github.com/stretchr/testify/assert"
-	"go.mongodb.org/mongo-driver/bson"
 )
 
 func (c *FilesAPIComponent) RegisterSteps(ctx *godog.ScenarioContext) {
@@ -51,6 +49,7 @@ func (c *FilesAPIComponent) RegisterSteps(ctx *godog.ScenarioContext) {
 	ctx.Step(`^I get files in the bundle "([^"]*)"$`, c.iGetFilesInTheBundle)
 	ctx.Step(`^I get files with both collection_id "([^"]*)" and bundle_id "([^"]*)"$`, c.iGetFilesWithBothCollectionAndBundleID)
 	ctx.Step(`^the file upload "([^"]*)" is removed$`, c.theFileUploadIsRemoved)
+	ctx.Step(`^the file upload "([^"]*)" is restored$`, c.theFileUploadIsRestored)
 	ctx.Step(`^I create a file event with payload:$`, c.iCreateFileEvent)
 	ctx.Step(`^the file event should be created in the database$`, c.theFileEventShouldBeCreatedInTheDatabase)
 	ctx.Step(`^the following file events exist in the database:$`, c.theFollowingFileEventsExistInTheDatabase)
@@ -138,8 +137,8 @@ func (c *FilesAPIComponent) theFollowingDocumentShouldBeCreated(table *godog.Tab
 
 	expectedMetaData := keyValues.(*ExpectedMetaData)
 
-	res := c.mongoClient.Database("files").Collection("metadata").FindOne(ctx, bson.M{"path": expectedMetaData.Path})
-	assert.NoError(c.APIFeature, res.Decode(&metaData))
+	metaData, err = c.repository.GetMetadata(ctx, expectedMetaData.Path)
+	assert.NoError(c.APIFeature, err)
 
 	isPublishable, _ := strconv.ParseBool(expectedMetaData.IsPublishable)
 	sizeInBytes, _ := strconv.ParseUint(expectedMetaData.SizeInBytes, 10, 64)
@@ -181,7 +180,7 @@ func (c *FilesAPIComponent) theFileUploadHasBeenRegistered(path string) error {
 
 	m := files.StoredRegisteredMetaData{Path: path}
 
-	_, err := c.mongoClient.Database("files").Collection("metadata").InsertOne(ctx, &m)
+	err := c.repository.InsertMetadata(ctx, m)
 	assert.NoError(c.APIFeature, err)
 
 	return c.APIFeature.StepError()
@@ -192,7 +191,7 @@ func (c *FilesAPIComponent) theCollectionIsPublished(collectionID string) error
 
 	coll := files.StoredCollection{ID: collectionID, State: store.StatePublished}
 
-	_, err := c.mongoClient.Database("files").Collection("collections").InsertOne(ctx, &coll)
+	err := c.repository.InsertCollection(ctx, coll)
 	assert.NoError(c.APIFeature, err)
 	return c.APIFeature.StepError()
 }
@@ -202,7 +201,7 @@ func (c *FilesAPIComponent) theBundleIsPublished(bundleID string) error {
 
 	bundle := files.StoredBundle{ID: bundleID, State: store.StatePublished}
 
-	_, err := c.mongoClient.Database("files").Collection("bundles").InsertOne(ctx, &bundle)
+	err := c.repository.InsertBundle(ctx, bundle)
 	assert.NoError(c.APIFeature, err)
 	return c.APIFeature.StepError()
 }
@@ -240,7 +239,7 @@ func (c *FilesAPIComponent) theFileUploadHasBeenPublishedWith(path string, table
 		Etag:              data.Etag,
 	}
 
-	_, err = c.mongoClient.Database("files").Collection("metadata").InsertOne(context.Background(), &m)
+	err = c.repository.InsertMetadata(context.Background(), m)
 	assert.NoError(c.APIFeature, err)
 
 	return c.APIFeature.StepError()
@@ -283,7 +282,7 @@ func (c *FilesAPIComponent) theFileUploadHasBeenCompletedWith(path string, table
 		m.BundleID = &data.BundleID
 	}
 
-	_, err = c.mongoClient.Database("files").Collection("metadata").InsertOne(context.Background(), &m)
+	err = c.repository.InsertMetadata(context.Background(), m)
 	assert.NoError(c.APIFeature, err)
 
 	return c.APIFeature.StepError()
@@ -331,7 +330,7 @@ func (c *FilesAPIComponent) theFileUploadHasBeenRegisteredWith(path string, tabl
 		}
 	}
 
-	_, err = c.mongoClient.Database("files").Collection("metadata").InsertOne(context.Background(), &m)
+	err = c.repository.InsertMetadata(context.Background(), m)
 	assert.NoError(c.APIFeature, err)
 
 	return c.APIFeature.StepError()
@@ -339,7 +338,7 @@ func (c *FilesAPIComponent) theFileUploadHasBeenRegisteredWith(path string, tabl
 
 func (c *FilesAPIComponent) theFileUploadHasNotBeenRegistered(path string) error {
 	ctx := context.Background()
-	_, err := c.mongoClient.Database("files").Collection("metadata").DeleteMany(ctx, bson.M{"path": path})
+	_, err := c.repository.DeleteMetadata(ctx, path)
 
 	assert.NoError(c.APIFeature, err)
 
@@ -377,6 +376,10 @@ func (c *FilesAPIComponent) theFileUploadIsRemoved(path string) error {
 	return c.APIFeature.IDelete(fmt.Sprintf("/files/%s", path))
 }
 
+func (c *FilesAPIComponent) theFileUploadIsRestored(path string) error {
+	return c.APIFeature.IPostToWithBody(fmt.Sprintf("/files/%s/restore", path), &godog.DocString{})
+}
+
 func (c *FilesAPIComponent) theFollowingDocumentEntryShouldLookLike(table *godog.Table) error {
 	ctx := context.Background()
 
@@ -395,9 +398,8 @@ func (c *FilesAPIComponent) theFollowingDocumentEntryShouldLookLike(table *godog
 	body, _ := io.ReadAll(responseBody)
 	assert.NoError(c.APIFeature, json.Unmarshal(body, &metaData))
 
-	dbMetadata := files.StoredRegisteredMetaData{}
-	res := c.mongoClient.Database("files").Collection("metadata").FindOne(ctx, bson.M{"path": expectedMetaData.Path})
-	assert.NoError(c.APIFeature, res.Decode(&dbMetadata))
+	dbMetadata, err := c.repository.GetMetadata(ctx, expectedMetaData.Path)
+	assert.NoError(c.APIFeature, err)
 
 	metaData.CreatedAt = dbMetadata.CreatedAt
 	metaData.LastModified = dbMetadata.LastModified
@@ -405,9 +407,7 @@ func (c *FilesAPIComponent) theFollowingDocumentEntryShouldLookLike(table *godog
 	metaData.MovedAt = dbMetadata.MovedAt
 
 	if metaData.CollectionID != nil && metaData.State == store.StatePublished {
-		dbCollection := files.StoredCollection{}
-		res = c.mongoClient.Database("files").Collection("collections").FindOne(ctx, bson.M{"id": *metaData.CollectionID})
-		_ = res.Decode(&dbCollection)
+		dbCollection, _ := c.repository.GetCollection(ctx, *metaData.CollectionID)
 
 		if dbCollection.State == store.StatePublished {
 			metaData.LastModified = dbCollection.LastModified
@@ -416,9 +416,7 @@ func (c *FilesAPIComponent) theFollowingDocumentEntryShouldLookLike(table *godog
 	}
 
 	if metaData.BundleID != nil && metaData.State == store.StatePublished {
-		dbBundle := files.StoredBundle{}
-		res = c.mongoClient.Database("files").Collection("bundles").FindOne(ctx, bson.M{"id": *metaData.BundleID})
-		_ = res.Decode(&dbBundle)
+		dbBundle, _ := c.repository.GetBundle(ctx, *metaData.BundleID)
 
 		if dbBundle.State == store.StatePublished {
 			metaData.LastModified = dbBundle.LastModified
@@ -515,20 +513,8 @@ func (c *FilesAPIComponent) kafkaConsumerGroupIsRunning() error {
 	assert.NoError(c.APIFeature, err)
 
 	err = c.cg.RegisterHandler(ctx, func(ctx context.Context, workerID int, msg kafka.Message) error {
-		schema := &avro.Schema{
-			Definition: `{
-					"type": "record",
-					"name": "file-published",
-					"fields": [
-					  {"name": "path", "type": "string"},
-					  {"name": "etag", "type": "string"},
-					  {"name": "type", "type": "string"},
-					  {"name": "sizeInBytes", "type": "string"}
-					]
-				  }`,
-		}
 		fp := files.FilePublished{}
-		unmarshalErr := schema.Unmarshal(msg.GetData(), &fp)
+		unmarshalErr := files.AvroSchema.Unmarshal(msg.GetData(), &fp)
 		assert.NoError(c.APIFeature, unmarshalErr)
 
 		c.msgsMu.Lock()
@@ -576,12 +562,11 @@ func (c *FilesAPIComponent) iUpdateTheContentItemOfTheFileWith(path string, payl
 func (c *FilesAPIComponent) anUpdateAuditEventShouldBeCreatedForFile(path string) error {
 	ctx := context.Background()
 
-	count, err := c.mongoClient.Database("files").Collection("file_events").CountDocuments(ctx, bson.M{
-		"action":    "UPDATE",
-		"file.path": path,
+	count, err := c.countFileEvents(ctx, store.FileEventFilter{Path: path}, func(e files.FileEvent) bool {
+		return e.Action == "UPDATE"
 	})
 	assert.NoError(c.APIFeature, err)
-	assert.Equal(c.APIFeature, int64(1), count, "Expected exactly one UPDATE audit event for file %s", path)
+	assert.Equal(c.APIFeature, 1, count, "Expected exactly one UPDATE audit event for file %s", path)
 
 	return c.APIFeature.StepError()
 }
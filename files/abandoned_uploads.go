@@ -0,0 +1,19 @@
+package files
+
+import "time"
+
+// AbandonedUploads lists the files whose upload was started no later than CreatedBefore and never completed
+type AbandonedUploads struct {
+	CreatedBefore time.Time         `json:"created_before"`
+	Count         int               `json:"count"`
+	Items         []AbandonedUpload `json:"items"`
+}
+
+// AbandonedUpload is a file whose upload was started at CreatedAt and never completed
+type AbandonedUpload struct {
+	Path         string    `json:"path"`
+	CollectionID *string   `json:"collection_id,omitempty"`
+	BundleID     *string   `json:"bundle_id,omitempty"`
+	SizeInBytes  uint64    `json:"size_in_bytes"`
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -0,0 +1,24 @@
+package files
+
+// Directory is a page of the listing of the files stored under a path prefix. FileCount and SizeInBytes cover every
+// file under the prefix, however deep; Directories and Files are only its immediate children, Count of them on this
+// page. NextCursor gets the next page when there is one.
+type Directory struct {
+	Path        string           `json:"path"`
+	FileCount   int              `json:"file_count"`
+	SizeInBytes uint64           `json:"size_in_bytes"`
+	Count       int              `json:"count"`
+	Limit       int              `json:"limit"`
+	NextCursor  string           `json:"next_cursor,omitempty"`
+	Directories []DirectoryEntry `json:"directories"`
+	Files       []DirectoryEntry `json:"files"`
+}
+
+// DirectoryEntry is a child of a directory. For a child directory FileCount and SizeInBytes cover every file under it.
+type DirectoryEntry struct {
+	Name        string `bson:"name" json:"name"`
+	Path        string `bson:"-" json:"path"`
+	IsDirectory bool   `bson:"is_directory" json:"-"`
+	FileCount   int    `bson:"file_count" json:"file_count,omitempty"`
+	SizeInBytes uint64 `bson:"size_in_bytes" json:"size_in_bytes"`
+}
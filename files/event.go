@@ -10,15 +10,151 @@ var AvroSchema = &avro.Schema{
 			  {"name": "path", "type": "string"},
 			  {"name": "etag", "type": "string"},
 			  {"name": "type", "type": "string"},
-			  {"name": "sizeInBytes", "type": "string"}
+			  {"name": "sizeInBytes", "type": "string"},
+			  {"name": "checksumSHA256", "type": "string", "default": ""},
+			  {"name": "checksumMD5", "type": "string", "default": ""}
 			]
 		  }`,
 }
 
-// FilePublished provides an avro structure for an image published event
+// FilePublished provides an avro structure for an image published event. The checksums are hex encoded and empty
+// when they are not known.
 type FilePublished struct {
-	Path        string `avro:"path"`
-	Type        string `avro:"type"`
-	Etag        string `avro:"etag"`
-	SizeInBytes string `avro:"sizeInBytes"`
+	Path           string `avro:"path" bson:"path"`
+	Type           string `avro:"type" bson:"type"`
+	Etag           string `avro:"etag" bson:"etag"`
+	SizeInBytes    string `avro:"sizeInBytes" bson:"size_in_bytes"`
+	ChecksumSHA256 string `avro:"checksumSHA256" bson:"checksum_sha256,omitempty"`
+	ChecksumMD5    string `avro:"checksumMD5" bson:"checksum_md5,omitempty"`
+}
+
+// AvroSchemaV3 is version 3 of the file published event. It carries the file's metadata as well as its location, so
+// that consumers need not call back into the API for it.
+var AvroSchemaV3 = &avro.Schema{
+	Definition: `{
+			"type": "record",
+			"name": "file-published-v3",
+			"fields": [
+			  {"name": "eventId", "type": "string"},
+			  {"name": "path", "type": "string"},
+			  {"name": "etag", "type": "string"},
+			  {"name": "type", "type": "string"},
+			  {"name": "sizeInBytes", "type": "long"},
+			  {"name": "title", "type": "string"},
+			  {"name": "licence", "type": "string"},
+			  {"name": "licenceUrl", "type": "string"},
+			  {"name": "collectionId", "type": "string"},
+			  {"name": "bundleId", "type": "string"},
+			  {"name": "contentItem", "type": {
+			    "type": "record",
+			    "name": "content-item",
+			    "fields": [
+			      {"name": "datasetId", "type": "string"},
+			      {"name": "edition", "type": "string"},
+			      {"name": "version", "type": "string"}
+			    ]
+			  }},
+			  {"name": "publishedAt", "type": "long"},
+			  {"name": "checksumSHA256", "type": "string"},
+			  {"name": "checksumMD5", "type": "string"}
+			]
+		  }`,
+}
+
+// FilePublishedV3 provides an avro structure for version 3 of the file published event. The event ID is unique to
+// the event and is the same each time it is sent. PublishedAt is in milliseconds since the Unix epoch. Fields that
+// are not set on the file are empty.
+type FilePublishedV3 struct {
+	EventID        string                   `avro:"eventId" bson:"event_id"`
+	Path           string                   `avro:"path" bson:"path"`
+	Etag           string                   `avro:"etag" bson:"etag"`
+	Type           string                   `avro:"type" bson:"type"`
+	SizeInBytes    int64                    `avro:"sizeInBytes" bson:"size_in_bytes"`
+	Title          string                   `avro:"title" bson:"title"`
+	Licence        string                   `avro:"licence" bson:"licence"`
+	LicenceURL     string                   `avro:"licenceUrl" bson:"licence_url"`
+	CollectionID   string                   `avro:"collectionId" bson:"collection_id"`
+	BundleID       string                   `avro:"bundleId" bson:"bundle_id"`
+	ContentItem    FilePublishedContentItem `avro:"contentItem" bson:"content_item"`
+	PublishedAt    int64                    `avro:"publishedAt" bson:"published_at"`
+	ChecksumSHA256 string                   `avro:"checksumSHA256" bson:"checksum_sha256"`
+	ChecksumMD5    string                   `avro:"checksumMD5" bson:"checksum_md5"`
+}
+
+// FilePublishedContentItem is the dataset information of a published file
+type FilePublishedContentItem struct {
+	DatasetID string `avro:"datasetId" bson:"dataset_id"`
+	Edition   string `avro:"edition" bson:"edition"`
+	Version   string `avro:"version" bson:"version"`
+}
+
+// AvroLifecycleSchema is the file lifecycle event, sent whenever a file is registered, changes state, is removed or
+// restored, or has its content item updated
+var AvroLifecycleSchema = &avro.Schema{
+	Definition: `{
+			"type": "record",
+			"name": "file-lifecycle",
+			"fields": [
+			  {"name": "eventId", "type": "string"},
+			  {"name": "path", "type": "string"},
+			  {"name": "change", "type": "string"},
+			  {"name": "fromState", "type": "string"},
+			  {"name": "toState", "type": "string"},
+			  {"name": "actor", "type": "string"},
+			  {"name": "timestamp", "type": "long"}
+			]
+		  }`,
+}
+
+// Lifecycle changes
+const (
+	LifecycleRegistered         = "REGISTERED"
+	LifecycleStateChanged       = "STATE_CHANGED"
+	LifecycleRemoved            = "REMOVED"
+	LifecycleContentItemUpdated = "CONTENT_ITEM_UPDATED"
+	LifecycleRestored           = "RESTORED"
+)
+
+// FileLifecycle provides an avro structure for a file lifecycle event. FromState is empty for a newly registered or
+// restored file and ToState is empty for a removed one; a content item update leaves the state as it was. Actor is the
+// user or service that made the change, empty when the service made it itself, and Timestamp is in milliseconds since
+// the Unix epoch.
+type FileLifecycle struct {
+	EventID   string `avro:"eventId" bson:"event_id"`
+	Path      string `avro:"path" bson:"path"`
+	Change    string `avro:"change" bson:"change"`
+	FromState string `avro:"fromState" bson:"from_state"`
+	ToState   string `avro:"toState" bson:"to_state"`
+	Actor     string `avro:"actor" bson:"actor"`
+	Timestamp int64  `avro:"timestamp" bson:"timestamp"`
+}
+
+// AvroWithdrawnSchema is the file withdrawn event, sent when a published file is withdrawn on its own or as part of a
+// collection or bundle
+var AvroWithdrawnSchema = &avro.Schema{
+	Definition: `{
+			"type": "record",
+			"name": "file-withdrawn",
+			"fields": [
+			  {"name": "eventId", "type": "string"},
+			  {"name": "path", "type": "string"},
+			  {"name": "collectionId", "type": "string"},
+			  {"name": "bundleId", "type": "string"},
+			  {"name": "reason", "type": "string"},
+			  {"name": "actor", "type": "string"},
+			  {"name": "withdrawnAt", "type": "long"}
+			]
+		  }`,
+}
+
+// FileWithdrawn provides an avro structure for a file withdrawn event. The collection and bundle IDs are empty when
+// the file is not in one, and WithdrawnAt is in milliseconds since the Unix epoch.
+type FileWithdrawn struct {
+	EventID      string `avro:"eventId" bson:"event_id"`
+	Path         string `avro:"path" bson:"path"`
+	CollectionID string `avro:"collectionId" bson:"collection_id"`
+	BundleID     string `avro:"bundleId" bson:"bundle_id"`
+	Reason       string `avro:"reason" bson:"reason"`
+	Actor        string `avro:"actor" bson:"actor"`
+	WithdrawnAt  int64  `avro:"withdrawnAt" bson:"withdrawn_at"`
 }
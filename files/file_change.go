@@ -0,0 +1,18 @@
+package files
+
+import "time"
+
+// FileChange is an entry in the change log of file states, which clients following a collection or bundle read to
+// find out what has changed since they last looked. Change is one of the lifecycle changes. Sequence numbers only
+// increase, so a client reads on from the last one it has seen. The entry is deleted once ExpiresAt has passed.
+type FileChange struct {
+	Sequence     int64     `bson:"sequence" json:"sequence"`
+	Path         string    `bson:"path" json:"path"`
+	CollectionID *string   `bson:"collection_id,omitempty" json:"collection_id,omitempty"`
+	BundleID     *string   `bson:"bundle_id,omitempty" json:"bundle_id,omitempty"`
+	Change       string    `bson:"change" json:"change"`
+	FromState    string    `bson:"from_state,omitempty" json:"from_state,omitempty"`
+	ToState      string    `bson:"to_state,omitempty" json:"to_state,omitempty"`
+	CreatedAt    time.Time `bson:"created_at" json:"created_at"`
+	ExpiresAt    time.Time `bson:"expires_at" json:"-"`
+}
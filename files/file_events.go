@@ -2,13 +2,18 @@ package files
 
 import "time"
 
-// FileEvent represents a file access event for the audit log
+// FileEvent represents a file access event for the audit log. Events are chained in the order they were written:
+// each has the next Sequence number and the Hash of the event before it as PreviousHash. Hash is kept last, as it
+// covers the stored document without it.
 type FileEvent struct {
-	CreatedAt   *time.Time                `json:"created_at,omitempty" bson:"created_at,omitempty"`
-	RequestedBy *RequestedBy              `json:"requested_by" bson:"requested_by"`
-	Action      string                    `json:"action" bson:"action"`
-	Resource    string                    `json:"resource" bson:"resource"`
-	File        *StoredRegisteredMetaData `json:"file" bson:"file"`
+	Sequence     int64                     `json:"sequence,omitempty" bson:"sequence,omitempty"`
+	PreviousHash string                    `json:"previous_hash,omitempty" bson:"previous_hash,omitempty"`
+	CreatedAt    *time.Time                `json:"created_at,omitempty" bson:"created_at,omitempty"`
+	RequestedBy  *RequestedBy              `json:"requested_by" bson:"requested_by"`
+	Action       string                    `json:"action" bson:"action"`
+	Resource     string                    `json:"resource" bson:"resource"`
+	File         *StoredRegisteredMetaData `json:"file" bson:"file"`
+	Hash         string                    `json:"hash,omitempty" bson:"hash,omitempty"`
 }
 
 // RequestedBy represents the user who made the request
@@ -41,6 +46,32 @@ type EventsList struct {
 	Items      []FileEvent `json:"items"`
 }
 
+// FileEventChainVerification is the outcome of walking the chain of file events from the first. Break is the first
+// event found not to follow on from the one before it, and is nil when the whole chain is intact. LastSequence and
+// LastHash are those of the last event checked, so they can be recorded elsewhere to show that no events have since
+// been removed from the end of the chain.
+type FileEventChainVerification struct {
+	Verified      bool                 `json:"verified"`
+	EventsChecked int                  `json:"events_checked"`
+	LastSequence  int64                `json:"last_sequence"`
+	LastHash      string               `json:"last_hash,omitempty"`
+	Break         *FileEventChainBreak `json:"break,omitempty"`
+}
+
+// FileEventChainBreak is where the chain of file events was found to be broken, and why
+type FileEventChainBreak struct {
+	Sequence int64  `json:"sequence"`
+	Reason   string `json:"reason"`
+}
+
+// Chain break reasons
+const (
+	ChainBreakMissingEvent         = "MISSING_EVENT"
+	ChainBreakPreviousHashMismatch = "PREVIOUS_HASH_MISMATCH"
+	ChainBreakHashMismatch         = "HASH_MISMATCH"
+	ChainBreakHeadMismatch         = "HEAD_MISMATCH"
+)
+
 // Action consts
 const (
 	ActionCreate = "CREATE"
@@ -0,0 +1,28 @@
+package files
+
+import "time"
+
+// FileVersion describes one version of the content stored at a path
+type FileVersion struct {
+	Version           int        `json:"version"`
+	State             string     `json:"state"`
+	Etag              string     `json:"etag"`
+	ChecksumSHA256    string     `json:"checksum_sha256,omitempty"`
+	ChecksumMD5       string     `json:"checksum_md5,omitempty"`
+	SizeInBytes       uint64     `json:"size_in_bytes"`
+	Type              string     `json:"type"`
+	CollectionID      *string    `json:"collection_id,omitempty"`
+	BundleID          *string    `json:"bundle_id,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	LastModified      time.Time  `json:"last_modified"`
+	UploadCompletedAt *time.Time `json:"upload_completed_at,omitempty"`
+	PublishedAt       *time.Time `json:"published_at,omitempty"`
+	MovedAt           *time.Time `json:"moved_at,omitempty"`
+}
+
+// FileVersionsList lists the versions of the file at a path, oldest first
+type FileVersionsList struct {
+	Path  string        `json:"path"`
+	Count int           `json:"count"`
+	Items []FileVersion `json:"items"`
+}
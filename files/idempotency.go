@@ -0,0 +1,21 @@
+package files
+
+import "time"
+
+// IdempotencyRecord is a request made with an Idempotency-Key and, once it has been handled, the response to it, which
+// is replayed to retries of the request until ExpiresAt. The key is the document's _id, so it is unique without an
+// index having to be created for it.
+type IdempotencyRecord struct {
+	Key         string              `bson:"_id"`
+	Fingerprint string              `bson:"fingerprint"`
+	Response    *IdempotentResponse `bson:"response,omitempty"`
+	CreatedAt   time.Time           `bson:"created_at"`
+	ExpiresAt   time.Time           `bson:"expires_at"`
+}
+
+// IdempotentResponse is the response first given to a request made with an Idempotency-Key
+type IdempotentResponse struct {
+	StatusCode int                 `bson:"status_code"`
+	Header     map[string][]string `bson:"header,omitempty"`
+	Body       []byte              `bson:"body,omitempty"`
+}
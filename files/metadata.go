@@ -22,24 +22,56 @@ type StoredRegisteredMetaData struct {
 	MovedAt           *time.Time         `bson:"moved_at,omitempty" json:"-"`
 	State             string             `bson:"state" json:"state"`
 	Etag              string             `bson:"etag" json:"etag"`
+	ChecksumSHA256    string             `bson:"checksum_sha256,omitempty" json:"checksum_sha256,omitempty"`
+	ChecksumMD5       string             `bson:"checksum_md5,omitempty" json:"checksum_md5,omitempty"`
+	Version           int                `bson:"version,omitempty" json:"version,omitempty"`
+	Revision          int64              `bson:"revision,omitempty" json:"-"`
+	WithdrawnAt       *time.Time         `bson:"withdrawn_at,omitempty" json:"-"`
+	WithdrawalReason  string             `bson:"withdrawal_reason,omitempty" json:"withdrawal_reason,omitempty"`
+}
+
+// FilesList represents a page of file metadata. NextCursor is set when there are more files, and is given as the
+// cursor to get the next page. Pages can also be taken by Offset.
+type FilesList struct {
+	Count      int                        `json:"count"`
+	Limit      int                        `json:"limit"`
+	Offset     int                        `json:"offset"`
+	TotalCount int                        `json:"total_count"`
+	NextCursor string                     `json:"next_cursor,omitempty"`
+	Items      []StoredRegisteredMetaData `json:"items"`
 }
 
 type StoredCollection struct {
-	ID           string     `bson:"id" json:"id"`
-	State        string     `bson:"state" json:"state"`
-	LastModified time.Time  `bson:"last_modified" json:"-"`
-	PublishedAt  *time.Time `bson:"published_at,omitempty" json:"-"`
+	ID               string     `bson:"id" json:"id"`
+	State            string     `bson:"state" json:"state"`
+	LastModified     time.Time  `bson:"last_modified" json:"-"`
+	PublishAt        *time.Time `bson:"publish_at,omitempty" json:"-"`
+	PublishedAt      *time.Time `bson:"published_at,omitempty" json:"-"`
+	WithdrawnAt      *time.Time `bson:"withdrawn_at,omitempty" json:"-"`
+	WithdrawalReason string     `bson:"withdrawal_reason,omitempty" json:"-"`
 }
 
 type StoredBundle struct {
-	ID           string    `bson:"id" json:"id"`
-	State        string    `bson:"state" json:"state"`
-	LastModified time.Time `bson:"last_modified" json:"-"`
+	ID               string     `bson:"id" json:"id"`
+	State            string     `bson:"state" json:"state"`
+	LastModified     time.Time  `bson:"last_modified" json:"-"`
+	PublishAt        *time.Time `bson:"publish_at,omitempty" json:"-"`
+	WithdrawnAt      *time.Time `bson:"withdrawn_at,omitempty" json:"-"`
+	WithdrawalReason string     `bson:"withdrawal_reason,omitempty" json:"-"`
+}
+
+// WithdrawalNotice tells those asking for a withdrawn file when and why it was withdrawn
+type WithdrawalNotice struct {
+	Path        string    `json:"path"`
+	WithdrawnAt time.Time `json:"withdrawn_at"`
+	Reason      string    `json:"reason"`
 }
 
 type FileEtagChange struct {
-	Path string
-	Etag string
+	Path           string
+	Etag           string
+	ChecksumSHA256 string
+	ChecksumMD5    string
 }
 
 type StoredContentItem struct {
@@ -0,0 +1,22 @@
+package files
+
+import "time"
+
+// OutboxMessage is a Kafka message persisted alongside the state change that produced it.
+// Messages stay in the outbox until the producer for their topic has had time to deliver them without reporting an
+// error. A Pending message is written before its change is made, and is not relayed until it is released once the
+// change has been made. SentAt is set while a message that has been handed to the producer awaits confirmation.
+type OutboxMessage struct {
+	ID              string           `bson:"id" json:"id"`
+	Topic           string           `bson:"topic" json:"topic"`
+	FilePublished   *FilePublished   `bson:"file_published,omitempty" json:"file_published,omitempty"`
+	FilePublishedV3 *FilePublishedV3 `bson:"file_published_v3,omitempty" json:"file_published_v3,omitempty"`
+	FileLifecycle   *FileLifecycle   `bson:"file_lifecycle,omitempty" json:"file_lifecycle,omitempty"`
+	FileWithdrawn   *FileWithdrawn   `bson:"file_withdrawn,omitempty" json:"file_withdrawn,omitempty"`
+	Pending         bool             `bson:"pending,omitempty" json:"pending,omitempty"`
+	Attempts        int              `bson:"attempts" json:"attempts"`
+	LastError       string           `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	SentAt          *time.Time       `bson:"sent_at,omitempty" json:"sent_at,omitempty"`
+	CreatedAt       time.Time        `bson:"created_at" json:"created_at"`
+	NextAttemptAt   time.Time        `bson:"next_attempt_at" json:"next_attempt_at"`
+}
@@ -0,0 +1,40 @@
+package files
+
+import "time"
+
+// PublishJob records the progress of writing FilePublished messages for every file in a published collection or bundle,
+// or of withdrawing every file in a withdrawn one, as given by its Action. Jobs written before withdrawals were run this
+// way have no Action, and publish their files. PublishedAt is when the collection or bundle was published, which every
+// message of a publishing job carries. Files are split into batches which are worked through concurrently, each keeping
+// the path of the last file it handled so that an interrupted job can be resumed without starting again. The job is run
+// by one instance of the service at a time, the Owner, until its lease expires.
+type PublishJob struct {
+	ID             string            `bson:"id" json:"id"`
+	CollectionID   *string           `bson:"collection_id,omitempty" json:"collection_id,omitempty"`
+	BundleID       *string           `bson:"bundle_id,omitempty" json:"bundle_id,omitempty"`
+	Action         string            `bson:"action,omitempty" json:"action,omitempty"`
+	Reason         string            `bson:"reason,omitempty" json:"reason,omitempty"`
+	Actor          string            `bson:"actor,omitempty" json:"-"`
+	PublishedAt    *time.Time        `bson:"published_at,omitempty" json:"published_at,omitempty"`
+	State          string            `bson:"state" json:"state"`
+	TotalFiles     int               `bson:"total_files" json:"total_files"`
+	Sent           int               `bson:"sent" json:"sent"`
+	Failed         int               `bson:"failed" json:"failed"`
+	Batches        []PublishJobBatch `bson:"batches" json:"batches"`
+	CreatedAt      time.Time         `bson:"created_at" json:"created_at"`
+	LastModified   time.Time         `bson:"last_modified" json:"last_modified"`
+	CompletedAt    *time.Time        `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+	Owner          string            `bson:"owner,omitempty" json:"-"`
+	LeaseExpiresAt *time.Time        `bson:"lease_expires_at,omitempty" json:"-"`
+}
+
+type PublishJobBatch struct {
+	Number    int    `bson:"number" json:"number"`
+	Offset    int    `bson:"offset" json:"offset"`
+	Size      int    `bson:"size" json:"size"`
+	Position  int    `bson:"position" json:"position"`
+	LastPath  string `bson:"last_path,omitempty" json:"-"`
+	Sent      int    `bson:"sent" json:"sent"`
+	Failed    int    `bson:"failed" json:"failed"`
+	Completed bool   `bson:"completed" json:"completed"`
+}
@@ -0,0 +1,37 @@
+package files
+
+import "time"
+
+// ReconciliationReport is the outcome of comparing the metadata of the files with the objects in the private bucket.
+// The counts cover everything found, while each list holds no more than the first of them by path. A report is stored
+// IN_PROGRESS when the reconciliation starts, and is COMPLETED or FAILED once it has finished.
+type ReconciliationReport struct {
+	ID                  string         `bson:"id" json:"id"`
+	State               string         `bson:"state" json:"state"`
+	StartedAt           time.Time      `bson:"started_at" json:"started_at"`
+	CompletedAt         *time.Time     `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+	FilesChecked        int            `bson:"files_checked" json:"files_checked"`
+	ObjectsChecked      int            `bson:"objects_checked" json:"objects_checked"`
+	MissingObjectCount  int            `bson:"missing_object_count" json:"missing_object_count"`
+	MissingObjects      []string       `bson:"missing_objects" json:"missing_objects"`
+	OrphanedObjectCount int            `bson:"orphaned_object_count" json:"orphaned_object_count"`
+	OrphanedObjects     []string       `bson:"orphaned_objects" json:"orphaned_objects"`
+	SizeMismatchCount   int            `bson:"size_mismatch_count" json:"size_mismatch_count"`
+	SizeMismatches      []SizeMismatch `bson:"size_mismatches" json:"size_mismatches"`
+	EtagMismatchCount   int            `bson:"etag_mismatch_count" json:"etag_mismatch_count"`
+	EtagMismatches      []EtagMismatch `bson:"etag_mismatches" json:"etag_mismatches"`
+}
+
+// SizeMismatch is a file whose object is not the size its metadata says
+type SizeMismatch struct {
+	Path              string `bson:"path" json:"path"`
+	SizeInBytes       uint64 `bson:"size_in_bytes" json:"size_in_bytes"`
+	ObjectSizeInBytes int64  `bson:"object_size_in_bytes" json:"object_size_in_bytes"`
+}
+
+// EtagMismatch is a file whose object does not have the etag its metadata says
+type EtagMismatch struct {
+	Path       string `bson:"path" json:"path"`
+	Etag       string `bson:"etag" json:"etag"`
+	ObjectEtag string `bson:"object_etag" json:"object_etag"`
+}
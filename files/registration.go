@@ -0,0 +1,28 @@
+package files
+
+// Results of registering a file in a batch
+const (
+	RegistrationCreated             = "CREATED"
+	RegistrationDuplicate           = "DUPLICATE"
+	RegistrationInvalid             = "INVALID"
+	RegistrationCollectionPublished = "COLLECTION_PUBLISHED"
+	RegistrationBundlePublished     = "BUNDLE_PUBLISHED"
+	RegistrationCollectionWithdrawn = "COLLECTION_WITHDRAWN"
+	RegistrationBundleWithdrawn     = "BUNDLE_WITHDRAWN"
+	RegistrationFailed              = "FAILED"
+)
+
+// RegistrationResult is the outcome of registering one file in a batch. Error explains why an invalid or failed file
+// was not registered.
+type RegistrationResult struct {
+	Path   string `json:"path"`
+	Result string `json:"result"`
+	Error  string `json:"error,omitempty"`
+}
+
+// RegistrationResults lists the outcome of registering each file in a batch, in the order the files were given
+type RegistrationResults struct {
+	Count   int                  `json:"count"`
+	Created int                  `json:"created"`
+	Items   []RegistrationResult `json:"items"`
+}
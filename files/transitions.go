@@ -0,0 +1,31 @@
+package files
+
+// Results of a state transition in a bulk request
+const (
+	TransitionApplied    = "APPLIED"
+	TransitionFailed     = "FAILED"
+	TransitionNotApplied = "NOT_APPLIED"
+)
+
+// StateTransition asks for the file at Path to be moved to State. Etag is the etag of the uploaded or moved file.
+type StateTransition struct {
+	Path  string `json:"path"`
+	State string `json:"state"`
+	Etag  string `json:"etag,omitempty"`
+}
+
+// TransitionResult is the outcome of one state transition in a bulk request. Error explains why a transition failed.
+// A transition is not applied when all-or-nothing was asked for and another transition in the request failed.
+type TransitionResult struct {
+	Path   string `json:"path"`
+	State  string `json:"state"`
+	Result string `json:"result"`
+	Error  string `json:"error,omitempty"`
+}
+
+// TransitionResults lists the outcome of each state transition in a bulk request, in the order they were given
+type TransitionResults struct {
+	Count   int                `json:"count"`
+	Applied int                `json:"applied"`
+	Items   []TransitionResult `json:"items"`
+}
@@ -0,0 +1,13 @@
+package files
+
+import "time"
+
+// TrashedFile is a removed file, kept with its stored object until PurgeAfter so that it can be restored
+type TrashedFile struct {
+	ID         string                   `bson:"id" json:"id"`
+	Path       string                   `bson:"path" json:"path"`
+	Metadata   StoredRegisteredMetaData `bson:"metadata" json:"metadata"`
+	DeletedAt  time.Time                `bson:"deleted_at" json:"deleted_at"`
+	DeletedBy  string                   `bson:"deleted_by,omitempty" json:"deleted_by,omitempty"`
+	PurgeAfter time.Time                `bson:"purge_after" json:"purge_after"`
+}
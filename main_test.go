@@ -13,6 +13,7 @@ import (
 	"github.com/ONSdigital/log.go/v2/log"
 
 	componenttest "github.com/ONSdigital/dp-component-test"
+	"github.com/ONSdigital/dp-files-api/config"
 	"github.com/ONSdigital/dp-files-api/features/steps"
 	"github.com/cucumber/godog"
 )
@@ -20,6 +21,7 @@ import (
 var (
 	componentFlag = flag.Bool("component", false, "perform component tests")
 	loggingFlag   = flag.Bool("logging", false, "print logging")
+	backendFlag   = flag.String("backend", config.StorageBackendMongo, "storage backend to run the component tests against (mongo or memory)")
 )
 
 const mongoVersion = "4.4.8"
@@ -33,12 +35,16 @@ type ComponentTest struct {
 func (f *ComponentTest) InitializeScenario(ctx *godog.ScenarioContext) {
 	authorizationFeature := componenttest.NewAuthorizationFeature()
 
-	mongoURI, err := f.MongoFeature.GetConnectionString()
-	if err != nil {
-		panic(err)
+	var mongoURI string
+	if f.MongoFeature != nil {
+		var err error
+		mongoURI, err = f.MongoFeature.GetConnectionString()
+		if err != nil {
+			panic(err)
+		}
 	}
 
-	component, err := steps.NewFilesAPIComponent(mongoURI, authorizationFeature.FakeAuthService.Server.URL)
+	component, err := steps.NewFilesAPIComponent(*backendFlag, mongoURI, authorizationFeature.FakeAuthService.Server.URL)
 	if err != nil {
 		panic(err)
 	}
@@ -70,9 +76,15 @@ func (f *ComponentTest) InitializeTestSuite(ctx *godog.TestSuiteContext) {
 			buf := bytes.NewBufferString("")
 			log.SetDestination(buf, buf)
 		}
-		f.MongoFeature = componenttest.NewMongoFeature(componenttest.MongoOptions{MongoVersion: mongoVersion, DatabaseName: databaseName, ReplicaSetName: replicaSetName})
+		// the in-memory storage backend needs no mongo container
+		if *backendFlag != config.StorageBackendMemory {
+			f.MongoFeature = componenttest.NewMongoFeature(componenttest.MongoOptions{MongoVersion: mongoVersion, DatabaseName: databaseName, ReplicaSetName: replicaSetName})
+		}
 	})
 	ctx.AfterSuite(func() {
+		if f.MongoFeature == nil {
+			return
+		}
 		if err := f.MongoFeature.Close(); err != nil {
 			log.Error(context.Background(), "failed to close mongo feature", err)
 		}
@@ -33,6 +33,9 @@ var _ mongo.Client = &ClientMock{}
 //			ConnectionFunc: func() *mongodriver.MongoConnection {
 //				panic("mock out the Connection method")
 //			},
+//			CreateIndexesFunc: func(ctx context.Context, wellKnownName string, indexes []mongo.Index) error {
+//				panic("mock out the CreateIndexes method")
+//			},
 //			URIFunc: func() string {
 //				panic("mock out the URI method")
 //			},
@@ -55,6 +58,9 @@ type ClientMock struct {
 	// ConnectionFunc mocks the Connection method.
 	ConnectionFunc func() *mongodriver.MongoConnection
 
+	// CreateIndexesFunc mocks the CreateIndexes method.
+	CreateIndexesFunc func(ctx context.Context, wellKnownName string, indexes []mongo.Index) error
+
 	// URIFunc mocks the URI method.
 	URIFunc func() string
 
@@ -80,15 +86,25 @@ type ClientMock struct {
 		// Connection holds details about calls to the Connection method.
 		Connection []struct {
 		}
+		// CreateIndexes holds details about calls to the CreateIndexes method.
+		CreateIndexes []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// WellKnownName is the wellKnownName argument value.
+			WellKnownName string
+			// Indexes is the indexes argument value.
+			Indexes []mongo.Index
+		}
 		// URI holds details about calls to the URI method.
 		URI []struct {
 		}
 	}
-	lockChecker    sync.RWMutex
-	lockClose      sync.RWMutex
-	lockCollection sync.RWMutex
-	lockConnection sync.RWMutex
-	lockURI        sync.RWMutex
+	lockChecker       sync.RWMutex
+	lockClose         sync.RWMutex
+	lockCollection    sync.RWMutex
+	lockConnection    sync.RWMutex
+	lockCreateIndexes sync.RWMutex
+	lockURI           sync.RWMutex
 }
 
 // Checker calls CheckerFunc.
@@ -218,6 +234,46 @@ func (mock *ClientMock) ConnectionCalls() []struct {
 	return calls
 }
 
+// CreateIndexes calls CreateIndexesFunc.
+func (mock *ClientMock) CreateIndexes(ctx context.Context, wellKnownName string, indexes []mongo.Index) error {
+	if mock.CreateIndexesFunc == nil {
+		panic("ClientMock.CreateIndexesFunc: method is nil but Client.CreateIndexes was just called")
+	}
+	callInfo := struct {
+		Ctx           context.Context
+		WellKnownName string
+		Indexes       []mongo.Index
+	}{
+		Ctx:           ctx,
+		WellKnownName: wellKnownName,
+		Indexes:       indexes,
+	}
+	mock.lockCreateIndexes.Lock()
+	mock.calls.CreateIndexes = append(mock.calls.CreateIndexes, callInfo)
+	mock.lockCreateIndexes.Unlock()
+	return mock.CreateIndexesFunc(ctx, wellKnownName, indexes)
+}
+
+// CreateIndexesCalls gets all the calls that were made to CreateIndexes.
+// Check the length with:
+//
+//	len(mockedClient.CreateIndexesCalls())
+func (mock *ClientMock) CreateIndexesCalls() []struct {
+	Ctx           context.Context
+	WellKnownName string
+	Indexes       []mongo.Index
+} {
+	var calls []struct {
+		Ctx           context.Context
+		WellKnownName string
+		Indexes       []mongo.Index
+	}
+	mock.lockCreateIndexes.RLock()
+	calls = mock.calls.CreateIndexes
+	mock.lockCreateIndexes.RUnlock()
+	return calls
+}
+
 // URI calls URIFunc.
 func (mock *ClientMock) URI() string {
 	if mock.URIFunc == nil {
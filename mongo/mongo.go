@@ -7,6 +7,7 @@ import (
 	"github.com/ONSdigital/dp-healthcheck/healthcheck"
 	mongohealth "github.com/ONSdigital/dp-mongodb/v3/health"
 	mongodriver "github.com/ONSdigital/dp-mongodb/v3/mongodb"
+	"go.mongodb.org/mongo-driver/bson"
 )
 
 //go:generate moq -out mock/Client.go -pkg mock . Client
@@ -16,6 +17,18 @@ type Client interface {
 	Checker(context.Context, *healthcheck.CheckState) error
 	Connection() *mongodriver.MongoConnection
 	Collection(string) *mongodriver.Collection
+	CreateIndexes(ctx context.Context, wellKnownName string, indexes []Index) error
+}
+
+// Index is an index on a collection. Keys are in the order the index is built on them. An index with a
+// PartialFilter only holds the documents matching it. An index with ExpireAfterSeconds is a TTL index, which has
+// MongoDB delete each document that many seconds after the time in its single key.
+type Index struct {
+	Name               string
+	Keys               bson.D
+	Unique             bool
+	PartialFilter      bson.M
+	ExpireAfterSeconds *int32
 }
 
 // Mongo represents a simplistic MongoDB configuration.
@@ -65,3 +78,27 @@ func (m *Mongo) Connection() *mongodriver.MongoConnection {
 func (m *Mongo) Collection(wellKnownName string) *mongodriver.Collection {
 	return m.conn.Collection(m.ActualCollectionName(wellKnownName))
 }
+
+// CreateIndexes creates the indexes on the collection with the well known name. Indexes already created with the same
+// name and keys are left as they are.
+func (m *Mongo) CreateIndexes(ctx context.Context, wellKnownName string, indexes []Index) error {
+	specs := bson.A{}
+	for _, index := range indexes {
+		spec := bson.D{{Key: "key", Value: index.Keys}, {Key: "name", Value: index.Name}}
+		if index.Unique {
+			spec = append(spec, bson.E{Key: "unique", Value: true})
+		}
+		if index.PartialFilter != nil {
+			spec = append(spec, bson.E{Key: "partialFilterExpression", Value: index.PartialFilter})
+		}
+		if index.ExpireAfterSeconds != nil {
+			spec = append(spec, bson.E{Key: "expireAfterSeconds", Value: *index.ExpireAfterSeconds})
+		}
+		specs = append(specs, spec)
+	}
+
+	return m.conn.RunCommand(ctx, bson.D{
+		{Key: "createIndexes", Value: m.ActualCollectionName(wellKnownName)},
+		{Key: "indexes", Value: specs},
+	})
+}
@@ -6,10 +6,67 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
 	"github.com/ONSdigital/dp-files-api/files"
 )
 
+// FileEventsQuery selects a page of file events. Empty fields are not filtered on, and a Limit of zero leaves the
+// page size to the API. Events are returned newest first unless OldestFirst is set.
+type FileEventsQuery struct {
+	Path         string
+	PathPrefix   string
+	Action       string
+	RequestedBy  string
+	CollectionID string
+	BundleID     string
+	DatasetID    string
+	Edition      string
+	After        *time.Time
+	Before       *time.Time
+	OldestFirst  bool
+	Limit        int
+	Offset       int
+}
+
+// values gives the query as the query string parameters of GET /file-events
+func (q FileEventsQuery) values() url.Values {
+	values := url.Values{}
+	params := map[string]string{
+		"path":          q.Path,
+		"path_prefix":   q.PathPrefix,
+		"action":        q.Action,
+		"requested_by":  q.RequestedBy,
+		"collection_id": q.CollectionID,
+		"bundle_id":     q.BundleID,
+		"dataset_id":    q.DatasetID,
+		"edition":       q.Edition,
+	}
+	for name, value := range params {
+		if value != "" {
+			values.Set(name, value)
+		}
+	}
+	if q.After != nil {
+		values.Set("after", q.After.UTC().Format(time.RFC3339))
+	}
+	if q.Before != nil {
+		values.Set("before", q.Before.UTC().Format(time.RFC3339))
+	}
+	if q.OldestFirst {
+		values.Set("sort", "asc")
+	}
+	if q.Limit > 0 {
+		values.Set("limit", strconv.Itoa(q.Limit))
+	}
+	if q.Offset > 0 {
+		values.Set("offset", strconv.Itoa(q.Offset))
+	}
+	return values
+}
+
 // CreateFileEvent creates a new file event in the audit log and returns the created event
 func (c *Client) CreateFileEvent(ctx context.Context, event files.FileEvent, headers Headers) (*files.FileEvent, error) {
 	payload, err := json.Marshal(event)
@@ -49,3 +106,42 @@ func (c *Client) CreateFileEvent(ctx context.Context, event files.FileEvent, hea
 	}
 	return &createdEvent, nil
 }
+
+// GetFileEvents retrieves a page of the file events in the audit log matching the query
+func (c *Client) GetFileEvents(ctx context.Context, query FileEventsQuery, headers Headers) (*files.EventsList, error) {
+	reqURL := fmt.Sprintf("%s/file-events", c.hcCli.URL)
+	if values := query.values(); len(values) > 0 {
+		reqURL += "?" + values.Encode()
+	}
+
+	req, err := http.NewRequest(http.MethodGet, reqURL, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	headers.Add(req)
+
+	resp, err := c.hcCli.Client.Do(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer closeResponseBody(ctx, resp)
+
+	statusCode := resp.StatusCode
+	if statusCode != http.StatusOK {
+		jsonErrors, err := unmarshalJSONErrors(ctx, resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		return nil, &APIError{
+			StatusCode: statusCode,
+			Errors:     jsonErrors,
+		}
+	}
+
+	var eventsList files.EventsList
+	if err := json.NewDecoder(resp.Body).Decode(&eventsList); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &eventsList, nil
+}
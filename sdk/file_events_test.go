@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/ONSdigital/dp-files-api/api"
 	"github.com/ONSdigital/dp-files-api/files"
@@ -107,3 +108,110 @@ func TestCreateFileEvent_Failure(t *testing.T) {
 		})
 	})
 }
+
+func TestGetFileEvents_Success(t *testing.T) {
+	t.Parallel()
+
+	Convey("Given a files-api client", t, func() {
+		expectedEventsList := files.EventsList{Count: 1, Limit: 10, Offset: 5, TotalCount: 6, Items: []files.FileEvent{exampleFileEvent}}
+		responseBody, err := json.Marshal(expectedEventsList)
+		So(err, ShouldBeNil)
+
+		mockClienter := newMockClienter(&http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(string(responseBody)))}, nil)
+		client := newMockFilesAPIClient(mockClienter)
+
+		Convey("When GetFileEvents is called with a query", func() {
+			after := time.Date(2026, 1, 2, 9, 0, 0, 0, time.UTC)
+			query := FileEventsQuery{
+				PathPrefix:   "data/",
+				Action:       files.ActionRead,
+				RequestedBy:  "user123",
+				CollectionID: "collection-1",
+				DatasetID:    "cpih",
+				After:        &after,
+				OldestFirst:  true,
+				Limit:        10,
+				Offset:       5,
+			}
+			eventsList, err := client.GetFileEvents(context.Background(), query, testHeaders)
+
+			Convey("Then no error is returned", func() {
+				So(err, ShouldBeNil)
+			})
+
+			Convey("And the expected events are returned", func() {
+				So(eventsList, ShouldResemble, &expectedEventsList)
+			})
+
+			Convey("And the query is sent as query string parameters", func() {
+				So(mockClienter.DoCalls(), ShouldHaveLength, 1)
+				actualCall := mockClienter.DoCalls()[0]
+				So(actualCall.Req.Method, ShouldEqual, http.MethodGet)
+				So(actualCall.Req.URL.String(), ShouldEqual, filesAPIURL+"/file-events?action=READ&after=2026-01-02T09%3A00%3A00Z&collection_id=collection-1&dataset_id=cpih&limit=10&offset=5&path_prefix=data%2F&requested_by=user123&sort=asc")
+				So(actualCall.Req.Header.Get("Authorization"), ShouldEqual, "Bearer "+testAuthToken)
+			})
+		})
+
+		Convey("When GetFileEvents is called with an empty query", func() {
+			_, err := client.GetFileEvents(context.Background(), FileEventsQuery{}, testHeaders)
+
+			Convey("Then no query string is sent", func() {
+				So(err, ShouldBeNil)
+				So(mockClienter.DoCalls()[0].Req.URL.String(), ShouldEqual, filesAPIURL+"/file-events")
+			})
+		})
+	})
+}
+
+func TestGetFileEvents_Failure(t *testing.T) {
+	t.Parallel()
+
+	Convey("Given a files-api client that returns an error on Do()", t, func() {
+		mockClienter := newMockClienter(nil, errExpectedDoFailure)
+		client := newMockFilesAPIClient(mockClienter)
+
+		Convey("When GetFileEvents is called", func() {
+			eventsList, err := client.GetFileEvents(context.Background(), FileEventsQuery{}, testHeaders)
+
+			Convey("Then the expected error is returned", func() {
+				So(err, ShouldResemble, fmt.Errorf("failed to execute request: %w", errExpectedDoFailure))
+			})
+
+			Convey("And no events are returned", func() {
+				So(eventsList, ShouldBeNil)
+			})
+		})
+	})
+
+	Convey("Given a files-api client that returns an unexpected status code", t, func() {
+		body := `{"errors":[{"errorCode":"InvalidRequest","description":"invalid query parameter"}]}`
+		mockClienter := newMockClienter(&http.Response{
+			StatusCode: http.StatusBadRequest,
+			Body:       io.NopCloser(strings.NewReader(body)),
+		}, nil)
+		client := newMockFilesAPIClient(mockClienter)
+
+		Convey("When GetFileEvents is called", func() {
+			eventsList, err := client.GetFileEvents(context.Background(), FileEventsQuery{Action: "PUBLISH"}, testHeaders)
+
+			Convey("Then the expected API error is returned", func() {
+				expectedError := &APIError{
+					StatusCode: http.StatusBadRequest,
+					Errors: &api.JSONErrors{
+						Error: []api.JSONError{
+							{
+								Code:        "InvalidRequest",
+								Description: "invalid query parameter",
+							},
+						},
+					},
+				}
+				So(err, ShouldResemble, expectedError)
+			})
+
+			Convey("And no events are returned", func() {
+				So(eventsList, ShouldBeNil)
+			})
+		})
+	})
+}
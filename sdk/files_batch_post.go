@@ -0,0 +1,55 @@
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/ONSdigital/dp-files-api/files"
+)
+
+// RegisterFiles makes a POST request to register a batch of new file metadata, returning the result for each file
+func (c *Client) RegisterFiles(ctx context.Context, metadata []files.StoredRegisteredMetaData, headers Headers) (*files.RegistrationResults, error) {
+	payload, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.hcCli.URL+"/files/batch", bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	headers.Add(req)
+
+	resp, err := c.hcCli.Client.Do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer closeResponseBody(ctx, resp)
+
+	statusCode := resp.StatusCode
+	if statusCode != http.StatusOK {
+		jsonErrors, err := unmarshalJSONErrors(ctx, resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		return nil, &APIError{
+			StatusCode: statusCode,
+			Errors:     jsonErrors,
+		}
+	}
+
+	if resp.Body == nil {
+		return nil, ErrMissingResponseBody
+	}
+
+	var results files.RegistrationResults
+	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+		return nil, err
+	}
+
+	return &results, nil
+}
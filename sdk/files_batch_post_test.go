@@ -0,0 +1,111 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dp-files-api/api"
+	"github.com/ONSdigital/dp-files-api/files"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRegisterFiles_Success(t *testing.T) {
+	t.Parallel()
+
+	Convey("Given a files-api client", t, func() {
+		body := `{"count":2,"created":1,"items":[{"path":"path/to/file.txt","result":"CREATED"},{"path":"path/to/other.txt","result":"DUPLICATE"}]}`
+		mockClienter := newMockClienter(&http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(body)),
+		}, nil)
+		client := newMockFilesAPIClient(mockClienter)
+
+		Convey("When RegisterFiles is called", func() {
+			metadata := []files.StoredRegisteredMetaData{
+				{Path: "path/to/file.txt", IsPublishable: true, SizeInBytes: 12345, Licence: "OGL v3", LicenceURL: "http://example.com/licence"},
+				{Path: "path/to/other.txt", IsPublishable: true, SizeInBytes: 54321, Licence: "OGL v3", LicenceURL: "http://example.com/licence"},
+			}
+
+			results, err := client.RegisterFiles(context.Background(), metadata, testHeaders)
+
+			Convey("Then the result for each file is returned", func() {
+				So(err, ShouldBeNil)
+				So(results, ShouldResemble, &files.RegistrationResults{
+					Count:   2,
+					Created: 1,
+					Items: []files.RegistrationResult{
+						{Path: "path/to/file.txt", Result: files.RegistrationCreated},
+						{Path: "path/to/other.txt", Result: files.RegistrationDuplicate},
+					},
+				})
+			})
+
+			Convey("And the mock clienter's Do method is called once with the correct request details", func() {
+				So(mockClienter.DoCalls(), ShouldHaveLength, 1)
+				actualCall := mockClienter.DoCalls()[0]
+				So(actualCall.Req.Method, ShouldEqual, http.MethodPost)
+				So(actualCall.Req.URL.String(), ShouldEqual, filesAPIURL+"/files/batch")
+				So(actualCall.Req.Header.Get("Authorization"), ShouldEqual, "Bearer "+testAuthToken)
+				So(actualCall.Req.Header.Get("Content-Type"), ShouldEqual, "application/json")
+
+				bodyBytes, err := io.ReadAll(actualCall.Req.Body)
+				So(err, ShouldBeNil)
+
+				var actualMetadata []files.StoredRegisteredMetaData
+				err = json.Unmarshal(bodyBytes, &actualMetadata)
+				So(err, ShouldBeNil)
+				So(actualMetadata, ShouldResemble, metadata)
+			})
+		})
+	})
+}
+
+func TestRegisterFiles_Failure(t *testing.T) {
+	t.Parallel()
+
+	Convey("Given a files-api client that fails when Do() is called", t, func() {
+		mockClienter := newMockClienter(nil, errExpectedDoFailure)
+		client := newMockFilesAPIClient(mockClienter)
+
+		Convey("When RegisterFiles is called", func() {
+			results, err := client.RegisterFiles(context.Background(), []files.StoredRegisteredMetaData{{Path: "path/to/file.txt"}}, testHeaders)
+
+			Convey("Then the expected error is returned", func() {
+				So(err, ShouldResemble, errExpectedDoFailure)
+				So(results, ShouldBeNil)
+			})
+		})
+	})
+
+	Convey("Given a files-api client that returns an unexpected status code", t, func() {
+		body := `{"errors":[{"errorCode":"InvalidBatchSize","description":"a batch must contain between 1 and 1000 files"}]}`
+		mockClienter := newMockClienter(&http.Response{
+			StatusCode: http.StatusBadRequest,
+			Body:       io.NopCloser(strings.NewReader(body)),
+		}, nil)
+		client := newMockFilesAPIClient(mockClienter)
+
+		Convey("When RegisterFiles is called", func() {
+			results, err := client.RegisterFiles(context.Background(), []files.StoredRegisteredMetaData{}, testHeaders)
+
+			Convey("Then an APIError is returned with the expected status code and errors", func() {
+				So(results, ShouldBeNil)
+				So(err, ShouldResemble, &APIError{
+					StatusCode: http.StatusBadRequest,
+					Errors: &api.JSONErrors{
+						Error: []api.JSONError{
+							{
+								Code:        "InvalidBatchSize",
+								Description: "a batch must contain between 1 and 1000 files",
+							},
+						},
+					},
+				})
+			})
+		})
+	})
+}
@@ -19,8 +19,10 @@ type Clienter interface {
 	CreateFileEvent(ctx context.Context, event files.FileEvent, headers Headers) (*files.FileEvent, error)
 	DeleteFile(ctx context.Context, filePath string, headers Headers) error
 	GetFile(ctx context.Context, filePath string, headers Headers) (*files.StoredRegisteredMetaData, error)
+	GetFileEvents(ctx context.Context, query FileEventsQuery, headers Headers) (*files.EventsList, error)
 	MarkFilePublished(ctx context.Context, filePath string, headers Headers) error
 	RegisterFile(ctx context.Context, metadata files.StoredRegisteredMetaData, headers Headers) error
+	RegisterFiles(ctx context.Context, metadata []files.StoredRegisteredMetaData, headers Headers) (*files.RegistrationResults, error)
 	MarkFileUploaded(ctx context.Context, filePath string, etag string, headers Headers) error
 	UpdateContentItem(ctx context.Context, filePath string, item api.ContentItem, headers Headers) (files.StoredRegisteredMetaData, error)
 }
@@ -35,6 +35,9 @@ var _ sdk.Clienter = &ClienterMock{}
 //			GetFileFunc: func(ctx context.Context, filePath string, headers sdk.Headers) (*files.StoredRegisteredMetaData, error) {
 //				panic("mock out the GetFile method")
 //			},
+//			GetFileEventsFunc: func(ctx context.Context, query sdk.FileEventsQuery, headers sdk.Headers) (*files.EventsList, error) {
+//				panic("mock out the GetFileEvents method")
+//			},
 //			HealthFunc: func() *health.Client {
 //				panic("mock out the Health method")
 //			},
@@ -47,6 +50,9 @@ var _ sdk.Clienter = &ClienterMock{}
 //			RegisterFileFunc: func(ctx context.Context, metadata files.StoredRegisteredMetaData, headers sdk.Headers) error {
 //				panic("mock out the RegisterFile method")
 //			},
+//			RegisterFilesFunc: func(ctx context.Context, metadata []files.StoredRegisteredMetaData, headers sdk.Headers) (*files.RegistrationResults, error) {
+//				panic("mock out the RegisterFiles method")
+//			},
 //			URLFunc: func() string {
 //				panic("mock out the URL method")
 //			},
@@ -72,6 +78,9 @@ type ClienterMock struct {
 	// GetFileFunc mocks the GetFile method.
 	GetFileFunc func(ctx context.Context, filePath string, headers sdk.Headers) (*files.StoredRegisteredMetaData, error)
 
+	// GetFileEventsFunc mocks the GetFileEvents method.
+	GetFileEventsFunc func(ctx context.Context, query sdk.FileEventsQuery, headers sdk.Headers) (*files.EventsList, error)
+
 	// HealthFunc mocks the Health method.
 	HealthFunc func() *health.Client
 
@@ -84,6 +93,9 @@ type ClienterMock struct {
 	// RegisterFileFunc mocks the RegisterFile method.
 	RegisterFileFunc func(ctx context.Context, metadata files.StoredRegisteredMetaData, headers sdk.Headers) error
 
+	// RegisterFilesFunc mocks the RegisterFiles method.
+	RegisterFilesFunc func(ctx context.Context, metadata []files.StoredRegisteredMetaData, headers sdk.Headers) (*files.RegistrationResults, error)
+
 	// URLFunc mocks the URL method.
 	URLFunc func() string
 
@@ -126,6 +138,15 @@ type ClienterMock struct {
 			// Headers is the headers argument value.
 			Headers sdk.Headers
 		}
+		// GetFileEvents holds details about calls to the GetFileEvents method.
+		GetFileEvents []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Query is the query argument value.
+			Query sdk.FileEventsQuery
+			// Headers is the headers argument value.
+			Headers sdk.Headers
+		}
 		// Health holds details about calls to the Health method.
 		Health []struct {
 		}
@@ -158,6 +179,15 @@ type ClienterMock struct {
 			// Headers is the headers argument value.
 			Headers sdk.Headers
 		}
+		// RegisterFiles holds details about calls to the RegisterFiles method.
+		RegisterFiles []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Metadata is the metadata argument value.
+			Metadata []files.StoredRegisteredMetaData
+			// Headers is the headers argument value.
+			Headers sdk.Headers
+		}
 		// URL holds details about calls to the URL method.
 		URL []struct {
 		}
@@ -177,10 +207,12 @@ type ClienterMock struct {
 	lockCreateFileEvent   sync.RWMutex
 	lockDeleteFile        sync.RWMutex
 	lockGetFile           sync.RWMutex
+	lockGetFileEvents     sync.RWMutex
 	lockHealth            sync.RWMutex
 	lockMarkFilePublished sync.RWMutex
 	lockMarkFileUploaded  sync.RWMutex
 	lockRegisterFile      sync.RWMutex
+	lockRegisterFiles     sync.RWMutex
 	lockURL               sync.RWMutex
 	lockUpdateContentItem sync.RWMutex
 }
@@ -341,6 +373,46 @@ func (mock *ClienterMock) GetFileCalls() []struct {
 	return calls
 }
 
+// GetFileEvents calls GetFileEventsFunc.
+func (mock *ClienterMock) GetFileEvents(ctx context.Context, query sdk.FileEventsQuery, headers sdk.Headers) (*files.EventsList, error) {
+	if mock.GetFileEventsFunc == nil {
+		panic("ClienterMock.GetFileEventsFunc: method is nil but Clienter.GetFileEvents was just called")
+	}
+	callInfo := struct {
+		Ctx     context.Context
+		Query   sdk.FileEventsQuery
+		Headers sdk.Headers
+	}{
+		Ctx:     ctx,
+		Query:   query,
+		Headers: headers,
+	}
+	mock.lockGetFileEvents.Lock()
+	mock.calls.GetFileEvents = append(mock.calls.GetFileEvents, callInfo)
+	mock.lockGetFileEvents.Unlock()
+	return mock.GetFileEventsFunc(ctx, query, headers)
+}
+
+// GetFileEventsCalls gets all the calls that were made to GetFileEvents.
+// Check the length with:
+//
+//	len(mockedClienter.GetFileEventsCalls())
+func (mock *ClienterMock) GetFileEventsCalls() []struct {
+	Ctx     context.Context
+	Query   sdk.FileEventsQuery
+	Headers sdk.Headers
+} {
+	var calls []struct {
+		Ctx     context.Context
+		Query   sdk.FileEventsQuery
+		Headers sdk.Headers
+	}
+	mock.lockGetFileEvents.RLock()
+	calls = mock.calls.GetFileEvents
+	mock.lockGetFileEvents.RUnlock()
+	return calls
+}
+
 // Health calls HealthFunc.
 func (mock *ClienterMock) Health() *health.Client {
 	if mock.HealthFunc == nil {
@@ -492,6 +564,46 @@ func (mock *ClienterMock) RegisterFileCalls() []struct {
 	return calls
 }
 
+// RegisterFiles calls RegisterFilesFunc.
+func (mock *ClienterMock) RegisterFiles(ctx context.Context, metadata []files.StoredRegisteredMetaData, headers sdk.Headers) (*files.RegistrationResults, error) {
+	if mock.RegisterFilesFunc == nil {
+		panic("ClienterMock.RegisterFilesFunc: method is nil but Clienter.RegisterFiles was just called")
+	}
+	callInfo := struct {
+		Ctx      context.Context
+		Metadata []files.StoredRegisteredMetaData
+		Headers  sdk.Headers
+	}{
+		Ctx:      ctx,
+		Metadata: metadata,
+		Headers:  headers,
+	}
+	mock.lockRegisterFiles.Lock()
+	mock.calls.RegisterFiles = append(mock.calls.RegisterFiles, callInfo)
+	mock.lockRegisterFiles.Unlock()
+	return mock.RegisterFilesFunc(ctx, metadata, headers)
+}
+
+// RegisterFilesCalls gets all the calls that were made to RegisterFiles.
+// Check the length with:
+//
+//	len(mockedClienter.RegisterFilesCalls())
+func (mock *ClienterMock) RegisterFilesCalls() []struct {
+	Ctx      context.Context
+	Metadata []files.StoredRegisteredMetaData
+	Headers  sdk.Headers
+} {
+	var calls []struct {
+		Ctx      context.Context
+		Metadata []files.StoredRegisteredMetaData
+		Headers  sdk.Headers
+	}
+	mock.lockRegisterFiles.RLock()
+	calls = mock.calls.RegisterFiles
+	mock.lockRegisterFiles.RUnlock()
+	return calls
+}
+
 // URL calls URLFunc.
 func (mock *ClienterMock) URL() string {
 	if mock.URLFunc == nil {
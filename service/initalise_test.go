@@ -6,9 +6,11 @@ import (
 	"testing"
 
 	authMock "github.com/ONSdigital/dp-authorisation/v2/authorisation/mock"
+	"github.com/ONSdigital/dp-files-api/config"
 	"github.com/ONSdigital/dp-files-api/files/mock"
 	hcMock "github.com/ONSdigital/dp-files-api/health/mock"
 	mongoMock "github.com/ONSdigital/dp-files-api/mongo/mock"
+	"github.com/ONSdigital/dp-files-api/store"
 	. "github.com/smartystreets/goconvey/convey"
 	"github.com/stretchr/testify/assert"
 )
@@ -47,3 +49,39 @@ func TestServicesShutdownCalled(t *testing.T) {
 		})
 	})
 }
+
+func TestCreateRepository(t *testing.T) {
+	Convey("Creating the storage repository", t, func() {
+		Convey("The memory backend needs no mongo client", func() {
+			serviceList := &ExternalServiceList{cfg: &config.Config{StorageBackend: config.StorageBackendMemory}}
+
+			assert.NoError(t, serviceList.createRepository(context.Background()))
+			assert.IsType(t, &store.MemoryRepository{}, serviceList.GetRepository())
+			assert.Nil(t, serviceList.GetMongoDB())
+		})
+
+		Convey("An unknown backend is rejected", func() {
+			serviceList := &ExternalServiceList{cfg: &config.Config{StorageBackend: "postgres"}}
+
+			assert.Error(t, serviceList.createRepository(context.Background()))
+			assert.Nil(t, serviceList.GetRepository())
+		})
+	})
+}
+
+func TestServicesShutdownWithoutMongo(t *testing.T) {
+	Convey("Shutting down a service container running on the memory backend", t, func() {
+		hc := &hcMock.CheckerMock{StopFunc: func() {}}
+		hs := &mock.HTTPServerMock{ShutdownFunc: func(ctx context.Context) error { return nil }}
+
+		serviceList := &ExternalServiceList{
+			repository:    store.NewMemoryRepository(),
+			httpServer:    hs,
+			healthChecker: hc,
+		}
+
+		assert.NoError(t, serviceList.Shutdown(context.Background()))
+		assert.Len(t, hc.StopCalls(), 1)
+		assert.Len(t, hs.ShutdownCalls(), 1)
+	})
+}
@@ -3,6 +3,8 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"os"
 
 	auth "github.com/ONSdigital/dp-authorisation/v2/authorisation"
 	"github.com/ONSdigital/dp-files-api/aws"
@@ -21,6 +23,7 @@ import (
 	"github.com/ONSdigital/dp-files-api/files"
 	"github.com/ONSdigital/dp-files-api/health"
 	"github.com/ONSdigital/dp-files-api/mongo"
+	"github.com/ONSdigital/dp-files-api/store"
 	kafka "github.com/ONSdigital/dp-kafka/v3"
 )
 
@@ -31,10 +34,12 @@ type ExternalServiceList struct {
 	gitCommit      string
 	version        string
 	mongo          mongo.Client
+	repository     store.Repository
 	httpServer     files.HTTPServer
 	healthChecker  health.Checker
 	authMiddleware auth.Middleware
-	kafkaProducer  kafka.IProducer
+	kafkaProducers map[string]kafka.IProducer
+	kafkaConsumers map[string]kafka.IConsumerGroup
 	s3Client       aws.S3Clienter
 	router         *mux.Router
 }
@@ -64,12 +69,16 @@ func (e *ExternalServiceList) setup(ctx context.Context) error {
 		return err
 	}
 
-	if err := e.createMongo(); err != nil {
+	if err := e.createRepository(ctx); err != nil {
 		return err
 	}
 
 	e.createHTTPServer()
-	if err := e.createKafkaProducer(); err != nil {
+	if err := e.createKafkaProducers(); err != nil {
+		return err
+	}
+
+	if err := e.createKafkaConsumers(ctx); err != nil {
 		return err
 	}
 
@@ -91,10 +100,11 @@ func (e *ExternalServiceList) createS3(ctx context.Context) error {
 			return err
 		}
 
-		e.s3Client = dps3.NewClientWithConfig(e.cfg.PrivateBucketName, awsCfg, func(o *s3.Options) {
+		localstack := func(o *s3.Options) {
 			o.BaseEndpoint = awssdk.String(e.cfg.LocalstackHost)
 			o.UsePathStyle = true
-		})
+		}
+		e.s3Client = aws.NewClient(dps3.NewClientWithConfig(e.cfg.PrivateBucketName, awsCfg, localstack), localstack)
 		return nil
 	}
 
@@ -102,7 +112,7 @@ func (e *ExternalServiceList) createS3(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
-	e.s3Client = s3Client
+	e.s3Client = aws.NewClient(s3Client)
 	return nil
 }
 
@@ -111,10 +121,23 @@ func (e *ExternalServiceList) createAuthMiddleware() (err error) {
 	return
 }
 
-func (e *ExternalServiceList) createKafkaProducer() error {
+// createKafkaProducers creates a producer for each topic that events are sent to
+func (e *ExternalServiceList) createKafkaProducers() error {
+	e.kafkaProducers = map[string]kafka.IProducer{}
+	for _, topic := range e.cfg.ProducerTopics() {
+		p, err := e.createKafkaProducer(topic)
+		if err != nil {
+			return err
+		}
+		e.kafkaProducers[topic] = p
+	}
+	return nil
+}
+
+func (e *ExternalServiceList) createKafkaProducer(topic string) (kafka.IProducer, error) {
 	pConfig := &kafka.ProducerConfig{
 		BrokerAddrs:       e.cfg.Addr,
-		Topic:             e.cfg.StaticFilePublishedTopic,
+		Topic:             topic,
 		MinBrokersHealthy: &e.cfg.ProducerMinBrokersHealthy,
 		KafkaVersion:      &e.cfg.Version,
 		MaxMessageBytes:   &e.cfg.MaxBytes,
@@ -133,15 +156,57 @@ func (e *ExternalServiceList) createKafkaProducer() error {
 
 	p, err := kafka.NewProducer(ctx, pConfig)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if !e.cfg.IsPublishing {
 		// In Web mode we do not want to produce kafka messages
 		p.Close(ctx)
 	}
-	e.kafkaProducer = p
 
+	return p, nil
+}
+
+// createKafkaConsumers creates a consumer of each file published topic in web mode, so that cached metadata can be
+// forgotten as files are published. Every instance has its own consumer group as each has its own cache, and starts
+// from the newest message as its cache starts empty.
+func (e *ExternalServiceList) createKafkaConsumers(ctx context.Context) error {
+	e.kafkaConsumers = map[string]kafka.IConsumerGroup{}
+	if e.cfg.IsPublishing || e.cfg.WebMetadataCacheSize <= 0 {
+		return nil
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		return err
+	}
+
+	offset := kafka.OffsetNewest
+	for _, topic := range e.cfg.WebCacheTopics() {
+		cgConfig := &kafka.ConsumerGroupConfig{
+			BrokerAddrs:       e.cfg.Addr,
+			Topic:             topic,
+			GroupName:         e.cfg.WebCacheConsumerGroup + "-" + hostname,
+			KafkaVersion:      &e.cfg.Version,
+			MinBrokersHealthy: &e.cfg.ProducerMinBrokersHealthy,
+			Offset:            &offset,
+		}
+
+		if e.cfg.SecProtocol != "" {
+			cgConfig.SecurityConfig = kafka.GetSecurityConfig(
+				e.cfg.SecCACerts,
+				e.cfg.SecClientCert,
+				e.cfg.SecClientKey,
+				e.cfg.SecSkipVerify,
+			)
+		}
+
+		cg, err := kafka.NewConsumerGroup(ctx, cgConfig)
+		if err != nil {
+			return err
+		}
+		e.kafkaConsumers[topic] = cg
+	}
 	return nil
 }
 
@@ -151,6 +216,59 @@ func (e *ExternalServiceList) createHTTPServer() {
 	e.httpServer = s
 }
 
+func (e *ExternalServiceList) createRepository(ctx context.Context) error {
+	switch e.cfg.StorageBackend {
+	case config.StorageBackendMemory:
+		log.Warn(ctx, "using in-memory storage, nothing will be kept when the service stops")
+		e.repository = store.NewMemoryRepository()
+		return nil
+	case config.StorageBackendMongo:
+		if err := e.createMongo(); err != nil {
+			return err
+		}
+		e.repository = store.NewMongoRepository(store.MongoCollections{
+			Metadata:        e.mongo.Collection(config.MetadataCollection),
+			Collections:     e.mongo.Collection(config.CollectionsCollection),
+			Bundles:         e.mongo.Collection(config.BundlesCollection),
+			FileEvents:      e.mongo.Collection(config.FileEventsCollection),
+			Outbox:          e.mongo.Collection(config.OutboxCollection),
+			PublishJobs:     e.mongo.Collection(config.PublishJobsCollection),
+			FileVersions:    e.mongo.Collection(config.FileVersionsCollection),
+			FileChanges:     e.mongo.Collection(config.FileChangesCollection),
+			Locks:           e.mongo.Collection(config.LocksCollection),
+			Trash:           e.mongo.Collection(config.TrashCollection),
+			Reconciliations: e.mongo.Collection(config.ReconciliationsCollection),
+			IdempotencyKeys: e.mongo.Collection(config.IdempotencyKeysCollection),
+			FileEventChain:  e.mongo.Collection(config.FileEventChainCollection),
+		})
+		// without the unique index on the resource two instances can hold the same lock, so the service does not start
+		if err := store.CreateLockIndexes(ctx, e.mongo.Collection(config.LocksCollection)); err != nil {
+			log.Error(ctx, "failed to create lock indexes", err)
+			return err
+		}
+		// without the unique index a replaced file could be kept as a version another file is already kept as
+		if err := e.mongo.CreateIndexes(ctx, config.FileVersionsCollection, store.FileVersionIndexes); err != nil {
+			log.Error(ctx, "failed to create file version indexes", err)
+			return err
+		}
+		// the service still works without these indexes, only more slowly, so it starts even if they cannot be made
+		if err := e.mongo.CreateIndexes(ctx, config.FileEventsCollection, store.FileEventIndexes); err != nil {
+			log.Error(ctx, "failed to create file event indexes", err)
+		}
+		// without the unique index two instances can give changes the same sequence number, so a stream could miss one
+		if err := e.mongo.CreateIndexes(ctx, config.FileChangesCollection, store.FileChangeIndexes); err != nil {
+			log.Error(ctx, "failed to create file change indexes", err)
+		}
+		// expired idempotency keys are ignored, so they only build up until the TTL index is made
+		if err := e.mongo.CreateIndexes(ctx, config.IdempotencyKeysCollection, store.IdempotencyKeyIndexes); err != nil {
+			log.Error(ctx, "failed to create idempotency key indexes", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown storage backend %q", e.cfg.StorageBackend)
+	}
+}
+
 func (e *ExternalServiceList) createMongo() (err error) {
 	e.mongo, err = mongo.New(e.cfg.MongoConfig)
 	return
@@ -178,12 +296,20 @@ func (e *ExternalServiceList) GetMongoDB() mongo.Client {
 	return e.mongo
 }
 
+func (e *ExternalServiceList) GetRepository() store.Repository {
+	return e.repository
+}
+
 func (e *ExternalServiceList) GetClock() clock.Clock {
 	return clock.SystemClock{}
 }
 
-func (e *ExternalServiceList) GetKafkaProducer() kafka.IProducer {
-	return e.kafkaProducer
+func (e *ExternalServiceList) GetKafkaProducers() map[string]kafka.IProducer {
+	return e.kafkaProducers
+}
+
+func (e *ExternalServiceList) GetKafkaConsumers() map[string]kafka.IConsumerGroup {
+	return e.kafkaConsumers
 }
 
 func (e *ExternalServiceList) GetAuthMiddleware() auth.Middleware {
@@ -198,9 +324,18 @@ func (e *ExternalServiceList) Shutdown(ctx context.Context) error {
 	shutdownErr := false
 	e.healthChecker.Stop()
 
-	if err := e.mongo.Close(ctx); err != nil {
-		shutdownErr = true
-		log.Error(ctx, "failed to shutdown mongo", err)
+	for topic, consumer := range e.kafkaConsumers {
+		if err := consumer.Close(ctx); err != nil {
+			shutdownErr = true
+			log.Error(ctx, "failed to shutdown kafka consumer", err, log.Data{"topic": topic})
+		}
+	}
+
+	if e.mongo != nil {
+		if err := e.mongo.Close(ctx); err != nil {
+			shutdownErr = true
+			log.Error(ctx, "failed to shutdown mongo", err)
+		}
 	}
 
 	if err := e.httpServer.Shutdown(ctx); err != nil {
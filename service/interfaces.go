@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"time"
 
 	auth "github.com/ONSdigital/dp-authorisation/v2/authorisation"
 
@@ -10,11 +11,15 @@ import (
 	"github.com/ONSdigital/dp-files-api/files"
 	"github.com/ONSdigital/dp-files-api/health"
 	"github.com/ONSdigital/dp-files-api/mongo"
+	"github.com/ONSdigital/dp-files-api/store"
 	kafka "github.com/ONSdigital/dp-kafka/v3"
 )
 
 //go:generate moq -out mock/serviceContainer.go -pkg mock . ServiceContainer
 //go:generate moq -out mock/kafkaProducer.go -pkg mock . OurProducer
+//go:generate moq -out mock/outboxStore.go -pkg mock . OutboxStore
+//go:generate moq -out mock/jobLocker.go -pkg mock . JobLocker
+//go:generate moq -out mock/webMetadataCache.go -pkg mock . WebMetadataCache
 
 type OurProducer interface {
 	kafka.IProducer
@@ -24,9 +29,31 @@ type ServiceContainer interface {
 	GetHTTPServer() files.HTTPServer
 	GetHealthCheck() health.Checker
 	GetMongoDB() mongo.Client
+	GetRepository() store.Repository
 	GetClock() clock.Clock
-	GetKafkaProducer() kafka.IProducer
+	GetKafkaProducers() map[string]kafka.IProducer
+	GetKafkaConsumers() map[string]kafka.IConsumerGroup
 	GetAuthMiddleware() auth.Middleware
 	GetS3Clienter() aws.S3Clienter
 	Shutdown(ctx context.Context) error
 }
+
+type OutboxStore interface {
+	LockOutboxRelay(ctx context.Context, lockID string, ttl time.Duration) error
+	UnlockOutboxRelay(ctx context.Context, lockID string) error
+	SettleStalePendingOutboxMessages(ctx context.Context, limit int) error
+	GetPendingOutboxMessages(ctx context.Context, limit int) ([]files.OutboxMessage, error)
+	DeleteOutboxMessage(ctx context.Context, id string) error
+	MarkOutboxMessageSent(ctx context.Context, id string, confirmBy time.Time) error
+	MarkOutboxMessageFailed(ctx context.Context, id string, nextAttemptAt time.Time, reason string) error
+}
+
+type JobLocker interface {
+	LockResource(ctx context.Context, resource, lockID string, ttl time.Duration) error
+	UnlockResource(ctx context.Context, lockID string) error
+}
+
+type WebMetadataCache interface {
+	Invalidate(path, collectionID, bundleID string)
+	Stats() store.WebMetadataCacheStats
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/dp-healthcheck/healthcheck"
+	kafka "github.com/ONSdigital/dp-kafka/v3"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// MetadataCacheInvalidator forgets the cached web metadata of files as they change, as told by the file published
+// events consumed from each enabled version of the topic, and the file lifecycle and withdrawn events
+type MetadataCacheInvalidator struct {
+	cache WebMetadataCache
+}
+
+// NewMetadataCacheInvalidator creates a MetadataCacheInvalidator for the given cache
+func NewMetadataCacheInvalidator(cache WebMetadataCache) *MetadataCacheInvalidator {
+	return &MetadataCacheInvalidator{cache: cache}
+}
+
+// HandleFilePublished invalidates the file of a version 2 file published event, which only gives its path
+func (i *MetadataCacheInvalidator) HandleFilePublished(ctx context.Context, _ int, msg kafka.Message) error {
+	event := files.FilePublished{}
+	if err := files.AvroSchema.Unmarshal(msg.GetData(), &event); err != nil {
+		// the message can never be read, so there is nothing to gain from retrying it
+		log.Error(ctx, "failed to read file published event", err)
+		return nil
+	}
+
+	i.cache.Invalidate(event.Path, "", "")
+	return nil
+}
+
+// HandleFilePublishedV3 invalidates the file of a version 3 file published event, along with its collection or bundle
+func (i *MetadataCacheInvalidator) HandleFilePublishedV3(ctx context.Context, _ int, msg kafka.Message) error {
+	event := files.FilePublishedV3{}
+	if err := files.AvroSchemaV3.Unmarshal(msg.GetData(), &event); err != nil {
+		log.Error(ctx, "failed to read file published event", err)
+		return nil
+	}
+
+	i.cache.Invalidate(event.Path, event.CollectionID, event.BundleID)
+	return nil
+}
+
+// HandleFileLifecycle invalidates the file of a lifecycle event, such as a published file being moved or removed
+func (i *MetadataCacheInvalidator) HandleFileLifecycle(ctx context.Context, _ int, msg kafka.Message) error {
+	event := files.FileLifecycle{}
+	if err := files.AvroLifecycleSchema.Unmarshal(msg.GetData(), &event); err != nil {
+		log.Error(ctx, "failed to read file lifecycle event", err)
+		return nil
+	}
+
+	i.cache.Invalidate(event.Path, "", "")
+	return nil
+}
+
+// HandleFileWithdrawn invalidates the file of a withdrawn event, along with its collection or bundle, so that it is
+// served as withdrawn straight away
+func (i *MetadataCacheInvalidator) HandleFileWithdrawn(ctx context.Context, _ int, msg kafka.Message) error {
+	event := files.FileWithdrawn{}
+	if err := files.AvroWithdrawnSchema.Unmarshal(msg.GetData(), &event); err != nil {
+		log.Error(ctx, "failed to read file withdrawn event", err)
+		return nil
+	}
+
+	i.cache.Invalidate(event.Path, event.CollectionID, event.BundleID)
+	return nil
+}
+
+// Checker reports the size and hit ratio of the cache to the healthcheck library
+func (i *MetadataCacheInvalidator) Checker(_ context.Context, state *healthcheck.CheckState) error {
+	stats := i.cache.Stats()
+	return state.Update(healthcheck.StatusOK, fmt.Sprintf(
+		"web metadata cache holds %d files, %d collections and %d bundles, hit ratio %.2f (%d hits, %d misses)",
+		stats.Files, stats.Collections, stats.Bundles, stats.HitRatio(), stats.Hits, stats.Misses,
+	), 0)
+}
@@ -0,0 +1,129 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-files-api/clock"
+	"github.com/ONSdigital/dp-files-api/config"
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/dp-files-api/service"
+	"github.com/ONSdigital/dp-files-api/service/mock"
+	"github.com/ONSdigital/dp-files-api/store"
+	"github.com/ONSdigital/dp-healthcheck/healthcheck"
+	"github.com/ONSdigital/dp-kafka/v3/kafkatest"
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetadataCacheInvalidator(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Given a metadata cache invalidator", t, func() {
+		cache := &mock.WebMetadataCacheMock{
+			InvalidateFunc: func(path, collectionID, bundleID string) {},
+			StatsFunc: func() store.WebMetadataCacheStats {
+				return store.WebMetadataCacheStats{Files: 3, Collections: 1, Hits: 3, Misses: 1}
+			},
+		}
+		invalidator := service.NewMetadataCacheInvalidator(cache)
+
+		Convey("A version 2 file published event invalidates its file", func() {
+			data, err := files.AvroSchema.Marshal(&files.FilePublished{Path: "data/file.csv", Etag: "etag", Type: "text/csv", SizeInBytes: "10"})
+			assert.NoError(t, err)
+			msg, err := kafkatest.NewMessage(data, 1)
+			assert.NoError(t, err)
+
+			assert.NoError(t, invalidator.HandleFilePublished(ctx, 1, msg))
+
+			assert.Len(t, cache.InvalidateCalls(), 1)
+			assert.Equal(t, "data/file.csv", cache.InvalidateCalls()[0].Path)
+			assert.Empty(t, cache.InvalidateCalls()[0].CollectionID)
+		})
+
+		Convey("A version 3 file published event invalidates its file and collection", func() {
+			data, err := files.AvroSchemaV3.Marshal(&files.FilePublishedV3{Path: "data/file.csv", CollectionID: "collection-1"})
+			assert.NoError(t, err)
+			msg, err := kafkatest.NewMessage(data, 1)
+			assert.NoError(t, err)
+
+			assert.NoError(t, invalidator.HandleFilePublishedV3(ctx, 1, msg))
+
+			assert.Len(t, cache.InvalidateCalls(), 1)
+			assert.Equal(t, "data/file.csv", cache.InvalidateCalls()[0].Path)
+			assert.Equal(t, "collection-1", cache.InvalidateCalls()[0].CollectionID)
+			assert.Empty(t, cache.InvalidateCalls()[0].BundleID)
+		})
+
+		Convey("A file lifecycle event invalidates its file", func() {
+			data, err := files.AvroLifecycleSchema.Marshal(&files.FileLifecycle{Path: "data/file.csv", Change: files.LifecycleStateChanged, FromState: store.StatePublished, ToState: store.StateMoved})
+			assert.NoError(t, err)
+			msg, err := kafkatest.NewMessage(data, 1)
+			assert.NoError(t, err)
+
+			assert.NoError(t, invalidator.HandleFileLifecycle(ctx, 1, msg))
+
+			assert.Len(t, cache.InvalidateCalls(), 1)
+			assert.Equal(t, "data/file.csv", cache.InvalidateCalls()[0].Path)
+		})
+
+		Convey("A file withdrawn event invalidates its file and bundle", func() {
+			data, err := files.AvroWithdrawnSchema.Marshal(&files.FileWithdrawn{Path: "data/file.csv", BundleID: "bundle-1", Reason: "error"})
+			assert.NoError(t, err)
+			msg, err := kafkatest.NewMessage(data, 1)
+			assert.NoError(t, err)
+
+			assert.NoError(t, invalidator.HandleFileWithdrawn(ctx, 1, msg))
+
+			assert.Len(t, cache.InvalidateCalls(), 1)
+			assert.Equal(t, "data/file.csv", cache.InvalidateCalls()[0].Path)
+			assert.Empty(t, cache.InvalidateCalls()[0].CollectionID)
+			assert.Equal(t, "bundle-1", cache.InvalidateCalls()[0].BundleID)
+		})
+
+		Convey("A message that cannot be read is skipped", func() {
+			msg, err := kafkatest.NewMessage([]byte("not avro"), 1)
+			assert.NoError(t, err)
+
+			assert.NoError(t, invalidator.HandleFilePublishedV3(ctx, 1, msg))
+
+			assert.Empty(t, cache.InvalidateCalls())
+		})
+
+		Convey("The size and hit ratio of the cache are reported to the health check", func() {
+			state := healthcheck.NewCheckState("Web Metadata Cache")
+
+			assert.NoError(t, invalidator.Checker(ctx, state))
+
+			assert.Equal(t, healthcheck.StatusOK, state.Status())
+			assert.Equal(t, "web metadata cache holds 3 files, 1 collections and 0 bundles, hit ratio 0.75 (3 hits, 1 misses)", state.Message())
+		})
+	})
+}
+
+func TestMetadataCacheInvalidatorEvictsWithdrawnFile(t *testing.T) {
+	ctx := context.Background()
+	cfg, _ := config.Get()
+	repo := store.NewMemoryRepository()
+	cache := store.NewWebMetadataCache(store.NewStore(repo, clock.SystemClock{}, nil, cfg), 10, time.Hour)
+	invalidator := service.NewMetadataCacheInvalidator(cache)
+
+	require.NoError(t, repo.InsertMetadata(ctx, files.StoredRegisteredMetaData{Path: "data/file.csv", State: store.StatePublished}))
+	_, err := cache.GetFileMetadataWeb(ctx, "data/file.csv")
+	require.NoError(t, err)
+	require.Equal(t, 1, cache.Stats().Files)
+
+	require.NoError(t, repo.UpdateMetadata(ctx, "data/file.csv", store.Update{Set: []store.Field{{Key: "state", Value: store.StateWithdrawn}}}))
+	data, err := files.AvroWithdrawnSchema.Marshal(&files.FileWithdrawn{Path: "data/file.csv", Reason: "error"})
+	require.NoError(t, err)
+	msg, err := kafkatest.NewMessage(data, 1)
+	require.NoError(t, err)
+
+	assert.NoError(t, invalidator.HandleFileWithdrawn(ctx, 1, msg))
+
+	assert.Equal(t, 0, cache.Stats().Files)
+	_, err = cache.GetFileMetadataWeb(ctx, "data/file.csv")
+	assert.ErrorIs(t, err, store.ErrFileWithdrawn)
+}
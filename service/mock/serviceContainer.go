@@ -12,6 +12,7 @@ import (
 	"github.com/ONSdigital/dp-files-api/health"
 	"github.com/ONSdigital/dp-files-api/mongo"
 	"github.com/ONSdigital/dp-files-api/service"
+	"github.com/ONSdigital/dp-files-api/store"
 	kafka "github.com/ONSdigital/dp-kafka/v3"
 	"sync"
 )
@@ -38,12 +39,18 @@ var _ service.ServiceContainer = &ServiceContainerMock{}
 //			GetHealthCheckFunc: func() health.Checker {
 //				panic("mock out the GetHealthCheck method")
 //			},
-//			GetKafkaProducerFunc: func() kafka.IProducer {
-//				panic("mock out the GetKafkaProducer method")
+//			GetKafkaConsumersFunc: func() map[string]kafka.IConsumerGroup {
+//				panic("mock out the GetKafkaConsumers method")
+//			},
+//			GetKafkaProducersFunc: func() map[string]kafka.IProducer {
+//				panic("mock out the GetKafkaProducers method")
 //			},
 //			GetMongoDBFunc: func() mongo.Client {
 //				panic("mock out the GetMongoDB method")
 //			},
+//			GetRepositoryFunc: func() store.Repository {
+//				panic("mock out the GetRepository method")
+//			},
 //			GetS3ClienterFunc: func() aws.S3Clienter {
 //				panic("mock out the GetS3Clienter method")
 //			},
@@ -69,12 +76,18 @@ type ServiceContainerMock struct {
 	// GetHealthCheckFunc mocks the GetHealthCheck method.
 	GetHealthCheckFunc func() health.Checker
 
-	// GetKafkaProducerFunc mocks the GetKafkaProducer method.
-	GetKafkaProducerFunc func() kafka.IProducer
+	// GetKafkaConsumersFunc mocks the GetKafkaConsumers method.
+	GetKafkaConsumersFunc func() map[string]kafka.IConsumerGroup
+
+	// GetKafkaProducersFunc mocks the GetKafkaProducers method.
+	GetKafkaProducersFunc func() map[string]kafka.IProducer
 
 	// GetMongoDBFunc mocks the GetMongoDB method.
 	GetMongoDBFunc func() mongo.Client
 
+	// GetRepositoryFunc mocks the GetRepository method.
+	GetRepositoryFunc func() store.Repository
+
 	// GetS3ClienterFunc mocks the GetS3Clienter method.
 	GetS3ClienterFunc func() aws.S3Clienter
 
@@ -95,12 +108,18 @@ type ServiceContainerMock struct {
 		// GetHealthCheck holds details about calls to the GetHealthCheck method.
 		GetHealthCheck []struct {
 		}
-		// GetKafkaProducer holds details about calls to the GetKafkaProducer method.
-		GetKafkaProducer []struct {
+		// GetKafkaConsumers holds details about calls to the GetKafkaConsumers method.
+		GetKafkaConsumers []struct {
+		}
+		// GetKafkaProducers holds details about calls to the GetKafkaProducers method.
+		GetKafkaProducers []struct {
 		}
 		// GetMongoDB holds details about calls to the GetMongoDB method.
 		GetMongoDB []struct {
 		}
+		// GetRepository holds details about calls to the GetRepository method.
+		GetRepository []struct {
+		}
 		// GetS3Clienter holds details about calls to the GetS3Clienter method.
 		GetS3Clienter []struct {
 		}
@@ -114,8 +133,10 @@ type ServiceContainerMock struct {
 	lockGetClock          sync.RWMutex
 	lockGetHTTPServer     sync.RWMutex
 	lockGetHealthCheck    sync.RWMutex
-	lockGetKafkaProducer  sync.RWMutex
+	lockGetKafkaConsumers sync.RWMutex
+	lockGetKafkaProducers sync.RWMutex
 	lockGetMongoDB        sync.RWMutex
+	lockGetRepository     sync.RWMutex
 	lockGetS3Clienter     sync.RWMutex
 	lockShutdown          sync.RWMutex
 }
@@ -228,30 +249,57 @@ func (mock *ServiceContainerMock) GetHealthCheckCalls() []struct {
 	return calls
 }
 
-// GetKafkaProducer calls GetKafkaProducerFunc.
-func (mock *ServiceContainerMock) GetKafkaProducer() kafka.IProducer {
-	if mock.GetKafkaProducerFunc == nil {
-		panic("ServiceContainerMock.GetKafkaProducerFunc: method is nil but ServiceContainer.GetKafkaProducer was just called")
+// GetKafkaConsumers calls GetKafkaConsumersFunc.
+func (mock *ServiceContainerMock) GetKafkaConsumers() map[string]kafka.IConsumerGroup {
+	if mock.GetKafkaConsumersFunc == nil {
+		panic("ServiceContainerMock.GetKafkaConsumersFunc: method is nil but ServiceContainer.GetKafkaConsumers was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockGetKafkaConsumers.Lock()
+	mock.calls.GetKafkaConsumers = append(mock.calls.GetKafkaConsumers, callInfo)
+	mock.lockGetKafkaConsumers.Unlock()
+	return mock.GetKafkaConsumersFunc()
+}
+
+// GetKafkaConsumersCalls gets all the calls that were made to GetKafkaConsumers.
+// Check the length with:
+//
+//	len(mockedServiceContainer.GetKafkaConsumersCalls())
+func (mock *ServiceContainerMock) GetKafkaConsumersCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockGetKafkaConsumers.RLock()
+	calls = mock.calls.GetKafkaConsumers
+	mock.lockGetKafkaConsumers.RUnlock()
+	return calls
+}
+
+// GetKafkaProducers calls GetKafkaProducersFunc.
+func (mock *ServiceContainerMock) GetKafkaProducers() map[string]kafka.IProducer {
+	if mock.GetKafkaProducersFunc == nil {
+		panic("ServiceContainerMock.GetKafkaProducersFunc: method is nil but ServiceContainer.GetKafkaProducers was just called")
 	}
 	callInfo := struct {
 	}{}
-	mock.lockGetKafkaProducer.Lock()
-	mock.calls.GetKafkaProducer = append(mock.calls.GetKafkaProducer, callInfo)
-	mock.lockGetKafkaProducer.Unlock()
-	return mock.GetKafkaProducerFunc()
+	mock.lockGetKafkaProducers.Lock()
+	mock.calls.GetKafkaProducers = append(mock.calls.GetKafkaProducers, callInfo)
+	mock.lockGetKafkaProducers.Unlock()
+	return mock.GetKafkaProducersFunc()
 }
 
-// GetKafkaProducerCalls gets all the calls that were made to GetKafkaProducer.
+// GetKafkaProducersCalls gets all the calls that were made to GetKafkaProducers.
 // Check the length with:
 //
-//	len(mockedServiceContainer.GetKafkaProducerCalls())
-func (mock *ServiceContainerMock) GetKafkaProducerCalls() []struct {
+//	len(mockedServiceContainer.GetKafkaProducersCalls())
+func (mock *ServiceContainerMock) GetKafkaProducersCalls() []struct {
 } {
 	var calls []struct {
 	}
-	mock.lockGetKafkaProducer.RLock()
-	calls = mock.calls.GetKafkaProducer
-	mock.lockGetKafkaProducer.RUnlock()
+	mock.lockGetKafkaProducers.RLock()
+	calls = mock.calls.GetKafkaProducers
+	mock.lockGetKafkaProducers.RUnlock()
 	return calls
 }
 
@@ -282,6 +330,33 @@ func (mock *ServiceContainerMock) GetMongoDBCalls() []struct {
 	return calls
 }
 
+// GetRepository calls GetRepositoryFunc.
+func (mock *ServiceContainerMock) GetRepository() store.Repository {
+	if mock.GetRepositoryFunc == nil {
+		panic("ServiceContainerMock.GetRepositoryFunc: method is nil but ServiceContainer.GetRepository was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockGetRepository.Lock()
+	mock.calls.GetRepository = append(mock.calls.GetRepository, callInfo)
+	mock.lockGetRepository.Unlock()
+	return mock.GetRepositoryFunc()
+}
+
+// GetRepositoryCalls gets all the calls that were made to GetRepository.
+// Check the length with:
+//
+//	len(mockedServiceContainer.GetRepositoryCalls())
+func (mock *ServiceContainerMock) GetRepositoryCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockGetRepository.RLock()
+	calls = mock.calls.GetRepository
+	mock.lockGetRepository.RUnlock()
+	return calls
+}
+
 // GetS3Clienter calls GetS3ClienterFunc.
 func (mock *ServiceContainerMock) GetS3Clienter() aws.S3Clienter {
 	if mock.GetS3ClienterFunc == nil {
@@ -340,13 +415,3 @@ func (mock *ServiceContainerMock) ShutdownCalls() []struct {
 	mock.lockShutdown.RUnlock()
 	return calls
 }
-
-// GetPermissionsChecker returns nil unless regenerated with moq including this method.
-func (mock *ServiceContainerMock) GetPermissionsChecker() auth.PermissionsChecker {
-	return nil
-}
-
-// GetZebedeeClient returns nil unless regenerated with moq including this method.
-func (mock *ServiceContainerMock) GetZebedeeClient() auth.ZebedeeClient {
-	return nil
-}
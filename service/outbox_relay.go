@@ -0,0 +1,345 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ONSdigital/dp-files-api/clock"
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/dp-files-api/store"
+	"github.com/ONSdigital/dp-healthcheck/healthcheck"
+	kafka "github.com/ONSdigital/dp-kafka/v3"
+	"github.com/ONSdigital/dp-kafka/v3/avro"
+	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/Shopify/sarama"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OutboxRelay periodically drains the outbox, sending each pending message to the Kafka producer for its topic. Only
+// the instance holding the lock on relaying the outbox drains it, renewing the lock on each run so that it keeps it.
+// The producer delivers asynchronously, so a sent message is leased for the ack timeout and only removed from the
+// outbox once the lease has expired without the producer reporting an error for it. Messages that fail, or whose
+// lease expires after they were sent by another instance, are retried with exponential backoff, giving at-least-once
+// delivery.
+type OutboxRelay struct {
+	store       OutboxStore
+	producers   map[string]kafka.IProducer
+	clock       clock.Clock
+	interval    time.Duration
+	batchSize   int
+	maxBackoff  time.Duration
+	ackTimeout  time.Duration
+	lockTTL     time.Duration
+	lockID      string
+	locked      bool
+	mu          sync.RWMutex
+	lastErr     error
+	lastSuccess time.Time
+	sentMu      sync.Mutex
+	sent        map[string]sentMessage
+	cancel      context.CancelFunc
+	done        chan struct{}
+}
+
+// sentMessage is a message handed to the producer by this relay that is awaiting confirmation
+type sentMessage struct {
+	id       string
+	attempts int
+}
+
+// NewOutboxRelay creates an OutboxRelay sending messages for each topic through the given producers
+func NewOutboxRelay(s OutboxStore, producers map[string]kafka.IProducer, clk clock.Clock, interval time.Duration, batchSize int, maxBackoff, ackTimeout, lockTTL time.Duration) *OutboxRelay {
+	return &OutboxRelay{
+		store:      s,
+		producers:  producers,
+		clock:      clk,
+		interval:   interval,
+		batchSize:  batchSize,
+		maxBackoff: maxBackoff,
+		ackTimeout: ackTimeout,
+		lockTTL:    lockTTL,
+		lockID:     primitive.NewObjectID().Hex(),
+		sent:       map[string]sentMessage{},
+	}
+}
+
+// Start runs the relay, and watches each producer for delivery errors, in new go-routines until Close is called
+func (r *OutboxRelay) Start(ctx context.Context) {
+	ctx, r.cancel = context.WithCancel(ctx)
+	r.done = make(chan struct{})
+
+	var wg sync.WaitGroup
+	for _, producer := range r.producers {
+		if channels := producer.Channels(); channels != nil && channels.Errors != nil {
+			wg.Add(1)
+			go func(errs chan error) {
+				defer wg.Done()
+				r.watchErrors(ctx, errs)
+			}(channels.Errors)
+		}
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				r.Relay(ctx)
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(r.done)
+	}()
+}
+
+// Close stops the relay, waiting for any in-flight drain to finish or the context to expire, and releases the lock on
+// relaying the outbox so that another instance can take over
+func (r *OutboxRelay) Close(ctx context.Context) error {
+	if r.cancel == nil {
+		return nil
+	}
+	r.cancel()
+
+	select {
+	case <-r.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	if r.locked {
+		// the lock expires by itself if it cannot be released
+		return r.store.UnlockOutboxRelay(ctx, r.lockID)
+	}
+	return nil
+}
+
+// Relay takes, or renews, the lock on relaying the outbox, then settles the messages left pending by changes that
+// never released them and drains one batch of messages. It does nothing while another instance holds the lock.
+func (r *OutboxRelay) Relay(ctx context.Context) {
+	err := r.store.LockOutboxRelay(ctx, r.lockID, r.lockTTL)
+	r.locked = err == nil
+	if errors.Is(err, store.ErrResourceLocked) {
+		r.setStatus(nil)
+		return
+	}
+	if err != nil {
+		r.setStatus(err)
+		return
+	}
+
+	if err := r.store.SettleStalePendingOutboxMessages(ctx, r.batchSize); err != nil {
+		log.Error(ctx, "outbox relay: failed to settle stale pending messages", err)
+	}
+	r.Drain(ctx)
+}
+
+// Drain sends one batch of pending outbox messages, and removes those whose delivery has been confirmed
+func (r *OutboxRelay) Drain(ctx context.Context) {
+	messages, err := r.store.GetPendingOutboxMessages(ctx, r.batchSize)
+	if err != nil {
+		r.setStatus(err)
+		return
+	}
+
+	var lastErr error
+	for i := range messages {
+		if err := r.relay(ctx, &messages[i]); err != nil {
+			lastErr = err
+		}
+	}
+	r.setStatus(lastErr)
+}
+
+// relay removes a message whose lease has expired without an error being reported for it, and sends any other
+func (r *OutboxRelay) relay(ctx context.Context, msg *files.OutboxMessage) error {
+	if msg.SentAt != nil && r.confirm(msg.ID) {
+		// a failure here means the message will be sent again, which at-least-once delivery allows for
+		if err := r.store.DeleteOutboxMessage(ctx, msg.ID); err != nil {
+			log.Error(ctx, "outbox relay: failed to remove delivered message", err, log.Data{"id": msg.ID, "topic": msg.Topic})
+			return err
+		}
+		return nil
+	}
+
+	// a message sent by another instance, or before a restart, cannot be confirmed so is sent again
+	return r.send(ctx, msg)
+}
+
+func (r *OutboxRelay) send(ctx context.Context, msg *files.OutboxMessage) error {
+	logData := log.Data{"id": msg.ID, "topic": msg.Topic, "attempts": msg.Attempts}
+
+	key, err := r.produce(msg)
+	if err != nil {
+		log.Error(ctx, "outbox relay: failed to send message", err, logData)
+		r.fail(ctx, msg.ID, msg.Attempts, err)
+		return err
+	}
+
+	if err := r.store.MarkOutboxMessageSent(ctx, msg.ID, r.clock.GetCurrentTime().Add(r.ackTimeout)); err != nil {
+		// the message is still due, so it will be sent again
+		log.Error(ctx, "outbox relay: failed to record sent message", err, logData)
+		r.forget(key)
+		return err
+	}
+	return nil
+}
+
+// produce hands the message to its producer, tracking it by its encoded payload so that a delivery error reported
+// by the producer can be matched to it
+func (r *OutboxRelay) produce(msg *files.OutboxMessage) (string, error) {
+	producer, ok := r.producers[msg.Topic]
+	if !ok {
+		return "", fmt.Errorf("no producer configured for topic %q", msg.Topic)
+	}
+
+	schema, event, err := payload(msg)
+	if err != nil {
+		return "", err
+	}
+	encoded, err := schema.Marshal(event)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal outbox message %s: %w", msg.ID, err)
+	}
+
+	key := string(encoded)
+	r.track(key, sentMessage{id: msg.ID, attempts: msg.Attempts})
+	if err := producer.Send(schema, event); err != nil {
+		r.forget(key)
+		return "", err
+	}
+	return key, nil
+}
+
+func payload(msg *files.OutboxMessage) (*avro.Schema, interface{}, error) {
+	switch {
+	case msg.FilePublished != nil:
+		return files.AvroSchema, msg.FilePublished, nil
+	case msg.FilePublishedV3 != nil:
+		return files.AvroSchemaV3, msg.FilePublishedV3, nil
+	case msg.FileLifecycle != nil:
+		return files.AvroLifecycleSchema, msg.FileLifecycle, nil
+	case msg.FileWithdrawn != nil:
+		return files.AvroWithdrawnSchema, msg.FileWithdrawn, nil
+	default:
+		return nil, nil, fmt.Errorf("outbox message %s has no payload", msg.ID)
+	}
+}
+
+// watchErrors records a failure for each message the producer reports it could not deliver
+func (r *OutboxRelay) watchErrors(ctx context.Context, errs chan error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case err, ok := <-errs:
+			if !ok {
+				return
+			}
+			r.handleProducerError(ctx, err)
+		}
+	}
+}
+
+func (r *OutboxRelay) handleProducerError(ctx context.Context, err error) {
+	var producerErr *sarama.ProducerError
+	if !errors.As(err, &producerErr) || producerErr.Msg == nil || producerErr.Msg.Value == nil {
+		log.Error(ctx, "outbox relay: producer reported an error", err)
+		return
+	}
+
+	encoded, encodeErr := producerErr.Msg.Value.Encode()
+	if encodeErr != nil {
+		log.Error(ctx, "outbox relay: failed to read message the producer could not deliver", encodeErr)
+		return
+	}
+
+	msg, ok := r.forget(string(encoded))
+	if !ok {
+		// not sent by this relay, or already confirmed
+		log.Error(ctx, "outbox relay: producer failed to deliver an untracked message", err)
+		return
+	}
+
+	log.Error(ctx, "outbox relay: producer failed to deliver message", err, log.Data{"id": msg.id, "attempts": msg.attempts})
+	r.fail(ctx, msg.id, msg.attempts, err)
+	r.setStatus(err)
+}
+
+func (r *OutboxRelay) fail(ctx context.Context, id string, attempts int, err error) {
+	nextAttemptAt := r.clock.GetCurrentTime().Add(r.backoff(attempts))
+	if markErr := r.store.MarkOutboxMessageFailed(ctx, id, nextAttemptAt, err.Error()); markErr != nil {
+		log.Error(ctx, "outbox relay: failed to record send failure", markErr, log.Data{"id": id})
+	}
+}
+
+func (r *OutboxRelay) track(key string, msg sentMessage) {
+	r.sentMu.Lock()
+	defer r.sentMu.Unlock()
+	r.sent[key] = msg
+}
+
+func (r *OutboxRelay) forget(key string) (sentMessage, bool) {
+	r.sentMu.Lock()
+	defer r.sentMu.Unlock()
+	msg, ok := r.sent[key]
+	delete(r.sent, key)
+	return msg, ok
+}
+
+// confirm reports whether this relay sent the message and the producer has not reported an error for it, forgetting
+// the message if so
+func (r *OutboxRelay) confirm(id string) bool {
+	r.sentMu.Lock()
+	defer r.sentMu.Unlock()
+	for key, msg := range r.sent {
+		if msg.id == id {
+			delete(r.sent, key)
+			return true
+		}
+	}
+	return false
+}
+
+// backoff doubles the relay interval for every failed attempt, up to the configured maximum
+func (r *OutboxRelay) backoff(attempts int) time.Duration {
+	d := r.interval
+	for i := 0; i < attempts && d < r.maxBackoff; i++ {
+		d *= 2
+	}
+	if d > r.maxBackoff {
+		d = r.maxBackoff
+	}
+	return d
+}
+
+func (r *OutboxRelay) setStatus(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastErr = err
+	if err == nil {
+		r.lastSuccess = r.clock.GetCurrentTime()
+	}
+}
+
+// Checker reports the outcome of the most recent drain to the healthcheck library
+func (r *OutboxRelay) Checker(_ context.Context, state *healthcheck.CheckState) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.lastErr != nil {
+		return state.Update(healthcheck.StatusWarning, fmt.Sprintf("outbox relay failing: %s", r.lastErr.Error()), 0)
+	}
+	return state.Update(healthcheck.StatusOK, "outbox relay is OK", 0)
+}
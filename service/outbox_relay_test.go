@@ -0,0 +1,375 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/dp-files-api/service"
+	"github.com/ONSdigital/dp-files-api/service/mock"
+	storepkg "github.com/ONSdigital/dp-files-api/store"
+	"github.com/ONSdigital/dp-healthcheck/healthcheck"
+	kafka "github.com/ONSdigital/dp-kafka/v3"
+	"github.com/ONSdigital/dp-kafka/v3/avro"
+	"github.com/Shopify/sarama"
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+)
+
+const outboxTopic = "static-file-published-v2"
+
+type fixedClock struct{ now time.Time }
+
+func (c fixedClock) GetCurrentTime() time.Time { return c.now }
+
+func TestOutboxRelayDrain(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	published := &files.FilePublished{Path: "dir/file.txt", Type: "text/plain", Etag: "etag", SizeInBytes: "10"}
+
+	Convey("Given an outbox containing a FilePublished message", t, func() {
+		store := &mock.OutboxStoreMock{
+			GetPendingOutboxMessagesFunc: func(ctx context.Context, limit int) ([]files.OutboxMessage, error) {
+				return []files.OutboxMessage{{ID: "1", Topic: outboxTopic, FilePublished: published, Attempts: 2}}, nil
+			},
+			DeleteOutboxMessageFunc:     func(ctx context.Context, id string) error { return nil },
+			MarkOutboxMessageSentFunc:   func(ctx context.Context, id string, confirmBy time.Time) error { return nil },
+			MarkOutboxMessageFailedFunc: func(ctx context.Context, id string, nextAttemptAt time.Time, reason string) error { return nil },
+		}
+		producer := &mock.OurProducerMock{}
+		relay := service.NewOutboxRelay(store, map[string]kafka.IProducer{outboxTopic: producer}, fixedClock{now}, time.Second, 50, 5*time.Second, time.Minute, 30*time.Second)
+
+		Convey("When the producer accepts the message it is leased until the ack timeout rather than removed", func() {
+			producer.SendFunc = func(schema *avro.Schema, event interface{}) error { return nil }
+
+			relay.Drain(ctx)
+
+			assert.Equal(t, 50, store.GetPendingOutboxMessagesCalls()[0].Limit)
+			assert.Len(t, producer.SendCalls(), 1)
+			assert.Equal(t, files.AvroSchema, producer.SendCalls()[0].Schema)
+			assert.Equal(t, published, producer.SendCalls()[0].Event)
+			assert.Len(t, store.MarkOutboxMessageSentCalls(), 1)
+			assert.Equal(t, "1", store.MarkOutboxMessageSentCalls()[0].ID)
+			assert.Equal(t, now.Add(time.Minute), store.MarkOutboxMessageSentCalls()[0].ConfirmBy)
+			assert.Len(t, store.DeleteOutboxMessageCalls(), 0)
+			assert.Len(t, store.MarkOutboxMessageFailedCalls(), 0)
+
+			state := healthcheck.NewCheckState("Outbox Relay")
+			assert.NoError(t, relay.Checker(ctx, state))
+			assert.Equal(t, healthcheck.StatusOK, state.Status())
+
+			Convey("And once its lease expires without a delivery error it is removed from the outbox", func() {
+				store.GetPendingOutboxMessagesFunc = func(ctx context.Context, limit int) ([]files.OutboxMessage, error) {
+					return []files.OutboxMessage{{ID: "1", Topic: outboxTopic, FilePublished: published, Attempts: 2, SentAt: &now}}, nil
+				}
+
+				relay.Drain(ctx)
+
+				assert.Len(t, producer.SendCalls(), 1)
+				assert.Len(t, store.DeleteOutboxMessageCalls(), 1)
+				assert.Equal(t, "1", store.DeleteOutboxMessageCalls()[0].ID)
+			})
+		})
+
+		Convey("A message whose lease expired after another instance sent it is sent again", func() {
+			producer.SendFunc = func(schema *avro.Schema, event interface{}) error { return nil }
+			store.GetPendingOutboxMessagesFunc = func(ctx context.Context, limit int) ([]files.OutboxMessage, error) {
+				return []files.OutboxMessage{{ID: "1", Topic: outboxTopic, FilePublished: published, SentAt: &now}}, nil
+			}
+
+			relay.Drain(ctx)
+
+			assert.Len(t, producer.SendCalls(), 1)
+			assert.Len(t, store.MarkOutboxMessageSentCalls(), 1)
+			assert.Len(t, store.DeleteOutboxMessageCalls(), 0)
+		})
+
+		Convey("A message that cannot be recorded as sent is not leased", func() {
+			producer.SendFunc = func(schema *avro.Schema, event interface{}) error { return nil }
+			store.MarkOutboxMessageSentFunc = func(ctx context.Context, id string, confirmBy time.Time) error {
+				return errors.New("mongo unavailable")
+			}
+
+			relay.Drain(ctx)
+
+			store.GetPendingOutboxMessagesFunc = func(ctx context.Context, limit int) ([]files.OutboxMessage, error) {
+				return []files.OutboxMessage{{ID: "1", Topic: outboxTopic, FilePublished: published, SentAt: &now}}, nil
+			}
+			relay.Drain(ctx)
+
+			assert.Len(t, producer.SendCalls(), 2)
+			assert.Len(t, store.DeleteOutboxMessageCalls(), 0)
+		})
+
+		Convey("When the producer rejects the message it is retried with backoff", func() {
+			producer.SendFunc = func(schema *avro.Schema, event interface{}) error { return errors.New("broker unavailable") }
+
+			relay.Drain(ctx)
+
+			assert.Len(t, store.DeleteOutboxMessageCalls(), 0)
+			assert.Len(t, store.MarkOutboxMessageFailedCalls(), 1)
+			call := store.MarkOutboxMessageFailedCalls()[0]
+			assert.Equal(t, "1", call.ID)
+			assert.Equal(t, now.Add(4*time.Second), call.NextAttemptAt)
+			assert.Equal(t, "broker unavailable", call.Reason)
+
+			state := healthcheck.NewCheckState("Outbox Relay")
+			assert.NoError(t, relay.Checker(ctx, state))
+			assert.Equal(t, healthcheck.StatusWarning, state.Status())
+		})
+
+		Convey("Backoff is capped at the configured maximum", func() {
+			store.GetPendingOutboxMessagesFunc = func(ctx context.Context, limit int) ([]files.OutboxMessage, error) {
+				return []files.OutboxMessage{{ID: "1", Topic: outboxTopic, FilePublished: published, Attempts: 10}}, nil
+			}
+			producer.SendFunc = func(schema *avro.Schema, event interface{}) error { return errors.New("broker unavailable") }
+
+			relay.Drain(ctx)
+
+			assert.Equal(t, now.Add(5*time.Second), store.MarkOutboxMessageFailedCalls()[0].NextAttemptAt)
+		})
+
+		Convey("A v3 FilePublished message is sent with the v3 schema", func() {
+			publishedV3 := &files.FilePublishedV3{EventID: "2", Path: "dir/file.txt", Type: "text/plain", Etag: "etag", SizeInBytes: 10}
+			v3Producer := &mock.OurProducerMock{SendFunc: func(schema *avro.Schema, event interface{}) error { return nil }}
+			relay = service.NewOutboxRelay(store, map[string]kafka.IProducer{outboxTopic: producer, "static-file-published-v3": v3Producer}, fixedClock{now}, time.Second, 50, 5*time.Second, time.Minute, 30*time.Second)
+			store.GetPendingOutboxMessagesFunc = func(ctx context.Context, limit int) ([]files.OutboxMessage, error) {
+				return []files.OutboxMessage{{ID: "2", Topic: "static-file-published-v3", FilePublishedV3: publishedV3}}, nil
+			}
+
+			relay.Drain(ctx)
+
+			assert.Len(t, producer.SendCalls(), 0)
+			assert.Len(t, v3Producer.SendCalls(), 1)
+			assert.Equal(t, files.AvroSchemaV3, v3Producer.SendCalls()[0].Schema)
+			assert.Equal(t, publishedV3, v3Producer.SendCalls()[0].Event)
+			assert.Len(t, store.MarkOutboxMessageSentCalls(), 1)
+		})
+
+		Convey("A FileLifecycle message is sent with the lifecycle schema", func() {
+			lifecycle := &files.FileLifecycle{EventID: "3", Path: "dir/file.txt", Change: files.LifecycleRegistered, ToState: "CREATED"}
+			lifecycleProducer := &mock.OurProducerMock{SendFunc: func(schema *avro.Schema, event interface{}) error { return nil }}
+			relay = service.NewOutboxRelay(store, map[string]kafka.IProducer{outboxTopic: producer, "file-lifecycle": lifecycleProducer}, fixedClock{now}, time.Second, 50, 5*time.Second, time.Minute, 30*time.Second)
+			store.GetPendingOutboxMessagesFunc = func(ctx context.Context, limit int) ([]files.OutboxMessage, error) {
+				return []files.OutboxMessage{{ID: "3", Topic: "file-lifecycle", FileLifecycle: lifecycle}}, nil
+			}
+
+			relay.Drain(ctx)
+
+			assert.Len(t, lifecycleProducer.SendCalls(), 1)
+			assert.Equal(t, files.AvroLifecycleSchema, lifecycleProducer.SendCalls()[0].Schema)
+			assert.Equal(t, lifecycle, lifecycleProducer.SendCalls()[0].Event)
+		})
+
+		Convey("A FileWithdrawn message is sent with the withdrawn schema", func() {
+			withdrawn := &files.FileWithdrawn{EventID: "4", Path: "dir/file.txt", Reason: "released in error"}
+			withdrawnProducer := &mock.OurProducerMock{SendFunc: func(schema *avro.Schema, event interface{}) error { return nil }}
+			relay = service.NewOutboxRelay(store, map[string]kafka.IProducer{outboxTopic: producer, "file-withdrawn": withdrawnProducer}, fixedClock{now}, time.Second, 50, 5*time.Second, time.Minute, 30*time.Second)
+			store.GetPendingOutboxMessagesFunc = func(ctx context.Context, limit int) ([]files.OutboxMessage, error) {
+				return []files.OutboxMessage{{ID: "4", Topic: "file-withdrawn", FileWithdrawn: withdrawn}}, nil
+			}
+
+			relay.Drain(ctx)
+
+			assert.Len(t, withdrawnProducer.SendCalls(), 1)
+			assert.Equal(t, files.AvroWithdrawnSchema, withdrawnProducer.SendCalls()[0].Schema)
+			assert.Equal(t, withdrawn, withdrawnProducer.SendCalls()[0].Event)
+		})
+
+		Convey("A message for a topic without a producer is not sent", func() {
+			store.GetPendingOutboxMessagesFunc = func(ctx context.Context, limit int) ([]files.OutboxMessage, error) {
+				return []files.OutboxMessage{{ID: "1", Topic: "unknown", FilePublished: published}}, nil
+			}
+
+			relay.Drain(ctx)
+
+			assert.Len(t, producer.SendCalls(), 0)
+			assert.Len(t, store.MarkOutboxMessageFailedCalls(), 1)
+		})
+	})
+
+	Convey("Given the outbox cannot be read", t, func() {
+		store := &mock.OutboxStoreMock{
+			GetPendingOutboxMessagesFunc: func(ctx context.Context, limit int) ([]files.OutboxMessage, error) {
+				return nil, errors.New("mongo unavailable")
+			},
+		}
+		relay := service.NewOutboxRelay(store, map[string]kafka.IProducer{}, fixedClock{now}, time.Second, 50, 5*time.Second, time.Minute, 30*time.Second)
+
+		Convey("The relay reports a warning", func() {
+			relay.Drain(ctx)
+
+			state := healthcheck.NewCheckState("Outbox Relay")
+			assert.NoError(t, relay.Checker(ctx, state))
+			assert.Equal(t, healthcheck.StatusWarning, state.Status())
+		})
+	})
+}
+
+func TestOutboxRelayDeliveryErrors(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	published := &files.FilePublished{Path: "dir/file.txt", Type: "text/plain", Etag: "etag", SizeInBytes: "10"}
+
+	Convey("Given a relay whose producer accepts a message and later fails to deliver it", t, func() {
+		ctx := context.Background()
+		failed := make(chan struct{}, 1)
+		store := &mock.OutboxStoreMock{
+			GetPendingOutboxMessagesFunc: func(ctx context.Context, limit int) ([]files.OutboxMessage, error) {
+				return []files.OutboxMessage{{ID: "1", Topic: outboxTopic, FilePublished: published, Attempts: 1}}, nil
+			},
+			DeleteOutboxMessageFunc:   func(ctx context.Context, id string) error { return nil },
+			MarkOutboxMessageSentFunc: func(ctx context.Context, id string, confirmBy time.Time) error { return nil },
+			MarkOutboxMessageFailedFunc: func(ctx context.Context, id string, nextAttemptAt time.Time, reason string) error {
+				failed <- struct{}{}
+				return nil
+			},
+		}
+		channels := kafka.CreateProducerChannels()
+		producer := &mock.OurProducerMock{
+			SendFunc:     func(schema *avro.Schema, event interface{}) error { return nil },
+			ChannelsFunc: func() *kafka.ProducerChannels { return channels },
+		}
+		relay := service.NewOutboxRelay(store, map[string]kafka.IProducer{outboxTopic: producer}, fixedClock{now}, time.Hour, 50, 5*time.Hour, time.Minute, 30*time.Second)
+		relay.Start(ctx)
+		defer relay.Close(ctx)
+
+		relay.Drain(ctx)
+		So(store.MarkOutboxMessageSentCalls(), ShouldHaveLength, 1)
+
+		encoded, err := files.AvroSchema.Marshal(published)
+		So(err, ShouldBeNil)
+		channels.Errors <- &sarama.ProducerError{
+			Msg: &sarama.ProducerMessage{Topic: outboxTopic, Value: sarama.StringEncoder(encoded)},
+			Err: errors.New("broker unavailable"),
+		}
+
+		select {
+		case <-failed:
+		case <-time.After(time.Second):
+			t.Fatal("delivery failure was not recorded")
+		}
+
+		Convey("The message is retried with backoff rather than removed", func() {
+			call := store.MarkOutboxMessageFailedCalls()[0]
+			So(call.ID, ShouldEqual, "1")
+			So(call.NextAttemptAt, ShouldEqual, now.Add(2*time.Hour))
+			So(call.Reason, ShouldContainSubstring, "broker unavailable")
+
+			state := healthcheck.NewCheckState("Outbox Relay")
+			So(relay.Checker(ctx, state), ShouldBeNil)
+			So(state.Status(), ShouldEqual, healthcheck.StatusWarning)
+		})
+
+		Convey("The message is sent again, not removed, when it is next due", func() {
+			store.GetPendingOutboxMessagesFunc = func(ctx context.Context, limit int) ([]files.OutboxMessage, error) {
+				return []files.OutboxMessage{{ID: "1", Topic: outboxTopic, FilePublished: published, Attempts: 2, SentAt: &now}}, nil
+			}
+
+			relay.Drain(ctx)
+
+			So(producer.SendCalls(), ShouldHaveLength, 2)
+			So(store.DeleteOutboxMessageCalls(), ShouldBeEmpty)
+		})
+	})
+}
+
+func TestOutboxRelayRelay(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Given an outbox store", t, func() {
+		store := &mock.OutboxStoreMock{
+			LockOutboxRelayFunc:                  func(ctx context.Context, lockID string, ttl time.Duration) error { return nil },
+			SettleStalePendingOutboxMessagesFunc: func(ctx context.Context, limit int) error { return nil },
+			GetPendingOutboxMessagesFunc: func(ctx context.Context, limit int) ([]files.OutboxMessage, error) {
+				return nil, nil
+			},
+		}
+		relay := service.NewOutboxRelay(store, map[string]kafka.IProducer{}, fixedClock{time.Now()}, time.Second, 50, time.Second, time.Minute, 30*time.Second)
+
+		Convey("The relay holding the lock settles stale pending messages and drains the outbox", func() {
+			relay.Relay(ctx)
+			relay.Relay(ctx)
+
+			So(store.LockOutboxRelayCalls(), ShouldHaveLength, 2)
+			So(store.LockOutboxRelayCalls()[0].TTL, ShouldEqual, 30*time.Second)
+			So(store.LockOutboxRelayCalls()[1].LockID, ShouldEqual, store.LockOutboxRelayCalls()[0].LockID)
+			So(store.SettleStalePendingOutboxMessagesCalls(), ShouldHaveLength, 2)
+			So(store.SettleStalePendingOutboxMessagesCalls()[0].Limit, ShouldEqual, 50)
+			So(store.GetPendingOutboxMessagesCalls(), ShouldHaveLength, 2)
+		})
+
+		Convey("The outbox is still drained when stale pending messages cannot be settled", func() {
+			store.SettleStalePendingOutboxMessagesFunc = func(ctx context.Context, limit int) error { return errors.New("mongo unavailable") }
+
+			relay.Relay(ctx)
+
+			So(store.GetPendingOutboxMessagesCalls(), ShouldHaveLength, 1)
+		})
+
+		Convey("Nothing is relayed while another instance holds the lock", func() {
+			store.LockOutboxRelayFunc = func(ctx context.Context, lockID string, ttl time.Duration) error { return storepkg.ErrResourceLocked }
+
+			relay.Relay(ctx)
+
+			So(store.SettleStalePendingOutboxMessagesCalls(), ShouldBeEmpty)
+			So(store.GetPendingOutboxMessagesCalls(), ShouldBeEmpty)
+
+			state := healthcheck.NewCheckState("Outbox Relay")
+			So(relay.Checker(ctx, state), ShouldBeNil)
+			So(state.Status(), ShouldEqual, healthcheck.StatusOK)
+		})
+
+		Convey("The relay reports a warning when the lock cannot be taken", func() {
+			store.LockOutboxRelayFunc = func(ctx context.Context, lockID string, ttl time.Duration) error {
+				return errors.New("mongo unavailable")
+			}
+
+			relay.Relay(ctx)
+
+			So(store.GetPendingOutboxMessagesCalls(), ShouldBeEmpty)
+
+			state := healthcheck.NewCheckState("Outbox Relay")
+			So(relay.Checker(ctx, state), ShouldBeNil)
+			So(state.Status(), ShouldEqual, healthcheck.StatusWarning)
+		})
+	})
+}
+
+func TestOutboxRelayStartAndClose(t *testing.T) {
+	Convey("A started relay drains the outbox on each tick and stops when closed", t, func() {
+		drained := make(chan struct{}, 1)
+		store := &mock.OutboxStoreMock{
+			LockOutboxRelayFunc:                  func(ctx context.Context, lockID string, ttl time.Duration) error { return nil },
+			UnlockOutboxRelayFunc:                func(ctx context.Context, lockID string) error { return nil },
+			SettleStalePendingOutboxMessagesFunc: func(ctx context.Context, limit int) error { return nil },
+			GetPendingOutboxMessagesFunc: func(ctx context.Context, limit int) ([]files.OutboxMessage, error) {
+				select {
+				case drained <- struct{}{}:
+				default:
+				}
+				return nil, nil
+			},
+		}
+		relay := service.NewOutboxRelay(store, map[string]kafka.IProducer{}, fixedClock{time.Now()}, 10*time.Millisecond, 50, time.Second, time.Minute, 30*time.Second)
+
+		relay.Start(context.Background())
+
+		select {
+		case <-drained:
+		case <-time.After(time.Second):
+			t.Fatal("outbox was not drained")
+		}
+
+		assert.NoError(t, relay.Close(context.Background()))
+		assert.Len(t, store.UnlockOutboxRelayCalls(), 1)
+		assert.Equal(t, store.LockOutboxRelayCalls()[0].LockID, store.UnlockOutboxRelayCalls()[0].LockID)
+	})
+
+	Convey("Closing a relay that was never started succeeds", t, func() {
+		relay := service.NewOutboxRelay(&mock.OutboxStoreMock{}, map[string]kafka.IProducer{}, fixedClock{time.Now()}, time.Second, 50, time.Second, time.Minute, 30*time.Second)
+
+		assert.NoError(t, relay.Close(context.Background()))
+	})
+}
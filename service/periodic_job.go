@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ONSdigital/dp-files-api/store"
+	"github.com/ONSdigital/dp-healthcheck/healthcheck"
+	"github.com/ONSdigital/log.go/v2/log"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PeriodicJob runs a job every interval while holding the lock on its resource, so that only one instance of the
+// service runs it at a time. A run is skipped while another instance holds the lock, and the lock is released once the
+// run has finished.
+type PeriodicJob struct {
+	name     string
+	resource string
+	locker   JobLocker
+	interval time.Duration
+	lockTTL  time.Duration
+	run      func(ctx context.Context) error
+	mu       sync.RWMutex
+	lastErr  error
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// NewPeriodicJob creates a PeriodicJob that runs the job every interval, holding the lock on resource for up to lockTTL
+func NewPeriodicJob(name, resource string, locker JobLocker, interval, lockTTL time.Duration, run func(ctx context.Context) error) *PeriodicJob {
+	return &PeriodicJob{
+		name:     name,
+		resource: resource,
+		locker:   locker,
+		interval: interval,
+		lockTTL:  lockTTL,
+		run:      run,
+	}
+}
+
+// Start runs the job every interval in a new go-routine until Close is called
+func (j *PeriodicJob) Start(ctx context.Context) {
+	j.start(ctx, false)
+}
+
+// StartImmediately runs the job straight away, and then every interval, in a new go-routine until Close is called
+func (j *PeriodicJob) StartImmediately(ctx context.Context) {
+	j.start(ctx, true)
+}
+
+func (j *PeriodicJob) start(ctx context.Context, immediately bool) {
+	ctx, j.cancel = context.WithCancel(ctx)
+	j.done = make(chan struct{})
+
+	go func() {
+		defer close(j.done)
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+
+		if immediately {
+			j.Run(ctx)
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				j.Run(ctx)
+			}
+		}
+	}()
+}
+
+// Close stops the job, waiting for any in-flight run to finish or the context to expire
+func (j *PeriodicJob) Close(ctx context.Context) error {
+	if j.cancel == nil {
+		return nil
+	}
+	j.cancel()
+
+	select {
+	case <-j.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Run takes the lock on the job's resource and runs the job, doing nothing while another instance holds the lock
+func (j *PeriodicJob) Run(ctx context.Context) {
+	logData := log.Data{"job": j.name, "resource": j.resource}
+
+	lockID := primitive.NewObjectID().Hex()
+	err := j.locker.LockResource(ctx, j.resource, lockID, j.lockTTL)
+	if errors.Is(err, store.ErrResourceLocked) {
+		j.setStatus(nil)
+		return
+	}
+	if err != nil {
+		log.Error(ctx, "failed to lock periodic job", err, logData)
+		j.setStatus(err)
+		return
+	}
+	defer func() {
+		// the lock expires by itself if it cannot be released
+		if err := j.locker.UnlockResource(ctx, lockID); err != nil {
+			log.Error(ctx, "failed to unlock periodic job", err, logData)
+		}
+	}()
+
+	j.setStatus(j.run(ctx))
+}
+
+func (j *PeriodicJob) setStatus(err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.lastErr = err
+}
+
+// Checker reports the outcome of the most recent run to the healthcheck library
+func (j *PeriodicJob) Checker(_ context.Context, state *healthcheck.CheckState) error {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	if j.lastErr != nil {
+		return state.Update(healthcheck.StatusWarning, fmt.Sprintf("%s failing: %s", j.name, j.lastErr.Error()), 0)
+	}
+	return state.Update(healthcheck.StatusOK, fmt.Sprintf("%s is OK", j.name), 0)
+}
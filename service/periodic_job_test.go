@@ -0,0 +1,131 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-files-api/service"
+	"github.com/ONSdigital/dp-files-api/service/mock"
+	storepkg "github.com/ONSdigital/dp-files-api/store"
+	"github.com/ONSdigital/dp-healthcheck/healthcheck"
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeriodicJobRun(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Given a periodic job", t, func() {
+		locker := &mock.JobLockerMock{
+			LockResourceFunc:   func(ctx context.Context, resource, lockID string, ttl time.Duration) error { return nil },
+			UnlockResourceFunc: func(ctx context.Context, lockID string) error { return nil },
+		}
+		runs := 0
+		var runErr error
+		job := service.NewPeriodicJob("test job", "test-resource", locker, time.Second, 5*time.Second, func(ctx context.Context) error {
+			runs++
+			return runErr
+		})
+
+		Convey("When it runs it holds the lock on its resource while the job runs and reports OK", func() {
+			job.Run(ctx)
+
+			assert.Equal(t, 1, runs)
+			assert.Len(t, locker.LockResourceCalls(), 1)
+			assert.Equal(t, "test-resource", locker.LockResourceCalls()[0].Resource)
+			assert.Equal(t, 5*time.Second, locker.LockResourceCalls()[0].TTL)
+			assert.Len(t, locker.UnlockResourceCalls(), 1)
+			assert.Equal(t, locker.LockResourceCalls()[0].LockID, locker.UnlockResourceCalls()[0].LockID)
+
+			state := healthcheck.NewCheckState("Test Job")
+			assert.NoError(t, job.Checker(ctx, state))
+			assert.Equal(t, healthcheck.StatusOK, state.Status())
+			assert.Equal(t, "test job is OK", state.Message())
+		})
+
+		Convey("When the job fails it reports a warning and still releases the lock", func() {
+			runErr = errors.New("mongo is unavailable")
+
+			job.Run(ctx)
+
+			assert.Len(t, locker.UnlockResourceCalls(), 1)
+			state := healthcheck.NewCheckState("Test Job")
+			assert.NoError(t, job.Checker(ctx, state))
+			assert.Equal(t, healthcheck.StatusWarning, state.Status())
+			assert.Equal(t, "test job failing: mongo is unavailable", state.Message())
+		})
+
+		Convey("When another instance holds the lock the job is not run and reports OK", func() {
+			locker.LockResourceFunc = func(ctx context.Context, resource, lockID string, ttl time.Duration) error {
+				return storepkg.ErrResourceLocked
+			}
+
+			job.Run(ctx)
+
+			assert.Equal(t, 0, runs)
+			assert.Len(t, locker.UnlockResourceCalls(), 0)
+			state := healthcheck.NewCheckState("Test Job")
+			assert.NoError(t, job.Checker(ctx, state))
+			assert.Equal(t, healthcheck.StatusOK, state.Status())
+		})
+
+		Convey("When the lock cannot be taken the job is not run and reports a warning", func() {
+			locker.LockResourceFunc = func(ctx context.Context, resource, lockID string, ttl time.Duration) error {
+				return errors.New("mongo is unavailable")
+			}
+
+			job.Run(ctx)
+
+			assert.Equal(t, 0, runs)
+			assert.Len(t, locker.UnlockResourceCalls(), 0)
+			state := healthcheck.NewCheckState("Test Job")
+			assert.NoError(t, job.Checker(ctx, state))
+			assert.Equal(t, healthcheck.StatusWarning, state.Status())
+		})
+	})
+}
+
+func TestPeriodicJobStartAndClose(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Given a periodic job", t, func() {
+		locker := &mock.JobLockerMock{
+			LockResourceFunc:   func(ctx context.Context, resource, lockID string, ttl time.Duration) error { return nil },
+			UnlockResourceFunc: func(ctx context.Context, lockID string) error { return nil },
+		}
+		ran := make(chan struct{}, 1)
+		run := func(ctx context.Context) error {
+			select {
+			case ran <- struct{}{}:
+			default:
+			}
+			return nil
+		}
+
+		Convey("When it is started it runs every interval until it is closed", func() {
+			job := service.NewPeriodicJob("test job", "test-resource", locker, 10*time.Millisecond, time.Second, run)
+
+			job.Start(ctx)
+			<-ran
+
+			assert.NoError(t, job.Close(ctx))
+		})
+
+		Convey("When it is started immediately it runs straight away", func() {
+			job := service.NewPeriodicJob("test job", "test-resource", locker, time.Hour, time.Second, run)
+
+			job.StartImmediately(ctx)
+			<-ran
+
+			assert.NoError(t, job.Close(ctx))
+		})
+
+		Convey("When it is closed without being started it returns straight away", func() {
+			job := service.NewPeriodicJob("test job", "test-resource", locker, time.Hour, time.Second, run)
+
+			assert.NoError(t, job.Close(ctx))
+		})
+	})
+}
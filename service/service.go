@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"net/http"
+	"sort"
 	"time"
 
 	clientsidentity "github.com/ONSdigital/dp-api-clients-go/v2/identity"
@@ -38,39 +39,73 @@ import (
 
 // Service contains all the configs, server and clients to run the API
 type Service struct {
-	Server         files.HTTPServer
-	Router         *mux.Router
-	ServiceList    ServiceContainer
-	HealthCheck    health.Checker
-	MongoClient    mongo.Client
-	KafkaProducer  kafka.IProducer
-	AuthMiddleware auth.Middleware
-	S3Client       aws.S3Clienter
+	Server           files.HTTPServer
+	Router           *mux.Router
+	ServiceList      ServiceContainer
+	HealthCheck      health.Checker
+	MongoClient      mongo.Client
+	KafkaProducers   map[string]kafka.IProducer
+	KafkaConsumers   map[string]kafka.IConsumerGroup
+	AuthMiddleware   auth.Middleware
+	S3Client         aws.S3Clienter
+	OutboxRelay      *OutboxRelay
+	Scheduler        *PeriodicJob
+	UploadReaper     *PeriodicJob
+	TrashPurger      *PeriodicJob
+	JobResumer       *PeriodicJob
+	Reconciler       *PeriodicJob
+	CacheInvalidator *MetadataCacheInvalidator
 }
 
 // Run the service
 func Run(ctx context.Context, serviceList ServiceContainer, svcErrors chan error, cfg *config.Config, r *mux.Router) (*Service, error) {
 	log.Info(ctx, "running service")
 
+	if cfg.IsPublishing && len(cfg.FilePublishedTopics()) == 0 {
+		return nil, errors.New("at least one version of the file published event must be enabled")
+	}
+
 	mongoClient := serviceList.GetMongoDB()
-	kafkaProducer := serviceList.GetKafkaProducer()
+	kafkaProducers := serviceList.GetKafkaProducers()
 	hc := serviceList.GetHealthCheck()
 	identityClient := clientsidentity.New(cfg.ZebedeeURL)
 	authMiddleware := serviceList.GetAuthMiddleware()
 	s3Client := serviceList.GetS3Clienter()
-	dataStore := store.NewStore(
-		mongoClient.Collection(config.MetadataCollection),
-		mongoClient.Collection(config.CollectionsCollection),
-		mongoClient.Collection(config.BundlesCollection),
-		mongoClient.Collection(config.FileEventsCollection),
-		kafkaProducer,
-		serviceList.GetClock(),
-		s3Client,
-		cfg,
-	)
+	dataStore := store.NewStore(serviceList.GetRepository(), serviceList.GetClock(), s3Client, cfg)
 
 	const filesURI = "/files/{path:.*}"
+	var outboxRelay *OutboxRelay
+	var scheduler *PeriodicJob
+	var uploadReaper *PeriodicJob
+	var trashPurger *PeriodicJob
+	var jobResumer *PeriodicJob
+	var reconciler *PeriodicJob
+	var kafkaConsumers map[string]kafka.IConsumerGroup
+	var cacheInvalidator *MetadataCacheInvalidator
 	if cfg.IsPublishing {
+		outboxRelay = NewOutboxRelay(
+			dataStore,
+			kafkaProducers,
+			serviceList.GetClock(),
+			cfg.OutboxRelayInterval,
+			cfg.OutboxRelayBatchSize,
+			cfg.OutboxRelayMaxBackoff,
+			cfg.OutboxRelayAckTimeout,
+			cfg.OutboxRelayLockTTL,
+		)
+		scheduler = NewPeriodicJob("publish scheduler", store.ScheduledPublishingResource, dataStore, cfg.ScheduledPublishInterval, cfg.ScheduledPublishLockTTL, dataStore.PublishScheduled)
+		trashPurger = NewPeriodicJob("trash purger", store.TrashPurgeResource, dataStore, cfg.TrashPurgeInterval, cfg.TrashPurgeInterval, dataStore.PurgeTrash)
+		jobResumer = NewPeriodicJob("publish job resumer", store.PublishJobResumeResource, dataStore, cfg.PublishJobLeaseTTL, cfg.PublishJobLeaseTTL, dataStore.ResumePublishJobs)
+		if cfg.UploadReaperEnabled {
+			uploadReaper = NewPeriodicJob("upload reaper", store.UploadReaperResource, dataStore, cfg.UploadReaperInterval, cfg.UploadReaperInterval, dataStore.ReapAbandonedUploads)
+		}
+		if cfg.ReconciliationEnabled {
+			reconciler = NewPeriodicJob("reconciler", store.ReconciliationResource, dataStore, cfg.ReconciliationInterval, cfg.ReconciliationLockTTL, func(ctx context.Context) error {
+				_, err := dataStore.Reconcile(ctx)
+				return err
+			})
+		}
+
 		permissionChecker := permissions.NewChecker(
 			ctx,
 			cfg.PermissionsAPIURL,
@@ -78,35 +113,82 @@ func Run(ctx context.Context, serviceList ServiceContainer, svcErrors chan error
 			cfg.PermissionsMaxCacheTime,
 		)
 
-		register := api.HandlerRegisterUploadStarted(dataStore.RegisterFileUpload, dataStore.CreateFileEvent, authMiddleware, identityClient, cfg.QueryTimeout)
+		// retries of requests that change files are given the response to the first attempt, once it is authorised
+		idempotent := api.Idempotent(dataStore.ClaimIdempotencyKey, dataStore.CompleteIdempotencyKey, dataStore.ReleaseIdempotencyKey, authMiddleware, identityClient)
+
+		register := idempotent(api.HandlerRegisterUploadStarted(dataStore.RegisterFileUpload, dataStore.CreateFileEvent, authMiddleware, identityClient, cfg.QueryTimeout))
+		registerBatch := idempotent(api.HandlerRegisterFilesBatch(dataStore.RegisterFileUploads, dataStore.CreateFileEvents, authMiddleware, identityClient, cfg.FilesBatchMaxSize, cfg.QueryTimeout))
+		fileTransitions := idempotent(api.HandleFileTransitions(dataStore.TransitionFileStates, dataStore.CreateFileEvents, authMiddleware, identityClient, cfg.FilesBatchMaxSize))
+		withdrawCollection := idempotent(api.HandleWithdrawCollection(dataStore.WithdrawCollection, authMiddleware, identityClient))
+		withdrawBundle := idempotent(api.HandleWithdrawBundle(dataStore.WithdrawBundle, authMiddleware, identityClient))
 		getMultipleFiles := api.HandlerGetFilesMetadata(dataStore.GetFilesMetadata)
-		collectionPublished := api.HandleMarkCollectionPublished(dataStore.MarkCollectionPublished)
-		bundlePublished := api.HandleMarkBundlePublished(dataStore.MarkBundlePublished)
-		removeFile := api.HandleRemoveFile(dataStore.RemoveFile, dataStore.CreateFileEvent, dataStore.GetFileMetadata, authMiddleware, identityClient)
+		collectionPublished := idempotent(api.HandleMarkCollectionPublished(dataStore.MarkCollectionPublished, dataStore.ScheduleCollectionPublication))
+		bundlePublished := idempotent(api.HandleMarkBundlePublished(dataStore.MarkBundlePublished, dataStore.ScheduleBundlePublication))
+		collectionPublishStatus := api.HandleGetCollectionPublishStatus(dataStore.GetCollectionPublishJob)
+		bundlePublishStatus := api.HandleGetBundlePublishStatus(dataStore.GetBundlePublishJob)
+		removeFile := idempotent(api.HandleRemoveFile(dataStore.RemoveFile, dataStore.CreateFileEvent, dataStore.GetFileMetadata, authMiddleware, identityClient))
+		restoreFile := idempotent(api.HandleRestoreFile(dataStore.RestoreFile, dataStore.GetTrashedFile, dataStore.CreateFileEvent, authMiddleware, identityClient))
 		createFileEvent := api.HandlerCreateFileEvent(dataStore.CreateFileEvent, authMiddleware, identityClient, permissionChecker)
 		getFileEvents := api.HandlerGetFileEvents(dataStore.GetFileEvents, dataStore.CreateFileEvent, authMiddleware, identityClient)
-		updateContentItem := api.HandlerUpdateContentItem(dataStore.UpdateContentItem, dataStore.CreateFileEvent, dataStore.GetFileMetadata, authMiddleware, identityClient)
-		getSingleFile := api.HandleGetFileMetadataWithAuth(dataStore.GetFileMetadata, authMiddleware, identityClient, permissionChecker)
-
-		r.Path("/files").HandlerFunc(authMiddleware.Require("static-files:create", register)).Methods(http.MethodPost)
+		verifyFileEvents := api.HandleVerifyFileEvents(dataStore.VerifyFileEventChain)
+		exportFileEvents := api.HandlerExportFileEvents(dataStore.ExportFileEvents, dataStore.CreateFileEvent, authMiddleware, identityClient)
+		updateContentItem := idempotent(api.HandlerUpdateContentItem(dataStore.UpdateContentItem, dataStore.CreateFileEvent, dataStore.GetFileMetadata, authMiddleware, identityClient))
+		getFileVersions := api.HandleGetFileVersions(dataStore.GetFileVersions)
+		getDirectory := api.HandleGetDirectory(dataStore.GetDirectory)
+		getAbandonedUploads := api.HandleGetAbandonedUploads(dataStore.GetAbandonedUploads)
+		reconcile := idempotent(api.HandleReconcile(dataStore.StartReconciliation))
+		getReconciliation := api.HandleGetReconciliation(dataStore.GetReconciliation)
+		getLatestReconciliation := api.HandleGetLatestReconciliation(dataStore.GetLatestReconciliation)
+		fileStream := api.HandleFileStream(dataStore.GetFileChanges, dataStore.FileChanged, cfg.FileStreamPollInterval, cfg.FileStreamHeartbeat)
+		fileAttributes := api.FileAttributes(dataStore.GetFileMetadata)
+		getSingleFile := api.HandleGetFileMetadataWithAuth(dataStore.GetFileMetadata, dataStore.GetFileVersion, authMiddleware, identityClient, permissionChecker, cfg.MovedMetadataMaxAge)
+
+		r.Path("/files").HandlerFunc(authMiddleware.RequireWithAttributes("static-files:create", register, api.ContentItemAttributes)).Methods(http.MethodPost)
 		r.Path("/files").HandlerFunc(authMiddleware.Require("static-files:read", getMultipleFiles)).Methods(http.MethodGet)
-		r.Path("/collection/{collectionID}").HandlerFunc(authMiddleware.Require("static-files:update", collectionPublished)).Methods(http.MethodPatch)
-		r.Path("/bundle/{bundleID}").HandlerFunc(authMiddleware.Require("static-files:update", bundlePublished)).Methods(http.MethodPatch)
+		r.Path("/collection/{collectionID}").HandlerFunc(authMiddleware.RequireWithAttributes("static-files:update", collectionPublished, api.CollectionAttributes)).Methods(http.MethodPatch)
+		r.Path("/bundle/{bundleID}").HandlerFunc(authMiddleware.RequireWithAttributes("static-files:update", bundlePublished, api.BundleAttributes)).Methods(http.MethodPatch)
+		r.Path("/collection/{collectionID}/withdraw").HandlerFunc(authMiddleware.RequireWithAttributes("static-files:update", withdrawCollection, api.CollectionAttributes)).Methods(http.MethodPost)
+		r.Path("/bundle/{bundleID}/withdraw").HandlerFunc(authMiddleware.RequireWithAttributes("static-files:update", withdrawBundle, api.BundleAttributes)).Methods(http.MethodPost)
+		r.Path("/collection/{collectionID}/publish-status").HandlerFunc(authMiddleware.Require("static-files:read", collectionPublishStatus)).Methods(http.MethodGet)
+		r.Path("/bundle/{bundleID}/publish-status").HandlerFunc(authMiddleware.Require("static-files:read", bundlePublishStatus)).Methods(http.MethodGet)
 		r.Path("/file-events").HandlerFunc(createFileEvent).Methods(http.MethodPost)
 		r.Path("/file-events").HandlerFunc(authMiddleware.Require("static-files:read", getFileEvents)).Methods(http.MethodGet)
+		r.Path("/file-events/verify").HandlerFunc(authMiddleware.Require("static-files:read", verifyFileEvents)).Methods(http.MethodGet)
+		r.Path("/file-events/export").HandlerFunc(authMiddleware.Require("static-files:read", exportFileEvents)).Methods(http.MethodGet)
+		r.Path("/directories").HandlerFunc(authMiddleware.Require("static-files:read", getDirectory)).Methods(http.MethodGet)
+		r.Path("/directories/{prefix:.*}").HandlerFunc(authMiddleware.Require("static-files:read", getDirectory)).Methods(http.MethodGet)
+		r.Path("/abandoned-uploads").HandlerFunc(authMiddleware.Require("static-files:read", getAbandonedUploads)).Methods(http.MethodGet)
+		r.Path("/reconciliations").HandlerFunc(authMiddleware.Require("static-files:update", reconcile)).Methods(http.MethodPost)
+		r.Path("/reconciliations/latest").HandlerFunc(authMiddleware.Require("static-files:read", getLatestReconciliation)).Methods(http.MethodGet)
+		r.Path("/reconciliations/{id}").HandlerFunc(authMiddleware.Require("static-files:read", getReconciliation)).Methods(http.MethodGet)
+		r.Path("/files/batch").HandlerFunc(api.RequireForEachItem(authMiddleware, "static-files:create", registerBatch, api.BatchAttributes)).Methods(http.MethodPost)
+		r.Path("/files/transitions").HandlerFunc(api.RequireForEachItem(authMiddleware, "static-files:update", fileTransitions, api.TransitionAttributes(dataStore.GetFileMetadata))).Methods(http.MethodPost)
+		r.Path("/files/stream").HandlerFunc(authMiddleware.Require("static-files:read", fileStream)).Methods(http.MethodGet)
+		r.Path("/files/{path:.*}/versions").HandlerFunc(authMiddleware.Require("static-files:read", getFileVersions)).Methods(http.MethodGet)
+		r.Path("/files/{path:.*}/restore").HandlerFunc(authMiddleware.RequireWithAttributes("static-files:update", restoreFile, api.TrashedFileAttributes(dataStore.GetTrashedFile))).Methods(http.MethodPost)
 		r.Path(filesURI).HandlerFunc(getSingleFile).Methods(http.MethodGet)
-		r.Path(filesURI).HandlerFunc(authMiddleware.Require("static-files:update", removeFile)).Methods(http.MethodDelete)
-		r.Path(filesURI).HandlerFunc(authMiddleware.Require("static-files:update", updateContentItem)).Methods(http.MethodPut)
+		r.Path(filesURI).HandlerFunc(api.IfMatch(authMiddleware.RequireWithAttributes("static-files:update", removeFile, fileAttributes))).Methods(http.MethodDelete)
+		// the file is checked against both the content item it has and the one it is being moved to
+		r.Path(filesURI).HandlerFunc(api.IfMatch(authMiddleware.RequireWithAttributes("static-files:update",
+			authMiddleware.RequireWithAttributes("static-files:update", updateContentItem, api.ContentItemAttributes),
+			fileAttributes,
+		))).Methods(http.MethodPut)
+
+		fileTargetAttributes := api.FileTargetAttributes(dataStore.GetFileMetadata)
+		// a file being moved is checked against both where it is and the collection or bundle it is moved to
+		updateCollectionID := authMiddleware.RequireWithAttributes("static-files:update", idempotent(api.HandlerUpdateCollectionID(dataStore.UpdateCollectionID)), fileTargetAttributes)
+		updateBundleID := authMiddleware.RequireWithAttributes("static-files:update", idempotent(api.HandlerUpdateBundleID(dataStore.UpdateBundleID)), fileTargetAttributes)
 
 		patchRequestHandlers := api.PatchRequestHandlers{
-			UploadComplete:   authMiddleware.Require("static-files:update", api.HandleMarkUploadComplete(dataStore.MarkUploadComplete, dataStore.CreateFileEvent, dataStore.GetFileMetadata, authMiddleware, identityClient)),
-			Published:        authMiddleware.Require("static-files:update", api.HandleMarkFilePublished(dataStore.MarkFilePublished, dataStore.CreateFileEvent, dataStore.GetFileMetadata, authMiddleware, identityClient)),
-			Moved:            authMiddleware.Require("static-files:update", api.HandleMarkFileMoved(dataStore.MarkFileMoved, dataStore.CreateFileEvent, dataStore.GetFileMetadata, authMiddleware, identityClient)),
-			CollectionUpdate: authMiddleware.Require("static-files:update", api.HandlerUpdateCollectionID(dataStore.UpdateCollectionID)),
-			BundleUpdate:     authMiddleware.Require("static-files:update", api.HandlerUpdateBundleID(dataStore.UpdateBundleID)),
+			UploadComplete:   authMiddleware.RequireWithAttributes("static-files:update", idempotent(api.HandleMarkUploadComplete(dataStore.MarkUploadComplete, dataStore.CreateFileEvent, dataStore.GetFileMetadata, authMiddleware, identityClient)), fileAttributes),
+			Published:        authMiddleware.RequireWithAttributes("static-files:update", idempotent(api.HandleMarkFilePublished(dataStore.MarkFilePublished, dataStore.CreateFileEvent, dataStore.GetFileMetadata, authMiddleware, identityClient)), fileAttributes),
+			Moved:            authMiddleware.RequireWithAttributes("static-files:update", idempotent(api.HandleMarkFileMoved(dataStore.MarkFileMoved, dataStore.CreateFileEvent, dataStore.GetFileMetadata, authMiddleware, identityClient)), fileAttributes),
+			Withdrawn:        authMiddleware.RequireWithAttributes("static-files:update", idempotent(api.HandleWithdrawFile(dataStore.WithdrawFile, dataStore.CreateFileEvent, dataStore.GetFileMetadata, authMiddleware, identityClient)), fileAttributes),
+			CollectionUpdate: authMiddleware.RequireWithAttributes("static-files:update", updateCollectionID, fileAttributes),
+			BundleUpdate:     authMiddleware.RequireWithAttributes("static-files:update", updateBundleID, fileAttributes),
 		}
 
-		r.Path(filesURI).HandlerFunc(api.PatchRequestToHandler(patchRequestHandlers)).Methods(http.MethodPatch)
+		r.Path(filesURI).HandlerFunc(api.IfMatch(api.PatchRequestToHandler(patchRequestHandlers))).Methods(http.MethodPatch)
 	} else {
 		forbiddenHandler := func(w http.ResponseWriter, r *http.Request) {
 			w.WriteHeader(http.StatusForbidden)
@@ -117,22 +199,55 @@ func Run(ctx context.Context, serviceList ServiceContainer, svcErrors chan error
 		r.Path("/collection/{collectionID}").HandlerFunc(forbiddenHandler).Methods(http.MethodPatch)
 		r.Path("/bundle/{bundle-id}").HandlerFunc(forbiddenHandler).Methods(http.MethodPatch)
 
+		getFileMetadataWeb := dataStore.GetFileMetadataWeb
+		if cfg.WebMetadataCacheSize > 0 {
+			cache := store.NewWebMetadataCache(dataStore, cfg.WebMetadataCacheSize, cfg.WebMetadataCacheTTL)
+			getFileMetadataWeb = cache.GetFileMetadataWeb
+			cacheInvalidator = NewMetadataCacheInvalidator(cache)
+
+			kafkaConsumers = serviceList.GetKafkaConsumers()
+			for topic, consumer := range kafkaConsumers {
+				var handler kafka.Handler
+				switch topic {
+				case cfg.StaticFilePublishedV3Topic:
+					handler = cacheInvalidator.HandleFilePublishedV3
+				case cfg.FileLifecycleTopic:
+					handler = cacheInvalidator.HandleFileLifecycle
+				case cfg.FileWithdrawnTopic:
+					handler = cacheInvalidator.HandleFileWithdrawn
+				default:
+					handler = cacheInvalidator.HandleFilePublished
+				}
+				if err := consumer.RegisterHandler(ctx, handler); err != nil {
+					return nil, errors.Wrapf(err, "unable to register handler for kafka consumer of %s", topic)
+				}
+			}
+		}
+
 		// simple scenario - web mode where users are not authenticated - allowed based on publishing status
-		r.Path(filesURI).HandlerFunc(api.HandleGetFileMetadata(dataStore.GetFileMetadataWeb)).Methods(http.MethodGet)
+		r.Path(filesURI).HandlerFunc(api.HandleGetFileMetadata(getFileMetadataWeb, cfg.MovedMetadataMaxAge)).Methods(http.MethodGet)
 	}
 	r.Path("/health").HandlerFunc(hc.Handler)
 
 	s := serviceList.GetHTTPServer()
 
 	svc := &Service{
-		Router:         r,
-		HealthCheck:    hc,
-		ServiceList:    serviceList,
-		Server:         s,
-		MongoClient:    mongoClient,
-		KafkaProducer:  kafkaProducer,
-		AuthMiddleware: authMiddleware,
-		S3Client:       s3Client,
+		Router:           r,
+		HealthCheck:      hc,
+		ServiceList:      serviceList,
+		Server:           s,
+		MongoClient:      mongoClient,
+		KafkaProducers:   kafkaProducers,
+		KafkaConsumers:   kafkaConsumers,
+		AuthMiddleware:   authMiddleware,
+		S3Client:         s3Client,
+		OutboxRelay:      outboxRelay,
+		Scheduler:        scheduler,
+		UploadReaper:     uploadReaper,
+		TrashPurger:      trashPurger,
+		JobResumer:       jobResumer,
+		Reconciler:       reconciler,
+		CacheInvalidator: cacheInvalidator,
 	}
 
 	if err := svc.registerCheckers(ctx, hc, cfg.IsPublishing); err != nil {
@@ -141,6 +256,38 @@ func Run(ctx context.Context, serviceList ServiceContainer, svcErrors chan error
 
 	hc.Start(ctx)
 
+	if outboxRelay != nil {
+		outboxRelay.Start(ctx)
+	}
+
+	if scheduler != nil {
+		scheduler.Start(ctx)
+	}
+
+	if uploadReaper != nil {
+		uploadReaper.Start(ctx)
+	}
+
+	if trashPurger != nil {
+		trashPurger.Start(ctx)
+	}
+
+	if reconciler != nil {
+		reconciler.Start(ctx)
+	}
+
+	if jobResumer != nil {
+		// jobs left by an instance that stopped are resumed as soon as their lease has expired
+		jobResumer.StartImmediately(ctx)
+	}
+
+	for topic, consumer := range kafkaConsumers {
+		consumer.LogErrors(ctx)
+		if err := consumer.Start(); err != nil {
+			return nil, errors.Wrapf(err, "unable to start kafka consumer of %s", topic)
+		}
+	}
+
 	// Run the http server in a new go-routine
 	go func() {
 		if err := s.ListenAndServe(); err != nil {
@@ -160,6 +307,36 @@ func (svc *Service) Close(ctx context.Context, timeout time.Duration) error {
 
 	go func() {
 		defer cancel()
+		if svc.Reconciler != nil {
+			if reconcilerErr := svc.Reconciler.Close(ctx); reconcilerErr != nil {
+				log.Error(ctx, "failed to stop reconciler", reconcilerErr)
+			}
+		}
+		if svc.JobResumer != nil {
+			if resumerErr := svc.JobResumer.Close(ctx); resumerErr != nil {
+				log.Error(ctx, "failed to stop publish job resumer", resumerErr)
+			}
+		}
+		if svc.TrashPurger != nil {
+			if purgerErr := svc.TrashPurger.Close(ctx); purgerErr != nil {
+				log.Error(ctx, "failed to stop trash purger", purgerErr)
+			}
+		}
+		if svc.UploadReaper != nil {
+			if reaperErr := svc.UploadReaper.Close(ctx); reaperErr != nil {
+				log.Error(ctx, "failed to stop upload reaper", reaperErr)
+			}
+		}
+		if svc.Scheduler != nil {
+			if schedulerErr := svc.Scheduler.Close(ctx); schedulerErr != nil {
+				log.Error(ctx, "failed to stop publish scheduler", schedulerErr)
+			}
+		}
+		if svc.OutboxRelay != nil {
+			if relayErr := svc.OutboxRelay.Close(ctx); relayErr != nil {
+				log.Error(ctx, "failed to stop outbox relay", relayErr)
+			}
+		}
 		err = svc.ServiceList.Shutdown(ctx)
 	}()
 
@@ -185,9 +362,12 @@ func (svc *Service) Close(ctx context.Context, timeout time.Duration) error {
 func (svc *Service) registerCheckers(ctx context.Context, hc health.Checker, isPublishing bool) (err error) {
 	hasErrors := false
 
-	if err := hc.AddCheck("Mongo DB", svc.MongoClient.Checker); err != nil {
-		hasErrors = true
-		log.Error(ctx, "error adding health for mongo db", err)
+	// there is nothing to check when running on the in-memory storage backend
+	if svc.MongoClient != nil {
+		if err := hc.AddCheck("Mongo DB", svc.MongoClient.Checker); err != nil {
+			hasErrors = true
+			log.Error(ctx, "error adding health for mongo db", err)
+		}
 	}
 
 	if isPublishing {
@@ -201,15 +381,75 @@ func (svc *Service) registerCheckers(ctx context.Context, hc health.Checker, isP
 			log.Error(ctx, "error getting jwt keys from identity service", err)
 		}
 
-		if err := hc.AddCheck("Kafka Producer", svc.KafkaProducer.Checker); err != nil {
-			hasErrors = true
-			log.Error(ctx, "error adding health for kafka producer", err)
+		topics := make([]string, 0, len(svc.KafkaProducers))
+		for topic := range svc.KafkaProducers {
+			topics = append(topics, topic)
+		}
+		sort.Strings(topics)
+		for _, topic := range topics {
+			if err := hc.AddCheck("Kafka Producer "+topic, svc.KafkaProducers[topic].Checker); err != nil {
+				hasErrors = true
+				log.Error(ctx, "error adding health for kafka producer", err, log.Data{"topic": topic})
+			}
 		}
 
 		if err := hc.AddCheck("S3 Client", svc.S3Client.Checker); err != nil {
 			hasErrors = true
 			log.Error(ctx, "error adding health for s3 client", err)
 		}
+
+		if err := hc.AddCheck("Outbox Relay", svc.OutboxRelay.Checker); err != nil {
+			hasErrors = true
+			log.Error(ctx, "error adding health for outbox relay", err)
+		}
+
+		if err := hc.AddCheck("Publish Scheduler", svc.Scheduler.Checker); err != nil {
+			hasErrors = true
+			log.Error(ctx, "error adding health for publish scheduler", err)
+		}
+
+		if err := hc.AddCheck("Trash Purger", svc.TrashPurger.Checker); err != nil {
+			hasErrors = true
+			log.Error(ctx, "error adding health for trash purger", err)
+		}
+
+		if err := hc.AddCheck("Publish Job Resumer", svc.JobResumer.Checker); err != nil {
+			hasErrors = true
+			log.Error(ctx, "error adding health for publish job resumer", err)
+		}
+
+		if svc.UploadReaper != nil {
+			if err := hc.AddCheck("Upload Reaper", svc.UploadReaper.Checker); err != nil {
+				hasErrors = true
+				log.Error(ctx, "error adding health for upload reaper", err)
+			}
+		}
+
+		if svc.Reconciler != nil {
+			if err := hc.AddCheck("Reconciler", svc.Reconciler.Checker); err != nil {
+				hasErrors = true
+				log.Error(ctx, "error adding health for reconciler", err)
+			}
+		}
+	}
+
+	if svc.CacheInvalidator != nil {
+		topics := make([]string, 0, len(svc.KafkaConsumers))
+		for topic := range svc.KafkaConsumers {
+			topics = append(topics, topic)
+		}
+		sort.Strings(topics)
+		for _, topic := range topics {
+			if err := hc.AddCheck("Kafka Consumer "+topic, svc.KafkaConsumers[topic].Checker); err != nil {
+				hasErrors = true
+				log.Error(ctx, "error adding health for kafka consumer", err, log.Data{"topic": topic})
+			}
+		}
+
+		if err := hc.AddCheck("Web Metadata Cache", svc.CacheInvalidator.Checker); err != nil {
+			hasErrors = true
+			log.Error(ctx, "error adding health for web metadata cache", err)
+		}
 	}
 
 	if hasErrors {
@@ -21,8 +21,10 @@ import (
 	mongoMock "github.com/ONSdigital/dp-files-api/mongo/mock"
 	"github.com/ONSdigital/dp-files-api/service"
 	"github.com/ONSdigital/dp-files-api/service/mock"
+	"github.com/ONSdigital/dp-files-api/store"
 	"github.com/ONSdigital/dp-healthcheck/healthcheck"
 	kafka "github.com/ONSdigital/dp-kafka/v3"
+	"github.com/ONSdigital/dp-kafka/v3/kafkatest"
 	mongodriver "github.com/ONSdigital/dp-mongodb/v3/mongodb"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/gorilla/mux"
@@ -37,19 +39,18 @@ func TestClose(t *testing.T) {
 			AddCheckFunc: func(name string, checker healthcheck.Checker) error { return nil },
 			StartFunc:    func(context.Context) {},
 		}
-		m := &mongoMock.ClientMock{
-			CollectionFunc: func(s string) *mongodriver.Collection {
-				return &mongodriver.Collection{}
-			},
-		}
+		m := &mongoMock.ClientMock{}
 		hs := &mockFiles.HTTPServerMock{ListenAndServeFunc: func() error { return nil }}
 
-		km := &mock.OurProducerMock{}
+		km := &mock.OurProducerMock{ChannelsFunc: kafka.CreateProducerChannels}
 
 		am := &authMock.MiddlewareMock{
 			RequireFunc: func(permission string, handlerFunc http.HandlerFunc) http.HandlerFunc {
 				return handlerFunc
 			},
+			RequireWithAttributesFunc: func(permission string, handlerFunc http.HandlerFunc, getAttributes auth.GetAttributesFromRequest) http.HandlerFunc {
+				return handlerFunc
+			},
 		}
 		s3Client := &s3Mock.S3ClienterMock{
 			CheckerFunc: func(ctx context.Context, state *healthcheck.CheckState) error { return nil },
@@ -60,10 +61,11 @@ func TestClose(t *testing.T) {
 
 		serviceList := &mock.ServiceContainerMock{
 			GetMongoDBFunc:        func() mongo.Client { return m },
+			GetRepositoryFunc:     func() store.Repository { return store.NewMemoryRepository() },
 			GetClockFunc:          func() clock.Clock { return nil },
 			GetHTTPServerFunc:     func() files.HTTPServer { return hs },
 			GetHealthCheckFunc:    func() health.Checker { return hc },
-			GetKafkaProducerFunc:  func() kafka.IProducer { return km },
+			GetKafkaProducersFunc: func() map[string]kafka.IProducer { return map[string]kafka.IProducer{"static-file-published-v2": km} },
 			GetAuthMiddlewareFunc: func() auth.Middleware { return am },
 			GetS3ClienterFunc:     func() aws.S3Clienter { return s3Client },
 		}
@@ -79,12 +81,16 @@ func TestClose(t *testing.T) {
 
 			registerHealthChecks := hc.AddCheckCalls()
 
-			assert.Len(t, registerHealthChecks, 5)
+			assert.Len(t, registerHealthChecks, 9)
 			assert.Equal(t, registerHealthChecks[0].Name, "Mongo DB")
 			assert.Equal(t, registerHealthChecks[1].Name, "Authorization Middleware")
 			assert.Equal(t, registerHealthChecks[2].Name, "jwt keys state health check")
-			assert.Equal(t, registerHealthChecks[3].Name, "Kafka Producer")
+			assert.Equal(t, registerHealthChecks[3].Name, "Kafka Producer static-file-published-v2")
 			assert.Equal(t, registerHealthChecks[4].Name, "S3 Client")
+			assert.Equal(t, registerHealthChecks[5].Name, "Outbox Relay")
+			assert.Equal(t, registerHealthChecks[6].Name, "Publish Scheduler")
+			assert.Equal(t, registerHealthChecks[7].Name, "Trash Purger")
+			assert.Equal(t, registerHealthChecks[8].Name, "Publish Job Resumer")
 			assert.NoError(t, svc.Close(ctx, 2*time.Second))
 			assert.Len(t, serviceList.ShutdownCalls(), 1)
 		})
@@ -94,12 +100,16 @@ func TestClose(t *testing.T) {
 
 			registerHealthChecks := hc.AddCheckCalls()
 
-			assert.Len(t, registerHealthChecks, 5)
+			assert.Len(t, registerHealthChecks, 9)
 			assert.Equal(t, registerHealthChecks[0].Name, "Mongo DB")
 			assert.Equal(t, registerHealthChecks[1].Name, "Authorization Middleware")
 			assert.Equal(t, registerHealthChecks[2].Name, "jwt keys state health check")
-			assert.Equal(t, registerHealthChecks[3].Name, "Kafka Producer")
+			assert.Equal(t, registerHealthChecks[3].Name, "Kafka Producer static-file-published-v2")
 			assert.Equal(t, registerHealthChecks[4].Name, "S3 Client")
+			assert.Equal(t, registerHealthChecks[5].Name, "Outbox Relay")
+			assert.Equal(t, registerHealthChecks[6].Name, "Publish Scheduler")
+			assert.Equal(t, registerHealthChecks[7].Name, "Trash Purger")
+			assert.Equal(t, registerHealthChecks[8].Name, "Publish Job Resumer")
 			assert.Error(t, svc.Close(ctx, 2*time.Second))
 			assert.Len(t, serviceList.ShutdownCalls(), 1)
 		})
@@ -112,12 +122,16 @@ func TestClose(t *testing.T) {
 
 			registerHealthChecks := hc.AddCheckCalls()
 
-			assert.Len(t, registerHealthChecks, 5)
+			assert.Len(t, registerHealthChecks, 9)
 			assert.Equal(t, registerHealthChecks[0].Name, "Mongo DB")
 			assert.Equal(t, registerHealthChecks[1].Name, "Authorization Middleware")
 			assert.Equal(t, registerHealthChecks[2].Name, "jwt keys state health check")
-			assert.Equal(t, registerHealthChecks[3].Name, "Kafka Producer")
+			assert.Equal(t, registerHealthChecks[3].Name, "Kafka Producer static-file-published-v2")
 			assert.Equal(t, registerHealthChecks[4].Name, "S3 Client")
+			assert.Equal(t, registerHealthChecks[5].Name, "Outbox Relay")
+			assert.Equal(t, registerHealthChecks[6].Name, "Publish Scheduler")
+			assert.Equal(t, registerHealthChecks[7].Name, "Trash Purger")
+			assert.Equal(t, registerHealthChecks[8].Name, "Publish Job Resumer")
 			assert.Error(t, svc.Close(ctx, 100*time.Millisecond))
 			assert.Len(t, serviceList.ShutdownCalls(), 1)
 		})
@@ -135,10 +149,16 @@ func TestClose(t *testing.T) {
 		}
 		hs := &mockFiles.HTTPServerMock{ListenAndServeFunc: func() error { return nil }}
 
-		km := &mock.OurProducerMock{}
+		km := &mock.OurProducerMock{ChannelsFunc: kafka.CreateProducerChannels}
 
 		am := &authMock.MiddlewareMock{}
 
+		cg := &kafkatest.IConsumerGroupMock{
+			RegisterHandlerFunc: func(ctx context.Context, h kafka.Handler) error { return nil },
+			LogErrorsFunc:       func(ctx context.Context) {},
+			StartFunc:           func() error { return nil },
+		}
+
 		s3Client := &s3Mock.S3ClienterMock{
 			CheckerFunc: func(ctx context.Context, state *healthcheck.CheckState) error { return nil },
 			HeadFunc: func(ctx context.Context, key string) (*s3.HeadObjectOutput, error) {
@@ -148,10 +168,14 @@ func TestClose(t *testing.T) {
 
 		serviceList := &mock.ServiceContainerMock{
 			GetMongoDBFunc:        func() mongo.Client { return m },
+			GetRepositoryFunc:     func() store.Repository { return store.NewMemoryRepository() },
 			GetClockFunc:          func() clock.Clock { return nil },
 			GetHTTPServerFunc:     func() files.HTTPServer { return hs },
 			GetHealthCheckFunc:    func() health.Checker { return hc },
-			GetKafkaProducerFunc:  func() kafka.IProducer { return km },
+			GetKafkaProducersFunc: func() map[string]kafka.IProducer { return map[string]kafka.IProducer{"static-file-published-v2": km} },
+			GetKafkaConsumersFunc: func() map[string]kafka.IConsumerGroup {
+				return map[string]kafka.IConsumerGroup{"static-file-published-v2": cg}
+			},
 			GetAuthMiddlewareFunc: func() auth.Middleware { return am },
 			GetS3ClienterFunc:     func() aws.S3Clienter { return s3Client },
 		}
@@ -167,8 +191,12 @@ func TestClose(t *testing.T) {
 
 			registerHealthChecks := hc.AddCheckCalls()
 
-			assert.Len(t, registerHealthChecks, 1)
+			assert.Len(t, registerHealthChecks, 3)
 			assert.Equal(t, registerHealthChecks[0].Name, "Mongo DB")
+			assert.Equal(t, registerHealthChecks[1].Name, "Kafka Consumer static-file-published-v2")
+			assert.Equal(t, registerHealthChecks[2].Name, "Web Metadata Cache")
+			assert.Len(t, cg.RegisterHandlerCalls(), 1)
+			assert.Len(t, cg.StartCalls(), 1)
 
 			assert.NoError(t, svc.Close(ctx, 2*time.Second))
 			assert.Len(t, serviceList.ShutdownCalls(), 1)
@@ -179,8 +207,10 @@ func TestClose(t *testing.T) {
 
 			registerHealthChecks := hc.AddCheckCalls()
 
-			assert.Len(t, registerHealthChecks, 1)
+			assert.Len(t, registerHealthChecks, 3)
 			assert.Equal(t, registerHealthChecks[0].Name, "Mongo DB")
+			assert.Equal(t, registerHealthChecks[1].Name, "Kafka Consumer static-file-published-v2")
+			assert.Equal(t, registerHealthChecks[2].Name, "Web Metadata Cache")
 
 			assert.Error(t, svc.Close(ctx, 2*time.Second))
 			assert.Len(t, serviceList.ShutdownCalls(), 1)
@@ -194,11 +224,28 @@ func TestClose(t *testing.T) {
 
 			registerHealthChecks := hc.AddCheckCalls()
 
-			assert.Len(t, registerHealthChecks, 1)
+			assert.Len(t, registerHealthChecks, 3)
 			assert.Equal(t, registerHealthChecks[0].Name, "Mongo DB")
+			assert.Equal(t, registerHealthChecks[1].Name, "Kafka Consumer static-file-published-v2")
+			assert.Equal(t, registerHealthChecks[2].Name, "Web Metadata Cache")
 
 			assert.Error(t, svc.Close(ctx, 100*time.Millisecond))
 			assert.Len(t, serviceList.ShutdownCalls(), 1)
 		})
 	})
 }
+
+func TestRunWithoutFilePublishedEvents(t *testing.T) {
+	Convey("Running a publishing service with every version of the file published event disabled fails", t, func() {
+		cfg, _ := config.Get()
+		c := *cfg
+		c.IsPublishing = true
+		c.FilePublishedV2Enabled = false
+		c.FilePublishedV3Enabled = false
+
+		svc, err := service.Run(context.Background(), &mock.ServiceContainerMock{}, make(chan error, 1), &c, &mux.Router{})
+
+		assert.Nil(t, svc)
+		assert.EqualError(t, err, "at least one version of the file published event must be enabled")
+	})
+}
@@ -0,0 +1,152 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/ONSdigital/dp-files-api/files"
+	dprequest "github.com/ONSdigital/dp-net/v3/request"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+const (
+	// UploadReaperResource is locked while abandoned uploads are removed, so that only one instance of the service
+	// removes them
+	UploadReaperResource = "upload-reaper"
+
+	// UploadReaperIdentity is the identity that abandoned uploads are removed by in file events and lifecycle events
+	UploadReaperIdentity = "dp-files-api-upload-reaper"
+)
+
+// GetAbandonedUploads reports the files that would be removed by ReapAbandonedUploads, without removing them
+func (store *Store) GetAbandonedUploads(ctx context.Context) (*files.AbandonedUploads, error) {
+	createdBefore := store.abandonedUploadCutoff()
+
+	metadata, err := store.findAbandonedUploads(ctx, createdBefore)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &files.AbandonedUploads{
+		CreatedBefore: createdBefore,
+		Count:         len(metadata),
+		Items:         make([]files.AbandonedUpload, 0, len(metadata)),
+	}
+	for _, m := range metadata {
+		report.Items = append(report.Items, files.AbandonedUpload{
+			Path:         m.Path,
+			CollectionID: m.CollectionID,
+			BundleID:     m.BundleID,
+			SizeInBytes:  m.SizeInBytes,
+			CreatedAt:    m.CreatedAt,
+		})
+	}
+	return report, nil
+}
+
+// ReapAbandonedUploads removes the files that have been CREATED for longer than the abandoned upload TTL, along with
+// any part of them uploaded to S3, and the collection and bundle records left with no files. Each removal is recorded
+// as a DELETE file event by UploadReaperIdentity. A file is only removed while it is still the CREATED upload that was
+// found. Files whose metadata cannot be removed are tried again next time, and the last error is returned.
+func (store *Store) ReapAbandonedUploads(ctx context.Context) error {
+	metadata, err := store.findAbandonedUploads(ctx, store.abandonedUploadCutoff())
+	if err != nil {
+		return err
+	}
+
+	ctx = dprequest.SetCaller(ctx, UploadReaperIdentity)
+
+	var lastErr error
+	for _, m := range metadata {
+		if err := store.reapAbandonedUpload(ctx, m); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+func (store *Store) abandonedUploadCutoff() time.Time {
+	return store.clock.GetCurrentTime().Add(-store.cfg.AbandonedUploadTTL)
+}
+
+func (store *Store) findAbandonedUploads(ctx context.Context, createdBefore time.Time) ([]files.StoredRegisteredMetaData, error) {
+	metadata, err := store.repo.FindMetadata(ctx, MetadataFilter{States: []string{StateCreated}, CreatedBefore: &createdBefore})
+	if err != nil {
+		log.Error(ctx, "failed to find abandoned uploads", err, log.Data{"created_before": createdBefore})
+		return nil, err
+	}
+	return metadata, nil
+}
+
+func (store *Store) reapAbandonedUpload(ctx context.Context, m files.StoredRegisteredMetaData) error {
+	logData := log.Data{"path": m.Path, "created_at": m.CreatedAt}
+
+	outboxID, err := store.enqueueFileLifecycle(ctx, m.Path, files.LifecycleRemoved, m.State, "")
+	if err != nil {
+		return err
+	}
+
+	// the metadata is only deleted while the upload is still the CREATED one that was found, so that an upload that
+	// completes, or a file registered again at the path, in the meantime keeps both its metadata and its object in s3
+	deleted, err := store.repo.DeleteMetadataIfUnchanged(ctx, m.Path, StateCreated, m.Revision, m.CreatedAt)
+	if err != nil {
+		store.withdrawOutboxMessages(ctx, []string{outboxID})
+		log.Error(ctx, "reap abandoned upload: error while deleting metadata", err, logData)
+		return err
+	}
+	if !deleted {
+		store.withdrawOutboxMessages(ctx, []string{outboxID})
+		log.Info(ctx, "reap abandoned upload: file changed since it was found, so it is kept", logData)
+		return nil
+	}
+	log.Info(ctx, "reap abandoned upload: metadata deleted", logData)
+	store.releaseOutboxMessages(ctx, []string{outboxID})
+	store.recordFileChange(ctx, m, files.LifecycleRemoved, m.State, "")
+
+	// an upload that never completed may have left parts of the file in s3. Once the metadata is gone the file is not
+	// found again, so an object that cannot be deleted is left behind and the error is returned once the rest is done
+	s3Err := store.s3client.Delete(ctx, m.Path)
+	if s3Err != nil {
+		log.Error(ctx, "reap abandoned upload: error while deleting file from s3", s3Err, logData)
+	}
+
+	if err := store.CreateFileEvent(ctx, &files.FileEvent{
+		RequestedBy: &files.RequestedBy{ID: UploadReaperIdentity},
+		Action:      files.ActionDelete,
+		Resource:    m.Path,
+		File:        &m,
+	}); err != nil {
+		return err
+	}
+
+	if m.CollectionID != nil {
+		if err := store.removeEmptyRecord(ctx, MetadataFilter{CollectionID: m.CollectionID}, store.repo.DeleteCollection, *m.CollectionID); err != nil {
+			log.Error(ctx, "reap abandoned upload: error while removing collection record", err, logData)
+			return err
+		}
+	}
+	if m.BundleID != nil {
+		if err := store.removeEmptyRecord(ctx, MetadataFilter{BundleID: m.BundleID}, store.repo.DeleteBundle, *m.BundleID); err != nil {
+			log.Error(ctx, "reap abandoned upload: error while removing bundle record", err, logData)
+			return err
+		}
+	}
+	return s3Err
+}
+
+// removeEmptyRecord deletes the collection or bundle record with the ID when no files are left in it
+func (store *Store) removeEmptyRecord(ctx context.Context, filter MetadataFilter, deleteRecord func(ctx context.Context, id string) (bool, error), id string) error {
+	count, err := store.repo.CountMetadata(ctx, filter)
+	if err != nil || count > 0 {
+		return err
+	}
+
+	deleted, err := deleteRecord(ctx, id)
+	if err != nil {
+		return err
+	}
+	if deleted {
+		log.Info(ctx, "reap abandoned upload: empty record deleted", log.Data{"id": id})
+	}
+	return nil
+}
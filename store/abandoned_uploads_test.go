@@ -0,0 +1,145 @@
+package store_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	s3Mock "github.com/ONSdigital/dp-files-api/aws/mock"
+	"github.com/ONSdigital/dp-files-api/config"
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/dp-files-api/store"
+	mongodriver "github.com/ONSdigital/dp-mongodb/v3/mongodb"
+)
+
+func (suite *StoreSuite) insertAbandonedUploads(repo *store.MemoryRepository) {
+	now := suite.defaultClock.GetCurrentTime()
+	abandoned, recent := now.Add(-25*time.Hour), now.Add(-time.Hour)
+	collectionID, bundleID := testCollectionID, testBundleID
+	suite.NoError(repo.InsertCollection(suite.defaultContext, files.StoredCollection{ID: collectionID, State: store.StateScheduled}))
+	suite.NoError(repo.InsertBundle(suite.defaultContext, files.StoredBundle{ID: bundleID}))
+	suite.NoError(repo.InsertMetadata(suite.defaultContext, files.StoredRegisteredMetaData{Path: "abandoned.csv", State: store.StateCreated, CollectionID: &collectionID, SizeInBytes: 10, CreatedAt: abandoned}))
+	suite.NoError(repo.InsertMetadata(suite.defaultContext, files.StoredRegisteredMetaData{Path: "bundle/abandoned.csv", State: store.StateCreated, BundleID: &bundleID, CreatedAt: abandoned}))
+	suite.NoError(repo.InsertMetadata(suite.defaultContext, files.StoredRegisteredMetaData{Path: "bundle/recent.csv", State: store.StateCreated, BundleID: &bundleID, CreatedAt: recent}))
+	suite.NoError(repo.InsertMetadata(suite.defaultContext, files.StoredRegisteredMetaData{Path: "uploaded.csv", State: store.StateUploaded, CreatedAt: abandoned}))
+}
+
+func (suite *StoreSuite) TestGetAbandonedUploads() {
+	subject, repo := suite.lifecycleStore()
+	suite.insertAbandonedUploads(repo)
+
+	report, err := subject.GetAbandonedUploads(suite.defaultContext)
+
+	suite.NoError(err)
+	suite.Equal(suite.defaultClock.GetCurrentTime().Add(-24*time.Hour), report.CreatedBefore)
+	suite.Equal(2, report.Count)
+	suite.Equal("abandoned.csv", report.Items[0].Path)
+	suite.Equal(testCollectionID, *report.Items[0].CollectionID)
+	suite.Equal(uint64(10), report.Items[0].SizeInBytes)
+	suite.Equal("bundle/abandoned.csv", report.Items[1].Path)
+
+	_, err = repo.GetMetadata(suite.defaultContext, "abandoned.csv")
+	suite.NoError(err, "a report removes nothing")
+}
+
+func (suite *StoreSuite) TestReapAbandonedUploads() {
+	_, repo := suite.lifecycleStore()
+	var deletedKeys []string
+	s3Client := &s3Mock.S3ClienterMock{
+		DeleteFunc: func(ctx context.Context, key string) error {
+			deletedKeys = append(deletedKeys, key)
+			return nil
+		},
+	}
+	cfg, _ := config.Get()
+	subject := store.NewStore(repo, suite.defaultClock, s3Client, cfg)
+	suite.insertAbandonedUploads(repo)
+
+	suite.NoError(subject.ReapAbandonedUploads(suite.defaultContext))
+
+	suite.Equal([]string{"abandoned.csv", "bundle/abandoned.csv"}, deletedKeys)
+	for _, path := range []string{"abandoned.csv", "bundle/abandoned.csv"} {
+		_, err := repo.GetMetadata(suite.defaultContext, path)
+		suite.ErrorIs(err, mongodriver.ErrNoDocumentFound)
+	}
+	for _, path := range []string{"bundle/recent.csv", "uploaded.csv"} {
+		_, err := repo.GetMetadata(suite.defaultContext, path)
+		suite.NoError(err)
+	}
+
+	_, err := repo.GetCollection(suite.defaultContext, testCollectionID)
+	suite.ErrorIs(err, mongodriver.ErrNoDocumentFound, "the collection has no files left")
+	_, err = repo.GetBundle(suite.defaultContext, testBundleID)
+	suite.NoError(err, "the bundle still has a file")
+
+	fileEvents, err := repo.FindFileEvents(suite.defaultContext, store.FileEventFilter{}, false, 0, 10)
+	suite.NoError(err)
+	suite.Len(fileEvents, 2)
+	suite.Equal(files.ActionDelete, fileEvents[0].Action)
+	suite.Equal("abandoned.csv", fileEvents[0].Resource)
+	suite.Equal(store.UploadReaperIdentity, fileEvents[0].RequestedBy.ID)
+
+	events := suite.lifecycleEvents(repo)
+	suite.Len(events, 2)
+	suite.Equal(files.LifecycleRemoved, events[0].Change)
+	suite.Equal(store.StateCreated, events[0].FromState)
+	suite.Equal(store.UploadReaperIdentity, events[0].Actor)
+}
+
+func (suite *StoreSuite) TestReapAbandonedUploadsReportsFileThatCannotBeDeletedFromS3() {
+	_, repo := suite.lifecycleStore()
+	s3Err := errors.New("s3 is unavailable")
+	s3Client := &s3Mock.S3ClienterMock{
+		DeleteFunc: func(ctx context.Context, key string) error { return s3Err },
+	}
+	cfg, _ := config.Get()
+	subject := store.NewStore(repo, suite.defaultClock, s3Client, cfg)
+	suite.insertAbandonedUploads(repo)
+
+	suite.ErrorIs(subject.ReapAbandonedUploads(suite.defaultContext), s3Err)
+
+	_, err := repo.GetMetadata(suite.defaultContext, "abandoned.csv")
+	suite.ErrorIs(err, mongodriver.ErrNoDocumentFound)
+	suite.Len(suite.lifecycleEvents(repo), 2)
+}
+
+// completingUploadRepository completes the upload of a file after it is found as abandoned, before it is removed
+type completingUploadRepository struct {
+	*store.MemoryRepository
+	path string
+}
+
+func (r *completingUploadRepository) FindMetadata(ctx context.Context, filter store.MetadataFilter) ([]files.StoredRegisteredMetaData, error) {
+	metadata, err := r.MemoryRepository.FindMetadata(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return metadata, r.UpdateMetadata(ctx, r.path, store.Update{Set: []store.Field{{Key: "state", Value: store.StateUploaded}}})
+}
+
+func (suite *StoreSuite) TestReapAbandonedUploadsKeepsFileUploadedAfterItWasFound() {
+	repo := &completingUploadRepository{MemoryRepository: store.NewMemoryRepository(), path: "abandoned.csv"}
+	var deletedKeys []string
+	s3Client := &s3Mock.S3ClienterMock{
+		DeleteFunc: func(ctx context.Context, key string) error {
+			deletedKeys = append(deletedKeys, key)
+			return nil
+		},
+	}
+	cfg, _ := config.Get()
+	subject := store.NewStore(repo, suite.defaultClock, s3Client, cfg)
+	suite.insertAbandonedUploads(repo.MemoryRepository)
+
+	suite.NoError(subject.ReapAbandonedUploads(suite.defaultContext))
+
+	suite.Equal([]string{"bundle/abandoned.csv"}, deletedKeys)
+	m, err := repo.GetMetadata(suite.defaultContext, "abandoned.csv")
+	suite.NoError(err)
+	suite.Equal(store.StateUploaded, m.State)
+
+	fileEvents, err := repo.FindFileEvents(suite.defaultContext, store.FileEventFilter{}, false, 0, 10)
+	suite.NoError(err)
+	suite.Require().Len(fileEvents, 1)
+	suite.Equal("bundle/abandoned.csv", fileEvents[0].Resource)
+	suite.Len(suite.lifecycleEvents(repo.MemoryRepository), 1)
+}
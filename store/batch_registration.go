@@ -0,0 +1,194 @@
+package store
+
+import (
+	"context"
+
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/log.go/v2/log"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// RegisterFileUploads registers a batch of new file uploads, returning the result for each file in the order given.
+// Unlike RegisterFileUpload, a path that is already registered is reported as a duplicate rather than replaced, as
+// is a path given more than once. Files in a published or withdrawn collection or bundle are not registered.
+func (store *Store) RegisterFileUploads(ctx context.Context, metadata []files.StoredRegisteredMetaData) ([]files.RegistrationResult, error) {
+	results := make([]files.RegistrationResult, len(metadata))
+	paths := make([]string, 0, len(metadata))
+	for i, m := range metadata {
+		results[i] = files.RegistrationResult{Path: m.Path}
+		paths = append(paths, m.Path)
+	}
+
+	existing, err := store.repo.FindMetadata(ctx, MetadataFilter{Paths: paths})
+	if err != nil {
+		log.Error(ctx, "failed to find registered files", err)
+		return nil, err
+	}
+	registered := make(map[string]bool)
+	for _, m := range existing {
+		registered[m.Path] = true
+	}
+
+	collectionStates := make(map[string]string)
+	bundleStates := make(map[string]string)
+	now := store.clock.GetCurrentTime()
+
+	toInsert := make([]files.StoredRegisteredMetaData, 0, len(metadata))
+	resultIndexes := make([]int, 0, len(metadata))
+	for i, m := range metadata {
+		if registered[m.Path] {
+			results[i].Result = files.RegistrationDuplicate
+			continue
+		}
+
+		if m.CollectionID != nil {
+			state, err := cachedStateCheck(ctx, collectionStates, *m.CollectionID, store.collectionState)
+			if err != nil {
+				log.Error(ctx, "collection published check error", err, log.Data{"collection_id": *m.CollectionID})
+				return nil, err
+			}
+			if result, closed := closedRegistrationResult(state, files.RegistrationCollectionPublished, files.RegistrationCollectionWithdrawn); closed {
+				results[i].Result = result
+				continue
+			}
+		}
+		if m.BundleID != nil {
+			state, err := cachedStateCheck(ctx, bundleStates, *m.BundleID, store.bundleState)
+			if err != nil {
+				log.Error(ctx, "bundle published check error", err, log.Data{"bundle_id": *m.BundleID})
+				return nil, err
+			}
+			if result, closed := closedRegistrationResult(state, files.RegistrationBundlePublished, files.RegistrationBundleWithdrawn); closed {
+				results[i].Result = result
+				continue
+			}
+		}
+
+		registered[m.Path] = true
+		m.CreatedAt = now
+		m.LastModified = now
+		m.State = StateCreated
+		toInsert = append(toInsert, m)
+		resultIndexes = append(resultIndexes, i)
+	}
+
+	if len(toInsert) > 0 {
+		insertPaths := make([]string, 0, len(toInsert))
+		for _, m := range toInsert {
+			insertPaths = append(insertPaths, m.Path)
+		}
+		versions, err := store.nextFileVersions(ctx, insertPaths)
+		if err != nil {
+			return nil, err
+		}
+		for i := range toInsert {
+			toInsert[i].Version = versions[toInsert[i].Path]
+		}
+	}
+
+	outboxIDs := make([]string, 0, len(toInsert))
+	for _, m := range toInsert {
+		outboxID, err := store.enqueueFileLifecycle(ctx, m.Path, files.LifecycleRegistered, "", StateCreated)
+		if err != nil {
+			store.withdrawOutboxMessages(ctx, outboxIDs)
+			return nil, err
+		}
+		outboxIDs = append(outboxIDs, outboxID)
+	}
+
+	// a path registered since it was checked above stops the insert, so it is reported as a duplicate and the rest of
+	// the batch is inserted again. Any other error stops the batch: the files inserted before it are still registered
+	// and the rest are reported as failed, unless none were registered at all
+	created := 0
+	for inserted := 0; inserted < len(toInsert); {
+		n, err := store.repo.InsertManyMetadata(ctx, toInsert[inserted:])
+		store.releaseOutboxMessages(ctx, outboxIDs[inserted:inserted+n])
+		for _, m := range toInsert[inserted : inserted+n] {
+			store.recordFileChange(ctx, m, files.LifecycleRegistered, "", StateCreated)
+		}
+		for _, i := range resultIndexes[inserted : inserted+n] {
+			results[i].Result = files.RegistrationCreated
+		}
+		inserted += n
+		created += n
+		if err == nil {
+			break
+		}
+
+		if !mongo.IsDuplicateKeyError(err) {
+			log.Error(ctx, "failed to insert metadata", err, log.Data{"inserted": inserted, "files": len(toInsert)})
+			store.withdrawOutboxMessages(ctx, outboxIDs[inserted:])
+			if created == 0 {
+				return nil, err
+			}
+			for _, i := range resultIndexes[inserted:] {
+				results[i].Result = files.RegistrationFailed
+				results[i].Error = ErrBatchInsertFailed.Error()
+			}
+			break
+		}
+		results[resultIndexes[inserted]].Result = files.RegistrationDuplicate
+		store.withdrawOutboxMessages(ctx, outboxIDs[inserted:inserted+1])
+		inserted++
+	}
+
+	if err := store.registerBatchCollectionsAndBundles(ctx, toInsert, results, resultIndexes); err != nil {
+		return nil, err
+	}
+
+	log.Info(ctx, "registered batch of file uploads", log.Data{"files": len(metadata), "registered": created})
+	return results, nil
+}
+
+// registerBatchCollectionsAndBundles records each collection and bundle that a file in the batch was registered with
+func (store *Store) registerBatchCollectionsAndBundles(ctx context.Context, inserted []files.StoredRegisteredMetaData, results []files.RegistrationResult, resultIndexes []int) error {
+	collections := make(map[string]bool)
+	bundles := make(map[string]bool)
+	for j, m := range inserted {
+		if results[resultIndexes[j]].Result != files.RegistrationCreated {
+			continue
+		}
+
+		if m.CollectionID != nil && !collections[*m.CollectionID] {
+			collections[*m.CollectionID] = true
+			if err := store.registerCollection(ctx, *m.CollectionID); err != nil {
+				log.Error(ctx, "failed to register collection", err, log.Data{"collection_id": *m.CollectionID})
+				return err
+			}
+		}
+		if m.BundleID != nil && !bundles[*m.BundleID] {
+			bundles[*m.BundleID] = true
+			if err := store.registerBundle(ctx, *m.BundleID); err != nil {
+				log.Error(ctx, "failed to register bundle", err, log.Data{"bundle_id": *m.BundleID})
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// cachedStateCheck finds the publishing state of a collection or bundle once per batch
+func cachedStateCheck(ctx context.Context, checked map[string]string, id string, stateOf func(context.Context, string) (string, error)) (string, error) {
+	if state, ok := checked[id]; ok {
+		return state, nil
+	}
+
+	state, err := stateOf(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	checked[id] = state
+	return state, nil
+}
+
+// closedRegistrationResult gives the result for a file in a collection or bundle in the given state, reporting whether
+// it is closed to new files
+func closedRegistrationResult(state, published, withdrawn string) (string, bool) {
+	switch state {
+	case StatePublished:
+		return published, true
+	case StateWithdrawn:
+		return withdrawn, true
+	}
+	return "", false
+}
@@ -0,0 +1,166 @@
+package store_test
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ONSdigital/dp-files-api/config"
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/dp-files-api/mongo/mock"
+	"github.com/ONSdigital/dp-files-api/store"
+	mongodriver "github.com/ONSdigital/dp-mongodb/v3/mongodb"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func (suite *StoreSuite) TestRegisterFileUploadsReportsResultForEachFile() {
+	subject, repo := suite.lifecycleStore()
+	collectionID := "collection-1"
+	publishedCollectionID := "collection-2"
+	suite.NoError(repo.InsertMetadata(suite.defaultContext, files.StoredRegisteredMetaData{Path: "existing.csv", State: store.StateCreated}))
+	suite.NoError(repo.InsertCollection(suite.defaultContext, files.StoredCollection{ID: publishedCollectionID, State: store.StatePublished}))
+
+	results, err := subject.RegisterFileUploads(suite.defaultContext, []files.StoredRegisteredMetaData{
+		{Path: "new.csv", CollectionID: &collectionID, IsPublishable: true},
+		{Path: "existing.csv", CollectionID: &collectionID},
+		{Path: "new.csv", CollectionID: &collectionID},
+		{Path: "published.csv", CollectionID: &publishedCollectionID},
+		{Path: "other.csv", CollectionID: &collectionID},
+	})
+
+	suite.NoError(err)
+	suite.Equal([]files.RegistrationResult{
+		{Path: "new.csv", Result: files.RegistrationCreated},
+		{Path: "existing.csv", Result: files.RegistrationDuplicate},
+		{Path: "new.csv", Result: files.RegistrationDuplicate},
+		{Path: "published.csv", Result: files.RegistrationCollectionPublished},
+		{Path: "other.csv", Result: files.RegistrationCreated},
+	}, results)
+
+	registered, err := repo.GetMetadata(suite.defaultContext, "new.csv")
+	suite.NoError(err)
+	suite.Equal(store.StateCreated, registered.State)
+	suite.Equal(1, registered.Version)
+	suite.True(registered.IsPublishable)
+	suite.Equal(suite.defaultClock.GetCurrentTime(), registered.CreatedAt)
+
+	_, err = repo.GetMetadata(suite.defaultContext, "published.csv")
+	suite.ErrorIs(err, mongodriver.ErrNoDocumentFound)
+
+	collection, err := repo.GetCollection(suite.defaultContext, collectionID)
+	suite.NoError(err)
+	suite.Equal(store.StateCreated, collection.State)
+
+	events := suite.lifecycleEvents(repo)
+	suite.Require().Len(events, 2)
+	suite.Equal("new.csv", events[0].Path)
+	suite.Equal(files.LifecycleRegistered, events[0].Change)
+
+	changes, _, err := subject.GetFileChanges(suite.defaultContext, collectionID, "", "000000000000000000000000", 10)
+	suite.NoError(err)
+	suite.Len(changes, 2)
+}
+
+func (suite *StoreSuite) TestRegisterFileUploadsReportsPathRegisteredDuringInsertAsDuplicate() {
+	var inserted [][]interface{}
+	metadataColl := mock.MongoCollectionMock{
+		FindFunc: func(ctx context.Context, filter interface{}, results interface{}, opts ...mongodriver.FindOption) (int, error) {
+			return 0, nil
+		},
+		InsertManyFunc: func(ctx context.Context, documents []interface{}) (*mongodriver.CollectionInsertManyResult, error) {
+			inserted = append(inserted, documents)
+			if len(inserted) == 1 {
+				return nil, mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{
+					{WriteError: mongo.WriteError{Index: 1, Code: 11000, Message: "duplicate key"}},
+				}}
+			}
+			return &mongodriver.CollectionInsertManyResult{}, nil
+		},
+	}
+
+	cfg, _ := config.Get()
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &metadataColl, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
+
+	results, err := subject.RegisterFileUploads(suite.defaultContext, []files.StoredRegisteredMetaData{
+		{Path: "one.csv"}, {Path: "two.csv"}, {Path: "three.csv"},
+	})
+
+	suite.NoError(err)
+	suite.Equal([]files.RegistrationResult{
+		{Path: "one.csv", Result: files.RegistrationCreated},
+		{Path: "two.csv", Result: files.RegistrationDuplicate},
+		{Path: "three.csv", Result: files.RegistrationCreated},
+	}, results)
+	suite.Require().Len(inserted, 2)
+	suite.Len(inserted[0], 3)
+	suite.Len(inserted[1], 1)
+	suite.Equal("three.csv", inserted[1][0].(files.StoredRegisteredMetaData).Path)
+}
+
+func (suite *StoreSuite) TestRegisterFileUploadsInsertError() {
+	metadataColl := mock.MongoCollectionMock{
+		FindFunc: func(ctx context.Context, filter interface{}, results interface{}, opts ...mongodriver.FindOption) (int, error) {
+			return 0, nil
+		},
+		InsertManyFunc: func(ctx context.Context, documents []interface{}) (*mongodriver.CollectionInsertManyResult, error) {
+			return nil, errors.New("insert error")
+		},
+	}
+
+	cfg, _ := config.Get()
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &metadataColl, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
+
+	_, err := subject.RegisterFileUploads(suite.defaultContext, []files.StoredRegisteredMetaData{{Path: "one.csv"}})
+
+	suite.EqualError(err, "insert error")
+	suite.Len(suite.defaultOutboxCollection.DeleteCalls(), 1)
+}
+
+func (suite *StoreSuite) TestRegisterFileUploadsReportsFilesAfterPartialInsertErrorAsFailed() {
+	metadataColl := mock.MongoCollectionMock{
+		FindOneFunc: CollectionFindOneReturnsError(mongodriver.ErrNoDocumentFound),
+		FindFunc: func(ctx context.Context, filter interface{}, results interface{}, opts ...mongodriver.FindOption) (int, error) {
+			return 0, nil
+		},
+		InsertManyFunc: func(ctx context.Context, documents []interface{}) (*mongodriver.CollectionInsertManyResult, error) {
+			return nil, mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{
+				{WriteError: mongo.WriteError{Index: 1, Code: 9001, Message: "write conflict"}},
+			}}
+		},
+	}
+	collectionsColl := mock.MongoCollectionMock{
+		FindOneFunc: CollectionFindOneReturnsError(mongodriver.ErrNoDocumentFound),
+		InsertFunc:  CollectionInsertReturnsNilAndNil(),
+	}
+
+	cfg, _ := config.Get()
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &metadataColl, Collections: &collectionsColl, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
+
+	collectionID := "collection1"
+	results, err := subject.RegisterFileUploads(suite.defaultContext, []files.StoredRegisteredMetaData{
+		{Path: "one.csv", CollectionID: &collectionID}, {Path: "two.csv", CollectionID: &collectionID}, {Path: "three.csv", CollectionID: &collectionID},
+	})
+
+	suite.NoError(err)
+	suite.Equal([]files.RegistrationResult{
+		{Path: "one.csv", Result: files.RegistrationCreated},
+		{Path: "two.csv", Result: files.RegistrationFailed, Error: store.ErrBatchInsertFailed.Error()},
+		{Path: "three.csv", Result: files.RegistrationFailed, Error: store.ErrBatchInsertFailed.Error()},
+	}, results)
+	suite.Len(metadataColl.InsertManyCalls(), 1)
+	suite.Len(collectionsColl.InsertCalls(), 1)
+}
+
+func (suite *StoreSuite) TestCreateFileEventsSetsCreatedAt() {
+	subject, repo := suite.lifecycleStore()
+
+	err := subject.CreateFileEvents(suite.defaultContext, []*files.FileEvent{
+		{Action: files.ActionCreate, Resource: "one.csv"},
+		{Action: files.ActionCreate, Resource: "two.csv"},
+	})
+
+	suite.NoError(err)
+	events, err := repo.FindFileEvents(suite.defaultContext, store.FileEventFilter{}, false, 0, 10)
+	suite.NoError(err)
+	suite.Require().Len(events, 2)
+	suite.Equal(suite.defaultClock.GetCurrentTime(), events[0].CreatedAt.UTC())
+}
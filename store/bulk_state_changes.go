@@ -0,0 +1,176 @@
+package store
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// TransitionFileStates moves many files to new states at once, following the same rules as the single file state
+// changes, and returns the outcome for each transition in the order given. Up to FilesTransitionConcurrency
+// transitions are worked on at a time, and whether each collection is published is looked up once. Each transition is
+// only made while its file is still at the revision it was checked at, so a file changed in between fails with
+// ErrFileModified.
+//
+// With allOrNothing every transition is checked before any is made, and none are made if any check fails. If making one
+// fails, those already made are rolled back; only one whose file is changed again before it can be rolled back is left
+// made, and reported as applied.
+func (store *Store) TransitionFileStates(ctx context.Context, transitions []files.StateTransition, allOrNothing bool) []files.TransitionResult {
+	results := make([]files.TransitionResult, len(transitions))
+	changes := make([]*stateChange, len(transitions))
+	seen := make(map[string]bool)
+	for i, t := range transitions {
+		results[i] = files.TransitionResult{Path: t.Path, State: t.State}
+		if seen[t.Path] {
+			failTransition(&results[i], ErrDuplicateTransition)
+			continue
+		}
+		seen[t.Path] = true
+	}
+
+	published := &collectionsPublished{store: store, published: make(map[string]bool)}
+	store.forEachTransition(results, func(i int) {
+		change, err := store.checkFileTransition(ctx, transitions[i], published.isCollectionPublished)
+		if err != nil {
+			failTransition(&results[i], err)
+			return
+		}
+		if allOrNothing {
+			changes[i] = change
+			return
+		}
+		store.applyFileTransition(ctx, change, &results[i])
+	})
+
+	if allOrNothing {
+		store.applyAllFileTransitions(ctx, changes, results)
+	}
+
+	log.Info(ctx, "file state transitions complete", log.Data{"transitions": len(transitions), "all_or_nothing": allOrNothing})
+	return results
+}
+
+// forEachTransition calls fn with the index of each transition not already failed, up to FilesTransitionConcurrency at
+// a time, and waits for them all to finish
+func (store *Store) forEachTransition(results []files.TransitionResult, fn func(i int)) {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, max(store.cfg.FilesTransitionConcurrency, 1))
+	for i := range results {
+		if results[i].Result == files.TransitionFailed {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+func (store *Store) checkFileTransition(ctx context.Context, t files.StateTransition, isCollectionPublished func(context.Context, string) (bool, error)) (*stateChange, error) {
+	change := files.FileEtagChange{Path: t.Path, Etag: t.Etag}
+	switch t.State {
+	case StateUploaded:
+		return store.checkFileState(ctx, change, StateUploaded, StateCreated, fieldUploadCompletedAt, isCollectionPublished)
+	case StatePublished:
+		return store.checkFilePublished(ctx, t.Path)
+	case StateMoved:
+		return store.checkFileState(ctx, change, StateMoved, StatePublished, fieldMovedAt, isCollectionPublished)
+	default:
+		return nil, ErrInvalidStateChange
+	}
+}
+
+func (store *Store) applyFileTransition(ctx context.Context, change *stateChange, result *files.TransitionResult) {
+	outboxIDs, err := store.stageStateChange(ctx, change, store.updateMetadataIfUnchanged)
+	if err != nil {
+		failTransition(result, err)
+		return
+	}
+	store.commitStateChange(ctx, change, outboxIDs)
+	result.Result = files.TransitionApplied
+}
+
+// applyAllFileTransitions makes every checked transition, or none of them. The outbox messages of each transition are
+// held pending until every transition has been made, and if one fails those already made are rolled back instead.
+func (store *Store) applyAllFileTransitions(ctx context.Context, changes []*stateChange, results []files.TransitionResult) {
+	if anyTransitionFailed(results) {
+		markTransitionsNotApplied(results)
+		return
+	}
+
+	outboxIDs := make([][]string, len(changes))
+	store.forEachTransition(results, func(i int) {
+		ids, err := store.stageStateChange(ctx, changes[i], store.updateMetadataIfUnchanged)
+		if err != nil {
+			failTransition(&results[i], err)
+			return
+		}
+		outboxIDs[i] = ids
+		results[i].Result = files.TransitionApplied
+	})
+
+	rollBack := anyTransitionFailed(results)
+	store.forEachTransition(results, func(i int) {
+		if rollBack && store.rollBackStateChange(ctx, changes[i], outboxIDs[i]) == nil {
+			results[i].Result = files.TransitionNotApplied
+			return
+		}
+		store.commitStateChange(ctx, changes[i], outboxIDs[i])
+	})
+}
+
+func anyTransitionFailed(results []files.TransitionResult) bool {
+	for _, result := range results {
+		if result.Result == files.TransitionFailed {
+			return true
+		}
+	}
+	return false
+}
+
+func markTransitionsNotApplied(results []files.TransitionResult) {
+	for i := range results {
+		if results[i].Result != files.TransitionFailed {
+			results[i].Result = files.TransitionNotApplied
+		}
+	}
+}
+
+func failTransition(result *files.TransitionResult, err error) {
+	result.Result = files.TransitionFailed
+	result.Error = err.Error()
+}
+
+// collectionsPublished remembers whether each collection is published for the length of a bulk transition
+type collectionsPublished struct {
+	store     *Store
+	mu        sync.Mutex
+	published map[string]bool
+}
+
+// isCollectionPublished looks the collection up without holding the lock, so that transitions in other collections are
+// not held up; transitions in the same collection worked on at the same time may each look it up
+func (c *collectionsPublished) isCollectionPublished(ctx context.Context, collectionID string) (bool, error) {
+	c.mu.Lock()
+	published, ok := c.published[collectionID]
+	c.mu.Unlock()
+	if ok {
+		return published, nil
+	}
+
+	published, err := c.store.IsCollectionPublished(ctx, collectionID)
+	if err != nil {
+		return false, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.published[collectionID] = published
+	return published, nil
+}
@@ -0,0 +1,202 @@
+package store_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	s3Mock "github.com/ONSdigital/dp-files-api/aws/mock"
+	"github.com/ONSdigital/dp-files-api/config"
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/dp-files-api/store"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+func (suite *StoreSuite) transitionStore(concurrency int, head func()) (*store.Store, *store.MemoryRepository) {
+	repo := store.NewMemoryRepository()
+	collectionID := testCollectionID
+	for _, m := range []files.StoredRegisteredMetaData{
+		{Path: "a.csv", State: store.StatePublished, Etag: testEtag, CollectionID: &collectionID, IsPublishable: true},
+		{Path: "b.csv", State: store.StatePublished, Etag: testEtag, CollectionID: &collectionID, IsPublishable: true},
+		{Path: "c.csv", State: store.StateCreated, IsPublishable: true},
+		{Path: "d.csv", State: store.StateUploaded, Etag: testEtag, IsPublishable: true},
+	} {
+		suite.NoError(repo.InsertMetadata(suite.defaultContext, m))
+	}
+	return suite.transitionStoreOn(repo, concurrency, head), repo
+}
+
+// transitionStoreOn is a store for file state transitions on repo, calling head whenever S3 is asked about a file
+func (suite *StoreSuite) transitionStoreOn(repo store.Repository, concurrency int, head func()) *store.Store {
+	s3Client := &s3Mock.S3ClienterMock{
+		HeadFunc: func(ctx context.Context, key string) (*s3.HeadObjectOutput, error) {
+			head()
+			etag := `"` + testEtag + `"`
+			return &s3.HeadObjectOutput{ETag: &etag}, nil
+		},
+	}
+
+	defaultCfg, _ := config.Get()
+	cfg := *defaultCfg
+	cfg.FilesTransitionConcurrency = concurrency
+	return store.NewStore(repo, suite.defaultClock, s3Client, &cfg)
+}
+
+// conditionalUpdateFailsRepository fails the conditional updates of the metadata at one path
+type conditionalUpdateFailsRepository struct {
+	*store.MemoryRepository
+	path string
+}
+
+func (r conditionalUpdateFailsRepository) UpdateMetadataIfUnchanged(ctx context.Context, path string, revision int64, createdAt time.Time, update store.Update) (bool, error) {
+	if path == r.path {
+		return false, errors.New("update failed")
+	}
+	return r.MemoryRepository.UpdateMetadataIfUnchanged(ctx, path, revision, createdAt, update)
+}
+
+func (suite *StoreSuite) fileState(repo *store.MemoryRepository, path string) string {
+	m, err := repo.GetMetadata(suite.defaultContext, path)
+	suite.Require().NoError(err)
+	return m.State
+}
+
+func (suite *StoreSuite) TestTransitionFileStatesReportsOutcomeForEachPath() {
+	subject, repo := suite.transitionStore(10, func() {})
+
+	results := subject.TransitionFileStates(suite.defaultContext, []files.StateTransition{
+		{Path: "a.csv", State: store.StateMoved, Etag: testEtag},
+		{Path: "c.csv", State: store.StateMoved, Etag: testEtag},
+		{Path: "missing.csv", State: store.StateMoved},
+		{Path: "a.csv", State: store.StateMoved, Etag: testEtag},
+		{Path: "b.csv", State: "DELETED"},
+		{Path: "c.csv", State: store.StateUploaded, Etag: testEtag},
+		{Path: "d.csv", State: store.StatePublished},
+	}, false)
+
+	suite.Equal([]files.TransitionResult{
+		{Path: "a.csv", State: store.StateMoved, Result: files.TransitionApplied},
+		{Path: "c.csv", State: store.StateMoved, Result: files.TransitionFailed, Error: store.ErrFileStateMismatch.Error()},
+		{Path: "missing.csv", State: store.StateMoved, Result: files.TransitionFailed, Error: store.ErrFileNotRegistered.Error()},
+		{Path: "a.csv", State: store.StateMoved, Result: files.TransitionFailed, Error: store.ErrDuplicateTransition.Error()},
+		{Path: "b.csv", State: "DELETED", Result: files.TransitionFailed, Error: store.ErrInvalidStateChange.Error()},
+		{Path: "c.csv", State: store.StateUploaded, Result: files.TransitionFailed, Error: store.ErrDuplicateTransition.Error()},
+		{Path: "d.csv", State: store.StatePublished, Result: files.TransitionApplied},
+	}, results)
+
+	suite.Equal(store.StateMoved, suite.fileState(repo, "a.csv"))
+	suite.Equal(store.StatePublished, suite.fileState(repo, "b.csv"))
+	suite.Equal(store.StateCreated, suite.fileState(repo, "c.csv"))
+	suite.Equal(store.StatePublished, suite.fileState(repo, "d.csv"))
+}
+
+func (suite *StoreSuite) TestTransitionFileStatesAllOrNothingMakesNoChangeWhenOneFails() {
+	subject, repo := suite.transitionStore(10, func() {})
+
+	results := subject.TransitionFileStates(suite.defaultContext, []files.StateTransition{
+		{Path: "a.csv", State: store.StateMoved, Etag: testEtag},
+		{Path: "c.csv", State: store.StateMoved, Etag: testEtag},
+	}, true)
+
+	suite.Equal(files.TransitionNotApplied, results[0].Result)
+	suite.Equal(files.TransitionFailed, results[1].Result)
+	suite.Equal(store.StatePublished, suite.fileState(repo, "a.csv"))
+	suite.Empty(suite.lifecycleEvents(repo))
+}
+
+func (suite *StoreSuite) TestTransitionFileStatesAllOrNothingAppliesWhenAllPass() {
+	subject, repo := suite.transitionStore(10, func() {})
+
+	results := subject.TransitionFileStates(suite.defaultContext, []files.StateTransition{
+		{Path: "a.csv", State: store.StateMoved, Etag: testEtag},
+		{Path: "b.csv", State: store.StateMoved, Etag: testEtag},
+		{Path: "c.csv", State: store.StateUploaded, Etag: testEtag},
+	}, true)
+
+	for _, result := range results {
+		suite.Equal(files.TransitionApplied, result.Result, result.Path)
+	}
+	suite.Equal(store.StateMoved, suite.fileState(repo, "a.csv"))
+	suite.Equal(store.StateMoved, suite.fileState(repo, "b.csv"))
+	suite.Equal(store.StateUploaded, suite.fileState(repo, "c.csv"))
+	suite.Len(suite.lifecycleEvents(repo), 3)
+}
+
+func (suite *StoreSuite) TestTransitionFileStatesFailsFileChangedSinceItWasChecked() {
+	var repo *store.MemoryRepository
+	subject, repo := suite.transitionStore(1, func() {
+		// the file is changed after it has been checked, while S3 is asked about it
+		suite.NoError(repo.UpdateMetadata(suite.defaultContext, "a.csv", store.Update{Set: []store.Field{{Key: "is_publishable", Value: false}}}))
+	})
+
+	results := subject.TransitionFileStates(suite.defaultContext, []files.StateTransition{
+		{Path: "a.csv", State: store.StateMoved, Etag: testEtag},
+	}, false)
+
+	suite.Equal(files.TransitionFailed, results[0].Result)
+	suite.Equal(store.ErrFileModified.Error(), results[0].Error)
+	suite.Equal(store.StatePublished, suite.fileState(repo, "a.csv"))
+	suite.Empty(suite.lifecycleEvents(repo))
+}
+
+func (suite *StoreSuite) TestTransitionFileStatesAllOrNothingRollsBackWhenOneCannotBeMade() {
+	repo := store.NewMemoryRepository()
+	for _, m := range []files.StoredRegisteredMetaData{
+		{Path: "a.csv", State: store.StateCreated, Etag: "old"},
+		{Path: "b.csv", State: store.StateCreated},
+	} {
+		suite.NoError(repo.InsertMetadata(suite.defaultContext, m))
+	}
+	subject := suite.transitionStoreOn(conditionalUpdateFailsRepository{repo, "b.csv"}, 10, func() {})
+
+	results := subject.TransitionFileStates(suite.defaultContext, []files.StateTransition{
+		{Path: "a.csv", State: store.StateUploaded, Etag: testEtag},
+		{Path: "b.csv", State: store.StateUploaded, Etag: testEtag},
+	}, true)
+
+	suite.Equal(files.TransitionNotApplied, results[0].Result)
+	suite.Equal(files.TransitionFailed, results[1].Result)
+	a, err := repo.GetMetadata(suite.defaultContext, "a.csv")
+	suite.NoError(err)
+	suite.Equal(store.StateCreated, a.State)
+	suite.Equal("old", a.Etag)
+	suite.Nil(a.UploadCompletedAt)
+	suite.Empty(suite.lifecycleEvents(repo))
+	pending, err := repo.FindStalePendingOutboxMessages(suite.defaultContext, suite.defaultClock.GetCurrentTime().Add(time.Hour), 10)
+	suite.NoError(err)
+	suite.Empty(pending)
+}
+
+func (suite *StoreSuite) TestTransitionFileStatesBoundsConcurrency() {
+	var (
+		mu       sync.Mutex
+		inFlight int
+		most     int
+	)
+	subject, repo := suite.transitionStore(2, func() {
+		mu.Lock()
+		inFlight++
+		most = max(most, inFlight)
+		mu.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+	})
+
+	var transitions []files.StateTransition
+	for _, path := range []string{"e.csv", "f.csv", "g.csv", "h.csv", "i.csv"} {
+		suite.NoError(repo.InsertMetadata(suite.defaultContext, files.StoredRegisteredMetaData{Path: path, State: store.StatePublished, Etag: testEtag}))
+		transitions = append(transitions, files.StateTransition{Path: path, State: store.StateMoved, Etag: testEtag})
+	}
+
+	results := subject.TransitionFileStates(suite.defaultContext, transitions, false)
+
+	for _, result := range results {
+		suite.Equal(files.TransitionApplied, result.Result, result.Path)
+	}
+	suite.Equal(2, most)
+}
@@ -4,16 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"math"
-	"strconv"
-	"sync"
+	"time"
 
 	"github.com/ONSdigital/dp-files-api/config"
 	"github.com/ONSdigital/dp-files-api/files"
 	mongodriver "github.com/ONSdigital/dp-mongodb/v3/mongodb"
 	"github.com/ONSdigital/dp-net/v3/request"
 	"github.com/ONSdigital/log.go/v2/log"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
@@ -40,14 +37,22 @@ func (store *Store) MarkBundlePublished(ctx context.Context, bundleID string) er
 		return ErrFileNotInUploadedState
 	}
 
-	err = store.updateBundleState(ctx, bundleID, StatePublished)
+	// the publish job is recorded before the state change so that a published bundle always has a job to resume;
+	// if the state change fails the job is withdrawn again, and if the service stops first ResumePublishJobs deletes it
+	job, err := store.CreateBundlePublishJob(ctx, bundleID)
 	if err != nil {
 		return err
 	}
 
+	err = store.updateBundleState(ctx, bundleID, StatePublished, *job.PublishedAt)
+	if err != nil {
+		store.deletePublishJob(ctx, job)
+		return err
+	}
+
 	requestID := request.GetRequestId(ctx)
 	newCtx := request.WithRequestId(context.Background(), requestID)
-	go store.NotifyBundlePublished(newCtx, bundleID)
+	go store.RunPublishJob(newCtx, job)
 
 	return nil
 }
@@ -61,11 +66,10 @@ func (store *Store) IsBundleUploaded(ctx context.Context, bundleID string) (bool
 		return false, nil
 	}
 
-	metadata := files.StoredRegisteredMetaData{}
-	err = store.metadataCollection.FindOne(ctx, bson.M{"$and": []bson.M{
-		{fieldBundleID: bundleID},
-		{fieldState: bson.M{"$ne": StateUploaded}},
-	}}, &metadata)
+	_, err = store.repo.FindOneMetadata(ctx, MetadataFilter{
+		BundleID:      &bundleID,
+		ExcludeStates: []string{StateUploaded},
+	})
 	if err != nil {
 		if errors.Is(err, mongodriver.ErrNoDocumentFound) {
 			return true, nil
@@ -77,44 +81,63 @@ func (store *Store) IsBundleUploaded(ctx context.Context, bundleID string) (bool
 }
 
 func (store *Store) IsBundlePublished(ctx context.Context, bundleID string) (bool, error) {
+	state, err := store.bundleState(ctx, bundleID)
+	if err != nil {
+		return false, err
+	}
+	return state == StatePublished, nil
+}
+
+// bundleState gives the publishing state of the bundle, which is empty until it has been published
+func (store *Store) bundleState(ctx context.Context, bundleID string) (string, error) {
 	bundle, err := store.GetBundlePublishedMetadata(ctx, bundleID)
 	if err != nil {
 		// If there's no record of bundle being published in bundles DB, fall back
 		// to the older method that checks the file statuses (if all files in the bundle are marked
 		// as published, we consider the bundle published).
 		if errors.Is(err, ErrBundleMetadataNotRegistered) {
-			return store.AreAllBundleFilesPublished(ctx, bundleID)
+			published, err := store.AreAllBundleFilesPublished(ctx, bundleID)
+			if err != nil || !published {
+				return "", err
+			}
+			return StatePublished, nil
 		}
 		// we've hit an unexpected error
-		return false, fmt.Errorf("bundle published check: %w", err)
+		return "", fmt.Errorf("bundle published check: %w", err)
+	}
+	return bundle.State, nil
+}
+
+// checkBundleOpen returns ErrBundleAlreadyPublished or ErrBundleWithdrawn when files can no longer be added to the
+// bundle
+func (store *Store) checkBundleOpen(ctx context.Context, bundleID string) error {
+	state, err := store.bundleState(ctx, bundleID)
+	if err != nil {
+		return err
 	}
-	if bundle.State == StatePublished {
-		return true, nil
+	switch state {
+	case StatePublished:
+		return ErrBundleAlreadyPublished
+	case StateWithdrawn:
+		return ErrBundleWithdrawn
 	}
-	return false, nil
+	return nil
 }
 
-func (store *Store) updateBundleState(ctx context.Context, bundleID, state string) error {
+func (store *Store) updateBundleState(ctx context.Context, bundleID, state string, now time.Time) error {
 	logdata := log.Data{"bundle_id": bundleID, "state": state}
 
-	now := store.clock.GetCurrentTime()
-
-	fields := bson.D{
+	fields := []Field{
 		{Key: fieldState, Value: state},
 		{Key: fieldLastModified, Value: now},
 	}
 
 	// TODO: uncomment when PublishedAt is added to StoredBundle struct
 	// if state == StatePublished {
-	// 	fields = append(fields, bson.E{Key: fieldPublishedAt, Value: now})
+	// 	fields = append(fields, Field{Key: fieldPublishedAt, Value: now})
 	// }
 
-	_, err := store.bundlesCollection.Upsert(
-		ctx,
-		bson.M{fieldID: bundleID},
-		bson.D{
-			{Key: "$set", Value: fields},
-		})
+	err := store.repo.UpsertBundle(ctx, bundleID, fields)
 	if err != nil {
 		log.Error(ctx, fmt.Sprintf("failed to change bundle %v to %s state", bundleID, state), err, logdata)
 		return err
@@ -123,8 +146,7 @@ func (store *Store) updateBundleState(ctx context.Context, bundleID, state strin
 }
 
 func (store *Store) GetBundlePublishedMetadata(ctx context.Context, id string) (files.StoredBundle, error) {
-	bundle := files.StoredBundle{}
-	err := store.bundlesCollection.FindOne(ctx, bson.M{fieldID: id}, &bundle)
+	bundle, err := store.repo.GetBundle(ctx, id)
 	if err != nil {
 		if errors.Is(err, mongodriver.ErrNoDocumentFound) {
 			return files.StoredBundle{}, ErrBundleMetadataNotRegistered
@@ -144,12 +166,10 @@ func (store *Store) AreAllBundleFilesPublished(ctx context.Context, bundleID str
 		return false, nil
 	}
 
-	metadata := files.StoredRegisteredMetaData{}
-	err = store.metadataCollection.FindOne(ctx, bson.M{"$and": []bson.M{
-		{fieldBundleID: bundleID},
-		{fieldState: bson.M{"$ne": StatePublished}},
-		{fieldState: bson.M{"$ne": StateMoved}},
-	}}, &metadata)
+	_, err = store.repo.FindOneMetadata(ctx, MetadataFilter{
+		BundleID:      &bundleID,
+		ExcludeStates: []string{StatePublished, StateMoved},
+	})
 	if err != nil {
 		if errors.Is(err, mongodriver.ErrNoDocumentFound) {
 			return true, nil
@@ -160,9 +180,7 @@ func (store *Store) AreAllBundleFilesPublished(ctx context.Context, bundleID str
 }
 
 func (store *Store) IsBundleEmpty(ctx context.Context, bundleID string) (bool, error) {
-	metadata := files.StoredRegisteredMetaData{}
-
-	err := store.metadataCollection.FindOne(ctx, bson.M{fieldBundleID: bundleID}, &metadata)
+	_, err := store.repo.FindOneMetadata(ctx, MetadataFilter{BundleID: &bundleID})
 	if err != nil {
 		if errors.Is(err, mongodriver.ErrNoDocumentFound) {
 			return true, nil
@@ -181,7 +199,7 @@ func (store *Store) registerBundle(ctx context.Context, bundleID string) error {
 		State:        StateCreated,
 		LastModified: now,
 	}
-	if _, err := store.bundlesCollection.Insert(ctx, bundle); err != nil {
+	if err := store.repo.InsertBundle(ctx, bundle); err != nil {
 		if mongo.IsDuplicateKeyError(err) {
 			log.Info(ctx, "bundle already registered", logdata)
 			return nil
@@ -193,10 +211,10 @@ func (store *Store) registerBundle(ctx context.Context, bundleID string) error {
 }
 
 func (store *Store) UpdateBundleID(ctx context.Context, path, bundleID string) error {
-	metadata := files.StoredRegisteredMetaData{}
 	logdata := log.Data{"path": path}
 
-	if err := store.metadataCollection.FindOne(ctx, bson.M{"path": path}, &metadata); err != nil {
+	metadata, err := store.repo.GetMetadata(ctx, path)
+	if err != nil {
 		if errors.Is(err, mongodriver.ErrNoDocumentFound) {
 			log.Error(ctx, "update bundle ID: attempted to operate on unregistered file", err, logdata)
 			return ErrFileNotRegistered
@@ -210,119 +228,37 @@ func (store *Store) UpdateBundleID(ctx context.Context, path, bundleID string) e
 		return ErrFileMoved
 	}
 
+	if err = checkIfMatch(ctx, metadata); err != nil {
+		return err
+	}
+
 	if bundleID == "" {
 		if metadata.BundleID == nil {
 			return nil
 		}
 
-		_, err := store.metadataCollection.Update(
-			ctx,
-			bson.M{"path": path},
-			bson.D{
-				{Key: "$unset", Value: bson.D{
-					{Key: "bundle_id", Value: ""},
-				}},
-			})
-
+		err = store.updateMetadata(ctx, metadata, Update{Unset: []string{fieldBundleID}})
 		if err != nil {
 			log.Error(ctx, "failed to remove bundle ID", err, logdata)
 		}
 		return err
 	}
 
-	// check to see if bundleID exists and is not-published
-	published, err := store.IsBundlePublished(ctx, bundleID)
-	if err != nil {
+	// check to see if bundleID exists and is neither published nor withdrawn
+	err = store.checkBundleOpen(ctx, bundleID)
+	switch {
+	case errors.Is(err, ErrBundleAlreadyPublished):
+		log.Error(ctx, fmt.Sprintf("bundle with id [%s] is already published", bundleID), err, logdata)
+	case errors.Is(err, ErrBundleWithdrawn):
+		log.Error(ctx, fmt.Sprintf("bundle with id [%s] is withdrawn", bundleID), err, logdata)
+	case err != nil:
 		log.Error(ctx, "update bundle ID: caught db error", err, logdata)
-		return err
-	}
-	if published {
-		log.Error(ctx, fmt.Sprintf("bundle with id [%s] is already published", bundleID), ErrBundleAlreadyPublished, logdata)
-		return ErrBundleAlreadyPublished
-	}
-
-	_, err = store.metadataCollection.Update(
-		ctx,
-		bson.M{"path": path},
-		bson.D{
-			{Key: "$set", Value: bson.D{
-				{Key: "bundle_id", Value: bundleID}},
-			},
-		})
-
-	return err
-}
-
-func (store *Store) NotifyBundlePublished(ctx context.Context, bundleID string) {
-	// ignoring err as this would have been done previously
-	totalCount, _ := store.metadataCollection.Count(ctx, bson.M{fieldBundleID: bundleID})
-	log.Info(ctx, "notify bundle published start", log.Data{"bundle_id": bundleID, "total_files": totalCount})
-	// balance the number of batches Vs batch size
-	batchSize := store.cfg.MinBatchSize
-	numBatches := int(math.Ceil(float64(totalCount) / float64(batchSize)))
-	if numBatches > store.cfg.MaxNumBatches {
-		numBatches = store.cfg.MaxNumBatches
-		batchSize = int(math.Ceil(float64(totalCount) / float64(numBatches)))
-	}
-
-	var wg sync.WaitGroup
-	wg.Add(numBatches)
-	for i := 0; i < numBatches; i++ {
-		offset := i * batchSize
-		cursor, err := store.metadataCollection.FindCursor(ctx, bson.M{fieldBundleID: bundleID}, mongodriver.Offset(offset))
-		if err != nil {
-			wg.Done()
-			log.Error(ctx, "BatchSendKafkaMessages: failed to query collection", err, log.Data{"bundle_id": bundleID})
-			continue
-		}
-		go store.BatchSendBundleKafkaMessages(ctx, cursor, &wg, bundleID, offset, batchSize, i)
 	}
-	wg.Wait()
-
-	log.Info(ctx, "notify bundle published end", log.Data{"bundle_id": bundleID})
-}
-
-func (store *Store) BatchSendBundleKafkaMessages(
-	ctx context.Context,
-	cursor mongodriver.Cursor,
-	wg *sync.WaitGroup,
-	bundleID string,
-	offset,
-	batchSize,
-	batchNum int,
-) {
-	defer wg.Done()
-	ld := log.Data{"bundle_id": bundleID, "offset": offset, "batch_size": batchSize, "batch_num": batchNum}
-	log.Info(ctx, "BatchSendBundleKafkaMessages", ld)
-	defer func() {
-		if err := cursor.Close(ctx); err != nil {
-			log.Error(ctx, "BatchSendBundleKafkaMessages: failed to close cursor", err, ld)
-		}
-	}()
-
-	for i := 0; i < batchSize; i++ {
-		if cursor.Next(ctx) {
-			var m files.StoredRegisteredMetaData
-			if err := cursor.Decode(&m); err != nil {
-				log.Error(ctx, "BatchSendBundleKafkaMessages: failed to decode cursor", err, ld)
-				continue
-			}
-			fp := &files.FilePublished{
-				Path:        m.Path,
-				Type:        m.Type,
-				Etag:        m.Etag,
-				SizeInBytes: strconv.FormatUint(m.SizeInBytes, 10),
-			}
-			if err := store.kafka.Send(files.AvroSchema, fp); err != nil {
-				log.Error(ctx, "BatchSendBundleKafkaMessages: can't send message to kafka", err, log.Data{"metadata": m})
-			}
-		} else {
-			break
-		}
-	}
-	if err := cursor.Err(); err != nil {
-		log.Error(ctx, "BatchSendBundleKafkaMessages: cursor error", err, ld)
+	if err != nil {
+		return err
 	}
 
-	log.Info(ctx, "BatchSendBundleKafkaMessages end", ld)
+	return store.updateMetadata(ctx, metadata, Update{Set: []Field{
+		{Key: fieldBundleID, Value: bundleID},
+	}})
 }
@@ -4,16 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"math"
-	"strconv"
 	"time"
 
 	"github.com/ONSdigital/dp-files-api/config"
 	"github.com/ONSdigital/dp-files-api/files"
 	"github.com/ONSdigital/dp-files-api/mongo/mock"
 	"github.com/ONSdigital/dp-files-api/store"
-	"github.com/ONSdigital/dp-kafka/v3/avro"
-	"github.com/ONSdigital/dp-kafka/v3/kafkatest"
 	mongodriver "github.com/ONSdigital/dp-mongodb/v3/mongodb"
 	"go.mongodb.org/mongo-driver/bson"
 )
@@ -29,7 +25,7 @@ func (suite *StoreSuite) TestMarkBundlePublishedBundleEmptyCheckReturnsError() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&bundleCountReturnsError, nil, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &bundleCountReturnsError, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	err := subject.MarkBundlePublished(suite.defaultContext, suite.defaultBundleID)
 
@@ -49,7 +45,7 @@ func (suite *StoreSuite) TestMarkBundlePublishedBundleEmpty() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&bundleCountReturnsError, nil, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &bundleCountReturnsError, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	err := subject.MarkBundlePublished(suite.defaultContext, suite.defaultBundleID)
 
@@ -72,7 +68,7 @@ func (suite *StoreSuite) TestMarkBundlePublishedWhenFileExistsInStateOtherThanUp
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collection, nil, &bundleCollection, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collection, Bundles: &bundleCollection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	err := subject.MarkBundlePublished(suite.defaultContext, suite.defaultBundleID)
 
@@ -100,7 +96,7 @@ func (suite *StoreSuite) TestMarkBundlePublishedBundleUploadedCheckReturnsError(
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionCountReturnsError, nil, &bundleCollection, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionCountReturnsError, Bundles: &bundleCollection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	err := subject.MarkBundlePublished(suite.defaultContext, suite.defaultBundleID)
 
@@ -136,7 +132,7 @@ func (suite *StoreSuite) TestMarkBundlePublishedBundlePublishedCheckReturnsTrue(
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionCountReturnsError, nil, &bundleCollection, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionCountReturnsError, Bundles: &bundleCollection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	err := subject.MarkBundlePublished(suite.defaultContext, suite.defaultBundleID)
 
@@ -157,6 +153,7 @@ func (suite *StoreSuite) TestMarkBundlePublishedPersistenceFailure() {
 			{CollectionFindOneSucceeds(), 1},                                   // there are some files in the bundle
 			{CollectionFindOneReturnsError(mongodriver.ErrNoDocumentFound), 2}, // all of them are UPLOADED
 		}),
+		CountFunc: CollectionCountReturnsValueAndNil(1),
 	}
 	bundleColl := mock.MongoCollectionMock{
 		UpsertFunc: func(ctx context.Context, selector, update interface{}) (*mongodriver.CollectionUpdateResult, error) {
@@ -164,8 +161,9 @@ func (suite *StoreSuite) TestMarkBundlePublishedPersistenceFailure() {
 		},
 		FindOneFunc: BundleFindOneSucceeds(), // bundle is not PUBLISHED
 	}
+
 	cfg, _ := config.Get()
-	subject := store.NewStore(&metadataColl, nil, &bundleColl, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &metadataColl, Bundles: &bundleColl, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	err := subject.MarkBundlePublished(suite.defaultContext, suite.defaultBundleID)
 
@@ -174,6 +172,8 @@ func (suite *StoreSuite) TestMarkBundlePublishedPersistenceFailure() {
 	suite.Equal("failed to change bundle 789 to PUBLISHED state", logEvent)
 	suite.Error(err)
 	suite.ErrorIs(err, expectedError)
+	suite.Len(suite.defaultPublishJobsCollection.InsertCalls(), 1)
+	suite.Len(suite.defaultPublishJobsCollection.DeleteCalls(), 1)
 }
 
 func (suite *StoreSuite) TestMarkBundlePublishedFindCalled() {
@@ -194,199 +194,22 @@ func (suite *StoreSuite) TestMarkBundlePublishedFindCalled() {
 		FindOneFunc: BundleFindOneSucceeds(), // bundle is not PUBLISHED
 	}
 
-	cfg, _ := config.Get()
-	subject := store.NewStore(&metadataColl, nil, &bundleColl, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
-
-	err := subject.MarkBundlePublished(suite.defaultContext, suite.defaultBundleID)
-
-	suite.NoError(err)
-	suite.Eventually(func() bool {
-		return len(metadataColl.FindCursorCalls()) == 1
-	}, time.Second, 10*time.Millisecond)
-}
-
-func (suite *StoreSuite) TestNotifyBundlePublishedFindErrored() {
-	expectedError := errors.New("an error occurred")
-
-	collection := mock.MongoCollectionMock{
-		CountFunc:      CollectionCountReturnsOneNilWhenFilterContainsAndOrZeroNilWithout(),
-		UpdateManyFunc: CollectionUpdateManyReturnsNilAndNil(),
-		FindCursorFunc: CollectionFindCursorReturnsCursorAndError(nil, expectedError),
+	publishJobs := mock.MongoCollectionMock{
+		InsertFunc: CollectionInsertReturnsNilAndNil(),
+		UpdateFunc: CollectionUpdateReturnsNilAndNil(),
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collection, nil, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &metadataColl, Bundles: &bundleColl, Outbox: &suite.defaultOutboxCollection, PublishJobs: &publishJobs, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
-	subject.NotifyBundlePublished(suite.defaultContext, suite.defaultBundleID)
+	err := subject.MarkBundlePublished(suite.defaultContext, suite.defaultBundleID)
 
+	suite.NoError(err)
 	suite.Eventually(func() bool {
-		return len(collection.FindCursorCalls()) == 1
+		// the job is marked as failed once the query error has been handled
+		return len(publishJobs.UpdateCalls()) == 1
 	}, time.Second, 10*time.Millisecond)
-}
-
-func (suite *StoreSuite) TestNotifyBundlePublishedPersistenceSuccess() {
-	metadata := suite.generateBundleMetadata(suite.defaultBundleID)
-	metadataBytes, _ := bson.Marshal(metadata)
-
-	cursor := mock.MongoCursorMock{
-		CloseFunc: func(ctx context.Context) error { return nil },
-		NextFunc:  CursorReturnsNumberOfNext(5),
-		DecodeFunc: func(val interface{}) error {
-			return bson.Unmarshal(metadataBytes, val)
-		},
-		ErrFunc: func() error { return nil },
-	}
-
-	collection := mock.MongoCollectionMock{
-		CountFunc:      CollectionCountReturnsOneNilWhenFilterContainsAndOrZeroNilWithout(),
-		UpdateManyFunc: CollectionUpdateManyReturnsNilAndNil(),
-		FindCursorFunc: CollectionFindCursorReturnsCursorAndError(&cursor, nil),
-	}
-
-	kafkaMock := kafkatest.IProducerMock{
-		SendFunc: func(schema *avro.Schema, event interface{}) error {
-			filePublished := event.(*files.FilePublished)
-
-			suite.Equal(metadata.Path, filePublished.Path)
-			suite.Equal(metadata.Etag, filePublished.Etag)
-			suite.Equal(metadata.Type, filePublished.Type)
-			suite.Equal(strconv.FormatUint(metadata.SizeInBytes, 10), filePublished.SizeInBytes)
-
-			return nil
-		},
-	}
-
-	cfg, _ := config.Get()
-	subject := store.NewStore(&collection, nil, nil, nil, &kafkaMock, suite.defaultClock, nil, cfg)
-
-	subject.NotifyBundlePublished(suite.defaultContext, suite.defaultBundleID)
-
-	suite.Equal(6, len(cursor.NextCalls()))
-	suite.Equal(5, len(cursor.DecodeCalls()))
-	suite.Equal(5, len(kafkaMock.SendCalls()))
-	suite.Equal(1, len(cursor.ErrCalls()))
-	suite.Equal(1, len(cursor.CloseCalls()))
-}
-
-func (suite *StoreSuite) TestBatchingWithLargeNumberOfFilesBundle() {
-	suite.logInterceptor.Start()
-	defer suite.logInterceptor.Stop()
-	numFiles := 5000
-	cfg, _ := config.Get()
-	expectedBatchSize := int(math.Ceil(float64(numFiles) / float64(cfg.MaxNumBatches)))
-
-	metadata := suite.generateBundleMetadata(suite.defaultBundleID)
-	metadataBytes, _ := bson.Marshal(metadata)
-
-	cursor := mock.MongoCursorMock{
-		CloseFunc: func(ctx context.Context) error { return nil },
-		NextFunc:  CursorReturnsNumberOfNext(numFiles),
-		DecodeFunc: func(val interface{}) error {
-			return bson.Unmarshal(metadataBytes, val)
-		},
-		ErrFunc: func() error { return nil },
-	}
-
-	collection := mock.MongoCollectionMock{
-		CountFunc:      CollectionCountReturnsValueAndNil(numFiles),
-		UpdateManyFunc: CollectionUpdateManyReturnsNilAndNil(),
-		FindCursorFunc: CollectionFindCursorReturnsCursorAndError(&cursor, nil),
-	}
-
-	kafkaMock := kafkatest.IProducerMock{
-		SendFunc: func(schema *avro.Schema, event interface{}) error {
-			filePublished := event.(*files.FilePublished)
-
-			suite.Equal(metadata.Path, filePublished.Path)
-			suite.Equal(metadata.Etag, filePublished.Etag)
-			suite.Equal(metadata.Type, filePublished.Type)
-			suite.Equal(strconv.FormatUint(metadata.SizeInBytes, 10), filePublished.SizeInBytes)
-
-			return nil
-		},
-	}
-
-	subject := store.NewStore(&collection, nil, nil, nil, &kafkaMock, suite.defaultClock, nil, cfg)
-
-	subject.NotifyBundlePublished(suite.defaultContext, suite.defaultBundleID)
-
-	evts := suite.logInterceptor.GetLogEvents("BatchSendBundleKafkaMessages")
-
-	for _, evt := range evts {
-		suite.EqualValues(evt["batch_size"].(float64), expectedBatchSize)
-	}
-	// make sure correct number of messages are sent
-	suite.Equal(len(kafkaMock.SendCalls()), numFiles)
-	suite.Equal(cfg.MaxNumBatches, len(evts))
-	suite.Equal(cfg.MaxNumBatches, len(cursor.ErrCalls()))
-	suite.Equal(cfg.MaxNumBatches, len(cursor.CloseCalls()))
-}
-
-func (suite *StoreSuite) TestNotifyBundlePublishedKafkaErrorDoesNotFailOperation() {
-	metadata := suite.generateBundleMetadata(suite.defaultBundleID)
-	metadataBytes, _ := bson.Marshal(metadata)
-
-	kafkaError := errors.New("an error occurred with Kafka")
-
-	cursor := mock.MongoCursorMock{
-		CloseFunc: func(ctx context.Context) error { return nil },
-		NextFunc:  CursorReturnsNumberOfNext(5),
-		DecodeFunc: func(val interface{}) error {
-			return bson.Unmarshal(metadataBytes, val)
-		},
-		ErrFunc: func() error { return nil },
-	}
-	collection := mock.MongoCollectionMock{
-		CountFunc:      CollectionCountReturnsOneNilWhenFilterContainsAndOrZeroNilWithout(),
-		UpdateManyFunc: CollectionUpdateManyReturnsNilAndNil(),
-		FindCursorFunc: CollectionFindCursorReturnsCursorAndError(&cursor, nil),
-	}
-
-	kafkaMock := kafkatest.IProducerMock{
-		SendFunc: KafkaSendReturnsError(kafkaError),
-	}
-
-	cfg, _ := config.Get()
-	subject := store.NewStore(&collection, nil, nil, nil, &kafkaMock, suite.defaultClock, nil, cfg)
-
-	subject.NotifyBundlePublished(suite.defaultContext, suite.defaultBundleID)
-
-	suite.Equal(6, len(cursor.NextCalls()))
-	suite.Equal(5, len(cursor.DecodeCalls()))
-	suite.Equal(5, len(kafkaMock.SendCalls()))
-	suite.Equal(1, len(cursor.ErrCalls()))
-	suite.Equal(1, len(cursor.CloseCalls()))
-}
-
-func (suite *StoreSuite) TestNotifyBundlePublishedDecodeErrorDoesNotFailOperation() {
-	cursor := mock.MongoCursorMock{
-		CloseFunc: func(ctx context.Context) error { return nil },
-		NextFunc:  CursorReturnsNumberOfNext(5),
-		DecodeFunc: func(val interface{}) error {
-			return errors.New("decode error")
-		},
-		ErrFunc: func() error { return nil },
-	}
-	collection := mock.MongoCollectionMock{
-		CountFunc:      CollectionCountReturnsOneNilWhenFilterContainsAndOrZeroNilWithout(),
-		UpdateManyFunc: CollectionUpdateManyReturnsNilAndNil(),
-		FindCursorFunc: CollectionFindCursorReturnsCursorAndError(&cursor, nil),
-	}
-
-	kafkaMock := kafkatest.IProducerMock{
-		SendFunc: KafkaSendReturnsError(errors.New("an error occurred with Kafka")),
-	}
-
-	cfg, _ := config.Get()
-	subject := store.NewStore(&collection, nil, nil, nil, &kafkaMock, suite.defaultClock, nil, cfg)
-
-	subject.NotifyBundlePublished(suite.defaultContext, suite.defaultBundleID)
-
-	suite.Equal(6, len(cursor.NextCalls()))
-	suite.Equal(5, len(cursor.DecodeCalls()))
-	suite.Equal(0, len(kafkaMock.SendCalls()))
-	suite.Equal(1, len(cursor.ErrCalls()))
-	suite.Equal(1, len(cursor.CloseCalls()))
+	suite.Len(metadataColl.FindCursorCalls(), 1)
 }
 
 func (suite *StoreSuite) TestGetBundlePublishedMetadataSuccess() {
@@ -401,7 +224,7 @@ func (suite *StoreSuite) TestGetBundlePublishedMetadataSuccess() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(nil, nil, &bundlesCollection, nil, nil, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Bundles: &bundlesCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	actualBundle, err := subject.GetBundlePublishedMetadata(suite.defaultContext, suite.defaultBundleID)
 
@@ -415,7 +238,7 @@ func (suite *StoreSuite) TestGetBundlePublishedMetadataNotFound() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(nil, nil, &bundlesCollection, nil, nil, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Bundles: &bundlesCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	actualBundle, err := subject.GetBundlePublishedMetadata(suite.defaultContext, "non-existent-bundle-id")
 
@@ -432,7 +255,7 @@ func (suite *StoreSuite) TestGetBundlePublishedMetadataUnexpectedError() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(nil, nil, &bundlesCollection, nil, nil, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Bundles: &bundlesCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	actualBundle, err := subject.GetBundlePublishedMetadata(suite.defaultContext, suite.defaultBundleID)
 
@@ -452,7 +275,7 @@ func (suite *StoreSuite) TestIsBundlePublishedSuccess() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(nil, nil, &bundlesCollection, nil, nil, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Bundles: &bundlesCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	isPublished, err := subject.IsBundlePublished(suite.defaultContext, suite.defaultBundleID)
 
@@ -472,7 +295,7 @@ func (suite *StoreSuite) TestIsBundlePublishedNotPublishedState() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(nil, nil, &bundlesCollection, nil, nil, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Bundles: &bundlesCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	isPublished, err := subject.IsBundlePublished(suite.defaultContext, suite.defaultBundleID)
 
@@ -495,7 +318,7 @@ func (suite *StoreSuite) TestIsBundlePublishedFallbackToFileCheckNotPublished()
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&metadataCollection, nil, &bundlesCollection, nil, nil, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &metadataCollection, Bundles: &bundlesCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	isPublished, err := subject.IsBundlePublished(suite.defaultContext, suite.defaultBundleID)
 
@@ -511,7 +334,7 @@ func (suite *StoreSuite) TestIsBundlePublishedUnexpectedError() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(nil, nil, &bundlesCollection, nil, nil, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Bundles: &bundlesCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	isPublished, err := subject.IsBundlePublished(suite.defaultContext, suite.defaultBundleID)
 
@@ -534,7 +357,7 @@ func (suite *StoreSuite) TestAreAllBundleFilesPublishedNotAllFilesPublished() {
 	bundlesCollection := mock.MongoCollectionMock{}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&metadataCollection, nil, &bundlesCollection, nil, nil, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &metadataCollection, Bundles: &bundlesCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	isPublished, err := subject.AreAllBundleFilesPublished(suite.defaultContext, suite.defaultBundleID)
 
@@ -549,7 +372,7 @@ func (suite *StoreSuite) TestAreAllBundleFilesPublishedEmptyBundle() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&metadataCollection, nil, &bundlesCollection, nil, nil, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &metadataCollection, Bundles: &bundlesCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	isPublished, err := subject.AreAllBundleFilesPublished(suite.defaultContext, "empty-bundle-id")
 
@@ -567,7 +390,7 @@ func (suite *StoreSuite) TestAreAllBundleFilesPublishedUnexpectedError() {
 	bundlesCollection := mock.MongoCollectionMock{}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&metadataCollection, nil, &bundlesCollection, nil, nil, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &metadataCollection, Bundles: &bundlesCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	isPublished, err := subject.AreAllBundleFilesPublished(suite.defaultContext, suite.defaultBundleID)
 
@@ -585,7 +408,7 @@ func (suite *StoreSuite) TestUpdateBundleIDFindReturnsErrNoDocumentFound() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionWithUploadedFile, nil, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionWithUploadedFile, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	err := subject.UpdateBundleID(suite.defaultContext, suite.path, suite.defaultBundleID)
 
@@ -607,7 +430,7 @@ func (suite *StoreSuite) TestUpdateBundleIDFindReturnsUnspecifiedError() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionWithUploadedFile, nil, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionWithUploadedFile, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	err := subject.UpdateBundleID(suite.defaultContext, "", suite.defaultBundleID)
 
@@ -630,7 +453,7 @@ func (suite *StoreSuite) TestUpdateBundleIDFileIsInMovedState() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionWithUploadedFile, nil, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionWithUploadedFile, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	err := subject.UpdateBundleID(suite.defaultContext, suite.path, suite.defaultBundleID)
 	logEvent := suite.logInterceptor.GetLogEvent()
@@ -652,7 +475,7 @@ func (suite *StoreSuite) TestUpdateBundleIDtoEmptyStringFileIsInMovedState() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionWithUploadedFile, nil, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionWithUploadedFile, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	err := subject.UpdateBundleID(suite.defaultContext, suite.path, "")
 	logEvent := suite.logInterceptor.GetLogEvent()
@@ -683,7 +506,7 @@ func (suite *StoreSuite) TestUpdateBundleIDBundleCheckFail() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionWithUploadedFile, nil, &bundleCollection, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionWithUploadedFile, Bundles: &bundleCollection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	err := subject.UpdateBundleID(suite.defaultContext, suite.path, suite.defaultBundleID)
 
@@ -712,7 +535,7 @@ func (suite *StoreSuite) TestUpdateBundleIDBundleAlreadyPublished() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionWithUploadedFile, nil, &bundleCollection, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionWithUploadedFile, Bundles: &bundleCollection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	err := subject.UpdateBundleID(suite.defaultContext, suite.path, suite.defaultBundleID)
 	logEvent := suite.logInterceptor.GetLogEvent()
@@ -741,7 +564,7 @@ func (suite *StoreSuite) TestUpdateBundleIDUpdateReturnsError() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionWithUploadedFile, nil, &emptyBundle, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionWithUploadedFile, Bundles: &emptyBundle, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	err := subject.UpdateBundleID(suite.defaultContext, suite.path, suite.defaultBundleID)
 
@@ -766,7 +589,7 @@ func (suite *StoreSuite) TestUpdateBundleIDUpdateSuccess() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionContainsOneUploadedFileWithNoBundleID, nil, &emptyBundle, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionContainsOneUploadedFileWithNoBundleID, Bundles: &emptyBundle, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	err := subject.UpdateBundleID(suite.defaultContext, suite.path, suite.defaultBundleID)
 
@@ -784,7 +607,7 @@ func (suite *StoreSuite) TestUpdateBundleIDRemoveBundleID() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionWithFileHavingBundleID, nil, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionWithFileHavingBundleID, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	err := subject.UpdateBundleID(suite.defaultContext, suite.path, "")
 
@@ -803,7 +626,7 @@ func (suite *StoreSuite) TestUpdateBundleIDRemoveBundleIDNoExistingBundle() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionWithFileHavingNoBundleID, nil, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionWithFileHavingNoBundleID, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	err := subject.UpdateBundleID(suite.defaultContext, suite.path, "")
 
@@ -824,7 +647,7 @@ func (suite *StoreSuite) TestUpdateBundleIDRemoveBundleIDUpdateError() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionWithFileHavingBundleID, nil, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionWithFileHavingBundleID, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	err := subject.UpdateBundleID(suite.defaultContext, suite.path, "")
 
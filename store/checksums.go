@@ -0,0 +1,103 @@
+package store
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// uploadChecksums works out the checksums that uploaded content should have. Checksums given as the upload completes
+// must agree with those given when the file was registered, unless the upload replaces earlier content that they
+// described.
+func uploadChecksums(metadata files.StoredRegisteredMetaData, change files.FileEtagChange) (checksumSHA256, checksumMD5 string, err error) {
+	replacing := metadata.State == StateUploaded && change.Etag != metadata.Etag
+
+	if checksumSHA256, err = expectedChecksum(metadata.ChecksumSHA256, change.ChecksumSHA256, replacing); err != nil {
+		return "", "", err
+	}
+	if checksumMD5, err = expectedChecksum(metadata.ChecksumMD5, change.ChecksumMD5, replacing); err != nil {
+		return "", "", err
+	}
+
+	return checksumSHA256, checksumMD5, nil
+}
+
+func expectedChecksum(stored, given string, replacing bool) (string, error) {
+	switch {
+	case replacing || stored == "":
+		return given, nil
+	case given == "":
+		return stored, nil
+	case !strings.EqualFold(stored, given):
+		return "", ErrChecksumMismatch
+	}
+	return stored, nil
+}
+
+// checksumUpdate adds the checksums of uploaded content to update, removing any stored for earlier content that are
+// no longer known
+func checksumUpdate(update Update, metadata files.StoredRegisteredMetaData, checksumSHA256, checksumMD5 string) Update {
+	checksums := []struct {
+		key           string
+		stored, value string
+	}{
+		{fieldChecksumSHA256, metadata.ChecksumSHA256, checksumSHA256},
+		{fieldChecksumMD5, metadata.ChecksumMD5, checksumMD5},
+	}
+
+	for _, checksum := range checksums {
+		switch {
+		case checksum.value != "":
+			update.Set = append(update.Set, Field{Key: checksum.key, Value: checksum.value})
+		case checksum.stored != "":
+			update.Unset = append(update.Unset, checksum.key)
+		}
+	}
+	return update
+}
+
+// verifyChecksums checks the expected checksums against those S3 reports for the object. S3 only reports an object's
+// SHA-256 when it was uploaded with a full object SHA-256 checksum, and its etag is only the MD5 of its content when
+// it was uploaded in one part without KMS or customer provided encryption. Checksums S3 cannot report are not checked.
+func verifyChecksums(head *s3.HeadObjectOutput, checksumSHA256, checksumMD5 string) error {
+	if s3SHA256, ok := objectSHA256(head); ok && checksumSHA256 != "" && !strings.EqualFold(s3SHA256, checksumSHA256) {
+		return ErrChecksumMismatch
+	}
+	if s3MD5, ok := objectMD5(head); ok && checksumMD5 != "" && !strings.EqualFold(s3MD5, checksumMD5) {
+		return ErrChecksumMismatch
+	}
+	return nil
+}
+
+func objectSHA256(head *s3.HeadObjectOutput) (string, bool) {
+	if head.ChecksumSHA256 == nil || head.ChecksumType == types.ChecksumTypeComposite {
+		return "", false
+	}
+
+	// composite checksums of multipart uploads have the part count appended
+	sum, err := base64.StdEncoding.DecodeString(*head.ChecksumSHA256)
+	if err != nil {
+		return "", false
+	}
+	return hex.EncodeToString(sum), true
+}
+
+func objectMD5(head *s3.HeadObjectOutput) (string, bool) {
+	if head.ETag == nil || head.SSECustomerAlgorithm != nil {
+		return "", false
+	}
+	if head.ServerSideEncryption == types.ServerSideEncryptionAwsKms || head.ServerSideEncryption == types.ServerSideEncryptionAwsKmsDsse {
+		return "", false
+	}
+
+	etag := strings.Trim(*head.ETag, "\"")
+	if strings.Contains(etag, "-") {
+		// multipart upload
+		return "", false
+	}
+	return etag, true
+}
@@ -0,0 +1,164 @@
+package store_test
+
+import (
+	"context"
+	"crypto/md5" //nolint:gosec // md5 is the checksum under test
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+
+	s3Mock "github.com/ONSdigital/dp-files-api/aws/mock"
+	"github.com/ONSdigital/dp-files-api/config"
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/dp-files-api/store"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+var (
+	testContent       = []byte("file content")
+	testContentSHA256 = sha256.Sum256(testContent)
+	testContentMD5    = md5.Sum(testContent) //nolint:gosec // md5 is the checksum under test
+)
+
+func (suite *StoreSuite) checksumStore(metadata files.StoredRegisteredMetaData, head *s3.HeadObjectOutput) (*store.Store, *store.MemoryRepository) {
+	repo := store.NewMemoryRepository()
+	suite.NoError(repo.InsertMetadata(suite.defaultContext, metadata))
+
+	s3Client := &s3Mock.S3ClienterMock{
+		HeadFunc: func(ctx context.Context, key string) (*s3.HeadObjectOutput, error) {
+			return head, nil
+		},
+	}
+
+	cfg, _ := config.Get()
+	return store.NewStore(repo, suite.defaultClock, s3Client, cfg), repo
+}
+
+func (suite *StoreSuite) TestMarkUploadCompleteVerifiesAndStoresChecksums() {
+	s3SHA256 := base64.StdEncoding.EncodeToString(testContentSHA256[:])
+	s3Etag := `"` + hex.EncodeToString(testContentMD5[:]) + `"`
+	subject, repo := suite.checksumStore(
+		files.StoredRegisteredMetaData{Path: suite.path, State: store.StateCreated},
+		&s3.HeadObjectOutput{ETag: &s3Etag, ChecksumSHA256: &s3SHA256, ChecksumType: types.ChecksumTypeFullObject},
+	)
+
+	err := subject.MarkUploadComplete(suite.defaultContext, files.FileEtagChange{
+		Path:           suite.path,
+		Etag:           testEtag,
+		ChecksumSHA256: hex.EncodeToString(testContentSHA256[:]),
+		ChecksumMD5:    hex.EncodeToString(testContentMD5[:]),
+	})
+
+	suite.NoError(err)
+	metadata, _ := repo.GetMetadata(suite.defaultContext, suite.path)
+	suite.Equal(store.StateUploaded, metadata.State)
+	suite.Equal(hex.EncodeToString(testContentSHA256[:]), metadata.ChecksumSHA256)
+	suite.Equal(hex.EncodeToString(testContentMD5[:]), metadata.ChecksumMD5)
+}
+
+func (suite *StoreSuite) TestMarkUploadCompleteVerifiesRegisteredChecksums() {
+	s3SHA256 := base64.StdEncoding.EncodeToString(testContentSHA256[:])
+	subject, repo := suite.checksumStore(
+		files.StoredRegisteredMetaData{Path: suite.path, State: store.StateCreated, ChecksumSHA256: "00" + hex.EncodeToString(testContentSHA256[1:])},
+		&s3.HeadObjectOutput{ChecksumSHA256: &s3SHA256},
+	)
+
+	err := subject.MarkUploadComplete(suite.defaultContext, files.FileEtagChange{Path: suite.path, Etag: testEtag})
+
+	suite.ErrorIs(err, store.ErrChecksumMismatch)
+	metadata, _ := repo.GetMetadata(suite.defaultContext, suite.path)
+	suite.Equal(store.StateCreated, metadata.State)
+}
+
+func (suite *StoreSuite) TestMarkUploadCompleteRejectsChecksumDifferentFromRegistered() {
+	subject, _ := suite.checksumStore(
+		files.StoredRegisteredMetaData{Path: suite.path, State: store.StateCreated, ChecksumMD5: hex.EncodeToString(testContentMD5[:])},
+		nil,
+	)
+
+	err := subject.MarkUploadComplete(suite.defaultContext, files.FileEtagChange{Path: suite.path, Etag: testEtag, ChecksumMD5: "00000000000000000000000000000000"})
+
+	suite.ErrorIs(err, store.ErrChecksumMismatch)
+}
+
+func (suite *StoreSuite) TestMarkUploadCompleteSkipsChecksumsS3CannotReport() {
+	compositeSHA256 := base64.StdEncoding.EncodeToString(testContentSHA256[:]) + "-3"
+	multipartEtag := `"0123456789abcdef0123456789abcdef-3"`
+	subject, repo := suite.checksumStore(
+		files.StoredRegisteredMetaData{Path: suite.path, State: store.StateCreated},
+		&s3.HeadObjectOutput{ETag: &multipartEtag, ChecksumSHA256: &compositeSHA256, ChecksumType: types.ChecksumTypeComposite},
+	)
+
+	err := subject.MarkUploadComplete(suite.defaultContext, files.FileEtagChange{
+		Path:           suite.path,
+		Etag:           testEtag,
+		ChecksumSHA256: hex.EncodeToString(testContentSHA256[:]),
+		ChecksumMD5:    hex.EncodeToString(testContentMD5[:]),
+	})
+
+	suite.NoError(err)
+	metadata, _ := repo.GetMetadata(suite.defaultContext, suite.path)
+	suite.Equal(hex.EncodeToString(testContentSHA256[:]), metadata.ChecksumSHA256)
+}
+
+func (suite *StoreSuite) TestMarkUploadCompleteSkipsMD5OfKMSEncryptedFile() {
+	kmsEtag := `"0123456789abcdef0123456789abcdef"`
+	subject, _ := suite.checksumStore(
+		files.StoredRegisteredMetaData{Path: suite.path, State: store.StateCreated},
+		&s3.HeadObjectOutput{ETag: &kmsEtag, ServerSideEncryption: types.ServerSideEncryptionAwsKms},
+	)
+
+	err := subject.MarkUploadComplete(suite.defaultContext, files.FileEtagChange{Path: suite.path, Etag: testEtag, ChecksumMD5: hex.EncodeToString(testContentMD5[:])})
+
+	suite.NoError(err)
+}
+
+func (suite *StoreSuite) TestMarkUploadCompleteReplacingContentDropsEarlierChecksums() {
+	subject, repo := suite.checksumStore(
+		files.StoredRegisteredMetaData{Path: suite.path, State: store.StateUploaded, Etag: "first", ChecksumSHA256: hex.EncodeToString(testContentSHA256[:])},
+		nil,
+	)
+
+	err := subject.MarkUploadComplete(suite.defaultContext, files.FileEtagChange{Path: suite.path, Etag: "second"})
+
+	suite.NoError(err)
+	metadata, _ := repo.GetMetadata(suite.defaultContext, suite.path)
+	suite.Empty(metadata.ChecksumSHA256)
+
+	previous, _ := subject.GetFileVersion(suite.defaultContext, suite.path, 1)
+	suite.Equal(hex.EncodeToString(testContentSHA256[:]), previous.ChecksumSHA256)
+}
+
+func (suite *StoreSuite) TestMarkFileMovedChecksumMismatch() {
+	s3Etag := `"` + testEtag + `"`
+	otherSHA256 := base64.StdEncoding.EncodeToString(make([]byte, sha256.Size))
+	subject, repo := suite.checksumStore(
+		files.StoredRegisteredMetaData{Path: suite.path, State: store.StatePublished, Etag: testEtag, ChecksumSHA256: hex.EncodeToString(testContentSHA256[:])},
+		&s3.HeadObjectOutput{ETag: &s3Etag, ChecksumSHA256: &otherSHA256},
+	)
+
+	err := subject.MarkFileMoved(suite.defaultContext, files.FileEtagChange{Path: suite.path, Etag: testEtag})
+
+	suite.ErrorIs(err, store.ErrChecksumMismatch)
+	metadata, _ := repo.GetMetadata(suite.defaultContext, suite.path)
+	suite.Equal(store.StatePublished, metadata.State)
+}
+
+func (suite *StoreSuite) TestMarkFilePublishedMessageIncludesChecksums() {
+	subject, repo := suite.checksumStore(files.StoredRegisteredMetaData{
+		Path:           suite.path,
+		State:          store.StateUploaded,
+		IsPublishable:  true,
+		ChecksumSHA256: hex.EncodeToString(testContentSHA256[:]),
+		ChecksumMD5:    hex.EncodeToString(testContentMD5[:]),
+	}, nil)
+
+	suite.NoError(subject.MarkFilePublished(suite.defaultContext, suite.path))
+
+	msgs, err := repo.FindDueOutboxMessages(suite.defaultContext, suite.defaultClock.GetCurrentTime(), 10)
+	suite.NoError(err)
+	suite.Require().Len(msgs, 1)
+	suite.Equal(hex.EncodeToString(testContentSHA256[:]), msgs[0].FilePublished.ChecksumSHA256)
+	suite.Equal(hex.EncodeToString(testContentMD5[:]), msgs[0].FilePublished.ChecksumMD5)
+}
@@ -4,35 +4,58 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"math"
-	"strconv"
-	"sync"
+	"time"
 
 	"github.com/ONSdigital/dp-files-api/config"
 	"github.com/ONSdigital/dp-files-api/files"
 	mongodriver "github.com/ONSdigital/dp-mongodb/v3/mongodb"
 	"github.com/ONSdigital/dp-net/v3/request"
 	"github.com/ONSdigital/log.go/v2/log"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func (store *Store) IsCollectionPublished(ctx context.Context, collectionID string) (bool, error) {
+	state, err := store.collectionState(ctx, collectionID)
+	if err != nil {
+		return false, err
+	}
+	return state == StatePublished, nil
+}
+
+// collectionState gives the publishing state of the collection, which is empty until it has been published
+func (store *Store) collectionState(ctx context.Context, collectionID string) (string, error) {
 	coll, err := store.GetCollectionPublishedMetadata(ctx, collectionID)
 	if err != nil {
 		// If there's no record of collection being published in collections DB, fall back
 		// to the older method that checks the file statuses (if all files in the collection are marked
 		// as published, we consider the collection published).
 		if errors.Is(err, ErrCollectionMetadataNotRegistered) {
-			return store.AreAllCollectionFilesPublished(ctx, collectionID)
+			published, err := store.AreAllCollectionFilesPublished(ctx, collectionID)
+			if err != nil || !published {
+				return "", err
+			}
+			return StatePublished, nil
 		}
 		// we've hit an unexpected error
-		return false, fmt.Errorf("collection published check: %w", err)
+		return "", fmt.Errorf("collection published check: %w", err)
 	}
-	if coll.State == StatePublished {
-		return true, nil
+	return coll.State, nil
+}
+
+// checkCollectionOpen returns ErrCollectionAlreadyPublished or ErrCollectionWithdrawn when files can no longer be
+// added to the collection
+func (store *Store) checkCollectionOpen(ctx context.Context, collectionID string) error {
+	state, err := store.collectionState(ctx, collectionID)
+	if err != nil {
+		return err
 	}
-	return false, nil
+	switch state {
+	case StatePublished:
+		return ErrCollectionAlreadyPublished
+	case StateWithdrawn:
+		return ErrCollectionWithdrawn
+	}
+	return nil
 }
 
 func (store *Store) AreAllCollectionFilesPublished(ctx context.Context, collectionID string) (bool, error) {
@@ -44,12 +67,10 @@ func (store *Store) AreAllCollectionFilesPublished(ctx context.Context, collecti
 		return false, nil
 	}
 
-	metadata := files.StoredRegisteredMetaData{}
-	err = store.metadataCollection.FindOne(ctx, bson.M{"$and": []bson.M{
-		{fieldCollectionID: collectionID},
-		{fieldState: bson.M{"$ne": StatePublished}},
-		{fieldState: bson.M{"$ne": StateMoved}},
-	}}, &metadata)
+	_, err = store.repo.FindOneMetadata(ctx, MetadataFilter{
+		CollectionID:  &collectionID,
+		ExcludeStates: []string{StatePublished, StateMoved},
+	})
 	if err != nil {
 		if errors.Is(err, mongodriver.ErrNoDocumentFound) {
 			return true, nil
@@ -60,10 +81,10 @@ func (store *Store) AreAllCollectionFilesPublished(ctx context.Context, collecti
 }
 
 func (store *Store) UpdateCollectionID(ctx context.Context, path, collectionID string) error {
-	metadata := files.StoredRegisteredMetaData{}
 	logdata := log.Data{"path": path}
 
-	if err := store.metadataCollection.FindOne(ctx, bson.M{"path": path}, &metadata); err != nil {
+	metadata, err := store.repo.GetMetadata(ctx, path)
+	if err != nil {
 		if errors.Is(err, mongodriver.ErrNoDocumentFound) {
 			log.Error(ctx, "update collection ID: attempted to operate on unregistered file", err, logdata)
 			return ErrFileNotRegistered
@@ -72,33 +93,33 @@ func (store *Store) UpdateCollectionID(ctx context.Context, path, collectionID s
 		return err
 	}
 
+	if err = checkIfMatch(ctx, metadata); err != nil {
+		return err
+	}
+
 	if metadata.CollectionID != nil {
 		logdata["collection_id"] = *metadata.CollectionID
 		log.Error(ctx, "update collection ID: collection ID already set", ErrCollectionIDAlreadySet, logdata)
 		return ErrCollectionIDAlreadySet
 	}
 
-	// check to see if collectionID exists and is not-published
-	published, err := store.IsCollectionPublished(ctx, collectionID)
-	if err != nil {
+	// check to see if collectionID exists and is neither published nor withdrawn
+	err = store.checkCollectionOpen(ctx, collectionID)
+	switch {
+	case errors.Is(err, ErrCollectionAlreadyPublished):
+		log.Error(ctx, fmt.Sprintf("collection with id [%s] is already published", collectionID), err, logdata)
+	case errors.Is(err, ErrCollectionWithdrawn):
+		log.Error(ctx, fmt.Sprintf("collection with id [%s] is withdrawn", collectionID), err, logdata)
+	case err != nil:
 		log.Error(ctx, "update collection ID: caught db error", err, logdata)
-		return err
 	}
-	if published {
-		log.Error(ctx, fmt.Sprintf("collection with id [%s] is already published", collectionID), ErrCollectionAlreadyPublished, logdata)
-		return ErrCollectionAlreadyPublished
+	if err != nil {
+		return err
 	}
 
-	_, err = store.metadataCollection.Update(
-		ctx,
-		bson.M{"path": path},
-		bson.D{
-			{Key: "$set", Value: bson.D{
-				{Key: "collection_id", Value: collectionID}},
-			},
-		})
-
-	return err
+	return store.updateMetadata(ctx, metadata, Update{Set: []Field{
+		{Key: fieldCollectionID, Value: collectionID},
+	}})
 }
 
 type ChangeFileState struct {
@@ -141,38 +162,39 @@ func (store *Store) MarkCollectionPublished(ctx context.Context, collectionID st
 		return ErrFileNotInUploadedState
 	}
 
-	err = store.updateCollectionState(ctx, collectionID, StatePublished)
+	// the publish job is recorded before the state change so that a published collection always has a job to resume;
+	// if the state change fails the job is withdrawn again, and if the service stops first ResumePublishJobs deletes it
+	job, err := store.CreateCollectionPublishJob(ctx, collectionID)
 	if err != nil {
 		return err
 	}
 
+	err = store.updateCollectionState(ctx, collectionID, StatePublished, *job.PublishedAt)
+	if err != nil {
+		store.deletePublishJob(ctx, job)
+		return err
+	}
+
 	requestID := request.GetRequestId(ctx)
 	newCtx := request.WithRequestId(context.Background(), requestID)
-	go store.NotifyCollectionPublished(newCtx, collectionID)
+	go store.RunPublishJob(newCtx, job)
 
 	return nil
 }
 
-func (store *Store) updateCollectionState(ctx context.Context, collectionID, state string) error {
+func (store *Store) updateCollectionState(ctx context.Context, collectionID, state string, now time.Time) error {
 	logdata := log.Data{"collection_id": collectionID, "state": state}
 
-	now := store.clock.GetCurrentTime()
-
-	fields := bson.D{
+	fields := []Field{
 		{Key: fieldState, Value: state},
 		{Key: fieldLastModified, Value: now},
 	}
 
 	if state == StatePublished {
-		fields = append(fields, bson.E{Key: fieldPublishedAt, Value: now})
+		fields = append(fields, Field{Key: fieldPublishedAt, Value: now})
 	}
 
-	_, err := store.collectionsCollection.Upsert(
-		ctx,
-		bson.M{fieldID: collectionID},
-		bson.D{
-			{Key: "$set", Value: fields},
-		})
+	err := store.repo.UpsertCollection(ctx, collectionID, fields)
 	if err != nil {
 		log.Error(ctx, fmt.Sprintf("failed to change collection %v to %s state", collectionID, state), err, logdata)
 		return err
@@ -187,7 +209,7 @@ func (store *Store) registerCollection(ctx context.Context, collectionID string)
 		State:        StateCreated,
 		LastModified: now,
 	}
-	if _, err := store.collectionsCollection.Insert(ctx, collection); err != nil {
+	if err := store.repo.InsertCollection(ctx, collection); err != nil {
 		if mongo.IsDuplicateKeyError(err) {
 			log.Info(ctx, "collection already registered", logdata)
 			return nil
@@ -199,9 +221,7 @@ func (store *Store) registerCollection(ctx context.Context, collectionID string)
 }
 
 func (store *Store) IsCollectionEmpty(ctx context.Context, collectionID string) (bool, error) {
-	metadata := files.StoredRegisteredMetaData{}
-
-	err := store.metadataCollection.FindOne(ctx, bson.M{fieldCollectionID: collectionID}, &metadata)
+	_, err := store.repo.FindOneMetadata(ctx, MetadataFilter{CollectionID: &collectionID})
 	if err != nil {
 		if errors.Is(err, mongodriver.ErrNoDocumentFound) {
 			return true, nil
@@ -221,11 +241,10 @@ func (store *Store) IsCollectionUploaded(ctx context.Context, collectionID strin
 		return false, nil
 	}
 
-	metadata := files.StoredRegisteredMetaData{}
-	err = store.metadataCollection.FindOne(ctx, bson.M{"$and": []bson.M{
-		{fieldCollectionID: collectionID},
-		{fieldState: bson.M{"$ne": StateUploaded}},
-	}}, &metadata)
+	_, err = store.repo.FindOneMetadata(ctx, MetadataFilter{
+		CollectionID:  &collectionID,
+		ExcludeStates: []string{StateUploaded},
+	})
 	if err != nil {
 		if errors.Is(err, mongodriver.ErrNoDocumentFound) {
 			return true, nil
@@ -235,77 +254,3 @@ func (store *Store) IsCollectionUploaded(ctx context.Context, collectionID strin
 
 	return false, nil
 }
-
-func (store *Store) NotifyCollectionPublished(ctx context.Context, collectionID string) {
-	// ignoring err as this would have been done previously
-	totalCount, _ := store.metadataCollection.Count(ctx, bson.M{fieldCollectionID: collectionID})
-	log.Info(ctx, "notify collection published start", log.Data{"collection_id": collectionID, "total_files": totalCount})
-	// balance the number of batches Vs batch size
-	batchSize := store.cfg.MinBatchSize
-	numBatches := int(math.Ceil(float64(totalCount) / float64(batchSize)))
-	if numBatches > store.cfg.MaxNumBatches {
-		numBatches = store.cfg.MaxNumBatches
-		batchSize = int(math.Ceil(float64(totalCount) / float64(numBatches)))
-	}
-
-	var wg sync.WaitGroup
-	wg.Add(numBatches)
-	for i := 0; i < numBatches; i++ {
-		offset := i * batchSize
-		cursor, err := store.metadataCollection.FindCursor(ctx, bson.M{fieldCollectionID: collectionID}, mongodriver.Offset(offset))
-		if err != nil {
-			wg.Done()
-			log.Error(ctx, "BatchSendKafkaMessages: failed to query collection", err, log.Data{"collection_id": collectionID})
-			continue
-		}
-		go store.BatchSendCollectionKafkaMessages(ctx, cursor, &wg, collectionID, offset, batchSize, i)
-	}
-	wg.Wait()
-
-	log.Info(ctx, "notify collection published end", log.Data{"collection_id": collectionID})
-}
-
-func (store *Store) BatchSendCollectionKafkaMessages(
-	ctx context.Context,
-	cursor mongodriver.Cursor,
-	wg *sync.WaitGroup,
-	collectionID string,
-	offset,
-	batchSize,
-	batchNum int,
-) {
-	defer wg.Done()
-	ld := log.Data{"collection_id": collectionID, "offset": offset, "batch_size": batchSize, "batch_num": batchNum}
-	log.Info(ctx, "BatchSendCollectionKafkaMessages", ld)
-	defer func() {
-		if err := cursor.Close(ctx); err != nil {
-			log.Error(ctx, "BatchSendCollectionKafkaMessages: failed to close cursor", err, ld)
-		}
-	}()
-
-	for i := 0; i < batchSize; i++ {
-		if cursor.Next(ctx) {
-			var m files.StoredRegisteredMetaData
-			if err := cursor.Decode(&m); err != nil {
-				log.Error(ctx, "BatchSendCollectionKafkaMessages: failed to decode cursor", err, ld)
-				continue
-			}
-			fp := &files.FilePublished{
-				Path:        m.Path,
-				Type:        m.Type,
-				Etag:        m.Etag,
-				SizeInBytes: strconv.FormatUint(m.SizeInBytes, 10),
-			}
-			if err := store.kafka.Send(files.AvroSchema, fp); err != nil {
-				log.Error(ctx, "BatchSendCollectionKafkaMessages: can't send message to kafka", err, log.Data{"metadata": m})
-			}
-		} else {
-			break
-		}
-	}
-	if err := cursor.Err(); err != nil {
-		log.Error(ctx, "BatchSendCollectionKafkaMessages: cursor error", err, ld)
-	}
-
-	log.Info(ctx, "BatchSendCollectionKafkaMessages end", ld)
-}
@@ -3,16 +3,12 @@ package store_test
 import (
 	"context"
 	"errors"
-	"math"
-	"strconv"
 	"time"
 
 	"github.com/ONSdigital/dp-files-api/config"
 	"github.com/ONSdigital/dp-files-api/files"
 	"github.com/ONSdigital/dp-files-api/mongo/mock"
 	"github.com/ONSdigital/dp-files-api/store"
-	"github.com/ONSdigital/dp-kafka/v3/avro"
-	"github.com/ONSdigital/dp-kafka/v3/kafkatest"
 	mongodriver "github.com/ONSdigital/dp-mongodb/v3/mongodb"
 	"go.mongodb.org/mongo-driver/bson"
 )
@@ -26,7 +22,7 @@ func (suite *StoreSuite) TestUpdateCollectionIDFindReturnsErrNoDocumentFound() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionWithUploadedFile, nil, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionWithUploadedFile, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	err := subject.UpdateCollectionID(suite.defaultContext, suite.path, suite.defaultCollectionID)
 
@@ -48,7 +44,7 @@ func (suite *StoreSuite) TestUpdateCollectionIDFindReturnsUnspecifiedError() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionWithUploadedFile, nil, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionWithUploadedFile, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	err := subject.UpdateCollectionID(suite.defaultContext, "", suite.defaultCollectionID)
 
@@ -71,7 +67,7 @@ func (suite *StoreSuite) TestUpdateCollectionIDCollectionIDAlreadySet() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionWithUploadedFile, nil, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionWithUploadedFile, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	err := subject.UpdateCollectionID(suite.defaultContext, suite.path, suite.defaultCollectionID)
 	logEvent := suite.logInterceptor.GetLogEvent()
@@ -102,7 +98,7 @@ func (suite *StoreSuite) TestUpdateCollectionIDCollectionCheckFail() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionWithUploadedFile, &collCollection, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionWithUploadedFile, Collections: &collCollection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	err := subject.UpdateCollectionID(suite.defaultContext, suite.path, suite.defaultCollectionID)
 
@@ -131,7 +127,7 @@ func (suite *StoreSuite) TestUpdateCollectionIDCollectionAlreadyPublished() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionWithUploadedFile, &collCollection, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionWithUploadedFile, Collections: &collCollection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	err := subject.UpdateCollectionID(suite.defaultContext, suite.path, suite.defaultCollectionID)
 	logEvent := suite.logInterceptor.GetLogEvent()
@@ -160,7 +156,7 @@ func (suite *StoreSuite) TestUpdateCollectionIDUpdateReturnsError() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionWithUploadedFile, &emptyCollection, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionWithUploadedFile, Collections: &emptyCollection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	err := subject.UpdateCollectionID(suite.defaultContext, suite.path, suite.defaultCollectionID)
 
@@ -185,13 +181,44 @@ func (suite *StoreSuite) TestUpdateCollectionIDUpdateSuccess() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionContainsOneUploadedFileWithNoCollectionID, &emptyCollection, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionContainsOneUploadedFileWithNoCollectionID, Collections: &emptyCollection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	err := subject.UpdateCollectionID(suite.defaultContext, suite.path, suite.defaultCollectionID)
 
 	suite.NoError(err)
 }
 
+func (suite *StoreSuite) TestUpdateCollectionIDWithIfMatchChecksRevisionInUpdateFilter() {
+	metadata := suite.generateCollectionMetadata("")
+	metadata.State = store.StateUploaded
+	metadata.CollectionID = nil
+	metadata.Revision = 4
+	metadataBytes, _ := bson.Marshal(metadata)
+
+	var filter bson.M
+	collectionChangedSinceItWasRead := mock.MongoCollectionMock{
+		FindOneFunc: CollectionFindOneSetsResultAndReturnsNil(metadataBytes),
+		UpdateFunc: func(ctx context.Context, selector, update interface{}) (*mongodriver.CollectionUpdateResult, error) {
+			filter = selector.(bson.M)
+			return &mongodriver.CollectionUpdateResult{}, nil
+		},
+	}
+	emptyCollection := mock.MongoCollectionMock{
+		FindOneFunc: func(ctx context.Context, filter, result interface{}, opts ...mongodriver.FindOption) error {
+			return mongodriver.ErrNoDocumentFound
+		},
+	}
+
+	cfg, _ := config.Get()
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionChangedSinceItWasRead, Collections: &emptyCollection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
+
+	ctx := store.WithIfMatch(suite.defaultContext, store.MetadataETag(metadata))
+	err := subject.UpdateCollectionID(ctx, suite.path, suite.defaultCollectionID)
+
+	suite.ErrorIs(err, store.ErrFileModified)
+	suite.Equal(bson.M{"path": suite.path, "revision": int64(4), "created_at": metadata.CreatedAt}, filter)
+}
+
 func (suite *StoreSuite) TestMarkCollectionPublishedCollectionEmptyCheckReturnsError() {
 	suite.logInterceptor.Start()
 	defer suite.logInterceptor.Stop()
@@ -203,7 +230,7 @@ func (suite *StoreSuite) TestMarkCollectionPublishedCollectionEmptyCheckReturnsE
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionCountReturnsError, nil, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionCountReturnsError, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	err := subject.MarkCollectionPublished(suite.defaultContext, suite.defaultCollectionID)
 
@@ -223,7 +250,7 @@ func (suite *StoreSuite) TestMarkCollectionPublishedCollectionEmpty() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionCountReturnsError, nil, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionCountReturnsError, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	err := subject.MarkCollectionPublished(suite.defaultContext, suite.defaultCollectionID)
 
@@ -246,7 +273,7 @@ func (suite *StoreSuite) TestMarkCollectionPublishedWhenFileExistsInStateOtherTh
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collection, &collCollection, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collection, Collections: &collCollection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	err := subject.MarkCollectionPublished(suite.defaultContext, suite.defaultCollectionID)
 
@@ -274,7 +301,7 @@ func (suite *StoreSuite) TestMarkCollectionPublishedCollectionUploadedCheckRetur
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionCountReturnsError, &collCollection, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionCountReturnsError, Collections: &collCollection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	err := subject.MarkCollectionPublished(suite.defaultContext, suite.defaultCollectionID)
 
@@ -309,7 +336,7 @@ func (suite *StoreSuite) TestMarkCollectionPublishedCollectionPublishedCheckRetu
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionCountReturnsError, &collCollection, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionCountReturnsError, Collections: &collCollection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	err := subject.MarkCollectionPublished(suite.defaultContext, suite.defaultCollectionID)
 
@@ -330,6 +357,7 @@ func (suite *StoreSuite) TestMarkCollectionPublishedPersistenceFailure() {
 			{CollectionFindOneSucceeds(), 1},                                   // there are some files in the collection
 			{CollectionFindOneReturnsError(mongodriver.ErrNoDocumentFound), 2}, // all of them are UPLOADED
 		}),
+		CountFunc: CollectionCountReturnsValueAndNil(1),
 	}
 	collectionColl := mock.MongoCollectionMock{
 		UpsertFunc: func(ctx context.Context, selector, update interface{}) (*mongodriver.CollectionUpdateResult, error) {
@@ -338,7 +366,7 @@ func (suite *StoreSuite) TestMarkCollectionPublishedPersistenceFailure() {
 		FindOneFunc: CollectionFindOneSucceeds(), // collection is not PUBLISHED
 	}
 	cfg, _ := config.Get()
-	subject := store.NewStore(&metadataColl, &collectionColl, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &metadataColl, Collections: &collectionColl, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	err := subject.MarkCollectionPublished(suite.defaultContext, suite.defaultCollectionID)
 
@@ -347,6 +375,8 @@ func (suite *StoreSuite) TestMarkCollectionPublishedPersistenceFailure() {
 	suite.Equal("failed to change collection 123456 to PUBLISHED state", logEvent)
 	suite.Error(err)
 	suite.ErrorIs(err, expectedError)
+	suite.Len(suite.defaultPublishJobsCollection.InsertCalls(), 1)
+	suite.Len(suite.defaultPublishJobsCollection.DeleteCalls(), 1)
 }
 
 func (suite *StoreSuite) TestMarkCollectionPublishedFindCalled() {
@@ -367,199 +397,22 @@ func (suite *StoreSuite) TestMarkCollectionPublishedFindCalled() {
 		FindOneFunc: CollectionFindOneSucceeds(), // collection is not PUBLISHED
 	}
 
-	cfg, _ := config.Get()
-	subject := store.NewStore(&metadataColl, &collectionColl, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
-
-	err := subject.MarkCollectionPublished(suite.defaultContext, suite.defaultCollectionID)
-
-	suite.NoError(err)
-	suite.Eventually(func() bool {
-		return len(metadataColl.FindCursorCalls()) == 1
-	}, time.Second, 10*time.Millisecond)
-}
-
-func (suite *StoreSuite) TestNotifyCollectionPublishedFindErrored() {
-	expectedError := errors.New("an error occurred")
-
-	collection := mock.MongoCollectionMock{
-		CountFunc:      CollectionCountReturnsOneNilWhenFilterContainsAndOrZeroNilWithout(),
-		UpdateManyFunc: CollectionUpdateManyReturnsNilAndNil(),
-		FindCursorFunc: CollectionFindCursorReturnsCursorAndError(nil, expectedError),
+	publishJobs := mock.MongoCollectionMock{
+		InsertFunc: CollectionInsertReturnsNilAndNil(),
+		UpdateFunc: CollectionUpdateReturnsNilAndNil(),
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collection, nil, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &metadataColl, Collections: &collectionColl, Outbox: &suite.defaultOutboxCollection, PublishJobs: &publishJobs, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
-	subject.NotifyCollectionPublished(suite.defaultContext, suite.defaultCollectionID)
+	err := subject.MarkCollectionPublished(suite.defaultContext, suite.defaultCollectionID)
 
+	suite.NoError(err)
 	suite.Eventually(func() bool {
-		return len(collection.FindCursorCalls()) == 1
+		// the job is marked as failed once the query error has been handled
+		return len(publishJobs.UpdateCalls()) == 1
 	}, time.Second, 10*time.Millisecond)
-}
-
-func (suite *StoreSuite) TestNotifyCollectionPublishedPersistenceSuccess() {
-	metadata := suite.generateCollectionMetadata(suite.defaultCollectionID)
-	metadataBytes, _ := bson.Marshal(metadata)
-
-	cursor := mock.MongoCursorMock{
-		CloseFunc: func(ctx context.Context) error { return nil },
-		NextFunc:  CursorReturnsNumberOfNext(5),
-		DecodeFunc: func(val interface{}) error {
-			return bson.Unmarshal(metadataBytes, val)
-		},
-		ErrFunc: func() error { return nil },
-	}
-
-	collection := mock.MongoCollectionMock{
-		CountFunc:      CollectionCountReturnsOneNilWhenFilterContainsAndOrZeroNilWithout(),
-		UpdateManyFunc: CollectionUpdateManyReturnsNilAndNil(),
-		FindCursorFunc: CollectionFindCursorReturnsCursorAndError(&cursor, nil),
-	}
-
-	kafkaMock := kafkatest.IProducerMock{
-		SendFunc: func(schema *avro.Schema, event interface{}) error {
-			filePublished := event.(*files.FilePublished)
-
-			suite.Equal(metadata.Path, filePublished.Path)
-			suite.Equal(metadata.Etag, filePublished.Etag)
-			suite.Equal(metadata.Type, filePublished.Type)
-			suite.Equal(strconv.FormatUint(metadata.SizeInBytes, 10), filePublished.SizeInBytes)
-
-			return nil
-		},
-	}
-
-	cfg, _ := config.Get()
-	subject := store.NewStore(&collection, nil, nil, nil, &kafkaMock, suite.defaultClock, nil, cfg)
-
-	subject.NotifyCollectionPublished(suite.defaultContext, suite.defaultCollectionID)
-
-	suite.Equal(6, len(cursor.NextCalls()))
-	suite.Equal(5, len(cursor.DecodeCalls()))
-	suite.Equal(5, len(kafkaMock.SendCalls()))
-	suite.Equal(1, len(cursor.ErrCalls()))
-	suite.Equal(1, len(cursor.CloseCalls()))
-}
-
-func (suite *StoreSuite) TestBatchingWithLargeNumberOfFilesCollection() {
-	suite.logInterceptor.Start()
-	defer suite.logInterceptor.Stop()
-	numFiles := 5000
-	cfg, _ := config.Get()
-	expectedBatchSize := int(math.Ceil(float64(numFiles) / float64(cfg.MaxNumBatches)))
-
-	metadata := suite.generateCollectionMetadata(suite.defaultCollectionID)
-	metadataBytes, _ := bson.Marshal(metadata)
-
-	cursor := mock.MongoCursorMock{
-		CloseFunc: func(ctx context.Context) error { return nil },
-		NextFunc:  CursorReturnsNumberOfNext(numFiles),
-		DecodeFunc: func(val interface{}) error {
-			return bson.Unmarshal(metadataBytes, val)
-		},
-		ErrFunc: func() error { return nil },
-	}
-
-	collection := mock.MongoCollectionMock{
-		CountFunc:      CollectionCountReturnsValueAndNil(numFiles),
-		UpdateManyFunc: CollectionUpdateManyReturnsNilAndNil(),
-		FindCursorFunc: CollectionFindCursorReturnsCursorAndError(&cursor, nil),
-	}
-
-	kafkaMock := kafkatest.IProducerMock{
-		SendFunc: func(schema *avro.Schema, event interface{}) error {
-			filePublished := event.(*files.FilePublished)
-
-			suite.Equal(metadata.Path, filePublished.Path)
-			suite.Equal(metadata.Etag, filePublished.Etag)
-			suite.Equal(metadata.Type, filePublished.Type)
-			suite.Equal(strconv.FormatUint(metadata.SizeInBytes, 10), filePublished.SizeInBytes)
-
-			return nil
-		},
-	}
-
-	subject := store.NewStore(&collection, nil, nil, nil, &kafkaMock, suite.defaultClock, nil, cfg)
-
-	subject.NotifyCollectionPublished(suite.defaultContext, suite.defaultCollectionID)
-
-	evts := suite.logInterceptor.GetLogEvents("BatchSendCollectionKafkaMessages")
-
-	for _, evt := range evts {
-		suite.EqualValues(evt["batch_size"].(float64), expectedBatchSize)
-	}
-	// make sure correct number of messages are sent
-	suite.Equal(len(kafkaMock.SendCalls()), numFiles)
-	suite.Equal(cfg.MaxNumBatches, len(evts))
-	suite.Equal(cfg.MaxNumBatches, len(cursor.ErrCalls()))
-	suite.Equal(cfg.MaxNumBatches, len(cursor.CloseCalls()))
-}
-
-func (suite *StoreSuite) TestNotifyCollectionPublishedKafkaErrorDoesNotFailOperation() {
-	metadata := suite.generateCollectionMetadata(suite.defaultCollectionID)
-	metadataBytes, _ := bson.Marshal(metadata)
-
-	kafkaError := errors.New("an error occurred with Kafka")
-
-	cursor := mock.MongoCursorMock{
-		CloseFunc: func(ctx context.Context) error { return nil },
-		NextFunc:  CursorReturnsNumberOfNext(5),
-		DecodeFunc: func(val interface{}) error {
-			return bson.Unmarshal(metadataBytes, val)
-		},
-		ErrFunc: func() error { return nil },
-	}
-	collection := mock.MongoCollectionMock{
-		CountFunc:      CollectionCountReturnsOneNilWhenFilterContainsAndOrZeroNilWithout(),
-		UpdateManyFunc: CollectionUpdateManyReturnsNilAndNil(),
-		FindCursorFunc: CollectionFindCursorReturnsCursorAndError(&cursor, nil),
-	}
-
-	kafkaMock := kafkatest.IProducerMock{
-		SendFunc: KafkaSendReturnsError(kafkaError),
-	}
-
-	cfg, _ := config.Get()
-	subject := store.NewStore(&collection, nil, nil, nil, &kafkaMock, suite.defaultClock, nil, cfg)
-
-	subject.NotifyCollectionPublished(suite.defaultContext, suite.defaultCollectionID)
-
-	suite.Equal(6, len(cursor.NextCalls()))
-	suite.Equal(5, len(cursor.DecodeCalls()))
-	suite.Equal(5, len(kafkaMock.SendCalls()))
-	suite.Equal(1, len(cursor.ErrCalls()))
-	suite.Equal(1, len(cursor.CloseCalls()))
-}
-
-func (suite *StoreSuite) TestNotifyCollectionPublishedDecodeErrorDoesNotFailOperation() {
-	cursor := mock.MongoCursorMock{
-		CloseFunc: func(ctx context.Context) error { return nil },
-		NextFunc:  CursorReturnsNumberOfNext(5),
-		DecodeFunc: func(val interface{}) error {
-			return errors.New("decode error")
-		},
-		ErrFunc: func() error { return nil },
-	}
-	collection := mock.MongoCollectionMock{
-		CountFunc:      CollectionCountReturnsOneNilWhenFilterContainsAndOrZeroNilWithout(),
-		UpdateManyFunc: CollectionUpdateManyReturnsNilAndNil(),
-		FindCursorFunc: CollectionFindCursorReturnsCursorAndError(&cursor, nil),
-	}
-
-	kafkaMock := kafkatest.IProducerMock{
-		SendFunc: KafkaSendReturnsError(errors.New("an error occurred with Kafka")),
-	}
-
-	cfg, _ := config.Get()
-	subject := store.NewStore(&collection, nil, nil, nil, &kafkaMock, suite.defaultClock, nil, cfg)
-
-	subject.NotifyCollectionPublished(suite.defaultContext, suite.defaultCollectionID)
-
-	suite.Equal(6, len(cursor.NextCalls()))
-	suite.Equal(5, len(cursor.DecodeCalls()))
-	suite.Equal(0, len(kafkaMock.SendCalls()))
-	suite.Equal(1, len(cursor.ErrCalls()))
-	suite.Equal(1, len(cursor.CloseCalls()))
+	suite.Len(metadataColl.FindCursorCalls(), 1)
 }
 
 func (suite *StoreSuite) TestIsCollectionPublishedNoMetadata() {
@@ -568,7 +421,7 @@ func (suite *StoreSuite) TestIsCollectionPublishedNoMetadata() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&emptyCollection, &emptyCollection, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &emptyCollection, Collections: &emptyCollection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	published, err := subject.IsCollectionPublished(suite.defaultContext, suite.defaultCollectionID)
 
@@ -583,7 +436,7 @@ func (suite *StoreSuite) TestIsCollectionPublishedMetadataError() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(nil, &erroringCollection, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Collections: &erroringCollection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	published, err := subject.IsCollectionPublished(suite.defaultContext, suite.defaultCollectionID)
 
@@ -609,7 +462,7 @@ func (suite *StoreSuite) TestIsCollectionPublishedMetadataPublished() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionWithUploadedFile, &collCollection, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionWithUploadedFile, Collections: &collCollection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	published, err := subject.IsCollectionPublished(suite.defaultContext, suite.defaultCollectionID)
 
@@ -631,7 +484,7 @@ func (suite *StoreSuite) TestIsCollectionPublishedNoCollectionAllFilesNotPublish
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionWithUploadedFile, &collCollection, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionWithUploadedFile, Collections: &collCollection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	published, err := subject.IsCollectionPublished(suite.defaultContext, suite.defaultCollectionID)
 
@@ -654,7 +507,7 @@ func (suite *StoreSuite) TestIsCollectionPublishedCollectionError() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionWithUploadedFile, &collCollection, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionWithUploadedFile, Collections: &collCollection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	published, err := subject.IsCollectionPublished(suite.defaultContext, suite.defaultCollectionID)
 
@@ -681,7 +534,7 @@ func (suite *StoreSuite) TestIsCollectionPublishedCollectionPublished() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionWithUploadedFile, &collCollection, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionWithUploadedFile, Collections: &collCollection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	published, err := subject.IsCollectionPublished(suite.defaultContext, suite.defaultCollectionID)
 
@@ -707,7 +560,7 @@ func (suite *StoreSuite) TestIsCollectionPublishedCollectionNotPublished() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionWithUploadedFile, &collCollection, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionWithUploadedFile, Collections: &collCollection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	published, err := subject.IsCollectionPublished(suite.defaultContext, suite.defaultCollectionID)
 
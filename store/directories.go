@@ -0,0 +1,92 @@
+package store
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// DirectoryQuery selects a page of the entries in a directory. Cursor is the NextCursor of the previous page, or empty
+// for the first page.
+type DirectoryQuery struct {
+	Prefix string
+	Cursor string
+	// Limit is the page size; zero returns every entry on one page
+	Limit int
+}
+
+// GetDirectory lists the directories and files immediately under a path prefix, with the number and total size of
+// the files under each directory. An empty prefix lists the top level. Directories only exist as the paths of the
+// files under them, so a prefix with no files under it is not found.
+func (store *Store) GetDirectory(ctx context.Context, query DirectoryQuery) (*files.Directory, error) {
+	if query.Limit < 0 {
+		return nil, ErrInvalidPagination
+	}
+	after, err := decodeFilesCursor(query.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := strings.Trim(query.Prefix, "/")
+	pathPrefix := ""
+	if prefix != "" {
+		pathPrefix = prefix + "/"
+	}
+	logdata := log.Data{"prefix": prefix}
+
+	directory := &files.Directory{
+		Path:        prefix,
+		Limit:       query.Limit,
+		Directories: make([]files.DirectoryEntry, 0),
+		Files:       make([]files.DirectoryEntry, 0),
+	}
+	directory.FileCount, directory.SizeInBytes, err = store.repo.SumDirectory(ctx, pathPrefix)
+	if err != nil {
+		log.Error(ctx, "failed to list directory", err, logdata)
+		return nil, err
+	}
+	if prefix != "" && directory.FileCount == 0 {
+		return nil, ErrPathNotFound
+	}
+
+	// one more entry than the limit is read to find out whether there is another page
+	limit := 0
+	if query.Limit > 0 {
+		limit = query.Limit + 1
+	}
+	entries, err := store.repo.ListDirectory(ctx, pathPrefix, after, limit)
+	if err != nil {
+		log.Error(ctx, "failed to list directory", err, logdata)
+		return nil, err
+	}
+	if query.Limit > 0 && len(entries) > query.Limit {
+		entries = entries[:query.Limit]
+		directory.NextCursor = encodeFilesCursor(directoryEntryKey(entries[query.Limit-1]))
+	}
+
+	for _, entry := range entries {
+		entry.Path = pathPrefix + entry.Name
+		if entry.IsDirectory {
+			directory.Directories = append(directory.Directories, entry)
+		} else {
+			entry.FileCount = 0
+			directory.Files = append(directory.Files, entry)
+		}
+	}
+	directory.Count = len(entries)
+	if query.Limit == 0 {
+		directory.Limit = directory.Count
+	}
+
+	return directory, nil
+}
+
+// directoryEntryKey is the name of the entry, with a slash added for a directory, which entries are sorted by
+func directoryEntryKey(entry files.DirectoryEntry) string {
+	if entry.IsDirectory {
+		return entry.Name + "/"
+	}
+	return entry.Name
+}
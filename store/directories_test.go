@@ -0,0 +1,166 @@
+package store_test
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ONSdigital/dp-files-api/config"
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/dp-files-api/mongo/mock"
+	"github.com/ONSdigital/dp-files-api/store"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func (suite *StoreSuite) directoryStore() *store.Store {
+	repo := store.NewMemoryRepository()
+	for path, size := range map[string]uint64{
+		"cpih01/2024/v1/data.csv":  100,
+		"cpih01/2024/v1/notes.txt": 10,
+		"cpih01/2024/v2/data.csv":  200,
+		"cpih01/2024/readme.md":    5,
+		"cpih01-other/data.csv":    1,
+	} {
+		suite.NoError(repo.InsertMetadata(suite.defaultContext, files.StoredRegisteredMetaData{Path: path, SizeInBytes: size}))
+	}
+
+	cfg, _ := config.Get()
+	return store.NewStore(repo, suite.defaultClock, nil, cfg)
+}
+
+func (suite *StoreSuite) TestGetDirectoryListsImmediateChildren() {
+	subject := suite.directoryStore()
+
+	directory, err := subject.GetDirectory(suite.defaultContext, store.DirectoryQuery{Prefix: "/cpih01/2024/"})
+
+	suite.NoError(err)
+	suite.Equal(&files.Directory{
+		Path:        "cpih01/2024",
+		FileCount:   4,
+		SizeInBytes: 315,
+		Count:       3,
+		Limit:       3,
+		Directories: []files.DirectoryEntry{
+			{Name: "v1", Path: "cpih01/2024/v1", IsDirectory: true, FileCount: 2, SizeInBytes: 110},
+			{Name: "v2", Path: "cpih01/2024/v2", IsDirectory: true, FileCount: 1, SizeInBytes: 200},
+		},
+		Files: []files.DirectoryEntry{
+			{Name: "readme.md", Path: "cpih01/2024/readme.md", SizeInBytes: 5},
+		},
+	}, directory)
+}
+
+func (suite *StoreSuite) TestGetDirectoryAtTopLevel() {
+	subject := suite.directoryStore()
+
+	directory, err := subject.GetDirectory(suite.defaultContext, store.DirectoryQuery{})
+
+	suite.NoError(err)
+	suite.Equal(5, directory.FileCount)
+	suite.Len(directory.Directories, 2)
+	// entries are in path order, so cpih01-other/ comes before cpih01/
+	suite.Equal("cpih01-other", directory.Directories[0].Path)
+	suite.Equal("cpih01", directory.Directories[1].Path)
+	suite.Empty(directory.Files)
+}
+
+func (suite *StoreSuite) TestGetDirectoryNotFound() {
+	subject := suite.directoryStore()
+
+	_, err := subject.GetDirectory(suite.defaultContext, store.DirectoryQuery{Prefix: "cpih01/2025"})
+
+	suite.ErrorIs(err, store.ErrPathNotFound)
+}
+
+func (suite *StoreSuite) TestGetDirectoryMatchesPathPrefixInMongo() {
+	var pipeline bson.A
+	metadataColl := mock.MongoCollectionMock{
+		AggregateFunc: func(ctx context.Context, p interface{}, results interface{}) error {
+			pipeline = p.(bson.A)
+			*results.(*[]files.DirectoryEntry) = []files.DirectoryEntry{{Name: "data.csv", FileCount: 1, SizeInBytes: 10}}
+			return nil
+		},
+	}
+
+	cfg, _ := config.Get()
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &metadataColl, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
+
+	directory, err := subject.GetDirectory(suite.defaultContext, store.DirectoryQuery{Prefix: "a.b/c"})
+
+	suite.NoError(err)
+	suite.Equal(bson.M{"$match": bson.M{"path": bson.M{"$gte": "a.b/c/", "$lt": "a.b/c0"}}}, pipeline[0])
+	suite.Equal("a.b/c/data.csv", directory.Files[0].Path)
+	suite.Equal(uint64(10), directory.SizeInBytes)
+}
+
+func (suite *StoreSuite) TestGetDirectoryAggregateError() {
+	metadataColl := mock.MongoCollectionMock{
+		AggregateFunc: func(ctx context.Context, p interface{}, results interface{}) error {
+			return errors.New("aggregate error")
+		},
+	}
+
+	cfg, _ := config.Get()
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &metadataColl, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
+
+	_, err := subject.GetDirectory(suite.defaultContext, store.DirectoryQuery{Prefix: "a"})
+
+	suite.EqualError(err, "aggregate error")
+}
+
+func (suite *StoreSuite) TestGetDirectoryPagesThroughEntries() {
+	repo := store.NewMemoryRepository()
+	for _, path := range []string{"a.csv", "a/1.csv", "a/2.csv", "a-b/1.csv", "b.csv", "c/1.csv"} {
+		suite.NoError(repo.InsertMetadata(suite.defaultContext, files.StoredRegisteredMetaData{Path: path, SizeInBytes: 1}))
+	}
+	cfg, _ := config.Get()
+	subject := store.NewStore(repo, suite.defaultClock, nil, cfg)
+
+	var paths []string
+	query := store.DirectoryQuery{Limit: 2}
+	for {
+		directory, err := subject.GetDirectory(suite.defaultContext, query)
+		suite.Require().NoError(err)
+		suite.Equal(6, directory.FileCount, "the totals cover the whole directory on every page")
+		suite.LessOrEqual(directory.Count, 2)
+		for _, entry := range append(directory.Directories, directory.Files...) {
+			paths = append(paths, entry.Path)
+		}
+		if directory.NextCursor == "" {
+			break
+		}
+		query.Cursor = directory.NextCursor
+	}
+
+	suite.Equal([]string{"a-b", "a.csv", "a", "b.csv", "c"}, paths)
+}
+
+func (suite *StoreSuite) TestGetDirectoryStartsAfterCursorInMongo() {
+	var pipelines []bson.A
+	metadataColl := mock.MongoCollectionMock{
+		AggregateFunc: func(ctx context.Context, p interface{}, results interface{}) error {
+			pipelines = append(pipelines, p.(bson.A))
+			*results.(*[]files.DirectoryEntry) = []files.DirectoryEntry{{Name: "data.csv", FileCount: 1, SizeInBytes: 10}}
+			return nil
+		},
+	}
+
+	cfg, _ := config.Get()
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &metadataColl, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
+
+	// the cursor of a page ending with the directory v1
+	_, err := subject.GetDirectory(suite.defaultContext, store.DirectoryQuery{Cursor: "djEv", Limit: 10})
+
+	suite.NoError(err)
+	suite.Require().Len(pipelines, 2)
+	suite.Equal(bson.M{"$match": bson.M{"path": bson.M{"$gte": ""}}}, pipelines[0][0], "the totals cover every file")
+	suite.Equal(bson.M{"$match": bson.M{"path": bson.M{"$gte": "v10"}}}, pipelines[1][0], "the files under v1/ are skipped")
+	suite.Contains(pipelines[1], bson.M{"$limit": 11})
+}
+
+func (suite *StoreSuite) TestGetDirectoryInvalidCursor() {
+	subject := suite.directoryStore()
+
+	_, err := subject.GetDirectory(suite.defaultContext, store.DirectoryQuery{Cursor: "not base64!"})
+
+	suite.ErrorIs(err, store.ErrInvalidCursor)
+}
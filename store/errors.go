@@ -18,10 +18,43 @@ var (
 	ErrCollectionMetadataNotRegistered = errors.New("collection metadata not registered")
 	ErrBundleMetadataNotRegistered     = errors.New("bundle metadata not registered")
 	ErrEtagMismatchWhilePublishing     = errors.New("etag mismatch")
+	ErrChecksumMismatch                = errors.New("checksum mismatch")
 	ErrBundleIDAlreadySet              = errors.New("bundle ID already set")
 	ErrBothCollectionAndBundleIDSet    = errors.New("cannot set both collection and bundle ID")
 	ErrFileMoved                       = errors.New("record cannot be updated as the file is MOVED")
 	ErrFileIsPublished                 = errors.New("cannot delete file as it is already published")
 	ErrPathNotFound                    = errors.New("the requested resource does not exist")
+	ErrPublishJobNotFound              = errors.New("no publish job found")
+	ErrFileVersionNotFound             = errors.New("file version not found")
+	ErrFileVersionExists               = errors.New("file version already kept for another file")
+	ErrInvalidFileVersion              = errors.New("file version must be a positive whole number")
+	ErrInvalidFileChangeID             = errors.New("file change ID is not valid")
+	ErrInvalidCursor                   = errors.New("cursor is not valid")
+	ErrInvalidLimit                    = errors.New("limit must be a whole number from 1 to 1000")
+	ErrInvalidOffset                   = errors.New("offset must be a whole number of 0 or more, and cannot be given with a cursor")
+	ErrInvalidStateFilter              = errors.New("state must be one of CREATED, UPLOADED, PUBLISHED, MOVED or WITHDRAWN")
+	ErrInvalidIsPublishable            = errors.New("is_publishable must be true or false")
+	ErrInvalidCreatedAfter             = errors.New("created_after must be an RFC3339 time")
+	ErrInvalidCreatedBefore            = errors.New("created_before must be an RFC3339 time")
+	ErrInvalidModifiedAfter            = errors.New("modified_after must be an RFC3339 time")
+	ErrInvalidModifiedBefore           = errors.New("modified_before must be an RFC3339 time")
+	ErrFileNotPublished                = errors.New("file is not published")
+	ErrFileWithdrawn                   = errors.New("file has been withdrawn")
+	ErrCollectionNotPublished          = errors.New("collection with the given id is not published")
+	ErrBundleNotPublished              = errors.New("bundle with the given id is not published")
+	ErrCollectionWithdrawn             = errors.New("collection with the given id is withdrawn")
+	ErrBundleWithdrawn                 = errors.New("bundle with the given id is withdrawn")
+	ErrWithdrawalReasonRequired        = errors.New("a reason for the withdrawal is required")
+	ErrInvalidStateChange              = errors.New("invalid state change")
+	ErrPublishAtNotInFuture            = errors.New("publish_at must be in the future")
+	ErrResourceLocked                  = errors.New("resource is locked")
+	ErrFileNotInTrash                  = errors.New("no removed file found at the path")
+	ErrReconciliationNotFound          = errors.New("no reconciliation has been run")
+	ErrReconciliationInProgress        = errors.New("a reconciliation is already running")
+	ErrDuplicateTransition             = errors.New("state transition for the same path given more than once")
 	ErrInvalidPagination               = errors.New("unable to process request due to a malformed or invalid request body or query parameter")
+	ErrIdempotencyKeyReused            = errors.New("idempotency key has already been used for a different request")
+	ErrIdempotencyKeyInProgress        = errors.New("a request with the idempotency key is still being handled")
+	ErrFileModified                    = errors.New("file has been changed since the given etag")
+	ErrBatchInsertFailed               = errors.New("the file could not be stored")
 )
@@ -9,6 +9,48 @@ const (
 	fieldState             = "state"
 	fieldLastModified      = "last_modified"
 	fieldPublishedAt       = "published_at"
+	fieldPublishAt         = "publish_at"
 	fieldUploadCompletedAt = "upload_completed_at"
 	fieldMovedAt           = "moved_at"
+	fieldContentItem       = "content_item"
+	fieldCreatedAt         = "created_at"
+	fieldAttempts          = "attempts"
+	fieldLastError         = "last_error"
+	fieldNextAttemptAt     = "next_attempt_at"
+	fieldPending           = "pending"
+	fieldSentAt            = "sent_at"
+	fieldSent              = "sent"
+	fieldFailed            = "failed"
+	fieldBatches           = "batches"
+	fieldCompletedAt       = "completed_at"
+	fieldPosition          = "position"
+	fieldCompleted         = "completed"
+	fieldLastPath          = "last_path"
+	fieldOwner             = "owner"
+	fieldLeaseExpiresAt    = "lease_expires_at"
+	fieldVersion           = "version"
+	fieldChecksumSHA256    = "checksum_sha256"
+	fieldChecksumMD5       = "checksum_md5"
+	fieldType              = "type"
+	fieldIsPublishable     = "is_publishable"
+	fieldSizeInBytes       = "size_in_bytes"
+	fieldWithdrawnAt       = "withdrawn_at"
+	fieldWithdrawalReason  = "withdrawal_reason"
+	fieldDeletedAt         = "deleted_at"
+	fieldPurgeAfter        = "purge_after"
+	fieldStartedAt         = "started_at"
+	fieldSequence          = "sequence"
+	fieldHash              = "hash"
+	fieldAction            = "action"
+	fieldRequestedByID     = "requested_by.id"
+	fieldMongoID           = "_id"
+	fieldRevision          = "revision"
+	fieldResponse          = "response"
+	fieldExpiresAt         = "expires_at"
+
+	fieldFileEventPath         = "file.path"
+	fieldFileEventCollectionID = "file.collection_id"
+	fieldFileEventBundleID     = "file.bundle_id"
+	fieldFileEventDatasetID    = "file.content_item.dataset_id"
+	fieldFileEventEdition      = "file.content_item.edition"
 )
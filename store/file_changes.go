@@ -0,0 +1,108 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/ONSdigital/dp-files-api/files"
+	mongodriver "github.com/ONSdigital/dp-mongodb/v3/mongodb"
+	"github.com/ONSdigital/log.go/v2/log"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// maxFileChangeAttempts is how many times a change is given the next sequence number and inserted again when another
+// instance of the service takes that number first
+const maxFileChangeAttempts = 10
+
+// recordFileChange adds a change to the file's state to the change log and wakes any streams on this instance. The
+// change has already been made, so a failure to record it is logged rather than returned; streams on other instances
+// find the change when they next poll.
+func (store *Store) recordFileChange(ctx context.Context, m files.StoredRegisteredMetaData, change, fromState, toState string) {
+	now := store.clock.GetCurrentTime()
+	fileChange := files.FileChange{
+		Path:         m.Path,
+		CollectionID: m.CollectionID,
+		BundleID:     m.BundleID,
+		Change:       change,
+		FromState:    fromState,
+		ToState:      toState,
+		CreatedAt:    now,
+		ExpiresAt:    now.Add(store.cfg.FileChangeTTL),
+	}
+
+	if err := store.insertFileChange(ctx, &fileChange); err != nil {
+		log.Error(ctx, "failed to record file change", err, log.Data{"path": m.Path, "change": change})
+		return
+	}
+
+	store.changedMu.Lock()
+	close(store.changed)
+	store.changed = make(chan struct{})
+	store.changedMu.Unlock()
+}
+
+// insertFileChange gives the change the sequence number after the latest change and inserts it. The unique index on
+// sequence stops the insert when another instance of the service has taken that number first, so the change is given
+// the next one and tried again. A number is only taken once the one before it has been inserted, so a stream that has
+// read a change has already been able to read every change before it.
+func (store *Store) insertFileChange(ctx context.Context, change *files.FileChange) error {
+	for attempt := 1; ; attempt++ {
+		latest, err := store.repo.GetLatestFileChange(ctx)
+		if err != nil && !errors.Is(err, mongodriver.ErrNoDocumentFound) {
+			return err
+		}
+
+		change.Sequence = latest.Sequence + 1
+		err = store.repo.InsertFileChange(ctx, *change)
+		if err == nil || !mongo.IsDuplicateKeyError(err) || attempt == maxFileChangeAttempts {
+			return err
+		}
+	}
+}
+
+// FileChanged returns a channel that is closed the next time a file change is recorded by this instance
+func (store *Store) FileChanged() <-chan struct{} {
+	store.changedMu.Lock()
+	defer store.changedMu.Unlock()
+
+	return store.changed
+}
+
+// GetFileChanges returns up to limit changes to the files in a collection or bundle, oldest first, made after the
+// change with the given sequence number, along with the sequence number to read on from next time. With no sequence
+// number only changes made from now on are returned.
+func (store *Store) GetFileChanges(ctx context.Context, collectionID, bundleID, after string, limit int) ([]files.FileChange, string, error) {
+	filter := FileChangeFilter{}
+	if after == "" {
+		latest, err := store.repo.GetLatestFileChange(ctx)
+		if err != nil && !errors.Is(err, mongodriver.ErrNoDocumentFound) {
+			log.Error(ctx, "failed to find latest file change", err)
+			return nil, "", err
+		}
+		filter.AfterSequence = latest.Sequence
+	} else {
+		sequence, err := strconv.ParseInt(after, 10, 64)
+		if err != nil || sequence < 0 {
+			return nil, "", ErrInvalidFileChangeID
+		}
+		filter.AfterSequence = sequence
+	}
+	if collectionID != "" {
+		filter.CollectionID = &collectionID
+	}
+	if bundleID != "" {
+		filter.BundleID = &bundleID
+	}
+
+	changes, err := store.repo.FindFileChanges(ctx, filter, limit)
+	if err != nil {
+		log.Error(ctx, "failed to find file changes", err, log.Data{"collection_id": collectionID, "bundle_id": bundleID, "after": filter.AfterSequence})
+		return nil, "", err
+	}
+
+	if len(changes) > 0 {
+		filter.AfterSequence = changes[len(changes)-1].Sequence
+	}
+	return changes, strconv.FormatInt(filter.AfterSequence, 10), nil
+}
@@ -0,0 +1,138 @@
+package store_test
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ONSdigital/dp-files-api/clock"
+	"github.com/ONSdigital/dp-files-api/config"
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/dp-files-api/store"
+)
+
+func (suite *StoreSuite) TestFileChangesAreRecordedForACollection() {
+	subject, _ := suite.lifecycleStore()
+	collectionID := "collection-1"
+	changed := subject.FileChanged()
+
+	suite.NoError(subject.RegisterFileUpload(suite.defaultContext, files.StoredRegisteredMetaData{Path: suite.path, CollectionID: &collectionID, IsPublishable: true}))
+	suite.NoError(subject.MarkUploadComplete(suite.defaultContext, files.FileEtagChange{Path: suite.path, Etag: testEtag}))
+
+	select {
+	case <-changed:
+	default:
+		suite.Fail("file changed channel was not closed")
+	}
+
+	changes, cursor, err := subject.GetFileChanges(suite.defaultContext, collectionID, "", "0", 10)
+
+	suite.NoError(err)
+	suite.Require().Len(changes, 2)
+	suite.Equal(suite.path, changes[0].Path)
+	suite.Equal(collectionID, *changes[0].CollectionID)
+	suite.Equal(files.LifecycleRegistered, changes[0].Change)
+	suite.Equal(store.StateCreated, changes[0].ToState)
+	suite.Equal(files.LifecycleStateChanged, changes[1].Change)
+	suite.Equal(store.StateCreated, changes[1].FromState)
+	suite.Equal(store.StateUploaded, changes[1].ToState)
+	suite.Equal(int64(1), changes[0].Sequence)
+	suite.Equal(int64(2), changes[1].Sequence)
+	suite.Equal("2", cursor)
+
+	changes, next, err := subject.GetFileChanges(suite.defaultContext, collectionID, "", "1", 10)
+
+	suite.NoError(err)
+	suite.Require().Len(changes, 1)
+	suite.Equal(store.StateUploaded, changes[0].ToState)
+	suite.Equal(cursor, next)
+
+	changes, _, err = subject.GetFileChanges(suite.defaultContext, "", "bundle-1", "0", 10)
+
+	suite.NoError(err)
+	suite.Empty(changes)
+}
+
+func (suite *StoreSuite) TestGetFileChangesWithoutIDOnlyReturnsNewChanges() {
+	repo := store.NewMemoryRepository()
+	cfg, _ := config.Get()
+	subject := store.NewStore(repo, clock.SystemClock{}, nil, cfg)
+	bundleID := "bundle-1"
+
+	suite.NoError(repo.InsertFileChange(suite.defaultContext, files.FileChange{Sequence: 1, Path: "old.csv", BundleID: &bundleID}))
+
+	changes, cursor, err := subject.GetFileChanges(suite.defaultContext, "", bundleID, "", 10)
+
+	suite.NoError(err)
+	suite.Empty(changes)
+	suite.Equal("1", cursor)
+
+	suite.NoError(subject.RegisterFileUpload(suite.defaultContext, files.StoredRegisteredMetaData{Path: suite.path, BundleID: &bundleID}))
+	changes, _, err = subject.GetFileChanges(suite.defaultContext, "", bundleID, cursor, 10)
+
+	suite.NoError(err)
+	suite.Require().Len(changes, 1)
+	suite.Equal(suite.path, changes[0].Path)
+}
+
+func (suite *StoreSuite) TestGetFileChangesRejectsInvalidID() {
+	subject, _ := suite.lifecycleStore()
+
+	_, _, err := subject.GetFileChanges(suite.defaultContext, "collection-1", "", "not-an-id", 10)
+
+	suite.ErrorIs(err, store.ErrInvalidFileChangeID)
+}
+
+func (suite *StoreSuite) TestFileChangesAreGivenIncreasingSequenceNumbers() {
+	subject, repo := suite.lifecycleStore()
+	collectionID := "collection-1"
+
+	var wg sync.WaitGroup
+	for _, path := range []string{"a.csv", "b.csv", "c.csv", "d.csv", "e.csv"} {
+		wg.Add(1)
+		go func(path string) {
+			defer wg.Done()
+			suite.NoError(subject.RegisterFileUpload(suite.defaultContext, files.StoredRegisteredMetaData{Path: path, CollectionID: &collectionID}))
+		}(path)
+	}
+	wg.Wait()
+
+	changes, err := repo.FindFileChanges(suite.defaultContext, store.FileChangeFilter{}, 10)
+	suite.NoError(err)
+	suite.Require().Len(changes, 5)
+	for i, change := range changes {
+		suite.Equal(int64(i+1), change.Sequence)
+		suite.Equal(suite.defaultClock.GetCurrentTime().Add(24*time.Hour), change.ExpiresAt)
+	}
+}
+
+// racingFileChangeRepository records another instance's change just before the first change is inserted
+type racingFileChangeRepository struct {
+	*store.MemoryRepository
+	raced bool
+}
+
+func (r *racingFileChangeRepository) InsertFileChange(ctx context.Context, change files.FileChange) error {
+	if !r.raced {
+		r.raced = true
+		if err := r.MemoryRepository.InsertFileChange(ctx, files.FileChange{Sequence: change.Sequence, Path: "other.csv"}); err != nil {
+			return err
+		}
+	}
+	return r.MemoryRepository.InsertFileChange(ctx, change)
+}
+
+func (suite *StoreSuite) TestFileChangeTakesNextSequenceNumberWhenAnotherInstanceTakesItFirst() {
+	repo := &racingFileChangeRepository{MemoryRepository: store.NewMemoryRepository()}
+	cfg, _ := config.Get()
+	subject := store.NewStore(repo, suite.defaultClock, nil, cfg)
+
+	suite.NoError(subject.RegisterFileUpload(suite.defaultContext, files.StoredRegisteredMetaData{Path: suite.path}))
+
+	changes, err := repo.FindFileChanges(suite.defaultContext, store.FileChangeFilter{}, 10)
+	suite.NoError(err)
+	suite.Require().Len(changes, 2)
+	suite.Equal("other.csv", changes[0].Path)
+	suite.Equal(suite.path, changes[1].Path)
+	suite.Equal(int64(2), changes[1].Sequence)
+}
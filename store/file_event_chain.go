@@ -0,0 +1,242 @@
+package store
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+
+	"github.com/ONSdigital/dp-files-api/files"
+	mongodriver "github.com/ONSdigital/dp-mongodb/v3/mongodb"
+	"github.com/ONSdigital/log.go/v2/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+const (
+	// maxFileEventChainAttempts is how many times events are chained again when another instance of the service
+	// advances the head of the chain first
+	maxFileEventChainAttempts = 10
+
+	// fileEventChainPageSize is how many file events are read at a time while the chain is verified
+	fileEventChainPageSize = 500
+
+	// fileEventChainHeadID is the _id of the head of the chain
+	fileEventChainHeadID = "head"
+)
+
+// errFileEventChainContended is returned when the head of the chain keeps being advanced by other instances of the
+// service before the events can be given their place
+var errFileEventChainContended = errors.New("file event chain is being advanced by other instances")
+
+// insertChainedFileEvents gives the events the next sequence numbers in the chain and the hashes linking them, and
+// inserts them. The sequence numbers are taken by advancing the head of the chain in one atomic update, which only
+// applies while the head is still the one the events were chained on to, so when another instance of the service has
+// advanced it first the events are chained on to the new head and tried again. An insert that fails takes its events
+// back out of the chain while nothing has been chained after them, and otherwise leaves a gap that verification
+// reports as missing events.
+func (store *Store) insertChainedFileEvents(ctx context.Context, events []*files.FileEvent, insert func(ctx context.Context, events []*files.FileEvent) (int, error)) error {
+	// requests to this instance take their turn, so only other instances can advance the head first
+	store.fileEventsMu.Lock()
+	defer store.fileEventsMu.Unlock()
+
+	head, err := store.advanceFileEventChain(ctx, events)
+	if err != nil {
+		return err
+	}
+
+	n, err := insert(ctx, events)
+	if err != nil {
+		store.retreatFileEventChain(ctx, head, events[:n], events[n:])
+		return err
+	}
+	return nil
+}
+
+func (store *Store) insertFileEvent(ctx context.Context, events []*files.FileEvent) (int, error) {
+	if err := store.repo.InsertFileEvent(ctx, events[0]); err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
+
+// advanceFileEventChain chains the events on to the head of the chain and advances the head past them, returning the
+// head they were chained on to
+func (store *Store) advanceFileEventChain(ctx context.Context, events []*files.FileEvent) (FileEventChainHead, error) {
+	for attempt := 1; ; attempt++ {
+		head, err := store.repo.GetFileEventChainHead(ctx)
+		if err != nil && !errors.Is(err, mongodriver.ErrNoDocumentFound) {
+			log.Error(ctx, "failed to get head of file event chain", err)
+			return head, err
+		}
+
+		if err := store.chainFileEvents(head, events); err != nil {
+			return head, err
+		}
+
+		advanced, err := store.repo.AdvanceFileEventChainHead(ctx, head, len(events), events[len(events)-1].Hash)
+		if err != nil {
+			log.Error(ctx, "failed to advance head of file event chain", err, log.Data{"sequence": head.Sequence})
+			return head, err
+		}
+		if advanced {
+			return head, nil
+		}
+		if attempt == maxFileEventChainAttempts {
+			log.Error(ctx, "failed to advance head of file event chain", errFileEventChainContended, log.Data{"attempts": attempt})
+			return head, errFileEventChainContended
+		}
+	}
+}
+
+// retreatFileEventChain moves the head of the chain back from the events that were not inserted to the last that
+// was, or to head when none were
+func (store *Store) retreatFileEventChain(ctx context.Context, head FileEventChainHead, inserted, notInserted []*files.FileEvent) {
+	if len(notInserted) == 0 {
+		return
+	}
+	if len(inserted) > 0 {
+		last := inserted[len(inserted)-1]
+		head = FileEventChainHead{Sequence: last.Sequence, Hash: last.Hash}
+	}
+
+	claimed := notInserted[len(notInserted)-1]
+	logData := log.Data{"first_sequence": notInserted[0].Sequence, "last_sequence": claimed.Sequence}
+	retreated, err := store.repo.AdvanceFileEventChainHead(ctx, FileEventChainHead{Sequence: claimed.Sequence, Hash: claimed.Hash}, -len(notInserted), head.Hash)
+	if err != nil {
+		log.Error(ctx, "failed to move head of file event chain back", err, logData)
+	}
+	if err != nil || !retreated {
+		log.Warn(ctx, "file events not inserted leave a gap in the chain", log.Classification(log.ProtectiveMonitoring), logData)
+	}
+}
+
+// chainFileEvents links the events on to head, each following on from the one before it
+func (store *Store) chainFileEvents(head FileEventChainHead, events []*files.FileEvent) error {
+	previous := files.FileEvent{Sequence: head.Sequence, Hash: head.Hash}
+	for _, event := range events {
+		event.Sequence = previous.Sequence + 1
+		event.PreviousHash = previous.Hash
+		event.Hash = ""
+
+		doc, err := bson.Marshal(event)
+		if err != nil {
+			return err
+		}
+		if event.Hash, err = store.fileEventHash(doc); err != nil {
+			return err
+		}
+		previous = *event
+	}
+	return nil
+}
+
+// chainedFileEvent reads a file event from the document it is stored as
+func chainedFileEvent(doc bson.Raw) (ChainedFileEvent, error) {
+	chained := ChainedFileEvent{Stored: doc}
+	err := bson.Unmarshal(doc, &chained.Event)
+	return chained, err
+}
+
+// fileEventHash hashes a file event as stored, leaving out its hash and the _id given to it by MongoDB. With
+// FILE_EVENTS_HMAC_KEY set the hash is an HMAC, so that the chain cannot be written again without the key by someone
+// who can change the stored events.
+func (store *Store) fileEventHash(doc bson.Raw) (string, error) {
+	elements, err := bsoncore.Document(doc).Elements()
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	if key := store.cfg.FileEventsHMACKey; key != "" {
+		h = hmac.New(sha256.New, []byte(key))
+	}
+	for _, element := range elements {
+		switch element.Key() {
+		case "_id", "hash":
+			continue
+		}
+		h.Write(element)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// VerifyFileEventChain walks the chain of file events from the first up to the head of the chain, checking that none
+// are missing, that each follows on from the one before it and has not been changed since it was written, and that
+// the last is the head. It stops at the first break found. Events written before the chain was introduced have no
+// sequence number and are not checked, nor are events chained after the walk has started.
+func (store *Store) VerifyFileEventChain(ctx context.Context) (*files.FileEventChainVerification, error) {
+	head, err := store.repo.GetFileEventChainHead(ctx)
+	headFound := err == nil
+	if err != nil && !errors.Is(err, mongodriver.ErrNoDocumentFound) {
+		log.Error(ctx, "failed to get head of file event chain", err)
+		return nil, err
+	}
+
+	verification := &files.FileEventChainVerification{Verified: true}
+	previous := files.FileEvent{}
+	broken := func(sequence int64, reason string) {
+		verification.Verified = false
+		verification.Break = &files.FileEventChainBreak{Sequence: sequence, Reason: reason}
+		log.Warn(ctx, "file event chain is broken", log.Classification(log.ProtectiveMonitoring), log.Data{"sequence": sequence, "reason": reason})
+	}
+
+walk:
+	for {
+		events, err := store.repo.FindChainedFileEvents(ctx, previous.Sequence, fileEventChainPageSize)
+		if err != nil {
+			log.Error(ctx, "failed to find chained file events", err, log.Data{"after_sequence": previous.Sequence})
+			return nil, err
+		}
+
+		for _, chained := range events {
+			event := chained.Event
+			if headFound && event.Sequence > head.Sequence {
+				break walk
+			}
+
+			verification.EventsChecked++
+			hash, err := store.fileEventHash(chained.Stored)
+			if err != nil {
+				return nil, err
+			}
+			if reason := chainBreak(previous, event, hash); reason != "" {
+				broken(event.Sequence, reason)
+				break walk
+			}
+			previous = event
+		}
+
+		if len(events) < fileEventChainPageSize {
+			break
+		}
+	}
+
+	switch {
+	case verification.Break != nil:
+	case !headFound && previous.Sequence > 0:
+		broken(previous.Sequence, files.ChainBreakHeadMismatch)
+	case previous.Sequence < head.Sequence:
+		broken(previous.Sequence+1, files.ChainBreakMissingEvent)
+	case previous.Hash != head.Hash:
+		broken(previous.Sequence, files.ChainBreakHeadMismatch)
+	}
+
+	verification.LastSequence = previous.Sequence
+	verification.LastHash = previous.Hash
+	return verification, nil
+}
+
+// chainBreak gives the reason the event does not follow on from the one before it, or nothing when it does
+func chainBreak(previous, event files.FileEvent, hash string) string {
+	switch {
+	case event.Sequence != previous.Sequence+1:
+		return files.ChainBreakMissingEvent
+	case event.PreviousHash != previous.Hash:
+		return files.ChainBreakPreviousHashMismatch
+	case event.Hash != hash:
+		return files.ChainBreakHashMismatch
+	}
+	return ""
+}
@@ -0,0 +1,291 @@
+package store_test
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ONSdigital/dp-files-api/config"
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/dp-files-api/store"
+	mongodriver "github.com/ONSdigital/dp-mongodb/v3/mongodb"
+)
+
+// racingRepository runs race before the head of the chain is first advanced and raceInsert before the first insert of
+// a file event, as another instance of the service writing to the chain at the same time would. Inserts of file events
+// fail with insertErr.
+type racingRepository struct {
+	*store.MemoryRepository
+	race       func(ctx context.Context) error
+	raceInsert func(ctx context.Context) error
+	insertErr  error
+}
+
+func (r *racingRepository) AdvanceFileEventChainHead(ctx context.Context, head store.FileEventChainHead, n int, hash string) (bool, error) {
+	if race := r.race; race != nil {
+		r.race = nil
+		if err := race(ctx); err != nil {
+			return false, err
+		}
+	}
+	return r.MemoryRepository.AdvanceFileEventChainHead(ctx, head, n, hash)
+}
+
+func (r *racingRepository) InsertFileEvent(ctx context.Context, event *files.FileEvent) error {
+	if race := r.raceInsert; race != nil {
+		r.raceInsert = nil
+		if err := race(ctx); err != nil {
+			return err
+		}
+	}
+	if r.insertErr != nil {
+		return r.insertErr
+	}
+	return r.MemoryRepository.InsertFileEvent(ctx, event)
+}
+
+// headlessRepository has lost the head of the chain
+type headlessRepository struct {
+	*store.MemoryRepository
+}
+
+func (r *headlessRepository) GetFileEventChainHead(ctx context.Context) (store.FileEventChainHead, error) {
+	return store.FileEventChainHead{}, mongodriver.ErrNoDocumentFound
+}
+
+// tamperedRepository changes the stored file events as they are read
+type tamperedRepository struct {
+	*store.MemoryRepository
+	tamper func(events []files.FileEvent) []files.FileEvent
+}
+
+func (r *tamperedRepository) FindChainedFileEvents(ctx context.Context, afterSequence int64, limit int) ([]store.ChainedFileEvent, error) {
+	chained, err := r.MemoryRepository.FindChainedFileEvents(ctx, afterSequence, limit)
+	if err != nil || afterSequence > 0 {
+		return chained, err
+	}
+
+	events := make([]files.FileEvent, 0, len(chained))
+	for _, c := range chained {
+		events = append(events, c.Event)
+	}
+
+	// the tampered events are stored again so that they are hashed as they would be read back
+	tampered := store.NewMemoryRepository()
+	for _, event := range r.tamper(events) {
+		if err := tampered.InsertFileEvent(ctx, &event); err != nil {
+			return nil, err
+		}
+	}
+	return tampered.FindChainedFileEvents(ctx, afterSequence, limit)
+}
+
+func (suite *StoreSuite) chainFileEvents(repo store.Repository) *store.Store {
+	cfg, _ := config.Get()
+	return suite.chainFileEventsWith(repo, cfg)
+}
+
+func (suite *StoreSuite) chainFileEventsWith(repo store.Repository, cfg *config.Config) *store.Store {
+	subject := store.NewStore(repo, suite.defaultClock, nil, cfg)
+
+	suite.Require().NoError(subject.CreateFileEvent(suite.defaultContext, &files.FileEvent{Action: files.ActionCreate, Resource: "/files/a.csv", File: &files.StoredRegisteredMetaData{Path: "a.csv"}}))
+	suite.Require().NoError(subject.CreateFileEvents(suite.defaultContext, []*files.FileEvent{
+		{Action: files.ActionUpdate, Resource: "/files/a.csv", File: &files.StoredRegisteredMetaData{Path: "a.csv"}},
+		{Action: files.ActionRead, Resource: "/files/b.csv", File: &files.StoredRegisteredMetaData{Path: "b.csv"}},
+	}))
+	suite.Require().NoError(subject.CreateFileEvent(suite.defaultContext, &files.FileEvent{Action: files.ActionDelete, Resource: "/files/a.csv"}))
+	return subject
+}
+
+func (suite *StoreSuite) TestCreateFileEventsAreChained() {
+	repo := store.NewMemoryRepository()
+	suite.NoError(repo.InsertFileEvent(suite.defaultContext, &files.FileEvent{Action: files.ActionRead, Resource: "/files/before-the-chain.csv"}))
+	suite.chainFileEvents(repo)
+
+	first, err := repo.FindChainedFileEvents(suite.defaultContext, 0, 10)
+	suite.NoError(err)
+	suite.Len(first, 4)
+
+	var previous files.FileEvent
+	for i, chained := range first {
+		event := chained.Event
+		suite.Equal(int64(i+1), event.Sequence)
+		suite.Equal(previous.Hash, event.PreviousHash)
+		suite.Len(event.Hash, 64)
+		previous = event
+	}
+
+	head, err := repo.GetFileEventChainHead(suite.defaultContext)
+	suite.NoError(err)
+	suite.Equal(store.FileEventChainHead{Sequence: 4, Hash: previous.Hash}, head)
+}
+
+func (suite *StoreSuite) TestVerifyFileEventChain() {
+	subject := suite.chainFileEvents(store.NewMemoryRepository())
+
+	verification, err := subject.VerifyFileEventChain(suite.defaultContext)
+
+	suite.NoError(err)
+	suite.True(verification.Verified)
+	suite.Equal(4, verification.EventsChecked)
+	suite.Equal(int64(4), verification.LastSequence)
+	suite.NotEmpty(verification.LastHash)
+	suite.Nil(verification.Break)
+}
+
+func (suite *StoreSuite) TestVerifyEmptyFileEventChain() {
+	cfg, _ := config.Get()
+	subject := store.NewStore(store.NewMemoryRepository(), suite.defaultClock, nil, cfg)
+
+	verification, err := subject.VerifyFileEventChain(suite.defaultContext)
+
+	suite.NoError(err)
+	suite.True(verification.Verified)
+	suite.Equal(0, verification.EventsChecked)
+}
+
+func (suite *StoreSuite) TestVerifyFileEventChainReportsFirstBreak() {
+	tests := map[string]struct {
+		tamper       func(events []files.FileEvent) []files.FileEvent
+		sequence     int64
+		reason       string
+		lastSequence int64
+	}{
+		"changed event": {
+			tamper: func(events []files.FileEvent) []files.FileEvent {
+				events[1].RequestedBy = &files.RequestedBy{ID: "someone-else"}
+				return events
+			},
+			sequence:     2,
+			reason:       files.ChainBreakHashMismatch,
+			lastSequence: 1,
+		},
+		"deleted event": {
+			tamper: func(events []files.FileEvent) []files.FileEvent {
+				return append(events[:2], events[3:]...)
+			},
+			sequence:     4,
+			reason:       files.ChainBreakMissingEvent,
+			lastSequence: 2,
+		},
+		"deleted last event": {
+			tamper: func(events []files.FileEvent) []files.FileEvent {
+				return events[:3]
+			},
+			sequence:     4,
+			reason:       files.ChainBreakMissingEvent,
+			lastSequence: 3,
+		},
+		"relinked event": {
+			tamper: func(events []files.FileEvent) []files.FileEvent {
+				events[2].PreviousHash = "0000"
+				return events
+			},
+			sequence:     3,
+			reason:       files.ChainBreakPreviousHashMismatch,
+			lastSequence: 2,
+		},
+	}
+
+	for name, test := range tests {
+		suite.Run(name, func() {
+			repo := &tamperedRepository{MemoryRepository: store.NewMemoryRepository(), tamper: test.tamper}
+			subject := suite.chainFileEvents(repo)
+
+			verification, err := subject.VerifyFileEventChain(suite.defaultContext)
+
+			suite.NoError(err)
+			suite.False(verification.Verified)
+			suite.Equal(&files.FileEventChainBreak{Sequence: test.sequence, Reason: test.reason}, verification.Break)
+			suite.Equal(test.lastSequence, verification.LastSequence, "the last intact event")
+		})
+	}
+}
+
+func (suite *StoreSuite) TestVerifyFileEventChainWithoutHead() {
+	repo := store.NewMemoryRepository()
+	suite.chainFileEvents(repo)
+	cfg, _ := config.Get()
+	subject := store.NewStore(&headlessRepository{repo}, suite.defaultClock, nil, cfg)
+
+	verification, err := subject.VerifyFileEventChain(suite.defaultContext)
+
+	suite.NoError(err)
+	suite.False(verification.Verified)
+	suite.Equal(&files.FileEventChainBreak{Sequence: 4, Reason: files.ChainBreakHeadMismatch}, verification.Break)
+}
+
+func (suite *StoreSuite) TestVerifyFileEventChainWithHMACKey() {
+	cfg, _ := config.Get()
+	keyed := *cfg
+	keyed.FileEventsHMACKey = "file-events-key"
+	otherKey := *cfg
+	otherKey.FileEventsHMACKey = "another-key"
+	repo := store.NewMemoryRepository()
+	subject := suite.chainFileEventsWith(repo, &keyed)
+
+	verification, err := subject.VerifyFileEventChain(suite.defaultContext)
+	suite.NoError(err)
+	suite.True(verification.Verified)
+
+	for name, c := range map[string]*config.Config{"no key": cfg, "another key": &otherKey} {
+		suite.Run(name, func() {
+			verification, err := store.NewStore(repo, suite.defaultClock, nil, c).VerifyFileEventChain(suite.defaultContext)
+
+			suite.NoError(err)
+			suite.False(verification.Verified)
+			suite.Equal(&files.FileEventChainBreak{Sequence: 1, Reason: files.ChainBreakHashMismatch}, verification.Break)
+		})
+	}
+}
+
+func (suite *StoreSuite) TestCreateFileEventChainsOnToEventWrittenByAnotherInstance() {
+	repo := &racingRepository{MemoryRepository: store.NewMemoryRepository()}
+	cfg, _ := config.Get()
+	subject := store.NewStore(repo, suite.defaultClock, nil, cfg)
+	other := store.NewStore(repo.MemoryRepository, suite.defaultClock, nil, cfg)
+	repo.race = func(ctx context.Context) error {
+		return other.CreateFileEvent(ctx, &files.FileEvent{Action: files.ActionRead, Resource: "/files/b.csv"})
+	}
+
+	event := &files.FileEvent{Action: files.ActionCreate, Resource: "/files/a.csv"}
+	suite.NoError(subject.CreateFileEvent(suite.defaultContext, event))
+
+	suite.Equal(int64(2), event.Sequence)
+	verification, err := subject.VerifyFileEventChain(suite.defaultContext)
+	suite.NoError(err)
+	suite.True(verification.Verified)
+	suite.Equal(2, verification.EventsChecked)
+}
+
+func (suite *StoreSuite) TestCreateFileEventInsertErrorTakesEventOutOfChain() {
+	repo := &racingRepository{MemoryRepository: store.NewMemoryRepository(), insertErr: errors.New("database error")}
+	cfg, _ := config.Get()
+	subject := store.NewStore(repo, suite.defaultClock, nil, cfg)
+
+	suite.Error(subject.CreateFileEvent(suite.defaultContext, &files.FileEvent{Action: files.ActionCreate, Resource: "/files/a.csv"}))
+	repo.insertErr = nil
+	event := &files.FileEvent{Action: files.ActionRead, Resource: "/files/a.csv"}
+	suite.NoError(subject.CreateFileEvent(suite.defaultContext, event))
+
+	suite.Equal(int64(1), event.Sequence)
+	verification, err := subject.VerifyFileEventChain(suite.defaultContext)
+	suite.NoError(err)
+	suite.True(verification.Verified)
+}
+
+func (suite *StoreSuite) TestCreateFileEventInsertErrorAfterAnotherInstanceChainedOnLeavesGap() {
+	repo := &racingRepository{MemoryRepository: store.NewMemoryRepository(), insertErr: errors.New("database error")}
+	cfg, _ := config.Get()
+	subject := store.NewStore(repo, suite.defaultClock, nil, cfg)
+	other := store.NewStore(repo.MemoryRepository, suite.defaultClock, nil, cfg)
+	repo.raceInsert = func(ctx context.Context) error {
+		return other.CreateFileEvent(ctx, &files.FileEvent{Action: files.ActionRead, Resource: "/files/b.csv"})
+	}
+
+	suite.Error(subject.CreateFileEvent(suite.defaultContext, &files.FileEvent{Action: files.ActionCreate, Resource: "/files/a.csv"}))
+
+	verification, err := subject.VerifyFileEventChain(suite.defaultContext)
+	suite.NoError(err)
+	suite.False(verification.Verified)
+	suite.Equal(&files.FileEventChainBreak{Sequence: 2, Reason: files.ChainBreakMissingEvent}, verification.Break)
+}
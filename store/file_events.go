@@ -2,20 +2,17 @@ package store
 
 import (
 	"context"
-	"time"
 
 	"github.com/ONSdigital/dp-files-api/files"
-	"github.com/ONSdigital/dp-mongodb/v3/mongodb"
 	"github.com/ONSdigital/log.go/v2/log"
-	"go.mongodb.org/mongo-driver/bson"
 )
 
-// CreateFileEvent inserts a new file event into the file_events collection
+// CreateFileEvent inserts a new file event into the file_events collection, at the end of the chain of events
 func (store *Store) CreateFileEvent(ctx context.Context, event *files.FileEvent) error {
 	now := store.clock.GetCurrentTime()
 	event.CreatedAt = &now
 
-	_, err := store.fileEventsCollection.Insert(ctx, event)
+	err := store.insertChainedFileEvents(ctx, []*files.FileEvent{event}, store.insertFileEvent)
 	if err != nil {
 		log.Error(ctx, "failed to insert file event", err, log.Data{
 			"action":   event.Action,
@@ -27,27 +24,65 @@ func (store *Store) CreateFileEvent(ctx context.Context, event *files.FileEvent)
 	return nil
 }
 
-// GetFileEvents retrieves file events with optional filters and pagination
-func (store *Store) GetFileEvents(ctx context.Context, limit, offset int, path string, after, before *time.Time) (*files.EventsList, error) {
-	filter := bson.M{}
+// CreateFileEvents inserts a batch of file events into the file_events collection, at the end of the chain of events
+// in the order given
+func (store *Store) CreateFileEvents(ctx context.Context, events []*files.FileEvent) error {
+	now := store.clock.GetCurrentTime()
+	for _, event := range events {
+		event.CreatedAt = &now
+	}
 
-	if path != "" {
-		filter["file.path"] = path
+	if err := store.insertChainedFileEvents(ctx, events, store.repo.InsertFileEvents); err != nil {
+		log.Error(ctx, "failed to insert file events", err, log.Data{"events": len(events)})
+		return err
 	}
 
-	if after != nil || before != nil {
-		createdAtFilter := bson.M{}
-		if after != nil {
-			createdAtFilter["$gte"] = after
+	return nil
+}
+
+// ExportFileEvents calls each with every file event matching the filter, oldest first. The events are read from a
+// cursor rather than a page at a time, so any number of them can be exported. It stops at the first error from each.
+func (store *Store) ExportFileEvents(ctx context.Context, filter FileEventFilter, each func(event files.FileEvent) error) error {
+	cursor, err := store.repo.FileEventsCursor(ctx, filter)
+	if err != nil {
+		log.Error(ctx, "failed to find file events to export", err)
+		return err
+	}
+	defer func() {
+		if err := cursor.Close(ctx); err != nil {
+			log.Error(ctx, "failed to close file events cursor", err)
 		}
-		if before != nil {
-			createdAtFilter["$lte"] = before
+	}()
+
+	for cursor.Next(ctx) {
+		event, err := cursor.Current()
+		if err != nil {
+			log.Error(ctx, "failed to decode file event", err)
+			return err
+		}
+		if err := each(event); err != nil {
+			return err
 		}
-		filter["created_at"] = createdAtFilter
 	}
+	if err := cursor.Err(); err != nil {
+		log.Error(ctx, "failed to read file events to export", err)
+		return err
+	}
+	return nil
+}
+
+// FileEventsQuery selects a page of the file events matching Filter, newest first unless OldestFirst is set
+type FileEventsQuery struct {
+	Filter      FileEventFilter
+	OldestFirst bool
+	Limit       int
+	Offset      int
+}
 
-	if path != "" {
-		count, err := store.fileEventsCollection.Count(ctx, bson.M{"file.path": path})
+// GetFileEvents retrieves file events with optional filters and pagination
+func (store *Store) GetFileEvents(ctx context.Context, query FileEventsQuery) (*files.EventsList, error) {
+	if path := query.Filter.Path; path != "" {
+		count, err := store.repo.CountFileEvents(ctx, FileEventFilter{Path: path})
 		if err != nil {
 			log.Error(ctx, "failed to check if path exists", err, log.Data{"path": path})
 			return nil, err
@@ -57,19 +92,13 @@ func (store *Store) GetFileEvents(ctx context.Context, limit, offset int, path s
 		}
 	}
 
-	totalCount, err := store.fileEventsCollection.Count(ctx, filter)
+	totalCount, err := store.repo.CountFileEvents(ctx, query.Filter)
 	if err != nil {
 		log.Error(ctx, "failed to count file events", err)
 		return nil, err
 	}
 
-	events := make([]files.FileEvent, 0)
-
-	_, err = store.fileEventsCollection.Find(ctx, filter, &events,
-		mongodb.Sort(bson.M{"created_at": -1}),
-		mongodb.Offset(offset),
-		mongodb.Limit(limit),
-	)
+	events, err := store.repo.FindFileEvents(ctx, query.Filter, query.OldestFirst, query.Offset, query.Limit)
 	if err != nil {
 		log.Error(ctx, "failed to find file events", err)
 		return nil, err
@@ -77,8 +106,8 @@ func (store *Store) GetFileEvents(ctx context.Context, limit, offset int, path s
 
 	eventsList := &files.EventsList{
 		Count:      len(events),
-		Limit:      limit,
-		Offset:     offset,
+		Limit:      query.Limit,
+		Offset:     query.Offset,
 		TotalCount: totalCount,
 		Items:      events,
 	}
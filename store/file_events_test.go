@@ -11,6 +11,7 @@ import (
 	"github.com/ONSdigital/dp-files-api/store"
 	mongodriver "github.com/ONSdigital/dp-mongodb/v3/mongodb"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 func (suite *StoreSuite) TestCreateFileEventSuccess() {
@@ -21,7 +22,7 @@ func (suite *StoreSuite) TestCreateFileEventSuccess() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(nil, nil, nil, &fileEventsCollection, nil, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{FileEvents: &fileEventsCollection, FileEventChain: &suite.defaultFileEventChainCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	event := &files.FileEvent{
 		RequestedBy: &files.RequestedBy{ID: "user123"},
@@ -50,7 +51,7 @@ func (suite *StoreSuite) TestCreateFileEventInsertError() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(nil, nil, nil, &fileEventsCollection, nil, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{FileEvents: &fileEventsCollection, FileEventChain: &suite.defaultFileEventChainCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	event := &files.FileEvent{
 		RequestedBy: &files.RequestedBy{ID: "user123"},
@@ -79,7 +80,7 @@ func (suite *StoreSuite) TestCreateFileEventSetsCreatedAtTimestamp() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(nil, nil, nil, &fileEventsCollection, nil, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{FileEvents: &fileEventsCollection, FileEventChain: &suite.defaultFileEventChainCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	event := &files.FileEvent{
 		RequestedBy: &files.RequestedBy{ID: "user123", Email: "user@example.com"},
@@ -106,7 +107,7 @@ func (suite *StoreSuite) TestCreateFileEventPreservesEventData() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(nil, nil, nil, &fileEventsCollection, nil, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{FileEvents: &fileEventsCollection, FileEventChain: &suite.defaultFileEventChainCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	event := &files.FileEvent{
 		RequestedBy: &files.RequestedBy{ID: "user456", Email: "test@example.com"},
@@ -145,9 +146,9 @@ func (suite *StoreSuite) TestGetFileEventsSuccess() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(nil, nil, nil, &fileEventsCollection, nil, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{FileEvents: &fileEventsCollection, FileEventChain: &suite.defaultFileEventChainCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
-	eventsList, err := subject.GetFileEvents(suite.defaultContext, 20, 0, "", nil, nil)
+	eventsList, err := subject.GetFileEvents(suite.defaultContext, store.FileEventsQuery{Limit: 20})
 
 	suite.NoError(err)
 	suite.NotNil(eventsList)
@@ -174,9 +175,9 @@ func (suite *StoreSuite) TestGetFileEventsWithPathFilter() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(nil, nil, nil, &fileEventsCollection, nil, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{FileEvents: &fileEventsCollection, FileEventChain: &suite.defaultFileEventChainCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
-	eventsList, err := subject.GetFileEvents(suite.defaultContext, 20, 0, "test-file.csv", nil, nil)
+	eventsList, err := subject.GetFileEvents(suite.defaultContext, store.FileEventsQuery{Filter: store.FileEventFilter{Path: "test-file.csv"}, Limit: 20})
 
 	suite.NoError(err)
 	suite.NotNil(eventsList)
@@ -185,6 +186,68 @@ func (suite *StoreSuite) TestGetFileEventsWithPathFilter() {
 	suite.Equal("test-file.csv", filterMap["file.path"])
 }
 
+func (suite *StoreSuite) TestGetFileEventsWithFileAndRequesterFilters() {
+	var capturedFilter interface{}
+
+	fileEventsCollection := mock.MongoCollectionMock{
+		CountFunc: func(ctx context.Context, filter interface{}, opts ...mongodriver.FindOption) (int, error) {
+			return 1, nil
+		},
+		FindFunc: func(ctx context.Context, filter interface{}, results interface{}, opts ...mongodriver.FindOption) (int, error) {
+			capturedFilter = filter
+			events := results.(*[]files.FileEvent)
+			*events = []files.FileEvent{}
+			return 0, nil
+		},
+	}
+
+	cfg, _ := config.Get()
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{FileEvents: &fileEventsCollection, FileEventChain: &suite.defaultFileEventChainCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
+
+	_, err := subject.GetFileEvents(suite.defaultContext, store.FileEventsQuery{
+		Filter: store.FileEventFilter{
+			PathPrefix:    "data/",
+			Action:        files.ActionUpdate,
+			RequestedByID: "user123",
+			CollectionID:  "collection-1",
+			BundleID:      "bundle-1",
+			DatasetID:     "cpih",
+			Edition:       "2026",
+		},
+		OldestFirst: true,
+		Limit:       20,
+	})
+
+	suite.NoError(err)
+	suite.Equal(bson.M{
+		"file.path":                    primitive.Regex{Pattern: "^data/"},
+		"action":                       files.ActionUpdate,
+		"requested_by.id":              "user123",
+		"file.collection_id":           "collection-1",
+		"file.bundle_id":               "bundle-1",
+		"file.content_item.dataset_id": "cpih",
+		"file.content_item.edition":    "2026",
+	}, capturedFilter)
+}
+
+func (suite *StoreSuite) TestFileEventIndexesAreNamedAsMongoDBWould() {
+	names := make([]string, 0, len(store.FileEventIndexes))
+	for _, index := range store.FileEventIndexes {
+		names = append(names, index.Name)
+	}
+
+	suite.Equal([]string{
+		"sequence_1",
+		"created_at_-1",
+		"file.path_1_created_at_-1",
+		"action_1_created_at_-1",
+		"requested_by.id_1_created_at_-1",
+		"file.collection_id_1_created_at_-1",
+		"file.bundle_id_1_created_at_-1",
+		"file.content_item.dataset_id_1_file.content_item.edition_1_created_at_-1",
+	}, names)
+}
+
 func (suite *StoreSuite) TestGetFileEventsWithDateFilters() {
 	after := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
 	before := time.Date(2025, 12, 31, 23, 59, 59, 0, time.UTC)
@@ -203,9 +266,9 @@ func (suite *StoreSuite) TestGetFileEventsWithDateFilters() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(nil, nil, nil, &fileEventsCollection, nil, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{FileEvents: &fileEventsCollection, FileEventChain: &suite.defaultFileEventChainCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
-	eventsList, err := subject.GetFileEvents(suite.defaultContext, 20, 0, "", &after, &before)
+	eventsList, err := subject.GetFileEvents(suite.defaultContext, store.FileEventsQuery{Filter: store.FileEventFilter{After: &after, Before: &before}, Limit: 20})
 
 	suite.NoError(err)
 	suite.NotNil(eventsList)
@@ -230,9 +293,9 @@ func (suite *StoreSuite) TestGetFileEventsWithPagination() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(nil, nil, nil, &fileEventsCollection, nil, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{FileEvents: &fileEventsCollection, FileEventChain: &suite.defaultFileEventChainCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
-	eventsList, err := subject.GetFileEvents(suite.defaultContext, 10, 50, "", nil, nil)
+	eventsList, err := subject.GetFileEvents(suite.defaultContext, store.FileEventsQuery{Limit: 10, Offset: 50})
 
 	suite.NoError(err)
 	suite.NotNil(eventsList)
@@ -249,9 +312,9 @@ func (suite *StoreSuite) TestGetFileEventsPathNotFound() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(nil, nil, nil, &fileEventsCollection, nil, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{FileEvents: &fileEventsCollection, FileEventChain: &suite.defaultFileEventChainCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
-	eventsList, err := subject.GetFileEvents(suite.defaultContext, 20, 0, "nonexistent.csv", nil, nil)
+	eventsList, err := subject.GetFileEvents(suite.defaultContext, store.FileEventsQuery{Filter: store.FileEventFilter{Path: "nonexistent.csv"}, Limit: 20})
 
 	suite.Nil(eventsList)
 	suite.Error(err)
@@ -271,9 +334,9 @@ func (suite *StoreSuite) TestGetFileEventsCountError() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(nil, nil, nil, &fileEventsCollection, nil, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{FileEvents: &fileEventsCollection, FileEventChain: &suite.defaultFileEventChainCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
-	eventsList, err := subject.GetFileEvents(suite.defaultContext, 20, 0, "", nil, nil)
+	eventsList, err := subject.GetFileEvents(suite.defaultContext, store.FileEventsQuery{Limit: 20})
 
 	logEvent := suite.logInterceptor.GetLogEvent()
 
@@ -299,9 +362,9 @@ func (suite *StoreSuite) TestGetFileEventsFindError() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(nil, nil, nil, &fileEventsCollection, nil, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{FileEvents: &fileEventsCollection, FileEventChain: &suite.defaultFileEventChainCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
-	eventsList, err := subject.GetFileEvents(suite.defaultContext, 20, 0, "", nil, nil)
+	eventsList, err := subject.GetFileEvents(suite.defaultContext, store.FileEventsQuery{Limit: 20})
 
 	logEvent := suite.logInterceptor.GetLogEvent()
 
@@ -334,9 +397,9 @@ func (suite *StoreSuite) TestGetFileEventsWithAllFilters() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(nil, nil, nil, &fileEventsCollection, nil, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{FileEvents: &fileEventsCollection, FileEventChain: &suite.defaultFileEventChainCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
-	eventsList, err := subject.GetFileEvents(suite.defaultContext, 50, 10, "data.csv", &after, &before)
+	eventsList, err := subject.GetFileEvents(suite.defaultContext, store.FileEventsQuery{Filter: store.FileEventFilter{Path: "data.csv", After: &after, Before: &before}, Limit: 50, Offset: 10})
 
 	suite.NoError(err)
 	suite.NotNil(eventsList)
@@ -361,9 +424,9 @@ func (suite *StoreSuite) TestGetFileEventsEmptyResult() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(nil, nil, nil, &fileEventsCollection, nil, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{FileEvents: &fileEventsCollection, FileEventChain: &suite.defaultFileEventChainCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
-	eventsList, err := subject.GetFileEvents(suite.defaultContext, 20, 0, "", nil, nil)
+	eventsList, err := subject.GetFileEvents(suite.defaultContext, store.FileEventsQuery{Limit: 20})
 
 	suite.NoError(err)
 	suite.NotNil(eventsList)
@@ -385,9 +448,9 @@ func (suite *StoreSuite) TestGetFileEventsPathCheckError() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(nil, nil, nil, &fileEventsCollection, nil, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{FileEvents: &fileEventsCollection, FileEventChain: &suite.defaultFileEventChainCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
-	eventsList, err := subject.GetFileEvents(suite.defaultContext, 20, 0, "test-file.csv", nil, nil)
+	eventsList, err := subject.GetFileEvents(suite.defaultContext, store.FileEventsQuery{Filter: store.FileEventFilter{Path: "test-file.csv"}, Limit: 20})
 
 	logEvent := suite.logInterceptor.GetLogEvent()
 
@@ -396,3 +459,46 @@ func (suite *StoreSuite) TestGetFileEventsPathCheckError() {
 	suite.ErrorIs(err, expectedError)
 	suite.Equal("failed to check if path exists", logEvent)
 }
+
+func (suite *StoreSuite) TestExportFileEventsOldestFirst() {
+	repo := store.NewMemoryRepository()
+	base := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	for _, e := range []struct {
+		hours int
+		path  string
+	}{{2, "a.csv"}, {0, "a.csv"}, {1, "b.csv"}, {3, "a.csv"}} {
+		createdAt := base.Add(time.Duration(e.hours) * time.Hour)
+		suite.NoError(repo.InsertFileEvent(suite.defaultContext, &files.FileEvent{CreatedAt: &createdAt, Action: files.ActionRead, File: &files.StoredRegisteredMetaData{Path: e.path}}))
+	}
+	cfg, _ := config.Get()
+	subject := store.NewStore(repo, suite.defaultClock, nil, cfg)
+
+	before := base.Add(2 * time.Hour)
+	var exported []time.Time
+	err := subject.ExportFileEvents(suite.defaultContext, store.FileEventFilter{Path: "a.csv", Before: &before}, func(event files.FileEvent) error {
+		exported = append(exported, *event.CreatedAt)
+		return nil
+	})
+
+	suite.NoError(err)
+	suite.Equal([]time.Time{base, base.Add(2 * time.Hour)}, exported)
+}
+
+func (suite *StoreSuite) TestExportFileEventsStopsAtFirstError() {
+	repo := store.NewMemoryRepository()
+	for i := 0; i < 3; i++ {
+		suite.NoError(repo.InsertFileEvent(suite.defaultContext, &files.FileEvent{Action: files.ActionRead}))
+	}
+	cfg, _ := config.Get()
+	subject := store.NewStore(repo, suite.defaultClock, nil, cfg)
+	writeErr := errors.New("client went away")
+
+	exported := 0
+	err := subject.ExportFileEvents(suite.defaultContext, store.FileEventFilter{}, func(event files.FileEvent) error {
+		exported++
+		return writeErr
+	})
+
+	suite.ErrorIs(err, writeErr)
+	suite.Equal(1, exported)
+}
@@ -0,0 +1,131 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ONSdigital/dp-files-api/files"
+	mongodriver "github.com/ONSdigital/dp-mongodb/v3/mongodb"
+	"github.com/ONSdigital/log.go/v2/log"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// GetFileVersion returns the given version of the file at path. The current version is read from the file's metadata,
+// earlier versions from the records kept when the file was replaced.
+func (store *Store) GetFileVersion(ctx context.Context, path string, version int) (files.StoredRegisteredMetaData, error) {
+	logdata := log.Data{"path": path, "version": version}
+
+	metadata, err := store.GetFileMetadata(ctx, path)
+	if err != nil {
+		return metadata, err
+	}
+	if currentVersion(metadata) == version {
+		return metadata, nil
+	}
+
+	fileVersion, err := store.repo.GetFileVersion(ctx, path, version)
+	if err != nil {
+		if errors.Is(err, mongodriver.ErrNoDocumentFound) {
+			log.Error(ctx, "file version not found", err, logdata)
+			return fileVersion, ErrFileVersionNotFound
+		}
+		log.Error(ctx, "failed to get file version", err, logdata)
+		return fileVersion, err
+	}
+
+	return fileVersion, nil
+}
+
+// GetFileVersions lists every version of the file at path, oldest first
+func (store *Store) GetFileVersions(ctx context.Context, path string) (*files.FileVersionsList, error) {
+	metadata, err := store.GetFileMetadata(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	previous, err := store.repo.FindFileVersions(ctx, path)
+	if err != nil {
+		log.Error(ctx, "failed to find file versions", err, log.Data{"path": path})
+		return nil, err
+	}
+
+	metadata.Version = currentVersion(metadata)
+	versions := make([]files.FileVersion, 0, len(previous)+1)
+	for _, v := range append(previous, metadata) {
+		versions = append(versions, fileVersion(v))
+	}
+
+	return &files.FileVersionsList{Path: path, Count: len(versions), Items: versions}, nil
+}
+
+// archiveFileVersion keeps metadata as a version of its file before the file is replaced. The same file kept by an
+// earlier attempt that failed part way through is left as it was, but a different file already kept with the version
+// is ErrFileVersionExists, so that no version is lost.
+func (store *Store) archiveFileVersion(ctx context.Context, metadata files.StoredRegisteredMetaData) error {
+	metadata.Version = currentVersion(metadata)
+	logData := log.Data{"path": metadata.Path, "version": metadata.Version}
+
+	err := store.repo.InsertFileVersion(ctx, metadata)
+	if err == nil {
+		return nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		log.Error(ctx, "failed to keep file version", err, logData)
+		return err
+	}
+
+	kept, err := store.repo.GetFileVersion(ctx, metadata.Path, metadata.Version)
+	if err != nil {
+		log.Error(ctx, "failed to get kept file version", err, logData)
+		return err
+	}
+	if !kept.CreatedAt.Equal(metadata.CreatedAt) || kept.Etag != metadata.Etag {
+		log.Error(ctx, "a different file is already kept as this version", ErrFileVersionExists, logData)
+		return ErrFileVersionExists
+	}
+	return nil
+}
+
+// nextFileVersions gives the version each of paths is registered as, one after the highest version kept for it, so a
+// path registered again once its file has been removed carries on from its earlier versions
+func (store *Store) nextFileVersions(ctx context.Context, paths []string) (map[string]int, error) {
+	latest, err := store.repo.LatestFileVersions(ctx, paths)
+	if err != nil {
+		log.Error(ctx, "failed to find latest file versions", err, log.Data{"paths": paths})
+		return nil, err
+	}
+
+	next := make(map[string]int, len(paths))
+	for _, path := range paths {
+		next[path] = latest[path] + 1
+	}
+	return next, nil
+}
+
+// currentVersion is the version number of the file described by metadata. Files registered before versions were
+// kept have no number, and are the first version.
+func currentVersion(metadata files.StoredRegisteredMetaData) int {
+	if metadata.Version == 0 {
+		return 1
+	}
+	return metadata.Version
+}
+
+func fileVersion(metadata files.StoredRegisteredMetaData) files.FileVersion {
+	return files.FileVersion{
+		Version:           metadata.Version,
+		State:             metadata.State,
+		Etag:              metadata.Etag,
+		ChecksumSHA256:    metadata.ChecksumSHA256,
+		ChecksumMD5:       metadata.ChecksumMD5,
+		SizeInBytes:       metadata.SizeInBytes,
+		Type:              metadata.Type,
+		CollectionID:      metadata.CollectionID,
+		BundleID:          metadata.BundleID,
+		CreatedAt:         metadata.CreatedAt,
+		LastModified:      metadata.LastModified,
+		UploadCompletedAt: metadata.UploadCompletedAt,
+		PublishedAt:       metadata.PublishedAt,
+		MovedAt:           metadata.MovedAt,
+	}
+}
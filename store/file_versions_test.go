@@ -0,0 +1,274 @@
+package store_test
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ONSdigital/dp-files-api/config"
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/dp-files-api/mongo/mock"
+	"github.com/ONSdigital/dp-files-api/store"
+	mongodriver "github.com/ONSdigital/dp-mongodb/v3/mongodb"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func (suite *StoreSuite) TestMarkUploadCompleteWithNewEtagKeepsPreviousVersion() {
+	repo := store.NewMemoryRepository()
+	suite.NoError(repo.InsertMetadata(suite.defaultContext, files.StoredRegisteredMetaData{
+		Path:         suite.path,
+		CollectionID: &suite.defaultCollectionID,
+		State:        store.StateUploaded,
+		Etag:         "first",
+		Version:      1,
+	}))
+
+	cfg, _ := config.Get()
+	subject := store.NewStore(repo, suite.defaultClock, nil, cfg)
+
+	suite.NoError(subject.MarkUploadComplete(suite.defaultContext, files.FileEtagChange{Path: suite.path, Etag: "second"}))
+
+	current, err := subject.GetFileMetadata(suite.defaultContext, suite.path)
+	suite.NoError(err)
+	suite.Equal(2, current.Version)
+	suite.Equal("second", current.Etag)
+
+	previous, err := subject.GetFileVersion(suite.defaultContext, suite.path, 1)
+	suite.NoError(err)
+	suite.Equal("first", previous.Etag)
+	suite.Equal(1, previous.Version)
+}
+
+func (suite *StoreSuite) TestMarkUploadCompleteWithSameEtagKeepsVersion() {
+	repo := store.NewMemoryRepository()
+	suite.NoError(repo.InsertMetadata(suite.defaultContext, files.StoredRegisteredMetaData{
+		Path:         suite.path,
+		CollectionID: &suite.defaultCollectionID,
+		State:        store.StateUploaded,
+		Etag:         "first",
+		Version:      1,
+	}))
+
+	cfg, _ := config.Get()
+	subject := store.NewStore(repo, suite.defaultClock, nil, cfg)
+
+	suite.NoError(subject.MarkUploadComplete(suite.defaultContext, files.FileEtagChange{Path: suite.path, Etag: "first"}))
+
+	versions, err := subject.GetFileVersions(suite.defaultContext, suite.path)
+	suite.NoError(err)
+	suite.Equal(1, versions.Count)
+	suite.Equal(1, versions.Items[0].Version)
+}
+
+func (suite *StoreSuite) TestRegisterFileUploadFromDifferentCollectionKeepsPreviousVersion() {
+	repo := store.NewMemoryRepository()
+	otherCollectionID := "other-collection"
+	suite.NoError(repo.InsertMetadata(suite.defaultContext, files.StoredRegisteredMetaData{
+		Path:         suite.path,
+		CollectionID: &otherCollectionID,
+		State:        store.StateUploaded,
+		Etag:         "first",
+	}))
+
+	cfg, _ := config.Get()
+	subject := store.NewStore(repo, suite.defaultClock, nil, cfg)
+
+	suite.NoError(subject.RegisterFileUpload(suite.defaultContext, files.StoredRegisteredMetaData{
+		Path:         suite.path,
+		CollectionID: &suite.defaultCollectionID,
+	}))
+
+	versions, err := subject.GetFileVersions(suite.defaultContext, suite.path)
+	suite.NoError(err)
+	suite.Equal(suite.path, versions.Path)
+	suite.Equal(2, versions.Count)
+	suite.Equal(1, versions.Items[0].Version)
+	suite.Equal("first", versions.Items[0].Etag)
+	suite.Equal(otherCollectionID, *versions.Items[0].CollectionID)
+	suite.Equal(2, versions.Items[1].Version)
+	suite.Equal(store.StateCreated, versions.Items[1].State)
+	suite.Equal(suite.defaultCollectionID, *versions.Items[1].CollectionID)
+}
+
+func (suite *StoreSuite) TestRegisterFileUploadStartsAtFirstVersion() {
+	repo := store.NewMemoryRepository()
+	cfg, _ := config.Get()
+	subject := store.NewStore(repo, suite.defaultClock, nil, cfg)
+
+	suite.NoError(subject.RegisterFileUpload(suite.defaultContext, files.StoredRegisteredMetaData{
+		Path:         suite.path,
+		CollectionID: &suite.defaultCollectionID,
+	}))
+
+	metadata, err := subject.GetFileMetadata(suite.defaultContext, suite.path)
+	suite.NoError(err)
+	suite.Equal(1, metadata.Version)
+}
+
+func (suite *StoreSuite) TestRegisterFileUploadAfterRemovalContinuesFromKeptVersions() {
+	repo := store.NewMemoryRepository()
+	suite.NoError(repo.InsertFileVersion(suite.defaultContext, files.StoredRegisteredMetaData{Path: suite.path, Version: 1}))
+	suite.NoError(repo.InsertFileVersion(suite.defaultContext, files.StoredRegisteredMetaData{Path: suite.path, Version: 2}))
+
+	cfg, _ := config.Get()
+	subject := store.NewStore(repo, suite.defaultClock, nil, cfg)
+
+	suite.NoError(subject.RegisterFileUpload(suite.defaultContext, files.StoredRegisteredMetaData{
+		Path:         suite.path,
+		CollectionID: &suite.defaultCollectionID,
+	}))
+
+	metadata, err := subject.GetFileMetadata(suite.defaultContext, suite.path)
+	suite.NoError(err)
+	suite.Equal(3, metadata.Version)
+}
+
+func (suite *StoreSuite) TestRegisterFileUploadsContinuesFromKeptVersions() {
+	repo := store.NewMemoryRepository()
+	suite.NoError(repo.InsertFileVersion(suite.defaultContext, files.StoredRegisteredMetaData{Path: "kept.csv", Version: 4}))
+
+	cfg, _ := config.Get()
+	subject := store.NewStore(repo, suite.defaultClock, nil, cfg)
+
+	_, err := subject.RegisterFileUploads(suite.defaultContext, []files.StoredRegisteredMetaData{
+		{Path: "kept.csv", CollectionID: &suite.defaultCollectionID},
+		{Path: "new.csv", CollectionID: &suite.defaultCollectionID},
+	})
+	suite.NoError(err)
+
+	kept, err := subject.GetFileMetadata(suite.defaultContext, "kept.csv")
+	suite.NoError(err)
+	suite.Equal(5, kept.Version)
+	added, err := subject.GetFileMetadata(suite.defaultContext, "new.csv")
+	suite.NoError(err)
+	suite.Equal(1, added.Version)
+}
+
+func (suite *StoreSuite) TestMarkUploadCompleteFailsWhenAnotherFileIsKeptAsVersion() {
+	repo := store.NewMemoryRepository()
+	suite.NoError(repo.InsertMetadata(suite.defaultContext, files.StoredRegisteredMetaData{
+		Path:         suite.path,
+		CollectionID: &suite.defaultCollectionID,
+		State:        store.StateUploaded,
+		Etag:         "first",
+		Version:      1,
+	}))
+	suite.NoError(repo.InsertFileVersion(suite.defaultContext, files.StoredRegisteredMetaData{Path: suite.path, Version: 1, Etag: "other"}))
+
+	cfg, _ := config.Get()
+	subject := store.NewStore(repo, suite.defaultClock, nil, cfg)
+
+	err := subject.MarkUploadComplete(suite.defaultContext, files.FileEtagChange{Path: suite.path, Etag: "second"})
+
+	suite.ErrorIs(err, store.ErrFileVersionExists)
+	current, _ := subject.GetFileMetadata(suite.defaultContext, suite.path)
+	suite.Equal("first", current.Etag)
+}
+
+func (suite *StoreSuite) TestMarkUploadCompleteRetriedAfterVersionWasKept() {
+	repo := store.NewMemoryRepository()
+	first := files.StoredRegisteredMetaData{
+		Path:         suite.path,
+		CollectionID: &suite.defaultCollectionID,
+		State:        store.StateUploaded,
+		Etag:         "first",
+		Version:      1,
+	}
+	suite.NoError(repo.InsertMetadata(suite.defaultContext, first))
+	suite.NoError(repo.InsertFileVersion(suite.defaultContext, first))
+
+	cfg, _ := config.Get()
+	subject := store.NewStore(repo, suite.defaultClock, nil, cfg)
+
+	suite.NoError(subject.MarkUploadComplete(suite.defaultContext, files.FileEtagChange{Path: suite.path, Etag: "second"}))
+
+	current, err := subject.GetFileMetadata(suite.defaultContext, suite.path)
+	suite.NoError(err)
+	suite.Equal(2, current.Version)
+}
+
+func (suite *StoreSuite) TestGetFileVersionsTreatsUnnumberedFileAsFirstVersion() {
+	repo := store.NewMemoryRepository()
+	suite.NoError(repo.InsertMetadata(suite.defaultContext, files.StoredRegisteredMetaData{Path: suite.path, State: store.StatePublished}))
+
+	cfg, _ := config.Get()
+	subject := store.NewStore(repo, suite.defaultClock, nil, cfg)
+
+	versions, err := subject.GetFileVersions(suite.defaultContext, suite.path)
+	suite.NoError(err)
+	suite.Equal(1, versions.Count)
+	suite.Equal(1, versions.Items[0].Version)
+
+	current, err := subject.GetFileVersion(suite.defaultContext, suite.path, 1)
+	suite.NoError(err)
+	suite.Equal(store.StatePublished, current.State)
+}
+
+func (suite *StoreSuite) TestGetFileVersionsFileNotRegistered() {
+	cfg, _ := config.Get()
+	subject := store.NewStore(store.NewMemoryRepository(), suite.defaultClock, nil, cfg)
+
+	_, err := subject.GetFileVersions(suite.defaultContext, suite.path)
+
+	suite.ErrorIs(err, store.ErrFileNotRegistered)
+}
+
+func (suite *StoreSuite) TestGetFileVersionNotFound() {
+	metadataBytes, _ := bson.Marshal(files.StoredRegisteredMetaData{Path: suite.path, Version: 2})
+	metadataColl := mock.MongoCollectionMock{
+		FindOneFunc: CollectionFindOneSetsResultAndReturnsNil(metadataBytes),
+	}
+	versionsColl := mock.MongoCollectionMock{
+		FindOneFunc: CollectionFindOneReturnsError(mongodriver.ErrNoDocumentFound),
+	}
+
+	cfg, _ := config.Get()
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &metadataColl, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &versionsColl, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
+
+	_, err := subject.GetFileVersion(suite.defaultContext, suite.path, 5)
+
+	suite.ErrorIs(err, store.ErrFileVersionNotFound)
+}
+
+func (suite *StoreSuite) TestMarkUploadCompleteFailsWhenVersionCannotBeKept() {
+	metadataBytes, _ := bson.Marshal(files.StoredRegisteredMetaData{Path: suite.path, State: store.StateUploaded, Etag: "first"})
+	metadataColl := mock.MongoCollectionMock{
+		FindOneFunc: CollectionFindOneSetsResultAndReturnsNil(metadataBytes),
+		UpdateFunc:  CollectionUpdateReturnsNilAndNil(),
+	}
+	versionsColl := mock.MongoCollectionMock{
+		InsertFunc: CollectionInsertReturnsNilAndError(errors.New("broken")),
+	}
+
+	cfg, _ := config.Get()
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &metadataColl, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &versionsColl, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
+
+	err := subject.MarkUploadComplete(suite.defaultContext, files.FileEtagChange{Path: suite.path, Etag: "second"})
+
+	suite.Error(err)
+	suite.Empty(metadataColl.UpdateCalls())
+}
+
+func (suite *StoreSuite) TestRegisterFileUploadFailsWhenKeptVersionsCannotBeFound() {
+	suite.logInterceptor.Start()
+	defer suite.logInterceptor.Stop()
+
+	expectedError := errors.New("aggregate error")
+	metadataColl := mock.MongoCollectionMock{
+		FindOneFunc: CollectionFindOneReturnsError(mongodriver.ErrNoDocumentFound),
+		InsertFunc:  CollectionInsertReturnsNilAndNil(),
+	}
+	versionsColl := mock.MongoCollectionMock{
+		AggregateFunc: func(ctx context.Context, pipeline interface{}, results interface{}) error {
+			return expectedError
+		},
+	}
+
+	cfg, _ := config.Get()
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &metadataColl, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &versionsColl, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
+
+	err := subject.RegisterFileUpload(suite.defaultContext, files.StoredRegisteredMetaData{Path: suite.path, CollectionID: &suite.defaultCollectionID})
+
+	suite.ErrorIs(err, expectedError)
+	suite.True(suite.logInterceptor.IsEventPresent("failed to find latest file versions"))
+	suite.Empty(metadataColl.InsertCalls())
+}
@@ -0,0 +1,101 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ONSdigital/dp-files-api/files"
+	mongodriver "github.com/ONSdigital/dp-mongodb/v3/mongodb"
+	"github.com/ONSdigital/log.go/v2/log"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// maxIdempotencyClaimAttempts bounds how often a claim is retried when the record it found has gone by the time it is
+// read, or has been replaced by the time it is deleted
+const maxIdempotencyClaimAttempts = 3
+
+// ClaimIdempotencyKey claims key for a request with the given fingerprint, returning the new record and true when the
+// request is to be handled. When the key has already been used for the same request, the record of it is returned
+// with false so that its response can be replayed, or ErrIdempotencyKeyInProgress is returned while it is still
+// being handled. ErrIdempotencyKeyReused is returned when the key was used for a different request. Records are kept
+// for IdempotencyKeyTTL, and a claim whose response has not been recorded within IdempotencyKeyClaimTimeout is taken
+// to have been abandoned, so that the request can be retried.
+func (store *Store) ClaimIdempotencyKey(ctx context.Context, key, fingerprint string) (files.IdempotencyRecord, bool, error) {
+	logData := log.Data{"idempotency_key": key}
+
+	for attempt := 1; ; attempt++ {
+		now := store.clock.GetCurrentTime()
+		record := files.IdempotencyRecord{
+			Key:         key,
+			Fingerprint: fingerprint,
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(store.cfg.IdempotencyKeyTTL),
+		}
+
+		err := store.repo.InsertIdempotencyRecord(ctx, record)
+		if err == nil {
+			return record, true, nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			log.Error(ctx, "claim idempotency key: error while inserting record", err, logData)
+			return files.IdempotencyRecord{}, false, err
+		}
+
+		existing, err := store.repo.GetIdempotencyRecord(ctx, key)
+		if errors.Is(err, mongodriver.ErrNoDocumentFound) && attempt < maxIdempotencyClaimAttempts {
+			continue
+		}
+		if err != nil {
+			log.Error(ctx, "claim idempotency key: error while getting record", err, logData)
+			return files.IdempotencyRecord{}, false, err
+		}
+
+		if store.isIdempotencyRecordLive(existing) {
+			switch {
+			case existing.Fingerprint != fingerprint:
+				return files.IdempotencyRecord{}, false, ErrIdempotencyKeyReused
+			case existing.Response != nil:
+				return existing, false, nil
+			default:
+				return files.IdempotencyRecord{}, false, ErrIdempotencyKeyInProgress
+			}
+		}
+
+		// the record may outlive its expiry until MongoDB next removes expired documents
+		if _, err := store.repo.DeleteIdempotencyRecord(ctx, key, existing.CreatedAt); err != nil {
+			log.Error(ctx, "claim idempotency key: error while deleting expired record", err, logData)
+			return files.IdempotencyRecord{}, false, err
+		}
+		if attempt == maxIdempotencyClaimAttempts {
+			return files.IdempotencyRecord{}, false, ErrIdempotencyKeyInProgress
+		}
+	}
+}
+
+// isIdempotencyRecordLive reports whether a record still holds its key: until it expires, and while its request is
+// being handled, until the claim times out
+func (store *Store) isIdempotencyRecordLive(record files.IdempotencyRecord) bool {
+	now := store.clock.GetCurrentTime()
+	if !now.Before(record.ExpiresAt) {
+		return false
+	}
+	return record.Response != nil || now.Before(record.CreatedAt.Add(store.cfg.IdempotencyKeyClaimTimeout))
+}
+
+// CompleteIdempotencyKey records the response to the request that claimed the key, to be replayed to its retries
+func (store *Store) CompleteIdempotencyKey(ctx context.Context, record files.IdempotencyRecord, response files.IdempotentResponse) error {
+	if err := store.repo.CompleteIdempotencyRecord(ctx, record.Key, record.CreatedAt, response); err != nil {
+		log.Error(ctx, "complete idempotency key: error while recording response", err, log.Data{"idempotency_key": record.Key})
+		return err
+	}
+	return nil
+}
+
+// ReleaseIdempotencyKey forgets the claim on a key whose request could not be handled, so that it can be retried
+func (store *Store) ReleaseIdempotencyKey(ctx context.Context, record files.IdempotencyRecord) error {
+	if _, err := store.repo.DeleteIdempotencyRecord(ctx, record.Key, record.CreatedAt); err != nil {
+		log.Error(ctx, "release idempotency key: error while deleting record", err, log.Data{"idempotency_key": record.Key})
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package store_test
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/ONSdigital/dp-files-api/config"
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/dp-files-api/store"
+)
+
+const idempotencyKey = "0f8fad5b-d9cb-469f-a165-70867728950e"
+
+func (suite *StoreSuite) idempotencyStore() (*store.Store, *advancingClock) {
+	clk := &advancingClock{now: suite.defaultClock.GetCurrentTime()}
+	cfg, _ := config.Get()
+	return store.NewStore(store.NewMemoryRepository(), clk, nil, cfg), clk
+}
+
+func (suite *StoreSuite) TestClaimIdempotencyKeyReplaysCompletedRequest() {
+	subject, _ := suite.idempotencyStore()
+	response := files.IdempotentResponse{
+		StatusCode: http.StatusCreated,
+		Header:     map[string][]string{"Content-Type": {"application/json"}},
+		Body:       []byte(`{"path":"data/file.csv"}`),
+	}
+
+	record, claimed, err := subject.ClaimIdempotencyKey(suite.defaultContext, idempotencyKey, "fingerprint")
+	suite.NoError(err)
+	suite.True(claimed)
+	suite.Equal(suite.defaultClock.GetCurrentTime().Add(24*time.Hour), record.ExpiresAt)
+	suite.NoError(subject.CompleteIdempotencyKey(suite.defaultContext, record, response))
+
+	replayed, claimed, err := subject.ClaimIdempotencyKey(suite.defaultContext, idempotencyKey, "fingerprint")
+
+	suite.NoError(err)
+	suite.False(claimed)
+	suite.Equal(&response, replayed.Response)
+}
+
+func (suite *StoreSuite) TestClaimIdempotencyKeyRejections() {
+	subject, _ := suite.idempotencyStore()
+	record, _, err := subject.ClaimIdempotencyKey(suite.defaultContext, idempotencyKey, "fingerprint")
+	suite.NoError(err)
+
+	_, _, err = subject.ClaimIdempotencyKey(suite.defaultContext, idempotencyKey, "fingerprint")
+	suite.ErrorIs(err, store.ErrIdempotencyKeyInProgress)
+
+	suite.NoError(subject.CompleteIdempotencyKey(suite.defaultContext, record, files.IdempotentResponse{StatusCode: http.StatusOK}))
+	_, _, err = subject.ClaimIdempotencyKey(suite.defaultContext, idempotencyKey, "another fingerprint")
+	suite.ErrorIs(err, store.ErrIdempotencyKeyReused)
+}
+
+func (suite *StoreSuite) TestClaimIdempotencyKeyAfterRelease() {
+	subject, _ := suite.idempotencyStore()
+	record, _, err := subject.ClaimIdempotencyKey(suite.defaultContext, idempotencyKey, "fingerprint")
+	suite.NoError(err)
+
+	suite.NoError(subject.ReleaseIdempotencyKey(suite.defaultContext, record))
+	_, claimed, err := subject.ClaimIdempotencyKey(suite.defaultContext, idempotencyKey, "fingerprint")
+
+	suite.NoError(err)
+	suite.True(claimed)
+}
+
+func (suite *StoreSuite) TestClaimIdempotencyKeyTakesOverAbandonedClaim() {
+	subject, clk := suite.idempotencyStore()
+	abandoned, _, err := subject.ClaimIdempotencyKey(suite.defaultContext, idempotencyKey, "fingerprint")
+	suite.NoError(err)
+
+	clk.now = clk.now.Add(time.Minute)
+	record, claimed, err := subject.ClaimIdempotencyKey(suite.defaultContext, idempotencyKey, "fingerprint")
+	suite.NoError(err)
+	suite.True(claimed)
+
+	// the abandoned request finishing late leaves the new claim alone
+	suite.NoError(subject.CompleteIdempotencyKey(suite.defaultContext, abandoned, files.IdempotentResponse{StatusCode: http.StatusOK}))
+	_, _, err = subject.ClaimIdempotencyKey(suite.defaultContext, idempotencyKey, "fingerprint")
+	suite.ErrorIs(err, store.ErrIdempotencyKeyInProgress)
+
+	suite.NoError(subject.CompleteIdempotencyKey(suite.defaultContext, record, files.IdempotentResponse{StatusCode: http.StatusCreated}))
+	replayed, _, err := subject.ClaimIdempotencyKey(suite.defaultContext, idempotencyKey, "fingerprint")
+	suite.NoError(err)
+	suite.Equal(http.StatusCreated, replayed.Response.StatusCode)
+}
+
+func (suite *StoreSuite) TestClaimIdempotencyKeyAfterExpiry() {
+	subject, clk := suite.idempotencyStore()
+	record, _, err := subject.ClaimIdempotencyKey(suite.defaultContext, idempotencyKey, "fingerprint")
+	suite.NoError(err)
+	suite.NoError(subject.CompleteIdempotencyKey(suite.defaultContext, record, files.IdempotentResponse{StatusCode: http.StatusOK}))
+
+	clk.now = clk.now.Add(24 * time.Hour)
+	_, claimed, err := subject.ClaimIdempotencyKey(suite.defaultContext, idempotencyKey, "another fingerprint")
+
+	suite.NoError(err)
+	suite.True(claimed)
+}
@@ -0,0 +1,92 @@
+package store
+
+import (
+	"context"
+
+	"github.com/ONSdigital/dp-files-api/mongo"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// FileEventIndexes are the indexes on the file_events collection. The unique index on sequence stops two instances of
+// the service taking the same place in the chain of events. The others serve the filters on file events, each ending
+// in created_at so the matching events are read in either order without being sorted in memory. They are named as
+// MongoDB would name them, so indexes already created by hand are left as they are.
+var FileEventIndexes = []mongo.Index{
+	{
+		Name:          "sequence_1",
+		Keys:          bson.D{{Key: fieldSequence, Value: 1}},
+		Unique:        true,
+		PartialFilter: bson.M{fieldSequence: bson.M{"$exists": true}},
+	},
+	fileEventIndex(),
+	fileEventIndex(fieldFileEventPath),
+	fileEventIndex(fieldAction),
+	fileEventIndex(fieldRequestedByID),
+	fileEventIndex(fieldFileEventCollectionID),
+	fileEventIndex(fieldFileEventBundleID),
+	fileEventIndex(fieldFileEventDatasetID, fieldFileEventEdition),
+}
+
+// fileEventIndex indexes file events on the fields given and then newest first
+func fileEventIndex(fields ...string) mongo.Index {
+	index := mongo.Index{}
+	for _, field := range fields {
+		index.Keys = append(index.Keys, bson.E{Key: field, Value: 1})
+		index.Name += field + "_1_"
+	}
+	index.Keys = append(index.Keys, bson.E{Key: fieldCreatedAt, Value: -1})
+	index.Name += fieldCreatedAt + "_-1"
+	return index
+}
+
+// IdempotencyKeyIndexes are the indexes on the idempotency_keys collection. Keys are unique as they are the _id, so the
+// only index needed is the TTL index that has MongoDB delete each record once it has expired.
+var IdempotencyKeyIndexes = []mongo.Index{
+	{
+		Name:               fieldExpiresAt + "_1",
+		Keys:               bson.D{{Key: fieldExpiresAt, Value: 1}},
+		ExpireAfterSeconds: new(int32),
+	},
+}
+
+// FileChangeIndexes are the indexes on the file_changes collection. The unique index on sequence stops two instances of
+// the service giving changes the same sequence number, and is used by streams following every collection and bundle.
+// Streams following one collection or bundle read its changes in sequence order from the other two. The TTL index has
+// MongoDB delete each change once it has expired.
+var FileChangeIndexes = []mongo.Index{
+	{
+		Name:          fieldSequence + "_1",
+		Keys:          bson.D{{Key: fieldSequence, Value: 1}},
+		Unique:        true,
+		PartialFilter: bson.M{fieldSequence: bson.M{"$exists": true}},
+	},
+	{
+		Name: fieldCollectionID + "_1_" + fieldSequence + "_1",
+		Keys: bson.D{{Key: fieldCollectionID, Value: 1}, {Key: fieldSequence, Value: 1}},
+	},
+	{
+		Name: fieldBundleID + "_1_" + fieldSequence + "_1",
+		Keys: bson.D{{Key: fieldBundleID, Value: 1}, {Key: fieldSequence, Value: 1}},
+	},
+	{
+		Name:               fieldExpiresAt + "_1",
+		Keys:               bson.D{{Key: fieldExpiresAt, Value: 1}},
+		ExpireAfterSeconds: new(int32),
+	},
+}
+
+// FileVersionIndexes are the indexes on the file_versions collection. The unique index on path and version stops two
+// replacements of a file keeping different files as the same version, and finds the versions of a path in order.
+var FileVersionIndexes = []mongo.Index{
+	{
+		Name:   fieldPath + "_1_" + fieldVersion + "_1",
+		Keys:   bson.D{{Key: fieldPath, Value: 1}, {Key: fieldVersion, Value: 1}},
+		Unique: true,
+	},
+}
+
+// CreateLockIndexes creates the indexes mongo-lock needs on the locks collection. A lock is only exclusive once the
+// unique index on its resource exists, so LockResource cannot be relied on until this has succeeded.
+func CreateLockIndexes(ctx context.Context, locks mongo.MongoCollection) error {
+	return locks.NewLockClient().CreateIndexes(ctx)
+}
@@ -0,0 +1,48 @@
+package store
+
+import (
+	"context"
+
+	"github.com/ONSdigital/dp-files-api/files"
+	dprequest "github.com/ONSdigital/dp-net/v3/request"
+	"github.com/ONSdigital/log.go/v2/log"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// enqueueFileLifecycle writes a lifecycle event for a change to the file to the outbox, returning the ID of the
+// message. It is written as pending before the change is made, then released once the change has been made or
+// withdrawn if the change fails, so that every change is announced and nothing else is.
+func (store *Store) enqueueFileLifecycle(ctx context.Context, path, change, fromState, toState string) (string, error) {
+	msg := store.fileLifecycleMessage(ctx, path, change, fromState, toState)
+
+	if err := store.repo.InsertOutboxMessage(ctx, msg); err != nil {
+		log.Error(ctx, "failed to write message to outbox", err, log.Data{"path": path, "topic": msg.Topic})
+		return "", err
+	}
+
+	return msg.ID, nil
+}
+
+// fileLifecycleMessage builds the outbox message for a change to the file. The actor is the caller identity the API
+// put on the context, so changes the service makes by itself have none.
+func (store *Store) fileLifecycleMessage(ctx context.Context, path, change, fromState, toState string) files.OutboxMessage {
+	now := store.clock.GetCurrentTime()
+	id := primitive.NewObjectID().Hex()
+
+	return files.OutboxMessage{
+		ID:    id,
+		Topic: store.cfg.FileLifecycleTopic,
+		FileLifecycle: &files.FileLifecycle{
+			EventID:   id,
+			Path:      path,
+			Change:    change,
+			FromState: fromState,
+			ToState:   toState,
+			Actor:     dprequest.Caller(ctx),
+			Timestamp: now.UnixMilli(),
+		},
+		Pending:       true,
+		CreatedAt:     now,
+		NextAttemptAt: now,
+	}
+}
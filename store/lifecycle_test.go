@@ -0,0 +1,98 @@
+package store_test
+
+import (
+	"context"
+	"errors"
+
+	s3Mock "github.com/ONSdigital/dp-files-api/aws/mock"
+	"github.com/ONSdigital/dp-files-api/config"
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/dp-files-api/store"
+	dprequest "github.com/ONSdigital/dp-net/v3/request"
+)
+
+func (suite *StoreSuite) lifecycleStore() (*store.Store, *store.MemoryRepository) {
+	repo := store.NewMemoryRepository()
+	s3Client := &s3Mock.S3ClienterMock{
+		DeleteFunc: func(ctx context.Context, key string) error { return nil },
+	}
+
+	cfg, _ := config.Get()
+	return store.NewStore(repo, suite.defaultClock, s3Client, cfg), repo
+}
+
+func (suite *StoreSuite) lifecycleEvents(repo *store.MemoryRepository) []*files.FileLifecycle {
+	msgs, err := repo.FindDueOutboxMessages(suite.defaultContext, suite.defaultClock.GetCurrentTime(), 10)
+	suite.Require().NoError(err)
+
+	var events []*files.FileLifecycle
+	for _, msg := range msgs {
+		if msg.FileLifecycle != nil {
+			suite.Equal("file-lifecycle", msg.Topic)
+			suite.Equal(msg.ID, msg.FileLifecycle.EventID)
+			events = append(events, msg.FileLifecycle)
+		}
+	}
+	return events
+}
+
+func (suite *StoreSuite) TestFileLifecycleEvents() {
+	subject, repo := suite.lifecycleStore()
+	ctx := dprequest.SetCaller(suite.defaultContext, "publisher@ons.gov.uk")
+
+	suite.NoError(subject.RegisterFileUpload(ctx, files.StoredRegisteredMetaData{Path: suite.path, IsPublishable: true}))
+	suite.NoError(subject.MarkUploadComplete(ctx, files.FileEtagChange{Path: suite.path, Etag: testEtag}))
+	suite.NoError(subject.UpdateContentItem(ctx, suite.path, &files.StoredContentItem{DatasetID: "cpih01"}))
+	metadata, _ := repo.GetMetadata(suite.defaultContext, suite.path)
+	suite.NoError(subject.RemoveFile(ctx, suite.path, metadata))
+
+	events := suite.lifecycleEvents(repo)
+	suite.Require().Len(events, 4)
+
+	timestamp := suite.defaultClock.GetCurrentTime().UnixMilli()
+	expected := []files.FileLifecycle{
+		{Change: files.LifecycleRegistered, FromState: "", ToState: store.StateCreated},
+		{Change: files.LifecycleStateChanged, FromState: store.StateCreated, ToState: store.StateUploaded},
+		{Change: files.LifecycleContentItemUpdated, FromState: store.StateUploaded, ToState: store.StateUploaded},
+		{Change: files.LifecycleRemoved, FromState: store.StateUploaded, ToState: ""},
+	}
+	for i, e := range expected {
+		e.EventID = events[i].EventID
+		e.Path = suite.path
+		e.Actor = "publisher@ons.gov.uk"
+		e.Timestamp = timestamp
+		suite.Equal(e, *events[i])
+	}
+}
+
+func (suite *StoreSuite) TestFileLifecycleEventWithdrawnWhenRegistrationFails() {
+	subject, repo := suite.lifecycleStore()
+	suite.NoError(repo.InsertMetadata(suite.defaultContext, files.StoredRegisteredMetaData{Path: suite.path, State: store.StateCreated}))
+
+	err := subject.RegisterFileUpload(suite.defaultContext, files.StoredRegisteredMetaData{Path: suite.path})
+
+	suite.ErrorIs(err, store.ErrDuplicateFile)
+	suite.Empty(suite.lifecycleEvents(repo))
+}
+
+// failingUpdateRepository fails every metadata update
+type failingUpdateRepository struct {
+	*store.MemoryRepository
+}
+
+func (r failingUpdateRepository) UpdateMetadata(ctx context.Context, path string, update store.Update) error {
+	return errors.New("update failed")
+}
+
+func (suite *StoreSuite) TestFileLifecycleEventWithdrawnWhenStateChangeFails() {
+	repo := store.NewMemoryRepository()
+	suite.NoError(repo.InsertMetadata(suite.defaultContext, files.StoredRegisteredMetaData{Path: suite.path, State: store.StateCreated}))
+
+	cfg, _ := config.Get()
+	subject := store.NewStore(failingUpdateRepository{repo}, suite.defaultClock, nil, cfg)
+
+	err := subject.MarkUploadComplete(suite.defaultContext, files.FileEtagChange{Path: suite.path, Etag: testEtag})
+
+	suite.EqualError(err, "update failed")
+	suite.Empty(suite.lifecycleEvents(repo))
+}
@@ -0,0 +1,17 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// LockResource takes the lock on resource for ttl, identified by lockID. It returns ErrResourceLocked while another
+// instance of the service holds the lock.
+func (store *Store) LockResource(ctx context.Context, resource, lockID string, ttl time.Duration) error {
+	return store.repo.LockResource(ctx, resource, lockID, ttl)
+}
+
+// UnlockResource releases the lock taken with lockID
+func (store *Store) UnlockResource(ctx context.Context, lockID string) error {
+	return store.repo.UnlockResource(ctx, lockID)
+}
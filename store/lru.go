@@ -0,0 +1,130 @@
+package store
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/ONSdigital/dp-files-api/clock"
+)
+
+// lruCache holds up to size values, each for ttl after it was added. The least recently used value is evicted to make
+// room for a new one. It is safe for concurrent use.
+type lruCache[K comparable, V any] struct {
+	mu         sync.Mutex
+	size       int
+	ttl        time.Duration
+	clock      clock.Clock
+	entries    map[K]*list.Element
+	order      *list.List
+	generation uint64
+}
+
+type lruEntry[K comparable, V any] struct {
+	key     K
+	value   V
+	expires time.Time
+}
+
+func newLRUCache[K comparable, V any](size int, ttl time.Duration, clk clock.Clock) *lruCache[K, V] {
+	return &lruCache[K, V]{
+		size:    size,
+		ttl:     ttl,
+		clock:   clk,
+		entries: make(map[K]*list.Element, size),
+		order:   list.New(),
+	}
+}
+
+// get returns the value for key, if it has not expired
+func (c *lruCache[K, V]) get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero V
+	element, ok := c.entries[key]
+	if !ok {
+		return zero, false
+	}
+
+	entry := element.Value.(*lruEntry[K, V])
+	if !c.clock.GetCurrentTime().Before(entry.expires) {
+		c.removeElement(element)
+		return zero, false
+	}
+
+	c.order.MoveToFront(element)
+	return entry.value, true
+}
+
+// add sets the value for key, evicting the least recently used value if the cache is full
+func (c *lruCache[K, V]) add(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.addLocked(key, value)
+}
+
+// currentGeneration gives the number of values removed so far. A value read before any of them was removed is only
+// added with addIfGeneration while the generation is still the same.
+func (c *lruCache[K, V]) currentGeneration() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.generation
+}
+
+// addIfGeneration adds the value for key as add does, unless a value has been removed since generation was read, in
+// which case the value may have been read before the change that removed it and is not kept
+func (c *lruCache[K, V]) addIfGeneration(key K, value V, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.generation == generation {
+		c.addLocked(key, value)
+	}
+}
+
+func (c *lruCache[K, V]) addLocked(key K, value V) {
+	if c.size <= 0 {
+		return
+	}
+
+	expires := c.clock.GetCurrentTime().Add(c.ttl)
+	if element, ok := c.entries[key]; ok {
+		entry := element.Value.(*lruEntry[K, V])
+		entry.value = value
+		entry.expires = expires
+		c.order.MoveToFront(element)
+		return
+	}
+
+	if c.order.Len() >= c.size {
+		c.removeElement(c.order.Back())
+	}
+	c.entries[key] = c.order.PushFront(&lruEntry[K, V]{key: key, value: value, expires: expires})
+}
+
+// remove forgets the value for key, and moves on the generation so that a value read before now is not added
+func (c *lruCache[K, V]) remove(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	if element, ok := c.entries[key]; ok {
+		c.removeElement(element)
+	}
+}
+
+// len is the number of values held, including any that have expired but not yet been evicted
+func (c *lruCache[K, V]) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+func (c *lruCache[K, V]) removeElement(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*lruEntry[K, V]).key)
+}
@@ -0,0 +1,197 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ONSdigital/dp-files-api/files"
+	mongodriver "github.com/ONSdigital/dp-mongodb/v3/mongodb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const duplicateKeyErrorCode = 11000
+
+// MemoryRepository is a Repository that holds everything in memory, for running the API without MongoDB. Documents
+// are copied through bson on the way in and out, so they come back exactly as they would from MongoDB, and are
+// returned in insertion order unless a sort applies, as MongoDB returns them by ascending _id.
+type MemoryRepository struct {
+	mu          sync.RWMutex
+	metadata    []files.StoredRegisteredMetaData
+	collections []files.StoredCollection
+	bundles     []files.StoredBundle
+	fileEvents  []files.FileEvent
+	chainHead   *FileEventChainHead
+	outbox      []files.OutboxMessage
+	publishJobs []files.PublishJob
+	versions    []files.StoredRegisteredMetaData
+	changes     []files.FileChange
+	locks       []memoryLock
+	trash       []files.TrashedFile
+	reports     []files.ReconciliationReport
+	idempotency []files.IdempotencyRecord
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{}
+}
+
+func inTimeRange(t time.Time, after, before *time.Time) bool {
+	if after != nil && t.Before(toMillis(*after)) {
+		return false
+	}
+	if before != nil && t.After(toMillis(*before)) {
+		return false
+	}
+	return true
+}
+
+// clonePage copies out the page of docs found by skipping offset and taking up to limit. A limit of zero or less
+// gives an empty page, as the mongodriver.Limit option does.
+func clonePage[T any](docs []T, offset, limit int) ([]T, error) {
+	page := make([]T, 0)
+	for i := offset; i < len(docs) && len(page) < limit; i++ {
+		c, err := clone(docs[i])
+		if err != nil {
+			return nil, err
+		}
+		page = append(page, c)
+	}
+	return page, nil
+}
+
+// clone copies doc through bson, as a round trip to MongoDB would
+func clone[T any](doc T) (T, error) {
+	var c T
+	b, err := bson.Marshal(doc)
+	if err != nil {
+		return c, err
+	}
+	err = bson.Unmarshal(b, &c)
+	return c, err
+}
+
+// applyUpdate returns a copy of doc with update applied. Keys may be dotted paths into embedded documents.
+func applyUpdate[T any](doc T, update Update) (T, error) {
+	var updated T
+
+	b, err := bson.Marshal(doc)
+	if err != nil {
+		return updated, err
+	}
+	fields := bson.D{}
+	if err = bson.Unmarshal(b, &fields); err != nil {
+		return updated, err
+	}
+
+	for _, f := range update.Set {
+		value, err := documentValue(f.Value)
+		if err != nil {
+			return updated, err
+		}
+		fields = setField(fields, strings.Split(f.Key, "."), value)
+	}
+	for _, key := range update.Unset {
+		fields = unsetField(fields, strings.Split(key, "."))
+	}
+
+	if b, err = bson.Marshal(fields); err != nil {
+		return updated, err
+	}
+	err = bson.Unmarshal(b, &updated)
+	return updated, err
+}
+
+// documentValue returns value as bson.D when it encodes as an embedded document, so that later dotted keys can
+// reach into it
+func documentValue(value interface{}) (interface{}, error) {
+	t, data, err := bson.MarshalValue(value)
+	if err != nil || t != bson.TypeEmbeddedDocument {
+		return value, err
+	}
+	doc := bson.D{}
+	err = bson.Unmarshal(data, &doc)
+	return doc, err
+}
+
+func setField(doc bson.D, path []string, value interface{}) bson.D {
+	for i := range doc {
+		if doc[i].Key != path[0] {
+			continue
+		}
+		if len(path) == 1 {
+			doc[i].Value = value
+		} else {
+			embedded, _ := doc[i].Value.(bson.D)
+			doc[i].Value = setField(embedded, path[1:], value)
+		}
+		return doc
+	}
+
+	if len(path) == 1 {
+		return append(doc, bson.E{Key: path[0], Value: value})
+	}
+	return append(doc, bson.E{Key: path[0], Value: setField(bson.D{}, path[1:], value)})
+}
+
+func unsetField(doc bson.D, path []string) bson.D {
+	for i := range doc {
+		if doc[i].Key != path[0] {
+			continue
+		}
+		if len(path) == 1 {
+			return append(doc[:i], doc[i+1:]...)
+		}
+		if embedded, ok := doc[i].Value.(bson.D); ok {
+			doc[i].Value = unsetField(embedded, path[1:])
+		}
+		return doc
+	}
+	return doc
+}
+
+// toMillis truncates t to the millisecond precision that MongoDB stores dates with
+func toMillis(t time.Time) time.Time {
+	return t.Truncate(time.Millisecond)
+}
+
+func duplicateKeyError(collection, key string, value interface{}) error {
+	return mongo.WriteException{WriteErrors: []mongo.WriteError{{
+		Code:    duplicateKeyErrorCode,
+		Message: fmt.Sprintf("E11000 duplicate key error collection: files.%s index: %s_1 dup key: { %s: %q }", collection, key, key, value),
+	}}}
+}
+
+// memoryCursor walks a snapshot of documents taken when the cursor was opened
+type memoryCursor[T any] struct {
+	docs []T
+	next int
+}
+
+func (c *memoryCursor[T]) Next(ctx context.Context) bool {
+	if c.next+1 >= len(c.docs) {
+		c.next = len(c.docs)
+		return false
+	}
+	c.next++
+	return true
+}
+
+func (c *memoryCursor[T]) Current() (T, error) {
+	if c.next < 0 || c.next >= len(c.docs) {
+		var none T
+		return none, mongodriver.ErrNoDocumentFound
+	}
+	return clone(c.docs[c.next])
+}
+
+func (c *memoryCursor[T]) Err() error {
+	return nil
+}
+
+func (c *memoryCursor[T]) Close(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,169 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/ONSdigital/dp-files-api/files"
+	mongodriver "github.com/ONSdigital/dp-mongodb/v3/mongodb"
+)
+
+func (r *MemoryRepository) GetCollection(ctx context.Context, id string) (files.StoredCollection, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, c := range r.collections {
+		if c.ID == id {
+			return clone(c)
+		}
+	}
+	return files.StoredCollection{}, mongodriver.ErrNoDocumentFound
+}
+
+func (r *MemoryRepository) InsertCollection(ctx context.Context, collection files.StoredCollection) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, c := range r.collections {
+		if c.ID == collection.ID {
+			return duplicateKeyError("collections", fieldID, collection.ID)
+		}
+	}
+
+	stored, err := clone(collection)
+	if err != nil {
+		return err
+	}
+	r.collections = append(r.collections, stored)
+	return nil
+}
+
+func (r *MemoryRepository) UpsertCollection(ctx context.Context, id string, set []Field) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, c := range r.collections {
+		if c.ID == id {
+			updated, err := applyUpdate(c, Update{Set: set})
+			if err != nil {
+				return err
+			}
+			r.collections[i] = updated
+			return nil
+		}
+	}
+
+	created, err := applyUpdate(files.StoredCollection{ID: id}, Update{Set: set})
+	if err != nil {
+		return err
+	}
+	r.collections = append(r.collections, created)
+	return nil
+}
+
+func (r *MemoryRepository) DeleteCollection(ctx context.Context, id string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, c := range r.collections {
+		if c.ID == id {
+			r.collections = append(r.collections[:i], r.collections[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *MemoryRepository) FindScheduledCollections(ctx context.Context, due time.Time) ([]files.StoredCollection, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	due = toMillis(due)
+	collections := make([]files.StoredCollection, 0)
+	for _, c := range r.collections {
+		if c.State == StateScheduled && c.PublishAt != nil && !c.PublishAt.After(due) {
+			collections = append(collections, c)
+		}
+	}
+	return clonePage(collections, 0, len(collections))
+}
+
+func (r *MemoryRepository) GetBundle(ctx context.Context, id string) (files.StoredBundle, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, b := range r.bundles {
+		if b.ID == id {
+			return clone(b)
+		}
+	}
+	return files.StoredBundle{}, mongodriver.ErrNoDocumentFound
+}
+
+func (r *MemoryRepository) InsertBundle(ctx context.Context, bundle files.StoredBundle) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, b := range r.bundles {
+		if b.ID == bundle.ID {
+			return duplicateKeyError("bundles", fieldID, bundle.ID)
+		}
+	}
+
+	stored, err := clone(bundle)
+	if err != nil {
+		return err
+	}
+	r.bundles = append(r.bundles, stored)
+	return nil
+}
+
+func (r *MemoryRepository) UpsertBundle(ctx context.Context, id string, set []Field) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, b := range r.bundles {
+		if b.ID == id {
+			updated, err := applyUpdate(b, Update{Set: set})
+			if err != nil {
+				return err
+			}
+			r.bundles[i] = updated
+			return nil
+		}
+	}
+
+	created, err := applyUpdate(files.StoredBundle{ID: id}, Update{Set: set})
+	if err != nil {
+		return err
+	}
+	r.bundles = append(r.bundles, created)
+	return nil
+}
+
+func (r *MemoryRepository) DeleteBundle(ctx context.Context, id string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, b := range r.bundles {
+		if b.ID == id {
+			r.bundles = append(r.bundles[:i], r.bundles[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *MemoryRepository) FindScheduledBundles(ctx context.Context, due time.Time) ([]files.StoredBundle, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	due = toMillis(due)
+	bundles := make([]files.StoredBundle, 0)
+	for _, b := range r.bundles {
+		if b.State == StateScheduled && b.PublishAt != nil && !b.PublishAt.After(due) {
+			bundles = append(bundles, b)
+		}
+	}
+	return clonePage(bundles, 0, len(bundles))
+}
@@ -0,0 +1,69 @@
+package store
+
+import (
+	"context"
+	"sort"
+
+	"github.com/ONSdigital/dp-files-api/files"
+	mongodriver "github.com/ONSdigital/dp-mongodb/v3/mongodb"
+)
+
+func (r *MemoryRepository) InsertFileChange(ctx context.Context, change files.FileChange) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, c := range r.changes {
+		if c.Sequence == change.Sequence {
+			return duplicateKeyError("file_changes", fieldSequence, change.Sequence)
+		}
+	}
+
+	stored, err := clone(change)
+	if err != nil {
+		return err
+	}
+	r.changes = append(r.changes, stored)
+	return nil
+}
+
+func (r *MemoryRepository) GetLatestFileChange(ctx context.Context) (files.FileChange, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var latest *files.FileChange
+	for i, c := range r.changes {
+		if c.Sequence > 0 && (latest == nil || c.Sequence > latest.Sequence) {
+			latest = &r.changes[i]
+		}
+	}
+
+	if latest == nil {
+		return files.FileChange{}, mongodriver.ErrNoDocumentFound
+	}
+	return clone(*latest)
+}
+
+func (r *MemoryRepository) FindFileChanges(ctx context.Context, filter FileChangeFilter, limit int) ([]files.FileChange, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	changes := make([]files.FileChange, 0)
+	for _, c := range r.changes {
+		if c.Sequence <= filter.AfterSequence {
+			continue
+		}
+		if filter.CollectionID != nil && (c.CollectionID == nil || *c.CollectionID != *filter.CollectionID) {
+			continue
+		}
+		if filter.BundleID != nil && (c.BundleID == nil || *c.BundleID != *filter.BundleID) {
+			continue
+		}
+		changes = append(changes, c)
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Sequence < changes[j].Sequence
+	})
+
+	return clonePage(changes, 0, limit)
+}
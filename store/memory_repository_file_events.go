@@ -0,0 +1,202 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/ONSdigital/dp-files-api/files"
+	mongodriver "github.com/ONSdigital/dp-mongodb/v3/mongodb"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func (r *MemoryRepository) InsertFileEvents(ctx context.Context, events []*files.FileEvent) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, event := range events {
+		if err := r.insertFileEvent(*event); err != nil {
+			return i, err
+		}
+	}
+	return len(events), nil
+}
+
+func (r *MemoryRepository) InsertFileEvent(ctx context.Context, event *files.FileEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.insertFileEvent(*event)
+}
+
+// insertFileEvent adds an event with a sequence number not already taken. The caller holds the write lock.
+func (r *MemoryRepository) insertFileEvent(event files.FileEvent) error {
+	if event.Sequence != 0 {
+		for _, e := range r.fileEvents {
+			if e.Sequence == event.Sequence {
+				return duplicateKeyError("file_events", fieldSequence, event.Sequence)
+			}
+		}
+	}
+
+	stored, err := clone(event)
+	if err != nil {
+		return err
+	}
+	r.fileEvents = append(r.fileEvents, stored)
+	return nil
+}
+
+func (r *MemoryRepository) GetFileEventChainHead(ctx context.Context) (FileEventChainHead, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.chainHead == nil {
+		return FileEventChainHead{}, mongodriver.ErrNoDocumentFound
+	}
+	return *r.chainHead, nil
+}
+
+func (r *MemoryRepository) AdvanceFileEventChainHead(ctx context.Context, head FileEventChainHead, n int, hash string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.chainHead != nil && *r.chainHead != head {
+		return false, nil
+	}
+	r.chainHead = &FileEventChainHead{Sequence: head.Sequence + int64(n), Hash: hash}
+	return true, nil
+}
+
+func (r *MemoryRepository) FindChainedFileEvents(ctx context.Context, afterSequence int64, limit int) ([]ChainedFileEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	events := make([]files.FileEvent, 0)
+	for _, e := range r.fileEvents {
+		if e.Sequence > afterSequence {
+			events = append(events, e)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Sequence < events[j].Sequence })
+
+	chained := make([]ChainedFileEvent, 0)
+	for i := 0; i < len(events) && len(chained) < limit; i++ {
+		doc, err := bson.Marshal(events[i])
+		if err != nil {
+			return nil, err
+		}
+		event, err := chainedFileEvent(doc)
+		if err != nil {
+			return nil, err
+		}
+		chained = append(chained, event)
+	}
+	return chained, nil
+}
+
+func (r *MemoryRepository) CountFileEvents(ctx context.Context, filter FileEventFilter) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	count := 0
+	for _, e := range r.fileEvents {
+		if fileEventMatches(filter, e) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *MemoryRepository) FindFileEvents(ctx context.Context, filter FileEventFilter, oldestFirst bool, offset, limit int) ([]files.FileEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	events := make([]files.FileEvent, 0)
+	for _, e := range r.fileEvents {
+		if fileEventMatches(filter, e) {
+			events = append(events, e)
+		}
+	}
+
+	sortFileEvents(events, oldestFirst)
+
+	return clonePage(events, offset, limit)
+}
+
+func (r *MemoryRepository) FileEventsCursor(ctx context.Context, filter FileEventFilter) (Cursor[files.FileEvent], error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	events := make([]files.FileEvent, 0)
+	for _, e := range r.fileEvents {
+		if fileEventMatches(filter, e) {
+			events = append(events, e)
+		}
+	}
+
+	sortFileEvents(events, true)
+
+	return &memoryCursor[files.FileEvent]{docs: events, next: -1}, nil
+}
+
+// sortFileEvents sorts events by creation time, newest first unless oldestFirst is set. Events without a creation time
+// sort as null does in MongoDB, below any date.
+func sortFileEvents(events []files.FileEvent, oldestFirst bool) {
+	sort.SliceStable(events, func(i, j int) bool {
+		if oldestFirst {
+			i, j = j, i
+		}
+		if events[j].CreatedAt == nil {
+			return events[i].CreatedAt != nil
+		}
+		return events[i].CreatedAt != nil && events[i].CreatedAt.After(*events[j].CreatedAt)
+	})
+}
+
+func fileEventMatches(filter FileEventFilter, e files.FileEvent) bool {
+	file := e.File
+	if file == nil {
+		file = &files.StoredRegisteredMetaData{}
+	}
+	contentItem := file.ContentItem
+	if contentItem == nil {
+		contentItem = &files.StoredContentItem{}
+	}
+	requestedBy := e.RequestedBy
+	if requestedBy == nil {
+		requestedBy = &files.RequestedBy{}
+	}
+
+	if filter.Path != "" && file.Path != filter.Path {
+		return false
+	}
+	if !strings.HasPrefix(file.Path, filter.PathPrefix) {
+		return false
+	}
+	if filter.Action != "" && e.Action != filter.Action {
+		return false
+	}
+	if filter.RequestedByID != "" && requestedBy.ID != filter.RequestedByID {
+		return false
+	}
+	if filter.CollectionID != "" && (file.CollectionID == nil || *file.CollectionID != filter.CollectionID) {
+		return false
+	}
+	if filter.BundleID != "" && (file.BundleID == nil || *file.BundleID != filter.BundleID) {
+		return false
+	}
+	if filter.DatasetID != "" && contentItem.DatasetID != filter.DatasetID {
+		return false
+	}
+	if filter.Edition != "" && contentItem.Edition != filter.Edition {
+		return false
+	}
+	if filter.After != nil && (e.CreatedAt == nil || e.CreatedAt.Before(toMillis(*filter.After))) {
+		return false
+	}
+	if filter.Before != nil && (e.CreatedAt == nil || e.CreatedAt.After(toMillis(*filter.Before))) {
+		return false
+	}
+	return true
+}
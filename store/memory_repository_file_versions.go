@@ -0,0 +1,81 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"sort"
+
+	"github.com/ONSdigital/dp-files-api/files"
+	mongodriver "github.com/ONSdigital/dp-mongodb/v3/mongodb"
+)
+
+func (r *MemoryRepository) InsertFileVersion(ctx context.Context, version files.StoredRegisteredMetaData) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, v := range r.versions {
+		if v.Path == version.Path && v.Version == version.Version {
+			return duplicateKeyError("file_versions", fieldPath, version.Path)
+		}
+	}
+
+	stored, err := clone(version)
+	if err != nil {
+		return err
+	}
+	r.versions = append(r.versions, stored)
+	return nil
+}
+
+func (r *MemoryRepository) GetFileVersion(ctx context.Context, path string, version int) (files.StoredRegisteredMetaData, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, v := range r.versions {
+		if v.Path == path && v.Version == version {
+			return clone(v)
+		}
+	}
+	return files.StoredRegisteredMetaData{}, mongodriver.ErrNoDocumentFound
+}
+
+func (r *MemoryRepository) FindFileVersions(ctx context.Context, path string) ([]files.StoredRegisteredMetaData, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	versions := make([]files.StoredRegisteredMetaData, 0)
+	for _, v := range r.versions {
+		if v.Path == path {
+			versions = append(versions, v)
+		}
+	}
+
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].Version < versions[j].Version
+	})
+
+	return clonePage(versions, 0, len(versions))
+}
+
+func (r *MemoryRepository) DeleteFileVersion(ctx context.Context, path string, version int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.versions = slices.DeleteFunc(r.versions, func(v files.StoredRegisteredMetaData) bool {
+		return v.Path == path && v.Version == version
+	})
+	return nil
+}
+
+func (r *MemoryRepository) LatestFileVersions(ctx context.Context, paths []string) (map[string]int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	latest := make(map[string]int)
+	for _, v := range r.versions {
+		if slices.Contains(paths, v.Path) && v.Version > latest[v.Path] {
+			latest[v.Path] = v.Version
+		}
+	}
+	return latest, nil
+}
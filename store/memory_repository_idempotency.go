@@ -0,0 +1,75 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/ONSdigital/dp-files-api/files"
+	mongodriver "github.com/ONSdigital/dp-mongodb/v3/mongodb"
+)
+
+func (r *MemoryRepository) InsertIdempotencyRecord(ctx context.Context, record files.IdempotencyRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.idempotency {
+		if existing.Key == record.Key {
+			return duplicateKeyError("idempotency_keys", "_id", record.Key)
+		}
+	}
+
+	stored, err := clone(record)
+	if err != nil {
+		return err
+	}
+	r.idempotency = append(r.idempotency, stored)
+	return nil
+}
+
+func (r *MemoryRepository) GetIdempotencyRecord(ctx context.Context, key string) (files.IdempotencyRecord, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, record := range r.idempotency {
+		if record.Key == key {
+			return clone(record)
+		}
+	}
+	return files.IdempotencyRecord{}, mongodriver.ErrNoDocumentFound
+}
+
+func (r *MemoryRepository) CompleteIdempotencyRecord(ctx context.Context, key string, createdAt time.Time, response files.IdempotentResponse) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, err := clone(response)
+	if err != nil {
+		return err
+	}
+	if i := r.idempotencyRecordIndex(key, createdAt); i >= 0 {
+		r.idempotency[i].Response = &stored
+	}
+	return nil
+}
+
+func (r *MemoryRepository) DeleteIdempotencyRecord(ctx context.Context, key string, createdAt time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.idempotencyRecordIndex(key, createdAt)
+	if i < 0 {
+		return false, nil
+	}
+	r.idempotency = append(r.idempotency[:i], r.idempotency[i+1:]...)
+	return true, nil
+}
+
+func (r *MemoryRepository) idempotencyRecordIndex(key string, createdAt time.Time) int {
+	createdAt = toMillis(createdAt)
+	for i, record := range r.idempotency {
+		if record.Key == key && record.CreatedAt.Equal(createdAt) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,55 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// memoryLock is a lock on a resource taken with LockResource
+type memoryLock struct {
+	resource  string
+	lockID    string
+	expiresAt time.Time
+}
+
+// LockResource uses the wall clock for expiry, as mongo-lock does
+func (r *MemoryRepository) LockResource(ctx context.Context, resource, lockID string, ttl time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for i, l := range r.locks {
+		if l.resource == resource {
+			if now.Before(l.expiresAt) {
+				return ErrResourceLocked
+			}
+			r.locks = append(r.locks[:i], r.locks[i+1:]...)
+			break
+		}
+	}
+	r.locks = append(r.locks, memoryLock{resource: resource, lockID: lockID, expiresAt: now.Add(ttl)})
+	return nil
+}
+
+func (r *MemoryRepository) RenewResourceLock(ctx context.Context, lockID string, ttl time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for i, l := range r.locks {
+		if l.lockID == lockID && now.Before(l.expiresAt) {
+			r.locks[i].expiresAt = now.Add(ttl)
+			return nil
+		}
+	}
+	return ErrResourceLocked
+}
+
+func (r *MemoryRepository) UnlockResource(ctx context.Context, lockID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.locks = slices.DeleteFunc(r.locks, func(l memoryLock) bool { return l.lockID == lockID })
+	return nil
+}
@@ -0,0 +1,301 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ONSdigital/dp-files-api/files"
+	mongodriver "github.com/ONSdigital/dp-mongodb/v3/mongodb"
+)
+
+func (r *MemoryRepository) GetMetadata(ctx context.Context, path string) (files.StoredRegisteredMetaData, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, m := range r.metadata {
+		if m.Path == path {
+			return clone(m)
+		}
+	}
+	return files.StoredRegisteredMetaData{}, mongodriver.ErrNoDocumentFound
+}
+
+func (r *MemoryRepository) FindOneMetadata(ctx context.Context, filter MetadataFilter) (files.StoredRegisteredMetaData, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, m := range r.metadata {
+		if metadataMatches(filter, m) {
+			return clone(m)
+		}
+	}
+	return files.StoredRegisteredMetaData{}, mongodriver.ErrNoDocumentFound
+}
+
+func (r *MemoryRepository) FindMetadata(ctx context.Context, filter MetadataFilter) ([]files.StoredRegisteredMetaData, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.matchingMetadata(filter, 0)
+}
+
+func (r *MemoryRepository) FindMetadataPage(ctx context.Context, filter MetadataFilter, offset, limit int) ([]files.StoredRegisteredMetaData, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	metadata := make([]files.StoredRegisteredMetaData, 0)
+	for _, m := range r.metadata {
+		if metadataMatches(filter, m) {
+			metadata = append(metadata, m)
+		}
+	}
+
+	sort.Slice(metadata, func(i, j int) bool {
+		return metadata[i].Path < metadata[j].Path
+	})
+
+	// as with MongoDB, a limit of zero returns every match
+	if limit == 0 {
+		limit = len(metadata)
+	}
+	return clonePage(metadata, offset, limit)
+}
+
+func (r *MemoryRepository) ListDirectory(ctx context.Context, prefix, after string, limit int) ([]files.DirectoryEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	grouped := make(map[string]*files.DirectoryEntry)
+	for _, m := range r.metadata {
+		rest, ok := strings.CutPrefix(m.Path, prefix)
+		if !ok {
+			continue
+		}
+		key, entry := rest, files.DirectoryEntry{Name: rest}
+		if name, _, found := strings.Cut(rest, "/"); found {
+			key, entry = name+"/", files.DirectoryEntry{Name: name, IsDirectory: true}
+		}
+		if key <= after {
+			continue
+		}
+		if _, ok := grouped[key]; !ok {
+			grouped[key] = &entry
+		}
+		grouped[key].FileCount++
+		grouped[key].SizeInBytes += m.SizeInBytes
+	}
+
+	keys := make([]string, 0, len(grouped))
+	for key := range grouped {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	if limit > 0 && len(keys) > limit {
+		keys = keys[:limit]
+	}
+
+	entries := make([]files.DirectoryEntry, 0, len(keys))
+	for _, key := range keys {
+		entries = append(entries, *grouped[key])
+	}
+	return entries, nil
+}
+
+func (r *MemoryRepository) SumDirectory(ctx context.Context, prefix string) (int, uint64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	count, size := 0, uint64(0)
+	for _, m := range r.metadata {
+		if strings.HasPrefix(m.Path, prefix) {
+			count++
+			size += m.SizeInBytes
+		}
+	}
+	return count, size, nil
+}
+
+func (r *MemoryRepository) CountMetadata(ctx context.Context, filter MetadataFilter) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	count := 0
+	for _, m := range r.metadata {
+		if metadataMatches(filter, m) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *MemoryRepository) MetadataCursor(ctx context.Context, filter MetadataFilter, offset int) (Cursor[files.StoredRegisteredMetaData], error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	metadata, err := r.matchingMetadata(filter, offset)
+	if err != nil {
+		return nil, err
+	}
+	return &memoryCursor[files.StoredRegisteredMetaData]{docs: metadata, next: -1}, nil
+}
+
+// matchingMetadata returns the files matching the filter in path order, skipping the first offset
+func (r *MemoryRepository) matchingMetadata(filter MetadataFilter, offset int) ([]files.StoredRegisteredMetaData, error) {
+	matching := make([]files.StoredRegisteredMetaData, 0)
+	for _, m := range r.metadata {
+		if metadataMatches(filter, m) {
+			matching = append(matching, m)
+		}
+	}
+	sort.SliceStable(matching, func(i, j int) bool { return matching[i].Path < matching[j].Path })
+
+	metadata := make([]files.StoredRegisteredMetaData, 0)
+	for _, m := range matching[min(offset, len(matching)):] {
+		c, err := clone(m)
+		if err != nil {
+			return nil, err
+		}
+		metadata = append(metadata, c)
+	}
+	return metadata, nil
+}
+
+func (r *MemoryRepository) InsertManyMetadata(ctx context.Context, metadata []files.StoredRegisteredMetaData) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, m := range metadata {
+		if err := r.insertMetadata(m); err != nil {
+			return i, err
+		}
+	}
+	return len(metadata), nil
+}
+
+func (r *MemoryRepository) InsertMetadata(ctx context.Context, metadata files.StoredRegisteredMetaData) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.insertMetadata(metadata)
+}
+
+// insertMetadata adds metadata with a path not already stored. The caller holds the write lock.
+func (r *MemoryRepository) insertMetadata(metadata files.StoredRegisteredMetaData) error {
+	for _, m := range r.metadata {
+		if m.Path == metadata.Path {
+			return duplicateKeyError("metadata", fieldPath, metadata.Path)
+		}
+	}
+
+	stored, err := clone(metadata)
+	if err != nil {
+		return err
+	}
+	r.metadata = append(r.metadata, stored)
+	return nil
+}
+
+func (r *MemoryRepository) UpdateMetadata(ctx context.Context, path string, update Update) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, err := r.updateMetadata(path, update, func(files.StoredRegisteredMetaData) bool { return true })
+	return err
+}
+
+func (r *MemoryRepository) UpdateMetadataIfUnchanged(ctx context.Context, path string, revision int64, createdAt time.Time, update Update) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	createdAt = toMillis(createdAt)
+	return r.updateMetadata(path, update, func(m files.StoredRegisteredMetaData) bool {
+		return m.Revision == revision && (createdAt.IsZero() || m.CreatedAt.Equal(createdAt))
+	})
+}
+
+// updateMetadata updates the metadata at path, moving it on to its next revision, if it matches
+func (r *MemoryRepository) updateMetadata(path string, update Update, matches func(files.StoredRegisteredMetaData) bool) (bool, error) {
+	for i, m := range r.metadata {
+		if m.Path == path {
+			if !matches(m) {
+				return false, nil
+			}
+			updated, err := applyUpdate(m, update)
+			if err != nil {
+				return false, err
+			}
+			updated.Revision++
+			r.metadata[i] = updated
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *MemoryRepository) DeleteMetadata(ctx context.Context, path string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.deleteMetadata(path, func(files.StoredRegisteredMetaData) bool { return true }), nil
+}
+
+func (r *MemoryRepository) DeleteMetadataIfUnchanged(ctx context.Context, path, state string, revision int64, createdAt time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	createdAt = toMillis(createdAt)
+	return r.deleteMetadata(path, func(m files.StoredRegisteredMetaData) bool {
+		return m.State == state && m.Revision == revision && (createdAt.IsZero() || m.CreatedAt.Equal(createdAt))
+	}), nil
+}
+
+// deleteMetadata deletes the metadata at path if it matches, reporting whether it did. The caller holds the write lock.
+func (r *MemoryRepository) deleteMetadata(path string, matches func(files.StoredRegisteredMetaData) bool) bool {
+	for i, m := range r.metadata {
+		if m.Path == path {
+			if !matches(m) {
+				return false
+			}
+			r.metadata = append(r.metadata[:i], r.metadata[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func metadataMatches(filter MetadataFilter, m files.StoredRegisteredMetaData) bool {
+	if filter.Paths != nil && !slices.Contains(filter.Paths, m.Path) {
+		return false
+	}
+	if filter.CollectionID != nil && (m.CollectionID == nil || *m.CollectionID != *filter.CollectionID) {
+		return false
+	}
+	if filter.BundleID != nil && (m.BundleID == nil || *m.BundleID != *filter.BundleID) {
+		return false
+	}
+	for _, state := range filter.ExcludeStates {
+		if m.State == state {
+			return false
+		}
+	}
+	if filter.States != nil && !slices.Contains(filter.States, m.State) {
+		return false
+	}
+	if filter.Type != "" && m.Type != filter.Type {
+		return false
+	}
+	if filter.IsPublishable != nil && m.IsPublishable != *filter.IsPublishable {
+		return false
+	}
+	if !strings.HasPrefix(m.Path, filter.PathPrefix) {
+		return false
+	}
+	if filter.PathAfter != "" && m.Path <= filter.PathAfter {
+		return false
+	}
+	return inTimeRange(m.CreatedAt, filter.CreatedAfter, filter.CreatedBefore) &&
+		inTimeRange(m.LastModified, filter.ModifiedAfter, filter.ModifiedBefore)
+}
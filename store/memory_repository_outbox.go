@@ -0,0 +1,127 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/ONSdigital/dp-files-api/files"
+)
+
+func (r *MemoryRepository) InsertOutboxMessage(ctx context.Context, msg files.OutboxMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, err := clone(msg)
+	if err != nil {
+		return err
+	}
+	r.outbox = append(r.outbox, stored)
+	return nil
+}
+
+func (r *MemoryRepository) ReleaseOutboxMessages(ctx context.Context, ids []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.outbox {
+		if slices.Contains(ids, r.outbox[i].ID) {
+			r.outbox[i].Pending = false
+		}
+	}
+	return nil
+}
+
+func (r *MemoryRepository) FindStalePendingOutboxMessages(ctx context.Context, createdBefore time.Time, limit int) ([]files.OutboxMessage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	createdBefore = toMillis(createdBefore)
+	messages := make([]files.OutboxMessage, 0)
+	for _, msg := range r.outbox {
+		if msg.Pending && !msg.CreatedAt.After(createdBefore) {
+			messages = append(messages, msg)
+		}
+	}
+
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].CreatedAt.Before(messages[j].CreatedAt)
+	})
+
+	return clonePage(messages, 0, limit)
+}
+
+func (r *MemoryRepository) FindDueOutboxMessages(ctx context.Context, due time.Time, limit int) ([]files.OutboxMessage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	due = toMillis(due)
+	messages := make([]files.OutboxMessage, 0)
+	for _, msg := range r.outbox {
+		if !msg.Pending && !msg.NextAttemptAt.After(due) {
+			messages = append(messages, msg)
+		}
+	}
+
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].CreatedAt.Before(messages[j].CreatedAt)
+	})
+
+	return clonePage(messages, 0, limit)
+}
+
+func (r *MemoryRepository) DeleteOutboxMessage(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, msg := range r.outbox {
+		if msg.ID == id {
+			r.outbox = append(r.outbox[:i], r.outbox[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (r *MemoryRepository) RecordOutboxMessageSent(ctx context.Context, id string, sentAt, confirmBy time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, msg := range r.outbox {
+		if msg.ID == id {
+			msg.SentAt = &sentAt
+			msg.NextAttemptAt = confirmBy
+
+			updated, err := clone(msg)
+			if err != nil {
+				return err
+			}
+			r.outbox[i] = updated
+			return nil
+		}
+	}
+	return nil
+}
+
+func (r *MemoryRepository) RecordOutboxMessageFailure(ctx context.Context, id string, nextAttemptAt time.Time, reason string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, msg := range r.outbox {
+		if msg.ID == id {
+			msg.Attempts++
+			msg.LastError = reason
+			msg.NextAttemptAt = nextAttemptAt
+			msg.SentAt = nil
+
+			updated, err := clone(msg)
+			if err != nil {
+				return err
+			}
+			r.outbox[i] = updated
+			return nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/ONSdigital/dp-files-api/files"
+	mongodriver "github.com/ONSdigital/dp-mongodb/v3/mongodb"
+)
+
+func (r *MemoryRepository) InsertPublishJob(ctx context.Context, job *files.PublishJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, err := clone(*job)
+	if err != nil {
+		return err
+	}
+	r.publishJobs = append(r.publishJobs, stored)
+	return nil
+}
+
+func (r *MemoryRepository) DeletePublishJob(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, job := range r.publishJobs {
+		if job.ID == id {
+			r.publishJobs = append(r.publishJobs[:i], r.publishJobs[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (r *MemoryRepository) ClaimPublishJob(ctx context.Context, id, owner string, now, leaseExpiresAt time.Time) (bool, error) {
+	claimable := func(job files.PublishJob) bool {
+		return job.State == PublishJobStateInProgress && (job.LeaseExpiresAt == nil || !job.LeaseExpiresAt.After(now))
+	}
+	leaseExpiresAt = toMillis(leaseExpiresAt)
+	return r.updatePublishJob(id, claimable, func(job *files.PublishJob) {
+		job.Owner = owner
+		job.LeaseExpiresAt = &leaseExpiresAt
+	})
+}
+
+func (r *MemoryRepository) CheckpointPublishJobBatch(ctx context.Context, id string, checkpoint PublishJobCheckpoint) (bool, error) {
+	leaseExpiresAt := toMillis(checkpoint.LeaseExpiresAt)
+	return r.updatePublishJob(id, ownedBy(checkpoint.Owner), func(job *files.PublishJob) {
+		if checkpoint.Batch >= 0 && checkpoint.Batch < len(job.Batches) {
+			batch := &job.Batches[checkpoint.Batch]
+			batch.Position = checkpoint.Position
+			batch.LastPath = checkpoint.LastPath
+			batch.Completed = checkpoint.Completed
+			batch.Sent += checkpoint.Sent
+			batch.Failed += checkpoint.Failed
+		}
+		job.Sent += checkpoint.Sent
+		job.Failed += checkpoint.Failed
+		job.LastModified = checkpoint.LastModified
+		job.LeaseExpiresAt = &leaseExpiresAt
+	})
+}
+
+func (r *MemoryRepository) RenewPublishJobLease(ctx context.Context, id, owner string, leaseExpiresAt time.Time) (bool, error) {
+	leaseExpiresAt = toMillis(leaseExpiresAt)
+	return r.updatePublishJob(id, ownedBy(owner), func(job *files.PublishJob) {
+		job.LeaseExpiresAt = &leaseExpiresAt
+	})
+}
+
+func (r *MemoryRepository) CompletePublishJob(ctx context.Context, id, owner, state string, completedAt time.Time) error {
+	_, err := r.updatePublishJob(id, ownedBy(owner), func(job *files.PublishJob) {
+		job.State = state
+		job.LastModified = completedAt
+		job.CompletedAt = &completedAt
+		job.LeaseExpiresAt = nil
+	})
+	return err
+}
+
+func ownedBy(owner string) func(job files.PublishJob) bool {
+	return func(job files.PublishJob) bool { return job.Owner == owner }
+}
+
+// updatePublishJob updates the job with the given id if it matches, reporting whether it did
+func (r *MemoryRepository) updatePublishJob(id string, matches func(job files.PublishJob) bool, update func(job *files.PublishJob)) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.publishJobs {
+		if r.publishJobs[i].ID == id {
+			if !matches(r.publishJobs[i]) {
+				return false, nil
+			}
+			// work on a copy so that the batches slice held by the repository is never shared
+			job, err := clone(r.publishJobs[i])
+			if err != nil {
+				return false, err
+			}
+			update(&job)
+
+			updated, err := clone(job)
+			if err != nil {
+				return false, err
+			}
+			r.publishJobs[i] = updated
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *MemoryRepository) FindPublishJobs(ctx context.Context, state string) ([]files.PublishJob, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	jobs := make([]files.PublishJob, 0)
+	for _, job := range r.publishJobs {
+		if job.State == state {
+			jobs = append(jobs, job)
+		}
+	}
+	return clonePage(jobs, 0, len(jobs))
+}
+
+func (r *MemoryRepository) GetLatestPublishJob(ctx context.Context, collectionID, bundleID string) (files.PublishJob, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var latest *files.PublishJob
+	for i, job := range r.publishJobs {
+		matches := job.CollectionID != nil && *job.CollectionID == collectionID
+		if bundleID != "" {
+			matches = job.BundleID != nil && *job.BundleID == bundleID
+		}
+		if matches && (latest == nil || job.CreatedAt.After(latest.CreatedAt)) {
+			latest = &r.publishJobs[i]
+		}
+	}
+
+	if latest == nil {
+		return files.PublishJob{}, mongodriver.ErrNoDocumentFound
+	}
+	return clone(*latest)
+}
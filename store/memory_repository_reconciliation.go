@@ -0,0 +1,66 @@
+package store
+
+import (
+	"context"
+
+	"github.com/ONSdigital/dp-files-api/files"
+	mongodriver "github.com/ONSdigital/dp-mongodb/v3/mongodb"
+)
+
+func (r *MemoryRepository) InsertReconciliationReport(ctx context.Context, report *files.ReconciliationReport) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, err := clone(*report)
+	if err != nil {
+		return err
+	}
+	r.reports = append(r.reports, stored)
+	return nil
+}
+
+func (r *MemoryRepository) UpdateReconciliationReport(ctx context.Context, report *files.ReconciliationReport) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.reports {
+		if r.reports[i].ID != report.ID {
+			continue
+		}
+		stored, err := clone(*report)
+		if err != nil {
+			return err
+		}
+		r.reports[i] = stored
+	}
+	return nil
+}
+
+func (r *MemoryRepository) GetReconciliationReport(ctx context.Context, id string) (files.ReconciliationReport, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, report := range r.reports {
+		if report.ID == id {
+			return clone(report)
+		}
+	}
+	return files.ReconciliationReport{}, mongodriver.ErrNoDocumentFound
+}
+
+func (r *MemoryRepository) GetLatestReconciliationReport(ctx context.Context) (files.ReconciliationReport, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var latest *files.ReconciliationReport
+	for i, report := range r.reports {
+		if latest == nil || report.StartedAt.After(latest.StartedAt) {
+			latest = &r.reports[i]
+		}
+	}
+
+	if latest == nil {
+		return files.ReconciliationReport{}, mongodriver.ErrNoDocumentFound
+	}
+	return clone(*latest)
+}
@@ -0,0 +1,616 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-files-api/config"
+	"github.com/ONSdigital/dp-files-api/features/steps"
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/dp-files-api/store"
+	mongodriver "github.com/ONSdigital/dp-mongodb/v3/mongodb"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type MemoryRepositorySuite struct {
+	suite.Suite
+	ctx  context.Context
+	repo *store.MemoryRepository
+}
+
+func TestMemoryRepositorySuite(t *testing.T) {
+	suite.Run(t, new(MemoryRepositorySuite))
+}
+
+func (suite *MemoryRepositorySuite) SetupTest() {
+	suite.ctx = context.Background()
+	suite.repo = store.NewMemoryRepository()
+}
+
+func (suite *MemoryRepositorySuite) TestInsertFileEventsStopsAtFirstTakenSequence() {
+	suite.NoError(suite.repo.InsertFileEvent(suite.ctx, &files.FileEvent{Sequence: 2}))
+
+	n, err := suite.repo.InsertFileEvents(suite.ctx, []*files.FileEvent{{Sequence: 1}, {Sequence: 2}, {Sequence: 3}})
+
+	suite.True(mongo.IsDuplicateKeyError(err))
+	suite.Equal(1, n)
+	chained, err := suite.repo.FindChainedFileEvents(suite.ctx, 0, 10)
+	suite.NoError(err)
+	suite.Len(chained, 2)
+}
+
+func (suite *MemoryRepositorySuite) TestAdvanceFileEventChainHeadOnlyFromCurrentHead() {
+	_, err := suite.repo.GetFileEventChainHead(suite.ctx)
+	suite.ErrorIs(err, mongodriver.ErrNoDocumentFound)
+
+	advanced, err := suite.repo.AdvanceFileEventChainHead(suite.ctx, store.FileEventChainHead{}, 2, "hash-2")
+	suite.NoError(err)
+	suite.True(advanced)
+
+	advanced, err = suite.repo.AdvanceFileEventChainHead(suite.ctx, store.FileEventChainHead{}, 1, "hash-1")
+	suite.NoError(err)
+	suite.False(advanced, "the head has moved on")
+
+	advanced, err = suite.repo.AdvanceFileEventChainHead(suite.ctx, store.FileEventChainHead{Sequence: 2, Hash: "hash-2"}, -1, "hash-1")
+	suite.NoError(err)
+	suite.True(advanced)
+
+	head, err := suite.repo.GetFileEventChainHead(suite.ctx)
+	suite.NoError(err)
+	suite.Equal(store.FileEventChainHead{Sequence: 1, Hash: "hash-1"}, head)
+}
+
+func (suite *MemoryRepositorySuite) TestGetMetadataNotFound() {
+	_, err := suite.repo.GetMetadata(suite.ctx, "missing.csv")
+
+	suite.ErrorIs(err, mongodriver.ErrNoDocumentFound)
+}
+
+func (suite *MemoryRepositorySuite) TestInsertMetadataRejectsDuplicatePath() {
+	suite.NoError(suite.repo.InsertMetadata(suite.ctx, files.StoredRegisteredMetaData{Path: "file.csv"}))
+
+	err := suite.repo.InsertMetadata(suite.ctx, files.StoredRegisteredMetaData{Path: "file.csv"})
+
+	suite.True(mongo.IsDuplicateKeyError(err))
+}
+
+func (suite *MemoryRepositorySuite) TestInsertManyMetadataStopsAtFirstDuplicate() {
+	suite.NoError(suite.repo.InsertMetadata(suite.ctx, files.StoredRegisteredMetaData{Path: "b.csv"}))
+
+	n, err := suite.repo.InsertManyMetadata(suite.ctx, []files.StoredRegisteredMetaData{{Path: "a.csv"}, {Path: "b.csv"}, {Path: "c.csv"}})
+
+	suite.True(mongo.IsDuplicateKeyError(err))
+	suite.Equal(1, n)
+	all, _ := suite.repo.FindMetadata(suite.ctx, store.MetadataFilter{Paths: []string{"a.csv", "c.csv"}})
+	suite.Equal([]string{"a.csv"}, paths(all))
+}
+
+func (suite *MemoryRepositorySuite) TestInsertCollectionAndBundleRejectDuplicateIDs() {
+	suite.NoError(suite.repo.InsertCollection(suite.ctx, files.StoredCollection{ID: "c"}))
+	suite.NoError(suite.repo.InsertBundle(suite.ctx, files.StoredBundle{ID: "b"}))
+
+	suite.True(mongo.IsDuplicateKeyError(suite.repo.InsertCollection(suite.ctx, files.StoredCollection{ID: "c"})))
+	suite.True(mongo.IsDuplicateKeyError(suite.repo.InsertBundle(suite.ctx, files.StoredBundle{ID: "b"})))
+}
+
+func (suite *MemoryRepositorySuite) TestStoredMetadataIsACopy() {
+	collectionID := "c"
+	m := files.StoredRegisteredMetaData{Path: "file.csv", CollectionID: &collectionID}
+	suite.NoError(suite.repo.InsertMetadata(suite.ctx, m))
+
+	collectionID = "changed"
+	got, err := suite.repo.GetMetadata(suite.ctx, "file.csv")
+	suite.NoError(err)
+	suite.Equal("c", *got.CollectionID)
+
+	*got.CollectionID = "changed again"
+	again, _ := suite.repo.GetMetadata(suite.ctx, "file.csv")
+	suite.Equal("c", *again.CollectionID)
+}
+
+func (suite *MemoryRepositorySuite) TestStoredTimesHaveMillisecondPrecision() {
+	created := time.Date(2026, 1, 2, 3, 4, 5, 678901234, time.UTC)
+	suite.NoError(suite.repo.InsertMetadata(suite.ctx, files.StoredRegisteredMetaData{Path: "file.csv", CreatedAt: created}))
+
+	got, _ := suite.repo.GetMetadata(suite.ctx, "file.csv")
+
+	suite.Equal(created.Truncate(time.Millisecond), got.CreatedAt)
+}
+
+func (suite *MemoryRepositorySuite) TestUpdateMetadataSetsAndUnsetsFields() {
+	bundleID := "b"
+	suite.NoError(suite.repo.InsertMetadata(suite.ctx, files.StoredRegisteredMetaData{Path: "file.csv", State: store.StateCreated, BundleID: &bundleID}))
+
+	err := suite.repo.UpdateMetadata(suite.ctx, "file.csv", store.Update{
+		Set: []store.Field{
+			{Key: "state", Value: store.StateUploaded},
+			{Key: "content_item", Value: files.StoredContentItem{DatasetID: "d"}},
+			{Key: "content_item.edition", Value: "e"},
+		},
+		Unset: []string{"bundle_id"},
+	})
+
+	suite.NoError(err)
+	got, _ := suite.repo.GetMetadata(suite.ctx, "file.csv")
+	suite.Equal(store.StateUploaded, got.State)
+	suite.Nil(got.BundleID)
+	suite.Equal(&files.StoredContentItem{DatasetID: "d", Edition: "e"}, got.ContentItem)
+}
+
+func (suite *MemoryRepositorySuite) TestUpdateAndDeleteMatchingNothingSucceed() {
+	suite.NoError(suite.repo.UpdateMetadata(suite.ctx, "missing.csv", store.Update{Set: []store.Field{{Key: "state", Value: store.StateUploaded}}}))
+
+	deleted, err := suite.repo.DeleteMetadata(suite.ctx, "missing.csv")
+	suite.NoError(err)
+	suite.False(deleted)
+
+	deleted, err = suite.repo.DeleteBundle(suite.ctx, "missing")
+	suite.NoError(err)
+	suite.False(deleted)
+
+	deleted, err = suite.repo.DeleteCollection(suite.ctx, "missing")
+	suite.NoError(err)
+	suite.False(deleted)
+
+	_, err = suite.repo.GetMetadata(suite.ctx, "missing.csv")
+	suite.ErrorIs(err, mongodriver.ErrNoDocumentFound)
+}
+
+func (suite *MemoryRepositorySuite) TestUpsertCollectionCreatesThenUpdates() {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	suite.NoError(suite.repo.UpsertCollection(suite.ctx, "c", []store.Field{{Key: "state", Value: store.StatePublished}, {Key: "last_modified", Value: now}}))
+	suite.NoError(suite.repo.UpsertCollection(suite.ctx, "c", []store.Field{{Key: "published_at", Value: now}}))
+
+	got, err := suite.repo.GetCollection(suite.ctx, "c")
+	suite.NoError(err)
+	suite.Equal(files.StoredCollection{ID: "c", State: store.StatePublished, LastModified: now, PublishedAt: &now}, got)
+}
+
+func (suite *MemoryRepositorySuite) TestMetadataFilters() {
+	collectionA, collectionB, bundle := "a", "b", "bundle"
+	suite.NoError(suite.repo.InsertMetadata(suite.ctx, files.StoredRegisteredMetaData{Path: "1.csv", CollectionID: &collectionA, State: store.StatePublished}))
+	suite.NoError(suite.repo.InsertMetadata(suite.ctx, files.StoredRegisteredMetaData{Path: "2.csv", CollectionID: &collectionB, State: store.StateUploaded}))
+	suite.NoError(suite.repo.InsertMetadata(suite.ctx, files.StoredRegisteredMetaData{Path: "3.csv", CollectionID: &collectionA, State: store.StateUploaded}))
+	suite.NoError(suite.repo.InsertMetadata(suite.ctx, files.StoredRegisteredMetaData{Path: "4.csv", BundleID: &bundle, State: store.StateCreated}))
+
+	all, _ := suite.repo.CountMetadata(suite.ctx, store.MetadataFilter{})
+	suite.Equal(4, all)
+
+	inA, _ := suite.repo.FindMetadata(suite.ctx, store.MetadataFilter{CollectionID: &collectionA})
+	suite.Equal([]string{"1.csv", "3.csv"}, paths(inA))
+
+	unpublishedInA, _ := suite.repo.CountMetadata(suite.ctx, store.MetadataFilter{CollectionID: &collectionA, ExcludeStates: []string{store.StatePublished}})
+	suite.Equal(1, unpublishedInA)
+
+	first, err := suite.repo.FindOneMetadata(suite.ctx, store.MetadataFilter{ExcludeStates: []string{store.StatePublished}})
+	suite.NoError(err)
+	suite.Equal("2.csv", first.Path)
+
+	_, err = suite.repo.FindOneMetadata(suite.ctx, store.MetadataFilter{BundleID: &bundle, ExcludeStates: []string{store.StateCreated}})
+	suite.ErrorIs(err, mongodriver.ErrNoDocumentFound)
+}
+
+func (suite *MemoryRepositorySuite) TestMetadataCursorSortsByPathAndSkipsOffset() {
+	for _, path := range []string{"3.csv", "1.csv", "2.csv"} {
+		suite.NoError(suite.repo.InsertMetadata(suite.ctx, files.StoredRegisteredMetaData{Path: path}))
+	}
+
+	cursor, err := suite.repo.MetadataCursor(suite.ctx, store.MetadataFilter{}, 1)
+	suite.NoError(err)
+
+	found := make([]files.StoredRegisteredMetaData, 0)
+	for cursor.Next(suite.ctx) {
+		m, err := cursor.Current()
+		suite.NoError(err)
+		found = append(found, m)
+	}
+
+	suite.NoError(cursor.Err())
+	suite.NoError(cursor.Close(suite.ctx))
+	suite.Equal([]string{"2.csv", "3.csv"}, paths(found))
+}
+
+func (suite *MemoryRepositorySuite) TestFindFileEventsSortsNewestFirstWithOffsetAndLimit() {
+	base := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	for i, hours := range []int{2, 0, 3, 1} {
+		createdAt := base.Add(time.Duration(hours) * time.Hour)
+		path := "other.csv"
+		if i != 1 {
+			path = "file.csv"
+		}
+		suite.NoError(suite.repo.InsertFileEvent(suite.ctx, &files.FileEvent{CreatedAt: &createdAt, Action: files.ActionRead, File: &files.StoredRegisteredMetaData{Path: path}}))
+	}
+
+	filter := store.FileEventFilter{Path: "file.csv"}
+	count, _ := suite.repo.CountFileEvents(suite.ctx, filter)
+	suite.Equal(3, count)
+
+	events, err := suite.repo.FindFileEvents(suite.ctx, filter, false, 1, 5)
+	suite.NoError(err)
+	suite.Len(events, 2)
+	suite.Equal(base.Add(2*time.Hour), *events[0].CreatedAt)
+	suite.Equal(base.Add(1*time.Hour), *events[1].CreatedAt)
+
+	none, _ := suite.repo.FindFileEvents(suite.ctx, filter, false, 0, 0)
+	suite.Empty(none)
+
+	past, _ := suite.repo.FindFileEvents(suite.ctx, filter, false, 3, 5)
+	suite.Empty(past)
+
+	after, before := base.Add(time.Hour), base.Add(2*time.Hour)
+	between, _ := suite.repo.CountFileEvents(suite.ctx, store.FileEventFilter{After: &after, Before: &before})
+	suite.Equal(2, between)
+}
+
+func (suite *MemoryRepositorySuite) TestFindFileEventsByFileAndRequester() {
+	base := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	collectionID := "collection-1"
+	events := []files.FileEvent{
+		{Action: files.ActionUpdate, RequestedBy: &files.RequestedBy{ID: "user123"}, File: &files.StoredRegisteredMetaData{Path: "data/a.csv", CollectionID: &collectionID, ContentItem: &files.StoredContentItem{DatasetID: "cpih", Edition: "2026"}}},
+		{Action: files.ActionUpdate, RequestedBy: &files.RequestedBy{ID: "user123"}, File: &files.StoredRegisteredMetaData{Path: "data/b.csv", CollectionID: &collectionID, ContentItem: &files.StoredContentItem{DatasetID: "cpih", Edition: "2026"}}},
+		{Action: files.ActionRead, RequestedBy: &files.RequestedBy{ID: "user123"}, File: &files.StoredRegisteredMetaData{Path: "data/c.csv", CollectionID: &collectionID}},
+		{Action: files.ActionUpdate, RequestedBy: &files.RequestedBy{ID: "someone-else"}, File: &files.StoredRegisteredMetaData{Path: "data/d.csv"}},
+		{Action: files.ActionUpdate, Resource: "/file-events"},
+	}
+	for i := range events {
+		createdAt := base.Add(time.Duration(i) * time.Hour)
+		events[i].CreatedAt = &createdAt
+		suite.NoError(suite.repo.InsertFileEvent(suite.ctx, &events[i]))
+	}
+
+	filter := store.FileEventFilter{
+		PathPrefix:    "data/",
+		Action:        files.ActionUpdate,
+		RequestedByID: "user123",
+		CollectionID:  collectionID,
+		DatasetID:     "cpih",
+		Edition:       "2026",
+	}
+	count, _ := suite.repo.CountFileEvents(suite.ctx, filter)
+	suite.Equal(2, count)
+
+	oldestFirst, err := suite.repo.FindFileEvents(suite.ctx, filter, true, 0, 5)
+	suite.NoError(err)
+	suite.Len(oldestFirst, 2)
+	suite.Equal("data/a.csv", oldestFirst[0].File.Path)
+	suite.Equal("data/b.csv", oldestFirst[1].File.Path)
+
+	bundled, _ := suite.repo.CountFileEvents(suite.ctx, store.FileEventFilter{BundleID: "bundle-1"})
+	suite.Zero(bundled)
+
+	prefixed, _ := suite.repo.CountFileEvents(suite.ctx, store.FileEventFilter{PathPrefix: "data/"})
+	suite.Equal(4, prefixed, "events without a file have no path to match")
+}
+
+func (suite *MemoryRepositorySuite) TestOutboxMessagesDueOldestFirst() {
+	now := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	suite.NoError(suite.repo.InsertOutboxMessage(suite.ctx, files.OutboxMessage{ID: "later", CreatedAt: now, NextAttemptAt: now.Add(time.Minute)}))
+	suite.NoError(suite.repo.InsertOutboxMessage(suite.ctx, files.OutboxMessage{ID: "second", CreatedAt: now.Add(-time.Minute), NextAttemptAt: now}))
+	suite.NoError(suite.repo.InsertOutboxMessage(suite.ctx, files.OutboxMessage{ID: "first", CreatedAt: now.Add(-time.Hour), NextAttemptAt: now}))
+
+	due, err := suite.repo.FindDueOutboxMessages(suite.ctx, now, 10)
+	suite.NoError(err)
+	suite.Len(due, 2)
+	suite.Equal("first", due[0].ID)
+	suite.Equal("second", due[1].ID)
+
+	suite.NoError(suite.repo.RecordOutboxMessageSent(suite.ctx, "first", now, now.Add(time.Minute)))
+	suite.NoError(suite.repo.RecordOutboxMessageFailure(suite.ctx, "first", now.Add(time.Hour), "broken"))
+	suite.NoError(suite.repo.DeleteOutboxMessage(suite.ctx, "second"))
+
+	due, _ = suite.repo.FindDueOutboxMessages(suite.ctx, now.Add(time.Hour), 1)
+	suite.Len(due, 1)
+	suite.Equal("first", due[0].ID)
+	suite.Equal(1, due[0].Attempts)
+	suite.Equal("broken", due[0].LastError)
+	suite.Nil(due[0].SentAt)
+}
+
+func (suite *MemoryRepositorySuite) TestSentOutboxMessageLeasedUntilConfirmBy() {
+	now := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	suite.NoError(suite.repo.InsertOutboxMessage(suite.ctx, files.OutboxMessage{ID: "sent", CreatedAt: now, NextAttemptAt: now}))
+
+	suite.NoError(suite.repo.RecordOutboxMessageSent(suite.ctx, "sent", now, now.Add(time.Minute)))
+
+	due, err := suite.repo.FindDueOutboxMessages(suite.ctx, now, 10)
+	suite.NoError(err)
+	suite.Empty(due)
+
+	due, _ = suite.repo.FindDueOutboxMessages(suite.ctx, now.Add(time.Minute), 10)
+	suite.Require().Len(due, 1)
+	suite.Require().NotNil(due[0].SentAt)
+	suite.Equal(now, *due[0].SentAt)
+}
+
+func (suite *MemoryRepositorySuite) TestPendingOutboxMessagesNotDueUntilReleased() {
+	now := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	suite.NoError(suite.repo.InsertOutboxMessage(suite.ctx, files.OutboxMessage{ID: "pending", Pending: true, CreatedAt: now, NextAttemptAt: now}))
+
+	due, err := suite.repo.FindDueOutboxMessages(suite.ctx, now, 10)
+	suite.NoError(err)
+	suite.Empty(due)
+
+	suite.NoError(suite.repo.ReleaseOutboxMessages(suite.ctx, []string{"pending"}))
+
+	due, _ = suite.repo.FindDueOutboxMessages(suite.ctx, now, 10)
+	suite.Require().Len(due, 1)
+	suite.Equal("pending", due[0].ID)
+	suite.False(due[0].Pending)
+}
+
+func (suite *MemoryRepositorySuite) TestPublishJobCheckpointsAndCompletion() {
+	collectionID := "c"
+	now := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	older := &files.PublishJob{ID: "old", CollectionID: &collectionID, State: store.PublishJobStateCompleted, CreatedAt: now.Add(-time.Hour)}
+	job := &files.PublishJob{
+		ID:           "new",
+		CollectionID: &collectionID,
+		State:        store.PublishJobStateInProgress,
+		Owner:        "instance-1",
+		Batches:      []files.PublishJobBatch{{Number: 0, Size: 2}, {Number: 1, Offset: 2, Size: 2}},
+		CreatedAt:    now,
+	}
+	suite.NoError(suite.repo.InsertPublishJob(suite.ctx, job))
+	suite.NoError(suite.repo.InsertPublishJob(suite.ctx, older))
+
+	held, err := suite.repo.CheckpointPublishJobBatch(suite.ctx, "new", store.PublishJobCheckpoint{Owner: "instance-2", Batch: 1, Position: 1, Sent: 1, LastModified: now})
+	suite.NoError(err)
+	suite.False(held)
+
+	held, err = suite.repo.CheckpointPublishJobBatch(suite.ctx, "new", store.PublishJobCheckpoint{
+		Owner:          "instance-1",
+		Batch:          1,
+		Position:       2,
+		LastPath:       "4.csv",
+		Sent:           1,
+		Failed:         1,
+		Completed:      true,
+		LastModified:   now,
+		LeaseExpiresAt: now.Add(time.Minute),
+	})
+	suite.NoError(err)
+	suite.True(held)
+
+	unfinished, _ := suite.repo.FindPublishJobs(suite.ctx, store.PublishJobStateInProgress)
+	suite.Len(unfinished, 1)
+	suite.Equal(1, unfinished[0].Sent)
+	suite.Equal(1, unfinished[0].Failed)
+	suite.Equal(now.Add(time.Minute), *unfinished[0].LeaseExpiresAt)
+	suite.Equal(files.PublishJobBatch{Number: 1, Offset: 2, Size: 2, Position: 2, LastPath: "4.csv", Sent: 1, Failed: 1, Completed: true}, unfinished[0].Batches[1])
+	suite.Equal(files.PublishJobBatch{Number: 0, Size: 2}, unfinished[0].Batches[0])
+
+	suite.NoError(suite.repo.CompletePublishJob(suite.ctx, "new", "instance-1", store.PublishJobStateCompleted, now.Add(time.Minute)))
+
+	latest, err := suite.repo.GetLatestPublishJob(suite.ctx, collectionID, "")
+	suite.NoError(err)
+	suite.Equal("new", latest.ID)
+	suite.Equal(store.PublishJobStateCompleted, latest.State)
+	suite.Equal(now.Add(time.Minute), *latest.CompletedAt)
+
+	_, err = suite.repo.GetLatestPublishJob(suite.ctx, "", "bundle")
+	suite.ErrorIs(err, mongodriver.ErrNoDocumentFound)
+}
+
+func (suite *MemoryRepositorySuite) TestClaimPublishJobOnlyOnceLeaseExpires() {
+	now := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	leaseExpiresAt := now.Add(time.Minute)
+	suite.NoError(suite.repo.InsertPublishJob(suite.ctx, &files.PublishJob{
+		ID:             "job",
+		State:          store.PublishJobStateInProgress,
+		Owner:          "instance-1",
+		LeaseExpiresAt: &leaseExpiresAt,
+	}))
+
+	claimed, err := suite.repo.ClaimPublishJob(suite.ctx, "job", "instance-2", now, now.Add(5*time.Minute))
+	suite.NoError(err)
+	suite.False(claimed)
+
+	claimed, err = suite.repo.ClaimPublishJob(suite.ctx, "job", "instance-2", leaseExpiresAt, leaseExpiresAt.Add(5*time.Minute))
+	suite.NoError(err)
+	suite.True(claimed)
+
+	// the previous owner can no longer record its progress or complete the job
+	held, err := suite.repo.CheckpointPublishJobBatch(suite.ctx, "job", store.PublishJobCheckpoint{Owner: "instance-1"})
+	suite.NoError(err)
+	suite.False(held)
+	suite.NoError(suite.repo.CompletePublishJob(suite.ctx, "job", "instance-1", store.PublishJobStateCompleted, now))
+
+	jobs, _ := suite.repo.FindPublishJobs(suite.ctx, store.PublishJobStateInProgress)
+	suite.Len(jobs, 1)
+	suite.Equal("instance-2", jobs[0].Owner)
+
+	claimed, err = suite.repo.ClaimPublishJob(suite.ctx, "job", "instance-3", leaseExpiresAt, leaseExpiresAt.Add(5*time.Minute))
+	suite.NoError(err)
+	suite.False(claimed)
+}
+
+func (suite *MemoryRepositorySuite) TestFileVersionsSortedByVersion() {
+	suite.NoError(suite.repo.InsertFileVersion(suite.ctx, files.StoredRegisteredMetaData{Path: "file.csv", Version: 2, Etag: "second"}))
+	suite.NoError(suite.repo.InsertFileVersion(suite.ctx, files.StoredRegisteredMetaData{Path: "file.csv", Version: 1, Etag: "first"}))
+	suite.NoError(suite.repo.InsertFileVersion(suite.ctx, files.StoredRegisteredMetaData{Path: "other.csv", Version: 1}))
+
+	suite.True(mongo.IsDuplicateKeyError(suite.repo.InsertFileVersion(suite.ctx, files.StoredRegisteredMetaData{Path: "file.csv", Version: 1})))
+
+	versions, err := suite.repo.FindFileVersions(suite.ctx, "file.csv")
+	suite.NoError(err)
+	suite.Len(versions, 2)
+	suite.Equal("first", versions[0].Etag)
+	suite.Equal("second", versions[1].Etag)
+
+	version, err := suite.repo.GetFileVersion(suite.ctx, "file.csv", 2)
+	suite.NoError(err)
+	suite.Equal("second", version.Etag)
+
+	_, err = suite.repo.GetFileVersion(suite.ctx, "file.csv", 3)
+	suite.ErrorIs(err, mongodriver.ErrNoDocumentFound)
+
+	latest, err := suite.repo.LatestFileVersions(suite.ctx, []string{"file.csv", "other.csv", "missing.csv"})
+	suite.NoError(err)
+	suite.Equal(map[string]int{"file.csv": 2, "other.csv": 1}, latest)
+}
+
+func (suite *MemoryRepositorySuite) TestStoreRunsOnMemoryRepository() {
+	cfg, _ := config.Get()
+	subject := store.NewStore(suite.repo, steps.TestClock{}, nil, cfg)
+	collectionID := "c"
+
+	suite.NoError(subject.RegisterFileUpload(suite.ctx, files.StoredRegisteredMetaData{Path: "file.csv", CollectionID: &collectionID}))
+	suite.ErrorIs(subject.RegisterFileUpload(suite.ctx, files.StoredRegisteredMetaData{Path: "file.csv"}), store.ErrDuplicateFile)
+	suite.NoError(subject.MarkUploadComplete(suite.ctx, files.FileEtagChange{Path: "file.csv", Etag: "etag"}))
+
+	m, err := subject.GetFileMetadata(suite.ctx, "file.csv")
+	suite.NoError(err)
+	suite.Equal(store.StateUploaded, m.State)
+	suite.Equal("etag", m.Etag)
+
+	inCollection, err := subject.GetFilesMetadata(suite.ctx, store.FilesQuery{CollectionID: collectionID, Limit: 20})
+	suite.NoError(err)
+	suite.Equal([]string{"file.csv"}, paths(inCollection.Items))
+
+	_, err = subject.GetFileMetadata(suite.ctx, "missing.csv")
+	suite.ErrorIs(err, store.ErrFileNotRegistered)
+}
+
+func (suite *MemoryRepositorySuite) TestFindFileChangesFiltersAndOrdersBySequence() {
+	collectionID := "c"
+	for _, sequence := range []int64{3, 1, 2} {
+		suite.NoError(suite.repo.InsertFileChange(suite.ctx, files.FileChange{Sequence: sequence, Path: "file.csv", CollectionID: &collectionID}))
+	}
+	suite.NoError(suite.repo.InsertFileChange(suite.ctx, files.FileChange{Sequence: 4, Path: "other.csv"}))
+
+	err := suite.repo.InsertFileChange(suite.ctx, files.FileChange{Sequence: 4, Path: "again.csv"})
+	suite.True(mongo.IsDuplicateKeyError(err))
+
+	changes, err := suite.repo.FindFileChanges(suite.ctx, store.FileChangeFilter{CollectionID: &collectionID, AfterSequence: 1}, 10)
+	suite.NoError(err)
+	suite.Len(changes, 2)
+	suite.Equal(int64(2), changes[0].Sequence)
+	suite.Equal(int64(3), changes[1].Sequence)
+
+	changes, err = suite.repo.FindFileChanges(suite.ctx, store.FileChangeFilter{}, 3)
+	suite.NoError(err)
+	suite.Len(changes, 3)
+	suite.Equal(int64(1), changes[0].Sequence)
+
+	latest, err := suite.repo.GetLatestFileChange(suite.ctx)
+	suite.NoError(err)
+	suite.Equal("other.csv", latest.Path)
+}
+
+func (suite *MemoryRepositorySuite) TestListDirectoryGroupsByNextPathPart() {
+	for _, m := range []files.StoredRegisteredMetaData{
+		{Path: "a/b", SizeInBytes: 1},
+		{Path: "a/b/c", SizeInBytes: 2},
+		{Path: "a/b/d/e", SizeInBytes: 3},
+		{Path: "a/a", SizeInBytes: 4},
+		{Path: "ab/c", SizeInBytes: 5},
+	} {
+		suite.NoError(suite.repo.InsertMetadata(suite.ctx, m))
+	}
+
+	entries, err := suite.repo.ListDirectory(suite.ctx, "a/", "", 0)
+
+	suite.NoError(err)
+	suite.Equal([]files.DirectoryEntry{
+		{Name: "a", FileCount: 1, SizeInBytes: 4},
+		{Name: "b", FileCount: 1, SizeInBytes: 1},
+		{Name: "b", IsDirectory: true, FileCount: 2, SizeInBytes: 5},
+	}, entries)
+
+	entries, err = suite.repo.ListDirectory(suite.ctx, "a/", "a", 1)
+
+	suite.NoError(err)
+	suite.Equal([]files.DirectoryEntry{{Name: "b", FileCount: 1, SizeInBytes: 1}}, entries)
+
+	count, size, err := suite.repo.SumDirectory(suite.ctx, "a/")
+
+	suite.NoError(err)
+	suite.Equal(4, count)
+	suite.Equal(uint64(10), size)
+}
+
+func paths(metadata []files.StoredRegisteredMetaData) []string {
+	p := make([]string, 0, len(metadata))
+	for _, m := range metadata {
+		p = append(p, m.Path)
+	}
+	return p
+}
+
+func (suite *MemoryRepositorySuite) TestLockResource() {
+	suite.NoError(suite.repo.LockResource(suite.ctx, "resource", "lock-1", time.Minute))
+	suite.ErrorIs(suite.repo.LockResource(suite.ctx, "resource", "lock-2", time.Minute), store.ErrResourceLocked)
+	suite.NoError(suite.repo.LockResource(suite.ctx, "other", "lock-3", time.Minute))
+
+	suite.NoError(suite.repo.UnlockResource(suite.ctx, "lock-1"))
+	suite.NoError(suite.repo.LockResource(suite.ctx, "resource", "lock-2", time.Minute))
+}
+
+func (suite *MemoryRepositorySuite) TestLockResourceTakesExpiredLock() {
+	suite.NoError(suite.repo.LockResource(suite.ctx, "resource", "lock-1", -time.Second))
+
+	suite.NoError(suite.repo.LockResource(suite.ctx, "resource", "lock-2", time.Minute))
+}
+
+func (suite *MemoryRepositorySuite) TestFindScheduledCollections() {
+	due := time.Date(2024, 3, 1, 7, 0, 0, 0, time.UTC)
+	later := due.Add(time.Hour)
+	suite.NoError(suite.repo.InsertCollection(suite.ctx, files.StoredCollection{ID: "due", State: store.StateScheduled, PublishAt: &due}))
+	suite.NoError(suite.repo.InsertCollection(suite.ctx, files.StoredCollection{ID: "later", State: store.StateScheduled, PublishAt: &later}))
+	suite.NoError(suite.repo.InsertCollection(suite.ctx, files.StoredCollection{ID: "published", State: store.StatePublished, PublishAt: &due}))
+
+	collections, err := suite.repo.FindScheduledCollections(suite.ctx, due)
+
+	suite.NoError(err)
+	suite.Require().Len(collections, 1)
+	suite.Equal("due", collections[0].ID)
+}
+
+func (suite *MemoryRepositorySuite) TestIdempotencyRecordsOnlyChangeWhenCreatedAtMatches() {
+	createdAt := time.Date(2026, 10, 17, 9, 0, 0, 123456789, time.UTC)
+	record := files.IdempotencyRecord{Key: "key", Fingerprint: "fingerprint", CreatedAt: createdAt}
+	suite.NoError(suite.repo.InsertIdempotencyRecord(suite.ctx, record))
+	suite.True(mongo.IsDuplicateKeyError(suite.repo.InsertIdempotencyRecord(suite.ctx, record)))
+
+	suite.NoError(suite.repo.CompleteIdempotencyRecord(suite.ctx, "key", createdAt.Add(time.Second), files.IdempotentResponse{StatusCode: 500}))
+	suite.NoError(suite.repo.CompleteIdempotencyRecord(suite.ctx, "key", createdAt, files.IdempotentResponse{StatusCode: 201}))
+	stored, err := suite.repo.GetIdempotencyRecord(suite.ctx, "key")
+	suite.NoError(err)
+	suite.Equal(201, stored.Response.StatusCode)
+
+	deleted, err := suite.repo.DeleteIdempotencyRecord(suite.ctx, "key", createdAt.Add(time.Second))
+	suite.NoError(err)
+	suite.False(deleted)
+	deleted, err = suite.repo.DeleteIdempotencyRecord(suite.ctx, "key", createdAt)
+	suite.NoError(err)
+	suite.True(deleted)
+	_, err = suite.repo.GetIdempotencyRecord(suite.ctx, "key")
+	suite.ErrorIs(err, mongodriver.ErrNoDocumentFound)
+}
+
+func (suite *MemoryRepositorySuite) TestUpdateMetadataIfUnchangedMatchesRevisionAndCreatedAt() {
+	createdAt := time.Date(2026, 10, 17, 9, 0, 0, 123456789, time.UTC)
+	suite.NoError(suite.repo.InsertMetadata(suite.ctx, files.StoredRegisteredMetaData{Path: "file.csv", State: store.StateCreated, CreatedAt: createdAt}))
+	update := store.Update{Set: []store.Field{{Key: "state", Value: store.StateUploaded}}}
+
+	updated, err := suite.repo.UpdateMetadataIfUnchanged(suite.ctx, "file.csv", 1, createdAt, update)
+	suite.NoError(err)
+	suite.False(updated)
+	updated, err = suite.repo.UpdateMetadataIfUnchanged(suite.ctx, "file.csv", 0, createdAt.Add(time.Second), update)
+	suite.NoError(err)
+	suite.False(updated)
+
+	updated, err = suite.repo.UpdateMetadataIfUnchanged(suite.ctx, "file.csv", 0, createdAt, update)
+	suite.NoError(err)
+	suite.True(updated)
+	got, _ := suite.repo.GetMetadata(suite.ctx, "file.csv")
+	suite.Equal(store.StateUploaded, got.State)
+	suite.Equal(int64(1), got.Revision)
+
+	suite.NoError(suite.repo.UpdateMetadata(suite.ctx, "file.csv", update))
+	got, _ = suite.repo.GetMetadata(suite.ctx, "file.csv")
+	suite.Equal(int64(2), got.Revision)
+}
@@ -0,0 +1,65 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/ONSdigital/dp-files-api/files"
+	mongodriver "github.com/ONSdigital/dp-mongodb/v3/mongodb"
+)
+
+func (r *MemoryRepository) InsertTrashedFile(ctx context.Context, file files.TrashedFile) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, err := clone(file)
+	if err != nil {
+		return err
+	}
+	r.trash = append(r.trash, stored)
+	return nil
+}
+
+func (r *MemoryRepository) GetTrashedFile(ctx context.Context, path string) (files.TrashedFile, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var latest *files.TrashedFile
+	for i, t := range r.trash {
+		if t.Path == path && (latest == nil || t.DeletedAt.After(latest.DeletedAt)) {
+			latest = &r.trash[i]
+		}
+	}
+
+	if latest == nil {
+		return files.TrashedFile{}, mongodriver.ErrNoDocumentFound
+	}
+	return clone(*latest)
+}
+
+func (r *MemoryRepository) DeleteTrashedFile(ctx context.Context, id string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, t := range r.trash {
+		if t.ID == id {
+			r.trash = append(r.trash[:i], r.trash[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *MemoryRepository) FindExpiredTrashedFiles(ctx context.Context, due time.Time) ([]files.TrashedFile, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	due = toMillis(due)
+	trashed := make([]files.TrashedFile, 0)
+	for _, t := range r.trash {
+		if !t.PurgeAfter.After(due) {
+			trashed = append(trashed, t)
+		}
+	}
+	return clonePage(trashed, 0, len(trashed))
+}
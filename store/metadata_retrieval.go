@@ -2,18 +2,17 @@ package store
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
+	"time"
 
 	"github.com/ONSdigital/dp-files-api/files"
 	mongodriver "github.com/ONSdigital/dp-mongodb/v3/mongodb"
 	"github.com/ONSdigital/log.go/v2/log"
-	"go.mongodb.org/mongo-driver/bson"
 )
 
 func (store *Store) GetFileMetadata(ctx context.Context, path string) (files.StoredRegisteredMetaData, error) {
-	fileMetadata := files.StoredRegisteredMetaData{}
-
-	err := store.metadataCollection.FindOne(ctx, bson.M{fieldPath: path}, &fileMetadata)
+	fileMetadata, err := store.repo.GetMetadata(ctx, path)
 	if err != nil {
 		if errors.Is(err, mongodriver.ErrNoDocumentFound) {
 			log.Error(ctx, "file metadata not found", err, log.Data{"path": path})
@@ -46,9 +45,16 @@ func (store *Store) GetFileMetadata(ctx context.Context, path string) (files.Sto
 }
 
 func (store *Store) GetFileMetadataWeb(ctx context.Context, path string) (files.StoredRegisteredMetaData, error) {
-	fileMetadata := files.StoredRegisteredMetaData{}
+	return store.getFileMetadataWeb(ctx, path, store.isCollectionPublishedWeb, store.isBundlePublishedWeb)
+}
+
+// publishedCheck reports whether the collection or bundle with the given id is published
+type publishedCheck func(ctx context.Context, id string) (bool, error)
 
-	err := store.metadataCollection.FindOne(ctx, bson.M{fieldPath: path}, &fileMetadata)
+// getFileMetadataWeb returns the metadata of a file that may be served in web mode, using isCollectionPublished and
+// isBundlePublished to find whether an uploaded file has been published with its collection or bundle
+func (store *Store) getFileMetadataWeb(ctx context.Context, path string, isCollectionPublished, isBundlePublished publishedCheck) (files.StoredRegisteredMetaData, error) {
+	fileMetadata, err := store.repo.GetMetadata(ctx, path)
 	if err != nil {
 		if errors.Is(err, mongodriver.ErrNoDocumentFound) {
 			log.Error(ctx, "file metadata not found", err, log.Data{"path": path})
@@ -59,37 +65,62 @@ func (store *Store) GetFileMetadataWeb(ctx context.Context, path string) (files.
 
 	switch fileMetadata.State {
 	case StateUploaded:
+		var published bool
 		if fileMetadata.CollectionID != nil {
-			collectionPublishedMetadata, err := store.GetCollectionPublishedMetadata(ctx, *fileMetadata.CollectionID)
-
-			if err != nil {
-				return files.StoredRegisteredMetaData{}, err
-			}
-
-			if collectionPublishedMetadata.State == StatePublished {
-				return fileMetadata, nil
-			}
+			published, err = isCollectionPublished(ctx, *fileMetadata.CollectionID)
 		} else if fileMetadata.BundleID != nil {
-			bundlePublishedMetadata, err := store.GetBundlePublishedMetadata(ctx, *fileMetadata.BundleID)
-
-			if err != nil {
-				return files.StoredRegisteredMetaData{}, err
-			}
-
-			if bundlePublishedMetadata.State == StatePublished {
-				return fileMetadata, nil
-			}
-		} else {
-			return files.StoredRegisteredMetaData{}, ErrFileNotRegistered
+			published, err = isBundlePublished(ctx, *fileMetadata.BundleID)
+		}
+		if err != nil {
+			return files.StoredRegisteredMetaData{}, err
+		}
+		if published {
+			return fileMetadata, nil
 		}
 	case StateMoved, StatePublished:
 		return fileMetadata, nil
-	default:
-		return files.StoredRegisteredMetaData{}, ErrFileNotRegistered
+	case StateWithdrawn:
+		return fileMetadata, ErrFileWithdrawn
 	}
 	return files.StoredRegisteredMetaData{}, ErrFileNotRegistered
 }
 
+func (store *Store) isCollectionPublishedWeb(ctx context.Context, id string) (bool, error) {
+	collection, err := store.GetCollectionPublishedMetadata(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	return collection.State == StatePublished, nil
+}
+
+func (store *Store) isBundlePublishedWeb(ctx context.Context, id string) (bool, error) {
+	bundle, err := store.GetBundlePublishedMetadata(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	return bundle.State == StatePublished, nil
+}
+
+// FilesQuery selects a page of the files in a collection or bundle. Empty fields are not filtered on. Cursor is the
+// NextCursor of the previous page, or empty for the first page.
+type FilesQuery struct {
+	CollectionID   string
+	BundleID       string
+	State          string
+	Type           string
+	IsPublishable  *bool
+	PathPrefix     string
+	CreatedAfter   *time.Time
+	CreatedBefore  *time.Time
+	ModifiedAfter  *time.Time
+	ModifiedBefore *time.Time
+	Cursor         string
+	// Offset is the number of matching files skipped before the page, which cannot be given with a Cursor
+	Offset int
+	// Limit is the page size; zero returns every matching file on one page
+	Limit int
+}
+
 // GetFilesMetadata godoc
 // @Description  GETs metadata for a file
 // @Tags         File upload started
@@ -100,49 +131,119 @@ func (store *Store) GetFileMetadataWeb(ctx context.Context, path string) (files.
 // @Failure      404
 // @Failure      500
 // @Router       /files [get]
-func (store *Store) GetFilesMetadata(ctx context.Context, collectionID, bundleID string) ([]files.StoredRegisteredMetaData, error) {
-	storedFiles := make([]files.StoredRegisteredMetaData, 0)
+func (store *Store) GetFilesMetadata(ctx context.Context, query FilesQuery) (*files.FilesList, error) {
+	if query.Limit < 0 {
+		return nil, ErrInvalidPagination
+	}
+	if query.Offset < 0 || (query.Offset > 0 && query.Cursor != "") {
+		return nil, ErrInvalidOffset
+	}
 
-	if collectionID != "" {
-		_, err := store.metadataCollection.Find(ctx, bson.M{fieldCollectionID: collectionID}, &storedFiles)
-		if err != nil {
-			return nil, err
-		}
+	pathAfter, err := decodeFilesCursor(query.Cursor)
+	if err != nil {
+		return nil, err
+	}
 
-		// get the collection metadata, and if they're not present, return the files unchanged
-		collection, err := store.GetCollectionPublishedMetadata(ctx, collectionID)
-		if err != nil {
-			return storedFiles, nil
-		}
+	filter := MetadataFilter{
+		Type:           query.Type,
+		IsPublishable:  query.IsPublishable,
+		PathPrefix:     query.PathPrefix,
+		CreatedAfter:   query.CreatedAfter,
+		CreatedBefore:  query.CreatedBefore,
+		ModifiedAfter:  query.ModifiedAfter,
+		ModifiedBefore: query.ModifiedBefore,
+	}
 
-		// we got the collection published metadata, so apply them to all the files in the collection
-		for i := 0; i < len(storedFiles); i++ {
-			store.PatchFilePublishMetadata(&storedFiles[i], &collection)
+	// the collection or bundle published metadata is applied to every file on the page; if it is not present the
+	// files are returned unchanged
+	patch := func(*files.StoredRegisteredMetaData) {}
+	published := false
+	if query.CollectionID != "" {
+		filter.CollectionID = &query.CollectionID
+		if collection, err := store.GetCollectionPublishedMetadata(ctx, query.CollectionID); err == nil {
+			published = collection.State == StatePublished
+			patch = func(m *files.StoredRegisteredMetaData) { store.PatchFilePublishMetadata(m, &collection) }
 		}
-	} else if bundleID != "" {
-		_, err := store.metadataCollection.Find(ctx, bson.M{fieldBundleID: bundleID}, &storedFiles)
-		if err != nil {
-			return nil, err
+	} else if query.BundleID != "" {
+		filter.BundleID = &query.BundleID
+		if bundle, err := store.GetBundlePublishedMetadata(ctx, query.BundleID); err == nil {
+			published = bundle.State == StatePublished
+			patch = func(m *files.StoredRegisteredMetaData) { store.PatchFilePublishBundleMetadata(m, &bundle) }
 		}
+	}
+	if query.State != "" {
+		filter.States = storedStates(query.State, published)
+	}
 
-		// get the bundle metadata, and if they're not present, return the files unchanged
-		bundle, err := store.GetBundlePublishedMetadata(ctx, bundleID)
-		if err != nil {
-			return storedFiles, nil
-		}
+	list := &files.FilesList{Limit: query.Limit, Offset: query.Offset, Items: make([]files.StoredRegisteredMetaData, 0)}
+	if query.State != "" && len(filter.States) == 0 {
+		return list, nil
+	}
 
-		// we got the bundle published metadata, so apply them to all the files in the bundle
-		for i := 0; i < len(storedFiles); i++ {
-			store.PatchFilePublishBundleMetadata(&storedFiles[i], &bundle)
-		}
+	list.TotalCount, err = store.repo.CountMetadata(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// one more file than the limit is read to find out whether there is another page
+	filter.PathAfter = pathAfter
+	limit := 0
+	if query.Limit > 0 {
+		limit = query.Limit + 1
+	}
+	list.Items, err = store.repo.FindMetadataPage(ctx, filter, query.Offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	if query.Limit > 0 && len(list.Items) > query.Limit {
+		list.Items = list.Items[:query.Limit]
+		list.NextCursor = encodeFilesCursor(list.Items[query.Limit-1].Path)
 	}
 
-	return storedFiles, nil
+	for i := range list.Items {
+		patch(&list.Items[i])
+	}
+	list.Count = len(list.Items)
+	// a list that is not paginated gives the number of files returned as its limit, as it always has
+	if query.Limit == 0 {
+		list.Limit = list.Count
+	}
+
+	return list, nil
+}
+
+// storedStates returns the states a file can be stored in to be returned in the given state. Files in a published
+// collection or bundle are stored as UPLOADED but returned as PUBLISHED, so none are returned as UPLOADED.
+func storedStates(state string, published bool) []string {
+	if !published {
+		return []string{state}
+	}
+
+	switch state {
+	case StatePublished:
+		return []string{StateUploaded, StatePublished}
+	case StateUploaded:
+		return []string{}
+	default:
+		return []string{state}
+	}
+}
+
+// The files cursor is the path of the last file on the page, encoded so that clients treat it as opaque
+func encodeFilesCursor(path string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(path))
+}
+
+func decodeFilesCursor(cursor string) (string, error) {
+	path, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", ErrInvalidCursor
+	}
+	return string(path), nil
 }
 
 func (store *Store) GetCollectionPublishedMetadata(ctx context.Context, id string) (files.StoredCollection, error) {
-	collection := files.StoredCollection{}
-	err := store.collectionsCollection.FindOne(ctx, bson.M{fieldID: id}, &collection)
+	collection, err := store.repo.GetCollection(ctx, id)
 	if err != nil {
 		if errors.Is(err, mongodriver.ErrNoDocumentFound) {
 			return files.StoredCollection{}, ErrCollectionMetadataNotRegistered
@@ -219,22 +320,34 @@ func (store *Store) PatchFilePublishBundleMetadata(metadata *files.StoredRegiste
 func (store *Store) UpdateContentItem(ctx context.Context, path string, contentItem *files.StoredContentItem) error {
 	logdata := log.Data{"path": path}
 
-	query := bson.M{
-		fieldPath: path,
+	metadata, err := store.repo.GetMetadata(ctx, path)
+	if err != nil {
+		if errors.Is(err, mongodriver.ErrNoDocumentFound) {
+			return ErrFileNotRegistered
+		}
+		log.Error(ctx, "failed to find file metadata", err, logdata)
+		return err
+	}
+
+	if err = checkIfMatch(ctx, metadata); err != nil {
+		return err
 	}
 
-	update := bson.M{
-		"$set": bson.M{
-			"content_item":  contentItem,
-			"last_modified": store.clock.GetCurrentTime(),
-		},
+	outboxID, err := store.enqueueFileLifecycle(ctx, path, files.LifecycleContentItemUpdated, metadata.State, metadata.State)
+	if err != nil {
+		return err
 	}
 
-	_, err := store.metadataCollection.UpdateOne(ctx, query, update)
+	err = store.updateMetadata(ctx, metadata, Update{Set: []Field{
+		{Key: fieldContentItem, Value: contentItem},
+		{Key: fieldLastModified, Value: store.clock.GetCurrentTime()},
+	}})
 	if err != nil {
+		store.withdrawOutboxMessages(ctx, []string{outboxID})
 		log.Error(ctx, "failed to update content item in file metadata", err, logdata)
 		return err
 	}
+	store.releaseOutboxMessages(ctx, []string{outboxID})
 
 	return nil
 }
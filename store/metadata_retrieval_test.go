@@ -2,6 +2,8 @@ package store_test
 
 import (
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/ONSdigital/dp-files-api/config"
 	"github.com/ONSdigital/dp-files-api/files"
@@ -25,7 +27,7 @@ func (suite *StoreSuite) TestGetFileMetadataNotFoundError() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collection, nil, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 	_, err := subject.GetFileMetadata(suite.defaultContext, suite.path)
 
 	logEvent := suite.logInterceptor.GetLogEvent()
@@ -40,7 +42,7 @@ func (suite *StoreSuite) TestGetFileMetadataOtherError() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collection, nil, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 	_, err := subject.GetFileMetadata(suite.defaultContext, suite.path)
 
 	suite.EqualError(err, "find error")
@@ -56,7 +58,7 @@ func (suite *StoreSuite) TestGetFileMetadataNoCollectionPatching() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collection, nil, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 	actualMetadata, _ := subject.GetFileMetadata(suite.defaultContext, suite.path)
 
 	suite.Exactly(expectedMetadata, actualMetadata)
@@ -78,7 +80,7 @@ func (suite *StoreSuite) TestGetFileMetadataCollectionError() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&metadataColl, &collectionColl, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &metadataColl, Collections: &collectionColl, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 	actualMetadata, err := subject.GetFileMetadata(suite.defaultContext, suite.path)
 
 	logEvent := suite.logInterceptor.GetLogEvent()
@@ -104,7 +106,7 @@ func (suite *StoreSuite) TestGetFileMetadataWithCollectionPatching() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&metadataColl, &collectionColl, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &metadataColl, Collections: &collectionColl, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 	actualMetadata, err := subject.GetFileMetadata(suite.defaultContext, suite.path)
 
 	suite.NoError(err)
@@ -143,7 +145,7 @@ func (suite *StoreSuite) TestGetFileMetadataWithBundleID() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&metadataColl, nil, &bundleColl, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &metadataColl, Bundles: &bundleColl, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	actualMetadata, err := subject.GetFileMetadata(suite.defaultContext, suite.path)
 
@@ -171,7 +173,7 @@ func (suite *StoreSuite) TestGetFileMetadataWithBundleError() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&metadataColl, nil, &bundleColl, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &metadataColl, Bundles: &bundleColl, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	actualMetadata, err := subject.GetFileMetadata(suite.defaultContext, suite.path)
 
@@ -188,7 +190,7 @@ func (suite *StoreSuite) TestGetFileMetadataWebNotFoundError() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collection, nil, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 	_, err := subject.GetFileMetadataWeb(suite.defaultContext, suite.path)
 
 	logEvent := suite.logInterceptor.GetLogEvent()
@@ -202,7 +204,7 @@ func (suite *StoreSuite) TestGetFileMetadataWebOtherError() {
 		FindOneFunc: CollectionFindOneReturnsError(errors.New("find error")),
 	}
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collection, nil, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 	_, err := subject.GetFileMetadataWeb(suite.defaultContext, suite.path)
 
 	suite.EqualError(err, "find error")
@@ -235,7 +237,7 @@ func (suite *StoreSuite) TestGetFileMetadataWebWithCollectionID() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&metadataColl, nil, &collectionColl, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &metadataColl, Bundles: &collectionColl, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	actualMetadata, err := subject.GetFileMetadataWeb(suite.defaultContext, suite.path)
 
@@ -259,7 +261,7 @@ func (suite *StoreSuite) TestGetFileMetadataWebCollectionError() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&metadataColl, &collectionColl, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &metadataColl, Collections: &collectionColl, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 	_, err := subject.GetFileMetadataWeb(suite.defaultContext, suite.path)
 
 	logEvent := suite.logInterceptor.GetLogEvent()
@@ -295,7 +297,7 @@ func (suite *StoreSuite) TestGetFileMetadataWebWithBundleID() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&metadataColl, nil, &bundleColl, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &metadataColl, Bundles: &bundleColl, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	actualMetadata, err := subject.GetFileMetadataWeb(suite.defaultContext, suite.path)
 
@@ -322,7 +324,7 @@ func (suite *StoreSuite) TestGetFileMetadataWebBundleError() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&metadataColl, nil, &bundleColl, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &metadataColl, Bundles: &bundleColl, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	actualMetadata, err := subject.GetFileMetadataWeb(suite.defaultContext, suite.path)
 
@@ -337,6 +339,7 @@ func (suite *StoreSuite) TestGetFilesMetadataNoPatching() {
 	metadata2.Path += "2"
 
 	metadataColl := mock.MongoCollectionMock{
+		CountFunc: CollectionCountReturnsValueAndNil(2),
 		FindFunc: CollectionFindReturnsMetadataOnFilter(
 			[]files.StoredRegisteredMetaData{metadata1, metadata2},
 			bson.M{"collection_id": suite.defaultCollectionID},
@@ -347,13 +350,13 @@ func (suite *StoreSuite) TestGetFilesMetadataNoPatching() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&metadataColl, &collectionColl, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &metadataColl, Collections: &collectionColl, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	expectedMetadata := []files.StoredRegisteredMetaData{metadata1, metadata2}
-	actualMetadata, err := subject.GetFilesMetadata(suite.defaultContext, suite.defaultCollectionID, "")
+	actualMetadata, err := subject.GetFilesMetadata(suite.defaultContext, store.FilesQuery{CollectionID: suite.defaultCollectionID, Limit: 20})
 
 	suite.NoError(err)
-	suite.Exactly(expectedMetadata, actualMetadata)
+	suite.Exactly(expectedMetadata, actualMetadata.Items)
 }
 
 func (suite *StoreSuite) TestGetFilesMetadataWithPatching() {
@@ -368,6 +371,7 @@ func (suite *StoreSuite) TestGetFilesMetadataWithPatching() {
 	collectionBytes, _ := bson.Marshal(collection)
 
 	metadataColl := mock.MongoCollectionMock{
+		CountFunc: CollectionCountReturnsValueAndNil(2),
 		FindFunc: CollectionFindReturnsMetadataOnFilter(
 			[]files.StoredRegisteredMetaData{metadata1, metadata2},
 			bson.M{"collection_id": suite.defaultCollectionID},
@@ -378,7 +382,7 @@ func (suite *StoreSuite) TestGetFilesMetadataWithPatching() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&metadataColl, &collectionColl, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &metadataColl, Collections: &collectionColl, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	expectedMetadata := []files.StoredRegisteredMetaData{metadata1, metadata2}
 	expectedMetadata[0].State = store.StatePublished
@@ -388,7 +392,7 @@ func (suite *StoreSuite) TestGetFilesMetadataWithPatching() {
 	expectedMetadata[1].PublishedAt = collection.PublishedAt
 	expectedMetadata[1].LastModified = collection.LastModified
 
-	actualMetadata, err := subject.GetFilesMetadata(suite.defaultContext, suite.defaultCollectionID, "")
+	actualMetadata, err := subject.GetFilesMetadata(suite.defaultContext, store.FilesQuery{CollectionID: suite.defaultCollectionID, Limit: 20})
 
 	suite.NoError(err)
 	suite.NotEqual(metadata1.State, collection.State)
@@ -397,11 +401,12 @@ func (suite *StoreSuite) TestGetFilesMetadataWithPatching() {
 	suite.NotEqual(metadata2.State, collection.State)
 	suite.NotEqual(metadata2.PublishedAt, collection.PublishedAt)
 	suite.NotEqual(metadata2.LastModified, collection.LastModified)
-	suite.Exactly(expectedMetadata, actualMetadata)
+	suite.Exactly(expectedMetadata, actualMetadata.Items)
 }
 
 func (suite *StoreSuite) TestGetFilesMetadataNoResult() {
 	metadataColl := mock.MongoCollectionMock{
+		CountFunc: CollectionCountReturnsValueAndNil(2),
 		FindFunc: CollectionFindReturnsMetadataOnFilter(
 			[]files.StoredRegisteredMetaData{},
 			bson.M{"collection_id": "INVALID_COLLECTION_ID"},
@@ -412,27 +417,28 @@ func (suite *StoreSuite) TestGetFilesMetadataNoResult() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&metadataColl, &collectionColl, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &metadataColl, Collections: &collectionColl, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	expectedMetadata := make([]files.StoredRegisteredMetaData, 0)
-	actualMetadata, err := subject.GetFilesMetadata(suite.defaultContext, "INVALID_COLLECTION_ID", "")
+	actualMetadata, err := subject.GetFilesMetadata(suite.defaultContext, store.FilesQuery{CollectionID: "INVALID_COLLECTION_ID", Limit: 20})
 
 	suite.NoError(err)
-	suite.Exactly(expectedMetadata, actualMetadata)
+	suite.Exactly(expectedMetadata, actualMetadata.Items)
 }
 
 func (suite *StoreSuite) TestGetFilesMetadataFindError() {
 	metadataColl := mock.MongoCollectionMock{
-		FindFunc: CollectionFindReturnsValueAndError(0, errors.New("find error")),
+		CountFunc: CollectionCountReturnsValueAndNil(2),
+		FindFunc:  CollectionFindReturnsValueAndError(0, errors.New("find error")),
 	}
 	collectionColl := mock.MongoCollectionMock{
 		FindOneFunc: CollectionFindOneReturnsError(nil),
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&metadataColl, &collectionColl, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &metadataColl, Collections: &collectionColl, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
-	actualMetadata, err := subject.GetFilesMetadata(suite.defaultContext, suite.defaultCollectionID, "")
+	actualMetadata, err := subject.GetFilesMetadata(suite.defaultContext, store.FilesQuery{CollectionID: suite.defaultCollectionID, Limit: 20})
 
 	suite.EqualError(err, "find error")
 	suite.Nil(actualMetadata)
@@ -441,6 +447,7 @@ func (suite *StoreSuite) TestGetFilesMetadataFindError() {
 func (suite *StoreSuite) TestGetFilesMetadataCollectionError() {
 	metadata := suite.generateCollectionMetadata(suite.defaultCollectionID)
 	metadataColl := mock.MongoCollectionMock{
+		CountFunc: CollectionCountReturnsValueAndNil(2),
 		FindFunc: CollectionFindReturnsMetadataOnFilter(
 			[]files.StoredRegisteredMetaData{metadata},
 			bson.M{"collection_id": suite.defaultCollectionID},
@@ -451,12 +458,12 @@ func (suite *StoreSuite) TestGetFilesMetadataCollectionError() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&metadataColl, &collectionColl, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &metadataColl, Collections: &collectionColl, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
-	actualMetadata, err := subject.GetFilesMetadata(suite.defaultContext, suite.defaultCollectionID, "")
+	actualMetadata, err := subject.GetFilesMetadata(suite.defaultContext, store.FilesQuery{CollectionID: suite.defaultCollectionID, Limit: 20})
 
 	suite.NoError(err)
-	suite.Exactly([]files.StoredRegisteredMetaData{metadata}, actualMetadata)
+	suite.Exactly([]files.StoredRegisteredMetaData{metadata}, actualMetadata.Items)
 }
 
 // New tests for GetFilesMetadata with Bundle ID
@@ -469,6 +476,7 @@ func (suite *StoreSuite) TestGetFilesMetadataWithBundleID() {
 	metadata2.State = store.StateUploaded
 
 	metadataColl := mock.MongoCollectionMock{
+		CountFunc: CollectionCountReturnsValueAndNil(2),
 		FindFunc: CollectionFindReturnsMetadataOnFilter(
 			[]files.StoredRegisteredMetaData{metadata1, metadata2},
 			bson.M{"bundle_id": suite.defaultBundleID},
@@ -479,13 +487,13 @@ func (suite *StoreSuite) TestGetFilesMetadataWithBundleID() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&metadataColl, nil, &bundleColl, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &metadataColl, Bundles: &bundleColl, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	expectedMetadata := []files.StoredRegisteredMetaData{metadata1, metadata2}
-	actualMetadata, err := subject.GetFilesMetadata(suite.defaultContext, "", suite.defaultBundleID)
+	actualMetadata, err := subject.GetFilesMetadata(suite.defaultContext, store.FilesQuery{BundleID: suite.defaultBundleID, Limit: 20})
 
 	suite.NoError(err)
-	suite.Exactly(expectedMetadata, actualMetadata)
+	suite.Exactly(expectedMetadata, actualMetadata.Items)
 }
 
 func (suite *StoreSuite) TestGetFilesMetadataWithBundlePatching() {
@@ -500,6 +508,7 @@ func (suite *StoreSuite) TestGetFilesMetadataWithBundlePatching() {
 	bundleBytes, _ := bson.Marshal(bundle)
 
 	metadataColl := mock.MongoCollectionMock{
+		CountFunc: CollectionCountReturnsValueAndNil(2),
 		FindFunc: CollectionFindReturnsMetadataOnFilter(
 			[]files.StoredRegisteredMetaData{metadata1, metadata2},
 			bson.M{"bundle_id": suite.defaultBundleID},
@@ -510,23 +519,24 @@ func (suite *StoreSuite) TestGetFilesMetadataWithBundlePatching() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&metadataColl, nil, &bundleColl, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &metadataColl, Bundles: &bundleColl, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	expectedMetadata := []files.StoredRegisteredMetaData{metadata1, metadata2}
 	expectedMetadata[0].State = store.StatePublished
 	expectedMetadata[1].State = store.StatePublished
 
-	actualMetadata, err := subject.GetFilesMetadata(suite.defaultContext, "", suite.defaultBundleID)
+	actualMetadata, err := subject.GetFilesMetadata(suite.defaultContext, store.FilesQuery{BundleID: suite.defaultBundleID, Limit: 20})
 
 	suite.NoError(err)
 	suite.NotEqual(metadata1.State, bundle.State)
 	suite.NotEqual(metadata2.State, bundle.State)
-	suite.Exactly(expectedMetadata, actualMetadata)
+	suite.Exactly(expectedMetadata, actualMetadata.Items)
 }
 
 func (suite *StoreSuite) TestGetFilesMetadataWithBundleError() {
 	metadata := suite.generateBundleMetadata(suite.defaultBundleID)
 	metadataColl := mock.MongoCollectionMock{
+		CountFunc: CollectionCountReturnsValueAndNil(2),
 		FindFunc: CollectionFindReturnsMetadataOnFilter(
 			[]files.StoredRegisteredMetaData{metadata},
 			bson.M{"bundle_id": suite.defaultBundleID},
@@ -537,26 +547,27 @@ func (suite *StoreSuite) TestGetFilesMetadataWithBundleError() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&metadataColl, nil, &bundleColl, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &metadataColl, Bundles: &bundleColl, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
-	actualMetadata, err := subject.GetFilesMetadata(suite.defaultContext, "", suite.defaultBundleID)
+	actualMetadata, err := subject.GetFilesMetadata(suite.defaultContext, store.FilesQuery{BundleID: suite.defaultBundleID, Limit: 20})
 
 	suite.NoError(err)
-	suite.Exactly([]files.StoredRegisteredMetaData{metadata}, actualMetadata)
+	suite.Exactly([]files.StoredRegisteredMetaData{metadata}, actualMetadata.Items)
 }
 
 func (suite *StoreSuite) TestGetFilesMetadataBundleFindError() {
 	metadataColl := mock.MongoCollectionMock{
-		FindFunc: CollectionFindReturnsValueAndError(0, errors.New("find error")),
+		CountFunc: CollectionCountReturnsValueAndNil(2),
+		FindFunc:  CollectionFindReturnsValueAndError(0, errors.New("find error")),
 	}
 	bundleColl := mock.MongoCollectionMock{
 		FindOneFunc: CollectionFindOneReturnsError(nil),
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&metadataColl, nil, &bundleColl, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &metadataColl, Bundles: &bundleColl, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
-	actualMetadata, err := subject.GetFilesMetadata(suite.defaultContext, "", suite.defaultBundleID)
+	actualMetadata, err := subject.GetFilesMetadata(suite.defaultContext, store.FilesQuery{BundleID: suite.defaultBundleID, Limit: 20})
 
 	suite.EqualError(err, "find error")
 	suite.Nil(actualMetadata)
@@ -564,6 +575,7 @@ func (suite *StoreSuite) TestGetFilesMetadataBundleFindError() {
 
 func (suite *StoreSuite) TestGetFilesMetadataBundleNoResult() {
 	metadataColl := mock.MongoCollectionMock{
+		CountFunc: CollectionCountReturnsValueAndNil(2),
 		FindFunc: CollectionFindReturnsMetadataOnFilter(
 			[]files.StoredRegisteredMetaData{},
 			bson.M{"bundle_id": "INVALID_BUNDLE_ID"},
@@ -574,17 +586,146 @@ func (suite *StoreSuite) TestGetFilesMetadataBundleNoResult() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&metadataColl, nil, &bundleColl, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &metadataColl, Bundles: &bundleColl, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	expectedMetadata := make([]files.StoredRegisteredMetaData, 0)
-	actualMetadata, err := subject.GetFilesMetadata(suite.defaultContext, "", "INVALID_BUNDLE_ID")
+	actualMetadata, err := subject.GetFilesMetadata(suite.defaultContext, store.FilesQuery{BundleID: "INVALID_BUNDLE_ID", Limit: 20})
 
 	suite.NoError(err)
-	suite.Exactly(expectedMetadata, actualMetadata)
+	suite.Exactly(expectedMetadata, actualMetadata.Items)
+}
+
+func (suite *StoreSuite) TestGetFilesMetadataPagesThroughFilesWithCursor() {
+	repo := store.NewMemoryRepository()
+	cfg, _ := config.Get()
+	subject := store.NewStore(repo, suite.defaultClock, nil, cfg)
+	for _, path := range []string{"c.csv", "a.csv", "b.csv"} {
+		suite.NoError(repo.InsertMetadata(suite.defaultContext, files.StoredRegisteredMetaData{Path: path, CollectionID: &testCollectionID}))
+	}
+
+	page, err := subject.GetFilesMetadata(suite.defaultContext, store.FilesQuery{CollectionID: testCollectionID, Limit: 2})
+
+	suite.NoError(err)
+	suite.Equal(2, page.Count)
+	suite.Equal(3, page.TotalCount)
+	suite.Equal("a.csv", page.Items[0].Path)
+	suite.Equal("b.csv", page.Items[1].Path)
+	suite.NotEmpty(page.NextCursor)
+
+	page, err = subject.GetFilesMetadata(suite.defaultContext, store.FilesQuery{CollectionID: testCollectionID, Limit: 2, Cursor: page.NextCursor})
+
+	suite.NoError(err)
+	suite.Equal(1, page.Count)
+	suite.Equal(3, page.TotalCount)
+	suite.Equal("c.csv", page.Items[0].Path)
+	suite.Empty(page.NextCursor)
+
+	_, err = subject.GetFilesMetadata(suite.defaultContext, store.FilesQuery{CollectionID: testCollectionID, Limit: 2, Cursor: "not base64!"})
+
+	suite.ErrorIs(err, store.ErrInvalidCursor)
+}
+
+func (suite *StoreSuite) TestGetFilesMetadataReturnsEveryFileWithoutLimit() {
+	repo := store.NewMemoryRepository()
+	cfg, _ := config.Get()
+	subject := store.NewStore(repo, suite.defaultClock, nil, cfg)
+	for i := 0; i < 25; i++ {
+		path := fmt.Sprintf("data/%02d.csv", i)
+		suite.NoError(repo.InsertMetadata(suite.defaultContext, files.StoredRegisteredMetaData{Path: path, CollectionID: &testCollectionID}))
+	}
+
+	page, err := subject.GetFilesMetadata(suite.defaultContext, store.FilesQuery{CollectionID: testCollectionID})
+
+	suite.NoError(err)
+	suite.Equal(25, page.Count)
+	suite.Equal(25, page.Limit)
+	suite.Equal(0, page.Offset)
+	suite.Equal(25, page.TotalCount)
+	suite.Len(page.Items, 25)
+	suite.Empty(page.NextCursor)
+}
+
+func (suite *StoreSuite) TestGetFilesMetadataPagesThroughFilesWithOffset() {
+	repo := store.NewMemoryRepository()
+	cfg, _ := config.Get()
+	subject := store.NewStore(repo, suite.defaultClock, nil, cfg)
+	for _, path := range []string{"c.csv", "a.csv", "b.csv", "d.csv"} {
+		suite.NoError(repo.InsertMetadata(suite.defaultContext, files.StoredRegisteredMetaData{Path: path, CollectionID: &testCollectionID}))
+	}
+
+	page, err := subject.GetFilesMetadata(suite.defaultContext, store.FilesQuery{CollectionID: testCollectionID, Offset: 1, Limit: 2})
+
+	suite.NoError(err)
+	suite.Equal(2, page.Count)
+	suite.Equal(2, page.Limit)
+	suite.Equal(1, page.Offset)
+	suite.Equal(4, page.TotalCount)
+	suite.Equal("b.csv", page.Items[0].Path)
+	suite.Equal("c.csv", page.Items[1].Path)
+
+	page, err = subject.GetFilesMetadata(suite.defaultContext, store.FilesQuery{CollectionID: testCollectionID, Offset: 3})
+
+	suite.NoError(err)
+	suite.Equal(1, page.Count)
+	suite.Equal(1, page.Limit)
+	suite.Equal(3, page.Offset)
+	suite.Equal("d.csv", page.Items[0].Path)
+
+	_, err = subject.GetFilesMetadata(suite.defaultContext, store.FilesQuery{CollectionID: testCollectionID, Offset: 1, Limit: 2, Cursor: "abc"})
+
+	suite.ErrorIs(err, store.ErrInvalidOffset)
+}
+
+func (suite *StoreSuite) TestGetFilesMetadataFilters() {
+	repo := store.NewMemoryRepository()
+	cfg, _ := config.Get()
+	subject := store.NewStore(repo, suite.defaultClock, nil, cfg)
+	created := suite.defaultClock.GetCurrentTime()
+	publishable := true
+	for _, m := range []files.StoredRegisteredMetaData{
+		{Path: "data/a.csv", Type: "text/csv", IsPublishable: true, State: store.StateUploaded, CreatedAt: created, LastModified: created},
+		{Path: "data/b.json", Type: "application/json", IsPublishable: true, State: store.StateUploaded, CreatedAt: created, LastModified: created},
+		{Path: "data/c.csv", Type: "text/csv", IsPublishable: false, State: store.StateUploaded, CreatedAt: created, LastModified: created},
+		{Path: "images/d.csv", Type: "text/csv", IsPublishable: true, State: store.StateCreated, CreatedAt: created.Add(time.Hour), LastModified: created.Add(time.Hour)},
+	} {
+		m.BundleID = &testBundleID
+		suite.NoError(repo.InsertMetadata(suite.defaultContext, m))
+	}
+	later := created.Add(time.Minute)
+
+	page, err := subject.GetFilesMetadata(suite.defaultContext, store.FilesQuery{
+		BundleID: testBundleID, Type: "text/csv", IsPublishable: &publishable, PathPrefix: "data/", State: store.StateUploaded, CreatedBefore: &later, Limit: 20,
+	})
+	suite.NoError(err)
+	suite.Equal([]string{"data/a.csv"}, paths(page.Items))
+	suite.Equal(1, page.TotalCount)
+
+	page, err = subject.GetFilesMetadata(suite.defaultContext, store.FilesQuery{BundleID: testBundleID, ModifiedAfter: &later, Limit: 20})
+	suite.NoError(err)
+	suite.Equal([]string{"images/d.csv"}, paths(page.Items))
+}
+
+func (suite *StoreSuite) TestGetFilesMetadataStateFilterInPublishedCollection() {
+	repo := store.NewMemoryRepository()
+	cfg, _ := config.Get()
+	subject := store.NewStore(repo, suite.defaultClock, nil, cfg)
+	suite.NoError(repo.InsertMetadata(suite.defaultContext, files.StoredRegisteredMetaData{Path: "a.csv", CollectionID: &testCollectionID, State: store.StateUploaded}))
+	suite.NoError(repo.InsertMetadata(suite.defaultContext, files.StoredRegisteredMetaData{Path: "b.csv", CollectionID: &testCollectionID, State: store.StateMoved}))
+	suite.NoError(repo.InsertCollection(suite.defaultContext, suite.generatePublishedCollectionInfo(testCollectionID)))
+
+	page, err := subject.GetFilesMetadata(suite.defaultContext, store.FilesQuery{CollectionID: testCollectionID, State: store.StatePublished, Limit: 20})
+	suite.NoError(err)
+	suite.Equal([]string{"a.csv"}, paths(page.Items))
+	suite.Equal(store.StatePublished, page.Items[0].State)
+
+	page, err = subject.GetFilesMetadata(suite.defaultContext, store.FilesQuery{CollectionID: testCollectionID, State: store.StateUploaded, Limit: 20})
+	suite.NoError(err)
+	suite.Empty(page.Items)
+	suite.Equal(0, page.TotalCount)
 }
 
 func (suite *StoreSuite) TestPatchMetadataNilMetadata() {
-	subject := store.NewStore(nil, nil, nil, nil, nil, suite.defaultClock, nil, nil)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, nil)
 
 	var metadata *files.StoredRegisteredMetaData
 	collection := &files.StoredCollection{}
@@ -594,7 +735,7 @@ func (suite *StoreSuite) TestPatchMetadataNilMetadata() {
 }
 
 func (suite *StoreSuite) TestPatchMetadataNilCollection() {
-	subject := store.NewStore(nil, nil, nil, nil, nil, suite.defaultClock, nil, nil)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, nil)
 
 	metadata := files.StoredRegisteredMetaData{
 		Path:         "path1",
@@ -608,7 +749,7 @@ func (suite *StoreSuite) TestPatchMetadataNilCollection() {
 }
 
 func (suite *StoreSuite) TestPatchMetadataNilCollectionID() {
-	subject := store.NewStore(nil, nil, nil, nil, nil, suite.defaultClock, nil, nil)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, nil)
 
 	metadata := files.StoredRegisteredMetaData{
 		Path:  "path1",
@@ -626,7 +767,7 @@ func (suite *StoreSuite) TestPatchMetadataNilCollectionID() {
 }
 
 func (suite *StoreSuite) TestPatchMetadataCollectionIDMismatch() {
-	subject := store.NewStore(nil, nil, nil, nil, nil, suite.defaultClock, nil, nil)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, nil)
 
 	metadata := files.StoredRegisteredMetaData{
 		Path:         "path1",
@@ -645,7 +786,7 @@ func (suite *StoreSuite) TestPatchMetadataCollectionIDMismatch() {
 }
 
 func (suite *StoreSuite) TestPatchMetadataBadState() {
-	subject := store.NewStore(nil, nil, nil, nil, nil, suite.defaultClock, nil, nil)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, nil)
 
 	metadata := files.StoredRegisteredMetaData{
 		Path:         "path1",
@@ -664,7 +805,7 @@ func (suite *StoreSuite) TestPatchMetadataBadState() {
 }
 
 func (suite *StoreSuite) TestPatchMetadataBadCollectionState() {
-	subject := store.NewStore(nil, nil, nil, nil, nil, suite.defaultClock, nil, nil)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, nil)
 
 	metadata := files.StoredRegisteredMetaData{
 		Path:         "path1",
@@ -682,7 +823,7 @@ func (suite *StoreSuite) TestPatchMetadataBadCollectionState() {
 }
 
 func (suite *StoreSuite) TestPatchMetadataSuccess() {
-	subject := store.NewStore(nil, nil, nil, nil, nil, suite.defaultClock, nil, nil)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, nil)
 
 	publishedAt := suite.generateTestTime(1)
 	lastModified := suite.generateTestTime(2)
@@ -711,7 +852,7 @@ func (suite *StoreSuite) TestPatchMetadataSuccess() {
 }
 
 func (suite *StoreSuite) TestPatchBundleMetadataNilMetadata() {
-	subject := store.NewStore(nil, nil, nil, nil, nil, suite.defaultClock, nil, nil)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, nil)
 
 	var metadata *files.StoredRegisteredMetaData
 	bundle := &files.StoredBundle{}
@@ -721,7 +862,7 @@ func (suite *StoreSuite) TestPatchBundleMetadataNilMetadata() {
 }
 
 func (suite *StoreSuite) TestPatchBundleMetadataNilBundle() {
-	subject := store.NewStore(nil, nil, nil, nil, nil, suite.defaultClock, nil, nil)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, nil)
 
 	bundleID := testBundleID
 	metadata := files.StoredRegisteredMetaData{
@@ -736,7 +877,7 @@ func (suite *StoreSuite) TestPatchBundleMetadataNilBundle() {
 }
 
 func (suite *StoreSuite) TestPatchBundleMetadataNilBundleID() {
-	subject := store.NewStore(nil, nil, nil, nil, nil, suite.defaultClock, nil, nil)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, nil)
 
 	metadata := files.StoredRegisteredMetaData{
 		Path:  "path1",
@@ -754,7 +895,7 @@ func (suite *StoreSuite) TestPatchBundleMetadataNilBundleID() {
 }
 
 func (suite *StoreSuite) TestPatchBundleMetadataBundleIDMismatch() {
-	subject := store.NewStore(nil, nil, nil, nil, nil, suite.defaultClock, nil, nil)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, nil)
 
 	bundleID := testBundleID
 	metadata := files.StoredRegisteredMetaData{
@@ -774,7 +915,7 @@ func (suite *StoreSuite) TestPatchBundleMetadataBundleIDMismatch() {
 }
 
 func (suite *StoreSuite) TestPatchBundleMetadataBadState() {
-	subject := store.NewStore(nil, nil, nil, nil, nil, suite.defaultClock, nil, nil)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, nil)
 
 	bundleID := testBundleID
 	metadata := files.StoredRegisteredMetaData{
@@ -794,7 +935,7 @@ func (suite *StoreSuite) TestPatchBundleMetadataBadState() {
 }
 
 func (suite *StoreSuite) TestPatchBundleMetadataBadBundleState() {
-	subject := store.NewStore(nil, nil, nil, nil, nil, suite.defaultClock, nil, nil)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, nil)
 
 	bundleID := testBundleID
 	metadata := files.StoredRegisteredMetaData{
@@ -813,7 +954,7 @@ func (suite *StoreSuite) TestPatchBundleMetadataBadBundleState() {
 }
 
 func (suite *StoreSuite) TestPatchBundleMetadataSuccess() {
-	subject := store.NewStore(nil, nil, nil, nil, nil, suite.defaultClock, nil, nil)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, nil)
 
 	bundleID := testBundleID
 	lastModified := suite.generateTestTime(2)
@@ -852,15 +993,39 @@ func (suite *StoreSuite) TestUpdateContentItemSuccess() {
 	metadataContentUpdated := metadata
 	metadataContentUpdated.ContentItem.Version = "2"
 
+	metadata.State = store.StateUploaded
+	metadataBytes, _ := bson.Marshal(metadata)
+
 	metadataColl := mock.MongoCollectionMock{
-		UpdateOneFunc: CollectionUpdateReturnsNilAndNil(),
+		FindOneFunc: CollectionFindOneSetsResultAndReturnsNil(metadataBytes),
+		UpdateFunc:  CollectionUpdateReturnsNilAndNil(),
 	}
 
-	subject := store.NewStore(&metadataColl, nil, nil, nil, nil, suite.defaultClock, nil, nil)
+	cfg, _ := config.Get()
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &metadataColl, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	err := subject.UpdateContentItem(suite.defaultContext, metadata.Path, metadataContentUpdated.ContentItem)
 
 	suite.NoError(err)
+	suite.Require().Len(suite.defaultOutboxCollection.InsertCalls(), 1)
+	lifecycle := suite.defaultOutboxCollection.InsertCalls()[0].Document.(files.OutboxMessage).FileLifecycle
+	suite.Equal(files.LifecycleContentItemUpdated, lifecycle.Change)
+	suite.Equal(store.StateUploaded, lifecycle.FromState)
+	suite.Equal(store.StateUploaded, lifecycle.ToState)
+}
+
+func (suite *StoreSuite) TestUpdateContentItemFileNotRegistered() {
+	metadataColl := mock.MongoCollectionMock{
+		FindOneFunc: CollectionFindOneReturnsError(mongodriver.ErrNoDocumentFound),
+	}
+
+	cfg, _ := config.Get()
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &metadataColl, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
+
+	err := subject.UpdateContentItem(suite.defaultContext, suite.path, &files.StoredContentItem{DatasetID: "dataset1"})
+
+	suite.ErrorIs(err, store.ErrFileNotRegistered)
+	suite.Empty(suite.defaultOutboxCollection.InsertCalls())
 }
 
 func (suite *StoreSuite) TestUpdateContentItemMetadataFailure() {
@@ -870,13 +1035,18 @@ func (suite *StoreSuite) TestUpdateContentItemMetadataFailure() {
 		Version:   "1",
 	}
 
+	metadataBytes, _ := bson.Marshal(files.StoredRegisteredMetaData{Path: suite.path, State: store.StateUploaded})
+
 	collection := mock.MongoCollectionMock{
-		UpdateOneFunc: CollectionUpdateReturnsNilAndError(errors.New("mongo write error")),
+		FindOneFunc: CollectionFindOneSetsResultAndReturnsNil(metadataBytes),
+		UpdateFunc:  CollectionUpdateReturnsNilAndError(errors.New("mongo write error")),
 	}
 
-	subject := store.NewStore(&collection, nil, nil, nil, nil, suite.defaultClock, nil, nil)
+	cfg, _ := config.Get()
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	err := subject.UpdateContentItem(suite.defaultContext, suite.path, contentItem)
 
 	suite.ErrorContains(err, "mongo write error")
+	suite.Len(suite.defaultOutboxCollection.DeleteCalls(), 1)
 }
@@ -0,0 +1,917 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/dp-files-api/mongo"
+	mongodriver "github.com/ONSdigital/dp-mongodb/v3/mongodb"
+	lock "github.com/square/mongo-lock"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongoRaw "go.mongodb.org/mongo-driver/mongo"
+)
+
+// MongoRepository is the Repository backed by MongoDB collections
+type MongoRepository struct {
+	metadataCollection        mongo.MongoCollection
+	collectionsCollection     mongo.MongoCollection
+	bundlesCollection         mongo.MongoCollection
+	fileEventsCollection      mongo.MongoCollection
+	outboxCollection          mongo.MongoCollection
+	publishJobsCollection     mongo.MongoCollection
+	fileVersionsCollection    mongo.MongoCollection
+	fileChangesCollection     mongo.MongoCollection
+	locksCollection           mongo.MongoCollection
+	trashCollection           mongo.MongoCollection
+	reconciliationsCollection mongo.MongoCollection
+	idempotencyKeysCollection mongo.MongoCollection
+	fileEventChainCollection  mongo.MongoCollection
+}
+
+// MongoCollections are the collections a MongoRepository keeps its documents in. Collections that are never used, as
+// in tests of one part of the Store, can be left nil.
+type MongoCollections struct {
+	Metadata        mongo.MongoCollection
+	Collections     mongo.MongoCollection
+	Bundles         mongo.MongoCollection
+	FileEvents      mongo.MongoCollection
+	Outbox          mongo.MongoCollection
+	PublishJobs     mongo.MongoCollection
+	FileVersions    mongo.MongoCollection
+	FileChanges     mongo.MongoCollection
+	Locks           mongo.MongoCollection
+	Trash           mongo.MongoCollection
+	Reconciliations mongo.MongoCollection
+	IdempotencyKeys mongo.MongoCollection
+	FileEventChain  mongo.MongoCollection
+}
+
+func NewMongoRepository(collections MongoCollections) *MongoRepository {
+	return &MongoRepository{
+		metadataCollection:        collections.Metadata,
+		collectionsCollection:     collections.Collections,
+		bundlesCollection:         collections.Bundles,
+		fileEventsCollection:      collections.FileEvents,
+		outboxCollection:          collections.Outbox,
+		publishJobsCollection:     collections.PublishJobs,
+		fileVersionsCollection:    collections.FileVersions,
+		fileChangesCollection:     collections.FileChanges,
+		locksCollection:           collections.Locks,
+		trashCollection:           collections.Trash,
+		reconciliationsCollection: collections.Reconciliations,
+		idempotencyKeysCollection: collections.IdempotencyKeys,
+		fileEventChainCollection:  collections.FileEventChain,
+	}
+}
+
+func (r *MongoRepository) GetMetadata(ctx context.Context, path string) (files.StoredRegisteredMetaData, error) {
+	metadata := files.StoredRegisteredMetaData{}
+	err := r.metadataCollection.FindOne(ctx, bson.M{fieldPath: path}, &metadata)
+	return metadata, err
+}
+
+func (r *MongoRepository) FindOneMetadata(ctx context.Context, filter MetadataFilter) (files.StoredRegisteredMetaData, error) {
+	metadata := files.StoredRegisteredMetaData{}
+	err := r.metadataCollection.FindOne(ctx, metadataQuery(filter), &metadata)
+	return metadata, err
+}
+
+func (r *MongoRepository) FindMetadata(ctx context.Context, filter MetadataFilter) ([]files.StoredRegisteredMetaData, error) {
+	metadata := make([]files.StoredRegisteredMetaData, 0)
+	if _, err := r.metadataCollection.Find(ctx, metadataQuery(filter), &metadata); err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
+
+func (r *MongoRepository) FindMetadataPage(ctx context.Context, filter MetadataFilter, offset, limit int) ([]files.StoredRegisteredMetaData, error) {
+	metadata := make([]files.StoredRegisteredMetaData, 0)
+	_, err := r.metadataCollection.Find(ctx, metadataQuery(filter), &metadata,
+		mongodriver.Sort(bson.M{fieldPath: 1}),
+		mongodriver.Offset(offset),
+		mongodriver.Limit(limit),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
+
+func (r *MongoRepository) ListDirectory(ctx context.Context, prefix, after string, limit int) ([]files.DirectoryEntry, error) {
+	rest := bson.M{"$substrCP": bson.A{"$" + fieldPath, utf8.RuneCountInString(prefix), math.MaxInt32}}
+	lastChar := bson.M{"$substrCP": bson.A{"$_id", bson.M{"$subtract": bson.A{bson.M{"$strLenCP": "$_id"}, 1}}, 1}}
+	isDirectory := bson.M{"$eq": bson.A{lastChar, "/"}}
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{fieldPath: directoryPathRange(prefix, after)}},
+		bson.M{"$project": bson.M{fieldSizeInBytes: 1, "rest": rest}},
+		bson.M{"$project": bson.M{fieldSizeInBytes: 1, "rest": 1, "slash": bson.M{"$indexOfCP": bson.A{"$rest", "/"}}}},
+		bson.M{"$group": bson.M{
+			// the key of a directory keeps its slash, so that it sorts apart from a file of the same name
+			"_id": bson.M{"$cond": bson.A{
+				bson.M{"$eq": bson.A{"$slash", -1}},
+				"$rest",
+				bson.M{"$substrCP": bson.A{"$rest", 0, bson.M{"$add": bson.A{"$slash", 1}}}},
+			}},
+			"file_count":     bson.M{"$sum": 1},
+			fieldSizeInBytes: bson.M{"$sum": "$" + fieldSizeInBytes},
+		}},
+		bson.M{"$sort": bson.M{"_id": 1}},
+	}
+	if limit > 0 {
+		pipeline = append(pipeline, bson.M{"$limit": limit})
+	}
+	pipeline = append(pipeline, bson.M{"$project": bson.M{
+		"_id": 0,
+		"name": bson.M{"$cond": bson.A{
+			isDirectory,
+			bson.M{"$substrCP": bson.A{"$_id", 0, bson.M{"$subtract": bson.A{bson.M{"$strLenCP": "$_id"}, 1}}}},
+			"$_id",
+		}},
+		"is_directory":   isDirectory,
+		"file_count":     1,
+		fieldSizeInBytes: 1,
+	}})
+
+	entries := make([]files.DirectoryEntry, 0)
+	if err := r.metadataCollection.Aggregate(ctx, pipeline, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (r *MongoRepository) SumDirectory(ctx context.Context, prefix string) (int, uint64, error) {
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{fieldPath: directoryPathRange(prefix, "")}},
+		bson.M{"$group": bson.M{
+			"_id":            nil,
+			"file_count":     bson.M{"$sum": 1},
+			fieldSizeInBytes: bson.M{"$sum": "$" + fieldSizeInBytes},
+		}},
+	}
+
+	totals := make([]files.DirectoryEntry, 0)
+	if err := r.metadataCollection.Aggregate(ctx, pipeline, &totals); err != nil {
+		return 0, 0, err
+	}
+	if len(totals) == 0 {
+		return 0, 0, nil
+	}
+	return totals[0].FileCount, totals[0].SizeInBytes, nil
+}
+
+func (r *MongoRepository) CountMetadata(ctx context.Context, filter MetadataFilter) (int, error) {
+	return r.metadataCollection.Count(ctx, metadataQuery(filter))
+}
+
+func (r *MongoRepository) MetadataCursor(ctx context.Context, filter MetadataFilter, offset int) (Cursor[files.StoredRegisteredMetaData], error) {
+	cursor, err := r.metadataCollection.FindCursor(ctx, metadataQuery(filter),
+		mongodriver.Sort(bson.D{{Key: fieldPath, Value: 1}}),
+		mongodriver.Offset(offset),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return mongoCursor[files.StoredRegisteredMetaData]{cursor}, nil
+}
+
+func (r *MongoRepository) InsertMetadata(ctx context.Context, metadata files.StoredRegisteredMetaData) error {
+	_, err := r.metadataCollection.Insert(ctx, metadata)
+	return err
+}
+
+func (r *MongoRepository) InsertManyMetadata(ctx context.Context, metadata []files.StoredRegisteredMetaData) (int, error) {
+	documents := make([]interface{}, 0, len(metadata))
+	for _, m := range metadata {
+		documents = append(documents, m)
+	}
+
+	_, err := r.metadataCollection.InsertMany(ctx, documents)
+	if err == nil {
+		return len(metadata), nil
+	}
+
+	// an ordered insert stops at the first failing document, so those before it were inserted
+	var bulkErr mongoRaw.BulkWriteException
+	if errors.As(err, &bulkErr) && len(bulkErr.WriteErrors) > 0 {
+		return bulkErr.WriteErrors[0].Index, err
+	}
+	return 0, err
+}
+
+func (r *MongoRepository) UpdateMetadata(ctx context.Context, path string, update Update) error {
+	_, err := r.metadataCollection.Update(ctx, bson.M{fieldPath: path}, metadataUpdateDocument(update))
+	return err
+}
+
+func (r *MongoRepository) UpdateMetadataIfUnchanged(ctx context.Context, path string, revision int64, createdAt time.Time, update Update) (bool, error) {
+	result, err := r.metadataCollection.Update(ctx, unchangedMetadataQuery(path, revision, createdAt), metadataUpdateDocument(update))
+	return result != nil && result.MatchedCount > 0, err
+}
+
+func (r *MongoRepository) DeleteMetadata(ctx context.Context, path string) (bool, error) {
+	result, err := r.metadataCollection.Delete(ctx, bson.M{fieldPath: path})
+	return deleted(result), err
+}
+
+func (r *MongoRepository) DeleteMetadataIfUnchanged(ctx context.Context, path, state string, revision int64, createdAt time.Time) (bool, error) {
+	filter := unchangedMetadataQuery(path, revision, createdAt)
+	filter[fieldState] = state
+
+	result, err := r.metadataCollection.Delete(ctx, filter)
+	return deleted(result), err
+}
+
+// unchangedMetadataQuery matches the metadata at path while it is still at revision and was created at createdAt
+func unchangedMetadataQuery(path string, revision int64, createdAt time.Time) bson.M {
+	filter := bson.M{fieldPath: path, fieldRevision: revision}
+	if revision == 0 {
+		// metadata that has never been updated may have no revision
+		filter[fieldRevision] = bson.M{"$in": bson.A{0, nil}}
+	}
+	if !createdAt.IsZero() {
+		filter[fieldCreatedAt] = createdAt
+	}
+	return filter
+}
+
+func (r *MongoRepository) GetCollection(ctx context.Context, id string) (files.StoredCollection, error) {
+	collection := files.StoredCollection{}
+	err := r.collectionsCollection.FindOne(ctx, bson.M{fieldID: id}, &collection)
+	return collection, err
+}
+
+func (r *MongoRepository) InsertCollection(ctx context.Context, collection files.StoredCollection) error {
+	_, err := r.collectionsCollection.Insert(ctx, collection)
+	return err
+}
+
+func (r *MongoRepository) UpsertCollection(ctx context.Context, id string, set []Field) error {
+	_, err := r.collectionsCollection.Upsert(ctx, bson.M{fieldID: id}, updateDocument(Update{Set: set}))
+	return err
+}
+
+func (r *MongoRepository) DeleteCollection(ctx context.Context, id string) (bool, error) {
+	result, err := r.collectionsCollection.Delete(ctx, bson.M{fieldID: id})
+	return deleted(result), err
+}
+
+func (r *MongoRepository) FindScheduledCollections(ctx context.Context, due time.Time) ([]files.StoredCollection, error) {
+	collections := make([]files.StoredCollection, 0)
+	if _, err := r.collectionsCollection.Find(ctx, scheduledQuery(due), &collections); err != nil {
+		return nil, err
+	}
+	return collections, nil
+}
+
+func (r *MongoRepository) GetBundle(ctx context.Context, id string) (files.StoredBundle, error) {
+	bundle := files.StoredBundle{}
+	err := r.bundlesCollection.FindOne(ctx, bson.M{fieldID: id}, &bundle)
+	return bundle, err
+}
+
+func (r *MongoRepository) InsertBundle(ctx context.Context, bundle files.StoredBundle) error {
+	_, err := r.bundlesCollection.Insert(ctx, bundle)
+	return err
+}
+
+func (r *MongoRepository) UpsertBundle(ctx context.Context, id string, set []Field) error {
+	_, err := r.bundlesCollection.Upsert(ctx, bson.M{fieldID: id}, updateDocument(Update{Set: set}))
+	return err
+}
+
+func (r *MongoRepository) DeleteBundle(ctx context.Context, id string) (bool, error) {
+	result, err := r.bundlesCollection.Delete(ctx, bson.M{fieldID: id})
+	return deleted(result), err
+}
+
+func (r *MongoRepository) FindScheduledBundles(ctx context.Context, due time.Time) ([]files.StoredBundle, error) {
+	bundles := make([]files.StoredBundle, 0)
+	if _, err := r.bundlesCollection.Find(ctx, scheduledQuery(due), &bundles); err != nil {
+		return nil, err
+	}
+	return bundles, nil
+}
+
+func scheduledQuery(due time.Time) bson.M {
+	return bson.M{fieldState: StateScheduled, fieldPublishAt: bson.M{"$lte": due}}
+}
+
+func (r *MongoRepository) InsertFileEvent(ctx context.Context, event *files.FileEvent) error {
+	_, err := r.fileEventsCollection.Insert(ctx, event)
+	return err
+}
+
+func (r *MongoRepository) InsertFileEvents(ctx context.Context, events []*files.FileEvent) (int, error) {
+	documents := make([]interface{}, 0, len(events))
+	for _, e := range events {
+		documents = append(documents, e)
+	}
+
+	_, err := r.fileEventsCollection.InsertMany(ctx, documents)
+	if err == nil {
+		return len(events), nil
+	}
+
+	// an ordered insert stops at the first failing document, so those before it were inserted
+	var bulkErr mongoRaw.BulkWriteException
+	if errors.As(err, &bulkErr) && len(bulkErr.WriteErrors) > 0 {
+		return bulkErr.WriteErrors[0].Index, err
+	}
+	return 0, err
+}
+
+func (r *MongoRepository) GetFileEventChainHead(ctx context.Context) (FileEventChainHead, error) {
+	head := FileEventChainHead{}
+	err := r.fileEventChainCollection.FindOne(ctx, bson.M{fieldMongoID: fileEventChainHeadID}, &head)
+	return head, err
+}
+
+func (r *MongoRepository) AdvanceFileEventChainHead(ctx context.Context, head FileEventChainHead, n int, hash string) (bool, error) {
+	// a head that has moved on does not match, so the upsert tries to insert it again and clashes on _id
+	result, err := r.fileEventChainCollection.Upsert(
+		ctx,
+		bson.M{fieldMongoID: fileEventChainHeadID, fieldSequence: head.Sequence, fieldHash: head.Hash},
+		bson.D{
+			{Key: "$inc", Value: bson.D{{Key: fieldSequence, Value: int64(n)}}},
+			{Key: "$set", Value: bson.D{{Key: fieldHash, Value: hash}}},
+		},
+	)
+	if mongoRaw.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0 || result.UpsertedCount > 0, nil
+}
+
+func (r *MongoRepository) FindChainedFileEvents(ctx context.Context, afterSequence int64, limit int) ([]ChainedFileEvent, error) {
+	docs := make([]bson.Raw, 0)
+
+	_, err := r.fileEventsCollection.Find(ctx, bson.M{fieldSequence: bson.M{"$gt": afterSequence}}, &docs,
+		mongodriver.Sort(bson.D{{Key: fieldSequence, Value: 1}}),
+		mongodriver.Limit(limit),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]ChainedFileEvent, 0, len(docs))
+	for _, doc := range docs {
+		event, err := chainedFileEvent(doc)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+func (r *MongoRepository) CountFileEvents(ctx context.Context, filter FileEventFilter) (int, error) {
+	return r.fileEventsCollection.Count(ctx, fileEventQuery(filter))
+}
+
+func (r *MongoRepository) FindFileEvents(ctx context.Context, filter FileEventFilter, oldestFirst bool, offset, limit int) ([]files.FileEvent, error) {
+	events := make([]files.FileEvent, 0)
+
+	order := -1
+	if oldestFirst {
+		order = 1
+	}
+
+	_, err := r.fileEventsCollection.Find(ctx, fileEventQuery(filter), &events,
+		mongodriver.Sort(bson.M{fieldCreatedAt: order}),
+		mongodriver.Offset(offset),
+		mongodriver.Limit(limit),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (r *MongoRepository) FileEventsCursor(ctx context.Context, filter FileEventFilter) (Cursor[files.FileEvent], error) {
+	cursor, err := r.fileEventsCollection.FindCursor(ctx, fileEventQuery(filter),
+		mongodriver.Sort(bson.D{{Key: fieldCreatedAt, Value: 1}, {Key: "_id", Value: 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return mongoCursor[files.FileEvent]{cursor}, nil
+}
+
+func (r *MongoRepository) InsertOutboxMessage(ctx context.Context, msg files.OutboxMessage) error {
+	_, err := r.outboxCollection.Insert(ctx, msg)
+	return err
+}
+
+func (r *MongoRepository) ReleaseOutboxMessages(ctx context.Context, ids []string) error {
+	_, err := r.outboxCollection.UpdateMany(ctx, bson.M{fieldID: bson.M{"$in": ids}}, bson.M{"$unset": bson.M{fieldPending: ""}})
+	return err
+}
+
+func (r *MongoRepository) FindStalePendingOutboxMessages(ctx context.Context, createdBefore time.Time, limit int) ([]files.OutboxMessage, error) {
+	messages := make([]files.OutboxMessage, 0)
+
+	_, err := r.outboxCollection.Find(
+		ctx,
+		bson.M{fieldPending: true, fieldCreatedAt: bson.M{"$lte": createdBefore}},
+		&messages,
+		mongodriver.Sort(bson.D{{Key: fieldCreatedAt, Value: 1}}),
+		mongodriver.Limit(limit),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+func (r *MongoRepository) FindDueOutboxMessages(ctx context.Context, due time.Time, limit int) ([]files.OutboxMessage, error) {
+	messages := make([]files.OutboxMessage, 0)
+
+	_, err := r.outboxCollection.Find(
+		ctx,
+		bson.M{fieldNextAttemptAt: bson.M{"$lte": due}, fieldPending: bson.M{"$ne": true}},
+		&messages,
+		mongodriver.Sort(bson.D{{Key: fieldCreatedAt, Value: 1}}),
+		mongodriver.Limit(limit),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+func (r *MongoRepository) DeleteOutboxMessage(ctx context.Context, id string) error {
+	_, err := r.outboxCollection.Delete(ctx, bson.M{fieldID: id})
+	return err
+}
+
+func (r *MongoRepository) RecordOutboxMessageSent(ctx context.Context, id string, sentAt, confirmBy time.Time) error {
+	_, err := r.outboxCollection.Update(
+		ctx,
+		bson.M{fieldID: id},
+		bson.D{
+			{Key: "$set", Value: bson.D{
+				{Key: fieldSentAt, Value: sentAt},
+				{Key: fieldNextAttemptAt, Value: confirmBy}}},
+		})
+	return err
+}
+
+func (r *MongoRepository) RecordOutboxMessageFailure(ctx context.Context, id string, nextAttemptAt time.Time, reason string) error {
+	_, err := r.outboxCollection.Update(
+		ctx,
+		bson.M{fieldID: id},
+		bson.D{
+			{Key: "$inc", Value: bson.D{{Key: fieldAttempts, Value: 1}}},
+			{Key: "$set", Value: bson.D{
+				{Key: fieldLastError, Value: reason},
+				{Key: fieldNextAttemptAt, Value: nextAttemptAt}}},
+			{Key: "$unset", Value: bson.D{{Key: fieldSentAt, Value: ""}}},
+		})
+	return err
+}
+
+func (r *MongoRepository) InsertFileVersion(ctx context.Context, version files.StoredRegisteredMetaData) error {
+	_, err := r.fileVersionsCollection.Insert(ctx, version)
+	return err
+}
+
+func (r *MongoRepository) GetFileVersion(ctx context.Context, path string, version int) (files.StoredRegisteredMetaData, error) {
+	metadata := files.StoredRegisteredMetaData{}
+	err := r.fileVersionsCollection.FindOne(ctx, bson.M{fieldPath: path, fieldVersion: version}, &metadata)
+	return metadata, err
+}
+
+func (r *MongoRepository) FindFileVersions(ctx context.Context, path string) ([]files.StoredRegisteredMetaData, error) {
+	versions := make([]files.StoredRegisteredMetaData, 0)
+	if _, err := r.fileVersionsCollection.Find(ctx, bson.M{fieldPath: path}, &versions, mongodriver.Sort(bson.D{{Key: fieldVersion, Value: 1}})); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+func (r *MongoRepository) DeleteFileVersion(ctx context.Context, path string, version int) error {
+	_, err := r.fileVersionsCollection.Delete(ctx, bson.M{fieldPath: path, fieldVersion: version})
+	return err
+}
+
+func (r *MongoRepository) LatestFileVersions(ctx context.Context, paths []string) (map[string]int, error) {
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{fieldPath: bson.M{"$in": paths}}},
+		bson.M{"$group": bson.M{"_id": "$" + fieldPath, fieldVersion: bson.M{"$max": "$" + fieldVersion}}},
+	}
+
+	var results []struct {
+		Path    string `bson:"_id"`
+		Version int    `bson:"version"`
+	}
+	if err := r.fileVersionsCollection.Aggregate(ctx, pipeline, &results); err != nil {
+		return nil, err
+	}
+
+	latest := make(map[string]int, len(results))
+	for _, result := range results {
+		latest[result.Path] = result.Version
+	}
+	return latest, nil
+}
+
+func (r *MongoRepository) InsertFileChange(ctx context.Context, change files.FileChange) error {
+	_, err := r.fileChangesCollection.Insert(ctx, change)
+	return err
+}
+
+func (r *MongoRepository) GetLatestFileChange(ctx context.Context) (files.FileChange, error) {
+	change := files.FileChange{}
+	err := r.fileChangesCollection.FindOne(ctx, bson.M{fieldSequence: bson.M{"$gt": 0}}, &change, mongodriver.Sort(bson.D{{Key: fieldSequence, Value: -1}}))
+	return change, err
+}
+
+func (r *MongoRepository) FindFileChanges(ctx context.Context, filter FileChangeFilter, limit int) ([]files.FileChange, error) {
+	query := bson.M{fieldSequence: bson.M{"$gt": filter.AfterSequence}}
+	if filter.CollectionID != nil {
+		query[fieldCollectionID] = *filter.CollectionID
+	}
+	if filter.BundleID != nil {
+		query[fieldBundleID] = *filter.BundleID
+	}
+
+	changes := make([]files.FileChange, 0)
+
+	_, err := r.fileChangesCollection.Find(ctx, query, &changes,
+		mongodriver.Sort(bson.D{{Key: fieldSequence, Value: 1}}),
+		mongodriver.Limit(limit),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+func (r *MongoRepository) InsertPublishJob(ctx context.Context, job *files.PublishJob) error {
+	_, err := r.publishJobsCollection.Insert(ctx, job)
+	return err
+}
+
+func (r *MongoRepository) DeletePublishJob(ctx context.Context, id string) error {
+	_, err := r.publishJobsCollection.Delete(ctx, bson.M{fieldID: id})
+	return err
+}
+
+func (r *MongoRepository) ClaimPublishJob(ctx context.Context, id, owner string, now, leaseExpiresAt time.Time) (bool, error) {
+	result, err := r.publishJobsCollection.Update(
+		ctx,
+		bson.M{
+			fieldID:    id,
+			fieldState: PublishJobStateInProgress,
+			"$or": bson.A{
+				bson.M{fieldLeaseExpiresAt: bson.M{"$exists": false}},
+				bson.M{fieldLeaseExpiresAt: bson.M{"$lte": now}},
+			},
+		},
+		bson.D{
+			{Key: "$set", Value: bson.D{
+				{Key: fieldOwner, Value: owner},
+				{Key: fieldLeaseExpiresAt, Value: leaseExpiresAt}},
+			},
+		})
+	return result != nil && result.MatchedCount > 0, err
+}
+
+func (r *MongoRepository) CheckpointPublishJobBatch(ctx context.Context, id string, checkpoint PublishJobCheckpoint) (bool, error) {
+	prefix := fmt.Sprintf("%s.%d.", fieldBatches, checkpoint.Batch)
+
+	result, err := r.publishJobsCollection.Update(
+		ctx,
+		bson.M{fieldID: id, fieldOwner: checkpoint.Owner},
+		bson.D{
+			{Key: "$set", Value: bson.D{
+				{Key: prefix + fieldPosition, Value: checkpoint.Position},
+				{Key: prefix + fieldLastPath, Value: checkpoint.LastPath},
+				{Key: prefix + fieldCompleted, Value: checkpoint.Completed},
+				{Key: fieldLastModified, Value: checkpoint.LastModified},
+				{Key: fieldLeaseExpiresAt, Value: checkpoint.LeaseExpiresAt}},
+			},
+			{Key: "$inc", Value: bson.D{
+				{Key: prefix + fieldSent, Value: checkpoint.Sent},
+				{Key: prefix + fieldFailed, Value: checkpoint.Failed},
+				{Key: fieldSent, Value: checkpoint.Sent},
+				{Key: fieldFailed, Value: checkpoint.Failed}},
+			},
+		})
+	return result != nil && result.MatchedCount > 0, err
+}
+
+func (r *MongoRepository) RenewPublishJobLease(ctx context.Context, id, owner string, leaseExpiresAt time.Time) (bool, error) {
+	result, err := r.publishJobsCollection.Update(
+		ctx,
+		bson.M{fieldID: id, fieldOwner: owner},
+		bson.D{{Key: "$set", Value: bson.D{{Key: fieldLeaseExpiresAt, Value: leaseExpiresAt}}}})
+	return result != nil && result.MatchedCount > 0, err
+}
+
+func (r *MongoRepository) CompletePublishJob(ctx context.Context, id, owner, state string, completedAt time.Time) error {
+	_, err := r.publishJobsCollection.Update(
+		ctx,
+		bson.M{fieldID: id, fieldOwner: owner},
+		bson.D{
+			{Key: "$set", Value: bson.D{
+				{Key: fieldState, Value: state},
+				{Key: fieldLastModified, Value: completedAt},
+				{Key: fieldCompletedAt, Value: completedAt}},
+			},
+			{Key: "$unset", Value: bson.D{{Key: fieldLeaseExpiresAt, Value: ""}}},
+		})
+	return err
+}
+
+func (r *MongoRepository) FindPublishJobs(ctx context.Context, state string) ([]files.PublishJob, error) {
+	jobs := make([]files.PublishJob, 0)
+	if _, err := r.publishJobsCollection.Find(ctx, bson.M{fieldState: state}, &jobs); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func (r *MongoRepository) GetLatestPublishJob(ctx context.Context, collectionID, bundleID string) (files.PublishJob, error) {
+	filter := bson.M{fieldCollectionID: collectionID}
+	if bundleID != "" {
+		filter = bson.M{fieldBundleID: bundleID}
+	}
+
+	job := files.PublishJob{}
+	err := r.publishJobsCollection.FindOne(ctx, filter, &job, mongodriver.Sort(bson.D{{Key: fieldCreatedAt, Value: -1}}))
+	return job, err
+}
+
+func (r *MongoRepository) InsertTrashedFile(ctx context.Context, file files.TrashedFile) error {
+	_, err := r.trashCollection.Insert(ctx, file)
+	return err
+}
+
+func (r *MongoRepository) GetTrashedFile(ctx context.Context, path string) (files.TrashedFile, error) {
+	file := files.TrashedFile{}
+	err := r.trashCollection.FindOne(ctx, bson.M{fieldPath: path}, &file, mongodriver.Sort(bson.D{{Key: fieldDeletedAt, Value: -1}}))
+	return file, err
+}
+
+func (r *MongoRepository) DeleteTrashedFile(ctx context.Context, id string) (bool, error) {
+	result, err := r.trashCollection.Delete(ctx, bson.M{fieldID: id})
+	return deleted(result), err
+}
+
+func (r *MongoRepository) FindExpiredTrashedFiles(ctx context.Context, due time.Time) ([]files.TrashedFile, error) {
+	trashed := make([]files.TrashedFile, 0)
+	if _, err := r.trashCollection.Find(ctx, bson.M{fieldPurgeAfter: bson.M{"$lte": due}}, &trashed); err != nil {
+		return nil, err
+	}
+	return trashed, nil
+}
+
+func (r *MongoRepository) InsertReconciliationReport(ctx context.Context, report *files.ReconciliationReport) error {
+	_, err := r.reconciliationsCollection.Insert(ctx, report)
+	return err
+}
+
+func (r *MongoRepository) UpdateReconciliationReport(ctx context.Context, report *files.ReconciliationReport) error {
+	_, err := r.reconciliationsCollection.Update(ctx, bson.M{fieldID: report.ID}, bson.D{{Key: "$set", Value: report}})
+	return err
+}
+
+func (r *MongoRepository) GetReconciliationReport(ctx context.Context, id string) (files.ReconciliationReport, error) {
+	report := files.ReconciliationReport{}
+	err := r.reconciliationsCollection.FindOne(ctx, bson.M{fieldID: id}, &report)
+	return report, err
+}
+
+func (r *MongoRepository) GetLatestReconciliationReport(ctx context.Context) (files.ReconciliationReport, error) {
+	report := files.ReconciliationReport{}
+	err := r.reconciliationsCollection.FindOne(ctx, bson.M{}, &report, mongodriver.Sort(bson.D{{Key: fieldStartedAt, Value: -1}}))
+	return report, err
+}
+
+func (r *MongoRepository) InsertIdempotencyRecord(ctx context.Context, record files.IdempotencyRecord) error {
+	_, err := r.idempotencyKeysCollection.Insert(ctx, record)
+	return err
+}
+
+func (r *MongoRepository) GetIdempotencyRecord(ctx context.Context, key string) (files.IdempotencyRecord, error) {
+	record := files.IdempotencyRecord{}
+	err := r.idempotencyKeysCollection.FindOne(ctx, bson.M{fieldMongoID: key}, &record)
+	return record, err
+}
+
+func (r *MongoRepository) CompleteIdempotencyRecord(ctx context.Context, key string, createdAt time.Time, response files.IdempotentResponse) error {
+	_, err := r.idempotencyKeysCollection.Update(
+		ctx,
+		bson.M{fieldMongoID: key, fieldCreatedAt: createdAt},
+		bson.D{{Key: "$set", Value: bson.D{{Key: fieldResponse, Value: response}}}},
+	)
+	return err
+}
+
+func (r *MongoRepository) DeleteIdempotencyRecord(ctx context.Context, key string, createdAt time.Time) (bool, error) {
+	result, err := r.idempotencyKeysCollection.Delete(ctx, bson.M{fieldMongoID: key, fieldCreatedAt: createdAt})
+	return deleted(result), err
+}
+
+func (r *MongoRepository) LockResource(ctx context.Context, resource, lockID string, ttl time.Duration) error {
+	// mongo-lock counts its TTL in whole seconds and treats 0 as never expiring
+	seconds := uint(math.Max(1, math.Ceil(ttl.Seconds())))
+	err := r.locksCollection.NewLockClient().XLock(ctx, resource, lockID, lock.LockDetails{TTL: seconds})
+	if errors.Is(err, lock.ErrAlreadyLocked) {
+		return ErrResourceLocked
+	}
+	return err
+}
+
+func (r *MongoRepository) RenewResourceLock(ctx context.Context, lockID string, ttl time.Duration) error {
+	seconds := uint(math.Max(1, math.Ceil(ttl.Seconds())))
+	_, err := r.locksCollection.NewLockClient().Renew(ctx, lockID, seconds)
+	if errors.Is(err, lock.ErrLockNotFound) {
+		return ErrResourceLocked
+	}
+	return err
+}
+
+func (r *MongoRepository) UnlockResource(ctx context.Context, lockID string) error {
+	_, err := r.locksCollection.NewLockClient().Unlock(ctx, lockID)
+	return err
+}
+
+func metadataQuery(filter MetadataFilter) bson.M {
+	conditions := make([]bson.M, 0)
+	if filter.Paths != nil {
+		conditions = append(conditions, bson.M{fieldPath: bson.M{"$in": filter.Paths}})
+	}
+	if filter.CollectionID != nil {
+		conditions = append(conditions, bson.M{fieldCollectionID: *filter.CollectionID})
+	}
+	if filter.BundleID != nil {
+		conditions = append(conditions, bson.M{fieldBundleID: *filter.BundleID})
+	}
+	for _, state := range filter.ExcludeStates {
+		conditions = append(conditions, bson.M{fieldState: bson.M{"$ne": state}})
+	}
+	if filter.States != nil {
+		conditions = append(conditions, bson.M{fieldState: bson.M{"$in": filter.States}})
+	}
+	if filter.Type != "" {
+		conditions = append(conditions, bson.M{fieldType: filter.Type})
+	}
+	if filter.IsPublishable != nil {
+		conditions = append(conditions, bson.M{fieldIsPublishable: *filter.IsPublishable})
+	}
+	if filter.PathPrefix != "" {
+		conditions = append(conditions, bson.M{fieldPath: pathPrefixRegex(filter.PathPrefix)})
+	}
+	if filter.PathAfter != "" {
+		conditions = append(conditions, bson.M{fieldPath: bson.M{"$gt": filter.PathAfter}})
+	}
+	if q := timeRangeQuery(filter.CreatedAfter, filter.CreatedBefore); q != nil {
+		conditions = append(conditions, bson.M{fieldCreatedAt: q})
+	}
+	if q := timeRangeQuery(filter.ModifiedAfter, filter.ModifiedBefore); q != nil {
+		conditions = append(conditions, bson.M{fieldLastModified: q})
+	}
+
+	switch len(conditions) {
+	case 0:
+		return bson.M{}
+	case 1:
+		return conditions[0]
+	default:
+		return bson.M{"$and": conditions}
+	}
+}
+
+// directoryPathRange matches the paths starting with prefix as a range, which MongoDB answers from the path index
+// however short the prefix, leaving out the paths grouped under the directory entry with key after and those before it
+func directoryPathRange(prefix, after string) bson.M {
+	query := bson.M{"$gte": prefix}
+	switch {
+	case strings.HasSuffix(after, "/"):
+		query["$gte"] = pathPrefixEnd(prefix + after)
+	case after != "":
+		query = bson.M{"$gt": prefix + after}
+	}
+	if end := pathPrefixEnd(prefix); end != "" {
+		query["$lt"] = end
+	}
+	return query
+}
+
+// pathPrefixEnd is the first path after every path starting with prefix, or empty when there is none
+func pathPrefixEnd(prefix string) string {
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return string(end[:i+1])
+		}
+	}
+	return ""
+}
+
+// pathPrefixRegex matches the paths starting with prefix, which MongoDB can answer from an index on the path
+func pathPrefixRegex(prefix string) primitive.Regex {
+	return primitive.Regex{Pattern: "^" + regexp.QuoteMeta(prefix)}
+}
+
+func fileEventQuery(filter FileEventFilter) bson.M {
+	query := bson.M{}
+
+	switch {
+	case filter.Path != "" && filter.PathPrefix != "":
+		query[fieldFileEventPath] = bson.M{"$eq": filter.Path, "$regex": pathPrefixRegex(filter.PathPrefix)}
+	case filter.Path != "":
+		query[fieldFileEventPath] = filter.Path
+	case filter.PathPrefix != "":
+		query[fieldFileEventPath] = pathPrefixRegex(filter.PathPrefix)
+	}
+
+	fields := map[string]string{
+		fieldAction:                filter.Action,
+		fieldRequestedByID:         filter.RequestedByID,
+		fieldFileEventCollectionID: filter.CollectionID,
+		fieldFileEventBundleID:     filter.BundleID,
+		fieldFileEventDatasetID:    filter.DatasetID,
+		fieldFileEventEdition:      filter.Edition,
+	}
+	for field, value := range fields {
+		if value != "" {
+			query[field] = value
+		}
+	}
+
+	if q := timeRangeQuery(filter.After, filter.Before); q != nil {
+		query[fieldCreatedAt] = q
+	}
+
+	return query
+}
+
+// metadataUpdateDocument is the update document for file metadata, which moves it on to its next revision
+func metadataUpdateDocument(update Update) bson.D {
+	return append(updateDocument(update), bson.E{Key: "$inc", Value: bson.D{{Key: fieldRevision, Value: 1}}})
+}
+
+func updateDocument(update Update) bson.D {
+	doc := bson.D{}
+	if len(update.Set) > 0 {
+		set := bson.D{}
+		for _, f := range update.Set {
+			set = append(set, bson.E{Key: f.Key, Value: f.Value})
+		}
+		doc = append(doc, bson.E{Key: "$set", Value: set})
+	}
+	if len(update.Unset) > 0 {
+		unset := bson.D{}
+		for _, key := range update.Unset {
+			unset = append(unset, bson.E{Key: key, Value: ""})
+		}
+		doc = append(doc, bson.E{Key: "$unset", Value: unset})
+	}
+	return doc
+}
+
+func deleted(result *mongodriver.CollectionDeleteResult) bool {
+	return result != nil && result.DeletedCount > 0
+}
+
+// timeRangeQuery matches times from after to before inclusive, or returns nil when neither is set
+func timeRangeQuery(after, before *time.Time) bson.M {
+	if after == nil && before == nil {
+		return nil
+	}
+
+	q := bson.M{}
+	if after != nil {
+		q["$gte"] = after
+	}
+	if before != nil {
+		q["$lte"] = before
+	}
+	return q
+}
+
+// mongoCursor decodes each document a MongoDB cursor moves on to
+type mongoCursor[T any] struct {
+	mongodriver.Cursor
+}
+
+func (c mongoCursor[T]) Current() (T, error) {
+	var doc T
+	err := c.Decode(&doc)
+	return doc, err
+}
@@ -0,0 +1,269 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/ONSdigital/dp-files-api/files"
+	mongodriver "github.com/ONSdigital/dp-mongodb/v3/mongodb"
+	"github.com/ONSdigital/log.go/v2/log"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// outboxRelayResource is locked by the instance relaying the outbox, so that only one instance sends messages
+const outboxRelayResource = "outbox-relay"
+
+// enqueueFilePublished writes a pending file published message to the outbox for each enabled version of the event,
+// returning the IDs of the messages. Once released, the messages are sent to Kafka by the outbox relay, which retries
+// until they are delivered. If any message cannot be written none are left in the outbox.
+func (store *Store) enqueueFilePublished(ctx context.Context, m *files.StoredRegisteredMetaData) ([]string, error) {
+	now := store.clock.GetCurrentTime()
+
+	var messages []files.OutboxMessage
+	if store.cfg.FilePublishedV2Enabled {
+		messages = append(messages, files.OutboxMessage{
+			ID:            primitive.NewObjectID().Hex(),
+			Topic:         store.cfg.StaticFilePublishedTopic,
+			FilePublished: filePublishedFromMetadata(m),
+			Pending:       true,
+			CreatedAt:     now,
+			NextAttemptAt: now,
+		})
+	}
+	if store.cfg.FilePublishedV3Enabled {
+		id := primitive.NewObjectID().Hex()
+		messages = append(messages, files.OutboxMessage{
+			ID:              id,
+			Topic:           store.cfg.StaticFilePublishedV3Topic,
+			FilePublishedV3: filePublishedV3FromMetadata(id, m, now),
+			Pending:         true,
+			CreatedAt:       now,
+			NextAttemptAt:   now,
+		})
+	}
+
+	ids := make([]string, 0, len(messages))
+	for _, msg := range messages {
+		if err := store.repo.InsertOutboxMessage(ctx, msg); err != nil {
+			log.Error(ctx, "failed to write message to outbox", err, log.Data{"path": m.Path, "topic": msg.Topic})
+			store.withdrawOutboxMessages(ctx, ids)
+			return nil, err
+		}
+		ids = append(ids, msg.ID)
+	}
+
+	return ids, nil
+}
+
+// releaseOutboxMessages lets the relay send pending messages once the change they announce has been made. The change
+// has already been made, so a failure is only logged, and leaves the messages pending until they are settled by
+// SettleStalePendingOutboxMessages.
+func (store *Store) releaseOutboxMessages(ctx context.Context, ids []string) {
+	if len(ids) == 0 {
+		return
+	}
+	if err := store.repo.ReleaseOutboxMessages(ctx, ids); err != nil {
+		log.Error(ctx, "failed to release outbox messages", err, log.Data{"ids": ids})
+	}
+}
+
+// withdrawOutboxMessages removes messages from the outbox before they are sent, when the change they announce has
+// not been made
+func (store *Store) withdrawOutboxMessages(ctx context.Context, ids []string) {
+	for _, id := range ids {
+		if err := store.DeleteOutboxMessage(ctx, id); err != nil {
+			log.Error(ctx, "failed to withdraw outbox message", err, log.Data{"id": id})
+		}
+	}
+}
+
+// LockOutboxRelay takes the lock on relaying the outbox for ttl, or renews it when it is already held with lockID. It
+// returns ErrResourceLocked while another instance of the service holds the lock.
+func (store *Store) LockOutboxRelay(ctx context.Context, lockID string, ttl time.Duration) error {
+	err := store.repo.RenewResourceLock(ctx, lockID, ttl)
+	if errors.Is(err, ErrResourceLocked) {
+		err = store.repo.LockResource(ctx, outboxRelayResource, lockID, ttl)
+	}
+	if err != nil && !errors.Is(err, ErrResourceLocked) {
+		log.Error(ctx, "failed to lock outbox relay", err, log.Data{"lock_id": lockID})
+	}
+	return err
+}
+
+// UnlockOutboxRelay releases the lock on relaying the outbox taken with lockID
+func (store *Store) UnlockOutboxRelay(ctx context.Context, lockID string) error {
+	if err := store.repo.UnlockResource(ctx, lockID); err != nil {
+		log.Error(ctx, "failed to unlock outbox relay", err, log.Data{"lock_id": lockID})
+		return err
+	}
+	return nil
+}
+
+// SettleStalePendingOutboxMessages settles up to limit messages that have been pending for longer than the outbox
+// pending timeout, because the instance making their change failed to release or withdraw them. A message is released
+// when its file is in the state the change leads to, and withdrawn otherwise. The last error is returned, and any
+// message it left pending is tried again next time.
+func (store *Store) SettleStalePendingOutboxMessages(ctx context.Context, limit int) error {
+	createdBefore := store.clock.GetCurrentTime().Add(-store.cfg.OutboxPendingTimeout)
+	messages, err := store.repo.FindStalePendingOutboxMessages(ctx, createdBefore, limit)
+	if err != nil {
+		log.Error(ctx, "failed to find stale pending outbox messages", err)
+		return err
+	}
+
+	var lastErr error
+	var made []string
+	for i := range messages {
+		msg := &messages[i]
+		ok, err := store.outboxChangeMade(ctx, msg)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if ok {
+			made = append(made, msg.ID)
+			continue
+		}
+
+		log.Info(ctx, "withdrawing stale outbox message whose change was not made", log.Data{"id": msg.ID, "topic": msg.Topic})
+		if err := store.DeleteOutboxMessage(ctx, msg.ID); err != nil {
+			lastErr = err
+		}
+	}
+
+	if len(made) > 0 {
+		log.Info(ctx, "releasing stale outbox messages whose change was made", log.Data{"ids": made})
+		if err := store.repo.ReleaseOutboxMessages(ctx, made); err != nil {
+			log.Error(ctx, "failed to release stale outbox messages", err, log.Data{"ids": made})
+			return err
+		}
+	}
+	return lastErr
+}
+
+// outboxChangeMade reports whether the change a message announces was made, judging by the current state of its file.
+// A content item update leaves the state as it was, so it is taken as made while the file exists.
+func (store *Store) outboxChangeMade(ctx context.Context, msg *files.OutboxMessage) (bool, error) {
+	var path string
+	switch {
+	case msg.FilePublished != nil:
+		path = msg.FilePublished.Path
+	case msg.FilePublishedV3 != nil:
+		path = msg.FilePublishedV3.Path
+	case msg.FileLifecycle != nil:
+		path = msg.FileLifecycle.Path
+	case msg.FileWithdrawn != nil:
+		path = msg.FileWithdrawn.Path
+	default:
+		return false, nil
+	}
+
+	m, err := store.repo.GetMetadata(ctx, path)
+	found := err == nil
+	if err != nil && !errors.Is(err, mongodriver.ErrNoDocumentFound) {
+		log.Error(ctx, "failed to check the file of a stale outbox message", err, log.Data{"id": msg.ID, "path": path})
+		return false, err
+	}
+
+	switch {
+	case msg.FilePublished != nil, msg.FilePublishedV3 != nil:
+		return found && m.State != StateCreated && m.State != StateUploaded, nil
+	case msg.FileWithdrawn != nil:
+		return found && m.State == StateWithdrawn, nil
+	case msg.FileLifecycle.ToState == "":
+		return !found, nil
+	case msg.FileLifecycle.Change == files.LifecycleStateChanged:
+		return found && m.State != msg.FileLifecycle.FromState, nil
+	default:
+		return found, nil
+	}
+}
+
+// GetPendingOutboxMessages returns up to limit outbox messages that are due to be relayed, oldest first
+func (store *Store) GetPendingOutboxMessages(ctx context.Context, limit int) ([]files.OutboxMessage, error) {
+	messages, err := store.repo.FindDueOutboxMessages(ctx, store.clock.GetCurrentTime(), limit)
+	if err != nil {
+		log.Error(ctx, "failed to find pending outbox messages", err)
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+// DeleteOutboxMessage removes a message from the outbox once its delivery has been confirmed
+func (store *Store) DeleteOutboxMessage(ctx context.Context, id string) error {
+	if err := store.repo.DeleteOutboxMessage(ctx, id); err != nil {
+		log.Error(ctx, "failed to delete outbox message", err, log.Data{"id": id})
+		return err
+	}
+	return nil
+}
+
+// MarkOutboxMessageSent records that a message has been handed to the producer, leasing it until confirmBy
+func (store *Store) MarkOutboxMessageSent(ctx context.Context, id string, confirmBy time.Time) error {
+	if err := store.repo.RecordOutboxMessageSent(ctx, id, store.clock.GetCurrentTime(), confirmBy); err != nil {
+		log.Error(ctx, "failed to record outbox message sent", err, log.Data{"id": id})
+		return err
+	}
+	return nil
+}
+
+// MarkOutboxMessageFailed records a failed relay attempt and when the message should next be tried
+func (store *Store) MarkOutboxMessageFailed(ctx context.Context, id string, nextAttemptAt time.Time, reason string) error {
+	if err := store.repo.RecordOutboxMessageFailure(ctx, id, nextAttemptAt, reason); err != nil {
+		log.Error(ctx, "failed to record outbox message failure", err, log.Data{"id": id})
+		return err
+	}
+	return nil
+}
+
+func filePublishedFromMetadata(m *files.StoredRegisteredMetaData) *files.FilePublished {
+	return &files.FilePublished{
+		Path:           m.Path,
+		Type:           m.Type,
+		Etag:           m.Etag,
+		SizeInBytes:    strconv.FormatUint(m.SizeInBytes, 10),
+		ChecksumSHA256: m.ChecksumSHA256,
+		ChecksumMD5:    m.ChecksumMD5,
+	}
+}
+
+// filePublishedV3FromMetadata builds the version 3 event for the file. Files published as part of a collection or
+// bundle already have their publication time, otherwise the file is being published now.
+func filePublishedV3FromMetadata(eventID string, m *files.StoredRegisteredMetaData, now time.Time) *files.FilePublishedV3 {
+	publishedAt := now
+	if m.PublishedAt != nil {
+		publishedAt = *m.PublishedAt
+	}
+
+	event := &files.FilePublishedV3{
+		EventID:        eventID,
+		Path:           m.Path,
+		Etag:           m.Etag,
+		Type:           m.Type,
+		SizeInBytes:    int64(m.SizeInBytes), //nolint:gosec // file sizes are well within an int64
+		Title:          m.Title,
+		Licence:        m.Licence,
+		LicenceURL:     m.LicenceURL,
+		PublishedAt:    publishedAt.UnixMilli(),
+		ChecksumSHA256: m.ChecksumSHA256,
+		ChecksumMD5:    m.ChecksumMD5,
+	}
+	if m.CollectionID != nil {
+		event.CollectionID = *m.CollectionID
+	}
+	if m.BundleID != nil {
+		event.BundleID = *m.BundleID
+	}
+	if m.ContentItem != nil {
+		event.ContentItem = files.FilePublishedContentItem{
+			DatasetID: m.ContentItem.DatasetID,
+			Edition:   m.ContentItem.Edition,
+			Version:   m.ContentItem.Version,
+		}
+	}
+
+	return event
+}
@@ -0,0 +1,289 @@
+package store_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ONSdigital/dp-files-api/config"
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/dp-files-api/mongo/mock"
+	"github.com/ONSdigital/dp-files-api/store"
+	mongodriver "github.com/ONSdigital/dp-mongodb/v3/mongodb"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func (suite *StoreSuite) TestGetPendingOutboxMessagesSuccess() {
+	expected := []files.OutboxMessage{{ID: "1", Topic: "topic"}, {ID: "2", Topic: "topic"}}
+
+	outboxCollection := mock.MongoCollectionMock{
+		FindFunc: func(ctx context.Context, filter interface{}, results interface{}, opts ...mongodriver.FindOption) (int, error) {
+			*results.(*[]files.OutboxMessage) = expected
+			return len(expected), nil
+		},
+	}
+
+	cfg, _ := config.Get()
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Outbox: &outboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
+
+	messages, err := subject.GetPendingOutboxMessages(suite.defaultContext, 10)
+
+	suite.NoError(err)
+	suite.Equal(expected, messages)
+	suite.Equal(bson.M{"next_attempt_at": bson.M{"$lte": suite.defaultClock.GetCurrentTime()}, "pending": bson.M{"$ne": true}}, outboxCollection.FindCalls()[0].Filter)
+	suite.Len(outboxCollection.FindCalls()[0].Opts, 2)
+}
+
+func (suite *StoreSuite) TestGetPendingOutboxMessagesFindReturnsError() {
+	expectedError := errors.New("find failed")
+
+	outboxCollection := mock.MongoCollectionMock{
+		FindFunc: CollectionFindReturnsValueAndError(0, expectedError),
+	}
+
+	cfg, _ := config.Get()
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Outbox: &outboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
+
+	messages, err := subject.GetPendingOutboxMessages(suite.defaultContext, 10)
+
+	suite.Nil(messages)
+	suite.ErrorIs(err, expectedError)
+}
+
+func (suite *StoreSuite) TestDeleteOutboxMessage() {
+	outboxCollection := mock.MongoCollectionMock{
+		DeleteFunc: CollectionDeleteReturnsNilAndNil(),
+	}
+
+	cfg, _ := config.Get()
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Outbox: &outboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
+
+	suite.NoError(subject.DeleteOutboxMessage(suite.defaultContext, "1"))
+	suite.Equal(bson.M{"id": "1"}, outboxCollection.DeleteCalls()[0].Selector)
+}
+
+func (suite *StoreSuite) TestMarkOutboxMessageFailed() {
+	outboxCollection := mock.MongoCollectionMock{
+		UpdateFunc: CollectionUpdateReturnsNilAndNil(),
+	}
+	nextAttemptAt := suite.defaultClock.GetCurrentTime().Add(time.Minute)
+
+	cfg, _ := config.Get()
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Outbox: &outboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
+
+	suite.NoError(subject.MarkOutboxMessageFailed(suite.defaultContext, "1", nextAttemptAt, "broker unavailable"))
+
+	call := outboxCollection.UpdateCalls()[0]
+	suite.Equal(bson.M{"id": "1"}, call.Selector)
+	suite.Equal(bson.D{
+		{Key: "$inc", Value: bson.D{{Key: "attempts", Value: 1}}},
+		{Key: "$set", Value: bson.D{
+			{Key: "last_error", Value: "broker unavailable"},
+			{Key: "next_attempt_at", Value: nextAttemptAt}}},
+		{Key: "$unset", Value: bson.D{{Key: "sent_at", Value: ""}}},
+	}, call.Update)
+}
+
+func (suite *StoreSuite) TestMarkOutboxMessageSent() {
+	outboxCollection := mock.MongoCollectionMock{
+		UpdateFunc: CollectionUpdateReturnsNilAndNil(),
+	}
+	confirmBy := suite.defaultClock.GetCurrentTime().Add(time.Minute)
+
+	cfg, _ := config.Get()
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Outbox: &outboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
+
+	suite.NoError(subject.MarkOutboxMessageSent(suite.defaultContext, "1", confirmBy))
+
+	call := outboxCollection.UpdateCalls()[0]
+	suite.Equal(bson.M{"id": "1"}, call.Selector)
+	suite.Equal(bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "sent_at", Value: suite.defaultClock.GetCurrentTime()},
+			{Key: "next_attempt_at", Value: confirmBy}}},
+	}, call.Update)
+}
+
+func (suite *StoreSuite) TestMarkOutboxMessageFailedUpdateReturnsError() {
+	expectedError := errors.New("update failed")
+
+	outboxCollection := mock.MongoCollectionMock{
+		UpdateFunc: CollectionUpdateReturnsNilAndError(expectedError),
+	}
+
+	cfg, _ := config.Get()
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Outbox: &outboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
+
+	err := subject.MarkOutboxMessageFailed(suite.defaultContext, "1", suite.defaultClock.GetCurrentTime(), "broker unavailable")
+
+	suite.ErrorIs(err, expectedError)
+}
+
+// failingOutboxRepository fails to write outbox messages once failAfter messages have been written
+type failingOutboxRepository struct {
+	*store.MemoryRepository
+	failAfter int
+	written   int
+}
+
+func (r *failingOutboxRepository) InsertOutboxMessage(ctx context.Context, msg files.OutboxMessage) error {
+	if r.written >= r.failAfter {
+		return errors.New("insert failed")
+	}
+	r.written++
+	return r.MemoryRepository.InsertOutboxMessage(ctx, msg)
+}
+
+func (suite *StoreSuite) TestMarkFilePublishedWritesMessageForEachEnabledVersion() {
+	collectionID := "collection"
+	repo := store.NewMemoryRepository()
+	suite.NoError(repo.InsertMetadata(suite.defaultContext, files.StoredRegisteredMetaData{
+		Path:          suite.path,
+		State:         store.StateUploaded,
+		IsPublishable: true,
+		CollectionID:  &collectionID,
+		Type:          "text/csv",
+		SizeInBytes:   10,
+		Etag:          testEtag,
+		ContentItem:   &files.StoredContentItem{DatasetID: "cpih01", Edition: "feb-2026", Version: "1"},
+	}))
+
+	cfg, _ := config.Get()
+	c := *cfg
+	c.FilePublishedV3Enabled = true
+	subject := store.NewStore(repo, suite.defaultClock, nil, &c)
+
+	suite.NoError(subject.MarkFilePublished(suite.defaultContext, suite.path))
+
+	msgs, err := repo.FindDueOutboxMessages(suite.defaultContext, suite.defaultClock.GetCurrentTime(), 10)
+	suite.NoError(err)
+	suite.Require().Len(msgs, 2)
+
+	suite.Equal(c.StaticFilePublishedTopic, msgs[0].Topic)
+	suite.Equal("10", msgs[0].FilePublished.SizeInBytes)
+	suite.Nil(msgs[0].FilePublishedV3)
+
+	suite.Equal(c.StaticFilePublishedV3Topic, msgs[1].Topic)
+	suite.Nil(msgs[1].FilePublished)
+	suite.Equal(&files.FilePublishedV3{
+		EventID:      msgs[1].ID,
+		Path:         suite.path,
+		Etag:         testEtag,
+		Type:         "text/csv",
+		SizeInBytes:  10,
+		CollectionID: collectionID,
+		ContentItem:  files.FilePublishedContentItem{DatasetID: "cpih01", Edition: "feb-2026", Version: "1"},
+		PublishedAt:  suite.defaultClock.GetCurrentTime().UnixMilli(),
+	}, msgs[1].FilePublishedV3)
+}
+
+func (suite *StoreSuite) TestMarkFilePublishedWithdrawsMessagesWhenOutboxWriteFails() {
+	memory := store.NewMemoryRepository()
+	suite.NoError(memory.InsertMetadata(suite.defaultContext, files.StoredRegisteredMetaData{
+		Path:          suite.path,
+		State:         store.StateUploaded,
+		IsPublishable: true,
+	}))
+	repo := &failingOutboxRepository{MemoryRepository: memory, failAfter: 1}
+
+	cfg, _ := config.Get()
+	c := *cfg
+	c.FilePublishedV3Enabled = true
+	subject := store.NewStore(repo, suite.defaultClock, nil, &c)
+
+	suite.Error(subject.MarkFilePublished(suite.defaultContext, suite.path))
+
+	msgs, err := memory.FindDueOutboxMessages(suite.defaultContext, suite.defaultClock.GetCurrentTime(), 10)
+	suite.NoError(err)
+	suite.Empty(msgs)
+	metadata, _ := memory.GetMetadata(suite.defaultContext, suite.path)
+	suite.Equal(store.StateUploaded, metadata.State)
+}
+
+func (suite *StoreSuite) TestSettleStalePendingOutboxMessages() {
+	now := suite.defaultClock.GetCurrentTime()
+	stale := now.Add(-10 * time.Minute)
+	repo := store.NewMemoryRepository()
+	for _, m := range []files.StoredRegisteredMetaData{
+		{Path: "published.txt", State: store.StatePublished},
+		{Path: "unpublished.txt", State: store.StateUploaded},
+		{Path: "changed.txt", State: store.StateUploaded},
+		{Path: "unchanged.txt", State: store.StateCreated},
+	} {
+		suite.NoError(repo.InsertMetadata(suite.defaultContext, m))
+	}
+	for _, msg := range []files.OutboxMessage{
+		{ID: "published", FilePublished: &files.FilePublished{Path: "published.txt"}},
+		{ID: "unpublished", FilePublishedV3: &files.FilePublishedV3{Path: "unpublished.txt"}},
+		{ID: "changed", FileLifecycle: &files.FileLifecycle{Path: "changed.txt", Change: files.LifecycleStateChanged, FromState: store.StateCreated, ToState: store.StateUploaded}},
+		{ID: "unchanged", FileLifecycle: &files.FileLifecycle{Path: "unchanged.txt", Change: files.LifecycleStateChanged, FromState: store.StateCreated, ToState: store.StateUploaded}},
+		{ID: "removed", FileLifecycle: &files.FileLifecycle{Path: "removed.txt", Change: files.LifecycleRemoved, FromState: store.StateUploaded}},
+		{ID: "not-removed", FileLifecycle: &files.FileLifecycle{Path: "published.txt", Change: files.LifecycleRemoved, FromState: store.StatePublished}},
+		{ID: "not-registered", FileLifecycle: &files.FileLifecycle{Path: "missing.txt", Change: files.LifecycleRegistered, ToState: store.StateCreated}},
+		{ID: "not-withdrawn", FileWithdrawn: &files.FileWithdrawn{Path: "published.txt"}},
+	} {
+		msg.Pending = true
+		msg.CreatedAt = stale
+		msg.NextAttemptAt = stale
+		suite.NoError(repo.InsertOutboxMessage(suite.defaultContext, msg))
+	}
+	suite.NoError(repo.InsertOutboxMessage(suite.defaultContext, files.OutboxMessage{
+		ID: "recent", Pending: true, CreatedAt: now, NextAttemptAt: now,
+		FilePublished: &files.FilePublished{Path: "unpublished.txt"},
+	}))
+
+	cfg, _ := config.Get()
+	subject := store.NewStore(repo, suite.defaultClock, nil, cfg)
+
+	suite.NoError(subject.SettleStalePendingOutboxMessages(suite.defaultContext, 100))
+
+	due, err := repo.FindDueOutboxMessages(suite.defaultContext, now, 100)
+	suite.NoError(err)
+	var released []string
+	for _, msg := range due {
+		released = append(released, msg.ID)
+	}
+	suite.ElementsMatch([]string{"published", "changed", "removed"}, released)
+
+	pending, err := repo.FindStalePendingOutboxMessages(suite.defaultContext, now, 100)
+	suite.NoError(err)
+	suite.Require().Len(pending, 1)
+	suite.Equal("recent", pending[0].ID)
+}
+
+func (suite *StoreSuite) TestSettleStalePendingOutboxMessagesLeavesMessagesPendingWhenFileCannotBeChecked() {
+	expectedError := errors.New("find failed")
+	stale := suite.defaultClock.GetCurrentTime().Add(-10 * time.Minute)
+	outboxCollection := mock.MongoCollectionMock{
+		FindFunc: func(ctx context.Context, filter interface{}, results interface{}, opts ...mongodriver.FindOption) (int, error) {
+			*results.(*[]files.OutboxMessage) = []files.OutboxMessage{{ID: "1", Pending: true, CreatedAt: stale, FilePublished: &files.FilePublished{Path: suite.path}}}
+			return 1, nil
+		},
+	}
+	metadataCollection := mock.MongoCollectionMock{
+		FindOneFunc: CollectionFindOneReturnsError(expectedError),
+	}
+
+	cfg, _ := config.Get()
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &metadataCollection, Outbox: &outboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
+
+	err := subject.SettleStalePendingOutboxMessages(suite.defaultContext, 10)
+
+	suite.ErrorIs(err, expectedError)
+	suite.Equal(bson.M{"pending": true, "created_at": bson.M{"$lte": suite.defaultClock.GetCurrentTime().Add(-cfg.OutboxPendingTimeout)}}, outboxCollection.FindCalls()[0].Filter)
+	suite.Empty(outboxCollection.UpdateManyCalls())
+	suite.Empty(outboxCollection.DeleteCalls())
+}
+
+func (suite *StoreSuite) TestLockOutboxRelayRenewsLockHeldByTheSameRelay() {
+	repo := store.NewMemoryRepository()
+	cfg, _ := config.Get()
+	subject := store.NewStore(repo, suite.defaultClock, nil, cfg)
+
+	suite.NoError(subject.LockOutboxRelay(suite.defaultContext, "relay-1", time.Minute))
+	suite.NoError(subject.LockOutboxRelay(suite.defaultContext, "relay-1", time.Minute))
+	suite.ErrorIs(subject.LockOutboxRelay(suite.defaultContext, "relay-2", time.Minute), store.ErrResourceLocked)
+
+	suite.NoError(subject.UnlockOutboxRelay(suite.defaultContext, "relay-1"))
+	suite.NoError(subject.LockOutboxRelay(suite.defaultContext, "relay-2", time.Minute))
+}
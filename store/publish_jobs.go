@@ -0,0 +1,434 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/ONSdigital/dp-files-api/files"
+	mongodriver "github.com/ONSdigital/dp-mongodb/v3/mongodb"
+	dprequest "github.com/ONSdigital/dp-net/v3/request"
+	"github.com/ONSdigital/log.go/v2/log"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	PublishJobStateInProgress = "IN_PROGRESS"
+	PublishJobStateCompleted  = "COMPLETED"
+	PublishJobStateFailed     = "FAILED"
+
+	PublishJobActionPublish  = "PUBLISH"
+	PublishJobActionWithdraw = "WITHDRAW"
+
+	// publishJobCheckpointSize is the number of files a batch works through between recording its position
+	publishJobCheckpointSize = 100
+
+	// PublishJobResumeResource is locked while unfinished publish jobs are looked for, so that instances of the service
+	// do not race each other to claim them
+	PublishJobResumeResource = "publish-job-resume"
+)
+
+// errPublishJobLeaseLost is returned by a batch whose job has been claimed by another instance of the service
+var errPublishJobLeaseLost = errors.New("publish job lease lost")
+
+// CreateCollectionPublishJob records a publish job covering every file in the collection, published now
+func (store *Store) CreateCollectionPublishJob(ctx context.Context, collectionID string) (*files.PublishJob, error) {
+	return store.createPublishJob(ctx, &files.PublishJob{CollectionID: &collectionID, Action: PublishJobActionPublish})
+}
+
+// CreateBundlePublishJob records a publish job covering every file in the bundle, published now
+func (store *Store) CreateBundlePublishJob(ctx context.Context, bundleID string) (*files.PublishJob, error) {
+	return store.createPublishJob(ctx, &files.PublishJob{BundleID: &bundleID, Action: PublishJobActionPublish})
+}
+
+func (store *Store) createPublishJob(ctx context.Context, job *files.PublishJob) (*files.PublishJob, error) {
+	logdata := publishJobLogData(job)
+
+	totalCount, err := store.repo.CountMetadata(ctx, publishJobFileFilter(job))
+	if err != nil {
+		log.Error(ctx, "create publish job: failed to count files", err, logdata)
+		return nil, err
+	}
+
+	// balance the number of batches Vs batch size
+	batchSize := store.cfg.MinBatchSize
+	numBatches := int(math.Ceil(float64(totalCount) / float64(batchSize)))
+	if numBatches > store.cfg.MaxNumBatches {
+		numBatches = store.cfg.MaxNumBatches
+		batchSize = int(math.Ceil(float64(totalCount) / float64(numBatches)))
+	}
+
+	now := store.clock.GetCurrentTime()
+	leaseExpiresAt := now.Add(store.cfg.PublishJobLeaseTTL)
+	job.ID = primitive.NewObjectID().Hex()
+	job.State = PublishJobStateInProgress
+	job.Owner = store.instanceID
+	job.LeaseExpiresAt = &leaseExpiresAt
+	job.TotalFiles = totalCount
+	job.CreatedAt = now
+	job.LastModified = now
+	if job.Action == PublishJobActionPublish {
+		job.PublishedAt = &now
+	}
+	job.Batches = make([]files.PublishJobBatch, 0, numBatches)
+	for i := 0; i < numBatches; i++ {
+		job.Batches = append(job.Batches, files.PublishJobBatch{Number: i, Offset: i * batchSize, Size: batchSize})
+	}
+
+	if err := store.repo.InsertPublishJob(ctx, job); err != nil {
+		log.Error(ctx, "create publish job: failed to insert job", err, logdata)
+		return nil, err
+	}
+
+	return job, nil
+}
+
+func (store *Store) deletePublishJob(ctx context.Context, job *files.PublishJob) {
+	if err := store.repo.DeletePublishJob(ctx, job.ID); err != nil {
+		log.Error(ctx, "failed to delete publish job", err, publishJobLogData(job))
+	}
+}
+
+// RunPublishJob writes a FilePublished message to the outbox for every file in the job's unfinished batches, or
+// withdraws them for a withdrawal job, working through the batches concurrently, and then records the final state of
+// the job. The job must be held by this instance of the service, and is left unfinished for another instance to resume
+// if its lease is lost.
+func (store *Store) RunPublishJob(ctx context.Context, job *files.PublishJob) {
+	logdata := publishJobLogData(job)
+	log.Info(ctx, "run publish job start", logdata)
+
+	// a withdrawal is recorded against whoever asked for it, however long after the job is run
+	if job.Actor != "" {
+		ctx = dprequest.SetCaller(ctx, job.Actor)
+	}
+
+	// the lease is renewed on a timer as well as at each checkpoint, so that it does not run out however long a batch
+	// takes to get from one checkpoint to the next, and the batches are stopped if the job is claimed by another instance
+	batchCtx, stopBatches := context.WithCancel(ctx)
+	leaseRenewed := make(chan struct{})
+	go func() {
+		defer close(leaseRenewed)
+		store.renewPublishJobLease(batchCtx, job, stopBatches)
+	}()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		sent     = job.Sent
+		failed   = job.Failed
+		batchErr error
+	)
+	for _, batch := range job.Batches {
+		if batch.Completed {
+			continue
+		}
+		wg.Add(1)
+		go func(batch files.PublishJobBatch) {
+			defer wg.Done()
+			s, f, err := store.runPublishJobBatch(batchCtx, job, batch)
+
+			mu.Lock()
+			defer mu.Unlock()
+			sent += s
+			failed += f
+			if err != nil {
+				batchErr = err
+			}
+		}(batch)
+	}
+	wg.Wait()
+	stopBatches()
+	<-leaseRenewed
+
+	if errors.Is(batchErr, errPublishJobLeaseLost) {
+		log.Warn(ctx, "run publish job stopped: job claimed by another instance", logdata)
+		return
+	}
+
+	state := PublishJobStateCompleted
+	if failed > 0 || batchErr != nil {
+		state = PublishJobStateFailed
+	}
+	store.completePublishJob(ctx, job, state)
+
+	logdata["sent"] = sent
+	logdata["failed"] = failed
+	logdata["state"] = state
+	log.Info(ctx, "run publish job end", logdata)
+}
+
+func (store *Store) runPublishJobBatch(ctx context.Context, job *files.PublishJob, batch files.PublishJobBatch) (sent, failed int, err error) {
+	ld := publishJobLogData(job)
+	ld["batch_num"] = batch.Number
+	ld["offset"] = batch.Offset
+	ld["batch_size"] = batch.Size
+	ld["position"] = batch.Position
+	ld["last_path"] = batch.LastPath
+	log.Info(ctx, "run publish job batch", ld)
+
+	// files are walked in path order, so a batch that has made a checkpoint carries on from the last path it handled
+	filter, offset := publishJobFileFilter(job), batch.Offset+batch.Position
+	if batch.LastPath != "" {
+		filter.PathAfter, offset = batch.LastPath, 0
+	}
+
+	cursor, err := store.repo.MetadataCursor(ctx, filter, offset)
+	if err != nil {
+		log.Error(ctx, "run publish job batch: failed to query files", err, ld)
+		return 0, 0, err
+	}
+	defer func() {
+		if err := cursor.Close(ctx); err != nil {
+			log.Error(ctx, "run publish job batch: failed to close cursor", err, ld)
+		}
+	}()
+
+	pendingSent, pendingFailed := 0, 0
+	checkpoint := func(completed bool) error {
+		err := store.checkpointPublishJobBatch(ctx, job, batch, pendingSent, pendingFailed, completed)
+		pendingSent, pendingFailed = 0, 0
+		return err
+	}
+
+	for batch.Position < batch.Size && ctx.Err() == nil && cursor.Next(ctx) {
+		m, err := cursor.Current()
+		if err != nil {
+			log.Error(ctx, "run publish job batch: failed to decode cursor", err, ld)
+			pendingFailed++
+			failed++
+		} else if err := store.runPublishJobFile(ctx, job, m); err != nil {
+			pendingFailed++
+			failed++
+		} else {
+			pendingSent++
+			sent++
+		}
+
+		if err == nil {
+			batch.LastPath = m.Path
+		}
+		batch.Position++
+		if batch.Position%publishJobCheckpointSize == 0 {
+			if err := checkpoint(false); errors.Is(err, errPublishJobLeaseLost) {
+				return sent, failed, err
+			}
+		}
+	}
+	if ctx.Err() != nil {
+		// the lease was lost, and the instance that claimed the job carries on from the last checkpoint
+		return sent, failed, errPublishJobLeaseLost
+	}
+	if err := cursor.Err(); err != nil {
+		log.Error(ctx, "run publish job batch: cursor error", err, ld)
+		if cpErr := checkpoint(false); errors.Is(cpErr, errPublishJobLeaseLost) {
+			return sent, failed, cpErr
+		}
+		return sent, failed, err
+	}
+	if err := checkpoint(true); errors.Is(err, errPublishJobLeaseLost) {
+		return sent, failed, err
+	}
+
+	log.Info(ctx, "run publish job batch end", ld)
+	return sent, failed, nil
+}
+
+// runPublishJobFile writes the FilePublished message for one file of a publish job, or withdraws it for a withdrawal job
+func (store *Store) runPublishJobFile(ctx context.Context, job *files.PublishJob, m files.StoredRegisteredMetaData) error {
+	if job.Action == PublishJobActionWithdraw {
+		return store.withdrawPublishedFile(ctx, m, job.Reason)
+	}
+
+	// the messages carry the time the collection or bundle was published rather than when they are written
+	if job.PublishedAt != nil {
+		m.PublishedAt = job.PublishedAt
+	}
+	ids, err := store.enqueueFilePublished(ctx, &m)
+	if err != nil {
+		log.Error(ctx, "run publish job batch: can't write message to outbox", err, log.Data{"metadata": m})
+		return err
+	}
+	// the collection or bundle has already been published
+	store.releaseOutboxMessages(ctx, ids)
+	store.recordFileChange(ctx, m, files.LifecycleStateChanged, StateUploaded, StatePublished)
+	return nil
+}
+
+// checkpointPublishJobBatch records how far through its files a batch has got, so that a resumed job carries on from
+// there, and renews this instance's lease on the job. It returns errPublishJobLeaseLost when the job has been claimed by
+// another instance; failing to record the checkpoint is only logged.
+func (store *Store) checkpointPublishJobBatch(ctx context.Context, job *files.PublishJob, batch files.PublishJobBatch, sent, failed int, completed bool) error {
+	now := store.clock.GetCurrentTime()
+	held, err := store.repo.CheckpointPublishJobBatch(ctx, job.ID, PublishJobCheckpoint{
+		Owner:          store.instanceID,
+		Batch:          batch.Number,
+		Position:       batch.Position,
+		LastPath:       batch.LastPath,
+		Sent:           sent,
+		Failed:         failed,
+		Completed:      completed,
+		LastModified:   now,
+		LeaseExpiresAt: now.Add(store.cfg.PublishJobLeaseTTL),
+	})
+	ld := publishJobLogData(job)
+	ld["batch_num"] = batch.Number
+	if err != nil {
+		log.Error(ctx, "failed to record publish job progress", err, ld)
+		return nil
+	}
+	if !held {
+		log.Error(ctx, "failed to record publish job progress", errPublishJobLeaseLost, ld)
+		return errPublishJobLeaseLost
+	}
+	return nil
+}
+
+// renewPublishJobLease renews this instance's lease on the job three times per lease TTL until ctx is done. A failure to
+// renew the lease is only logged, as the next renewal may succeed before it runs out, but once the job has been claimed
+// by another instance stop is called.
+func (store *Store) renewPublishJobLease(ctx context.Context, job *files.PublishJob, stop context.CancelFunc) {
+	interval := store.cfg.PublishJobLeaseTTL / 3
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			leaseExpiresAt := store.clock.GetCurrentTime().Add(store.cfg.PublishJobLeaseTTL)
+			held, err := store.repo.RenewPublishJobLease(ctx, job.ID, store.instanceID, leaseExpiresAt)
+			if err != nil {
+				log.Error(ctx, "failed to renew publish job lease", err, publishJobLogData(job))
+				continue
+			}
+			if !held {
+				log.Error(ctx, "failed to renew publish job lease", errPublishJobLeaseLost, publishJobLogData(job))
+				stop()
+				return
+			}
+		}
+	}
+}
+
+func (store *Store) completePublishJob(ctx context.Context, job *files.PublishJob, state string) {
+	err := store.repo.CompletePublishJob(ctx, job.ID, store.instanceID, state, store.clock.GetCurrentTime())
+	if err != nil {
+		log.Error(ctx, "failed to record publish job completion", err, publishJobLogData(job))
+	}
+}
+
+// ResumePublishJobs runs the unfinished publish jobs whose lease has expired, such as those left by an instance of the
+// service that stopped while running them. Each job is claimed first, so that only one instance resumes it. Files a
+// job had written to the outbox since its last checkpoint are written again, which at-least-once delivery allows for.
+// A job is recorded before its collection or bundle is published or withdrawn, so a job whose collection or bundle is
+// not in that state was left by an instance that stopped in between, and is deleted rather than run.
+func (store *Store) ResumePublishJobs(ctx context.Context) error {
+	jobs, err := store.repo.FindPublishJobs(ctx, PublishJobStateInProgress)
+	if err != nil {
+		log.Error(ctx, "failed to find unfinished publish jobs", err)
+		return err
+	}
+
+	var lastErr error
+	for i := range jobs {
+		job := &jobs[i]
+		now := store.clock.GetCurrentTime()
+		claimed, err := store.repo.ClaimPublishJob(ctx, job.ID, store.instanceID, now, now.Add(store.cfg.PublishJobLeaseTTL))
+		if err != nil {
+			log.Error(ctx, "failed to claim unfinished publish job", err, publishJobLogData(job))
+			lastErr = err
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		started, err := store.isPublishJobStarted(ctx, job)
+		if err != nil {
+			log.Error(ctx, "failed to check unfinished publish job was started", err, publishJobLogData(job))
+			lastErr = err
+			continue
+		}
+		if !started {
+			log.Warn(ctx, "deleting publish job for a collection or bundle that was not published or withdrawn", publishJobLogData(job))
+			store.deletePublishJob(ctx, job)
+			continue
+		}
+
+		log.Info(ctx, "resuming publish job", publishJobLogData(job))
+		go store.RunPublishJob(ctx, job)
+	}
+	return lastErr
+}
+
+// isPublishJobStarted reports whether the collection or bundle of the job has been published, or withdrawn for a
+// withdrawal job
+func (store *Store) isPublishJobStarted(ctx context.Context, job *files.PublishJob) (bool, error) {
+	var (
+		state string
+		err   error
+	)
+	if job.BundleID != nil {
+		state, err = store.bundleState(ctx, *job.BundleID)
+	} else {
+		state, err = store.collectionState(ctx, *job.CollectionID)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if job.Action == PublishJobActionWithdraw {
+		return state == StateWithdrawn, nil
+	}
+	return state == StatePublished, nil
+}
+
+// GetCollectionPublishJob returns the most recent publish job for the collection
+func (store *Store) GetCollectionPublishJob(ctx context.Context, collectionID string) (files.PublishJob, error) {
+	return store.getLatestPublishJob(ctx, collectionID, "")
+}
+
+// GetBundlePublishJob returns the most recent publish job for the bundle
+func (store *Store) GetBundlePublishJob(ctx context.Context, bundleID string) (files.PublishJob, error) {
+	return store.getLatestPublishJob(ctx, "", bundleID)
+}
+
+func (store *Store) getLatestPublishJob(ctx context.Context, collectionID, bundleID string) (files.PublishJob, error) {
+	job, err := store.repo.GetLatestPublishJob(ctx, collectionID, bundleID)
+	if err != nil {
+		if errors.Is(err, mongodriver.ErrNoDocumentFound) {
+			return files.PublishJob{}, ErrPublishJobNotFound
+		}
+		log.Error(ctx, "failed to find publish job", err, log.Data{"collection_id": collectionID, "bundle_id": bundleID})
+		return files.PublishJob{}, err
+	}
+
+	return job, nil
+}
+
+func publishJobFileFilter(job *files.PublishJob) MetadataFilter {
+	if job.BundleID != nil {
+		return MetadataFilter{BundleID: job.BundleID}
+	}
+	return MetadataFilter{CollectionID: job.CollectionID}
+}
+
+func publishJobLogData(job *files.PublishJob) log.Data {
+	logdata := log.Data{"job_id": job.ID}
+	if job.Action != "" {
+		logdata["action"] = job.Action
+	}
+	if job.BundleID != nil {
+		logdata["bundle_id"] = *job.BundleID
+	}
+	if job.CollectionID != nil {
+		logdata["collection_id"] = *job.CollectionID
+	}
+	return logdata
+}
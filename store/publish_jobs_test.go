@@ -0,0 +1,639 @@
+package store_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ONSdigital/dp-files-api/config"
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/dp-files-api/mongo/mock"
+	"github.com/ONSdigital/dp-files-api/store"
+	mongodriver "github.com/ONSdigital/dp-mongodb/v3/mongodb"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func (suite *StoreSuite) publishJob(numFiles int) *files.PublishJob {
+	return &files.PublishJob{
+		ID:           "job-1",
+		CollectionID: &suite.defaultCollectionID,
+		State:        store.PublishJobStateInProgress,
+		TotalFiles:   numFiles,
+		Batches:      []files.PublishJobBatch{{Number: 0, Offset: 0, Size: numFiles}},
+	}
+}
+
+func (suite *StoreSuite) metadataCursor(numFiles int) *mock.MongoCursorMock {
+	metadata := suite.generateCollectionMetadata(suite.defaultCollectionID)
+	metadataBytes, _ := bson.Marshal(metadata)
+
+	return &mock.MongoCursorMock{
+		CloseFunc: func(ctx context.Context) error { return nil },
+		NextFunc:  CursorReturnsNumberOfNext(numFiles),
+		DecodeFunc: func(val interface{}) error {
+			return bson.Unmarshal(metadataBytes, val)
+		},
+		ErrFunc: func() error { return nil },
+	}
+}
+
+func publishJobStateUpdate(calls []struct {
+	Ctx      context.Context
+	Selector interface{}
+	Update   interface{}
+}) string {
+	last := calls[len(calls)-1].Update.(bson.D)
+	for _, e := range last[0].Value.(bson.D) {
+		if e.Key == "state" {
+			return e.Value.(string)
+		}
+	}
+	return ""
+}
+
+func (suite *StoreSuite) TestCreateCollectionPublishJobSplitsFilesIntoBatches() {
+	cfg, _ := config.Get()
+	numFiles := 5000
+
+	collection := mock.MongoCollectionMock{
+		CountFunc: CollectionCountReturnsValueAndNil(numFiles),
+	}
+
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
+
+	job, err := subject.CreateCollectionPublishJob(suite.defaultContext, suite.defaultCollectionID)
+
+	suite.NoError(err)
+	suite.Equal(suite.defaultCollectionID, *job.CollectionID)
+	suite.Equal(store.PublishJobStateInProgress, job.State)
+	suite.Equal(numFiles, job.TotalFiles)
+	suite.NotEmpty(job.Owner)
+	suite.Equal(suite.defaultClock.GetCurrentTime(), *job.PublishedAt)
+	suite.Equal(suite.defaultClock.GetCurrentTime().Add(cfg.PublishJobLeaseTTL), *job.LeaseExpiresAt)
+	suite.Len(job.Batches, cfg.MaxNumBatches)
+	suite.Equal(files.PublishJobBatch{Number: 1, Offset: 1000, Size: 1000}, job.Batches[1])
+	suite.Equal(bson.M{"collection_id": suite.defaultCollectionID}, collection.CountCalls()[0].Filter)
+	suite.Equal(job, suite.defaultPublishJobsCollection.InsertCalls()[0].Document)
+}
+
+func (suite *StoreSuite) TestCreateBundlePublishJobUsesMinimumBatchSize() {
+	cfg, _ := config.Get()
+
+	collection := mock.MongoCollectionMock{
+		CountFunc: CollectionCountReturnsValueAndNil(cfg.MinBatchSize + 1),
+	}
+
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
+
+	job, err := subject.CreateBundlePublishJob(suite.defaultContext, suite.defaultBundleID)
+
+	suite.NoError(err)
+	suite.Equal(suite.defaultBundleID, *job.BundleID)
+	suite.Len(job.Batches, 2)
+	suite.Equal(cfg.MinBatchSize, job.Batches[1].Offset)
+	suite.Equal(bson.M{"bundle_id": suite.defaultBundleID}, collection.CountCalls()[0].Filter)
+}
+
+func (suite *StoreSuite) TestCreatePublishJobInsertReturnsError() {
+	expectedError := errors.New("insert failed")
+
+	collection := mock.MongoCollectionMock{
+		CountFunc: CollectionCountReturnsValueAndNil(1),
+	}
+	publishJobs := mock.MongoCollectionMock{
+		InsertFunc: CollectionInsertReturnsNilAndError(expectedError),
+	}
+
+	cfg, _ := config.Get()
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &publishJobs, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
+
+	job, err := subject.CreateCollectionPublishJob(suite.defaultContext, suite.defaultCollectionID)
+
+	suite.Nil(job)
+	suite.ErrorIs(err, expectedError)
+}
+
+func (suite *StoreSuite) TestRunPublishJobWritesEveryFileToOutbox() {
+	numFiles := 250
+	metadata := suite.generateCollectionMetadata(suite.defaultCollectionID)
+	cursor := suite.metadataCursor(numFiles)
+
+	collection := mock.MongoCollectionMock{
+		FindCursorFunc: CollectionFindCursorReturnsCursorAndError(cursor, nil),
+	}
+
+	cfg, _ := config.Get()
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
+
+	subject.RunPublishJob(suite.defaultContext, suite.publishJob(numFiles))
+
+	suite.Len(suite.defaultOutboxCollection.InsertCalls(), numFiles)
+	for _, call := range suite.defaultOutboxCollection.InsertCalls() {
+		filePublished := call.Document.(files.OutboxMessage).FilePublished
+		suite.Equal(metadata.Path, filePublished.Path)
+		suite.Equal(metadata.Etag, filePublished.Etag)
+		suite.Equal(metadata.Type, filePublished.Type)
+		suite.Equal(strconv.FormatUint(metadata.SizeInBytes, 10), filePublished.SizeInBytes)
+	}
+	suite.Equal(1, len(cursor.CloseCalls()))
+	suite.Len(suite.defaultFileChangesCollection.InsertCalls(), numFiles)
+	suite.Equal(store.StatePublished, suite.defaultFileChangesCollection.InsertCalls()[0].Document.(files.FileChange).ToState)
+
+	// two checkpoints part way through, one when the batch completes and one for the final state
+	updates := suite.defaultPublishJobsCollection.UpdateCalls()
+	suite.Len(updates, 4)
+	suite.Equal("job-1", updates[0].Selector.(bson.M)["id"])
+	suite.NotEmpty(updates[0].Selector.(bson.M)["owner"])
+	suite.Equal(bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "batches.0.position", Value: 250},
+			{Key: "batches.0.last_path", Value: metadata.Path},
+			{Key: "batches.0.completed", Value: true},
+			{Key: "last_modified", Value: suite.defaultClock.GetCurrentTime()},
+			{Key: "lease_expires_at", Value: suite.defaultClock.GetCurrentTime().Add(cfg.PublishJobLeaseTTL)}},
+		},
+		{Key: "$inc", Value: bson.D{
+			{Key: "batches.0.sent", Value: 50},
+			{Key: "batches.0.failed", Value: 0},
+			{Key: "sent", Value: 50},
+			{Key: "failed", Value: 0}},
+		},
+	}, updates[2].Update)
+	suite.Equal(store.PublishJobStateCompleted, publishJobStateUpdate(updates))
+}
+
+func (suite *StoreSuite) TestRunPublishJobResumesFromBatchPosition() {
+	job := suite.publishJob(200)
+	job.Batches = []files.PublishJobBatch{
+		{Number: 0, Offset: 0, Size: 100, Position: 100, Sent: 100, Completed: true},
+		{Number: 1, Offset: 100, Size: 100, Position: 40, Sent: 40},
+	}
+	cursor := suite.metadataCursor(60)
+
+	collection := mock.MongoCollectionMock{
+		FindCursorFunc: CollectionFindCursorReturnsCursorAndError(cursor, nil),
+	}
+
+	cfg, _ := config.Get()
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
+
+	subject.RunPublishJob(suite.defaultContext, job)
+
+	suite.Require().Len(collection.FindCursorCalls(), 1)
+	suite.Equal(bson.M{"collection_id": suite.defaultCollectionID}, collection.FindCursorCalls()[0].Filter)
+	suite.Len(collection.FindCursorCalls()[0].Opts, 2)
+	suite.Len(suite.defaultOutboxCollection.InsertCalls(), 60)
+	suite.Equal(store.PublishJobStateCompleted, publishJobStateUpdate(suite.defaultPublishJobsCollection.UpdateCalls()))
+}
+
+func (suite *StoreSuite) TestRunPublishJobResumesAfterBatchLastPath() {
+	job := suite.publishJob(200)
+	job.Batches = []files.PublishJobBatch{
+		{Number: 0, Offset: 0, Size: 200, Position: 140, LastPath: "files/140.csv", Sent: 140},
+	}
+	cursor := suite.metadataCursor(60)
+
+	collection := mock.MongoCollectionMock{
+		FindCursorFunc: CollectionFindCursorReturnsCursorAndError(cursor, nil),
+	}
+
+	cfg, _ := config.Get()
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
+
+	subject.RunPublishJob(suite.defaultContext, job)
+
+	suite.Require().Len(collection.FindCursorCalls(), 1)
+	suite.Equal(bson.M{"$and": []bson.M{
+		{"collection_id": suite.defaultCollectionID},
+		{"path": bson.M{"$gt": "files/140.csv"}},
+	}}, collection.FindCursorCalls()[0].Filter)
+	suite.Len(suite.defaultOutboxCollection.InsertCalls(), 60)
+	suite.Equal(store.PublishJobStateCompleted, publishJobStateUpdate(suite.defaultPublishJobsCollection.UpdateCalls()))
+}
+
+func (suite *StoreSuite) TestRunPublishJobStopsWhenLeaseIsLost() {
+	cursor := suite.metadataCursor(250)
+
+	collection := mock.MongoCollectionMock{
+		FindCursorFunc: CollectionFindCursorReturnsCursorAndError(cursor, nil),
+	}
+	publishJobs := mock.MongoCollectionMock{
+		UpdateFunc: CollectionUpdateReturnsMatchedCountAndNil(0),
+	}
+
+	cfg, _ := config.Get()
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &publishJobs, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
+
+	subject.RunPublishJob(suite.defaultContext, suite.publishJob(250))
+
+	// the first checkpoint finds the job claimed by another instance, which carries on from there
+	suite.Len(suite.defaultOutboxCollection.InsertCalls(), 100)
+	suite.Len(publishJobs.UpdateCalls(), 1)
+	suite.Empty(publishJobStateUpdate(publishJobs.UpdateCalls()))
+}
+
+// slowMetadataCursor is a metadata cursor that takes a while to move to each file
+func (suite *StoreSuite) slowMetadataCursor(numFiles int, delay time.Duration) *mock.MongoCursorMock {
+	cursor := suite.metadataCursor(numFiles)
+	next := cursor.NextFunc
+	cursor.NextFunc = func(ctx context.Context) bool {
+		time.Sleep(delay)
+		return next(ctx)
+	}
+	return cursor
+}
+
+func (suite *StoreSuite) TestRunPublishJobRenewsLeaseBetweenCheckpoints() {
+	cursor := suite.slowMetadataCursor(10, 10*time.Millisecond)
+
+	collection := mock.MongoCollectionMock{
+		FindCursorFunc: CollectionFindCursorReturnsCursorAndError(cursor, nil),
+	}
+	publishJobs := mock.MongoCollectionMock{
+		UpdateFunc: CollectionUpdateReturnsMatchedCountAndNil(1),
+	}
+
+	cfg, _ := config.Get()
+	c := *cfg
+	c.PublishJobLeaseTTL = 30 * time.Millisecond
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &publishJobs, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, &c)
+
+	subject.RunPublishJob(suite.defaultContext, suite.publishJob(10))
+
+	// the batch takes longer than the lease to reach its only checkpoint, so the lease is renewed on the way
+	updates := publishJobs.UpdateCalls()
+	suite.Greater(len(updates), 2)
+	suite.Equal(bson.D{{Key: "$set", Value: bson.D{
+		{Key: "lease_expires_at", Value: suite.defaultClock.GetCurrentTime().Add(c.PublishJobLeaseTTL)},
+	}}}, updates[0].Update)
+	suite.Equal("job-1", updates[0].Selector.(bson.M)["id"])
+	suite.NotEmpty(updates[0].Selector.(bson.M)["owner"])
+	suite.Len(suite.defaultOutboxCollection.InsertCalls(), 10)
+	suite.Equal(store.PublishJobStateCompleted, publishJobStateUpdate(updates))
+}
+
+func (suite *StoreSuite) TestRunPublishJobStopsWhenLeaseRenewalFindsJobClaimed() {
+	cursor := suite.slowMetadataCursor(20, 10*time.Millisecond)
+
+	collection := mock.MongoCollectionMock{
+		FindCursorFunc: CollectionFindCursorReturnsCursorAndError(cursor, nil),
+	}
+	publishJobs := mock.MongoCollectionMock{
+		UpdateFunc: CollectionUpdateReturnsMatchedCountAndNil(0),
+	}
+
+	cfg, _ := config.Get()
+	c := *cfg
+	c.PublishJobLeaseTTL = 30 * time.Millisecond
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &publishJobs, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, &c)
+
+	subject.RunPublishJob(suite.defaultContext, suite.publishJob(20))
+
+	// the batch stops before its checkpoint, leaving the job to the instance that claimed it
+	suite.Less(len(suite.defaultOutboxCollection.InsertCalls()), 20)
+	suite.Len(publishJobs.UpdateCalls(), 1)
+	suite.Empty(publishJobStateUpdate(publishJobs.UpdateCalls()))
+}
+
+func (suite *StoreSuite) TestRunPublishJobMessagesCarryPublishTime() {
+	publishedAt := suite.defaultClock.GetCurrentTime().Add(-time.Hour)
+	job := suite.publishJob(1)
+	job.PublishedAt = &publishedAt
+
+	collection := mock.MongoCollectionMock{
+		FindCursorFunc: CollectionFindCursorReturnsCursorAndError(suite.metadataCursor(1), nil),
+	}
+
+	cfg, _ := config.Get()
+	c := *cfg
+	c.FilePublishedV3Enabled = true
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, &c)
+
+	subject.RunPublishJob(suite.defaultContext, job)
+
+	inserts := suite.defaultOutboxCollection.InsertCalls()
+	suite.Require().Len(inserts, 2)
+	suite.Equal(publishedAt.UnixMilli(), inserts[1].Document.(files.OutboxMessage).FilePublishedV3.PublishedAt)
+}
+
+func (suite *StoreSuite) TestRunPublishJobOutboxErrorFailsJob() {
+	cursor := suite.metadataCursor(5)
+
+	collection := mock.MongoCollectionMock{
+		FindCursorFunc: CollectionFindCursorReturnsCursorAndError(cursor, nil),
+	}
+	outboxCollection := mock.MongoCollectionMock{
+		InsertFunc: CollectionInsertReturnsNilAndError(errors.New("an error occurred writing to the outbox")),
+	}
+
+	cfg, _ := config.Get()
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collection, Outbox: &outboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
+
+	subject.RunPublishJob(suite.defaultContext, suite.publishJob(5))
+
+	suite.Equal(5, len(cursor.NextCalls()))
+	suite.Equal(5, len(outboxCollection.InsertCalls()))
+
+	updates := suite.defaultPublishJobsCollection.UpdateCalls()
+	suite.Contains(updates[0].Update.(bson.D)[1].Value, bson.E{Key: "failed", Value: 5})
+	suite.Equal(store.PublishJobStateFailed, publishJobStateUpdate(updates))
+}
+
+func (suite *StoreSuite) TestRunPublishJobDecodeErrorFailsJob() {
+	cursor := suite.metadataCursor(5)
+	cursor.DecodeFunc = func(val interface{}) error {
+		return errors.New("decode error")
+	}
+
+	collection := mock.MongoCollectionMock{
+		FindCursorFunc: CollectionFindCursorReturnsCursorAndError(cursor, nil),
+	}
+
+	cfg, _ := config.Get()
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
+
+	subject.RunPublishJob(suite.defaultContext, suite.publishJob(5))
+
+	suite.Equal(5, len(cursor.DecodeCalls()))
+	suite.Equal(0, len(suite.defaultOutboxCollection.InsertCalls()))
+	suite.Equal(store.PublishJobStateFailed, publishJobStateUpdate(suite.defaultPublishJobsCollection.UpdateCalls()))
+}
+
+func (suite *StoreSuite) TestRunPublishJobFindErrorFailsJob() {
+	collection := mock.MongoCollectionMock{
+		FindCursorFunc: CollectionFindCursorReturnsCursorAndError(nil, errors.New("an error occurred")),
+	}
+
+	cfg, _ := config.Get()
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
+
+	subject.RunPublishJob(suite.defaultContext, suite.publishJob(5))
+
+	updates := suite.defaultPublishJobsCollection.UpdateCalls()
+	suite.Len(updates, 1)
+	suite.Equal(store.PublishJobStateFailed, publishJobStateUpdate(updates))
+}
+
+// publishableCollection returns metadata and collections mocks for a collection whose files are all uploaded, so that it
+// can be marked as published and have its publish job run from the given cursor
+func (suite *StoreSuite) publishableCollection(findCursor CollectionFindCursorFunc) (*mock.MongoCollectionMock, *mock.MongoCollectionMock) {
+	metadataColl := &mock.MongoCollectionMock{
+		FindOneFunc: CollectionFindOneChain([]CollectionFindOneFuncChainEntry{
+			{CollectionFindOneSucceeds(), 1},                                   // there are some files in the collection
+			{CollectionFindOneReturnsError(mongodriver.ErrNoDocumentFound), 2}, // all of them are UPLOADED
+		}),
+		CountFunc:      CollectionCountReturnsOneNilWhenFilterContainsAndOrZeroNilWithout(),
+		FindCursorFunc: findCursor,
+	}
+	collectionColl := &mock.MongoCollectionMock{
+		UpsertFunc: func(ctx context.Context, selector, update interface{}) (*mongodriver.CollectionUpdateResult, error) {
+			return nil, nil
+		},
+		FindOneFunc: CollectionFindOneSucceeds(), // collection is not PUBLISHED
+	}
+	return metadataColl, collectionColl
+}
+
+func (suite *StoreSuite) TestNotifyCollectionPublishedFindErrored() {
+	metadataColl, collectionColl := suite.publishableCollection(CollectionFindCursorReturnsCursorAndError(nil, errors.New("an error occurred")))
+	publishJobs := mock.MongoCollectionMock{
+		InsertFunc: CollectionInsertReturnsNilAndNil(),
+		UpdateFunc: CollectionUpdateReturnsMatchedCountAndNil(1),
+	}
+
+	cfg, _ := config.Get()
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: metadataColl, Collections: collectionColl, Outbox: &suite.defaultOutboxCollection, PublishJobs: &publishJobs, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
+
+	err := subject.MarkCollectionPublished(suite.defaultContext, suite.defaultCollectionID)
+
+	suite.NoError(err)
+	suite.Eventually(func() bool {
+		return len(publishJobs.UpdateCalls()) == 1
+	}, time.Second, 10*time.Millisecond)
+	suite.Len(metadataColl.FindCursorCalls(), 1)
+	suite.Empty(suite.defaultOutboxCollection.InsertCalls())
+	suite.Equal(store.PublishJobStateFailed, publishJobStateUpdate(publishJobs.UpdateCalls()))
+}
+
+func (suite *StoreSuite) TestNotifyCollectionPublishedKafkaErrorDoesNotFailOperation() {
+	cursor := suite.metadataCursor(5)
+	metadataColl, collectionColl := suite.publishableCollection(CollectionFindCursorReturnsCursorAndError(cursor, nil))
+	outboxCollection := mock.MongoCollectionMock{
+		InsertFunc: CollectionInsertReturnsNilAndError(errors.New("an error occurred writing to the outbox")),
+	}
+	publishJobs := mock.MongoCollectionMock{
+		InsertFunc: CollectionInsertReturnsNilAndNil(),
+		UpdateFunc: CollectionUpdateReturnsMatchedCountAndNil(1),
+	}
+
+	cfg, _ := config.Get()
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: metadataColl, Collections: collectionColl, Outbox: &outboxCollection, PublishJobs: &publishJobs, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
+
+	err := subject.MarkCollectionPublished(suite.defaultContext, suite.defaultCollectionID)
+
+	suite.NoError(err)
+	suite.Eventually(func() bool {
+		return len(cursor.CloseCalls()) == 1 && len(publishJobs.UpdateCalls()) == 2
+	}, time.Second, 10*time.Millisecond)
+	suite.Equal(6, len(cursor.NextCalls()))
+	suite.Equal(5, len(cursor.DecodeCalls()))
+	suite.Equal(5, len(outboxCollection.InsertCalls()))
+	suite.Equal(1, len(cursor.ErrCalls()))
+	suite.Empty(suite.defaultFileChangesCollection.InsertCalls())
+	suite.Equal(store.PublishJobStateFailed, publishJobStateUpdate(publishJobs.UpdateCalls()))
+}
+
+func (suite *StoreSuite) TestNotifyCollectionPublishedDecodeErrorDoesNotFailOperation() {
+	cursor := suite.metadataCursor(5)
+	cursor.DecodeFunc = func(val interface{}) error {
+		return errors.New("decode error")
+	}
+	metadataColl, collectionColl := suite.publishableCollection(CollectionFindCursorReturnsCursorAndError(cursor, nil))
+	publishJobs := mock.MongoCollectionMock{
+		InsertFunc: CollectionInsertReturnsNilAndNil(),
+		UpdateFunc: CollectionUpdateReturnsMatchedCountAndNil(1),
+	}
+
+	cfg, _ := config.Get()
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: metadataColl, Collections: collectionColl, Outbox: &suite.defaultOutboxCollection, PublishJobs: &publishJobs, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
+
+	err := subject.MarkCollectionPublished(suite.defaultContext, suite.defaultCollectionID)
+
+	suite.NoError(err)
+	suite.Eventually(func() bool {
+		return len(cursor.CloseCalls()) == 1 && len(publishJobs.UpdateCalls()) == 2
+	}, time.Second, 10*time.Millisecond)
+	suite.Equal(6, len(cursor.NextCalls()))
+	suite.Equal(5, len(cursor.DecodeCalls()))
+	suite.Equal(0, len(suite.defaultOutboxCollection.InsertCalls()))
+	suite.Equal(1, len(cursor.ErrCalls()))
+	suite.Equal(store.PublishJobStateFailed, publishJobStateUpdate(publishJobs.UpdateCalls()))
+}
+
+func (suite *StoreSuite) TestBatchingWithLargeNumberOfFilesCollection() {
+	suite.logInterceptor.Start()
+	defer suite.logInterceptor.Stop()
+	numFiles := 5000
+	cfg, _ := config.Get()
+	expectedBatchSize := int(math.Ceil(float64(numFiles) / float64(cfg.MaxNumBatches)))
+
+	// every batch queries its own page of files
+	var (
+		mu      sync.Mutex
+		cursors []*mock.MongoCursorMock
+	)
+	collection := mock.MongoCollectionMock{
+		CountFunc: CollectionCountReturnsValueAndNil(numFiles),
+		FindCursorFunc: func(ctx context.Context, filter interface{}, opts ...mongodriver.FindOption) (mongodriver.Cursor, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			cursor := suite.metadataCursor(expectedBatchSize)
+			cursors = append(cursors, cursor)
+			return cursor, nil
+		},
+	}
+
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
+
+	job, err := subject.CreateCollectionPublishJob(suite.defaultContext, suite.defaultCollectionID)
+	suite.Require().NoError(err)
+	subject.RunPublishJob(suite.defaultContext, job)
+
+	logEvents := suite.logInterceptor.GetLogEvents("run publish job batch")
+	suite.Len(logEvents, cfg.MaxNumBatches)
+	for _, logEvent := range logEvents {
+		suite.Equal(float64(expectedBatchSize), logEvent["batch_size"])
+	}
+
+	suite.Len(suite.defaultOutboxCollection.InsertCalls(), numFiles)
+	suite.Len(collection.FindCursorCalls(), cfg.MaxNumBatches)
+	suite.Len(cursors, cfg.MaxNumBatches)
+	for _, cursor := range cursors {
+		suite.Len(cursor.DecodeCalls(), expectedBatchSize)
+		suite.Len(cursor.ErrCalls(), 1)
+		suite.Len(cursor.CloseCalls(), 1)
+	}
+	suite.Equal(store.PublishJobStateCompleted, publishJobStateUpdate(suite.defaultPublishJobsCollection.UpdateCalls()))
+}
+
+func (suite *StoreSuite) TestResumePublishJobsRunsUnfinishedJobs() {
+	job := suite.publishJob(3)
+	cursor := suite.metadataCursor(3)
+
+	collection := mock.MongoCollectionMock{
+		FindCursorFunc: CollectionFindCursorReturnsCursorAndError(cursor, nil),
+	}
+	publishJobs := mock.MongoCollectionMock{
+		FindFunc: func(ctx context.Context, filter interface{}, results interface{}, opts ...mongodriver.FindOption) (int, error) {
+			*results.(*[]files.PublishJob) = []files.PublishJob{*job}
+			return 1, nil
+		},
+		UpdateFunc: CollectionUpdateReturnsMatchedCountAndNil(1),
+	}
+	publishedCollection, _ := bson.Marshal(files.StoredCollection{ID: suite.defaultCollectionID, State: store.StatePublished})
+	collections := mock.MongoCollectionMock{
+		FindOneFunc: CollectionFindOneSetsResultAndReturnsNil(publishedCollection),
+	}
+
+	cfg, _ := config.Get()
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collection, Collections: &collections, Outbox: &suite.defaultOutboxCollection, PublishJobs: &publishJobs, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
+
+	suite.NoError(subject.ResumePublishJobs(suite.defaultContext))
+
+	suite.Equal(bson.M{"state": store.PublishJobStateInProgress}, publishJobs.FindCalls()[0].Filter)
+	// the job is claimed, checkpointed once and completed
+	suite.Eventually(func() bool {
+		return len(publishJobs.UpdateCalls()) == 3
+	}, time.Second, 10*time.Millisecond)
+	claim := publishJobs.UpdateCalls()[0]
+	suite.Equal("job-1", claim.Selector.(bson.M)["id"])
+	suite.Equal(store.PublishJobStateInProgress, claim.Selector.(bson.M)["state"])
+	suite.Len(suite.defaultOutboxCollection.InsertCalls(), 3)
+}
+
+func (suite *StoreSuite) TestResumePublishJobsDeletesJobForUnpublishedCollection() {
+	job := suite.publishJob(3)
+
+	collection := mock.MongoCollectionMock{}
+	publishJobs := mock.MongoCollectionMock{
+		FindFunc: func(ctx context.Context, filter interface{}, results interface{}, opts ...mongodriver.FindOption) (int, error) {
+			*results.(*[]files.PublishJob) = []files.PublishJob{*job}
+			return 1, nil
+		},
+		UpdateFunc: CollectionUpdateReturnsMatchedCountAndNil(1),
+		DeleteFunc: CollectionDeleteReturnsNilAndNil(),
+	}
+	// the service stopped after recording the job but before the collection was published
+	createdCollection, _ := bson.Marshal(files.StoredCollection{ID: suite.defaultCollectionID, State: store.StateCreated})
+	collections := mock.MongoCollectionMock{
+		FindOneFunc: CollectionFindOneSetsResultAndReturnsNil(createdCollection),
+	}
+
+	cfg, _ := config.Get()
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collection, Collections: &collections, Outbox: &suite.defaultOutboxCollection, PublishJobs: &publishJobs, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
+
+	suite.NoError(subject.ResumePublishJobs(suite.defaultContext))
+
+	suite.Len(publishJobs.UpdateCalls(), 1, "the job is claimed and not run")
+	suite.Require().Len(publishJobs.DeleteCalls(), 1)
+	suite.Equal(bson.M{"id": "job-1"}, publishJobs.DeleteCalls()[0].Selector)
+	suite.Empty(collection.FindCursorCalls())
+	suite.Empty(suite.defaultOutboxCollection.InsertCalls())
+}
+
+func (suite *StoreSuite) TestResumePublishJobsSkipsJobsHeldByAnotherInstance() {
+	job := suite.publishJob(3)
+
+	collection := mock.MongoCollectionMock{}
+	publishJobs := mock.MongoCollectionMock{
+		FindFunc: func(ctx context.Context, filter interface{}, results interface{}, opts ...mongodriver.FindOption) (int, error) {
+			*results.(*[]files.PublishJob) = []files.PublishJob{*job}
+			return 1, nil
+		},
+		UpdateFunc: CollectionUpdateReturnsMatchedCountAndNil(0),
+	}
+
+	cfg, _ := config.Get()
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &publishJobs, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
+
+	suite.NoError(subject.ResumePublishJobs(suite.defaultContext))
+
+	suite.Len(publishJobs.UpdateCalls(), 1)
+	suite.Empty(collection.FindCursorCalls())
+}
+
+func (suite *StoreSuite) TestGetCollectionPublishJob() {
+	expected := suite.publishJob(5)
+	jobBytes, _ := bson.Marshal(expected)
+
+	publishJobs := mock.MongoCollectionMock{
+		FindOneFunc: CollectionFindOneSetsResultAndReturnsNil(jobBytes),
+	}
+
+	cfg, _ := config.Get()
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Outbox: &suite.defaultOutboxCollection, PublishJobs: &publishJobs, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
+
+	job, err := subject.GetCollectionPublishJob(suite.defaultContext, suite.defaultCollectionID)
+
+	suite.NoError(err)
+	suite.Equal(expected.ID, job.ID)
+	suite.Equal(expected.Batches, job.Batches)
+	suite.Equal(bson.M{"collection_id": suite.defaultCollectionID}, publishJobs.FindOneCalls()[0].Filter)
+}
+
+func (suite *StoreSuite) TestGetBundlePublishJobNotFound() {
+	publishJobs := mock.MongoCollectionMock{
+		FindOneFunc: CollectionFindOneReturnsError(mongodriver.ErrNoDocumentFound),
+	}
+
+	cfg, _ := config.Get()
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Outbox: &suite.defaultOutboxCollection, PublishJobs: &publishJobs, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
+
+	_, err := subject.GetBundlePublishJob(suite.defaultContext, suite.defaultBundleID)
+
+	suite.ErrorIs(err, store.ErrPublishJobNotFound)
+	suite.Equal(bson.M{"bundle_id": suite.defaultBundleID}, publishJobs.FindOneCalls()[0].Filter)
+}
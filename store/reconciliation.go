@@ -0,0 +1,311 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ONSdigital/dp-files-api/aws"
+	"github.com/ONSdigital/dp-files-api/files"
+	mongodriver "github.com/ONSdigital/dp-mongodb/v3/mongodb"
+	"github.com/ONSdigital/dp-net/v3/request"
+	"github.com/ONSdigital/log.go/v2/log"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// ReconciliationResource is locked while a reconciliation runs, so that only one runs at a time
+	ReconciliationResource = "reconciliation"
+
+	ReconciliationStateInProgress = "IN_PROGRESS"
+	ReconciliationStateCompleted  = "COMPLETED"
+	ReconciliationStateFailed     = "FAILED"
+
+	// maxReconciliationItems is the most of each kind of drift listed in a reconciliation report
+	maxReconciliationItems = 1000
+)
+
+// StartReconciliation takes the lock on reconciling and reconciles in the background, returning the report as it is
+// first stored, IN_PROGRESS. It returns ErrReconciliationInProgress while another reconciliation holds the lock.
+func (store *Store) StartReconciliation(ctx context.Context) (*files.ReconciliationReport, error) {
+	lockID := primitive.NewObjectID().Hex()
+	err := store.repo.LockResource(ctx, ReconciliationResource, lockID, store.cfg.ReconciliationLockTTL)
+	if errors.Is(err, ErrResourceLocked) {
+		return nil, ErrReconciliationInProgress
+	}
+	if err != nil {
+		log.Error(ctx, "start reconciliation: failed to lock", err)
+		return nil, err
+	}
+
+	report, err := store.startReconciliationReport(ctx)
+	if err != nil {
+		store.unlockReconciliation(ctx, lockID)
+		return nil, err
+	}
+	started := *report
+
+	requestID := request.GetRequestId(ctx)
+	newCtx := request.WithRequestId(context.Background(), requestID)
+	go func() {
+		defer store.unlockReconciliation(newCtx, lockID)
+		_ = store.runReconciliation(newCtx, report)
+	}()
+
+	return &started, nil
+}
+
+// Reconcile compares the metadata of the files with the objects in the private bucket and stores a report of where
+// they disagree. The caller holds the lock on ReconciliationResource.
+func (store *Store) Reconcile(ctx context.Context) (*files.ReconciliationReport, error) {
+	report, err := store.startReconciliationReport(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := store.runReconciliation(ctx, report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (store *Store) startReconciliationReport(ctx context.Context) (*files.ReconciliationReport, error) {
+	report := &files.ReconciliationReport{
+		ID:              primitive.NewObjectID().Hex(),
+		State:           ReconciliationStateInProgress,
+		StartedAt:       store.clock.GetCurrentTime(),
+		MissingObjects:  []string{},
+		OrphanedObjects: []string{},
+		SizeMismatches:  []files.SizeMismatch{},
+		EtagMismatches:  []files.EtagMismatch{},
+	}
+	if err := store.repo.InsertReconciliationReport(ctx, report); err != nil {
+		log.Error(ctx, "reconcile: failed to store report", err, log.Data{"id": report.ID})
+		return nil, err
+	}
+	return report, nil
+}
+
+// runReconciliation fills in the report and stores it again, COMPLETED, or FAILED when the files or the objects could
+// not be read
+func (store *Store) runReconciliation(ctx context.Context, report *files.ReconciliationReport) error {
+	compareErr := store.compareWithBucket(ctx, report)
+
+	completedAt := store.clock.GetCurrentTime()
+	report.CompletedAt = &completedAt
+	report.State = ReconciliationStateCompleted
+	if compareErr != nil {
+		report.State = ReconciliationStateFailed
+	}
+
+	if err := store.repo.UpdateReconciliationReport(ctx, report); err != nil {
+		log.Error(ctx, "reconcile: failed to store report", err, log.Data{"id": report.ID})
+		if compareErr == nil {
+			return err
+		}
+	}
+	if compareErr != nil {
+		return compareErr
+	}
+
+	log.Info(ctx, "reconcile: completed", log.Data{
+		"id":                    report.ID,
+		"missing_object_count":  report.MissingObjectCount,
+		"orphaned_object_count": report.OrphanedObjectCount,
+		"size_mismatch_count":   report.SizeMismatchCount,
+		"etag_mismatch_count":   report.EtagMismatchCount,
+	})
+	return nil
+}
+
+// compareWithBucket walks the files and the objects side by side, both in path order, so that neither has to be held
+// in memory. Files still being uploaded are not expected to have a complete object, so only their objects are
+// accounted for. An object is only reported as orphaned when there is no metadata for it and no file removed from its
+// path is waiting in the trash.
+func (store *Store) compareWithBucket(ctx context.Context, report *files.ReconciliationReport) error {
+	cursor, err := store.repo.MetadataCursor(ctx, MetadataFilter{}, 0)
+	if err != nil {
+		log.Error(ctx, "reconcile: failed to find metadata", err)
+		return err
+	}
+	defer func() {
+		if err := cursor.Close(ctx); err != nil {
+			log.Error(ctx, "reconcile: failed to close cursor", err)
+		}
+	}()
+
+	metadata := &sortedMetadata{cursor: cursor}
+	objects := &sortedObjects{s3client: store.s3client}
+	for {
+		m, err := metadata.peek(ctx)
+		if err != nil {
+			log.Error(ctx, "reconcile: failed to read metadata", err)
+			return err
+		}
+		object, err := objects.peek(ctx)
+		if err != nil {
+			log.Error(ctx, "reconcile: failed to list objects", err)
+			return err
+		}
+
+		switch {
+		case m == nil && object == nil:
+			return nil
+		case object == nil || (m != nil && m.Path < object.Key):
+			report.FilesChecked++
+			if m.State != StateCreated {
+				addReconciliationItem(&report.MissingObjects, &report.MissingObjectCount, m.Path)
+			}
+			metadata.pop()
+		case m == nil || object.Key < m.Path:
+			report.ObjectsChecked++
+			isOrphan, err := store.isOrphanedObject(ctx, object.Key)
+			if err != nil {
+				return err
+			}
+			if isOrphan {
+				addReconciliationItem(&report.OrphanedObjects, &report.OrphanedObjectCount, object.Key)
+			}
+			objects.pop()
+		default:
+			report.FilesChecked++
+			report.ObjectsChecked++
+			compareWithObject(report, m, object)
+			metadata.pop()
+			objects.pop()
+		}
+	}
+}
+
+func compareWithObject(report *files.ReconciliationReport, m *files.StoredRegisteredMetaData, object *aws.Object) {
+	if m.State == StateCreated {
+		return
+	}
+	if uint64(object.SizeInBytes) != m.SizeInBytes {
+		addReconciliationItem(&report.SizeMismatches, &report.SizeMismatchCount,
+			files.SizeMismatch{Path: m.Path, SizeInBytes: m.SizeInBytes, ObjectSizeInBytes: object.SizeInBytes})
+	}
+	if m.Etag != "" && object.Etag != m.Etag {
+		addReconciliationItem(&report.EtagMismatches, &report.EtagMismatchCount,
+			files.EtagMismatch{Path: m.Path, Etag: m.Etag, ObjectEtag: object.Etag})
+	}
+}
+
+// addReconciliationItem counts a drift, listing it while there are fewer than maxReconciliationItems listed. Drifts are
+// found in path order, so the list holds the first of them by path.
+func addReconciliationItem[T any](items *[]T, count *int, item T) {
+	*count++
+	if len(*items) < maxReconciliationItems {
+		*items = append(*items, item)
+	}
+}
+
+func (store *Store) unlockReconciliation(ctx context.Context, lockID string) {
+	// the lock expires by itself if it cannot be released
+	if err := store.repo.UnlockResource(ctx, lockID); err != nil {
+		log.Error(ctx, "failed to unlock reconciliation", err, log.Data{"lock_id": lockID})
+	}
+}
+
+// sortedMetadata reads the files from a cursor in path order, one ahead of the one being compared
+type sortedMetadata struct {
+	cursor  Cursor[files.StoredRegisteredMetaData]
+	current *files.StoredRegisteredMetaData
+	done    bool
+}
+
+// peek returns the next file without moving past it, or nil once every file has been read
+func (s *sortedMetadata) peek(ctx context.Context) (*files.StoredRegisteredMetaData, error) {
+	if s.current != nil || s.done {
+		return s.current, nil
+	}
+	if !s.cursor.Next(ctx) {
+		s.done = true
+		return nil, s.cursor.Err()
+	}
+	m, err := s.cursor.Current()
+	if err != nil {
+		return nil, err
+	}
+	s.current = &m
+	return s.current, nil
+}
+
+func (s *sortedMetadata) pop() {
+	s.current = nil
+}
+
+// sortedObjects lists the objects in the private bucket a page at a time. S3 lists keys in the byte order of their
+// UTF-8 encoding, which is the order MongoDB and Go sort paths in too.
+type sortedObjects struct {
+	s3client aws.S3Clienter
+	page     []aws.Object
+	token    string
+	listed   bool
+}
+
+// peek returns the next object without moving past it, or nil once every object has been listed
+func (s *sortedObjects) peek(ctx context.Context) (*aws.Object, error) {
+	for len(s.page) == 0 && !s.listed {
+		page, token, err := s.s3client.ListObjectsPage(ctx, s.token)
+		if err != nil {
+			return nil, err
+		}
+		s.page, s.token, s.listed = page, token, token == ""
+	}
+	if len(s.page) == 0 {
+		return nil, nil
+	}
+	return &s.page[0], nil
+}
+
+func (s *sortedObjects) pop() {
+	s.page = s.page[1:]
+}
+
+// isOrphanedObject checks again for metadata at the key, in case the file was registered after the metadata was read,
+// and for a removed file in the trash that still owns the object
+func (store *Store) isOrphanedObject(ctx context.Context, key string) (bool, error) {
+	_, err := store.repo.GetMetadata(ctx, key)
+	if err == nil {
+		return false, nil
+	}
+	if !errors.Is(err, mongodriver.ErrNoDocumentFound) {
+		log.Error(ctx, "reconcile: failed to get metadata", err, log.Data{"path": key})
+		return false, err
+	}
+
+	_, err = store.repo.GetTrashedFile(ctx, key)
+	if err == nil {
+		return false, nil
+	}
+	if !errors.Is(err, mongodriver.ErrNoDocumentFound) {
+		log.Error(ctx, "reconcile: failed to get trashed file", err, log.Data{"path": key})
+		return false, err
+	}
+	return true, nil
+}
+
+// GetLatestReconciliation gets the report of the most recently started reconciliation
+func (store *Store) GetLatestReconciliation(ctx context.Context) (files.ReconciliationReport, error) {
+	report, err := store.repo.GetLatestReconciliationReport(ctx)
+	if errors.Is(err, mongodriver.ErrNoDocumentFound) {
+		return files.ReconciliationReport{}, ErrReconciliationNotFound
+	}
+	if err != nil {
+		log.Error(ctx, "failed to get latest reconciliation", err)
+		return files.ReconciliationReport{}, err
+	}
+	return report, nil
+}
+
+// GetReconciliation gets the report of the reconciliation with the given ID
+func (store *Store) GetReconciliation(ctx context.Context, id string) (files.ReconciliationReport, error) {
+	report, err := store.repo.GetReconciliationReport(ctx, id)
+	if errors.Is(err, mongodriver.ErrNoDocumentFound) {
+		return files.ReconciliationReport{}, ErrReconciliationNotFound
+	}
+	if err != nil {
+		log.Error(ctx, "failed to get reconciliation", err, log.Data{"id": id})
+		return files.ReconciliationReport{}, err
+	}
+	return report, nil
+}
@@ -0,0 +1,156 @@
+package store_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ONSdigital/dp-files-api/aws"
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/dp-files-api/store"
+)
+
+// reconciliationStore lists the objects as S3 does, in key order, two to a page
+func (suite *StoreSuite) reconciliationStore(objects ...aws.Object) (*store.Store, *store.MemoryRepository) {
+	subject, repo, s3Client := suite.trashStore()
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	s3Client.ListObjectsPageFunc = func(ctx context.Context, continuationToken string) ([]aws.Object, string, error) {
+		start := 0
+		if continuationToken != "" {
+			start, _ = strconv.Atoi(continuationToken)
+		}
+		end := min(start+2, len(objects))
+		if end == len(objects) {
+			return objects[start:end], "", nil
+		}
+		return objects[start:end], strconv.Itoa(end), nil
+	}
+	return subject, repo
+}
+
+func (suite *StoreSuite) TestReconcile() {
+	subject, repo := suite.reconciliationStore(
+		aws.Object{Key: "matching.csv", SizeInBytes: 10, Etag: "etag-1"},
+		aws.Object{Key: "wrong-size.csv", SizeInBytes: 11, Etag: "etag-2"},
+		aws.Object{Key: "wrong-etag.csv", SizeInBytes: 10, Etag: "etag-other"},
+		aws.Object{Key: "uploading.csv", SizeInBytes: 3},
+		aws.Object{Key: "orphan.csv", SizeInBytes: 10},
+		aws.Object{Key: "removed.csv", SizeInBytes: 10},
+	)
+	for _, m := range []files.StoredRegisteredMetaData{
+		{Path: "matching.csv", State: store.StateUploaded, SizeInBytes: 10, Etag: "etag-1"},
+		{Path: "wrong-size.csv", State: store.StatePublished, SizeInBytes: 10, Etag: "etag-2"},
+		{Path: "wrong-etag.csv", State: store.StateMoved, SizeInBytes: 10, Etag: "etag-3"},
+		{Path: "uploading.csv", State: store.StateCreated, SizeInBytes: 10},
+		{Path: "missing.csv", State: store.StateUploaded, SizeInBytes: 10},
+		{Path: "not-uploaded.csv", State: store.StateCreated, SizeInBytes: 10},
+	} {
+		suite.Require().NoError(repo.InsertMetadata(suite.defaultContext, m))
+	}
+	suite.removeUploadedFile(subject, repo, files.StoredRegisteredMetaData{Path: "removed.csv", SizeInBytes: 10})
+
+	report, err := subject.Reconcile(suite.defaultContext)
+
+	suite.NoError(err)
+	suite.NotEmpty(report.ID)
+	suite.Equal(store.ReconciliationStateCompleted, report.State)
+	suite.Equal(suite.defaultClock.GetCurrentTime(), report.StartedAt)
+	suite.Equal(6, report.FilesChecked)
+	suite.Equal(6, report.ObjectsChecked)
+	suite.Equal(1, report.MissingObjectCount)
+	suite.Equal([]string{"missing.csv"}, report.MissingObjects)
+	suite.Equal(1, report.OrphanedObjectCount)
+	suite.Equal([]string{"orphan.csv"}, report.OrphanedObjects, "a removed file owns its object until it is purged")
+	suite.Equal([]files.SizeMismatch{{Path: "wrong-size.csv", SizeInBytes: 10, ObjectSizeInBytes: 11}}, report.SizeMismatches)
+	suite.Equal([]files.EtagMismatch{{Path: "wrong-etag.csv", Etag: "etag-3", ObjectEtag: "etag-other"}}, report.EtagMismatches)
+
+	latest, err := subject.GetLatestReconciliation(suite.defaultContext)
+	suite.NoError(err)
+	suite.Equal(report.ID, latest.ID)
+	suite.Equal(store.ReconciliationStateCompleted, latest.State)
+	suite.Equal(1, latest.EtagMismatchCount)
+}
+
+func (suite *StoreSuite) TestReconcileListsTheFirstDriftsByPath() {
+	var objects []aws.Object
+	for i := 0; i < 1001; i++ {
+		objects = append(objects, aws.Object{Key: fmt.Sprintf("orphans/%04d.csv", 1000-i)})
+	}
+	subject, _ := suite.reconciliationStore(objects...)
+
+	report, err := subject.Reconcile(suite.defaultContext)
+
+	suite.NoError(err)
+	suite.Equal(1001, report.OrphanedObjectCount)
+	suite.Len(report.OrphanedObjects, 1000)
+	suite.Equal("orphans/0000.csv", report.OrphanedObjects[0])
+	suite.Equal("orphans/0999.csv", report.OrphanedObjects[999])
+	suite.Empty(report.MissingObjects)
+}
+
+func (suite *StoreSuite) TestReconcileListError() {
+	subject, _, s3Client := suite.trashStore()
+	listErr := errors.New("s3 is unavailable")
+	s3Client.ListObjectsPageFunc = func(ctx context.Context, continuationToken string) ([]aws.Object, string, error) {
+		return nil, "", listErr
+	}
+
+	_, err := subject.Reconcile(suite.defaultContext)
+
+	suite.ErrorIs(err, listErr)
+	latest, err := subject.GetLatestReconciliation(suite.defaultContext)
+	suite.NoError(err)
+	suite.Equal(store.ReconciliationStateFailed, latest.State)
+	suite.NotNil(latest.CompletedAt)
+}
+
+func (suite *StoreSuite) TestReconcileKeepsLatestReport() {
+	subject, _ := suite.reconciliationStore(aws.Object{Key: "orphan.csv"})
+
+	_, err := subject.Reconcile(suite.defaultContext)
+	suite.NoError(err)
+
+	latest, err := subject.GetLatestReconciliation(suite.defaultContext)
+	suite.NoError(err)
+	suite.Equal(1, latest.OrphanedObjectCount)
+}
+
+func (suite *StoreSuite) TestStartReconciliationRunsInTheBackground() {
+	subject, repo := suite.reconciliationStore(aws.Object{Key: "orphan.csv"})
+
+	started, err := subject.StartReconciliation(suite.defaultContext)
+
+	suite.Require().NoError(err)
+	suite.Equal(store.ReconciliationStateInProgress, started.State)
+	suite.Nil(started.CompletedAt)
+	suite.Eventually(func() bool {
+		report, err := subject.GetReconciliation(suite.defaultContext, started.ID)
+		return err == nil && report.State == store.ReconciliationStateCompleted && report.OrphanedObjectCount == 1
+	}, time.Second, time.Millisecond)
+	suite.Eventually(func() bool {
+		// the lock is released once the reconciliation has finished
+		return repo.LockResource(suite.defaultContext, store.ReconciliationResource, "next", time.Minute) == nil
+	}, time.Second, time.Millisecond)
+}
+
+func (suite *StoreSuite) TestStartReconciliationWhileAnotherIsRunning() {
+	subject, repo := suite.reconciliationStore()
+	suite.Require().NoError(repo.LockResource(suite.defaultContext, store.ReconciliationResource, "other-instance", time.Minute))
+
+	_, err := subject.StartReconciliation(suite.defaultContext)
+
+	suite.ErrorIs(err, store.ErrReconciliationInProgress)
+	_, err = subject.GetLatestReconciliation(suite.defaultContext)
+	suite.ErrorIs(err, store.ErrReconciliationNotFound)
+}
+
+func (suite *StoreSuite) TestGetReconciliationNotFound() {
+	subject, _ := suite.reconciliationStore()
+
+	_, err := subject.GetReconciliation(suite.defaultContext, "missing")
+
+	suite.ErrorIs(err, store.ErrReconciliationNotFound)
+}
@@ -0,0 +1,241 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/ONSdigital/dp-files-api/files"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Repository is the storage backend behind the Store. Every backend behaves as MongoDB does: lookups that match
+// nothing return mongodriver.ErrNoDocumentFound, inserts that clash with a unique key return a duplicate key error
+// (see mongo.IsDuplicateKeyError), and updates and deletes that match nothing succeed without changing anything.
+type Repository interface {
+	GetMetadata(ctx context.Context, path string) (files.StoredRegisteredMetaData, error)
+	FindOneMetadata(ctx context.Context, filter MetadataFilter) (files.StoredRegisteredMetaData, error)
+	FindMetadata(ctx context.Context, filter MetadataFilter) ([]files.StoredRegisteredMetaData, error)
+	FindMetadataPage(ctx context.Context, filter MetadataFilter, offset, limit int) ([]files.StoredRegisteredMetaData, error)
+	// ListDirectory groups the files whose paths start with prefix by the next part of their path, which is either a
+	// file name or the name of a directory. Entries are sorted by their key, which is the name with a slash added for a
+	// directory, and only the entries after the key after are listed, up to limit unless it is zero. The prefix is empty
+	// or ends with a slash.
+	ListDirectory(ctx context.Context, prefix, after string, limit int) ([]files.DirectoryEntry, error)
+	// SumDirectory gives the number and total size of the files whose paths start with prefix
+	SumDirectory(ctx context.Context, prefix string) (int, uint64, error)
+	CountMetadata(ctx context.Context, filter MetadataFilter) (int, error)
+	// MetadataCursor walks the files matching the filter in path order, skipping the first offset
+	MetadataCursor(ctx context.Context, filter MetadataFilter, offset int) (Cursor[files.StoredRegisteredMetaData], error)
+	InsertMetadata(ctx context.Context, metadata files.StoredRegisteredMetaData) error
+	// InsertManyMetadata inserts the metadata in order, stopping at the first error. It returns how many were
+	// inserted, so a duplicate path is the one at that index.
+	InsertManyMetadata(ctx context.Context, metadata []files.StoredRegisteredMetaData) (int, error)
+	// UpdateMetadata and UpdateMetadataIfUnchanged increment the revision of the metadata along with the update.
+	// UpdateMetadataIfUnchanged only updates the metadata while it is still at revision and was created at createdAt,
+	// reporting whether it did.
+	UpdateMetadata(ctx context.Context, path string, update Update) error
+	UpdateMetadataIfUnchanged(ctx context.Context, path string, revision int64, createdAt time.Time, update Update) (bool, error)
+	DeleteMetadata(ctx context.Context, path string) (bool, error)
+	// DeleteMetadataIfUnchanged only deletes the metadata while it is still in state, at revision and created at
+	// createdAt, reporting whether it did
+	DeleteMetadataIfUnchanged(ctx context.Context, path, state string, revision int64, createdAt time.Time) (bool, error)
+
+	GetCollection(ctx context.Context, id string) (files.StoredCollection, error)
+	InsertCollection(ctx context.Context, collection files.StoredCollection) error
+	UpsertCollection(ctx context.Context, id string, set []Field) error
+	DeleteCollection(ctx context.Context, id string) (bool, error)
+	// FindScheduledCollections finds the SCHEDULED collections whose publish_at is no later than due
+	FindScheduledCollections(ctx context.Context, due time.Time) ([]files.StoredCollection, error)
+
+	GetBundle(ctx context.Context, id string) (files.StoredBundle, error)
+	InsertBundle(ctx context.Context, bundle files.StoredBundle) error
+	UpsertBundle(ctx context.Context, id string, set []Field) error
+	DeleteBundle(ctx context.Context, id string) (bool, error)
+	// FindScheduledBundles finds the SCHEDULED bundles whose publish_at is no later than due
+	FindScheduledBundles(ctx context.Context, due time.Time) ([]files.StoredBundle, error)
+
+	InsertFileEvent(ctx context.Context, event *files.FileEvent) error
+	// InsertFileEvents inserts the events in order, stopping at the first error. It returns how many were inserted, so
+	// an event whose sequence number is already taken is the one at that index.
+	InsertFileEvents(ctx context.Context, events []*files.FileEvent) (int, error)
+	// GetFileEventChainHead gets the head of the chain of file events, or mongodriver.ErrNoDocumentFound before any
+	// event has been chained
+	GetFileEventChainHead(ctx context.Context) (FileEventChainHead, error)
+	// AdvanceFileEventChainHead moves the head of the chain on by n sequence numbers, to the event with hash, in a single
+	// atomic update that only applies while the head is still at head, reporting whether it did. The first advance,
+	// from the empty head, creates the head. A negative n moves the head back.
+	AdvanceFileEventChainHead(ctx context.Context, head FileEventChainHead, n int, hash string) (bool, error)
+	// FindChainedFileEvents finds up to limit file events with a sequence number above afterSequence, in sequence
+	// order, each with the document it is stored as
+	FindChainedFileEvents(ctx context.Context, afterSequence int64, limit int) ([]ChainedFileEvent, error)
+	CountFileEvents(ctx context.Context, filter FileEventFilter) (int, error)
+	// FindFileEvents finds a page of the file events matching the filter, newest first unless oldestFirst is set
+	FindFileEvents(ctx context.Context, filter FileEventFilter, oldestFirst bool, offset, limit int) ([]files.FileEvent, error)
+	// FileEventsCursor walks the file events matching the filter, oldest first
+	FileEventsCursor(ctx context.Context, filter FileEventFilter) (Cursor[files.FileEvent], error)
+
+	InsertOutboxMessage(ctx context.Context, msg files.OutboxMessage) error
+	// ReleaseOutboxMessages clears Pending on the messages, so that they are relayed
+	ReleaseOutboxMessages(ctx context.Context, ids []string) error
+	// FindStalePendingOutboxMessages finds up to limit pending messages created no later than createdBefore, oldest
+	// first
+	FindStalePendingOutboxMessages(ctx context.Context, createdBefore time.Time, limit int) ([]files.OutboxMessage, error)
+	// FindDueOutboxMessages finds up to limit messages that are not pending and whose next_attempt_at is no later than
+	// due, oldest first
+	FindDueOutboxMessages(ctx context.Context, due time.Time, limit int) ([]files.OutboxMessage, error)
+	DeleteOutboxMessage(ctx context.Context, id string) error
+	// RecordOutboxMessageSent sets sent_at on the message and leases it until confirmBy, when it is due again
+	RecordOutboxMessageSent(ctx context.Context, id string, sentAt, confirmBy time.Time) error
+	// RecordOutboxMessageFailure clears sent_at on the message so that it is sent again at nextAttemptAt
+	RecordOutboxMessageFailure(ctx context.Context, id string, nextAttemptAt time.Time, reason string) error
+
+	InsertFileVersion(ctx context.Context, version files.StoredRegisteredMetaData) error
+	GetFileVersion(ctx context.Context, path string, version int) (files.StoredRegisteredMetaData, error)
+	FindFileVersions(ctx context.Context, path string) ([]files.StoredRegisteredMetaData, error)
+	DeleteFileVersion(ctx context.Context, path string, version int) error
+	// LatestFileVersions gives the highest version kept for each of the paths that has any
+	LatestFileVersions(ctx context.Context, paths []string) (map[string]int, error)
+
+	// InsertFileChange returns a duplicate key error when the sequence number of the change is already taken
+	InsertFileChange(ctx context.Context, change files.FileChange) error
+	// GetLatestFileChange gets the file change with the highest sequence number
+	GetLatestFileChange(ctx context.Context) (files.FileChange, error)
+	FindFileChanges(ctx context.Context, filter FileChangeFilter, limit int) ([]files.FileChange, error)
+
+	InsertPublishJob(ctx context.Context, job *files.PublishJob) error
+	DeletePublishJob(ctx context.Context, id string) error
+	// ClaimPublishJob makes owner the owner of the unfinished job until leaseExpiresAt, unless another owner's lease on
+	// it lasts beyond now, reporting whether it did
+	ClaimPublishJob(ctx context.Context, id, owner string, now, leaseExpiresAt time.Time) (bool, error)
+	// CheckpointPublishJobBatch records the checkpoint and renews the lease on the job while it is held by the
+	// checkpoint's owner, reporting whether it was
+	CheckpointPublishJobBatch(ctx context.Context, id string, checkpoint PublishJobCheckpoint) (bool, error)
+	// RenewPublishJobLease extends the lease on the job to leaseExpiresAt while it is held by owner, reporting whether it
+	// was
+	RenewPublishJobLease(ctx context.Context, id, owner string, leaseExpiresAt time.Time) (bool, error)
+	// CompletePublishJob records the final state of the job while it is held by owner
+	CompletePublishJob(ctx context.Context, id, owner, state string, completedAt time.Time) error
+	FindPublishJobs(ctx context.Context, state string) ([]files.PublishJob, error)
+	GetLatestPublishJob(ctx context.Context, collectionID, bundleID string) (files.PublishJob, error)
+
+	InsertTrashedFile(ctx context.Context, file files.TrashedFile) error
+	// GetTrashedFile gets the file most recently removed from the path
+	GetTrashedFile(ctx context.Context, path string) (files.TrashedFile, error)
+	DeleteTrashedFile(ctx context.Context, id string) (bool, error)
+	// FindExpiredTrashedFiles finds the removed files whose purge_after is no later than due
+	FindExpiredTrashedFiles(ctx context.Context, due time.Time) ([]files.TrashedFile, error)
+
+	InsertReconciliationReport(ctx context.Context, report *files.ReconciliationReport) error
+	// UpdateReconciliationReport replaces the stored report with the same ID
+	UpdateReconciliationReport(ctx context.Context, report *files.ReconciliationReport) error
+	GetReconciliationReport(ctx context.Context, id string) (files.ReconciliationReport, error)
+	GetLatestReconciliationReport(ctx context.Context) (files.ReconciliationReport, error)
+
+	InsertIdempotencyRecord(ctx context.Context, record files.IdempotencyRecord) error
+	GetIdempotencyRecord(ctx context.Context, key string) (files.IdempotencyRecord, error)
+	// CompleteIdempotencyRecord and DeleteIdempotencyRecord only change the record of key created at createdAt, so that
+	// a record that has since been replaced is left alone
+	CompleteIdempotencyRecord(ctx context.Context, key string, createdAt time.Time, response files.IdempotentResponse) error
+	DeleteIdempotencyRecord(ctx context.Context, key string, createdAt time.Time) (bool, error)
+
+	// LockResource takes an exclusive lock on a resource, shared by every instance of the service, for ttl. It returns
+	// ErrResourceLocked while another lock on the resource has been neither released nor expired.
+	LockResource(ctx context.Context, resource, lockID string, ttl time.Duration) error
+	// RenewResourceLock extends the lock taken with lockID to expire after ttl. It returns ErrResourceLocked once the
+	// lock has expired or been released, when it has to be taken again.
+	RenewResourceLock(ctx context.Context, lockID string, ttl time.Duration) error
+	UnlockResource(ctx context.Context, lockID string) error
+}
+
+// Cursor walks the results of a query one at a time. Next moves on to the next result, returning false once there are
+// no more or reading them has failed, when Err gives the error. Current gives the result Next moved on to.
+type Cursor[T any] interface {
+	Next(ctx context.Context) bool
+	Current() (T, error)
+	Err() error
+	Close(ctx context.Context) error
+}
+
+// ChainedFileEvent is a file event in the chain, with Stored, the document it is stored as by the backend. The stored
+// document is hashed rather than the event read from it, so events written before a field is added to FileEvent or
+// StoredRegisteredMetaData still have the same hash.
+type ChainedFileEvent struct {
+	Event  files.FileEvent
+	Stored bson.Raw
+}
+
+// FileEventChainHead is the sequence number and hash of the last file event given a place in the chain. Sequence
+// numbers are taken by advancing the head, and the head anchors the end of the chain, so that events removed from the
+// end are noticed.
+type FileEventChainHead struct {
+	Sequence int64  `bson:"sequence"`
+	Hash     string `bson:"hash"`
+}
+
+// MetadataFilter selects files by path, collection or bundle, leaving out any files in ExcludeStates. Nil or empty fields
+// are not filtered on, except that a non-nil but empty States matches no files. PathAfter selects files whose path
+// sorts after it, to read on from the last page.
+type MetadataFilter struct {
+	Paths          []string
+	CollectionID   *string
+	BundleID       *string
+	ExcludeStates  []string
+	States         []string
+	Type           string
+	IsPublishable  *bool
+	PathPrefix     string
+	PathAfter      string
+	CreatedAfter   *time.Time
+	CreatedBefore  *time.Time
+	ModifiedAfter  *time.Time
+	ModifiedBefore *time.Time
+}
+
+// FileEventFilter selects file events by what was done, who asked for it, the file it was done to and when. Empty
+// fields are not filtered on.
+type FileEventFilter struct {
+	Path          string
+	PathPrefix    string
+	Action        string
+	RequestedByID string
+	CollectionID  string
+	BundleID      string
+	DatasetID     string
+	Edition       string
+	After         *time.Time
+	Before        *time.Time
+}
+
+// FileChangeFilter selects the changes to files in a collection or bundle with a sequence number above AfterSequence,
+// in sequence order. Nil IDs are not filtered on.
+type FileChangeFilter struct {
+	CollectionID  *string
+	BundleID      *string
+	AfterSequence int64
+}
+
+// Field is a stored field, named by its bson key, and the value to give it
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Update changes a stored document: the Set fields are written in order and the Unset fields are removed
+type Update struct {
+	Set   []Field
+	Unset []string
+}
+
+// PublishJobCheckpoint records the progress of one batch of a publish job, made by the owner of the job. LastPath is
+// the path of the last file handled, and Sent and Failed are the files handled since the previous checkpoint.
+type PublishJobCheckpoint struct {
+	Owner          string
+	Batch          int
+	Position       int
+	LastPath       string
+	Sent           int
+	Failed         int
+	Completed      bool
+	LastModified   time.Time
+	LeaseExpiresAt time.Time
+}
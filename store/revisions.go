@@ -0,0 +1,106 @@
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+type ifMatchKey struct{}
+
+// WithIfMatch returns a context carrying the If-Match header of a request, so that the file it changes is only
+// changed while it still has one of the given ETags
+func WithIfMatch(ctx context.Context, ifMatch string) context.Context {
+	return context.WithValue(ctx, ifMatchKey{}, ifMatch)
+}
+
+func ifMatch(ctx context.Context) string {
+	value, _ := ctx.Value(ifMatchKey{}).(string)
+	return strings.TrimSpace(value)
+}
+
+// MetadataETag is the strong ETag of the metadata of a file as it is served. It starts with the revision of the
+// metadata, which changes with every change to it and differs between files registered at the same path, and ends with
+// the state and publication of the file served, which change when its collection or bundle is published without
+// changing the revision.
+func MetadataETag(metadata files.StoredRegisteredMetaData) string {
+	var publishedAt int64
+	if metadata.PublishedAt != nil {
+		publishedAt = metadata.PublishedAt.UnixMilli()
+	}
+	served := sha256.Sum256(fmt.Appendf(nil, "%s\x00%d", metadata.State, publishedAt))
+	return fmt.Sprintf(`"%s-%s"`, revisionTag(metadata), hex.EncodeToString(served[:4]))
+}
+
+// revisionTag identifies the revision of the metadata of a file
+func revisionTag(metadata files.StoredRegisteredMetaData) string {
+	hash := sha256.Sum256(fmt.Appendf(nil, "%s\x00%d", metadata.Path, metadata.CreatedAt.UnixMilli()))
+	return fmt.Sprintf("%d-%s", metadata.Revision, hex.EncodeToString(hash[:8]))
+}
+
+// checkIfMatch returns ErrFileModified when the request has an If-Match header with no ETag for the revision of the
+// metadata. Only the revision is compared, as the state served for a file in a published collection or bundle is not
+// the state stored. Weak ETags never match.
+func checkIfMatch(ctx context.Context, metadata files.StoredRegisteredMetaData) error {
+	value := ifMatch(ctx)
+	if value == "" || value == "*" {
+		return nil
+	}
+
+	revision := `"` + revisionTag(metadata) + "-"
+	for _, candidate := range strings.Split(value, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if strings.HasPrefix(candidate, revision) && strings.HasSuffix(candidate, `"`) {
+			return nil
+		}
+	}
+
+	log.Error(ctx, "file has been changed since the etag in the request", ErrFileModified, log.Data{"path": metadata.Path, "if_match": value, "revision": revisionTag(metadata)})
+	return ErrFileModified
+}
+
+// updateMetadata updates the metadata of a file. When the request has an If-Match header the update is only made
+// while the file is still at the revision it was read at, so that a change made in the meantime is not overwritten.
+func (store *Store) updateMetadata(ctx context.Context, metadata files.StoredRegisteredMetaData, update Update) error {
+	if value := ifMatch(ctx); value == "" || value == "*" {
+		return store.repo.UpdateMetadata(ctx, metadata.Path, update)
+	}
+	return store.updateMetadataIfUnchanged(ctx, metadata, update)
+}
+
+// updateMetadataIfUnchanged updates the metadata of a file only while it is still at the revision it was read at,
+// returning ErrFileModified if it has been changed since
+func (store *Store) updateMetadataIfUnchanged(ctx context.Context, metadata files.StoredRegisteredMetaData, update Update) error {
+	updated, err := store.repo.UpdateMetadataIfUnchanged(ctx, metadata.Path, metadata.Revision, metadata.CreatedAt, update)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		log.Error(ctx, "file was changed while it was being updated", ErrFileModified, log.Data{"path": metadata.Path})
+		return ErrFileModified
+	}
+	return nil
+}
+
+// deleteMetadata deletes the metadata of a file, reporting whether it did. When the request has an If-Match header
+// it is only deleted while the file is still in the state and at the revision it was read at.
+func (store *Store) deleteMetadata(ctx context.Context, metadata files.StoredRegisteredMetaData) (bool, error) {
+	if value := ifMatch(ctx); value == "" || value == "*" {
+		return store.repo.DeleteMetadata(ctx, metadata.Path)
+	}
+
+	deleted, err := store.repo.DeleteMetadataIfUnchanged(ctx, metadata.Path, metadata.State, metadata.Revision, metadata.CreatedAt)
+	if err != nil {
+		return false, err
+	}
+	if !deleted {
+		log.Error(ctx, "file was changed while it was being deleted", ErrFileModified, log.Data{"path": metadata.Path})
+		return false, ErrFileModified
+	}
+	return true, nil
+}
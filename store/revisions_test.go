@@ -0,0 +1,183 @@
+package store_test
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/ONSdigital/dp-files-api/config"
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/dp-files-api/store"
+	mongodriver "github.com/ONSdigital/dp-mongodb/v3/mongodb"
+)
+
+// changingRepository runs change just after the next read of file metadata, as a request changing the file at the
+// same time would
+type changingRepository struct {
+	*store.MemoryRepository
+	change func(ctx context.Context, path string) error
+}
+
+func (r *changingRepository) GetMetadata(ctx context.Context, path string) (files.StoredRegisteredMetaData, error) {
+	m, err := r.MemoryRepository.GetMetadata(ctx, path)
+	if change := r.change; change != nil && err == nil {
+		r.change = nil
+		err = change(ctx, path)
+	}
+	return m, err
+}
+
+func changeContentItem(repo *store.MemoryRepository) func(ctx context.Context, path string) error {
+	return func(ctx context.Context, path string) error {
+		return repo.UpdateMetadata(ctx, path, store.Update{Set: []store.Field{{Key: "content_item", Value: files.StoredContentItem{DatasetID: "other"}}}})
+	}
+}
+
+func (suite *StoreSuite) revisionStore(repo store.Repository) *store.Store {
+	cfg, _ := config.Get()
+	subject := store.NewStore(repo, suite.defaultClock, nil, cfg)
+	suite.Require().NoError(subject.RegisterFileUpload(suite.defaultContext, files.StoredRegisteredMetaData{Path: suite.path}))
+	return subject
+}
+
+func (suite *StoreSuite) etag(subject *store.Store) string {
+	m, err := subject.GetFileMetadata(suite.defaultContext, suite.path)
+	suite.Require().NoError(err)
+	return store.MetadataETag(m)
+}
+
+func (suite *StoreSuite) TestMetadataRevisionChangesETag() {
+	subject := suite.revisionStore(store.NewMemoryRepository())
+	registered := suite.etag(subject)
+
+	suite.NoError(subject.UpdateCollectionID(suite.defaultContext, suite.path, "collection"))
+
+	suite.NotEqual(registered, suite.etag(subject))
+	suite.Regexp(`^"1-[0-9a-f]{16}-[0-9a-f]{8}"$`, suite.etag(subject))
+}
+
+func (suite *StoreSuite) TestMetadataETagDiffersForFileRegisteredAgain() {
+	createdAt := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	first := files.StoredRegisteredMetaData{Path: suite.path, CreatedAt: createdAt}
+	second := files.StoredRegisteredMetaData{Path: suite.path, CreatedAt: createdAt.Add(time.Minute)}
+
+	suite.NotEqual(store.MetadataETag(first), store.MetadataETag(second))
+	suite.Equal(store.MetadataETag(first), store.MetadataETag(first))
+}
+
+func (suite *StoreSuite) TestMetadataETagChangesWhenCollectionIsPublished() {
+	repo := store.NewMemoryRepository()
+	subject := suite.revisionStore(repo)
+	suite.NoError(subject.UpdateCollectionID(suite.defaultContext, suite.path, testCollectionID))
+	suite.NoError(repo.UpdateMetadata(suite.defaultContext, suite.path, store.Update{Set: []store.Field{{Key: "state", Value: store.StateUploaded}}}))
+	uploaded := suite.etag(subject)
+
+	publishedAt := suite.defaultClock.GetCurrentTime()
+	suite.NoError(repo.InsertCollection(suite.defaultContext, files.StoredCollection{ID: testCollectionID, State: store.StatePublished, PublishedAt: &publishedAt, LastModified: publishedAt}))
+	published := suite.etag(subject)
+
+	suite.NotEqual(uploaded, published, "the state served has changed")
+	suite.Equal(uploaded[:strings.LastIndex(uploaded, "-")], published[:strings.LastIndex(published, "-")], "the revision has not")
+
+	ctx := store.WithIfMatch(suite.defaultContext, published)
+	suite.NoError(subject.UpdateContentItem(ctx, suite.path, &files.StoredContentItem{DatasetID: "cpih"}), "If-Match only compares the revision")
+}
+
+func (suite *StoreSuite) TestUpdateWithIfMatch() {
+	subject := suite.revisionStore(store.NewMemoryRepository())
+	etag := suite.etag(subject)
+
+	ctx := store.WithIfMatch(suite.defaultContext, `"0-0000000000000000", `+etag)
+	suite.NoError(subject.UpdateCollectionID(ctx, suite.path, "collection"))
+
+	m, err := subject.GetFileMetadata(suite.defaultContext, suite.path)
+	suite.NoError(err)
+	suite.Equal("collection", *m.CollectionID)
+}
+
+func (suite *StoreSuite) TestUpdateWithStaleIfMatch() {
+	subject := suite.revisionStore(store.NewMemoryRepository())
+	etag := suite.etag(subject)
+	suite.NoError(subject.UpdateContentItem(suite.defaultContext, suite.path, &files.StoredContentItem{DatasetID: "first"}))
+
+	for _, ifMatch := range []string{etag, "W/" + etag, `"other"`} {
+		ctx := store.WithIfMatch(suite.defaultContext, ifMatch)
+		suite.ErrorIs(subject.UpdateContentItem(ctx, suite.path, &files.StoredContentItem{DatasetID: "second"}), store.ErrFileModified, ifMatch)
+	}
+
+	m, err := subject.GetFileMetadata(suite.defaultContext, suite.path)
+	suite.NoError(err)
+	suite.Equal("first", m.ContentItem.DatasetID)
+}
+
+func (suite *StoreSuite) TestUpdateWithAnyIfMatch() {
+	subject := suite.revisionStore(store.NewMemoryRepository())
+
+	ctx := store.WithIfMatch(suite.defaultContext, "*")
+	suite.NoError(subject.UpdateContentItem(ctx, suite.path, &files.StoredContentItem{DatasetID: "first"}))
+}
+
+func (suite *StoreSuite) TestUpdateWithIfMatchDoesNotOverwriteConcurrentChange() {
+	repo := &changingRepository{MemoryRepository: store.NewMemoryRepository()}
+	subject := suite.revisionStore(repo)
+	ctx := store.WithIfMatch(suite.defaultContext, suite.etag(subject))
+	repo.change = changeContentItem(repo.MemoryRepository)
+
+	err := subject.UpdateContentItem(ctx, suite.path, &files.StoredContentItem{DatasetID: "mine"})
+
+	suite.ErrorIs(err, store.ErrFileModified)
+	m, err := repo.MemoryRepository.GetMetadata(suite.defaultContext, suite.path)
+	suite.NoError(err)
+	suite.Equal("other", m.ContentItem.DatasetID)
+}
+
+func (suite *StoreSuite) TestUpdateWithoutIfMatchIsUnconditional() {
+	repo := &changingRepository{MemoryRepository: store.NewMemoryRepository()}
+	subject := suite.revisionStore(repo)
+	repo.change = changeContentItem(repo.MemoryRepository)
+
+	suite.NoError(subject.UpdateContentItem(suite.defaultContext, suite.path, &files.StoredContentItem{DatasetID: "mine"}))
+
+	m, err := repo.MemoryRepository.GetMetadata(suite.defaultContext, suite.path)
+	suite.NoError(err)
+	suite.Equal("mine", m.ContentItem.DatasetID)
+	suite.Equal(int64(2), m.Revision)
+}
+
+func (suite *StoreSuite) uploadedRevisionStore(repo *changingRepository) *store.Store {
+	subject := suite.revisionStore(repo)
+	suite.Require().NoError(repo.MemoryRepository.UpdateMetadata(suite.defaultContext, suite.path, store.Update{Set: []store.Field{{Key: "state", Value: store.StateUploaded}}}))
+	return subject
+}
+
+func (suite *StoreSuite) TestRemoveFileWithIfMatch() {
+	repo := &changingRepository{MemoryRepository: store.NewMemoryRepository()}
+	subject := suite.uploadedRevisionStore(repo)
+	m, err := subject.GetFileMetadata(suite.defaultContext, suite.path)
+	suite.Require().NoError(err)
+
+	suite.ErrorIs(subject.RemoveFile(store.WithIfMatch(suite.defaultContext, `"0-0000000000000000"`), suite.path, m), store.ErrFileModified)
+	_, err = repo.MemoryRepository.GetMetadata(suite.defaultContext, suite.path)
+	suite.NoError(err)
+
+	suite.NoError(subject.RemoveFile(store.WithIfMatch(suite.defaultContext, store.MetadataETag(m)), suite.path, m))
+	_, err = repo.MemoryRepository.GetMetadata(suite.defaultContext, suite.path)
+	suite.ErrorIs(err, mongodriver.ErrNoDocumentFound)
+}
+
+func (suite *StoreSuite) TestRemoveFileWithIfMatchDoesNotDeleteConcurrentChange() {
+	repo := &changingRepository{MemoryRepository: store.NewMemoryRepository()}
+	subject := suite.uploadedRevisionStore(repo)
+	repo.change = changeContentItem(repo.MemoryRepository)
+	m, err := subject.GetFileMetadata(suite.defaultContext, suite.path)
+	suite.Require().NoError(err)
+
+	err = subject.RemoveFile(store.WithIfMatch(suite.defaultContext, store.MetadataETag(m)), suite.path, m)
+
+	suite.ErrorIs(err, store.ErrFileModified)
+	m, err = repo.MemoryRepository.GetMetadata(suite.defaultContext, suite.path)
+	suite.NoError(err)
+	suite.Equal("other", m.ContentItem.DatasetID)
+	_, err = subject.GetTrashedFile(suite.defaultContext, suite.path)
+	suite.ErrorIs(err, store.ErrFileNotInTrash, "the file is taken back out of the trash")
+}
@@ -0,0 +1,112 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// ScheduledPublishingResource is locked while scheduled collections and bundles are published, so that only one
+// instance of the service publishes them
+const ScheduledPublishingResource = "scheduled-publishing"
+
+// ScheduleCollectionPublication moves a collection to the SCHEDULED state, to be published by PublishScheduled at
+// publishAt. A scheduled collection can be given a new time, or published straight away with MarkCollectionPublished.
+// Its files are checked when it is published, so they can still be uploading when it is scheduled.
+func (store *Store) ScheduleCollectionPublication(ctx context.Context, collectionID string, publishAt time.Time) error {
+	logdata := log.Data{"collection_id": collectionID, "publish_at": publishAt}
+
+	now := store.clock.GetCurrentTime()
+	if !publishAt.After(now) {
+		log.Error(ctx, "schedule collection publication: publish time is not in the future", ErrPublishAtNotInFuture, logdata)
+		return ErrPublishAtNotInFuture
+	}
+
+	published, err := store.IsCollectionPublished(ctx, collectionID)
+	if err != nil {
+		log.Error(ctx, "failed to check if collection is published", err, logdata)
+		return err
+	}
+	if published {
+		log.Error(ctx, "schedule collection publication: collection already published", ErrCollectionAlreadyPublished, logdata)
+		return ErrCollectionAlreadyPublished
+	}
+
+	if err := store.repo.UpsertCollection(ctx, collectionID, scheduleFields(publishAt, now)); err != nil {
+		log.Error(ctx, "failed to schedule collection publication", err, logdata)
+		return err
+	}
+	return nil
+}
+
+// ScheduleBundlePublication moves a bundle to the SCHEDULED state, as ScheduleCollectionPublication does for
+// collections
+func (store *Store) ScheduleBundlePublication(ctx context.Context, bundleID string, publishAt time.Time) error {
+	logdata := log.Data{"bundle_id": bundleID, "publish_at": publishAt}
+
+	now := store.clock.GetCurrentTime()
+	if !publishAt.After(now) {
+		log.Error(ctx, "schedule bundle publication: publish time is not in the future", ErrPublishAtNotInFuture, logdata)
+		return ErrPublishAtNotInFuture
+	}
+
+	published, err := store.IsBundlePublished(ctx, bundleID)
+	if err != nil {
+		log.Error(ctx, "failed to check if bundle is published", err, logdata)
+		return err
+	}
+	if published {
+		log.Error(ctx, "schedule bundle publication: bundle already published", ErrBundleAlreadyPublished, logdata)
+		return ErrBundleAlreadyPublished
+	}
+
+	if err := store.repo.UpsertBundle(ctx, bundleID, scheduleFields(publishAt, now)); err != nil {
+		log.Error(ctx, "failed to schedule bundle publication", err, logdata)
+		return err
+	}
+	return nil
+}
+
+func scheduleFields(publishAt, now time.Time) []Field {
+	return []Field{
+		{Key: fieldState, Value: StateScheduled},
+		{Key: fieldPublishAt, Value: publishAt},
+		{Key: fieldLastModified, Value: now},
+	}
+}
+
+// PublishScheduled publishes the scheduled collections and bundles whose publication time has come, as
+// MarkCollectionPublished and MarkBundlePublished do. Those that cannot be published stay SCHEDULED, to be tried again
+// next time, and the last of their errors is returned.
+func (store *Store) PublishScheduled(ctx context.Context) error {
+	now := store.clock.GetCurrentTime()
+
+	collections, err := store.repo.FindScheduledCollections(ctx, now)
+	if err != nil {
+		log.Error(ctx, "failed to find scheduled collections", err)
+		return err
+	}
+	bundles, err := store.repo.FindScheduledBundles(ctx, now)
+	if err != nil {
+		log.Error(ctx, "failed to find scheduled bundles", err)
+		return err
+	}
+
+	var lastErr error
+	for _, collection := range collections {
+		log.Info(ctx, "publishing scheduled collection", log.Data{"collection_id": collection.ID, "publish_at": collection.PublishAt})
+		if err := store.MarkCollectionPublished(ctx, collection.ID); err != nil {
+			log.Error(ctx, "failed to publish scheduled collection", err, log.Data{"collection_id": collection.ID})
+			lastErr = err
+		}
+	}
+	for _, bundle := range bundles {
+		log.Info(ctx, "publishing scheduled bundle", log.Data{"bundle_id": bundle.ID, "publish_at": bundle.PublishAt})
+		if err := store.MarkBundlePublished(ctx, bundle.ID); err != nil {
+			log.Error(ctx, "failed to publish scheduled bundle", err, log.Data{"bundle_id": bundle.ID})
+			lastErr = err
+		}
+	}
+	return lastErr
+}
@@ -0,0 +1,94 @@
+package store_test
+
+import (
+	"time"
+
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/dp-files-api/store"
+)
+
+func (suite *StoreSuite) TestScheduleCollectionPublication() {
+	subject, repo := suite.lifecycleStore()
+	collectionID := testCollectionID
+	publishAt := suite.defaultClock.GetCurrentTime().Add(time.Hour)
+	suite.NoError(repo.InsertMetadata(suite.defaultContext, files.StoredRegisteredMetaData{Path: suite.path, State: store.StateUploaded, CollectionID: &collectionID}))
+
+	suite.NoError(subject.ScheduleCollectionPublication(suite.defaultContext, collectionID, publishAt))
+
+	collection, err := repo.GetCollection(suite.defaultContext, collectionID)
+	suite.NoError(err)
+	suite.Equal(store.StateScheduled, collection.State)
+	suite.Equal(publishAt, *collection.PublishAt)
+
+	_, err = subject.GetFileMetadataWeb(suite.defaultContext, suite.path)
+	suite.ErrorIs(err, store.ErrFileNotRegistered)
+}
+
+func (suite *StoreSuite) TestScheduleCollectionPublicationRejections() {
+	subject, repo := suite.lifecycleStore()
+	suite.NoError(repo.InsertCollection(suite.defaultContext, files.StoredCollection{ID: "published", State: store.StatePublished}))
+	now := suite.defaultClock.GetCurrentTime()
+
+	suite.ErrorIs(subject.ScheduleCollectionPublication(suite.defaultContext, testCollectionID, now), store.ErrPublishAtNotInFuture)
+	suite.ErrorIs(subject.ScheduleCollectionPublication(suite.defaultContext, "published", now.Add(time.Hour)), store.ErrCollectionAlreadyPublished)
+}
+
+func (suite *StoreSuite) TestScheduleBundlePublication() {
+	subject, repo := suite.lifecycleStore()
+	publishAt := suite.defaultClock.GetCurrentTime().Add(time.Hour)
+	suite.NoError(repo.InsertBundle(suite.defaultContext, files.StoredBundle{ID: "published", State: store.StatePublished}))
+
+	suite.NoError(subject.ScheduleBundlePublication(suite.defaultContext, testBundleID, publishAt))
+
+	bundle, err := repo.GetBundle(suite.defaultContext, testBundleID)
+	suite.NoError(err)
+	suite.Equal(store.StateScheduled, bundle.State)
+	suite.Equal(publishAt, *bundle.PublishAt)
+
+	suite.ErrorIs(subject.ScheduleBundlePublication(suite.defaultContext, "published", publishAt), store.ErrBundleAlreadyPublished)
+}
+
+func (suite *StoreSuite) TestPublishScheduledPublishesDueCollectionsAndBundles() {
+	subject, repo := suite.lifecycleStore()
+	now := suite.defaultClock.GetCurrentTime()
+	due, later := now.Add(-time.Minute), now.Add(time.Hour)
+	dueCollection, laterCollection, dueBundle := "due-collection", "later-collection", "due-bundle"
+	suite.NoError(repo.InsertCollection(suite.defaultContext, files.StoredCollection{ID: dueCollection, State: store.StateScheduled, PublishAt: &due}))
+	suite.NoError(repo.InsertCollection(suite.defaultContext, files.StoredCollection{ID: laterCollection, State: store.StateScheduled, PublishAt: &later}))
+	suite.NoError(repo.InsertBundle(suite.defaultContext, files.StoredBundle{ID: dueBundle, State: store.StateScheduled, PublishAt: &due}))
+	suite.NoError(repo.InsertMetadata(suite.defaultContext, files.StoredRegisteredMetaData{Path: "due.csv", State: store.StateUploaded, CollectionID: &dueCollection}))
+	suite.NoError(repo.InsertMetadata(suite.defaultContext, files.StoredRegisteredMetaData{Path: "later.csv", State: store.StateUploaded, CollectionID: &laterCollection}))
+	suite.NoError(repo.InsertMetadata(suite.defaultContext, files.StoredRegisteredMetaData{Path: "bundle.csv", State: store.StateUploaded, BundleID: &dueBundle}))
+
+	suite.NoError(subject.PublishScheduled(suite.defaultContext))
+
+	collection, err := repo.GetCollection(suite.defaultContext, dueCollection)
+	suite.NoError(err)
+	suite.Equal(store.StatePublished, collection.State)
+	collection, err = repo.GetCollection(suite.defaultContext, laterCollection)
+	suite.NoError(err)
+	suite.Equal(store.StateScheduled, collection.State)
+	bundle, err := repo.GetBundle(suite.defaultContext, dueBundle)
+	suite.NoError(err)
+	suite.Equal(store.StatePublished, bundle.State)
+
+	_, err = subject.GetFileMetadataWeb(suite.defaultContext, "due.csv")
+	suite.NoError(err)
+	_, err = subject.GetFileMetadataWeb(suite.defaultContext, "later.csv")
+	suite.ErrorIs(err, store.ErrFileNotRegistered)
+}
+
+func (suite *StoreSuite) TestPublishScheduledLeavesCollectionThatCannotBePublished() {
+	subject, repo := suite.lifecycleStore()
+	due := suite.defaultClock.GetCurrentTime().Add(-time.Minute)
+	collectionID := testCollectionID
+	suite.NoError(repo.InsertCollection(suite.defaultContext, files.StoredCollection{ID: collectionID, State: store.StateScheduled, PublishAt: &due}))
+	suite.NoError(repo.InsertMetadata(suite.defaultContext, files.StoredRegisteredMetaData{Path: suite.path, State: store.StateCreated, CollectionID: &collectionID}))
+
+	err := subject.PublishScheduled(suite.defaultContext)
+
+	suite.ErrorIs(err, store.ErrFileNotInUploadedState)
+	collection, err := repo.GetCollection(suite.defaultContext, collectionID)
+	suite.NoError(err)
+	suite.Equal(store.StateScheduled, collection.State)
+}
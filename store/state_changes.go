@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"strconv"
 	"strings"
 
 	mongodriver "github.com/ONSdigital/dp-mongodb/v3/mongodb"
@@ -21,6 +20,8 @@ const (
 	StateUploaded  = "UPLOADED"
 	StatePublished = "PUBLISHED"
 	StateMoved     = "MOVED"
+	StateWithdrawn = "WITHDRAWN"
+	StateScheduled = "SCHEDULED"
 )
 
 // GetFilesMetadata godoc
@@ -40,72 +41,70 @@ func (store *Store) RegisterFileUpload(ctx context.Context, metaData files.Store
 	logdata := log.Data{"path": metaData.Path}
 
 	// don't register file upload if it is already registered
-	m := files.StoredRegisteredMetaData{}
-	errFindingMetadata := store.metadataCollection.FindOne(ctx, bson.M{fieldPath: metaData.Path}, &m)
+	m, errFindingMetadata := store.repo.GetMetadata(ctx, metaData.Path)
 	if errFindingMetadata != nil && !errors.Is(errFindingMetadata, mongodriver.ErrNoDocumentFound) {
 		log.Error(ctx, "error while finding metadata", errFindingMetadata, logdata)
 		return ErrDuplicateFile
 	}
 
-	if metaData.CollectionID != nil && m.CollectionID != nil {
-		if m.State == StateUploaded && *m.CollectionID == *metaData.CollectionID {
-			log.Info(ctx, "File upload already registered: skipping registration of file metadata", logdata)
-			return nil
-		}
+	// a path is registered as the version after those kept for it, unless it replaces an earlier upload below
+	versions, err := store.nextFileVersions(ctx, []string{metaData.Path})
+	if err != nil {
+		return err
+	}
+	metaData.Version = versions[metaData.Path]
 
-		// delete existing file metadata if file upload comes from a different collection
-		if m.State == StateUploaded && *m.CollectionID != *metaData.CollectionID {
-			result, err := store.metadataCollection.Delete(ctx, bson.M{fieldPath: metaData.Path})
-			if err != nil {
-				log.Error(ctx, "error while deleting metadata", err, logdata)
-				return err
-			}
-			if result.DeletedCount > 0 {
-				log.Info(ctx, "deleted existing file metadata", logdata)
-			}
-		}
+	if m.State == StateUploaded && (sameID(m.CollectionID, metaData.CollectionID) || sameID(m.BundleID, metaData.BundleID)) {
+		log.Info(ctx, "File upload already registered: skipping registration of file metadata", logdata)
+		return nil
+	}
 
-		// check to see if collectionID exists and is not-published
+	// files cannot be added to a collection or bundle that is published or withdrawn
+	if metaData.CollectionID != nil {
 		logdata["collection_id"] = *metaData.CollectionID
-		published, err := store.IsCollectionPublished(ctx, *metaData.CollectionID)
-		if err != nil {
+		err = store.checkCollectionOpen(ctx, *metaData.CollectionID)
+		switch {
+		case errors.Is(err, ErrCollectionAlreadyPublished):
+			log.Error(ctx, "collection is already published", err, logdata)
+		case errors.Is(err, ErrCollectionWithdrawn):
+			log.Error(ctx, "collection is withdrawn", err, logdata)
+		case err != nil:
 			log.Error(ctx, "collection published check error", err, logdata)
-			return err
 		}
-		if published {
-			log.Error(ctx, "collection is already published", ErrCollectionAlreadyPublished, logdata)
-			return ErrCollectionAlreadyPublished
+		if err != nil {
+			return err
 		}
 	}
-
-	if metaData.BundleID != nil && m.BundleID != nil {
-		if m.State == StateUploaded && *m.BundleID == *metaData.BundleID {
-			log.Info(ctx, "File upload already registered: skipping registration of file metadata", logdata)
-			return nil
+	if metaData.BundleID != nil {
+		logdata["bundle_id"] = *metaData.BundleID
+		err = store.checkBundleOpen(ctx, *metaData.BundleID)
+		switch {
+		case errors.Is(err, ErrBundleAlreadyPublished):
+			log.Error(ctx, "bundle is already published", err, logdata)
+		case errors.Is(err, ErrBundleWithdrawn):
+			log.Error(ctx, "bundle is withdrawn", err, logdata)
+		case err != nil:
+			log.Error(ctx, "bundle published check error", err, logdata)
+		}
+		if err != nil {
+			return err
 		}
+	}
 
-		// delete existing file metadata if file upload comes from a different bundle
-		if m.State == StateUploaded && *m.BundleID != *metaData.BundleID {
-			result, err := store.metadataCollection.Delete(ctx, bson.M{fieldPath: metaData.Path})
-			if err != nil {
-				log.Error(ctx, "error while deleting metadata", err, logdata)
-				return err
-			}
-			if result.DeletedCount > 0 {
-				log.Info(ctx, "deleted existing file metadata", logdata)
-			}
+	// replace existing file metadata if file upload comes from a different collection or bundle, keeping it as a version
+	if m.State == StateUploaded && (differentID(m.CollectionID, metaData.CollectionID) || differentID(m.BundleID, metaData.BundleID)) {
+		if err := store.archiveFileVersion(ctx, m); err != nil {
+			return err
 		}
+		metaData.Version = currentVersion(m) + 1
 
-		// check to see if bundleID exists and is not-published
-		logdata["bundle_id"] = *metaData.BundleID
-		published, err := store.IsBundlePublished(ctx, *metaData.BundleID)
+		deleted, err := store.repo.DeleteMetadata(ctx, metaData.Path)
 		if err != nil {
-			log.Error(ctx, "bundle published check error", err, logdata)
+			log.Error(ctx, "error while deleting metadata", err, logdata)
 			return err
 		}
-		if published {
-			log.Error(ctx, "bundle is already published", ErrBundleAlreadyPublished, logdata)
-			return ErrBundleAlreadyPublished
+		if deleted {
+			log.Info(ctx, "deleted existing file metadata", logdata)
 		}
 	}
 
@@ -114,7 +113,14 @@ func (store *Store) RegisterFileUpload(ctx context.Context, metaData files.Store
 	metaData.LastModified = now
 	metaData.State = StateCreated
 
-	if _, err := store.metadataCollection.Insert(ctx, metaData); err != nil {
+	// a file replacing an earlier upload moves back from that upload's state
+	outboxID, err := store.enqueueFileLifecycle(ctx, metaData.Path, files.LifecycleRegistered, m.State, StateCreated)
+	if err != nil {
+		return err
+	}
+
+	if err := store.repo.InsertMetadata(ctx, metaData); err != nil {
+		store.withdrawOutboxMessages(ctx, []string{outboxID})
 		if mongo.IsDuplicateKeyError(err) {
 			log.Error(ctx, "file upload already registered", err, logdata)
 			return ErrDuplicateFile
@@ -122,6 +128,9 @@ func (store *Store) RegisterFileUpload(ctx context.Context, metaData files.Store
 		log.Error(ctx, "failed to insert metadata", err, log.Data{"collection": config.MetadataCollection, "metadata": metaData})
 		return err
 	}
+	store.releaseOutboxMessages(ctx, []string{outboxID})
+	store.recordFileChange(ctx, metaData, files.LifecycleRegistered, m.State, StateCreated)
+
 	if metaData.CollectionID != nil {
 		err := store.registerCollection(ctx, *metaData.CollectionID)
 		if err != nil {
@@ -143,64 +152,85 @@ func (store *Store) RegisterFileUpload(ctx context.Context, metaData files.Store
 }
 
 func (store *Store) MarkUploadComplete(ctx context.Context, metaData files.FileEtagChange) error {
-	return store.updateFileState(ctx, metaData.Path, metaData.Etag, StateUploaded, StateCreated, fieldUploadCompletedAt)
+	return store.updateFileState(ctx, metaData, StateUploaded, StateCreated, fieldUploadCompletedAt)
 }
 
 func (store *Store) MarkFileMoved(ctx context.Context, metaData files.FileEtagChange) error {
-	return store.updateFileState(ctx, metaData.Path, metaData.Etag, StateMoved, StatePublished, fieldMovedAt)
+	return store.updateFileState(ctx, metaData, StateMoved, StatePublished, fieldMovedAt)
 }
 
 func (store *Store) MarkFilePublished(ctx context.Context, path string) error {
+	change, err := store.checkFilePublished(ctx, path)
+	if err != nil {
+		return err
+	}
+
+	return store.applyStateChange(ctx, change)
+}
+
+// stateChange is a change to a file's state that has passed the checks for it and is ready to be made
+type stateChange struct {
+	metadata files.StoredRegisteredMetaData
+	toState  string
+	update   Update
+	// archive keeps the previous upload as a version before the change is made
+	archive bool
+	logdata log.Data
+}
+
+func (store *Store) checkFilePublished(ctx context.Context, path string) (*stateChange, error) {
 	logdata := log.Data{"path": path}
 
 	m, err := store.GetFileMetadata(ctx, path)
 	if err != nil {
 		if errors.Is(err, ErrFileNotRegistered) {
 			log.Error(ctx, "mark file as published: attempted to operate on unregistered file", err, logdata)
-			return ErrFileNotRegistered
+			return nil, ErrFileNotRegistered
 		}
 		log.Error(ctx, "mark file as published: failed finding file metadata", err, logdata)
-		return err
+		return nil, err
 	}
 	logdata["metadata"] = m
 
 	if m.State != StateUploaded {
 		log.Error(ctx, fmt.Sprintf("mark file published: file was not in state %s", StateUploaded),
 			ErrFileNotInUploadedState, logdata)
-		return ErrFileNotInUploadedState
+		return nil, ErrFileNotInUploadedState
 	}
 
 	if !m.IsPublishable {
 		log.Error(ctx, "mark file published: file not set as publishable",
 			ErrFileIsNotPublishable, logdata)
-		return ErrFileIsNotPublishable
+		return nil, ErrFileIsNotPublishable
 	}
 
 	now := store.clock.GetCurrentTime()
-	_, err = store.metadataCollection.Update(
-		ctx,
-		bson.M{fieldPath: path},
-		bson.D{
-			{Key: "$set", Value: bson.D{
-				{Key: fieldState, Value: StatePublished},
-				{Key: fieldLastModified, Value: now},
-				{Key: fieldPublishedAt, Value: now}}},
-		})
+	m.PublishedAt = &now
+	return &stateChange{
+		metadata: m,
+		toState:  StatePublished,
+		update: Update{Set: []Field{
+			{Key: fieldState, Value: StatePublished},
+			{Key: fieldLastModified, Value: now},
+			{Key: fieldPublishedAt, Value: now},
+		}},
+		logdata: logdata,
+	}, nil
+}
+
+func (store *Store) updateFileState(ctx context.Context, change files.FileEtagChange, toState, expectedCurrentState, timestampField string) error {
+	sc, err := store.checkFileState(ctx, change, toState, expectedCurrentState, timestampField, store.IsCollectionPublished)
 	if err != nil {
 		return err
 	}
 
-	log.Info(ctx, fmt.Sprintf("file set as published - %s", now.String()), logdata)
-
-	return store.kafka.Send(files.AvroSchema, &files.FilePublished{
-		Path:        m.Path,
-		Etag:        m.Etag,
-		Type:        m.Type,
-		SizeInBytes: strconv.FormatUint(m.SizeInBytes, 10),
-	})
+	return store.applyStateChange(ctx, sc)
 }
 
-func (store *Store) updateFileState(ctx context.Context, path, etag, toState, expectedCurrentState, timestampField string) error {
+// checkFileState checks that a file can be moved to toState, using isCollectionPublished to find whether its
+// collection has been published
+func (store *Store) checkFileState(ctx context.Context, change files.FileEtagChange, toState, expectedCurrentState, timestampField string, isCollectionPublished func(context.Context, string) (bool, error)) (*stateChange, error) {
+	path, etag := change.Path, change.Etag
 	logdata := log.Data{
 		"path":                 path,
 		"expectedCurrentState": expectedCurrentState,
@@ -211,76 +241,234 @@ func (store *Store) updateFileState(ctx context.Context, path, etag, toState, ex
 	if err != nil {
 		if errors.Is(err, ErrFileNotRegistered) {
 			log.Error(ctx, "update file state: attempted to operate on unregistered file", err, logdata)
-			return ErrFileNotRegistered
+			return nil, ErrFileNotRegistered
 		}
 		log.Error(ctx, "update file state: failed finding file metadata", err, logdata)
-		return err
+		return nil, err
 	}
 	logdata["actualCurrentState"] = metadata.State
 
-	var isCollectionPublished bool
+	var collectionPublished bool
 	if metadata.CollectionID != nil {
-		isCollectionPublished, err = store.IsCollectionPublished(ctx, *metadata.CollectionID) // also moved
+		collectionPublished, err = isCollectionPublished(ctx, *metadata.CollectionID) // also moved
 		if err != nil {
 			log.Error(ctx, "is collection published: caught db error", err, logdata)
-			return err
+			return nil, err
+		}
+	}
+
+	var checksumSHA256, checksumMD5 string
+	if toState == StateUploaded {
+		checksumSHA256, checksumMD5, err = uploadChecksums(metadata, change)
+		if err != nil {
+			log.Error(ctx, "update file state: checksums differ from those registered", err, logdata)
+			return nil, err
 		}
 	}
 
 	// update only timestamps if we are already in uploaded state
-	if !isCollectionPublished && metadata.State != StateMoved {
+	if !collectionPublished && metadata.State != StateMoved {
 		if toState == StateUploaded && metadata.State == StateUploaded {
+			if err = store.verifyUploadChecksums(ctx, path, checksumSHA256, checksumMD5, logdata); err != nil {
+				return nil, err
+			}
+
 			now := store.clock.GetCurrentTime()
-			_, err = store.metadataCollection.Update(
-				ctx,
-				bson.M{fieldPath: path},
-				bson.D{
-					{Key: "$set", Value: bson.D{
-						{Key: fieldEtag, Value: etag},
-						{Key: fieldLastModified, Value: now},
-						{Key: timestampField, Value: now}}},
-				})
-			if err != nil {
-				log.Error(ctx, "error while updating file metadata", err, logdata)
-				return err
+			sc := &stateChange{
+				metadata: metadata,
+				toState:  toState,
+				update: checksumUpdate(Update{Set: []Field{
+					{Key: fieldEtag, Value: etag},
+					{Key: fieldLastModified, Value: now},
+					{Key: timestampField, Value: now},
+				}}, metadata, checksumSHA256, checksumMD5),
+				logdata: logdata,
+			}
+
+			// a different etag means new content has been uploaded, so the previous upload is kept as a version
+			if etag != metadata.Etag {
+				sc.archive = true
+				sc.update.Set = append(sc.update.Set, Field{Key: fieldVersion, Value: currentVersion(metadata) + 1})
+				logdata["version"] = currentVersion(metadata) + 1
 			}
-			log.Info(ctx, "file metadata updated", logdata)
-			return nil
+
+			return sc, nil
 		}
 	}
 
 	if metadata.State != expectedCurrentState {
 		log.Error(ctx, "update file state: state mismatch", ErrFileStateMismatch, logdata)
-		return ErrFileStateMismatch
+		return nil, ErrFileStateMismatch
 	}
 	// while publishing check that you are publishing the correct/expected version of the file
 	if toState == StateMoved {
 		head, headErr := store.s3client.Head(ctx, metadata.Path)
 		if headErr != nil {
 			log.Error(ctx, fmt.Sprintf("Failed trying to get head data for %s from bucket %s", metadata.Path, store.cfg.PrivateBucketName), headErr)
-			return headErr
+			return nil, headErr
 		}
 		if head.ETag != nil && (strings.Trim(*head.ETag, "\"") != metadata.Etag) {
 			log.Error(ctx, fmt.Sprintf("Etags mismatch, expected [%s], from s3 [%s]", metadata.Etag, *head.ETag), ErrEtagMismatchWhilePublishing)
-			return ErrEtagMismatchWhilePublishing
+			return nil, ErrEtagMismatchWhilePublishing
+		}
+		if err = verifyChecksums(head, metadata.ChecksumSHA256, metadata.ChecksumMD5); err != nil {
+			log.Error(ctx, "update file state: checksums do not match the stored file", err, logdata)
+			return nil, err
 		}
 	}
 
 	now := store.clock.GetCurrentTime()
-	_, err = store.metadataCollection.Update(
-		ctx,
-		bson.M{fieldPath: path},
-		bson.D{
-			{Key: "$set", Value: bson.D{
-				{Key: fieldEtag, Value: etag},
-				{Key: fieldState, Value: toState},
-				{Key: fieldLastModified, Value: now},
-				{Key: timestampField, Value: now}}},
-		})
-
-	return err
+	update := Update{Set: []Field{
+		{Key: fieldEtag, Value: etag},
+		{Key: fieldState, Value: toState},
+		{Key: fieldLastModified, Value: now},
+		{Key: timestampField, Value: now},
+	}}
+	if toState == StateUploaded {
+		if err = store.verifyUploadChecksums(ctx, path, checksumSHA256, checksumMD5, logdata); err != nil {
+			return nil, err
+		}
+		update = checksumUpdate(update, metadata, checksumSHA256, checksumMD5)
+	}
+
+	return &stateChange{metadata: metadata, toState: toState, update: update, logdata: logdata}, nil
 }
 
+// applyStateChange makes a checked state change. The outbox messages are written as pending before the change so that
+// a changed file always has messages, and are released once the change has been made; if the change fails the messages
+// are withdrawn again.
+func (store *Store) applyStateChange(ctx context.Context, sc *stateChange) error {
+	outboxIDs, err := store.stageStateChange(ctx, sc, store.updateMetadata)
+	if err != nil {
+		return err
+	}
+	store.commitStateChange(ctx, sc, outboxIDs)
+	return nil
+}
+
+// stageStateChange makes a checked state change with update, returning the outbox messages written for it. The
+// messages are left pending, for commitStateChange to release once the change is to stand or rollBackStateChange to
+// withdraw.
+func (store *Store) stageStateChange(ctx context.Context, sc *stateChange, update func(context.Context, files.StoredRegisteredMetaData, Update) error) ([]string, error) {
+	if err := checkIfMatch(ctx, sc.metadata); err != nil {
+		return nil, err
+	}
+
+	if sc.archive {
+		if err := store.archiveFileVersion(ctx, sc.metadata); err != nil {
+			return nil, err
+		}
+	}
+
+	var outboxIDs []string
+	if sc.toState == StatePublished {
+		ids, err := store.enqueueFilePublished(ctx, &sc.metadata)
+		if err != nil {
+			return nil, err
+		}
+		outboxIDs = ids
+	} else {
+		id, err := store.enqueueFileLifecycle(ctx, sc.metadata.Path, files.LifecycleStateChanged, sc.metadata.State, sc.toState)
+		if err != nil {
+			return nil, err
+		}
+		outboxIDs = []string{id}
+	}
+
+	if err := update(ctx, sc.metadata, sc.update); err != nil {
+		store.withdrawOutboxMessages(ctx, outboxIDs)
+		log.Error(ctx, "error while updating file metadata", err, sc.logdata)
+		return nil, err
+	}
+	return outboxIDs, nil
+}
+
+// commitStateChange releases the outbox messages of a staged state change and records the change
+func (store *Store) commitStateChange(ctx context.Context, sc *stateChange, outboxIDs []string) {
+	store.releaseOutboxMessages(ctx, outboxIDs)
+	store.recordFileChange(ctx, sc.metadata, files.LifecycleStateChanged, sc.metadata.State, sc.toState)
+
+	log.Info(ctx, fmt.Sprintf("file set as %s", sc.toState), sc.logdata)
+}
+
+// rollBackStateChange undoes a staged state change, putting back the fields it changed while the file is still at the
+// revision the change moved it to, and withdraws its outbox messages. It returns ErrFileModified if the file has been
+// changed again since, in which case the change stands.
+func (store *Store) rollBackStateChange(ctx context.Context, sc *stateChange, outboxIDs []string) error {
+	revert, err := revertUpdate(sc.metadata, sc.update)
+	if err != nil {
+		log.Error(ctx, "failed to work out how to roll back file state change", err, sc.logdata)
+		return err
+	}
+
+	reverted, err := store.repo.UpdateMetadataIfUnchanged(ctx, sc.metadata.Path, sc.metadata.Revision+1, sc.metadata.CreatedAt, revert)
+	if err != nil {
+		log.Error(ctx, "failed to roll back file state change", err, sc.logdata)
+		return err
+	}
+	if !reverted {
+		log.Error(ctx, "file was changed before its state change could be rolled back", ErrFileModified, sc.logdata)
+		return ErrFileModified
+	}
+
+	store.withdrawOutboxMessages(ctx, outboxIDs)
+	if sc.archive {
+		// the version kept for the change is the metadata the file has again
+		if err := store.repo.DeleteFileVersion(ctx, sc.metadata.Path, currentVersion(sc.metadata)); err != nil {
+			log.Error(ctx, "failed to remove file version kept for rolled back state change", err, sc.logdata)
+		}
+	}
+
+	log.Info(ctx, fmt.Sprintf("file state change to %s rolled back", sc.toState), sc.logdata)
+	return nil
+}
+
+// revertUpdate is the update that puts back the fields of metadata changed by update
+func revertUpdate(metadata files.StoredRegisteredMetaData, update Update) (Update, error) {
+	doc, err := bson.Marshal(metadata)
+	if err != nil {
+		return Update{}, err
+	}
+
+	revert := Update{}
+	restore := func(key string) {
+		if value, err := bson.Raw(doc).LookupErr(strings.Split(key, ".")...); err == nil {
+			revert.Set = append(revert.Set, Field{Key: key, Value: value})
+		} else {
+			revert.Unset = append(revert.Unset, key)
+		}
+	}
+	for _, f := range update.Set {
+		restore(f.Key)
+	}
+	for _, key := range update.Unset {
+		restore(key)
+	}
+	return revert, nil
+}
+
+// verifyUploadChecksums checks any expected checksums of an upload against the object in the private bucket
+func (store *Store) verifyUploadChecksums(ctx context.Context, path, checksumSHA256, checksumMD5 string, logdata log.Data) error {
+	if checksumSHA256 == "" && checksumMD5 == "" {
+		return nil
+	}
+
+	head, err := store.s3client.Head(ctx, path)
+	if err != nil {
+		log.Error(ctx, fmt.Sprintf("Failed trying to get head data for %s from bucket %s", path, store.cfg.PrivateBucketName), err)
+		return err
+	}
+	if err = verifyChecksums(head, checksumSHA256, checksumMD5); err != nil {
+		log.Error(ctx, "update file state: checksums do not match the uploaded file", err, logdata)
+		return err
+	}
+
+	return nil
+}
+
+// RemoveFile moves an UPLOADED file to the trash, where it can be restored with RestoreFile until it is purged by
+// PurgeTrash. Moved files cannot be removed, and ErrFileNotRegistered is returned if the file is removed by another
+// request first.
 func (store *Store) RemoveFile(ctx context.Context, path string, fileMetadata files.StoredRegisteredMetaData) error {
 	logData := log.Data{"path": path}
 
@@ -289,40 +477,58 @@ func (store *Store) RemoveFile(ctx context.Context, path string, fileMetadata fi
 		return ErrFileIsPublished
 	}
 
+	if err := checkIfMatch(ctx, fileMetadata); err != nil {
+		return err
+	}
+
 	if fileMetadata.State == StateUploaded {
-		// delete the file from s3
-		err := store.s3client.Delete(ctx, path)
+		// the file is kept in the trash, along with its object in s3, until it is restored or purged
+		trashed, err := store.trashFile(ctx, fileMetadata)
+		if err != nil {
+			log.Error(ctx, "remove file: error while moving file to trash", err, logData)
+			return err
+		}
+		log.Info(ctx, "remove file: file moved to trash", logData)
+
+		outboxID, err := store.enqueueFileLifecycle(ctx, path, files.LifecycleRemoved, fileMetadata.State, "")
 		if err != nil {
-			log.Error(ctx, "remove file: error while deleting file from s3", err, logData)
+			store.untrashFile(ctx, trashed)
 			return err
 		}
-		log.Info(ctx, "remove file: file deleted from s3", logData)
 
 		// delete the file metadata
-		result, err := store.metadataCollection.Delete(ctx, bson.M{fieldPath: path})
+		deleted, err := store.deleteMetadata(ctx, fileMetadata)
 		if err != nil {
+			store.withdrawOutboxMessages(ctx, []string{outboxID})
+			store.untrashFile(ctx, trashed)
 			log.Error(ctx, "remove file: error while deleting metadata", err, logData)
 			return err
 		}
-		if result.DeletedCount > 0 {
-			log.Info(ctx, "remove file: metadata deleted", logData)
+		if !deleted {
+			// the file was removed by another request since it was looked up
+			store.withdrawOutboxMessages(ctx, []string{outboxID})
+			store.untrashFile(ctx, trashed)
+			log.Error(ctx, "remove file: file no longer registered", ErrFileNotRegistered, logData)
+			return ErrFileNotRegistered
 		}
+		log.Info(ctx, "remove file: metadata deleted", logData)
+		store.releaseOutboxMessages(ctx, []string{outboxID})
+		store.recordFileChange(ctx, fileMetadata, files.LifecycleRemoved, fileMetadata.State, "")
 
 		// if the file is the only one associated with a bundle then the bundle record is removed from the database
 		if fileMetadata.BundleID != nil {
-			var m []files.StoredRegisteredMetaData
-			_, err = store.metadataCollection.Find(ctx, bson.M{fieldBundleID: *fileMetadata.BundleID}, &m)
+			m, err := store.repo.FindMetadata(ctx, MetadataFilter{BundleID: fileMetadata.BundleID})
 			if err != nil && !errors.Is(err, mongodriver.ErrNoDocumentFound) {
 				log.Error(ctx, "remove file: error while finding metadata", err, logData)
 				return err
 			}
 			if len(m) == 0 {
-				result, err = store.bundlesCollection.Delete(ctx, bson.M{fieldID: *fileMetadata.BundleID})
+				deleted, err = store.repo.DeleteBundle(ctx, *fileMetadata.BundleID)
 				if err != nil {
 					log.Error(ctx, "remove file: error while deleting bundle record", err, logData)
 					return err
 				}
-				if result.DeletedCount > 0 {
+				if deleted {
 					log.Info(ctx, "remove file: bundle record deleted", logData)
 				}
 			}
@@ -332,3 +538,14 @@ func (store *Store) RemoveFile(ctx context.Context, path string, fileMetadata fi
 
 	return nil
 }
+
+// sameID reports whether an existing file's collection or bundle ID is set and is the one it is registered with again
+func sameID(existing, registered *string) bool {
+	return existing != nil && registered != nil && *existing == *registered
+}
+
+// differentID reports whether an existing file's collection or bundle ID is set and differs from the one it is
+// registered with again
+func differentID(existing, registered *string) bool {
+	return existing != nil && registered != nil && *existing != *registered
+}
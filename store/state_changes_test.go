@@ -10,7 +10,6 @@ import (
 	"github.com/ONSdigital/dp-files-api/mongo/mock"
 	"github.com/ONSdigital/dp-files-api/store"
 	"github.com/ONSdigital/dp-healthcheck/healthcheck"
-	"github.com/ONSdigital/dp-kafka/v3/kafkatest"
 	mongodriver "github.com/ONSdigital/dp-mongodb/v3/mongodb"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"go.mongodb.org/mongo-driver/bson"
@@ -42,7 +41,7 @@ func (suite *StoreSuite) TestRegisterFileUploadCollectionPublishedCheckError() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&metadataCollection, &collCollection, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &metadataCollection, Collections: &collCollection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 	err := subject.RegisterFileUpload(suite.defaultContext, metadata)
 
 	suite.Equal(true, suite.logInterceptor.IsEventPresent("collection published check error"))
@@ -71,7 +70,7 @@ func (suite *StoreSuite) TestRegisterFileUploadBundlePublishedCheckError() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&metadataCollection, nil, &bundleCollection, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &metadataCollection, Bundles: &bundleCollection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 	err := subject.RegisterFileUpload(suite.defaultContext, metadata)
 
 	suite.Equal(true, suite.logInterceptor.IsEventPresent("bundle published check error"))
@@ -102,7 +101,7 @@ func (suite *StoreSuite) TestRegisterFileUploadWhenCollectionAlreadyPublished()
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&metadataCollection, &collCollection, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &metadataCollection, Collections: &collCollection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 	err := subject.RegisterFileUpload(suite.defaultContext, metadata)
 
 	logEvent := suite.logInterceptor.GetLogEvent()
@@ -135,7 +134,7 @@ func (suite *StoreSuite) TestRegisterFileUploadWhenBundleAlreadyPublished() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&metadataCollection, nil, &bundleCollection, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &metadataCollection, Bundles: &bundleCollection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 	err := subject.RegisterFileUpload(suite.defaultContext, metadata)
 
 	logEvent := suite.logInterceptor.GetLogEvent()
@@ -162,7 +161,7 @@ func (suite *StoreSuite) TestRegisterFileUploadWhenFilePathAlreadyExistsCollecti
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&alwaysFindsExistingCollection, &emptyCollection, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &alwaysFindsExistingCollection, Collections: &emptyCollection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 	err := subject.RegisterFileUpload(suite.defaultContext, metadata)
 
 	logEvent := suite.logInterceptor.GetLogEvent()
@@ -189,7 +188,7 @@ func (suite *StoreSuite) TestRegisterFileUploadWhenFilePathAlreadyExistsBundle()
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&alwaysFindsExistingCollection, nil, nil, &emptyCollection, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &alwaysFindsExistingCollection, FileEvents: &emptyCollection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 	err := subject.RegisterFileUpload(suite.defaultContext, metadata)
 
 	logEvent := suite.logInterceptor.GetLogEvent()
@@ -237,7 +236,7 @@ func (suite *StoreSuite) TestRegisterFileUploadWhenFileDoesNotAlreadyExist() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionCountReturnsZero, &collCollection, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionCountReturnsZero, Collections: &collCollection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 	err := subject.RegisterFileUpload(suite.defaultContext, metadata)
 
 	suite.NoError(err)
@@ -263,7 +262,7 @@ func (suite *StoreSuite) TestRegisterFileUploadInsertReturnsError() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionCountReturnsZero, &emptyCollection, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionCountReturnsZero, Collections: &emptyCollection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 	err := subject.RegisterFileUpload(suite.defaultContext, metadata)
 
 	logEvent := suite.logInterceptor.GetLogEvent()
@@ -293,7 +292,7 @@ func (suite *StoreSuite) TestRegisterFileUploadInsertSucceeds() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionCountReturnsZero, &emptyCollection, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionCountReturnsZero, Collections: &emptyCollection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 	err := subject.RegisterFileUpload(suite.defaultContext, metadata)
 
 	logEvent := suite.logInterceptor.GetLogEvent()
@@ -323,7 +322,7 @@ func (suite *StoreSuite) TestRegisterFileUploadRegisterCollectionFails() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionCountReturnsZero, &emptyCollection, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionCountReturnsZero, Collections: &emptyCollection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 	err := subject.RegisterFileUpload(suite.defaultContext, metadata)
 
 	suite.Equal(true, suite.logInterceptor.IsEventPresent("failed to register collection"))
@@ -352,7 +351,7 @@ func (suite *StoreSuite) TestRegisterFileUploadRegisterBundleFails() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionCountReturnsZero, nil, &emptyCollection, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionCountReturnsZero, Bundles: &emptyCollection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 	err := subject.RegisterFileUpload(suite.defaultContext, metadata)
 
 	suite.Equal(true, suite.logInterceptor.IsEventPresent("failed to register bundle"))
@@ -389,7 +388,7 @@ func (suite *StoreSuite) TestMarkUploadCompleteFailsWhenNotInCreatedState() {
 		}
 
 		cfg, _ := config.Get()
-		subject := store.NewStore(&collectionWithUploadedFile, &emptyCollection, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+		subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionWithUploadedFile, Collections: &emptyCollection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 		err := subject.MarkUploadComplete(suite.defaultContext, suite.etagReference(metadata))
 
 		logEvents := suite.logInterceptor.GetLogEvents("update file state: state mismatch")
@@ -418,7 +417,7 @@ func (suite *StoreSuite) TestMarkUploadCompleteFailsWhenFileNotExists() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionWithUploadedFile, &emptyCollection, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionWithUploadedFile, Collections: &emptyCollection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 	err := subject.MarkUploadComplete(suite.defaultContext, suite.etagReference(metadata))
 
 	logEvents := suite.logInterceptor.GetLogEvents("update file state: attempted to operate on unregistered file")
@@ -446,7 +445,7 @@ func (suite *StoreSuite) TestMarkUploadCompleteFailsWhenUpdateReturnsError() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionWithUploadedFile, &collectionsCollection, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionWithUploadedFile, Collections: &collectionsCollection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 	err := subject.MarkUploadComplete(suite.defaultContext, suite.etagReference(metadata))
 
 	suite.Error(err)
@@ -470,7 +469,7 @@ func (suite *StoreSuite) TestMarkUploadCompleteSucceeds() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionWithUploadedFile, &collectionsCollection, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionWithUploadedFile, Collections: &collectionsCollection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 	err := subject.MarkUploadComplete(suite.defaultContext, suite.etagReference(metadata))
 
 	suite.NoError(err)
@@ -506,7 +505,7 @@ func (suite *StoreSuite) TestMarkFileMovedFailsWhenNotInCreatedState() {
 		}
 
 		cfg, _ := config.Get()
-		subject := store.NewStore(&collectionWithUploadedFile, &emptyCollection, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+		subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionWithUploadedFile, Collections: &emptyCollection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 		err := subject.MarkFileMoved(suite.defaultContext, suite.etagReference(metadata))
 
 		logEvents := suite.logInterceptor.GetLogEvents("update file state: state mismatch")
@@ -530,7 +529,7 @@ func (suite *StoreSuite) TestMarkFileMovedFailsWhenFileNotExists() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionWithUploadedFile, nil, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionWithUploadedFile, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 	err := subject.MarkFileMoved(suite.defaultContext, suite.etagReference(metadata))
 
 	logEvents := suite.logInterceptor.GetLogEvents("update file state: attempted to operate on unregistered file")
@@ -566,7 +565,7 @@ func (suite *StoreSuite) TestMarkFileMovedFailsWhenUpdateReturnsError() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionWithUploadedFile, &collectionsCollection, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, s3Client, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionWithUploadedFile, Collections: &collectionsCollection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, s3Client, cfg)
 	err := subject.MarkFileMoved(suite.defaultContext, suite.etagReference(metadata))
 
 	suite.Error(err)
@@ -596,7 +595,7 @@ func (suite *StoreSuite) TestMarkFileMovedEtagMismatch() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionWithUploadedFile, &collectionsCollection, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, s3Client, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionWithUploadedFile, Collections: &collectionsCollection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, s3Client, cfg)
 	err := subject.MarkFileMoved(suite.defaultContext, suite.etagReference(metadata))
 
 	suite.ErrorIs(err, store.ErrEtagMismatchWhilePublishing, "the actual err was %v", err)
@@ -625,7 +624,7 @@ func (suite *StoreSuite) TestMarkFileMovedSucceeds() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionWithUploadedFile, &collectionsCollection, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, s3Client, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionWithUploadedFile, Collections: &collectionsCollection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, s3Client, cfg)
 	err := subject.MarkFileMoved(suite.defaultContext, suite.etagReference(metadata))
 
 	suite.NoError(err)
@@ -640,7 +639,7 @@ func (suite *StoreSuite) TestMarkFilePublishedFindReturnsErrNoDocumentFound() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionWithUploadedFile, nil, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionWithUploadedFile, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 	err := subject.MarkFilePublished(suite.defaultContext, suite.path)
 
 	logEvents := suite.logInterceptor.GetLogEvents("mark file as published: attempted to operate on unregistered file")
@@ -661,7 +660,7 @@ func (suite *StoreSuite) TestMarkFilePublishedFindReturnsUnspecifiedError() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionWithUploadedFile, nil, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionWithUploadedFile, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 	err := subject.MarkFilePublished(suite.defaultContext, suite.path)
 
 	logEvent := suite.logInterceptor.GetLogEvent()
@@ -684,7 +683,7 @@ func (suite *StoreSuite) TestMarkFilePublishedCollectionIDNil() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionWithUploadedFile, nil, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionWithUploadedFile, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 	err := subject.MarkFilePublished(suite.defaultContext, suite.path)
 
 	logEvent := suite.logInterceptor.GetLogEvent()
@@ -713,7 +712,7 @@ func (suite *StoreSuite) TestMarkFilePublishedStateUploaded() {
 		}
 
 		cfg, _ := config.Get()
-		subject := store.NewStore(&collectionWithUploadedFile, nil, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+		subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionWithUploadedFile, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 		err := subject.MarkFilePublished(suite.defaultContext, suite.path)
 
 		logEvent := suite.logInterceptor.GetLogEvent()
@@ -742,7 +741,7 @@ func (suite *StoreSuite) TestMarkFilePublishedUpdateReturnsError() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionWithUploadedFile, &emptyCollection, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionWithUploadedFile, Collections: &emptyCollection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	err := subject.MarkFilePublished(suite.defaultContext, suite.path)
 
@@ -750,7 +749,7 @@ func (suite *StoreSuite) TestMarkFilePublishedUpdateReturnsError() {
 	suite.ErrorIs(err, expectedError)
 }
 
-func (suite *StoreSuite) TestMarkFilePublishedUpdateKafkaReturnsError() {
+func (suite *StoreSuite) TestMarkFilePublishedOutboxInsertReturnsError() {
 	metadata := suite.generateCollectionMetadata(suite.defaultCollectionID)
 	metadata.State = store.StateUploaded
 	metadataBytes, _ := bson.Marshal(metadata)
@@ -765,22 +764,22 @@ func (suite *StoreSuite) TestMarkFilePublishedUpdateKafkaReturnsError() {
 			return mongodriver.ErrNoDocumentFound
 		},
 	}
-
-	kafkaMock := kafkatest.IProducerMock{
-		SendFunc: KafkaSendReturnsError(expectedError),
+	outboxCollection := mock.MongoCollectionMock{
+		InsertFunc: CollectionInsertReturnsNilAndError(expectedError),
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionWithUploadedFile, &emptyCollection, nil, nil, &kafkaMock, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionWithUploadedFile, Collections: &emptyCollection, Outbox: &outboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	err := subject.MarkFilePublished(suite.defaultContext, suite.path)
 
-	suite.Equal(1, len(kafkaMock.SendCalls()))
+	suite.Equal(1, len(outboxCollection.InsertCalls()))
+	suite.Equal(0, len(collectionWithUploadedFile.UpdateCalls()))
 	suite.Error(err)
 	suite.ErrorIs(err, expectedError)
 }
 
-func (suite *StoreSuite) TestMarkFilePublishedUpdateKafkaDoesNotReturnError() {
+func (suite *StoreSuite) TestMarkFilePublishedWritesMessageToOutbox() {
 	metadata := suite.generateCollectionMetadata(suite.defaultCollectionID)
 	metadata.State = store.StateUploaded
 	metadataBytes, _ := bson.Marshal(metadata)
@@ -794,18 +793,64 @@ func (suite *StoreSuite) TestMarkFilePublishedUpdateKafkaDoesNotReturnError() {
 			return mongodriver.ErrNoDocumentFound
 		},
 	}
-
-	kafkaMock := kafkatest.IProducerMock{
-		SendFunc: KafkaSendReturnsNil(),
+	outboxCollection := mock.MongoCollectionMock{
+		InsertFunc: CollectionInsertReturnsNilAndNil(),
+		UpdateManyFunc: func(ctx context.Context, selector, update interface{}) (*mongodriver.CollectionUpdateResult, error) {
+			suite.Len(collectionWithUploadedFile.UpdateCalls(), 1, "the message is released once the file is published")
+			return nil, nil
+		},
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionWithUploadedFile, &emptyCollection, nil, nil, &kafkaMock, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionWithUploadedFile, Collections: &emptyCollection, Outbox: &outboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 
 	err := subject.MarkFilePublished(suite.defaultContext, suite.path)
 
-	suite.Equal(1, len(kafkaMock.SendCalls()))
 	suite.NoError(err)
+	suite.Require().Equal(1, len(outboxCollection.InsertCalls()))
+
+	msg := outboxCollection.InsertCalls()[0].Document.(files.OutboxMessage)
+	suite.Equal(cfg.StaticFilePublishedTopic, msg.Topic)
+	suite.Equal(metadata.Path, msg.FilePublished.Path)
+	suite.Equal(metadata.Etag, msg.FilePublished.Etag)
+	suite.Equal(suite.defaultClock.GetCurrentTime(), msg.NextAttemptAt)
+	suite.True(msg.Pending)
+
+	suite.Require().Len(outboxCollection.UpdateManyCalls(), 1)
+	suite.Equal(bson.M{"id": bson.M{"$in": []string{msg.ID}}}, outboxCollection.UpdateManyCalls()[0].Selector)
+	suite.Equal(bson.M{"$unset": bson.M{"pending": ""}}, outboxCollection.UpdateManyCalls()[0].Update)
+}
+
+func (suite *StoreSuite) TestMarkFilePublishedUpdateErrorWithdrawsOutboxMessage() {
+	metadata := suite.generateCollectionMetadata(suite.defaultCollectionID)
+	metadata.State = store.StateUploaded
+	metadataBytes, _ := bson.Marshal(metadata)
+	expectedError := errors.New("an error occurred")
+
+	collectionWithUploadedFile := mock.MongoCollectionMock{
+		FindOneFunc: CollectionFindOneSetsResultAndReturnsNil(metadataBytes),
+		UpdateFunc:  CollectionUpdateReturnsNilAndError(expectedError),
+	}
+	emptyCollection := mock.MongoCollectionMock{
+		FindOneFunc: func(ctx context.Context, filter, result interface{}, opts ...mongodriver.FindOption) error {
+			return mongodriver.ErrNoDocumentFound
+		},
+	}
+	outboxCollection := mock.MongoCollectionMock{
+		InsertFunc: CollectionInsertReturnsNilAndNil(),
+		DeleteFunc: CollectionDeleteReturnsNilAndNil(),
+	}
+
+	cfg, _ := config.Get()
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionWithUploadedFile, Collections: &emptyCollection, Outbox: &outboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
+
+	err := subject.MarkFilePublished(suite.defaultContext, suite.path)
+
+	suite.ErrorIs(err, expectedError)
+	suite.Require().Equal(1, len(outboxCollection.DeleteCalls()))
+
+	msg := outboxCollection.InsertCalls()[0].Document.(files.OutboxMessage)
+	suite.Equal(bson.M{"id": msg.ID}, outboxCollection.DeleteCalls()[0].Selector)
 }
 
 func (suite *StoreSuite) TestRemoveFile_MetadataInMovedState() {
@@ -816,7 +861,7 @@ func (suite *StoreSuite) TestRemoveFile_MetadataInMovedState() {
 	metadata.State = store.StateMoved
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(nil, nil, nil, nil, &suite.defaultKafkaProducer, suite.defaultClock, nil, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection}), suite.defaultClock, nil, cfg)
 	err := subject.RemoveFile(suite.defaultContext, suite.path, metadata)
 
 	logEvent := suite.logInterceptor.GetLogEvent()
@@ -826,7 +871,7 @@ func (suite *StoreSuite) TestRemoveFile_MetadataInMovedState() {
 	suite.ErrorIs(err, store.ErrFileIsPublished)
 }
 
-func (suite *StoreSuite) TestRemoveFile_S3Error() {
+func (suite *StoreSuite) TestRemoveFile_TrashError() {
 	suite.logInterceptor.Start()
 	defer suite.logInterceptor.Stop()
 
@@ -836,25 +881,23 @@ func (suite *StoreSuite) TestRemoveFile_S3Error() {
 
 	expectedError := errors.New("an error occurred")
 
-	s3Client := &s3Mock.S3ClienterMock{
-		DeleteFunc: func(ctx context.Context, key string) error {
-			return expectedError
-		},
-	}
+	s3Client := &s3Mock.S3ClienterMock{}
 
-	bundlesCollectionReturnsOK := mock.MongoCollectionMock{
-		FindOneFunc: BundleFindOneSucceeds(),
+	trashCollectionReturnsError := mock.MongoCollectionMock{
+		InsertFunc: func(ctx context.Context, document interface{}) (*mongodriver.CollectionInsertResult, error) {
+			return nil, expectedError
+		},
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(nil, nil, &bundlesCollectionReturnsOK, nil, &suite.defaultKafkaProducer, suite.defaultClock, s3Client, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection, Trash: &trashCollectionReturnsError}), suite.defaultClock, s3Client, cfg)
 	err := subject.RemoveFile(suite.defaultContext, suite.path, metadata)
 
 	logEvent := suite.logInterceptor.GetLogEvent()
 
-	suite.Equal("remove file: error while deleting file from s3", logEvent)
-	suite.Error(err)
+	suite.Equal("remove file: error while moving file to trash", logEvent)
 	suite.ErrorIs(err, expectedError)
+	suite.Empty(suite.defaultOutboxCollection.InsertCalls())
 }
 
 func (suite *StoreSuite) TestRemoveFile_MetadataCollectionDeleteReturnAnError() {
@@ -883,7 +926,7 @@ func (suite *StoreSuite) TestRemoveFile_MetadataCollectionDeleteReturnAnError()
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionWithUploadedFile, nil, &bundlesCollectionReturnsOK, nil, &suite.defaultKafkaProducer, suite.defaultClock, s3Client, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionWithUploadedFile, Bundles: &bundlesCollectionReturnsOK, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection, Trash: &suite.defaultTrashCollection}), suite.defaultClock, s3Client, cfg)
 	err := subject.RemoveFile(suite.defaultContext, suite.path, metadata)
 
 	suite.Equal(true, suite.logInterceptor.IsEventPresent("remove file: error while deleting metadata"))
@@ -909,7 +952,7 @@ func (suite *StoreSuite) TestRemoveFile_TestErrorWhileFindingMetadata() {
 
 	collectionWithUploadedFile := mock.MongoCollectionMock{
 		DeleteFunc: func(ctx context.Context, selector interface{}) (*mongodriver.CollectionDeleteResult, error) {
-			return &mongodriver.CollectionDeleteResult{}, nil
+			return &mongodriver.CollectionDeleteResult{DeletedCount: 1}, nil
 		},
 		FindFunc: func(ctx context.Context, filter interface{}, results interface{}, opts ...mongodriver.FindOption) (int, error) {
 			return 0, expectedError
@@ -921,7 +964,7 @@ func (suite *StoreSuite) TestRemoveFile_TestErrorWhileFindingMetadata() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionWithUploadedFile, nil, &bundlesCollectionReturnsOK, nil, &suite.defaultKafkaProducer, suite.defaultClock, s3Client, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionWithUploadedFile, Bundles: &bundlesCollectionReturnsOK, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection, Trash: &suite.defaultTrashCollection}), suite.defaultClock, s3Client, cfg)
 	err := subject.RemoveFile(suite.defaultContext, suite.path, metadata)
 
 	suite.Equal(true, suite.logInterceptor.IsEventPresent("remove file: error while finding metadata"))
@@ -947,7 +990,7 @@ func (suite *StoreSuite) TestRemoveFile_TestErrorWhileDeletingBundleRecord() {
 
 	collectionWithUploadedFile := mock.MongoCollectionMock{
 		DeleteFunc: func(ctx context.Context, selector interface{}) (*mongodriver.CollectionDeleteResult, error) {
-			return &mongodriver.CollectionDeleteResult{}, nil
+			return &mongodriver.CollectionDeleteResult{DeletedCount: 1}, nil
 		},
 		FindFunc: func(ctx context.Context, filter interface{}, results interface{}, opts ...mongodriver.FindOption) (int, error) {
 			return 0, nil
@@ -962,7 +1005,7 @@ func (suite *StoreSuite) TestRemoveFile_TestErrorWhileDeletingBundleRecord() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionWithUploadedFile, nil, &bundlesCollectionReturnsOK, nil, &suite.defaultKafkaProducer, suite.defaultClock, s3Client, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionWithUploadedFile, Bundles: &bundlesCollectionReturnsOK, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection, Trash: &suite.defaultTrashCollection}), suite.defaultClock, s3Client, cfg)
 	err := subject.RemoveFile(suite.defaultContext, suite.path, metadata)
 
 	suite.Equal(true, suite.logInterceptor.IsEventPresent("remove file: error while deleting bundle record"))
@@ -986,7 +1029,7 @@ func (suite *StoreSuite) TestRemoveFile_TestBundleRecordDeletedSuccessfully() {
 
 	collectionWithUploadedFile := mock.MongoCollectionMock{
 		DeleteFunc: func(ctx context.Context, selector interface{}) (*mongodriver.CollectionDeleteResult, error) {
-			return &mongodriver.CollectionDeleteResult{}, nil
+			return &mongodriver.CollectionDeleteResult{DeletedCount: 1}, nil
 		},
 		FindFunc: func(ctx context.Context, filter interface{}, results interface{}, opts ...mongodriver.FindOption) (int, error) {
 			return 0, nil
@@ -1003,9 +1046,37 @@ func (suite *StoreSuite) TestRemoveFile_TestBundleRecordDeletedSuccessfully() {
 	}
 
 	cfg, _ := config.Get()
-	subject := store.NewStore(&collectionWithUploadedFile, nil, &bundlesCollectionReturnsOK, nil, &suite.defaultKafkaProducer, suite.defaultClock, s3Client, cfg)
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionWithUploadedFile, Bundles: &bundlesCollectionReturnsOK, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection, Trash: &suite.defaultTrashCollection}), suite.defaultClock, s3Client, cfg)
 	err := subject.RemoveFile(suite.defaultContext, suite.path, metadata)
 
 	suite.Equal(true, suite.logInterceptor.IsEventPresent("remove file: bundle record deleted"))
 	suite.NoError(err)
+	suite.Empty(s3Client.DeleteCalls(), "the file is kept in s3 until it is purged from the trash")
+}
+
+func (suite *StoreSuite) TestRemoveFile_FileAlreadyRemoved() {
+	suite.logInterceptor.Start()
+	defer suite.logInterceptor.Stop()
+
+	metadata := suite.generateBundleMetadata(suite.defaultBundleID)
+	metadata.State = store.StateUploaded
+	metadata.Etag = testEtag
+
+	collectionWithoutFile := mock.MongoCollectionMock{
+		DeleteFunc: func(ctx context.Context, selector interface{}) (*mongodriver.CollectionDeleteResult, error) {
+			return &mongodriver.CollectionDeleteResult{}, nil
+		},
+	}
+	bundlesCollection := mock.MongoCollectionMock{}
+
+	cfg, _ := config.Get()
+	subject := store.NewStore(store.NewMongoRepository(store.MongoCollections{Metadata: &collectionWithoutFile, Bundles: &bundlesCollection, Outbox: &suite.defaultOutboxCollection, PublishJobs: &suite.defaultPublishJobsCollection, FileVersions: &suite.defaultFileVersionsCollection, FileChanges: &suite.defaultFileChangesCollection, Trash: &suite.defaultTrashCollection}), suite.defaultClock, nil, cfg)
+	err := subject.RemoveFile(suite.defaultContext, suite.path, metadata)
+
+	suite.ErrorIs(err, store.ErrFileNotRegistered)
+	suite.True(suite.logInterceptor.IsEventPresent("remove file: file no longer registered"))
+	suite.Len(suite.defaultOutboxCollection.DeleteCalls(), 1, "the removal message is withdrawn")
+	suite.Len(suite.defaultTrashCollection.DeleteCalls(), 1, "the file is taken out of the trash again")
+	suite.Empty(collectionWithoutFile.FindCalls(), "the bundle is left alone")
+	suite.Empty(bundlesCollection.DeleteCalls())
 }
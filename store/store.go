@@ -1,24 +1,37 @@
 package store
 
 import (
+	"sync"
+
 	"github.com/ONSdigital/dp-files-api/aws"
 	"github.com/ONSdigital/dp-files-api/clock"
 	"github.com/ONSdigital/dp-files-api/config"
-	"github.com/ONSdigital/dp-files-api/mongo"
-	kafka "github.com/ONSdigital/dp-kafka/v3"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type Store struct {
-	metadataCollection    mongo.MongoCollection
-	collectionsCollection mongo.MongoCollection
-	bundlesCollection     mongo.MongoCollection
-	fileEventsCollection  mongo.MongoCollection
-	kafka                 kafka.IProducer
-	clock                 clock.Clock
-	s3client              aws.S3Clienter
-	cfg                   *config.Config
+	repo     Repository
+	clock    clock.Clock
+	s3client aws.S3Clienter
+	cfg      *config.Config
+
+	// instanceID identifies this instance of the service as the owner of the publish jobs it runs
+	instanceID string
+
+	changedMu sync.Mutex
+	changed   chan struct{}
+
+	// fileEventsMu is held while file events are given their place in the chain
+	fileEventsMu sync.Mutex
 }
 
-func NewStore(metadataCollection, collectionsCollection, bundlesCollection, fileEventsCollection mongo.MongoCollection, kafkaProducer kafka.IProducer, clk clock.Clock, c aws.S3Clienter, cfg *config.Config) *Store {
-	return &Store{metadataCollection, collectionsCollection, bundlesCollection, fileEventsCollection, kafkaProducer, clk, c, cfg}
+func NewStore(repo Repository, clk clock.Clock, c aws.S3Clienter, cfg *config.Config) *Store {
+	return &Store{
+		repo:       repo,
+		clock:      clk,
+		s3client:   c,
+		cfg:        cfg,
+		instanceID: primitive.NewObjectID().Hex(),
+		changed:    make(chan struct{}),
+	}
 }
@@ -16,9 +16,8 @@ import (
 
 	"github.com/ONSdigital/dp-files-api/features/steps"
 	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/dp-files-api/mongo/mock"
 	"github.com/ONSdigital/dp-files-api/store"
-	"github.com/ONSdigital/dp-kafka/v3/avro"
-	"github.com/ONSdigital/dp-kafka/v3/kafkatest"
 	mongodriver "github.com/ONSdigital/dp-mongodb/v3/mongodb"
 	"github.com/ONSdigital/log.go/v2/log"
 	"github.com/stretchr/testify/suite"
@@ -28,13 +27,18 @@ import (
 
 type StoreSuite struct {
 	suite.Suite
-	logInterceptor       LogInterceptor
-	defaultCollectionID  string
-	defaultBundleID      string
-	path                 string
-	defaultContext       context.Context
-	defaultClock         steps.TestClock
-	defaultKafkaProducer kafkatest.IProducerMock
+	logInterceptor                  LogInterceptor
+	defaultCollectionID             string
+	defaultBundleID                 string
+	path                            string
+	defaultContext                  context.Context
+	defaultClock                    steps.TestClock
+	defaultOutboxCollection         mock.MongoCollectionMock
+	defaultPublishJobsCollection    mock.MongoCollectionMock
+	defaultFileVersionsCollection   mock.MongoCollectionMock
+	defaultFileChangesCollection    mock.MongoCollectionMock
+	defaultTrashCollection          mock.MongoCollectionMock
+	defaultFileEventChainCollection mock.MongoCollectionMock
 }
 
 var (
@@ -49,7 +53,8 @@ type CollectionUpdateFunc func(ctx context.Context, selector interface{}, update
 type CollectionUpdateManyFunc func(ctx context.Context, selector interface{}, update interface{}) (*mongodriver.CollectionUpdateResult, error)
 type CollectionInsertFunc func(ctx context.Context, document interface{}) (*mongodriver.CollectionInsertResult, error)
 type BundleFindOneFunc func(ctx context.Context, filter interface{}, result interface{}, opts ...mongodriver.FindOption) error
-type KafkaSendFunc func(schema *avro.Schema, event interface{}) error
+type CollectionDeleteFunc func(ctx context.Context, selector interface{}) (*mongodriver.CollectionDeleteResult, error)
+type CollectionAggregateFunc func(ctx context.Context, pipeline interface{}, results interface{}) error
 
 func CollectionFindReturnsValueAndError(value int, expectedError error) CollectionFindFunc {
 	return func(ctx context.Context, filter interface{}, results interface{}, opts ...mongodriver.FindOption) (int, error) {
@@ -129,6 +134,12 @@ func CollectionUpdateReturnsNilAndNil() CollectionUpdateFunc {
 	}
 }
 
+func CollectionUpdateReturnsMatchedCountAndNil(matched int) CollectionUpdateFunc {
+	return func(ctx context.Context, selector interface{}, update interface{}) (*mongodriver.CollectionUpdateResult, error) {
+		return &mongodriver.CollectionUpdateResult{MatchedCount: matched}, nil
+	}
+}
+
 func CollectionUpdateReturnsNilAndError(expectedError error) CollectionUpdateFunc {
 	return func(ctx context.Context, selector interface{}, update interface{}) (*mongodriver.CollectionUpdateResult, error) {
 		return nil, expectedError
@@ -220,15 +231,15 @@ func CursorReturnsNumberOfNext(number int) func(ctx context.Context) bool {
 	}
 }
 
-func KafkaSendReturnsError(expectedError error) KafkaSendFunc {
-	return func(schema *avro.Schema, event interface{}) error {
-		return expectedError
+func CollectionAggregateReturnsNil() CollectionAggregateFunc {
+	return func(ctx context.Context, pipeline interface{}, results interface{}) error {
+		return nil
 	}
 }
 
-func KafkaSendReturnsNil() KafkaSendFunc {
-	return func(schema *avro.Schema, event interface{}) error {
-		return nil
+func CollectionDeleteReturnsNilAndNil() CollectionDeleteFunc {
+	return func(ctx context.Context, selector interface{}) (*mongodriver.CollectionDeleteResult, error) {
+		return nil, nil
 	}
 }
 
@@ -239,7 +250,32 @@ func (s *StoreSuite) SetupTest() {
 	s.path = "test.txt"
 	s.defaultContext = context.Background()
 	s.defaultClock = steps.TestClock{}
-	s.defaultKafkaProducer = kafkatest.IProducerMock{}
+	s.defaultOutboxCollection = mock.MongoCollectionMock{
+		InsertFunc:     CollectionInsertReturnsNilAndNil(),
+		UpdateManyFunc: CollectionUpdateManyReturnsNilAndNil(),
+		DeleteFunc:     CollectionDeleteReturnsNilAndNil(),
+	}
+	s.defaultPublishJobsCollection = mock.MongoCollectionMock{
+		InsertFunc: CollectionInsertReturnsNilAndNil(),
+		UpdateFunc: CollectionUpdateReturnsMatchedCountAndNil(1),
+		DeleteFunc: CollectionDeleteReturnsNilAndNil(),
+	}
+	s.defaultFileVersionsCollection = mock.MongoCollectionMock{
+		InsertFunc:    CollectionInsertReturnsNilAndNil(),
+		AggregateFunc: CollectionAggregateReturnsNil(),
+	}
+	s.defaultFileChangesCollection = mock.MongoCollectionMock{
+		FindOneFunc: CollectionFindOneReturnsError(mongodriver.ErrNoDocumentFound),
+		InsertFunc:  CollectionInsertReturnsNilAndNil(),
+	}
+	s.defaultTrashCollection = mock.MongoCollectionMock{
+		InsertFunc: CollectionInsertReturnsNilAndNil(),
+		DeleteFunc: CollectionDeleteReturnsNilAndNil(),
+	}
+	s.defaultFileEventChainCollection = mock.MongoCollectionMock{
+		FindOneFunc: CollectionFindOneReturnsError(mongodriver.ErrNoDocumentFound),
+		UpsertFunc:  CollectionUpdateReturnsMatchedCountAndNil(1),
+	}
 	s.logInterceptor = NewLogInterceptor()
 }
 
@@ -0,0 +1,196 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ONSdigital/dp-files-api/files"
+	mongodriver "github.com/ONSdigital/dp-mongodb/v3/mongodb"
+	dprequest "github.com/ONSdigital/dp-net/v3/request"
+	"github.com/ONSdigital/log.go/v2/log"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// TrashPurgeResource is locked while the trash is purged, so that only one instance of the service purges it
+	TrashPurgeResource = "trash-purge"
+
+	// TrashPurgeIdentity is the identity that files are purged from the trash by in file events
+	TrashPurgeIdentity = "dp-files-api-trash-purge"
+)
+
+// trashFile keeps a copy of the file's metadata in the trash until the trash retention period has passed
+func (store *Store) trashFile(ctx context.Context, m files.StoredRegisteredMetaData) (files.TrashedFile, error) {
+	now := store.clock.GetCurrentTime()
+	trashed := files.TrashedFile{
+		ID:         primitive.NewObjectID().Hex(),
+		Path:       m.Path,
+		Metadata:   m,
+		DeletedAt:  now,
+		DeletedBy:  dprequest.Caller(ctx),
+		PurgeAfter: now.Add(store.cfg.TrashRetention),
+	}
+
+	if err := store.repo.InsertTrashedFile(ctx, trashed); err != nil {
+		return files.TrashedFile{}, err
+	}
+	return trashed, nil
+}
+
+// untrashFile takes a file back out of the trash when it could not be removed
+func (store *Store) untrashFile(ctx context.Context, trashed files.TrashedFile) {
+	if _, err := store.repo.DeleteTrashedFile(ctx, trashed.ID); err != nil {
+		// the file is still registered, so purging the trashed copy leaves its object in s3
+		log.Error(ctx, "failed to take file back out of the trash", err, log.Data{"path": trashed.Path, "trash_id": trashed.ID})
+	}
+}
+
+// GetTrashedFile gets the file most recently removed from the path
+func (store *Store) GetTrashedFile(ctx context.Context, path string) (files.TrashedFile, error) {
+	trashed, err := store.repo.GetTrashedFile(ctx, path)
+	if errors.Is(err, mongodriver.ErrNoDocumentFound) {
+		return files.TrashedFile{}, ErrFileNotInTrash
+	}
+	if err != nil {
+		log.Error(ctx, "failed to get trashed file", err, log.Data{"path": path})
+		return files.TrashedFile{}, err
+	}
+	return trashed, nil
+}
+
+// RestoreFile puts the file most recently removed from the path back as it was when it was removed. It cannot be
+// restored once another file has been registered at the path, or into a collection or bundle that has been published
+// since it was removed.
+func (store *Store) RestoreFile(ctx context.Context, path string) error {
+	logData := log.Data{"path": path}
+
+	trashed, err := store.GetTrashedFile(ctx, path)
+	if err != nil {
+		return err
+	}
+	m := trashed.Metadata
+
+	if m.CollectionID != nil {
+		published, err := store.IsCollectionPublished(ctx, *m.CollectionID)
+		if err != nil {
+			log.Error(ctx, "restore file: collection published check error", err, logData)
+			return err
+		}
+		if published {
+			log.Error(ctx, "restore file: collection is already published", ErrCollectionAlreadyPublished, logData)
+			return ErrCollectionAlreadyPublished
+		}
+	}
+	if m.BundleID != nil {
+		published, err := store.IsBundlePublished(ctx, *m.BundleID)
+		if err != nil {
+			log.Error(ctx, "restore file: bundle published check error", err, logData)
+			return err
+		}
+		if published {
+			log.Error(ctx, "restore file: bundle is already published", ErrBundleAlreadyPublished, logData)
+			return ErrBundleAlreadyPublished
+		}
+	}
+
+	m.LastModified = store.clock.GetCurrentTime()
+	// the restored file must not match ETags given out before it was trashed
+	m.Revision++
+
+	outboxID, err := store.enqueueFileLifecycle(ctx, path, files.LifecycleRestored, "", m.State)
+	if err != nil {
+		return err
+	}
+
+	if err := store.repo.InsertMetadata(ctx, m); err != nil {
+		store.withdrawOutboxMessages(ctx, []string{outboxID})
+		if mongo.IsDuplicateKeyError(err) {
+			log.Error(ctx, "restore file: another file has been registered at the path", ErrDuplicateFile, logData)
+			return ErrDuplicateFile
+		}
+		log.Error(ctx, "restore file: error while inserting metadata", err, logData)
+		return err
+	}
+	log.Info(ctx, "restore file: file restored from trash", logData)
+	store.releaseOutboxMessages(ctx, []string{outboxID})
+	store.recordFileChange(ctx, m, files.LifecycleRestored, "", m.State)
+
+	// a trashed copy left behind is purged without touching the restored file's object in s3
+	if _, err := store.repo.DeleteTrashedFile(ctx, trashed.ID); err != nil {
+		log.Error(ctx, "restore file: error while deleting trashed file", err, logData)
+	}
+	return nil
+}
+
+// PurgeTrash deletes the files that have been in the trash for longer than the trash retention period, along with
+// their objects in s3. Each is recorded as a DELETE file event by TrashPurgeIdentity. Files that cannot be purged are
+// tried again next time, and the last of their errors is returned.
+func (store *Store) PurgeTrash(ctx context.Context) error {
+	expired, err := store.repo.FindExpiredTrashedFiles(ctx, store.clock.GetCurrentTime())
+	if err != nil {
+		log.Error(ctx, "failed to find expired trashed files", err)
+		return err
+	}
+
+	ctx = dprequest.SetCaller(ctx, TrashPurgeIdentity)
+
+	var lastErr error
+	for _, trashed := range expired {
+		if err := store.purgeTrashedFile(ctx, trashed); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+func (store *Store) purgeTrashedFile(ctx context.Context, trashed files.TrashedFile) error {
+	logData := log.Data{"path": trashed.Path, "trash_id": trashed.ID}
+
+	inUse, err := store.isObjectInUse(ctx, trashed)
+	if err != nil {
+		log.Error(ctx, "purge trash: error while checking the file's object is unused", err, logData)
+		return err
+	}
+	if !inUse {
+		if err := store.s3client.Delete(ctx, trashed.Path); err != nil {
+			log.Error(ctx, "purge trash: error while deleting file from s3", err, logData)
+			return err
+		}
+		log.Info(ctx, "purge trash: file deleted from s3", logData)
+	}
+
+	if err := store.CreateFileEvent(ctx, &files.FileEvent{
+		RequestedBy: &files.RequestedBy{ID: TrashPurgeIdentity},
+		Action:      files.ActionDelete,
+		Resource:    trashed.Path,
+		File:        &trashed.Metadata,
+	}); err != nil {
+		return err
+	}
+
+	if _, err := store.repo.DeleteTrashedFile(ctx, trashed.ID); err != nil {
+		log.Error(ctx, "purge trash: error while deleting trashed file", err, logData)
+		return err
+	}
+	log.Info(ctx, "purge trash: trashed file deleted", logData)
+	return nil
+}
+
+// isObjectInUse reports whether the object in s3 at the trashed file's path now belongs to a file registered at the
+// path since, or to a copy of the path removed to the trash more recently
+func (store *Store) isObjectInUse(ctx context.Context, trashed files.TrashedFile) (bool, error) {
+	_, err := store.repo.GetMetadata(ctx, trashed.Path)
+	if err == nil {
+		return true, nil
+	}
+	if !errors.Is(err, mongodriver.ErrNoDocumentFound) {
+		return false, err
+	}
+
+	latest, err := store.repo.GetTrashedFile(ctx, trashed.Path)
+	if err != nil {
+		return false, err
+	}
+	return latest.ID != trashed.ID, nil
+}
@@ -0,0 +1,120 @@
+package store_test
+
+import (
+	"context"
+	"time"
+
+	s3Mock "github.com/ONSdigital/dp-files-api/aws/mock"
+	"github.com/ONSdigital/dp-files-api/config"
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/dp-files-api/store"
+	mongodriver "github.com/ONSdigital/dp-mongodb/v3/mongodb"
+	dprequest "github.com/ONSdigital/dp-net/v3/request"
+)
+
+func (suite *StoreSuite) trashStore() (*store.Store, *store.MemoryRepository, *s3Mock.S3ClienterMock) {
+	repo := store.NewMemoryRepository()
+	s3Client := &s3Mock.S3ClienterMock{
+		DeleteFunc: func(ctx context.Context, key string) error { return nil },
+	}
+
+	cfg, _ := config.Get()
+	return store.NewStore(repo, suite.defaultClock, s3Client, cfg), repo, s3Client
+}
+
+func (suite *StoreSuite) removeUploadedFile(subject *store.Store, repo *store.MemoryRepository, metadata files.StoredRegisteredMetaData) {
+	metadata.State = store.StateUploaded
+	suite.Require().NoError(repo.InsertMetadata(suite.defaultContext, metadata))
+	ctx := dprequest.SetCaller(suite.defaultContext, "publisher@ons.gov.uk")
+	suite.Require().NoError(subject.RemoveFile(ctx, metadata.Path, metadata))
+}
+
+func (suite *StoreSuite) TestRemoveFileKeepsFileInTrash() {
+	subject, repo, s3Client := suite.trashStore()
+
+	suite.removeUploadedFile(subject, repo, files.StoredRegisteredMetaData{Path: suite.path, Title: "Retail sales"})
+
+	_, err := repo.GetMetadata(suite.defaultContext, suite.path)
+	suite.ErrorIs(err, mongodriver.ErrNoDocumentFound)
+	suite.Empty(s3Client.DeleteCalls())
+
+	trashed, err := subject.GetTrashedFile(suite.defaultContext, suite.path)
+	suite.NoError(err)
+	suite.Equal("Retail sales", trashed.Metadata.Title)
+	suite.Equal("publisher@ons.gov.uk", trashed.DeletedBy)
+	suite.Equal(suite.defaultClock.GetCurrentTime(), trashed.DeletedAt)
+	suite.Equal(suite.defaultClock.GetCurrentTime().Add(7*24*time.Hour), trashed.PurgeAfter)
+}
+
+func (suite *StoreSuite) TestRestoreFile() {
+	subject, repo, _ := suite.trashStore()
+	suite.removeUploadedFile(subject, repo, files.StoredRegisteredMetaData{Path: suite.path, Title: "Retail sales"})
+
+	suite.NoError(subject.RestoreFile(suite.defaultContext, suite.path))
+
+	restored, err := repo.GetMetadata(suite.defaultContext, suite.path)
+	suite.NoError(err)
+	suite.Equal(store.StateUploaded, restored.State)
+	suite.Equal("Retail sales", restored.Title)
+
+	_, err = subject.GetTrashedFile(suite.defaultContext, suite.path)
+	suite.ErrorIs(err, store.ErrFileNotInTrash)
+
+	events := suite.lifecycleEvents(repo)
+	suite.Len(events, 2)
+	suite.Equal(files.LifecycleRestored, events[1].Change)
+	suite.Empty(events[1].FromState)
+	suite.Equal(store.StateUploaded, events[1].ToState)
+}
+
+func (suite *StoreSuite) TestRestoreFileRejections() {
+	subject, repo, _ := suite.trashStore()
+	collectionID := testCollectionID
+
+	suite.ErrorIs(subject.RestoreFile(suite.defaultContext, suite.path), store.ErrFileNotInTrash)
+
+	suite.removeUploadedFile(subject, repo, files.StoredRegisteredMetaData{Path: suite.path})
+	suite.NoError(repo.InsertMetadata(suite.defaultContext, files.StoredRegisteredMetaData{Path: suite.path, State: store.StateCreated}))
+	suite.ErrorIs(subject.RestoreFile(suite.defaultContext, suite.path), store.ErrDuplicateFile)
+
+	suite.removeUploadedFile(subject, repo, files.StoredRegisteredMetaData{Path: "in-collection.csv", CollectionID: &collectionID})
+	suite.NoError(repo.InsertCollection(suite.defaultContext, files.StoredCollection{ID: collectionID, State: store.StatePublished}))
+	suite.ErrorIs(subject.RestoreFile(suite.defaultContext, "in-collection.csv"), store.ErrCollectionAlreadyPublished)
+
+	_, err := subject.GetTrashedFile(suite.defaultContext, "in-collection.csv")
+	suite.NoError(err, "a file that cannot be restored stays in the trash")
+}
+
+func (suite *StoreSuite) TestPurgeTrash() {
+	subject, repo, s3Client := suite.trashStore()
+	now := suite.defaultClock.GetCurrentTime()
+	expired, later := now.Add(-time.Minute), now.Add(time.Hour)
+	suite.NoError(repo.InsertTrashedFile(suite.defaultContext, files.TrashedFile{ID: "1", Path: "expired.csv", PurgeAfter: expired, Metadata: files.StoredRegisteredMetaData{Path: "expired.csv"}}))
+	suite.NoError(repo.InsertTrashedFile(suite.defaultContext, files.TrashedFile{ID: "2", Path: "later.csv", PurgeAfter: later}))
+	suite.NoError(repo.InsertTrashedFile(suite.defaultContext, files.TrashedFile{ID: "3", Path: "registered-again.csv", PurgeAfter: expired}))
+	suite.NoError(repo.InsertMetadata(suite.defaultContext, files.StoredRegisteredMetaData{Path: "registered-again.csv", State: store.StateUploaded}))
+	suite.NoError(repo.InsertTrashedFile(suite.defaultContext, files.TrashedFile{ID: "4", Path: "removed-twice.csv", PurgeAfter: expired, DeletedAt: now.Add(-2 * time.Hour)}))
+	suite.NoError(repo.InsertTrashedFile(suite.defaultContext, files.TrashedFile{ID: "5", Path: "removed-twice.csv", PurgeAfter: later, DeletedAt: now.Add(-time.Hour)}))
+
+	suite.NoError(subject.PurgeTrash(suite.defaultContext))
+
+	suite.Len(s3Client.DeleteCalls(), 1, "only objects that no other file uses are deleted")
+	suite.Equal("expired.csv", s3Client.DeleteCalls()[0].Key)
+
+	for _, path := range []string{"expired.csv", "registered-again.csv"} {
+		_, err := subject.GetTrashedFile(suite.defaultContext, path)
+		suite.ErrorIs(err, store.ErrFileNotInTrash)
+	}
+	trashed, err := subject.GetTrashedFile(suite.defaultContext, "removed-twice.csv")
+	suite.NoError(err)
+	suite.Equal("5", trashed.ID)
+	_, err = subject.GetTrashedFile(suite.defaultContext, "later.csv")
+	suite.NoError(err)
+
+	fileEvents, err := repo.FindFileEvents(suite.defaultContext, store.FileEventFilter{}, false, 0, 10)
+	suite.NoError(err)
+	suite.Len(fileEvents, 3)
+	suite.Equal(files.ActionDelete, fileEvents[0].Action)
+	suite.Equal("expired.csv", fileEvents[0].Resource)
+	suite.Equal(store.TrashPurgeIdentity, fileEvents[0].RequestedBy.ID)
+}
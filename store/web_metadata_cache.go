@@ -0,0 +1,120 @@
+package store
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/ONSdigital/dp-files-api/files"
+)
+
+// WebMetadataCache keeps the metadata of the files served in web mode, and which of their collections and bundles are
+// published, so that most requests are answered without reading the database. Only what makes a file visible is kept:
+// files that cannot be served, and collections and bundles that are not published, are looked up every time so that
+// they are served as soon as they are published. Entries are kept for at most ttl, and are forgotten sooner when
+// Invalidate is told that their file has been published, moved, withdrawn or otherwise changed. A lookup that was
+// reading the database when something was invalidated does not keep what it read, as it may be from before the change.
+type WebMetadataCache struct {
+	store       *Store
+	files       *lruCache[string, files.StoredRegisteredMetaData]
+	collections *lruCache[string, struct{}]
+	bundles     *lruCache[string, struct{}]
+	hits        atomic.Int64
+	misses      atomic.Int64
+}
+
+// WebMetadataCacheStats describes the use of a WebMetadataCache since it was created
+type WebMetadataCacheStats struct {
+	Files       int
+	Collections int
+	Bundles     int
+	Hits        int64
+	Misses      int64
+}
+
+// HitRatio is the share of the file lookups that were answered by the cache
+func (s WebMetadataCacheStats) HitRatio() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// NewWebMetadataCache creates a cache in front of the web metadata of the store, holding up to size files, and as many
+// collections and bundles, for ttl each
+func NewWebMetadataCache(store *Store, size int, ttl time.Duration) *WebMetadataCache {
+	return &WebMetadataCache{
+		store:       store,
+		files:       newLRUCache[string, files.StoredRegisteredMetaData](size, ttl, store.clock),
+		collections: newLRUCache[string, struct{}](size, ttl, store.clock),
+		bundles:     newLRUCache[string, struct{}](size, ttl, store.clock),
+	}
+}
+
+// GetFileMetadataWeb returns the metadata of a file as Store.GetFileMetadataWeb does, from the cache when it can
+func (c *WebMetadataCache) GetFileMetadataWeb(ctx context.Context, path string) (files.StoredRegisteredMetaData, error) {
+	if metadata, ok := c.files.get(path); ok {
+		c.hits.Add(1)
+		return metadata, nil
+	}
+	c.misses.Add(1)
+
+	// the generation is read before the database, so that a file invalidated while it is read is not cached as it was
+	generation := c.files.currentGeneration()
+	metadata, err := c.store.getFileMetadataWeb(ctx, path, c.isCollectionPublished, c.isBundlePublished)
+	if err != nil {
+		return metadata, err
+	}
+
+	c.files.addIfGeneration(path, metadata, generation)
+	return metadata, nil
+}
+
+// Invalidate forgets the metadata of a changed file, along with whether its collection and bundle are published.
+// The collection and bundle may be empty when they are not known.
+func (c *WebMetadataCache) Invalidate(path, collectionID, bundleID string) {
+	c.files.remove(path)
+	if collectionID != "" {
+		c.collections.remove(collectionID)
+	}
+	if bundleID != "" {
+		c.bundles.remove(bundleID)
+	}
+}
+
+// Stats gives the number of entries held and how many file lookups the cache has answered
+func (c *WebMetadataCache) Stats() WebMetadataCacheStats {
+	return WebMetadataCacheStats{
+		Files:       c.files.len(),
+		Collections: c.collections.len(),
+		Bundles:     c.bundles.len(),
+		Hits:        c.hits.Load(),
+		Misses:      c.misses.Load(),
+	}
+}
+
+func (c *WebMetadataCache) isCollectionPublished(ctx context.Context, id string) (bool, error) {
+	if _, ok := c.collections.get(id); ok {
+		return true, nil
+	}
+
+	generation := c.collections.currentGeneration()
+	published, err := c.store.isCollectionPublishedWeb(ctx, id)
+	if published {
+		c.collections.addIfGeneration(id, struct{}{}, generation)
+	}
+	return published, err
+}
+
+func (c *WebMetadataCache) isBundlePublished(ctx context.Context, id string) (bool, error) {
+	if _, ok := c.bundles.get(id); ok {
+		return true, nil
+	}
+
+	generation := c.bundles.currentGeneration()
+	published, err := c.store.isBundlePublishedWeb(ctx, id)
+	if published {
+		c.bundles.addIfGeneration(id, struct{}{}, generation)
+	}
+	return published, err
+}
@@ -0,0 +1,169 @@
+package store_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/ONSdigital/dp-files-api/config"
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/dp-files-api/store"
+)
+
+// advancingClock is a clock whose time is moved on by the test
+type advancingClock struct {
+	now time.Time
+}
+
+func (c *advancingClock) GetCurrentTime() time.Time {
+	return c.now
+}
+
+func (suite *StoreSuite) webMetadataCache(size int) (*store.WebMetadataCache, *store.MemoryRepository, *advancingClock) {
+	repo := store.NewMemoryRepository()
+	clk := &advancingClock{now: suite.defaultClock.GetCurrentTime()}
+	cfg, _ := config.Get()
+	return store.NewWebMetadataCache(store.NewStore(repo, clk, nil, cfg), size, time.Minute), repo, clk
+}
+
+func (suite *StoreSuite) setFileState(repo *store.MemoryRepository, path, state string) {
+	suite.Require().NoError(repo.UpdateMetadata(suite.defaultContext, path, store.Update{Set: []store.Field{{Key: "state", Value: state}}}))
+}
+
+func (suite *StoreSuite) TestWebMetadataCacheServesRepeatedLookups() {
+	subject, repo, _ := suite.webMetadataCache(10)
+	suite.NoError(repo.InsertMetadata(suite.defaultContext, files.StoredRegisteredMetaData{Path: suite.path, State: store.StatePublished}))
+
+	first, err := subject.GetFileMetadataWeb(suite.defaultContext, suite.path)
+	suite.NoError(err)
+	suite.setFileState(repo, suite.path, store.StateMoved)
+	second, err := subject.GetFileMetadataWeb(suite.defaultContext, suite.path)
+
+	suite.NoError(err)
+	suite.Equal(first, second)
+	suite.Equal(store.StatePublished, second.State)
+	suite.Equal(store.WebMetadataCacheStats{Files: 1, Hits: 1, Misses: 1}, subject.Stats())
+	suite.Equal(0.5, subject.Stats().HitRatio())
+}
+
+func (suite *StoreSuite) TestWebMetadataCacheInvalidate() {
+	subject, repo, _ := suite.webMetadataCache(10)
+	suite.NoError(repo.InsertMetadata(suite.defaultContext, files.StoredRegisteredMetaData{Path: suite.path, State: store.StatePublished}))
+	_, err := subject.GetFileMetadataWeb(suite.defaultContext, suite.path)
+	suite.NoError(err)
+
+	suite.setFileState(repo, suite.path, store.StateMoved)
+	subject.Invalidate(suite.path, "", "")
+	metadata, err := subject.GetFileMetadataWeb(suite.defaultContext, suite.path)
+
+	suite.NoError(err)
+	suite.Equal(store.StateMoved, metadata.State)
+	suite.Equal(int64(2), subject.Stats().Misses)
+}
+
+// invalidatingRepository runs invalidate while a file's metadata is being read, as a change to it would be when it
+// happens between the read and the cache being filled
+type invalidatingRepository struct {
+	*store.MemoryRepository
+	invalidate func()
+}
+
+func (r *invalidatingRepository) GetMetadata(ctx context.Context, path string) (files.StoredRegisteredMetaData, error) {
+	metadata, err := r.MemoryRepository.GetMetadata(ctx, path)
+	if r.invalidate != nil {
+		r.invalidate()
+		r.invalidate = nil
+	}
+	return metadata, err
+}
+
+func (suite *StoreSuite) TestWebMetadataCacheDoesNotKeepLookupRacingInvalidate() {
+	repo := &invalidatingRepository{MemoryRepository: store.NewMemoryRepository()}
+	cfg, _ := config.Get()
+	subject := store.NewWebMetadataCache(store.NewStore(repo, suite.defaultClock, nil, cfg), 10, time.Minute)
+	suite.NoError(repo.InsertMetadata(suite.defaultContext, files.StoredRegisteredMetaData{Path: suite.path, State: store.StatePublished}))
+	repo.invalidate = func() {
+		suite.setFileState(repo.MemoryRepository, suite.path, store.StateMoved)
+		subject.Invalidate(suite.path, "", "")
+	}
+
+	stale, err := subject.GetFileMetadataWeb(suite.defaultContext, suite.path)
+	suite.NoError(err)
+	suite.Equal(store.StatePublished, stale.State)
+	metadata, err := subject.GetFileMetadataWeb(suite.defaultContext, suite.path)
+
+	suite.NoError(err)
+	suite.Equal(store.StateMoved, metadata.State, "what was read before the invalidation is not cached")
+	suite.Equal(store.WebMetadataCacheStats{Files: 1, Misses: 2}, subject.Stats())
+}
+
+func (suite *StoreSuite) TestWebMetadataCacheEntriesExpire() {
+	subject, repo, clk := suite.webMetadataCache(10)
+	suite.NoError(repo.InsertMetadata(suite.defaultContext, files.StoredRegisteredMetaData{Path: suite.path, State: store.StatePublished}))
+	_, err := subject.GetFileMetadataWeb(suite.defaultContext, suite.path)
+	suite.NoError(err)
+
+	suite.setFileState(repo, suite.path, store.StateMoved)
+	clk.now = clk.now.Add(time.Minute)
+	metadata, err := subject.GetFileMetadataWeb(suite.defaultContext, suite.path)
+
+	suite.NoError(err)
+	suite.Equal(store.StateMoved, metadata.State)
+}
+
+func (suite *StoreSuite) TestWebMetadataCacheEvictsLeastRecentlyUsed() {
+	subject, repo, _ := suite.webMetadataCache(2)
+	for _, path := range []string{"a.csv", "b.csv", "c.csv"} {
+		suite.NoError(repo.InsertMetadata(suite.defaultContext, files.StoredRegisteredMetaData{Path: path, State: store.StateMoved}))
+	}
+
+	for _, path := range []string{"a.csv", "b.csv", "a.csv", "c.csv", "a.csv", "b.csv"} {
+		_, err := subject.GetFileMetadataWeb(suite.defaultContext, path)
+		suite.NoError(err)
+	}
+
+	stats := subject.Stats()
+	suite.Equal(2, stats.Files)
+	suite.Equal(int64(2), stats.Hits, "a.csv is found twice, b.csv having been evicted by c.csv")
+	suite.Equal(int64(4), stats.Misses)
+}
+
+func (suite *StoreSuite) TestWebMetadataCacheDoesNotKeepFilesThatCannotBeServed() {
+	subject, repo, _ := suite.webMetadataCache(10)
+	collectionID := suite.defaultCollectionID
+	suite.NoError(repo.InsertMetadata(suite.defaultContext, files.StoredRegisteredMetaData{Path: suite.path, State: store.StateUploaded, CollectionID: &collectionID}))
+	suite.NoError(repo.InsertMetadata(suite.defaultContext, files.StoredRegisteredMetaData{Path: "withdrawn.csv", State: store.StateWithdrawn}))
+	suite.NoError(repo.UpsertCollection(suite.defaultContext, collectionID, []store.Field{{Key: "state", Value: store.StateCreated}}))
+
+	_, err := subject.GetFileMetadataWeb(suite.defaultContext, suite.path)
+	suite.ErrorIs(err, store.ErrFileNotRegistered)
+	_, err = subject.GetFileMetadataWeb(suite.defaultContext, "withdrawn.csv")
+	suite.ErrorIs(err, store.ErrFileWithdrawn)
+
+	suite.NoError(repo.UpsertCollection(suite.defaultContext, collectionID, []store.Field{{Key: "state", Value: store.StatePublished}}))
+	metadata, err := subject.GetFileMetadataWeb(suite.defaultContext, suite.path)
+
+	suite.NoError(err)
+	suite.Equal(suite.path, metadata.Path)
+	suite.Equal(store.WebMetadataCacheStats{Files: 1, Collections: 1, Misses: 3}, subject.Stats())
+}
+
+func (suite *StoreSuite) TestWebMetadataCacheKeepsPublishedBundles() {
+	subject, repo, _ := suite.webMetadataCache(10)
+	bundleID := suite.defaultBundleID
+	suite.NoError(repo.InsertBundle(suite.defaultContext, files.StoredBundle{ID: bundleID, State: store.StatePublished}))
+	for _, path := range []string{"a.csv", "b.csv"} {
+		suite.NoError(repo.InsertMetadata(suite.defaultContext, files.StoredRegisteredMetaData{Path: path, State: store.StateUploaded, BundleID: &bundleID}))
+	}
+
+	_, err := subject.GetFileMetadataWeb(suite.defaultContext, "a.csv")
+	suite.NoError(err)
+	suite.NoError(repo.UpsertBundle(suite.defaultContext, bundleID, []store.Field{{Key: "state", Value: store.StateWithdrawn}}))
+	_, err = subject.GetFileMetadataWeb(suite.defaultContext, "b.csv")
+	suite.NoError(err, "the bundle is still known to be published")
+
+	subject.Invalidate("b.csv", "", bundleID)
+	_, err = subject.GetFileMetadataWeb(suite.defaultContext, "b.csv")
+	suite.ErrorIs(err, store.ErrFileNotRegistered)
+	suite.Equal(1, subject.Stats().Files)
+	suite.Equal(0, subject.Stats().Bundles)
+}
@@ -0,0 +1,236 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ONSdigital/dp-files-api/files"
+	dprequest "github.com/ONSdigital/dp-net/v3/request"
+	"github.com/ONSdigital/log.go/v2/log"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// WithdrawFile withdraws a published or moved file, recording why. A withdrawn file is no longer served in web mode.
+func (store *Store) WithdrawFile(ctx context.Context, path, reason string) error {
+	logdata := log.Data{"path": path}
+
+	if reason == "" {
+		return ErrWithdrawalReasonRequired
+	}
+
+	m, err := store.GetFileMetadata(ctx, path)
+	if err != nil {
+		if errors.Is(err, ErrFileNotRegistered) {
+			log.Error(ctx, "withdraw file: attempted to operate on unregistered file", err, logdata)
+			return ErrFileNotRegistered
+		}
+		log.Error(ctx, "withdraw file: failed finding file metadata", err, logdata)
+		return err
+	}
+	logdata["state"] = m.State
+
+	switch m.State {
+	case StatePublished, StateMoved:
+	case StateWithdrawn:
+		log.Error(ctx, "withdraw file: file already withdrawn", ErrFileWithdrawn, logdata)
+		return ErrFileWithdrawn
+	default:
+		log.Error(ctx, "withdraw file: file is not published", ErrFileNotPublished, logdata)
+		return ErrFileNotPublished
+	}
+
+	return store.withdrawFile(ctx, m, reason)
+}
+
+// WithdrawCollection withdraws a published collection, and then every file in it with a withdrawal job run in the
+// background
+func (store *Store) WithdrawCollection(ctx context.Context, collectionID, reason string) error {
+	logdata := log.Data{"collection_id": collectionID}
+
+	if reason == "" {
+		return ErrWithdrawalReasonRequired
+	}
+
+	state, err := store.collectionState(ctx, collectionID)
+	if err != nil {
+		log.Error(ctx, "withdraw collection: collection published check error", err, logdata)
+		return err
+	}
+	if state != StatePublished && state != StateWithdrawn {
+		log.Error(ctx, "withdraw collection: collection is not published", ErrCollectionNotPublished, logdata)
+		return ErrCollectionNotPublished
+	}
+
+	return store.startWithdrawal(ctx, &files.PublishJob{CollectionID: &collectionID}, state, reason, ErrCollectionWithdrawn, func() error {
+		return store.repo.UpsertCollection(ctx, collectionID, store.withdrawalFields(reason))
+	})
+}
+
+// WithdrawBundle withdraws a published bundle, and then every file in it with a withdrawal job run in the background
+func (store *Store) WithdrawBundle(ctx context.Context, bundleID, reason string) error {
+	logdata := log.Data{"bundle_id": bundleID}
+
+	if reason == "" {
+		return ErrWithdrawalReasonRequired
+	}
+
+	state, err := store.bundleState(ctx, bundleID)
+	if err != nil {
+		log.Error(ctx, "withdraw bundle: bundle published check error", err, logdata)
+		return err
+	}
+	if state != StatePublished && state != StateWithdrawn {
+		log.Error(ctx, "withdraw bundle: bundle is not published", ErrBundleNotPublished, logdata)
+		return ErrBundleNotPublished
+	}
+
+	return store.startWithdrawal(ctx, &files.PublishJob{BundleID: &bundleID}, state, reason, ErrBundleWithdrawn, func() error {
+		return store.repo.UpsertBundle(ctx, bundleID, store.withdrawalFields(reason))
+	})
+}
+
+// startWithdrawal records a job withdrawing every file in the collection or bundle of job, marks the collection or
+// bundle withdrawn and runs the job in the background. Like a publish job, the job is recorded before the state change
+// so that a withdrawn collection or bundle always has a job to resume. One already withdrawn is only withdrawn again
+// when its last withdrawal job failed, to withdraw the files that job could not; otherwise it is errWithdrawn.
+func (store *Store) startWithdrawal(ctx context.Context, job *files.PublishJob, state, reason string, errWithdrawn error, markWithdrawn func() error) error {
+	if state == StateWithdrawn {
+		failed, err := store.isLastWithdrawalFailed(ctx, job)
+		if err != nil {
+			return err
+		}
+		if !failed {
+			log.Error(ctx, "withdrawal already made", errWithdrawn, publishJobLogData(job))
+			return errWithdrawn
+		}
+	}
+
+	job.Action = PublishJobActionWithdraw
+	job.Reason = reason
+	job.Actor = dprequest.Caller(ctx)
+	if _, err := store.createPublishJob(ctx, job); err != nil {
+		return err
+	}
+
+	if state == StatePublished {
+		if err := markWithdrawn(); err != nil {
+			log.Error(ctx, "failed to change to withdrawn state", err, publishJobLogData(job))
+			store.deletePublishJob(ctx, job)
+			return err
+		}
+	}
+
+	newCtx := dprequest.WithRequestId(context.Background(), dprequest.GetRequestId(ctx))
+	go store.RunPublishJob(newCtx, job)
+
+	log.Info(ctx, "withdrawal started", publishJobLogData(job))
+	return nil
+}
+
+// isLastWithdrawalFailed reports whether the most recent job for the collection or bundle of job is a withdrawal that
+// failed
+func (store *Store) isLastWithdrawalFailed(ctx context.Context, job *files.PublishJob) (bool, error) {
+	var collectionID, bundleID string
+	if job.BundleID != nil {
+		bundleID = *job.BundleID
+	} else {
+		collectionID = *job.CollectionID
+	}
+
+	latest, err := store.getLatestPublishJob(ctx, collectionID, bundleID)
+	if errors.Is(err, ErrPublishJobNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return latest.Action == PublishJobActionWithdraw && latest.State == PublishJobStateFailed, nil
+}
+
+// withdrawPublishedFile withdraws a file of a withdrawn collection or bundle. Files that have not been moved are still
+// stored as uploaded, but were published with their collection or bundle. Files already withdrawn are skipped, so a
+// withdrawal job that is resumed or run again carries on from where it stopped.
+func (store *Store) withdrawPublishedFile(ctx context.Context, m files.StoredRegisteredMetaData, reason string) error {
+	if m.State == StateWithdrawn {
+		return nil
+	}
+	if m.State != StateMoved {
+		m.State = StatePublished
+	}
+	return store.withdrawFile(ctx, m, reason)
+}
+
+// withdrawFile moves a file to the withdrawn state. The withdrawn and lifecycle messages are written to the outbox as
+// pending before the change, then released once it has been made or withdrawn again if it fails.
+func (store *Store) withdrawFile(ctx context.Context, m files.StoredRegisteredMetaData, reason string) error {
+	logdata := log.Data{"path": m.Path, "state": m.State}
+	now := store.clock.GetCurrentTime()
+
+	if err := checkIfMatch(ctx, m); err != nil {
+		return err
+	}
+
+	withdrawn := store.fileWithdrawnMessage(ctx, &m, reason, now)
+	if err := store.repo.InsertOutboxMessage(ctx, withdrawn); err != nil {
+		log.Error(ctx, "failed to write message to outbox", err, log.Data{"path": m.Path, "topic": withdrawn.Topic})
+		return err
+	}
+	outboxIDs := []string{withdrawn.ID}
+
+	lifecycleID, err := store.enqueueFileLifecycle(ctx, m.Path, files.LifecycleStateChanged, m.State, StateWithdrawn)
+	if err != nil {
+		store.withdrawOutboxMessages(ctx, outboxIDs)
+		return err
+	}
+	outboxIDs = append(outboxIDs, lifecycleID)
+
+	if err = store.updateMetadata(ctx, m, Update{Set: store.withdrawalFields(reason)}); err != nil {
+		store.withdrawOutboxMessages(ctx, outboxIDs)
+		log.Error(ctx, "error while withdrawing file", err, logdata)
+		return err
+	}
+	store.releaseOutboxMessages(ctx, outboxIDs)
+	store.recordFileChange(ctx, m, files.LifecycleStateChanged, m.State, StateWithdrawn)
+
+	log.Info(ctx, "file withdrawn", logdata)
+	return nil
+}
+
+// withdrawalFields are the fields set on a withdrawn file, collection or bundle
+func (store *Store) withdrawalFields(reason string) []Field {
+	now := store.clock.GetCurrentTime()
+	return []Field{
+		{Key: fieldState, Value: StateWithdrawn},
+		{Key: fieldLastModified, Value: now},
+		{Key: fieldWithdrawnAt, Value: now},
+		{Key: fieldWithdrawalReason, Value: reason},
+	}
+}
+
+func (store *Store) fileWithdrawnMessage(ctx context.Context, m *files.StoredRegisteredMetaData, reason string, now time.Time) files.OutboxMessage {
+	id := primitive.NewObjectID().Hex()
+
+	event := &files.FileWithdrawn{
+		EventID:     id,
+		Path:        m.Path,
+		Reason:      reason,
+		Actor:       dprequest.Caller(ctx),
+		WithdrawnAt: now.UnixMilli(),
+	}
+	if m.CollectionID != nil {
+		event.CollectionID = *m.CollectionID
+	}
+	if m.BundleID != nil {
+		event.BundleID = *m.BundleID
+	}
+
+	return files.OutboxMessage{
+		ID:            id,
+		Topic:         store.cfg.FileWithdrawnTopic,
+		FileWithdrawn: event,
+		Pending:       true,
+		CreatedAt:     now,
+		NextAttemptAt: now,
+	}
+}
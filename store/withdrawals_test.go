@@ -0,0 +1,255 @@
+package store_test
+
+import (
+	"time"
+
+	"github.com/ONSdigital/dp-files-api/files"
+	"github.com/ONSdigital/dp-files-api/store"
+	dprequest "github.com/ONSdigital/dp-net/v3/request"
+)
+
+const testWithdrawalReason = "figures were released in error"
+
+func (suite *StoreSuite) withdrawnEvents(repo *store.MemoryRepository) []*files.FileWithdrawn {
+	msgs, err := repo.FindDueOutboxMessages(suite.defaultContext, suite.defaultClock.GetCurrentTime(), 10)
+	suite.Require().NoError(err)
+
+	var events []*files.FileWithdrawn
+	for _, msg := range msgs {
+		if msg.FileWithdrawn != nil {
+			suite.Equal("file-withdrawn", msg.Topic)
+			events = append(events, msg.FileWithdrawn)
+		}
+	}
+	return events
+}
+
+func (suite *StoreSuite) TestWithdrawFile() {
+	subject, repo := suite.lifecycleStore()
+	ctx := dprequest.SetCaller(suite.defaultContext, "publisher@ons.gov.uk")
+	collectionID := testCollectionID
+	suite.NoError(repo.InsertMetadata(ctx, files.StoredRegisteredMetaData{Path: suite.path, State: store.StateMoved, CollectionID: &collectionID}))
+
+	suite.NoError(subject.WithdrawFile(ctx, suite.path, testWithdrawalReason))
+
+	m, err := repo.GetMetadata(ctx, suite.path)
+	suite.NoError(err)
+	suite.Equal(store.StateWithdrawn, m.State)
+	suite.Equal(testWithdrawalReason, m.WithdrawalReason)
+	suite.Equal(suite.defaultClock.GetCurrentTime(), *m.WithdrawnAt)
+
+	withdrawn := suite.withdrawnEvents(repo)
+	suite.Require().Len(withdrawn, 1)
+	suite.Equal(suite.path, withdrawn[0].Path)
+	suite.Equal(testCollectionID, withdrawn[0].CollectionID)
+	suite.Equal(testWithdrawalReason, withdrawn[0].Reason)
+	suite.Equal("publisher@ons.gov.uk", withdrawn[0].Actor)
+	suite.Equal(suite.defaultClock.GetCurrentTime().UnixMilli(), withdrawn[0].WithdrawnAt)
+
+	lifecycle := suite.lifecycleEvents(repo)
+	suite.Require().Len(lifecycle, 1)
+	suite.Equal(store.StateMoved, lifecycle[0].FromState)
+	suite.Equal(store.StateWithdrawn, lifecycle[0].ToState)
+}
+
+func (suite *StoreSuite) TestWithdrawFileRejections() {
+	subject, repo := suite.lifecycleStore()
+	suite.NoError(repo.InsertMetadata(suite.defaultContext, files.StoredRegisteredMetaData{Path: "created.csv", State: store.StateCreated}))
+	suite.NoError(repo.InsertMetadata(suite.defaultContext, files.StoredRegisteredMetaData{Path: "withdrawn.csv", State: store.StateWithdrawn}))
+
+	suite.ErrorIs(subject.WithdrawFile(suite.defaultContext, "created.csv", ""), store.ErrWithdrawalReasonRequired)
+	suite.ErrorIs(subject.WithdrawFile(suite.defaultContext, "missing.csv", testWithdrawalReason), store.ErrFileNotRegistered)
+	suite.ErrorIs(subject.WithdrawFile(suite.defaultContext, "created.csv", testWithdrawalReason), store.ErrFileNotPublished)
+	suite.ErrorIs(subject.WithdrawFile(suite.defaultContext, "withdrawn.csv", testWithdrawalReason), store.ErrFileWithdrawn)
+	suite.Empty(suite.withdrawnEvents(repo))
+}
+
+// finishedPublishJob waits for the latest job of the collection or bundle to finish running in the background
+func (suite *StoreSuite) finishedPublishJob(subject *store.Store, collectionID, bundleID string) files.PublishJob {
+	var job files.PublishJob
+	suite.Eventually(func() bool {
+		var err error
+		if bundleID != "" {
+			job, err = subject.GetBundlePublishJob(suite.defaultContext, bundleID)
+		} else {
+			job, err = subject.GetCollectionPublishJob(suite.defaultContext, collectionID)
+		}
+		return err == nil && job.State != store.PublishJobStateInProgress
+	}, time.Second, 10*time.Millisecond)
+	return job
+}
+
+func (suite *StoreSuite) TestWithdrawCollection() {
+	subject, repo := suite.lifecycleStore()
+	ctx := dprequest.SetCaller(suite.defaultContext, "publisher@ons.gov.uk")
+	collectionID := testCollectionID
+	suite.NoError(repo.InsertCollection(ctx, files.StoredCollection{ID: collectionID, State: store.StatePublished}))
+	suite.NoError(repo.InsertMetadata(ctx, files.StoredRegisteredMetaData{Path: "uploaded.csv", State: store.StateUploaded, CollectionID: &collectionID}))
+	suite.NoError(repo.InsertMetadata(ctx, files.StoredRegisteredMetaData{Path: "moved.csv", State: store.StateMoved, CollectionID: &collectionID}))
+	suite.NoError(repo.InsertMetadata(ctx, files.StoredRegisteredMetaData{Path: "withdrawn.csv", State: store.StateWithdrawn, CollectionID: &collectionID}))
+
+	suite.NoError(subject.WithdrawCollection(ctx, collectionID, testWithdrawalReason))
+
+	collection, err := repo.GetCollection(ctx, collectionID)
+	suite.NoError(err)
+	suite.Equal(store.StateWithdrawn, collection.State)
+	suite.Equal(testWithdrawalReason, collection.WithdrawalReason)
+
+	// the files are withdrawn by a job run in the background
+	job := suite.finishedPublishJob(subject, collectionID, "")
+	suite.Equal(store.PublishJobActionWithdraw, job.Action)
+	suite.Equal(store.PublishJobStateCompleted, job.State)
+	suite.Equal(testWithdrawalReason, job.Reason)
+	suite.Equal(3, job.TotalFiles)
+
+	for _, path := range []string{"uploaded.csv", "moved.csv"} {
+		m, err := repo.GetMetadata(ctx, path)
+		suite.NoError(err)
+		suite.Equal(store.StateWithdrawn, m.State, path)
+	}
+
+	withdrawn := suite.withdrawnEvents(repo)
+	suite.Require().Len(withdrawn, 2)
+	suite.Equal("publisher@ons.gov.uk", withdrawn[0].Actor)
+	lifecycle := suite.lifecycleEvents(repo)
+	suite.Require().Len(lifecycle, 2)
+	// files are withdrawn in path order
+	suite.Equal(store.StateMoved, lifecycle[0].FromState)
+	suite.Equal(store.StatePublished, lifecycle[1].FromState)
+
+	suite.ErrorIs(subject.WithdrawCollection(ctx, collectionID, testWithdrawalReason), store.ErrCollectionWithdrawn)
+}
+
+func (suite *StoreSuite) TestWithdrawCollectionAgainAfterWithdrawalJobFailed() {
+	subject, repo := suite.lifecycleStore()
+	collectionID := testCollectionID
+	suite.NoError(repo.InsertCollection(suite.defaultContext, files.StoredCollection{ID: collectionID, State: store.StateWithdrawn}))
+	suite.NoError(repo.InsertMetadata(suite.defaultContext, files.StoredRegisteredMetaData{Path: "withdrawn.csv", State: store.StateWithdrawn, CollectionID: &collectionID}))
+	suite.NoError(repo.InsertMetadata(suite.defaultContext, files.StoredRegisteredMetaData{Path: "uploaded.csv", State: store.StateUploaded, CollectionID: &collectionID}))
+	suite.NoError(repo.InsertPublishJob(suite.defaultContext, &files.PublishJob{
+		ID:           "failed-withdrawal",
+		CollectionID: &collectionID,
+		Action:       store.PublishJobActionWithdraw,
+		State:        store.PublishJobStateFailed,
+		CreatedAt:    suite.defaultClock.GetCurrentTime().Add(-time.Hour),
+	}))
+
+	suite.NoError(subject.WithdrawCollection(suite.defaultContext, collectionID, testWithdrawalReason))
+
+	job := suite.finishedPublishJob(subject, collectionID, "")
+	suite.NotEqual("failed-withdrawal", job.ID)
+	suite.Equal(store.PublishJobStateCompleted, job.State)
+	m, err := repo.GetMetadata(suite.defaultContext, "uploaded.csv")
+	suite.NoError(err)
+	suite.Equal(store.StateWithdrawn, m.State)
+	suite.Len(suite.withdrawnEvents(repo), 1)
+}
+
+func (suite *StoreSuite) TestWithdrawCollectionNotPublished() {
+	subject, repo := suite.lifecycleStore()
+	collectionID := testCollectionID
+	suite.NoError(repo.InsertMetadata(suite.defaultContext, files.StoredRegisteredMetaData{Path: "uploaded.csv", State: store.StateUploaded, CollectionID: &collectionID}))
+
+	err := subject.WithdrawCollection(suite.defaultContext, collectionID, testWithdrawalReason)
+
+	suite.ErrorIs(err, store.ErrCollectionNotPublished)
+	_, err = subject.GetCollectionPublishJob(suite.defaultContext, collectionID)
+	suite.ErrorIs(err, store.ErrPublishJobNotFound)
+	suite.Empty(suite.withdrawnEvents(repo))
+}
+
+func (suite *StoreSuite) TestWithdrawBundle() {
+	subject, repo := suite.lifecycleStore()
+	bundleID := testBundleID
+	suite.NoError(repo.InsertBundle(suite.defaultContext, files.StoredBundle{ID: bundleID, State: store.StatePublished}))
+	suite.NoError(repo.InsertMetadata(suite.defaultContext, files.StoredRegisteredMetaData{Path: "uploaded.csv", State: store.StateUploaded, BundleID: &bundleID}))
+
+	suite.NoError(subject.WithdrawBundle(suite.defaultContext, bundleID, testWithdrawalReason))
+
+	bundle, err := repo.GetBundle(suite.defaultContext, bundleID)
+	suite.NoError(err)
+	suite.Equal(store.StateWithdrawn, bundle.State)
+
+	job := suite.finishedPublishJob(subject, "", bundleID)
+	suite.Equal(store.PublishJobActionWithdraw, job.Action)
+	suite.Equal(store.PublishJobStateCompleted, job.State)
+
+	m, err := repo.GetMetadata(suite.defaultContext, "uploaded.csv")
+	suite.NoError(err)
+	suite.Equal(store.StateWithdrawn, m.State)
+
+	withdrawn := suite.withdrawnEvents(repo)
+	suite.Require().Len(withdrawn, 1)
+	suite.Equal(testBundleID, withdrawn[0].BundleID)
+
+	suite.ErrorIs(subject.WithdrawBundle(suite.defaultContext, bundleID, testWithdrawalReason), store.ErrBundleWithdrawn)
+	suite.ErrorIs(subject.WithdrawBundle(suite.defaultContext, "other-bundle", testWithdrawalReason), store.ErrBundleNotPublished)
+}
+
+func (suite *StoreSuite) TestResumePublishJobsFinishesWithdrawal() {
+	subject, repo := suite.lifecycleStore()
+	collectionID := testCollectionID
+	expired := suite.defaultClock.GetCurrentTime().Add(-time.Minute)
+	suite.NoError(repo.InsertCollection(suite.defaultContext, files.StoredCollection{ID: collectionID, State: store.StateWithdrawn}))
+	suite.NoError(repo.InsertMetadata(suite.defaultContext, files.StoredRegisteredMetaData{Path: "moved.csv", State: store.StateMoved, CollectionID: &collectionID}))
+	suite.NoError(repo.InsertPublishJob(suite.defaultContext, &files.PublishJob{
+		ID:             "withdrawal",
+		CollectionID:   &collectionID,
+		Action:         store.PublishJobActionWithdraw,
+		Reason:         testWithdrawalReason,
+		Actor:          "publisher@ons.gov.uk",
+		State:          store.PublishJobStateInProgress,
+		Owner:          "stopped-instance",
+		LeaseExpiresAt: &expired,
+		TotalFiles:     1,
+		Batches:        []files.PublishJobBatch{{Number: 0, Size: 1}},
+	}))
+
+	suite.NoError(subject.ResumePublishJobs(suite.defaultContext))
+
+	job := suite.finishedPublishJob(subject, collectionID, "")
+	suite.Equal(store.PublishJobStateCompleted, job.State)
+	withdrawn := suite.withdrawnEvents(repo)
+	suite.Require().Len(withdrawn, 1)
+	suite.Equal("moved.csv", withdrawn[0].Path)
+	suite.Equal(testWithdrawalReason, withdrawn[0].Reason)
+	suite.Equal("publisher@ons.gov.uk", withdrawn[0].Actor)
+}
+
+func (suite *StoreSuite) TestFilesCannotBeAddedToWithdrawnCollectionOrBundle() {
+	subject, repo := suite.lifecycleStore()
+	collectionID, bundleID := testCollectionID, testBundleID
+	suite.NoError(repo.InsertCollection(suite.defaultContext, files.StoredCollection{ID: collectionID, State: store.StateWithdrawn}))
+	suite.NoError(repo.InsertBundle(suite.defaultContext, files.StoredBundle{ID: bundleID, State: store.StateWithdrawn}))
+	suite.NoError(repo.InsertMetadata(suite.defaultContext, files.StoredRegisteredMetaData{Path: "created.csv", State: store.StateCreated}))
+
+	suite.ErrorIs(subject.UpdateCollectionID(suite.defaultContext, "created.csv", collectionID), store.ErrCollectionWithdrawn)
+	suite.ErrorIs(subject.UpdateBundleID(suite.defaultContext, "created.csv", bundleID), store.ErrBundleWithdrawn)
+	suite.ErrorIs(subject.RegisterFileUpload(suite.defaultContext, files.StoredRegisteredMetaData{Path: "new.csv", CollectionID: &collectionID}), store.ErrCollectionWithdrawn)
+	suite.ErrorIs(subject.RegisterFileUpload(suite.defaultContext, files.StoredRegisteredMetaData{Path: "new.csv", BundleID: &bundleID}), store.ErrBundleWithdrawn)
+
+	results, err := subject.RegisterFileUploads(suite.defaultContext, []files.StoredRegisteredMetaData{
+		{Path: "in-collection.csv", CollectionID: &collectionID},
+		{Path: "in-bundle.csv", BundleID: &bundleID},
+	})
+	suite.NoError(err)
+	suite.Equal(files.RegistrationCollectionWithdrawn, results[0].Result)
+	suite.Equal(files.RegistrationBundleWithdrawn, results[1].Result)
+
+	m, err := repo.GetMetadata(suite.defaultContext, "created.csv")
+	suite.NoError(err)
+	suite.Nil(m.CollectionID)
+	suite.Nil(m.BundleID)
+}
+
+func (suite *StoreSuite) TestGetFileMetadataWebForWithdrawnFile() {
+	subject, repo := suite.lifecycleStore()
+	suite.NoError(repo.InsertMetadata(suite.defaultContext, files.StoredRegisteredMetaData{Path: suite.path, State: store.StateMoved}))
+	suite.NoError(subject.WithdrawFile(suite.defaultContext, suite.path, testWithdrawalReason))
+
+	m, err := subject.GetFileMetadataWeb(suite.defaultContext, suite.path)
+
+	suite.ErrorIs(err, store.ErrFileWithdrawn)
+	suite.Equal(testWithdrawalReason, m.WithdrawalReason)
+	suite.NotNil(m.WithdrawnAt)
+}